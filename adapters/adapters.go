@@ -0,0 +1,53 @@
+// Package adapters registers the mapper of every built-in chain with abstraction.RegisterMapper. Commands
+// import it for its side effect and build mappers by name with abstraction.NewMapper.
+package adapters
+
+import (
+	aptosAdapter "codec/aptos/adapter"
+	avalancheAdapter "codec/avalanche/adapter"
+	cometbftAdapter "codec/cometbft/adapter"
+	ethereumAdapter "codec/ethereum/adapter"
+	hotstuffAdapter "codec/hotstuff/adapter"
+	besuAdapter "codec/hyperledger/besu/adapter"
+	fabricAdapter "codec/hyperledger/fabric/adapter"
+	kaiaAdapter "codec/kaia/adapter"
+	"codec/message/abstraction"
+)
+
+var factories = map[abstraction.ChainType]abstraction.MapperFactory{
+	abstraction.ChainTypeCometBFT: func(chainID string) abstraction.Mapper {
+		return cometbftAdapter.NewCometBFTMapper(chainID)
+	},
+	abstraction.ChainTypeHyperledger: func(chainID string) abstraction.Mapper {
+		return besuAdapter.NewBesuMapper(chainID)
+	},
+	abstraction.ChainTypeKaia: func(chainID string) abstraction.Mapper {
+		return kaiaAdapter.NewKaiaMapper(chainID)
+	},
+	abstraction.ChainTypeFabric: func(chainID string) abstraction.Mapper {
+		return fabricAdapter.NewFabricMapper(chainID)
+	},
+	abstraction.ChainTypeFabricRaft: func(chainID string) abstraction.Mapper {
+		return fabricAdapter.NewFabricRaftMapper(chainID)
+	},
+	abstraction.ChainTypeEthereum: func(chainID string) abstraction.Mapper {
+		return ethereumAdapter.NewEthereumMapper(chainID)
+	},
+	abstraction.ChainTypeAptos: func(chainID string) abstraction.Mapper {
+		return aptosAdapter.NewAptosMapper(chainID)
+	},
+	abstraction.ChainTypeAvalanche: func(chainID string) abstraction.Mapper {
+		return avalancheAdapter.NewAvalancheMapper(chainID)
+	},
+	abstraction.ChainTypeHotStuff: func(chainID string) abstraction.Mapper {
+		return hotstuffAdapter.NewHotStuffMapper(chainID)
+	},
+}
+
+func init() {
+	for chainType, factory := range factories {
+		if err := abstraction.RegisterMapper(chainType, factory); err != nil {
+			panic(err)
+		}
+	}
+}
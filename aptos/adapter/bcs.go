@@ -0,0 +1,249 @@
+package adapter
+
+import (
+	"crypto/sha3"
+	"fmt"
+
+	"github.com/fardream/go-bcs/bcs"
+)
+
+// HashValue is a 32-byte SHA3-256 digest
+type HashValue [32]byte
+
+// Signature is a BLS12-381 signature in its serialized form
+type Signature []byte
+
+// AccountAddress identifies a validator by its 32-byte account address
+type AccountAddress [32]byte
+
+// The containers below follow the field order of the DiemBFT v4 consensus-types (the 2-chain protocol AptosBFT
+// started from), so BCS encodes them the same way. Transaction payloads are carried as opaque bytes, and BLS
+// signatures as the byte vectors their serde form uses.
+
+// BlockInfo identifies a block and the state it executed to
+type BlockInfo struct {
+	Epoch           uint64
+	Round           uint64
+	ID              HashValue
+	ExecutedStateID HashValue
+	Version         uint64
+	TimestampUsecs  uint64
+	NextEpochState  *EpochState `bcs:"optional"`
+}
+
+// EpochState is the validator set a reconfiguration block hands over to
+type EpochState struct {
+	Epoch      uint64
+	Validators []ValidatorConsensusInfo
+}
+
+// ValidatorConsensusInfo is a validator's BLS key and voting power
+type ValidatorConsensusInfo struct {
+	Address     AccountAddress
+	PublicKey   []byte
+	VotingPower uint64
+}
+
+// VoteData is the proposed block a vote certifies together with its parent
+type VoteData struct {
+	Proposed BlockInfo
+	Parent   BlockInfo
+}
+
+// LedgerInfo is the commit a vote or certificate signs
+type LedgerInfo struct {
+	CommitInfo        BlockInfo
+	ConsensusDataHash HashValue
+}
+
+// AggregateSignature is a BLS aggregate signature with the signers' bitmask
+type AggregateSignature struct {
+	ValidatorBitmask []byte
+	Sig              *Signature `bcs:"optional"`
+}
+
+// LedgerInfoWithSignatures is the versioned enum of a ledger info and its aggregate signature; V0 is the only
+// variant.
+type LedgerInfoWithSignatures struct {
+	V0 *LedgerInfoWithV0
+}
+
+// IsBcsEnum marks LedgerInfoWithSignatures as a BCS enum
+func (LedgerInfoWithSignatures) IsBcsEnum() {}
+
+// MarshalBCS encodes the V0 variant, treating an unset one as an empty ledger info so zero certificates encode.
+func (l LedgerInfoWithSignatures) MarshalBCS() ([]byte, error) {
+	v0 := l.V0
+	if v0 == nil {
+		v0 = &LedgerInfoWithV0{}
+	}
+	data, err := bcs.Marshal(v0)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{0}, data...), nil
+}
+
+// LedgerInfoWithV0 is the V0 variant of LedgerInfoWithSignatures
+type LedgerInfoWithV0 struct {
+	LedgerInfo LedgerInfo
+	Signatures AggregateSignature
+}
+
+// QuorumCert certifies VoteData with a quorum of signatures over the matching ledger info
+type QuorumCert struct {
+	VoteData         VoteData
+	SignedLedgerInfo LedgerInfoWithSignatures
+}
+
+// FailedAuthor records a round whose leader failed to propose
+type FailedAuthor struct {
+	Round  uint64
+	Author AccountAddress
+}
+
+// BlockType is the enum of proposal, nil and genesis blocks
+type BlockType struct {
+	Proposal *ProposalBlock
+	NilBlock *NilBlock
+	Genesis  *struct{}
+}
+
+// IsBcsEnum marks BlockType as a BCS enum
+func (BlockType) IsBcsEnum() {}
+
+// ProposalBlock is a block proposed by a leader
+type ProposalBlock struct {
+	Payload       []byte
+	Author        AccountAddress
+	FailedAuthors []FailedAuthor
+}
+
+// NilBlock is a block inserted for a round without a proposal
+type NilBlock struct {
+	FailedAuthors []FailedAuthor
+}
+
+// BlockData is the signed content of a block; its hash is the block id
+type BlockData struct {
+	Epoch          uint64
+	Round          uint64
+	TimestampUsecs uint64
+	QuorumCert     QuorumCert
+	BlockType      BlockType
+}
+
+// Block is a block with the author's signature. The id is not serialized; it is the hash of the block data.
+type Block struct {
+	BlockData BlockData
+	Signature *Signature `bcs:"optional"`
+}
+
+// TwoChainTimeout is a validator's timeout for a round together with its highest quorum certificate
+type TwoChainTimeout struct {
+	Epoch      uint64
+	Round      uint64
+	QuorumCert QuorumCert
+}
+
+// AggregateSignatureWithRounds is the aggregate signature of a timeout certificate with each signer's highest
+// certified round
+type AggregateSignatureWithRounds struct {
+	Sig    AggregateSignature
+	Rounds []uint64
+}
+
+// TwoChainTimeoutCertificate is a quorum of timeouts for a round
+type TwoChainTimeoutCertificate struct {
+	Timeout              TwoChainTimeout
+	SignaturesWithRounds AggregateSignatureWithRounds
+}
+
+// SyncInfo carries the certificates a node needs to catch up with the sender
+type SyncInfo struct {
+	HighestQuorumCert        QuorumCert
+	HighestCommitCert        QuorumCert
+	Highest2ChainTimeoutCert *TwoChainTimeoutCertificate `bcs:"optional"`
+}
+
+// ProposalMsg is a leader's proposal for a round
+type ProposalMsg struct {
+	Proposal Block
+	SyncInfo SyncInfo
+}
+
+// TimeoutSignature is the signed timeout a vote may carry
+type TimeoutSignature struct {
+	Timeout   TwoChainTimeout
+	Signature Signature
+}
+
+// Vote is a validator's vote for a proposed block
+type Vote struct {
+	VoteData        VoteData
+	Author          AccountAddress
+	LedgerInfo      LedgerInfo
+	Signature       Signature
+	TwoChainTimeout *TimeoutSignature `bcs:"optional"`
+}
+
+// VoteMsg is a vote with the voter's sync info
+type VoteMsg struct {
+	Vote     Vote
+	SyncInfo SyncInfo
+}
+
+// TimeoutMsg is a validator's signed timeout for a round without a vote
+type TimeoutMsg struct {
+	Timeout   TwoChainTimeout
+	Author    AccountAddress
+	Signature Signature
+	SyncInfo  SyncInfo
+}
+
+// CommitDecision announces a ledger info that a quorum signed
+type CommitDecision struct {
+	LedgerInfo LedgerInfoWithSignatures
+}
+
+// blockDataSeed is the domain separator Aptos' CryptoHasher derives for BlockData
+var blockDataSeed = sha3.Sum256([]byte("APTOS::BlockData"))
+
+// ID returns the block id, the salted SHA3-256 hash of the BCS-encoded block data.
+func (d BlockData) ID() (HashValue, error) {
+	data, err := bcs.Marshal(d)
+	if err != nil {
+		return HashValue{}, err
+	}
+	h := sha3.New256()
+	h.Write(blockDataSeed[:])
+	h.Write(data)
+	var id HashValue
+	copy(id[:], h.Sum(nil))
+	return id, nil
+}
+
+// Author returns the proposer of a proposal block, or false for nil and genesis blocks.
+func (t BlockType) Author() (AccountAddress, bool) {
+	if t.Proposal == nil {
+		return AccountAddress{}, false
+	}
+	return t.Proposal.Author, true
+}
+
+// EncodeBCS serializes a consensus container with BCS.
+func EncodeBCS(v any) ([]byte, error) {
+	return bcs.Marshal(v)
+}
+
+// DecodeBCS parses BCS data into a consensus container and rejects trailing bytes.
+func DecodeBCS(data []byte, v any) error {
+	n, err := bcs.Unmarshal(data, v)
+	if err != nil {
+		return err
+	}
+	if n != len(data) {
+		return fmt.Errorf("%d trailing bytes after bcs value", len(data)-n)
+	}
+	return nil
+}
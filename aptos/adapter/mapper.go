@@ -0,0 +1,442 @@
+package adapter
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"codec/message/abstraction"
+)
+
+// AptosBFT message types as carried in RawConsensusMessage.MessageType
+const (
+	MessageProposal       = "ProposalMsg"
+	MessageVote           = "VoteMsg"
+	MessageTimeout        = "TimeoutMsg"
+	MessageCommitDecision = "CommitDecision"
+)
+
+// AptosMapper implements the Mapper interface for AptosBFT (DiemBFT v4) messages. Payloads are BCS-encoded.
+// AptosBFT identifies blocks by epoch and round and carries no block height, so the epoch becomes the canonical
+// height and the round the view. Certificates the canonical form has no fields for travel BCS-encoded in the
+// extensions, so a message converts back unchanged.
+type AptosMapper struct {
+	chainID string
+}
+
+// NewAptosMapper creates a new Aptos mapper
+func NewAptosMapper(chainID string) *AptosMapper {
+	return &AptosMapper{
+		chainID: chainID,
+	}
+}
+
+// ToCanonical converts an AptosBFT message to canonical format. A proposal maps to a proposal, a vote to a
+// vote, a timeout to a view change, and a commit decision to a commit carrying the aggregate signature.
+func (m *AptosMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abstraction.CanonicalMessage, error) {
+	if raw.ChainType != abstraction.ChainTypeAptos {
+		return nil, abstraction.ErrChainMismatch
+	}
+	if raw.Encoding != "bcs" {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "encoding",
+			Message: fmt.Sprintf("unsupported encoding: %s", raw.Encoding),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+	decodeFailure := func(err error) error {
+		return &abstraction.MessageValidationError{
+			Field:   "payload",
+			Message: fmt.Sprintf("failed to parse bcs: %v", err),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+
+	canonical := &abstraction.CanonicalMessage{
+		ChainID:    m.chainID,
+		Timestamp:  raw.Timestamp,
+		RawPayload: raw.Payload,
+		Extensions: map[string]interface{}{
+			"aptos_message_type": raw.MessageType,
+		},
+	}
+
+	var err error
+	switch raw.MessageType {
+	case MessageProposal:
+		var msg ProposalMsg
+		if err := DecodeBCS(raw.Payload, &msg); err != nil {
+			return nil, decodeFailure(err)
+		}
+		data := msg.Proposal.BlockData
+		id, err := data.ID()
+		if err != nil {
+			return nil, decodeFailure(err)
+		}
+		canonical.Type = abstraction.MsgTypeProposal
+		canonical.Height = new(big.Int).SetUint64(data.Epoch)
+		canonical.View = new(big.Int).SetUint64(data.Round)
+		canonical.BlockHash = formatBytes(id[:])
+		canonical.PrevHash = formatBytes(data.QuorumCert.VoteData.Proposed.ID[:])
+		if author, ok := data.BlockType.Author(); ok {
+			canonical.Proposer = formatBytes(author[:])
+		}
+		if msg.Proposal.Signature != nil {
+			canonical.Signature = formatBytes(*msg.Proposal.Signature)
+		}
+		canonical.Extensions["timestamp_usecs"] = data.TimestampUsecs
+		canonical.Extensions["qc_round"] = data.QuorumCert.VoteData.Proposed.Round
+		err = setBCSExtensions(canonical.Extensions, map[string]any{
+			"quorum_cert": data.QuorumCert,
+			"block_type":  data.BlockType,
+			"sync_info":   msg.SyncInfo,
+		})
+
+	case MessageVote:
+		var msg VoteMsg
+		if err := DecodeBCS(raw.Payload, &msg); err != nil {
+			return nil, decodeFailure(err)
+		}
+		vote := msg.Vote
+		canonical.Type = abstraction.MsgTypeVote
+		canonical.Validator = formatBytes(vote.Author[:])
+		canonical.Signature = formatBytes(vote.Signature)
+		canonical.PrevHash = formatBytes(vote.VoteData.Parent.ID[:])
+		err = blockInfoToCanonical(canonical, vote.VoteData.Proposed)
+		if err == nil {
+			extensions := map[string]any{
+				"parent":      vote.VoteData.Parent,
+				"ledger_info": vote.LedgerInfo,
+				"sync_info":   msg.SyncInfo,
+			}
+			if vote.TwoChainTimeout != nil {
+				extensions["two_chain_timeout"] = *vote.TwoChainTimeout
+			}
+			err = setBCSExtensions(canonical.Extensions, extensions)
+		}
+
+	case MessageTimeout:
+		var msg TimeoutMsg
+		if err := DecodeBCS(raw.Payload, &msg); err != nil {
+			return nil, decodeFailure(err)
+		}
+		canonical.Type = abstraction.MsgTypeViewChange
+		canonical.Height = new(big.Int).SetUint64(msg.Timeout.Epoch)
+		canonical.View = new(big.Int).SetUint64(msg.Timeout.Round)
+		canonical.Validator = formatBytes(msg.Author[:])
+		canonical.Signature = formatBytes(msg.Signature)
+		canonical.Extensions["hqc_round"] = msg.Timeout.QuorumCert.VoteData.Proposed.Round
+		err = setBCSExtensions(canonical.Extensions, map[string]any{
+			"quorum_cert": msg.Timeout.QuorumCert,
+			"sync_info":   msg.SyncInfo,
+		})
+
+	case MessageCommitDecision:
+		var msg CommitDecision
+		if err := DecodeBCS(raw.Payload, &msg); err != nil {
+			return nil, decodeFailure(err)
+		}
+		if msg.LedgerInfo.V0 == nil {
+			return nil, decodeFailure(fmt.Errorf("commit decision has no ledger info"))
+		}
+		info := msg.LedgerInfo.V0
+		canonical.Type = abstraction.MsgTypeCommit
+		if info.Signatures.Sig != nil {
+			canonical.CommitSeals = []string{formatBytes(*info.Signatures.Sig)}
+		}
+		canonical.Extensions["consensus_data_hash"] = formatBytes(info.LedgerInfo.ConsensusDataHash[:])
+		canonical.Extensions["validator_bitmask"] = formatBytes(info.Signatures.ValidatorBitmask)
+		err = blockInfoToCanonical(canonical, info.LedgerInfo.CommitInfo)
+
+	default:
+		return nil, &abstraction.MessageValidationError{
+			Field:   "message_type",
+			Message: fmt.Sprintf("unsupported Aptos message type: %s", raw.MessageType),
+			Code:    "UNSUPPORTED_TYPE",
+		}
+	}
+	if err != nil {
+		return nil, decodeFailure(err)
+	}
+
+	return canonical, nil
+}
+
+// FromCanonical converts a canonical message to BCS. A proposal's block hash is not encoded: the block id is
+// the hash of the block data, so it follows from the other fields.
+func (m *AptosMapper) FromCanonical(msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
+	if msg == nil {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "message",
+			Message: "message cannot be nil",
+			Code:    "MISSING_FIELD",
+		}
+	}
+	invalid := func(field string, err error) error {
+		return &abstraction.MessageValidationError{
+			Field:   field,
+			Message: err.Error(),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+	var epoch, round uint64
+	if msg.Height != nil {
+		epoch = msg.Height.Uint64()
+	}
+	if msg.View != nil {
+		round = msg.View.Uint64()
+	}
+
+	var messageType string
+	var value any
+	switch msg.Type {
+	case abstraction.MsgTypeProposal, abstraction.MsgTypeBlock:
+		proposal := ProposalMsg{}
+		data := &proposal.Proposal.BlockData
+		data.Epoch, data.Round = epoch, round
+		data.TimestampUsecs, _ = msg.Extensions.GetUint64("timestamp_usecs")
+		if err := bcsExtension(msg.Extensions, "quorum_cert", &data.QuorumCert); err != nil {
+			return nil, invalid("quorum_cert", err)
+		}
+		if err := bcsExtension(msg.Extensions, "block_type", &data.BlockType); err != nil {
+			return nil, invalid("block_type", err)
+		}
+		if data.BlockType == (BlockType{}) {
+			data.BlockType.Proposal = &ProposalBlock{}
+		}
+		if data.BlockType.Proposal != nil {
+			author, err := parseAddress(msg.Proposer)
+			if err != nil {
+				return nil, invalid("proposer", err)
+			}
+			data.BlockType.Proposal.Author = author
+		}
+		if msg.Signature != "" {
+			sig, err := parseBytes(msg.Signature)
+			if err != nil {
+				return nil, invalid("signature", err)
+			}
+			proposal.Proposal.Signature = (*Signature)(&sig)
+		}
+		if err := bcsExtension(msg.Extensions, "sync_info", &proposal.SyncInfo); err != nil {
+			return nil, invalid("sync_info", err)
+		}
+		messageType, value = MessageProposal, proposal
+
+	case abstraction.MsgTypeVote, abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit:
+		vote := VoteMsg{}
+		proposed, err := blockInfoFromCanonical(msg, epoch, round)
+		if err != nil {
+			return nil, invalid("extensions", err)
+		}
+		vote.Vote.VoteData.Proposed = proposed
+		if err := bcsExtension(msg.Extensions, "parent", &vote.Vote.VoteData.Parent); err != nil {
+			return nil, invalid("parent", err)
+		}
+		if vote.Vote.VoteData.Parent.ID, err = parseHash(msg.PrevHash); err != nil {
+			return nil, invalid("prev_hash", err)
+		}
+		if vote.Vote.Author, err = parseAddress(msg.Validator); err != nil {
+			return nil, invalid("validator", err)
+		}
+		if vote.Vote.Signature, err = parseBytes(msg.Signature); err != nil {
+			return nil, invalid("signature", err)
+		}
+		if err := bcsExtension(msg.Extensions, "ledger_info", &vote.Vote.LedgerInfo); err != nil {
+			return nil, invalid("ledger_info", err)
+		}
+		if msg.Extensions.Has("two_chain_timeout") {
+			vote.Vote.TwoChainTimeout = &TimeoutSignature{}
+			if err := bcsExtension(msg.Extensions, "two_chain_timeout", vote.Vote.TwoChainTimeout); err != nil {
+				return nil, invalid("two_chain_timeout", err)
+			}
+		}
+		if err := bcsExtension(msg.Extensions, "sync_info", &vote.SyncInfo); err != nil {
+			return nil, invalid("sync_info", err)
+		}
+		messageType, value = MessageVote, vote
+
+	case abstraction.MsgTypeViewChange, abstraction.MsgTypeRoundChange:
+		timeout := TimeoutMsg{Timeout: TwoChainTimeout{Epoch: epoch, Round: round}}
+		var err error
+		if timeout.Author, err = parseAddress(msg.Validator); err != nil {
+			return nil, invalid("validator", err)
+		}
+		if timeout.Signature, err = parseBytes(msg.Signature); err != nil {
+			return nil, invalid("signature", err)
+		}
+		if err := bcsExtension(msg.Extensions, "quorum_cert", &timeout.Timeout.QuorumCert); err != nil {
+			return nil, invalid("quorum_cert", err)
+		}
+		if err := bcsExtension(msg.Extensions, "sync_info", &timeout.SyncInfo); err != nil {
+			return nil, invalid("sync_info", err)
+		}
+		messageType, value = MessageTimeout, timeout
+
+	case abstraction.MsgTypeCommit:
+		info := &LedgerInfoWithV0{}
+		commitInfo, err := blockInfoFromCanonical(msg, epoch, round)
+		if err != nil {
+			return nil, invalid("extensions", err)
+		}
+		info.LedgerInfo.CommitInfo = commitInfo
+		if info.LedgerInfo.ConsensusDataHash, err = parseHash(stringExtension(msg.Extensions, "consensus_data_hash")); err != nil {
+			return nil, invalid("consensus_data_hash", err)
+		}
+		if info.Signatures.ValidatorBitmask, err = parseBytes(stringExtension(msg.Extensions, "validator_bitmask")); err != nil {
+			return nil, invalid("validator_bitmask", err)
+		}
+		if len(msg.CommitSeals) > 0 {
+			sig, err := parseBytes(msg.CommitSeals[0])
+			if err != nil {
+				return nil, invalid("commit_seals", err)
+			}
+			info.Signatures.Sig = (*Signature)(&sig)
+		}
+		messageType, value = MessageCommitDecision, CommitDecision{LedgerInfo: LedgerInfoWithSignatures{V0: info}}
+
+	default:
+		return nil, &abstraction.MessageValidationError{
+			Field:   "type",
+			Message: fmt.Sprintf("unsupported canonical message type: %s", msg.Type),
+			Code:    "UNSUPPORTED_TYPE",
+		}
+	}
+
+	payload, err := EncodeBCS(value)
+	if err != nil {
+		return nil, invalid("payload", err)
+	}
+
+	return &abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeAptos,
+		ChainID:     m.chainID,
+		MessageType: messageType,
+		Payload:     payload,
+		Encoding:    "bcs",
+		Timestamp:   abstraction.Now(),
+		Metadata: map[string]interface{}{
+			"epoch": epoch,
+			"round": round,
+		},
+	}, nil
+}
+
+// GetSupportedTypes returns the message types supported by AptosBFT
+func (m *AptosMapper) GetSupportedTypes() []abstraction.MsgType {
+	return []abstraction.MsgType{
+		abstraction.MsgTypeProposal,   // ProposalMsg
+		abstraction.MsgTypeVote,       // VoteMsg
+		abstraction.MsgTypeViewChange, // TimeoutMsg
+		abstraction.MsgTypeCommit,     // CommitDecision
+	}
+}
+
+// GetChainType returns the chain type this mapper handles
+func (m *AptosMapper) GetChainType() abstraction.ChainType {
+	return abstraction.ChainTypeAptos
+}
+
+// blockInfoToCanonical places the block a vote or commit refers to into the canonical message.
+func blockInfoToCanonical(canonical *abstraction.CanonicalMessage, info BlockInfo) error {
+	canonical.Height = new(big.Int).SetUint64(info.Epoch)
+	canonical.View = new(big.Int).SetUint64(info.Round)
+	canonical.BlockHash = formatBytes(info.ID[:])
+	canonical.Extensions["executed_state_id"] = formatBytes(info.ExecutedStateID[:])
+	canonical.Extensions["version"] = info.Version
+	canonical.Extensions["timestamp_usecs"] = info.TimestampUsecs
+	if info.NextEpochState != nil {
+		return setBCSExtensions(canonical.Extensions, map[string]any{"next_epoch_state": *info.NextEpochState})
+	}
+	return nil
+}
+
+// blockInfoFromCanonical is the inverse of blockInfoToCanonical.
+func blockInfoFromCanonical(msg *abstraction.CanonicalMessage, epoch, round uint64) (BlockInfo, error) {
+	info := BlockInfo{Epoch: epoch, Round: round}
+	var err error
+	if info.ID, err = parseHash(msg.BlockHash); err != nil {
+		return info, err
+	}
+	if info.ExecutedStateID, err = parseHash(stringExtension(msg.Extensions, "executed_state_id")); err != nil {
+		return info, err
+	}
+	info.Version, _ = msg.Extensions.GetUint64("version")
+	info.TimestampUsecs, _ = msg.Extensions.GetUint64("timestamp_usecs")
+	if msg.Extensions.Has("next_epoch_state") {
+		info.NextEpochState = &EpochState{}
+		if err := bcsExtension(msg.Extensions, "next_epoch_state", info.NextEpochState); err != nil {
+			return info, err
+		}
+	}
+	return info, nil
+}
+
+// setBCSExtensions stores each value BCS-encoded as a 0x-prefixed hex string.
+func setBCSExtensions(extensions map[string]interface{}, values map[string]any) error {
+	for key, value := range values {
+		data, err := EncodeBCS(value)
+		if err != nil {
+			return fmt.Errorf("encode %s: %v", key, err)
+		}
+		extensions[key] = formatBytes(data)
+	}
+	return nil
+}
+
+// bcsExtension decodes a setBCSExtensions value into v; a missing extension leaves v unchanged.
+func bcsExtension(extensions abstraction.Extensions, key string, v any) error {
+	value := stringExtension(extensions, key)
+	if value == "" {
+		return nil
+	}
+	data, err := parseBytes(value)
+	if err != nil {
+		return err
+	}
+	return DecodeBCS(data, v)
+}
+
+func parseHash(value string) (HashValue, error) {
+	var hash HashValue
+	if value == "" {
+		return hash, nil
+	}
+	data, err := parseBytes(value)
+	if err != nil || len(data) != len(hash) {
+		return hash, fmt.Errorf("invalid hash %q: expected %d hex bytes", value, len(hash))
+	}
+	copy(hash[:], data)
+	return hash, nil
+}
+
+func parseAddress(value string) (AccountAddress, error) {
+	var addr AccountAddress
+	if value == "" {
+		return addr, nil
+	}
+	data, err := parseBytes(value)
+	if err != nil || len(data) != len(addr) {
+		return addr, fmt.Errorf("invalid account address %q: expected %d hex bytes", value, len(addr))
+	}
+	copy(addr[:], data)
+	return addr, nil
+}
+
+func parseBytes(value string) ([]byte, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex %q: %v", value, err)
+	}
+	return data, nil
+}
+
+func formatBytes(data []byte) string {
+	return "0x" + hex.EncodeToString(data)
+}
+
+func stringExtension(extensions abstraction.Extensions, key string) string {
+	s, _ := extensions.GetString(key)
+	return s
+}
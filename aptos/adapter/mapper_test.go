@@ -0,0 +1,139 @@
+package adapter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/validator"
+)
+
+func hash(b byte) (h HashValue) {
+	for i := range h {
+		h[i] = b
+	}
+	return h
+}
+
+func aptosRaw(t *testing.T, messageType string, msg any) abstraction.RawConsensusMessage {
+	t.Helper()
+	payload, err := EncodeBCS(msg)
+	if err != nil {
+		t.Fatalf("encode %s: %v", messageType, err)
+	}
+	return abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeAptos,
+		ChainID:     "aptos",
+		MessageType: messageType,
+		Payload:     payload,
+		Encoding:    "bcs",
+		Timestamp:   time.Now(),
+	}
+}
+
+func TestBCSEncoding(t *testing.T) {
+	sig := Signature{0xaa}
+	agg := AggregateSignature{ValidatorBitmask: []byte{0x80}, Sig: &sig}
+	data, err := EncodeBCS(agg)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	// Vectors carry a ULEB128 length and options a 0/1 tag.
+	if want := []byte{0x01, 0x80, 0x01, 0x01, 0xaa}; !bytes.Equal(data, want) {
+		t.Fatalf("unexpected encoding %x, want %x", data, want)
+	}
+	if err := DecodeBCS(append(data, 0x00), &AggregateSignature{}); err == nil {
+		t.Fatalf("expected trailing bytes to be rejected")
+	}
+}
+
+func TestAptosRoundTrip(t *testing.T) {
+	mapper := NewAptosMapper("aptos-test")
+	author := AccountAddress(hash(0x0a))
+	sig := Signature{1, 2, 3}
+	qc := QuorumCert{
+		VoteData: VoteData{
+			Proposed: BlockInfo{Epoch: 7, Round: 41, ID: hash(0x41), Version: 900},
+			Parent:   BlockInfo{Epoch: 7, Round: 40, ID: hash(0x40), Version: 880},
+		},
+		SignedLedgerInfo: LedgerInfoWithSignatures{V0: &LedgerInfoWithV0{Signatures: AggregateSignature{ValidatorBitmask: []byte{0xe0}, Sig: &sig}}},
+	}
+	syncInfo := SyncInfo{HighestQuorumCert: qc, HighestCommitCert: qc}
+
+	block := Block{
+		BlockData: BlockData{Epoch: 7, Round: 42, TimestampUsecs: 1700000000000000, QuorumCert: qc,
+			BlockType: BlockType{Proposal: &ProposalBlock{Payload: []byte("txns"), Author: author,
+				FailedAuthors: []FailedAuthor{{Round: 39, Author: AccountAddress(hash(0x0b))}}}}},
+		Signature: &sig,
+	}
+	blockID, err := block.BlockData.ID()
+	if err != nil {
+		t.Fatalf("block id: %v", err)
+	}
+	proposed := BlockInfo{Epoch: 7, Round: 42, ID: blockID, ExecutedStateID: hash(0x5e), Version: 920, TimestampUsecs: 1700000000000000,
+		NextEpochState: &EpochState{Epoch: 8, Validators: []ValidatorConsensusInfo{{Address: author, PublicKey: []byte{0x0f}, VotingPower: 10}}}}
+	vote := VoteMsg{
+		Vote: Vote{VoteData: VoteData{Proposed: proposed, Parent: qc.VoteData.Proposed}, Author: author,
+			LedgerInfo: LedgerInfo{CommitInfo: qc.VoteData.Parent}, Signature: sig,
+			TwoChainTimeout: &TimeoutSignature{Timeout: TwoChainTimeout{Epoch: 7, Round: 42, QuorumCert: qc}, Signature: sig}},
+		SyncInfo: syncInfo,
+	}
+	timeout := TimeoutMsg{Timeout: TwoChainTimeout{Epoch: 7, Round: 43, QuorumCert: qc}, Author: author, Signature: sig, SyncInfo: syncInfo}
+	commit := CommitDecision{LedgerInfo: LedgerInfoWithSignatures{V0: &LedgerInfoWithV0{
+		LedgerInfo: LedgerInfo{CommitInfo: qc.VoteData.Parent, ConsensusDataHash: hash(0xcd)},
+		Signatures: AggregateSignature{ValidatorBitmask: []byte{0xe0}, Sig: &sig},
+	}}}
+
+	tests := []struct {
+		name      string
+		raw       abstraction.RawConsensusMessage
+		msgType   abstraction.MsgType
+		round     uint64
+		blockHash HashValue
+	}{
+		{"proposal", aptosRaw(t, MessageProposal, ProposalMsg{Proposal: block, SyncInfo: syncInfo}), abstraction.MsgTypeProposal, 42, blockID},
+		{"vote", aptosRaw(t, MessageVote, vote), abstraction.MsgTypeVote, 42, blockID},
+		{"timeout", aptosRaw(t, MessageTimeout, timeout), abstraction.MsgTypeViewChange, 43, HashValue{}},
+		{"commit decision", aptosRaw(t, MessageCommitDecision, commit), abstraction.MsgTypeCommit, 40, hash(0x40)},
+	}
+
+	v := validator.NewValidator(abstraction.ChainTypeAptos)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canonical, err := mapper.ToCanonical(tt.raw)
+			if err != nil {
+				t.Fatalf("ToCanonical: %v", err)
+			}
+			wantHash := formatBytes(tt.blockHash[:])
+			if tt.blockHash == (HashValue{}) {
+				wantHash = ""
+			}
+			if canonical.Type != tt.msgType || canonical.Height.Uint64() != 7 || canonical.View.Uint64() != tt.round ||
+				canonical.Round != nil || canonical.BlockHash != wantHash {
+				t.Fatalf("unexpected canonical message %+v", canonical)
+			}
+			if err := v.Validate(canonical); err != nil {
+				t.Fatalf("validation failed: %v", err)
+			}
+
+			raw, err := mapper.FromCanonical(canonical)
+			if err != nil {
+				t.Fatalf("FromCanonical: %v", err)
+			}
+			if raw.MessageType != tt.raw.MessageType || !bytes.Equal(raw.Payload, tt.raw.Payload) {
+				t.Fatalf("round trip changed the payload:\n got %x\nwant %x", raw.Payload, tt.raw.Payload)
+			}
+		})
+	}
+
+	for name, raw := range map[string]abstraction.RawConsensusMessage{
+		"truncated vote": {ChainType: abstraction.ChainTypeAptos, MessageType: MessageVote, Payload: tests[1].raw.Payload[:50], Encoding: "bcs"},
+		"unknown type":   {ChainType: abstraction.ChainTypeAptos, MessageType: "BatchMsg", Encoding: "bcs"},
+		"json payload":   {ChainType: abstraction.ChainTypeAptos, MessageType: MessageVote, Encoding: "json"},
+	} {
+		if _, err := mapper.ToCanonical(raw); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
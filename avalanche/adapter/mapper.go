@@ -0,0 +1,269 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+// Snowman message types relayed between Avalanche validators
+const (
+	SnowmanPushQuery = "PushQuery" // Poll that carries the block to vote on
+	SnowmanPullQuery = "PullQuery" // Poll that names the block by ID
+	SnowmanChits     = "Chits"     // Poll answer with the responder's preference
+	SnowmanPut       = "Put"       // Block delivered outside a poll
+)
+
+// SnowmanMessage is the JSON projection of an avalanchego p2p consensus message. IDs are CB58 strings and
+// validators are identified by their NodeID-prefixed node IDs.
+type SnowmanMessage struct {
+	MessageType         string         `json:"message_type"`
+	BlockchainID        string         `json:"blockchain_id"`
+	NodeID              string         `json:"node_id"`
+	RequestID           uint32         `json:"request_id"`
+	Deadline            uint64         `json:"deadline,omitempty"`
+	RequestedHeight     uint64         `json:"requested_height,omitempty"`
+	ContainerID         string         `json:"container_id,omitempty"`
+	Block               *ProposerBlock `json:"block,omitempty"`
+	PreferredID         string         `json:"preferred_id,omitempty"`
+	PreferredIDAtHeight string         `json:"preferred_id_at_height,omitempty"`
+	AcceptedID          string         `json:"accepted_id,omitempty"`
+	AcceptedHeight      uint64         `json:"accepted_height,omitempty"`
+	Timestamp           time.Time      `json:"timestamp"`
+}
+
+// ProposerBlock is a Snowman++ proposer-VM block wrapping the inner VM block. Proposer and Signature are
+// empty for unsigned blocks, which anyone may build once the proposer windows have passed.
+type ProposerBlock struct {
+	ID           string `json:"id"`
+	ParentID     string `json:"parent_id"`
+	Height       uint64 `json:"height"`
+	Timestamp    int64  `json:"timestamp"`
+	PChainHeight uint64 `json:"p_chain_height"`
+	Proposer     string `json:"proposer,omitempty"`
+	InnerBlock   string `json:"inner_block,omitempty"`
+	Signature    string `json:"signature,omitempty"`
+}
+
+// AvalancheMapper implements the Mapper interface for Avalanche Snowman++ consensus. Snowman decides by
+// repeated sampling rather than rounds of voting, so only some canonical fields carry meaning:
+//
+//   - Height is the block height, or the requested height of a PullQuery and the last accepted height of Chits.
+//   - BlockHash is the block polled, delivered, or preferred; PrevHash is a block's parent.
+//   - Proposer is the proposer-VM block's signer and Validator the node that sent the message.
+//   - Round and View stay empty.
+//
+// Poll request IDs, deadlines, P-Chain heights, and the Chits' accepted block go into the extensions.
+type AvalancheMapper struct {
+	chainID string
+}
+
+// NewAvalancheMapper creates a new Avalanche mapper
+func NewAvalancheMapper(chainID string) *AvalancheMapper {
+	return &AvalancheMapper{
+		chainID: chainID,
+	}
+}
+
+// ToCanonical converts a Snowman message to canonical format. Both polls map to a proposal, Chits to a vote,
+// and Put to a block.
+func (m *AvalancheMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abstraction.CanonicalMessage, error) {
+	if raw.ChainType != abstraction.ChainTypeAvalanche {
+		return nil, abstraction.ErrChainMismatch
+	}
+
+	var snowMsg SnowmanMessage
+	switch raw.Encoding {
+	case "json", "proto":
+		if err := json.Unmarshal(raw.Payload, &snowMsg); err != nil {
+			return nil, &abstraction.MessageValidationError{
+				Field:   "payload",
+				Message: fmt.Sprintf("failed to parse %s: %v", raw.Encoding, err),
+				Code:    "DECODE_FAILURE",
+			}
+		}
+	default:
+		return nil, &abstraction.MessageValidationError{
+			Field:   "encoding",
+			Message: fmt.Sprintf("unsupported encoding: %s", raw.Encoding),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+	if snowMsg.MessageType == "" {
+		snowMsg.MessageType = raw.MessageType
+	}
+
+	timestamp := snowMsg.Timestamp
+	if timestamp.IsZero() {
+		timestamp = raw.Timestamp
+	}
+
+	canonical := &abstraction.CanonicalMessage{
+		ChainID:    m.chainID,
+		Timestamp:  timestamp,
+		Validator:  snowMsg.NodeID,
+		RawPayload: raw.Payload,
+		Extensions: map[string]interface{}{
+			"snowman_message_type": snowMsg.MessageType,
+			"blockchain_id":        snowMsg.BlockchainID,
+			"request_id":           snowMsg.RequestID,
+		},
+	}
+
+	var height uint64
+	switch snowMsg.MessageType {
+	case SnowmanPushQuery, SnowmanPut:
+		if snowMsg.Block == nil {
+			return nil, &abstraction.MessageValidationError{
+				Field:   "block",
+				Message: fmt.Sprintf("%s carries no block", snowMsg.MessageType),
+				Code:    "MISSING_FIELD",
+			}
+		}
+		canonical.Type = abstraction.MsgTypeBlock
+		if snowMsg.MessageType == SnowmanPushQuery {
+			canonical.Type = abstraction.MsgTypeProposal
+			canonical.Extensions["deadline"] = snowMsg.Deadline
+			canonical.Extensions["requested_height"] = snowMsg.RequestedHeight
+		}
+		block := snowMsg.Block
+		height = block.Height
+		canonical.BlockHash = block.ID
+		canonical.PrevHash = block.ParentID
+		canonical.Proposer = block.Proposer
+		canonical.Signature = block.Signature
+		canonical.Extensions["block_timestamp"] = block.Timestamp
+		canonical.Extensions["p_chain_height"] = block.PChainHeight
+		canonical.Extensions["inner_block"] = block.InnerBlock
+	case SnowmanPullQuery:
+		canonical.Type = abstraction.MsgTypeProposal
+		height = snowMsg.RequestedHeight
+		canonical.BlockHash = snowMsg.ContainerID
+		canonical.Extensions["deadline"] = snowMsg.Deadline
+		canonical.Extensions["requested_height"] = snowMsg.RequestedHeight
+	case SnowmanChits:
+		canonical.Type = abstraction.MsgTypeVote
+		height = snowMsg.AcceptedHeight
+		canonical.BlockHash = snowMsg.PreferredID
+		canonical.Extensions["preferred_id_at_height"] = snowMsg.PreferredIDAtHeight
+		canonical.Extensions["accepted_id"] = snowMsg.AcceptedID
+		canonical.Extensions["accepted_height"] = snowMsg.AcceptedHeight
+	default:
+		return nil, &abstraction.MessageValidationError{
+			Field:   "message_type",
+			Message: fmt.Sprintf("unsupported Snowman message type: %s", snowMsg.MessageType),
+			Code:    "UNSUPPORTED_TYPE",
+		}
+	}
+	canonical.Height = new(big.Int).SetUint64(height)
+
+	return canonical, nil
+}
+
+// FromCanonical converts a canonical message to a Snowman message. A proposal becomes a PushQuery unless it
+// came from a PullQuery, since a PushQuery carries the whole block.
+func (m *AvalancheMapper) FromCanonical(msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
+	if msg == nil {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "message",
+			Message: "message cannot be nil",
+			Code:    "MISSING_FIELD",
+		}
+	}
+
+	snowMsg := SnowmanMessage{NodeID: msg.Validator, Timestamp: msg.Timestamp}
+	var height uint64
+	if msg.Height != nil {
+		height = msg.Height.Uint64()
+	}
+	if msg.Extensions != nil {
+		snowMsg.BlockchainID = stringExtension(msg.Extensions, "blockchain_id")
+		requestID, _ := msg.Extensions.GetUint64("request_id")
+		snowMsg.RequestID = uint32(requestID)
+	}
+	block := func() *ProposerBlock {
+		block := &ProposerBlock{
+			ID:         msg.BlockHash,
+			ParentID:   msg.PrevHash,
+			Height:     height,
+			Proposer:   msg.Proposer,
+			InnerBlock: stringExtension(msg.Extensions, "inner_block"),
+			Signature:  msg.Signature,
+		}
+		timestamp, _ := msg.Extensions.GetUint64("block_timestamp")
+		block.Timestamp = int64(timestamp)
+		block.PChainHeight, _ = msg.Extensions.GetUint64("p_chain_height")
+		return block
+	}
+
+	switch msg.Type {
+	case abstraction.MsgTypeProposal, abstraction.MsgTypePrepare:
+		snowMsg.Deadline, _ = msg.Extensions.GetUint64("deadline")
+		snowMsg.RequestedHeight = height
+		if requested, ok := msg.Extensions.GetUint64("requested_height"); ok {
+			snowMsg.RequestedHeight = requested
+		}
+		if stringExtension(msg.Extensions, "snowman_message_type") == SnowmanPullQuery {
+			snowMsg.MessageType = SnowmanPullQuery
+			snowMsg.ContainerID = msg.BlockHash
+			break
+		}
+		snowMsg.MessageType = SnowmanPushQuery
+		snowMsg.Block = block()
+	case abstraction.MsgTypeVote, abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit, abstraction.MsgTypeCommit:
+		snowMsg.MessageType = SnowmanChits
+		snowMsg.PreferredID = msg.BlockHash
+		snowMsg.PreferredIDAtHeight = stringExtension(msg.Extensions, "preferred_id_at_height")
+		snowMsg.AcceptedID = stringExtension(msg.Extensions, "accepted_id")
+		snowMsg.AcceptedHeight = height
+	case abstraction.MsgTypeBlock:
+		snowMsg.MessageType = SnowmanPut
+		snowMsg.Block = block()
+	default:
+		return nil, &abstraction.MessageValidationError{
+			Field:   "type",
+			Message: fmt.Sprintf("unsupported canonical message type: %s", msg.Type),
+			Code:    "UNSUPPORTED_TYPE",
+		}
+	}
+
+	payload, err := json.Marshal(snowMsg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeAvalanche,
+		ChainID:     m.chainID,
+		MessageType: snowMsg.MessageType,
+		Payload:     payload,
+		Encoding:    "json",
+		Timestamp:   abstraction.Now(),
+		Metadata: map[string]interface{}{
+			"blockchain_id": snowMsg.BlockchainID,
+			"request_id":    snowMsg.RequestID,
+		},
+	}, nil
+}
+
+// GetSupportedTypes returns the message types supported by Snowman
+func (m *AvalancheMapper) GetSupportedTypes() []abstraction.MsgType {
+	return []abstraction.MsgType{
+		abstraction.MsgTypeProposal, // PushQuery and PullQuery
+		abstraction.MsgTypeVote,     // Chits
+		abstraction.MsgTypeBlock,    // Put
+	}
+}
+
+// GetChainType returns the chain type this mapper handles
+func (m *AvalancheMapper) GetChainType() abstraction.ChainType {
+	return abstraction.ChainTypeAvalanche
+}
+
+func stringExtension(extensions abstraction.Extensions, key string) string {
+	s, _ := extensions.GetString(key)
+	return s
+}
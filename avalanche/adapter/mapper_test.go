@@ -0,0 +1,99 @@
+package adapter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/validator"
+)
+
+func snowmanRaw(t *testing.T, msg SnowmanMessage) abstraction.RawConsensusMessage {
+	t.Helper()
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeAvalanche,
+		ChainID:     "avalanche",
+		MessageType: msg.MessageType,
+		Payload:     payload,
+		Encoding:    "json",
+		Timestamp:   time.Now(),
+	}
+}
+
+func TestAvalancheRoundTrip(t *testing.T) {
+	mapper := NewAvalancheMapper("avalanche-test")
+	now := time.Now().UTC()
+	const chain = "2q9e4r6Mu3U68nU1fYjgbR6JvwrRx36CohpAX5UQxse55x1Q5"
+	block := &ProposerBlock{
+		ID:           "2ZDg3QXrwcQZ8vRcGxCG8AFXrGC2KycbXFh5LbXHcw3ebkG4D5",
+		ParentID:     "nQdMw1FWuSdNxeiVzbq3hpv1uJvprWfuM4yz9N7CXQStfqMwe",
+		Height:       41822317,
+		Timestamp:    1717430400,
+		PChainHeight: 14012256,
+		Proposer:     "NodeID-7Xhw2mDxuDS44j42TCB6U5579esbSt3Lg",
+		InnerBlock:   "0xf9021ea0",
+		Signature:    "0x3045",
+	}
+
+	tests := []struct {
+		msg     SnowmanMessage
+		msgType abstraction.MsgType
+		height  uint64
+		hash    string
+	}{
+		{SnowmanMessage{MessageType: SnowmanPushQuery, BlockchainID: chain, NodeID: "NodeID-A", RequestID: 7, Deadline: 2000000000,
+			RequestedHeight: 41822317, Block: block}, abstraction.MsgTypeProposal, 41822317, block.ID},
+		{SnowmanMessage{MessageType: SnowmanPullQuery, BlockchainID: chain, NodeID: "NodeID-A", RequestID: 8, Deadline: 2000000000,
+			RequestedHeight: 41822317, ContainerID: block.ID}, abstraction.MsgTypeProposal, 41822317, block.ID},
+		{SnowmanMessage{MessageType: SnowmanChits, BlockchainID: chain, NodeID: "NodeID-B", RequestID: 7, PreferredID: block.ID,
+			PreferredIDAtHeight: block.ID, AcceptedID: block.ParentID, AcceptedHeight: 41822316}, abstraction.MsgTypeVote, 41822316, block.ID},
+		{SnowmanMessage{MessageType: SnowmanPut, BlockchainID: chain, NodeID: "NodeID-C", Block: block},
+			abstraction.MsgTypeBlock, 41822317, block.ID},
+	}
+
+	v := validator.NewValidator(abstraction.ChainTypeAvalanche)
+	for _, tt := range tests {
+		t.Run(tt.msg.MessageType, func(t *testing.T) {
+			tt.msg.Timestamp = now
+			canonical, err := mapper.ToCanonical(snowmanRaw(t, tt.msg))
+			if err != nil {
+				t.Fatalf("ToCanonical: %v", err)
+			}
+			if canonical.Type != tt.msgType || canonical.Height.Uint64() != tt.height || canonical.BlockHash != tt.hash ||
+				canonical.Validator != tt.msg.NodeID || canonical.Round != nil || canonical.View != nil {
+				t.Fatalf("unexpected canonical message %+v", canonical)
+			}
+			if err := v.Validate(canonical); err != nil {
+				t.Fatalf("validation failed: %v", err)
+			}
+
+			raw, err := mapper.FromCanonical(canonical)
+			if err != nil {
+				t.Fatalf("FromCanonical: %v", err)
+			}
+			var back SnowmanMessage
+			if err := json.Unmarshal(raw.Payload, &back); err != nil {
+				t.Fatalf("decode round trip: %v", err)
+			}
+			back.Timestamp = tt.msg.Timestamp
+			if !reflect.DeepEqual(back, tt.msg) {
+				t.Fatalf("round trip changed the message:\n got %+v\nwant %+v", back, tt.msg)
+			}
+		})
+	}
+
+	for name, msg := range map[string]SnowmanMessage{
+		"push query without block": {MessageType: SnowmanPushQuery, NodeID: "NodeID-A"},
+		"app gossip":               {MessageType: "AppGossip", NodeID: "NodeID-A"},
+	} {
+		if _, err := mapper.ToCanonical(snowmanRaw(t, msg)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
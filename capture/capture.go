@@ -0,0 +1,128 @@
+// Package capture stores consensus traffic for later analysis. A capture is a JSON Lines file with one
+// Record per line, accompanied by a height index (<capture>.idx) so readers can jump to the heights around
+// an attack window without scanning multi-gigabyte files from the start.
+package capture
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+// Record is one captured consensus message.
+type Record struct {
+	Time time.Time `json:"time"`
+	// Source identifies where the message was observed, such as a peer address or proxy direction.
+	Source    string                           `json:"source,omitempty"`
+	Canonical *abstraction.CanonicalMessage    `json:"canonical"`
+	Raw       *abstraction.RawConsensusMessage `json:"raw,omitempty"`
+
+	// The fields below are set for traffic recorded by the byzantine proxy: the flow direction, the p2p channel,
+	// whether the message is one the proxy received or one it sent in its place, and the attack applied.
+	Direction string `json:"direction,omitempty"`
+	Channel   byte   `json:"channel,omitempty"`
+	Event     string `json:"event,omitempty"`
+	Action    string `json:"action,omitempty"`
+}
+
+// Height returns the record's consensus height, or -1 when it has none.
+func (r *Record) Height() int64 {
+	if r.Canonical == nil {
+		return -1
+	}
+	height, ok := r.Canonical.HeightInt64()
+	if !ok {
+		return -1
+	}
+	return height
+}
+
+// Writer appends records to a capture and writes its index on Close.
+type Writer struct {
+	path   string
+	file   *os.File
+	buf    *bufio.Writer
+	index  *index
+	offset int64
+}
+
+// Create starts a new capture at path, truncating any existing file.
+func Create(path string) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create capture: %w", err)
+	}
+	return &Writer{path: path, file: f, buf: bufio.NewWriterSize(f, 1<<16), index: newIndex()}, nil
+}
+
+// Append opens the capture at path for appending, creating it when missing. The records already in it stay
+// indexed, so the index written on Close covers the whole capture. A trailing partial line left by an
+// interrupted writer is cut off so new records start on a line of their own.
+func Append(path string) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open capture: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	ix, err := readIndex(IndexPath(path))
+	if err != nil || ix.size > info.Size() {
+		ix = newIndex()
+	}
+	if ix.size < info.Size() {
+		if err := ix.scan(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if ix.size < info.Size() {
+		if err := f.Truncate(ix.size); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to drop partial capture record: %w", err)
+		}
+	}
+	return &Writer{path: path, file: f, buf: bufio.NewWriterSize(f, 1<<16), index: ix, offset: ix.size}, nil
+}
+
+// Write appends a record.
+func (w *Writer) Write(rec *Record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode capture record: %w", err)
+	}
+	line = append(line, '\n')
+	if _, err := w.buf.Write(line); err != nil {
+		return fmt.Errorf("failed to write capture record: %w", err)
+	}
+	w.index.observe(rec.Height(), w.offset)
+	w.offset += int64(len(line))
+	return nil
+}
+
+// Flush writes buffered records to the file, so readers see them before Close.
+func (w *Writer) Flush() error {
+	if err := w.buf.Flush(); err != nil {
+		return fmt.Errorf("failed to flush capture: %w", err)
+	}
+	return nil
+}
+
+// Close flushes the capture and writes its height index.
+func (w *Writer) Close() error {
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("failed to flush capture: %w", err)
+	}
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.index.size = w.offset
+	return w.index.writeFile(IndexPath(w.path))
+}
@@ -0,0 +1,255 @@
+package capture
+
+import (
+	"errors"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+func record(height int64, validator string) *Record {
+	return &Record{
+		Time: time.Unix(1700000000+height, 0).UTC(),
+		Canonical: &abstraction.CanonicalMessage{
+			Height:    big.NewInt(height),
+			Round:     big.NewInt(0),
+			Type:      abstraction.MsgTypePrevote,
+			Validator: validator,
+		},
+	}
+}
+
+func writeCapture(t *testing.T, path string, records []*Record) {
+	t.Helper()
+	w, err := Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for _, rec := range records {
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+func TestSeekByHeight(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traffic.capture")
+	var records []*Record
+	for h := int64(1); h <= 200; h++ {
+		if h == 120 {
+			continue
+		}
+		records = append(records, record(h, "v1"), record(h, "v2"))
+	}
+	// A flood copy reaches height 500 early; it must not hide later heights.
+	records = append(records[:10], append([]*Record{record(500, "byz")}, records[10:]...)...)
+	writeCapture(t, path, records)
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer r.Close()
+
+	if lowest, highest, ok := r.Heights(); !ok || lowest != 1 || highest != 500 {
+		t.Fatalf("unexpected height range %d..%d (%v)", lowest, highest, ok)
+	}
+
+	if err := r.SeekHeight(150); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	for _, want := range []string{"v1", "v2"} {
+		rec, err := r.Next()
+		if err != nil || rec.Height() != 150 || rec.Canonical.Validator != want {
+			t.Fatalf("expected height 150 from %s, got %+v (%v)", want, rec, err)
+		}
+	}
+
+	// A missing height lands on the next one.
+	if err := r.SeekHeight(120); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	if rec, _ := r.Next(); rec.Height() != 121 {
+		t.Fatalf("expected seek past the gap to height 121, got %d", rec.Height())
+	}
+
+	if err := r.SeekHeight(501); !errors.Is(err, ErrHeightNotFound) {
+		t.Fatalf("expected ErrHeightNotFound, got %v", err)
+	}
+
+	if err := r.SeekHeight(200); err != nil {
+		t.Fatal(err)
+	}
+	r.Next()
+	r.Next()
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("expected EOF after the last height, got %v", err)
+	}
+}
+
+func TestIndexCatchesUpWithAppendedRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traffic.capture")
+	writeCapture(t, path, []*Record{record(1, "v1"), record(2, "v1")})
+
+	// Records appended without updating the index, plus a partial line from an interrupted write.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":"2024-01-01T00:00:00Z","canonical":{"height":3,"round":0,"type":"prevote","validator":"v1","chain_id":"","timestamp":"0001-01-01T00:00:00Z"}}` + "\n")
+	f.WriteString(`{"time":"2024-01-01T00:00:00Z","canon`)
+	f.Close()
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := r.SeekHeight(3); err != nil {
+		t.Fatalf("seek to appended height: %v", err)
+	}
+	if rec, err := r.Next(); err != nil || rec.Height() != 3 {
+		t.Fatalf("expected appended record, got %+v (%v)", rec, err)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("expected partial line to be skipped, got %v", err)
+	}
+	r.Close()
+
+	// Without an index the capture is scanned; BuildIndex persists the result.
+	os.Remove(IndexPath(path))
+	if err := BuildIndex(path); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	ix, err := readIndex(IndexPath(path))
+	if err != nil || len(ix.first) != 3 {
+		t.Fatalf("expected rebuilt index with 3 heights, got %v (%v)", ix, err)
+	}
+}
+
+func TestAppendContinuesCapture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traffic.capture")
+	writeCapture(t, path, []*Record{record(1, "v1"), record(2, "v1")})
+
+	// An interrupted writer left half a record behind.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":"2024-01-01T00:00:00Z","canon`)
+	f.Close()
+
+	w, err := Append(path)
+	if err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if err := w.Write(record(3, "v2")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	ix, err := readIndex(IndexPath(path))
+	if err != nil || len(ix.first) != 3 {
+		t.Fatalf("expected the index to cover old and appended heights, got %v (%v)", ix, err)
+	}
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer r.Close()
+	if err := r.SeekHeight(3); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	if rec, err := r.Next(); err != nil || rec.Canonical.Validator != "v2" {
+		t.Fatalf("expected the appended record, got %+v (%v)", rec, err)
+	}
+}
+
+func TestOnWireReplacesWhatTheProxyChanged(t *testing.T) {
+	proxied := func(rec *Record, source, event string) *Record {
+		rec.Source, rec.Direction, rec.Event = source, "inbound", event
+		return rec
+	}
+	relayed := proxied(record(1, "v1"), "peer-1", eventReceived)
+	replaced := proxied(record(1, "v2"), "peer-2", eventReceived)
+	dropped := proxied(record(1, "v3"), "peer-3", eventReceived)
+	forged := proxied(record(1, "v2"), "peer-2", eventMutated)
+	forged.Canonical.BlockHash = "FORGED"
+	plain := record(2, "v4")
+
+	msgs := OnWire([]*Record{
+		relayed, replaced, dropped,
+		forged,
+		proxied(record(1, "v3"), "peer-3", eventDropped),
+		plain,
+	})
+	if len(msgs) != 3 || msgs[0] != forged.Canonical || msgs[1] != plain.Canonical || msgs[2] != relayed.Canonical {
+		t.Fatalf("expected the forged, plain and relayed messages, got %+v", msgs)
+	}
+}
+
+func TestQueryFiltersRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traffic.capture")
+	var records []*Record
+	for h := int64(1); h <= 50; h++ {
+		records = append(records, record(h, "v1"), record(h, "v2"))
+	}
+	// A flood copy of height 30 arrives before height 20, and height 22 is replayed at the end.
+	flood := record(30, "byz")
+	flood.Event = eventMutated
+	records = append(records[:10], append([]*Record{flood}, records[10:]...)...)
+	records = append(records, record(22, "v1"))
+	writeCapture(t, path, records)
+
+	collect := func(f Filter) []*Record {
+		t.Helper()
+		q, err := Query(path, f)
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		defer q.Close()
+		var out []*Record
+		for {
+			rec, err := q.Next()
+			if err == io.EOF {
+				return out
+			}
+			if err != nil {
+				t.Fatalf("next: %v", err)
+			}
+			out = append(out, rec)
+		}
+	}
+	from, to := int64(20), int64(30)
+
+	got := collect(Filter{FromHeight: &from, ToHeight: &to, Validators: []string{"v1", "byz"}})
+	if len(got) != 13 || got[0].Canonical.Validator != "byz" || got[12].Height() != 22 {
+		t.Fatalf("expected the flood copy, eleven v1 records, and the replayed one, got %d records", len(got))
+	}
+	// With slack the query ends shortly after the range and misses the late replay.
+	if got := collect(Filter{FromHeight: &from, ToHeight: &to, Validators: []string{"v1"}, Slack: 4}); len(got) != 11 {
+		t.Fatalf("expected slack to end the query before the replay, got %d records", len(got))
+	}
+	if got := collect(Filter{OnlyMutated: true}); len(got) != 1 || got[0].Canonical.Validator != "byz" {
+		t.Fatalf("expected only the mutated record, got %d", len(got))
+	}
+	if got := collect(Filter{Types: []abstraction.MsgType{abstraction.MsgTypePrecommit}}); len(got) != 0 {
+		t.Fatalf("expected no precommits, got %d", len(got))
+	}
+	above := int64(51)
+	if got := collect(Filter{FromHeight: &above}); len(got) != 0 {
+		t.Fatalf("expected nothing above the capture, got %d", len(got))
+	}
+	if got := collect(Filter{Chains: []string{""}}); len(got) != len(records) {
+		t.Fatalf("expected every record on the unnamed chain, got %d of %d", len(got), len(records))
+	}
+}
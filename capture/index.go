@@ -0,0 +1,177 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// indexMagic starts every index file. The layout after it is big-endian: the number of capture bytes covered
+// (int64), the entry count (uint64), then (height int64, offset int64) pairs sorted by height.
+var indexMagic = [8]byte{'B', 'Z', 'C', 'A', 'P', 'I', 'X', '1'}
+
+// ErrHeightNotFound is returned by SeekHeight when the capture holds no record at or above the requested height.
+var ErrHeightNotFound = errors.New("height not found in capture")
+
+// IndexPath returns the index location for a capture file.
+func IndexPath(capturePath string) string {
+	return capturePath + ".idx"
+}
+
+// index maps each height to the offset of the first record carrying it. Captures are mostly ordered by height,
+// but flood and replay traffic can reach a height early; the first occurrence is what the index remembers.
+type index struct {
+	size    int64
+	first   map[int64]int64
+	heights []int64
+}
+
+type indexEntry struct {
+	Height int64
+	Offset int64
+}
+
+func newIndex() *index {
+	return &index{first: make(map[int64]int64)}
+}
+
+func (ix *index) observe(height, offset int64) {
+	if height < 0 {
+		return
+	}
+	if _, ok := ix.first[height]; !ok {
+		ix.first[height] = offset
+		ix.heights = nil
+	}
+}
+
+func (ix *index) sorted() []int64 {
+	if ix.heights == nil {
+		ix.heights = make([]int64, 0, len(ix.first))
+		for h := range ix.first {
+			ix.heights = append(ix.heights, h)
+		}
+		sort.Slice(ix.heights, func(i, j int) bool { return ix.heights[i] < ix.heights[j] })
+	}
+	return ix.heights
+}
+
+// lookup returns the offset for height, or for the next higher indexed height when height itself is absent.
+func (ix *index) lookup(height int64) (int64, bool) {
+	heights := ix.sorted()
+	i := sort.Search(len(heights), func(i int) bool { return heights[i] >= height })
+	if i == len(heights) {
+		return 0, false
+	}
+	return ix.first[heights[i]], true
+}
+
+// firstIn returns the lowest offset of any height from from to to. Flood traffic can reach a height before
+// lower ones, so the lowest height in the range does not always come first.
+func (ix *index) firstIn(from, to int64) (int64, bool) {
+	heights := ix.sorted()
+	i := sort.Search(len(heights), func(i int) bool { return heights[i] >= from })
+	offset, found := int64(0), false
+	for ; i < len(heights) && heights[i] <= to; i++ {
+		if first := ix.first[heights[i]]; !found || first < offset {
+			offset, found = first, true
+		}
+	}
+	return offset, found
+}
+
+func (ix *index) writeFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create capture index: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	heights := ix.sorted()
+	w.Write(indexMagic[:])
+	binary.Write(w, binary.BigEndian, ix.size)
+	binary.Write(w, binary.BigEndian, uint64(len(heights)))
+	for _, h := range heights {
+		binary.Write(w, binary.BigEndian, indexEntry{Height: h, Offset: ix.first[h]})
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write capture index: %w", err)
+	}
+	return f.Close()
+}
+
+func readIndex(path string) (*index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	var magic [8]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil || magic != indexMagic {
+		return nil, fmt.Errorf("%s is not a capture index", path)
+	}
+	ix := newIndex()
+	var count uint64
+	if err := binary.Read(r, binary.BigEndian, &ix.size); err != nil {
+		return nil, fmt.Errorf("truncated capture index: %w", err)
+	}
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return nil, fmt.Errorf("truncated capture index: %w", err)
+	}
+	for i := uint64(0); i < count; i++ {
+		var entry indexEntry
+		if err := binary.Read(r, binary.BigEndian, &entry); err != nil {
+			return nil, fmt.Errorf("truncated capture index: %w", err)
+		}
+		ix.first[entry.Height] = entry.Offset
+	}
+	return ix, nil
+}
+
+// scan extends the index with the records stored after ix.size. A trailing partial line, as left by a
+// capture that is still being written, is not indexed.
+func (ix *index) scan(f *os.File) error {
+	if _, err := f.Seek(ix.size, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReaderSize(f, 1<<16)
+	offset := ix.size
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("corrupt capture record at offset %d: %w", offset, err)
+		}
+		ix.observe(rec.Height(), offset)
+		offset += int64(len(line))
+	}
+	ix.size = offset
+	return nil
+}
+
+// BuildIndex rebuilds the index of an existing capture, for example one whose writer did not shut down cleanly.
+func BuildIndex(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	ix := newIndex()
+	if err := ix.scan(f); err != nil {
+		return err
+	}
+	return ix.writeFile(IndexPath(path))
+}
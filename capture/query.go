@@ -0,0 +1,136 @@
+package capture
+
+import (
+	"io"
+	"math"
+	"slices"
+
+	"codec/message/abstraction"
+)
+
+// Filter selects the records Query returns. Zero fields match every record, and a record must match every
+// field that is set.
+type Filter struct {
+	// Chains matches the canonical chain ID.
+	Chains []string
+	// FromHeight and ToHeight bound the height, inclusively. Records without a height are left out when
+	// either is set.
+	FromHeight *int64
+	ToHeight   *int64
+	Types      []abstraction.MsgType
+	// Validators matches the validator of a vote, or the proposer of a message without one.
+	Validators []string
+	// OnlyMutated keeps the records of messages a byzantine proxy sent in place of what it received.
+	OnlyMutated bool
+	// Slack ends a query with ToHeight after this many consecutive records above it, rather than reading to
+	// the end of the capture; stray future-height copies then do not end it early. Zero reads to the end, which
+	// also finds old heights replayed late in an experiment.
+	Slack int
+}
+
+// Matches reports whether rec passes the filter.
+func (f Filter) Matches(rec *Record) bool {
+	msg := rec.Canonical
+	if msg == nil {
+		return false
+	}
+	if len(f.Chains) > 0 && !slices.Contains(f.Chains, msg.ChainID) {
+		return false
+	}
+	if f.FromHeight != nil || f.ToHeight != nil {
+		height := rec.Height()
+		if height < 0 || (f.FromHeight != nil && height < *f.FromHeight) || (f.ToHeight != nil && height > *f.ToHeight) {
+			return false
+		}
+	}
+	if len(f.Types) > 0 && !slices.Contains(f.Types, msg.Type) {
+		return false
+	}
+	if len(f.Validators) > 0 {
+		validator := msg.Validator
+		if validator == "" {
+			validator = msg.Proposer
+		}
+		if !slices.Contains(f.Validators, validator) {
+			return false
+		}
+	}
+	return !f.OnlyMutated || rec.Event == eventMutated
+}
+
+// Results streams the records of a query, reading the capture one record at a time.
+type Results struct {
+	r      *Reader
+	filter Filter
+	above  int
+	done   bool
+}
+
+// Query opens the capture at path and returns its records that match f, in file order. With a height range,
+// the index moves the reader straight to the first record in the range. The caller closes the results.
+func Query(path string, f Filter) (*Results, error) {
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	q, err := r.Query(f)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// Query returns the records of r that match f, starting from r's current position or, with a height range,
+// from the first record in the range. Closing the results closes r.
+func (r *Reader) Query(f Filter) (*Results, error) {
+	q := &Results{r: r, filter: f}
+	if f.FromHeight == nil && f.ToHeight == nil {
+		return q, nil
+	}
+	from, to := int64(0), int64(math.MaxInt64)
+	if f.FromHeight != nil {
+		from = *f.FromHeight
+	}
+	if f.ToHeight != nil {
+		to = *f.ToHeight
+	}
+	offset, ok := r.index.firstIn(from, to)
+	if !ok {
+		q.done = true
+		return q, nil
+	}
+	return q, r.seekOffset(offset)
+}
+
+// Next returns the next matching record, or io.EOF once there are no more.
+func (q *Results) Next() (*Record, error) {
+	for !q.done {
+		rec, err := q.r.Next()
+		if err == io.EOF {
+			q.done = true
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if q.filter.Slack > 0 && q.filter.ToHeight != nil {
+			if rec.Height() > *q.filter.ToHeight {
+				if q.above++; q.above >= q.filter.Slack {
+					q.done = true
+				}
+				continue
+			}
+			q.above = 0
+		}
+		if q.filter.Matches(rec) {
+			return rec, nil
+		}
+	}
+	return nil, io.EOF
+}
+
+// Close closes the capture.
+func (q *Results) Close() error {
+	return q.r.Close()
+}
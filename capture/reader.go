@@ -0,0 +1,109 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Reader reads records from a capture in file order and can jump to a height with SeekHeight.
+type Reader struct {
+	file   *os.File
+	buf    *bufio.Reader
+	offset int64
+	index  *index
+}
+
+// Open opens a capture for reading. The index is loaded from <path>.idx and brought up to date with any
+// records appended since it was written; a missing or unreadable index is rebuilt in memory by scanning.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	ix, err := readIndex(IndexPath(path))
+	if err != nil || ix.size > info.Size() {
+		ix = newIndex()
+	}
+	if ix.size < info.Size() {
+		if err := ix.scan(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	r := &Reader{file: f, index: ix}
+	if err := r.seekOffset(0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Next returns the next record, or io.EOF at the end of the capture.
+func (r *Reader) Next() (*Record, error) {
+	line, err := r.buf.ReadBytes('\n')
+	if err == io.EOF {
+		// A trailing partial line belongs to a record that is still being written.
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rec Record
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return nil, fmt.Errorf("corrupt capture record at offset %d: %w", r.offset, err)
+	}
+	r.offset += int64(len(line))
+	return &rec, nil
+}
+
+// SeekHeight positions the reader at the first record carrying height, or at the first record of the next higher
+// height in the capture when height itself was never seen.
+func (r *Reader) SeekHeight(height int64) error {
+	offset, ok := r.index.lookup(height)
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrHeightNotFound, height)
+	}
+	return r.seekOffset(offset)
+}
+
+// Rewind positions the reader at the start of the capture.
+func (r *Reader) Rewind() error {
+	return r.seekOffset(0)
+}
+
+// Heights returns the lowest and highest indexed heights; ok is false for a capture without heights.
+func (r *Reader) Heights() (lowest, highest int64, ok bool) {
+	heights := r.index.sorted()
+	if len(heights) == 0 {
+		return 0, 0, false
+	}
+	return heights[0], heights[len(heights)-1], true
+}
+
+// Close closes the capture file.
+func (r *Reader) Close() error {
+	return r.file.Close()
+}
+
+func (r *Reader) seekOffset(offset int64) error {
+	if _, err := r.file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	r.offset = offset
+	if r.buf == nil {
+		r.buf = bufio.NewReaderSize(r.file, 1<<16)
+	} else {
+		r.buf.Reset(r.file)
+	}
+	return nil
+}
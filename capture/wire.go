@@ -0,0 +1,49 @@
+package capture
+
+import "codec/message/abstraction"
+
+// Record events written by byzproxy's recorder; see proxy/engine.
+const (
+	eventReceived = "received"
+	eventMutated  = "mutated"
+	eventDropped  = "dropped"
+)
+
+// OnWire returns the canonical messages of the records that reached a peer. Records from a
+// byzantine proxy are followed, from the same source and direction, by the mutated or dropped records of
+// what the proxy did with what it received; a received record followed by none of those was relayed as is.
+// Records of any other capture are all returned.
+func OnWire(records []*Record) []*abstraction.CanonicalMessage {
+	type flow struct{ source, direction string }
+	pending := map[flow]*abstraction.CanonicalMessage{}
+	var order []flow
+	var msgs []*abstraction.CanonicalMessage
+	flush := func(f flow) {
+		if msg := pending[f]; msg != nil {
+			msgs = append(msgs, msg)
+		}
+		delete(pending, f)
+	}
+	for _, rec := range records {
+		f := flow{rec.Source, rec.Direction}
+		switch rec.Event {
+		case eventReceived:
+			flush(f)
+			pending[f] = rec.Canonical
+			order = append(order, f)
+		case eventMutated, eventDropped:
+			delete(pending, f)
+			if rec.Event == eventMutated && rec.Canonical != nil {
+				msgs = append(msgs, rec.Canonical)
+			}
+		default:
+			if rec.Canonical != nil {
+				msgs = append(msgs, rec.Canonical)
+			}
+		}
+	}
+	for _, f := range order {
+		flush(f)
+	}
+	return msgs
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"codec/message/abstraction/byzantine"
+	"codec/proxy/besu"
+	"codec/proxy/devp2p"
+	"codec/proxy/engine"
+)
+
+func main() {
+	var (
+		listenAddr        = flag.String("listen", "0.0.0.0:30303", "address to accept external peers (host:port)")
+		upstreamNode      = flag.String("upstream", "", "enode URL of the upstream Besu validator (enode://<public key>@host:port)")
+		nodeKeyPath       = flag.String("node-key", "", "hex secp256k1 key the proxy presents to the validator and, without --listen-key, to the peers")
+		listenKeyPath     = flag.String("listen-key", "", "optional hex secp256k1 key presented to the downstream peers, usually the validator's own node key")
+		validatorKeyPath  = flag.String("validator-key", "", "hex secp256k1 key used to re-sign mutated messages")
+		chainID           = flag.String("chain-id", "besu-chain", "chain identifier used for canonical mapping")
+		attack            = flag.String("attack", string(byzantine.ActionNone), "byzantine action to apply")
+		triggerHeight     = flag.Int64("trigger-height", 0, "height at which mutations activate (0 disables)")
+		triggerRound      = flag.Int64("trigger-round", 0, "round at which mutations activate (0 disables)")
+		triggerStep       = flag.String("trigger-step", "", "canonical message type (proposal|prepare|commit|round_change) required for mutation")
+		triggerValidators = flag.String("trigger-validators", "", "comma separated hex addresses of the validators whose messages are attacked")
+		triggerProb       = flag.Float64("trigger-prob", 0, "probability that a matching message triggers the attack (0 disables sampling)")
+		triggerEvery      = flag.Int("trigger-every", 0, "trigger on every Nth matching message only (0 or 1 triggers on all)")
+		triggerSeed       = flag.Int64("trigger-seed", 0, "seed for --trigger-prob; 0 picks one from the clock and logs it")
+		delayDur          = flag.Duration("delay", 0, "delay applied to triggered messages before forwarding")
+		dropMessages      = flag.Bool("drop", false, "drop triggered messages instead of forwarding")
+		duplicate         = flag.Bool("duplicate", false, "duplicate triggered messages after mutation")
+		emitBoth          = flag.Bool("emit-both", false, "nil_flip: forward the original vote as well as the flipped one")
+		floodRange        = flag.String("flood-range", "", "height offsets for height_flood as N..M (negative for stale heights)")
+		fuzzSeed          = flag.Int64("fuzz-seed", 0, "fuzz_payload: seed for payload damage; the same seed and traffic reproduce the same messages")
+		params            = flag.String("params", "", "chain-specific action parameters as key=value pairs")
+		alternateBlock    = flag.String("alternate-block", "", "alternate block hash used during mutation")
+		alternateSig      = flag.String("alternate-signature", "", "alternate signature for forged messages")
+		roundOffset       = flag.Int64("round-offset", 0, "offset applied to canonical round when mutating")
+		heightOffset      = flag.Int64("height-offset", 0, "offset applied to canonical height when mutating")
+		dialTimeout       = flag.Duration("dial-timeout", 5*time.Second, "timeout used for the RLPx handshakes and dialing the upstream validator")
+		metricsListen     = flag.String("metrics-listen", "", "optional HTTP address serving the Prometheus /metrics endpoint")
+		mutateDir         = flag.String("mutate-direction", "upstream", "direction to apply mutations (upstream|downstream|both)")
+	)
+
+	flag.Parse()
+
+	if strings.TrimSpace(*nodeKeyPath) == "" {
+		fmt.Fprintln(os.Stderr, "--node-key is required")
+		os.Exit(1)
+	}
+	nodeKey := loadKey("node key", *nodeKeyPath)
+	listenKey := loadKey("listen key", *listenKeyPath)
+	validatorKey := loadKey("validator key", *validatorKeyPath)
+
+	byzAction, err := besu.ParseByzantineAction(*attack)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid attack type: %v\n", err)
+		os.Exit(1)
+	}
+
+	actionParams, err := byzantine.ParseParams(*params)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid params: %v\n", err)
+		os.Exit(1)
+	}
+
+	var floodFrom, floodTo int64
+	if strings.TrimSpace(*floodRange) != "" {
+		floodFrom, floodTo, err = byzantine.ParseRange(*floodRange)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid flood range: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	opts := byzantine.Options{
+		AlternateBlockHash: *alternateBlock,
+		AlternateSignature: *alternateSig,
+		RoundOffset:        *roundOffset,
+		HeightOffset:       *heightOffset,
+		EmitBoth:           *emitBoth,
+		FloodFrom:          floodFrom,
+		FloodTo:            floodTo,
+		FuzzSeed:           *fuzzSeed,
+		Params:             actionParams,
+	}
+
+	trigger := engine.Trigger{}
+	if *triggerHeight > 0 {
+		trigger.Height = triggerHeight
+	}
+	if *triggerRound > 0 {
+		trigger.Round = triggerRound
+	}
+	if step := strings.TrimSpace(*triggerStep); step != "" {
+		trigger.Step = strings.ToLower(step)
+	}
+	for _, validator := range strings.Split(*triggerValidators, ",") {
+		if validator = strings.TrimSpace(validator); validator != "" {
+			trigger.Validators = append(trigger.Validators, validator)
+		}
+	}
+	trigger.Every = *triggerEvery
+	trigger.Probability = *triggerProb
+
+	direction, err := engine.ParseDirection(*mutateDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid mutate direction: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	cfg, err := besu.NewConfig(besu.ConfigOptions{
+		ListenAddress: *listenAddr,
+		Upstream:      *upstreamNode,
+		ChainID:       *chainID,
+		NodeKey:       nodeKey,
+		ListenKey:     listenKey,
+		ValidatorKey:  validatorKey,
+		Action:        byzAction,
+		Options:       opts,
+		Trigger:       trigger,
+		Hooks:         devp2p.Hooks{Delay: *delayDur, Drop: *dropMessages, Duplicate: *duplicate},
+		Direction:     direction,
+		DialTimeout:   *dialTimeout,
+		Logger:        logger,
+		TriggerSeed:   *triggerSeed,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to build config: %v\n", err)
+		os.Exit(1)
+	}
+
+	eng, err := besu.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start proxy: %v\n", err)
+		os.Exit(1)
+	}
+	logger.Info("trigger seed", "seed", cfg.TriggerSeed)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if addr := strings.TrimSpace(*metricsListen); addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", eng.Metrics().Handler())
+		srv := &http.Server{Addr: addr, Handler: mux}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("metrics listener stopped", "err", err)
+			}
+		}()
+		logger.Info("metrics listening", "address", addr)
+		defer srv.Close()
+	}
+
+	if err := eng.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintf(os.Stderr, "proxy exited with error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// loadKey reads an optional key file and exits when it cannot be read.
+func loadKey(name, path string) *ecdsa.PrivateKey {
+	if strings.TrimSpace(path) == "" {
+		return nil
+	}
+	key, err := devp2p.LoadNodeKey(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load %s: %v\n", name, err)
+		os.Exit(1)
+	}
+	return key
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"codec/experiment"
+	"codec/message/abstraction/byzantine"
+)
+
+// indexName is the file in the output directory that labels every (input, action) combination.
+const indexName = "index.jsonl"
+
+// indexEntry labels one output of a batch run. Output is relative to the output directory and is empty when
+// the action does not apply to the input, in which case Error says why.
+type indexEntry struct {
+	Input    string `json:"input"`
+	Chain    string `json:"chain"`
+	Action   string `json:"action"`
+	Output   string `json:"output,omitempty"`
+	Messages int    `json:"messages"`
+	Error    string `json:"error,omitempty"`
+}
+
+// batch forges every input with every action into an output tree.
+type batch struct {
+	chain string
+	forge forgeFunc
+	opts  byzantine.Options
+	dir   string
+	meter *experiment.ComponentMeter
+
+	outputs  map[string]bool
+	messages int
+	skipped  int
+}
+
+// run writes <dir>/<action>/<input name>.json for each combination, then the index. A combination the action
+// rejects, such as a double vote of a proposal, is skipped and recorded in the index; anything else stops the
+// run.
+func (b *batch) run(inputs []string, actions []byzantine.Action) error {
+	names := make(map[string]string, len(inputs))
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("%s and %s would write the same output name %q", other, input, name)
+		}
+		names[name] = input
+	}
+
+	var index []indexEntry
+	for _, input := range inputs {
+		canonical, err := loadCanonical(input)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", input, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+		for _, action := range actions {
+			entry := indexEntry{Input: input, Chain: b.chain, Action: string(action)}
+			// Each action gets its own copy, so no action sees another's changes.
+			outputs, err := forgeOutputs(b.forge, b.meter, byzantine.Clone(canonical), string(action), b.opts)
+			if err != nil {
+				entry.Error = err.Error()
+				b.skipped++
+				index = append(index, entry)
+				continue
+			}
+			data, err := json.MarshalIndent(outputs, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode output: %w", err)
+			}
+			entry.Output = filepath.ToSlash(filepath.Join(string(action), name+".json"))
+			path := filepath.Join(b.dir, entry.Output)
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				return fmt.Errorf("failed to write output file: %w", err)
+			}
+			b.outputs[path] = true
+			entry.Messages = len(outputs)
+			b.messages += len(outputs)
+			index = append(index, entry)
+		}
+	}
+	return b.writeIndex(index)
+}
+
+func (b *batch) writeIndex(index []indexEntry) error {
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(b.dir, indexName))
+	if err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	encoder := json.NewEncoder(f)
+	for _, entry := range index {
+		if err := encoder.Encode(entry); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write index: %w", err)
+		}
+	}
+	return f.Close()
+}
+
+// artifacts lists the files the run wrote, index first, for the manifest.
+func (b *batch) artifacts() []string {
+	paths := make([]string, 0, len(b.outputs))
+	for path := range b.outputs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return append([]string{filepath.Join(b.dir, indexName)}, paths...)
+}
+
+// expandInputs resolves -input to canonical message files: the file itself, the .json files of a directory,
+// or the matches of a glob, in sorted order.
+func expandInputs(input string) ([]string, error) {
+	input = strings.TrimSpace(input)
+	if info, err := os.Stat(input); err == nil {
+		if !info.IsDir() {
+			return []string{input}, nil
+		}
+		entries, err := os.ReadDir(input)
+		if err != nil {
+			return nil, err
+		}
+		var files []string
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".json") {
+				files = append(files, filepath.Join(input, entry.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no .json files in %s", input)
+		}
+		return files, nil
+	}
+	matches, err := filepath.Glob(input)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && !info.IsDir() {
+			files = append(files, match)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s matches no files", input)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// parseActions resolves the actions of a batch run: the -actions list, every action of the engine for all,
+// or the single -action.
+func parseActions(engine *byzantine.Engine, action, list string) ([]byzantine.Action, error) {
+	list = strings.TrimSpace(list)
+	if strings.EqualFold(list, "all") {
+		return engine.Actions(), nil
+	}
+	if list == "" {
+		list = action
+	}
+	var actions []byzantine.Action
+	seen := make(map[byzantine.Action]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		parsed, err := engine.Parse(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[parsed] {
+			seen[parsed] = true
+			actions = append(actions, parsed)
+		}
+	}
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("no actions given")
+	}
+	return actions, nil
+}
@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	_ "codec/adapters"
 	cometbftAdapter "codec/cometbft/adapter"
+	ethereumAdapter "codec/ethereum/adapter"
+	"codec/experiment"
+	besuAdapter "codec/hyperledger/besu/adapter"
+	fabricAdapter "codec/hyperledger/fabric/adapter"
+	kaiaAdapter "codec/kaia/adapter"
 	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
 )
 
 type outputMessage struct {
@@ -27,9 +36,13 @@ type pipelineOutput struct {
 	Raw       outputMessage                 `json:"byz_comet"`
 }
 
+// forgeFunc applies a byzantine action and returns the canonical messages paired with their encoded form.
+type forgeFunc func(msg *abstraction.CanonicalMessage, action string, opts byzantine.Options) ([]*abstraction.CanonicalMessage, []*abstraction.RawConsensusMessage, error)
+
 func main() {
-	inputPath := flag.String("input", "", "Path to a canonical message JSON file")
-	actionFlag := flag.String("action", string(cometbftAdapter.ByzantineActionDoubleVote), "Byzantine action to apply (double_vote|double_proposal|alter_validator|drop_signature|timestamp_skew|none)")
+	inputPath := flag.String("input", "", "Canonical message JSON file, or a directory or glob of them for a batch run")
+	chain := flag.String("chain", string(abstraction.ChainTypeCometBFT), "Target chain adapter (cometbft|besu|kaia|fabric|fabric-raft|ethereum)")
+	actionFlag := flag.String("action", string(byzantine.ActionDoubleVote), "Byzantine action to apply (double_vote|double_proposal|alter_validator|drop_signature|timestamp_skew|nil_flip|height_flood|fuzz_payload|amnesia|withhold_commit|corrupt_extension|none; amnesia, withhold_commit and corrupt_extension are cometbft only; fabric replaces alter_validator with forge_identity and adds drop_config_seq; besu has no alter_validator, drop_signature or timestamp_skew)")
 	chainID := flag.String("chain-id", "cosmos-hub-4", "Chain identifier used when re-encoding the message")
 	alternateBlock := flag.String("alternate-block", "", "Alternate block hash to use for the forged message")
 	alternatePrev := flag.String("alternate-prev-hash", "", "Alternate previous block hash (used for proposals)")
@@ -38,29 +51,72 @@ func main() {
 	roundOffset := flag.Int("round-offset", 0, "Offset (positive or negative) applied to the canonical round")
 	heightOffset := flag.Int("height-offset", 0, "Offset (positive or negative) applied to the canonical height")
 	timestampSkew := flag.Duration("timestamp-skew", 0, "Duration added to canonical timestamps when mutating messages")
-	outputPath := flag.String("output", "", "Optional path to write the resulting CometBFT messages as JSON")
+	emitBoth := flag.Bool("emit-both", false, "Emit the original vote ahead of the flipped one for nil_flip")
+	floodRange := flag.String("flood-range", "", "Height offsets for height_flood as N..M, e.g. 5..10 for future or -10..-1 for stale heights")
+	fuzzSeed := flag.Int64("fuzz-seed", 0, "Seed for fuzz_payload; the same seed and input reproduce the same damaged payload")
+	paramsFlag := flag.String("params", "", "Chain-specific action parameters as key=value pairs, e.g. extension_mode=both for corrupt_extension")
+	actionsFlag := flag.String("actions", "", "Comma-separated actions for a batch run, each applied to every input, or all for every action the chain supports; replaces -action")
+	outputPath := flag.String("output", "", "Optional path to write the resulting chain messages as JSON")
+	outputDir := flag.String("output-dir", "", "Directory for a batch run: writes <dir>/<action>/<input name>.json for every input and action, and an index.jsonl labelling each")
+	privvalKey := flag.String("privval-key", "", "Optional CometBFT priv_validator_key.json used to re-sign forged votes and proposals")
+	manifestPath := flag.String("manifest", "", "Optional path to write a run manifest with resource usage")
+	signKey := flag.String("sign-key", "", "Optional lab secret key used to sign the output and manifest for publication (requires -output or -output-dir, and -manifest)")
+	seed := flag.Int64("seed", 0, "Seed for timestamps and random draws so the run replays exactly; also the fuzz seed unless -fuzz-seed is set (0 uses the clock)")
 	flag.Parse()
 
+	if *seed != 0 {
+		abstraction.Seed(*seed)
+		if *fuzzSeed == 0 {
+			*fuzzSeed = *seed
+		}
+	}
+
+	manifest := experiment.NewManifest("byzantine")
+	resources := experiment.NewResourceAccountant(100 * time.Millisecond)
+	resources.Start(context.Background())
+
 	if strings.TrimSpace(*inputPath) == "" {
 		fmt.Fprintln(os.Stderr, "input path is required")
 		os.Exit(1)
 	}
 
-	canonical, err := loadCanonical(*inputPath)
+	var labKey *experiment.SigningKey
+	if strings.TrimSpace(*signKey) != "" {
+		if strings.TrimSpace(*outputPath) == "" && strings.TrimSpace(*outputDir) == "" || strings.TrimSpace(*manifestPath) == "" {
+			fmt.Fprintln(os.Stderr, "-sign-key requires -output or -output-dir, and -manifest")
+			os.Exit(1)
+		}
+		key, err := experiment.LoadSigningKey(*signKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load signing key: %v\n", err)
+			os.Exit(1)
+		}
+		labKey = key
+	}
+
+	engine, mapper, err := chainAdapter(*chain, *chainID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to load canonical message: %v\n", err)
+		fmt.Fprintf(os.Stderr, "invalid chain: %v\n", err)
 		os.Exit(1)
 	}
+	forge := forger(engine, mapper)
 
-	mapper := cometbftAdapter.NewCometBFTMapper(*chainID)
-
-	action, err := cometbftAdapter.ParseByzantineAction(*actionFlag)
+	params, err := byzantine.ParseParams(*paramsFlag)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "invalid byzantine action: %v\n", err)
+		fmt.Fprintf(os.Stderr, "invalid params: %v\n", err)
 		os.Exit(1)
 	}
 
-	opts := cometbftAdapter.ByzantineOptions{
+	var floodFrom, floodTo int64
+	if strings.TrimSpace(*floodRange) != "" {
+		floodFrom, floodTo, err = byzantine.ParseRange(*floodRange)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid flood range: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	opts := byzantine.Options{
 		AlternateBlockHash: *alternateBlock,
 		AlternatePrevHash:  *alternatePrev,
 		AlternateSignature: *alternateSig,
@@ -68,22 +124,232 @@ func main() {
 		RoundOffset:        int64(*roundOffset),
 		HeightOffset:       int64(*heightOffset),
 		TimestampShift:     *timestampSkew,
+		EmitBoth:           *emitBoth,
+		FloodFrom:          floodFrom,
+		FloodTo:            floodTo,
+		FuzzSeed:           *fuzzSeed,
+		Params:             params,
+	}
+	if strings.TrimSpace(*privvalKey) != "" {
+		if abstraction.ChainType(strings.ToLower(strings.TrimSpace(*chain))) != abstraction.ChainTypeCometBFT {
+			fmt.Fprintln(os.Stderr, "-privval-key is only supported for the cometbft chain")
+			os.Exit(1)
+		}
+		signer, err := cometbftAdapter.LoadPrivValSigner(*chainID, *privvalKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load privval key: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Signer = signer
+	}
+
+	if strings.TrimSpace(*outputDir) != "" {
+		if strings.TrimSpace(*outputPath) != "" {
+			fmt.Fprintln(os.Stderr, "-output and -output-dir are exclusive")
+			os.Exit(1)
+		}
+		inputs, err := expandInputs(*inputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid input: %v\n", err)
+			os.Exit(1)
+		}
+		actions, err := parseActions(engine, *actionFlag, *actionsFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid actions: %v\n", err)
+			os.Exit(1)
+		}
+		b := &batch{
+			chain:   *chain,
+			forge:   forge,
+			opts:    opts,
+			dir:     *outputDir,
+			meter:   resources.Component("mutation"),
+			outputs: make(map[string]bool),
+		}
+		if err := b.run(inputs, actions); err != nil {
+			fmt.Fprintf(os.Stderr, "batch failed: %v\n", err)
+			os.Exit(1)
+		}
+		if strings.TrimSpace(*manifestPath) != "" {
+			manifest.SetParameter("chain", *chain)
+			manifest.SetParameter("actions", actions)
+			manifest.SetParameter("input", *inputPath)
+			manifest.SetParameter("inputs", len(inputs))
+			manifest.SetParameter("messages", b.messages)
+			manifest.SetParameter("skipped", b.skipped)
+			if *seed != 0 {
+				manifest.SetParameter("seed", *seed)
+			}
+			resources.Stop()
+			manifest.Finish(resources)
+			for _, path := range b.artifacts() {
+				name, err := filepath.Rel(filepath.Dir(*manifestPath), path)
+				if err == nil {
+					err = manifest.AddArtifact(name, path)
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "failed to record output in manifest: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			if err := manifest.WriteFile(*manifestPath); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+		}
+		if labKey != nil {
+			if err := experiment.SignDataset(labKey, *manifestPath); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to sign dataset: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		fmt.Printf("Generated %d messages from %d inputs and %d actions (%d combinations skipped) into %s\n", b.messages, len(inputs), len(actions), b.skipped, *outputDir)
+		return
+	}
+	if strings.TrimSpace(*actionsFlag) != "" {
+		fmt.Fprintln(os.Stderr, "-actions requires -output-dir")
+		os.Exit(1)
+	}
+	if info, err := os.Stat(*inputPath); err != nil && strings.ContainsAny(*inputPath, "*?[") || err == nil && info.IsDir() {
+		fmt.Fprintln(os.Stderr, "a directory or glob input requires -output-dir")
+		os.Exit(1)
+	}
+
+	canonical, err := loadCanonical(*inputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load canonical message: %v\n", err)
+		os.Exit(1)
 	}
 
-	byzCanonicals, err := cometbftAdapter.ApplyByzantineCanonical(canonical, action, opts)
+	meter := resources.Component("mutation")
+	outputs, err := forgeOutputs(forge, meter, canonical, *actionFlag, opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "conversion failed: %v\n", err)
 		os.Exit(1)
 	}
 
-	outputs := make([]pipelineOutput, len(byzCanonicals))
-	for i, byzCanonical := range byzCanonicals {
-		raw, err := mapper.FromCanonical(byzCanonical)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "failed to encode byzantine canonical message %d: %v\n", i+1, err)
+	result, err := json.MarshalIndent(outputs, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode output: %v\n", err)
+		os.Exit(1)
+	}
+
+	if strings.TrimSpace(*outputPath) != "" {
+		if err := os.WriteFile(*outputPath, result, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write output file: %v\n", err)
 			os.Exit(1)
 		}
+	}
+
+	if strings.TrimSpace(*manifestPath) != "" {
+		manifest.SetParameter("chain", *chain)
+		manifest.SetParameter("action", *actionFlag)
+		manifest.SetParameter("input", *inputPath)
+		manifest.SetParameter("messages", len(outputs))
+		if *seed != 0 {
+			manifest.SetParameter("seed", *seed)
+		}
+		resources.Stop()
+		manifest.Finish(resources)
+		if strings.TrimSpace(*outputPath) != "" {
+			name, err := filepath.Rel(filepath.Dir(*manifestPath), *outputPath)
+			if err == nil {
+				err = manifest.AddArtifact(name, *outputPath)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "failed to record output in manifest: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := manifest.WriteFile(*manifestPath); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if labKey != nil {
+		if err := experiment.SignDataset(labKey, *manifestPath); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to sign dataset: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if strings.TrimSpace(*outputPath) != "" {
+		fmt.Printf("Generated %d messages with action %s and wrote them to %s\n", len(outputs), *actionFlag, *outputPath)
+		return
+	}
+
+	fmt.Println(string(result))
+}
+
+// chainAdapter selects the byzantine engine for the requested chain and builds its mapper.
+func chainAdapter(chain, chainID string) (*byzantine.Engine, abstraction.Mapper, error) {
+	var engine *byzantine.Engine
+	chainType := abstraction.ParseChainType(chain)
+	switch chainType {
+	case abstraction.ChainTypeCometBFT:
+		engine = cometbftAdapter.ByzantineEngine.Engine
+	case abstraction.ChainTypeHyperledger:
+		engine = besuAdapter.ByzantineEngine
+	case abstraction.ChainTypeKaia:
+		engine = kaiaAdapter.ByzantineEngine
+	case abstraction.ChainTypeFabric:
+		engine = fabricAdapter.ByzantineEngine
+	case abstraction.ChainTypeFabricRaft:
+		engine = fabricAdapter.RaftByzantineEngine
+	case abstraction.ChainTypeEthereum:
+		engine = ethereumAdapter.ByzantineEngine
+	default:
+		return nil, nil, fmt.Errorf("unsupported chain %q", chain)
+	}
+	mapper, err := abstraction.NewMapper(chainType, chainID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return engine, mapper, nil
+}
+
+// forger applies the engine's actions and encodes the results with mapper.
+func forger(engine *byzantine.Engine, mapper abstraction.Mapper) forgeFunc {
+	return func(msg *abstraction.CanonicalMessage, actionName string, opts byzantine.Options) ([]*abstraction.CanonicalMessage, []*abstraction.RawConsensusMessage, error) {
+		action, err := engine.Parse(actionName)
+		if err != nil {
+			return nil, nil, err
+		}
+		canonicals, err := engine.Apply(msg, action, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		canonicals, raws, err := encodeAll(canonicals, mapper)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, raw := range raws {
+			if raw.Payload, err = engine.MutatePayload(action, raw.Payload, opts); err != nil {
+				return nil, nil, err
+			}
+		}
+		return canonicals, raws, nil
+	}
+}
 
+// forgeOutputs applies action to canonical and pairs each forged message with its encoding.
+func forgeOutputs(forge forgeFunc, meter *experiment.ComponentMeter, canonical *abstraction.CanonicalMessage, action string, opts byzantine.Options) ([]pipelineOutput, error) {
+	doneMutating := meter.Track()
+	byzCanonicals, raws, err := forge(canonical, action, opts)
+	doneMutating()
+	if err != nil {
+		return nil, err
+	}
+	forgedBytes := 0
+	for _, raw := range raws {
+		forgedBytes += len(raw.Payload)
+	}
+	meter.Observe(len(canonical.RawPayload), forgedBytes)
+
+	outputs := make([]pipelineOutput, len(byzCanonicals))
+	for i, byzCanonical := range byzCanonicals {
+		raw := raws[i]
 		outputs[i] = pipelineOutput{
 			Canonical: byzCanonical,
 			Raw: outputMessage{
@@ -92,28 +358,33 @@ func main() {
 				MessageType: raw.MessageType,
 				Encoding:    raw.Encoding,
 				Timestamp:   raw.Timestamp.Format(time.RFC3339Nano),
-				Payload:     json.RawMessage(raw.Payload),
+				Payload:     payloadJSON(raw.Payload),
 				Metadata:    raw.Metadata,
 			},
 		}
 	}
+	return outputs, nil
+}
 
-	result, err := json.MarshalIndent(outputs, "", "  ")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to encode output: %v\n", err)
-		os.Exit(1)
-	}
-
-	if strings.TrimSpace(*outputPath) != "" {
-		if err := os.WriteFile(*outputPath, result, 0o644); err != nil {
-			fmt.Fprintf(os.Stderr, "failed to write output file: %v\n", err)
-			os.Exit(1)
+func encodeAll(canonicals []*abstraction.CanonicalMessage, mapper abstraction.Mapper) ([]*abstraction.CanonicalMessage, []*abstraction.RawConsensusMessage, error) {
+	raws := make([]*abstraction.RawConsensusMessage, len(canonicals))
+	for i, canonical := range canonicals {
+		raw, err := mapper.FromCanonical(canonical)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode byzantine canonical message %d: %w", i+1, err)
 		}
-		fmt.Printf("Generated %d messages with action %s and wrote them to %s\n", len(outputs), action, *outputPath)
-		return
+		raws[i] = raw
 	}
+	return canonicals, raws, nil
+}
 
-	fmt.Println(string(result))
+// payloadJSON embeds JSON payloads as-is and anything else, such as fuzzed bytes, as a base64 string.
+func payloadJSON(payload []byte) json.RawMessage {
+	if json.Valid(payload) {
+		return json.RawMessage(payload)
+	}
+	encoded, _ := json.Marshal(payload)
+	return encoded
 }
 
 func loadCanonical(path string) (*abstraction.CanonicalMessage, error) {
@@ -126,7 +397,7 @@ func loadCanonical(path string) (*abstraction.CanonicalMessage, error) {
 		return nil, err
 	}
 	if canonical.Timestamp.IsZero() {
-		canonical.Timestamp = time.Now().UTC()
+		canonical.Timestamp = abstraction.Now().UTC()
 	}
 	return &canonical, nil
 }
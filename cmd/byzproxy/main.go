@@ -5,14 +5,19 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
 	"time"
 
+	"codec/cmd/internal/cli"
 	cometbftAdapter "codec/cometbft/adapter"
+	"codec/experiment"
+	"codec/message/abstraction/byzantine"
 	"codec/proxy/engine"
 
 	"github.com/cometbft/cometbft/p2p"
@@ -28,18 +33,58 @@ func main() {
 		triggerHeight      = flag.Int64("trigger-height", 0, "height at which mutations activate (0 disables)")
 		triggerRound       = flag.Int64("trigger-round", 0, "round at which mutations activate (0 disables)")
 		triggerStep        = flag.String("trigger-step", "", "canonical message type (proposal|prevote|precommit) required for mutation")
+		triggerValidators  = flag.String("trigger-validators", "", "comma separated hex addresses or indexes of the validators whose messages are attacked")
+		triggerProb        = flag.Float64("trigger-prob", 0, "probability that a matching message triggers the attack (0 disables sampling)")
+		triggerEvery       = flag.Int("trigger-every", 0, "trigger on every Nth matching message only (0 or 1 triggers on all)")
+		triggerSeed        = flag.Int64("trigger-seed", 0, "seed for --trigger-prob; 0 picks one from the clock and logs it")
 		delayDur           = flag.Duration("delay", 0, "delay applied to triggered messages before forwarding")
+		validatorDelay     = flag.String("validator-delay", "", "per-validator vote delays by index, e.g. even=500ms,odd=0,3=1s")
+		victimSkew         = flag.String("victim-clock-skew", "", "shift timestamps of consensus messages delivered to victims, e.g. validator=-2s,<node-id>=+500ms")
 		dropMessages       = flag.Bool("drop", false, "drop triggered messages instead of forwarding")
 		duplicate          = flag.Bool("duplicate", false, "duplicate triggered messages after mutation")
+		emitBoth           = flag.Bool("emit-both", false, "nil_flip: forward the original vote as well as the flipped one")
+		floodRange         = flag.String("flood-range", "", "height offsets for height_flood as N..M (negative for stale heights)")
+		fuzzSeed           = flag.Int64("fuzz-seed", 0, "fuzz_payload: seed for payload damage; the same seed and traffic reproduce the same frames")
+		params             = flag.String("params", "", "chain-specific action parameters as key=value pairs, e.g. extension_mode=signature")
+		targetPeers        = flag.String("target-peers", "", "comma separated node IDs or addresses that receive mutated messages; other peers keep receiving the originals")
+		splitPeers         = flag.Bool("split-peers", false, "send each mutated variant to a different peer instead of all variants to every peer")
 		alternateBlock     = flag.String("alternate-block", "", "alternate block hash used during mutation")
 		alternatePrev      = flag.String("alternate-prev-hash", "", "alternate previous block hash used during mutation")
 		alternateSig       = flag.String("alternate-signature", "", "alternate signature for forged messages")
 		alternateValidator = flag.String("alternate-validator", "", "alternate validator/proposer identifier")
+		privvalKey         = flag.String("privval-key", "", "priv_validator_key.json used to re-sign mutated votes and proposals")
 		roundOffset        = flag.Int64("round-offset", 0, "offset applied to canonical round when mutating")
 		heightOffset       = flag.Int64("height-offset", 0, "offset applied to canonical height when mutating")
 		timestampShift     = flag.Duration("timestamp-skew", 0, "duration applied to canonical timestamps when mutating")
 		dialTimeout        = flag.Duration("dial-timeout", 5*time.Second, "timeout used when dialing the upstream validator")
+		upstreamFailover   = flag.String("upstream-failover", "", "comma separated validators to dial, in order, when the upstream does not answer")
+		reconnect          = flag.Bool("reconnect", false, "redial a lost upstream with backoff instead of disconnecting the downstream peers")
+		reconnectBackoff   = flag.Duration("reconnect-backoff", 500*time.Millisecond, "wait after the first failed reconnect attempt; doubles after each further failure")
+		reconnectMax       = flag.Duration("reconnect-max-backoff", 30*time.Second, "longest wait between reconnect attempts")
+		reconnectAttempts  = flag.Int("reconnect-attempts", 0, "reconnect attempts per outage before the peers are disconnected (0 retries forever)")
+		multiplex          = flag.Bool("multiplex", false, "share one upstream connection among all downstream peers")
+		statusListen       = flag.String("status-listen", "", "optional HTTP address serving GET/PUT /peers and /attack for per-peer policies and live attack settings, plus /metrics")
+		metricsListen      = flag.String("metrics-listen", "", "optional HTTP address serving only the Prometheus /metrics endpoint")
+		scenarioPath       = flag.String("scenario", "", "YAML or JSON file of attack phases to step through instead of --attack, the trigger, and the drop/delay/duplicate hooks")
+		recordPath         = flag.String("record", "", "append every intercepted consensus message, and each message sent in its place, to this capture file (JSON lines)")
+		replayPath         = flag.String("replay", "", "capture file recorded with --record whose messages are re-sent once the first peer connects")
+		replayDir          = flag.String("replay-direction", "upstream", "recorded flows to replay (upstream: validator to peers|downstream: peers to validator|both)")
+		replaySpeed        = flag.Float64("replay-speed", 1, "replay timing scale: 1 keeps the recorded gaps, 2 halves them, 0 sends back to back")
+		replayMutate       = flag.Bool("replay-mutate", false, "apply the attack, trigger, and hooks to replayed messages")
+		partitionDir       = flag.String("partition", "", "drop all traffic in this direction (upstream|downstream|both) during the partition window, then heal")
+		partitionAfter     = flag.Duration("partition-after", 0, "time after startup at which the partition starts")
+		partitionFor       = flag.Duration("partition-for", 0, "how long the partition lasts (0 lasts until the height range ends)")
+		partitionHeights   = flag.String("partition-heights", "", "height range N..M during which the partition is in effect")
+		latencyMean        = flag.Duration("latency", 0, "mean one-way latency added to every forwarded frame")
+		latencyJitter      = flag.Duration("latency-jitter", 0, "spread of the added latency (half-width for uniform, standard deviation for normal, tail scale for pareto)")
+		latencyDist        = flag.String("latency-dist", "constant", "latency distribution (constant|uniform|normal|pareto)")
+		bandwidth          = flag.String("bandwidth", "", "per-channel throttle in bytes per second, e.g. mempool=64k,consensus=1m or all=256k")
+		blockChannels      = flag.String("block-channels", "", "channels to blackhole instead of relay, e.g. evidence,mempool=downstream,0x40 (names: consensus, mempool, evidence, blocksync, snapshot)")
 		mutateDir          = flag.String("mutate-direction", "upstream", "direction to apply mutations (upstream|downstream|both)")
+		manifestPath       = flag.String("manifest", "", "optional path to write a run manifest with resource usage on exit")
+		signKey            = flag.String("sign-key", "", "lab secret key used to sign the manifest on exit (requires --manifest)")
+		tuiMode            = flag.Bool("tui", false, "show a terminal dashboard of live traffic with keys to change the attack; logs go to --log-file")
+		logFile            = flag.String("log-file", "", "append JSON logs to this file instead of stdout (byzproxy.log with --tui)")
 	)
 
 	flag.Parse()
@@ -49,6 +94,20 @@ func main() {
 		os.Exit(1)
 	}
 
+	var labKey *experiment.SigningKey
+	if strings.TrimSpace(*signKey) != "" {
+		if strings.TrimSpace(*manifestPath) == "" {
+			fmt.Fprintln(os.Stderr, "--sign-key requires --manifest")
+			os.Exit(1)
+		}
+		key, err := experiment.LoadSigningKey(*signKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load signing key: %v\n", err)
+			os.Exit(1)
+		}
+		labKey = key
+	}
+
 	nodeKey, err := p2p.LoadNodeKey(*nodeKeyPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to load node key: %v\n", err)
@@ -61,6 +120,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	actionParams, err := byzantine.ParseParams(*params)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid params: %v\n", err)
+		os.Exit(1)
+	}
+
+	var floodFrom, floodTo int64
+	if strings.TrimSpace(*floodRange) != "" {
+		floodFrom, floodTo, err = byzantine.ParseRange(*floodRange)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid flood range: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	opts := cometbftAdapter.ByzantineOptions{
 		AlternateBlockHash: *alternateBlock,
 		AlternatePrevHash:  *alternatePrev,
@@ -69,6 +143,20 @@ func main() {
 		RoundOffset:        *roundOffset,
 		HeightOffset:       *heightOffset,
 		TimestampShift:     *timestampShift,
+		EmitBoth:           *emitBoth,
+		FloodFrom:          floodFrom,
+		FloodTo:            floodTo,
+		FuzzSeed:           *fuzzSeed,
+		TargetPeers:        cli.SplitList(*targetPeers),
+		Params:             actionParams,
+	}
+	if strings.TrimSpace(*privvalKey) != "" {
+		signer, err := cometbftAdapter.LoadPrivValSigner(*chainID, *privvalKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load privval key: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Signer = signer
 	}
 
 	trigger := engine.Trigger{}
@@ -81,11 +169,53 @@ func main() {
 	if step := strings.TrimSpace(*triggerStep); step != "" {
 		trigger.Step = strings.ToLower(step)
 	}
+	trigger.Validators = cli.SplitList(*triggerValidators)
+	trigger.Every = *triggerEvery
+	trigger.Probability = *triggerProb
+
+	validatorDelays, err := engine.ParseValidatorDelayPolicy(*validatorDelay)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid validator delay: %v\n", err)
+		os.Exit(1)
+	}
+
+	victimSkews, err := engine.ParseVictimClockSkews(*victimSkew)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid victim clock skew: %v\n", err)
+		os.Exit(1)
+	}
+
+	var partition *engine.Partition
+	if strings.TrimSpace(*partitionDir) != "" {
+		partitionDirection, err := engine.ParseDirection(*partitionDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid partition direction: %v\n", err)
+			os.Exit(1)
+		}
+		partition = &engine.Partition{Direction: partitionDirection, After: *partitionAfter, For: *partitionFor}
+		if strings.TrimSpace(*partitionHeights) != "" {
+			from, to, err := byzantine.ParseRange(*partitionHeights)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "invalid partition heights: %v\n", err)
+				os.Exit(1)
+			}
+			partition.Trigger.MinHeight, partition.Trigger.MaxHeight = &from, &to
+		}
+	}
 
 	hooks := engine.Hooks{
-		Delay:     *delayDur,
-		Drop:      *dropMessages,
-		Duplicate: *duplicate,
+		Delay:            *delayDur,
+		Drop:             *dropMessages,
+		Duplicate:        *duplicate,
+		ValidatorDelays:  validatorDelays,
+		SplitPeers:       *splitPeers,
+		VictimClockSkews: victimSkews,
+		Partition:        partition,
+	}
+	hooks.Shaping.Latency = engine.LatencyModel{Distribution: *latencyDist, Mean: *latencyMean, Jitter: *latencyJitter}
+	if hooks.Shaping.Bandwidth, err = engine.ParseBandwidth(*bandwidth); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid bandwidth: %v\n", err)
+		os.Exit(1)
 	}
 
 	direction, err := engine.ParseDirection(*mutateDir)
@@ -94,20 +224,93 @@ func main() {
 		os.Exit(1)
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	blockedChannels, err := engine.ParseChannelBlocks(*blockChannels)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid blocked channels: %v\n", err)
+		os.Exit(1)
+	}
+
+	var failover []string
+	for _, addr := range strings.Split(*upstreamFailover, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			failover = append(failover, addr)
+		}
+	}
+	var reconnectPolicy *engine.Reconnect
+	if *reconnect {
+		reconnectPolicy = &engine.Reconnect{Backoff: *reconnectBackoff, MaxBackoff: *reconnectMax, Attempts: *reconnectAttempts}
+	}
+
+	var replay *engine.ReplayOptions
+	if path := strings.TrimSpace(*replayPath); path != "" {
+		replayDirection, err := engine.ParseDirection(*replayDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid replay direction: %v\n", err)
+			os.Exit(1)
+		}
+		replay = &engine.ReplayOptions{Path: path, Direction: replayDirection, Speed: *replaySpeed, Mutate: *replayMutate}
+	}
+
+	var sc *engine.Scenario
+	if path := strings.TrimSpace(*scenarioPath); path != "" {
+		if sc, err = engine.LoadScenario(path); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid scenario: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	logOutput := io.Writer(os.Stdout)
+	if path := strings.TrimSpace(*logFile); path != "" || *tuiMode {
+		if path == "" {
+			path = "byzproxy.log"
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open log file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		logOutput = f
+	}
+	logger := slog.New(slog.NewJSONHandler(logOutput, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	var recorder *engine.Recorder
+	if path := strings.TrimSpace(*recordPath); path != "" {
+		if recorder, err = engine.OpenRecorder(path); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open record file: %v\n", err)
+			os.Exit(1)
+		}
+		logger.Info("recording messages", "path", path)
+	}
+
+	var resources *experiment.ResourceAccountant
+	manifest := experiment.NewManifest("byzproxy")
+	if strings.TrimSpace(*manifestPath) != "" {
+		resources = experiment.NewResourceAccountant(time.Second)
+		resources.Start(context.Background())
+	}
 
 	cfg, err := engine.NewConfig(engine.ConfigOptions{
-		ListenAddress:  *listenAddr,
-		UpstreamTarget: *upstreamAddr,
-		ChainID:        *chainID,
-		NodeKey:        nodeKey,
-		Action:         byzAction,
-		Options:        opts,
-		Trigger:        trigger,
-		Hooks:          hooks,
-		Direction:      direction,
-		DialTimeout:    *dialTimeout,
-		Logger:         logger,
+		ListenAddress:   *listenAddr,
+		UpstreamTarget:  *upstreamAddr,
+		FailoverTargets: failover,
+		Reconnect:       reconnectPolicy,
+		ChainID:         *chainID,
+		NodeKey:         nodeKey,
+		Action:          byzAction,
+		Options:         opts,
+		Trigger:         trigger,
+		Hooks:           hooks,
+		Direction:       direction,
+		DialTimeout:     *dialTimeout,
+		Multiplex:       *multiplex,
+		Logger:          logger,
+		Resources:       resources,
+		TriggerSeed:     *triggerSeed,
+		Scenario:        sc,
+		Recorder:        recorder,
+		Replay:          replay,
+		BlockedChannels: blockedChannels,
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to build config: %v\n", err)
@@ -115,12 +318,104 @@ func main() {
 	}
 
 	eng := engine.New(cfg)
+	logger.Info("trigger seed", "seed", cfg.TriggerSeed)
+	if len(blockedChannels) > 0 {
+		logger.Info("blocked channels", "channels", *blockChannels)
+	}
+	if cfg.Hooks.Shaping.Latency.Mean > 0 || cfg.Hooks.Shaping.Latency.Jitter > 0 || *bandwidth != "" {
+		logger.Info("link shaping", "latency", *latencyMean, "jitter", *latencyJitter, "distribution", *latencyDist, "bandwidth", *bandwidth)
+	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	if err := eng.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
-		fmt.Fprintf(os.Stderr, "proxy exited with error: %v\n", err)
+	if addr := strings.TrimSpace(*statusListen); addr != "" {
+		defer serveHTTP(logger, "status", addr, eng.StatusHandler()).Close()
+	}
+	if addr := strings.TrimSpace(*metricsListen); addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", eng.Metrics().Handler())
+		defer serveHTTP(logger, "metrics", addr, mux).Close()
+	}
+
+	var runErr error
+	if *tuiMode {
+		// The dashboard holds the terminal, so the proxy runs alongside it and either one ending stops both.
+		done := make(chan error, 1)
+		go func() {
+			done <- eng.Run(ctx)
+			cancel()
+		}()
+		toggleDelay := *delayDur
+		if toggleDelay <= 0 {
+			toggleDelay = 500 * time.Millisecond
+		}
+		if err := runTUI(ctx, cancel, eng, toggleDelay); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			cancel()
+		}
+		runErr = <-done
+	} else {
+		runErr = eng.Run(ctx)
+	}
+	if recorder != nil {
+		if err := recorder.Close(); err != nil {
+			logger.Error("failed to close record file", "err", err)
+		}
+	}
+
+	if resources != nil {
+		resources.Stop()
+		manifest.SetParameter("attack", string(byzAction))
+		manifest.SetParameter("chain_id", *chainID)
+		manifest.SetParameter("mutate_direction", *mutateDir)
+		manifest.SetParameter("upstream", *upstreamAddr)
+		if len(failover) > 0 {
+			manifest.SetParameter("upstream_failover", strings.Join(failover, ","))
+		}
+		manifest.SetParameter("trigger_seed", cfg.TriggerSeed)
+		if sc != nil {
+			manifest.SetParameter("scenario", *scenarioPath)
+		}
+		if recorder != nil {
+			manifest.SetParameter("record", *recordPath)
+		}
+		if replay != nil {
+			manifest.SetParameter("replay", *replayPath)
+		}
+		if len(blockedChannels) > 0 {
+			manifest.SetParameter("block_channels", *blockChannels)
+		}
+		if *latencyMean > 0 || *latencyJitter > 0 {
+			manifest.SetParameter("latency", fmt.Sprintf("%s %s±%s", *latencyDist, *latencyMean, *latencyJitter))
+		}
+		if *bandwidth != "" {
+			manifest.SetParameter("bandwidth", *bandwidth)
+		}
+		manifest.Finish(resources)
+		if err := manifest.WriteFile(*manifestPath); err != nil {
+			logger.Error("failed to write manifest", "err", err)
+		} else if labKey != nil {
+			if err := experiment.SignDataset(labKey, *manifestPath); err != nil {
+				logger.Error("failed to sign manifest", "err", err)
+			}
+		}
+	}
+
+	if runErr != nil && !errors.Is(runErr, context.Canceled) {
+		fmt.Fprintf(os.Stderr, "proxy exited with error: %v\n", runErr)
 		os.Exit(1)
 	}
 }
+
+// serveHTTP serves handler on addr in the background until the returned server is closed.
+func serveHTTP(logger *slog.Logger, name, addr string, handler http.Handler) *http.Server {
+	srv := &http.Server{Addr: addr, Handler: handler}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(name+" listener stopped", "err", err)
+		}
+	}()
+	logger.Info(name+" listening", "address", addr)
+	return srv
+}
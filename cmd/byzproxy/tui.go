@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	cometbftAdapter "codec/cometbft/adapter"
+	"codec/proxy/engine"
+)
+
+// tuiRecent is how many of the latest consensus messages the TUI lists.
+const tuiRecent = 12
+
+// tuiRefresh is how often the dashboard redraws without a key press.
+const tuiRefresh = 500 * time.Millisecond
+
+var (
+	tuiBold    = lipgloss.NewStyle().Bold(true)
+	tuiAlert   = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	tuiSection = lipgloss.NewStyle().MarginTop(1)
+)
+
+// tuiTick asks the dashboard to redraw with fresh counts.
+type tuiTick time.Time
+
+// tui is the --tui dashboard: live traffic per channel, the consensus height and round, and the attack
+// settings, with keys that change the attack through Engine.SetAttack as PUT /attack does. It is a bubbletea
+// model.
+type tui struct {
+	eng     *engine.Engine
+	stop    context.CancelFunc
+	actions []cometbftAdapter.ByzantineAction
+	// delay is the hold applied when the delay hook is switched on.
+	delay   time.Duration
+	started time.Time
+	status  string
+
+	// last and lastAt are the per-channel totals of the previous frame, for the rate column.
+	last   map[byte]int64
+	lastAt time.Time
+	rates  map[byte]float64
+}
+
+// runTUI runs the dashboard until ctx is done or q is pressed, which calls stop.
+func runTUI(ctx context.Context, stop context.CancelFunc, eng *engine.Engine, delay time.Duration) error {
+	t := &tui{
+		eng:     eng,
+		stop:    stop,
+		actions: cometbftAdapter.ByzantineEngine.Actions(),
+		delay:   delay,
+		started: time.Now(),
+		status:  "ready",
+	}
+	t.sampleRates(time.Now())
+	_, err := tea.NewProgram(t, tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("--tui needs an interactive terminal: %w", err)
+	}
+	return nil
+}
+
+func tuiTickCmd() tea.Cmd {
+	return tea.Tick(tuiRefresh, func(at time.Time) tea.Msg { return tuiTick(at) })
+}
+
+func (t *tui) Init() tea.Cmd {
+	return tuiTickCmd()
+}
+
+func (t *tui) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			t.stop()
+			return t, tea.Quit
+		}
+		t.handleKey(msg.String())
+	case tuiTick:
+		t.sampleRates(time.Time(msg))
+		return t, tuiTickCmd()
+	}
+	return t, nil
+}
+
+// handleKey applies a keybinding to the live attack.
+func (t *tui) handleKey(key string) {
+	attack := t.eng.Attack()
+	switch key {
+	case "d":
+		attack.Drop = !attack.Drop
+	case "l":
+		if attack.Delay > 0 {
+			attack.Delay = 0
+		} else {
+			attack.Delay = t.delay
+		}
+	case "u":
+		attack.Duplicate = !attack.Duplicate
+	case "a", "A":
+		attack.Action = t.nextAction(attack.Action, key == "a")
+	case "n":
+		attack.Action = cometbftAdapter.ByzantineActionNone
+	default:
+		return
+	}
+	applied, err := t.eng.SetAttack(attack)
+	if err != nil {
+		t.status = "rejected: " + err.Error()
+		return
+	}
+	t.status = fmt.Sprintf("attack set to %s", describeAttack(applied))
+}
+
+// nextAction steps through the registered actions in name order.
+func (t *tui) nextAction(current cometbftAdapter.ByzantineAction, forward bool) cometbftAdapter.ByzantineAction {
+	index := sort.Search(len(t.actions), func(i int) bool { return t.actions[i] >= current })
+	if index < len(t.actions) && t.actions[index] == current {
+		if forward {
+			index++
+		} else {
+			index--
+		}
+	} else if !forward {
+		index--
+	}
+	return t.actions[(index+len(t.actions))%len(t.actions)]
+}
+
+// sampleRates updates the forwarded-per-second rate of each channel from the totals since the last tick.
+func (t *tui) sampleRates(now time.Time) {
+	counts := t.eng.Metrics().ChannelCounts()
+	totals := make(map[byte]int64, len(counts))
+	rates := make(map[byte]float64, len(counts))
+	for ch, c := range counts {
+		totals[ch] = c[engine.EventForwarded]
+		if elapsed := now.Sub(t.lastAt).Seconds(); t.last != nil && elapsed > 0 {
+			rates[ch] = float64(totals[ch]-t.last[ch]) / elapsed
+		}
+	}
+	t.last, t.lastAt, t.rates = totals, now, rates
+}
+
+func (t *tui) View() string {
+	activity := t.eng.Activity()
+	attack := t.eng.Attack()
+	header := fmt.Sprintf("%s  height %d  round %d  peers %d  up %s\nattack %s  trigger %s",
+		tuiBold.Render("byzproxy"), activity.Height, activity.Round, len(t.eng.Peers()),
+		time.Since(t.started).Truncate(time.Second), describeAttack(attack), attack.Trigger.String())
+
+	counts := t.eng.Metrics().ChannelCounts()
+	channels := make([]byte, 0, len(counts))
+	for ch := range counts {
+		channels = append(channels, ch)
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i] < channels[j] })
+	traffic := []string{tuiBold.Render(fmt.Sprintf("%-24s %10s %8s %9s %9s %9s %10s %8s",
+		"CHANNEL", "FORWARDED", "RATE/s", "MUTATED", "DROPPED", "DELAYED", "DUPLICATED", "SKEWED"))}
+	for _, ch := range channels {
+		c := counts[ch]
+		traffic = append(traffic, fmt.Sprintf("%-24s %10d %8.1f %9d %9d %9d %10d %8d", engine.ChannelName(ch),
+			c[engine.EventForwarded], t.rates[ch], c[engine.EventMutated], c[engine.EventDropped],
+			c[engine.EventDelayed], c[engine.EventDuplicated], c[engine.EventSkewed]))
+	}
+	if len(channels) == 0 {
+		traffic = append(traffic, "no traffic yet")
+	}
+
+	messages := []string{tuiBold.Render("RECENT CONSENSUS MESSAGES")}
+	recent := activity.Recent
+	for i := len(recent) - 1; i >= 0 && i >= len(recent)-tuiRecent; i-- {
+		entry := recent[i]
+		event := entry.Event
+		if entry.Action != "" {
+			event += " " + entry.Action
+		}
+		if entry.Event != engine.EventForwarded {
+			event = tuiAlert.Render(event)
+		}
+		messages = append(messages, fmt.Sprintf("%s  %-10s %-24s %-14s h=%-8d r=%-3d %-12s %s",
+			entry.Time.Local().Format("15:04:05.000"), entry.Direction, engine.ChannelName(entry.Channel), entry.Type,
+			entry.Height, entry.Round, shortID(entry.Validator), event))
+	}
+	for i := len(recent); i < tuiRecent; i++ {
+		messages = append(messages, "")
+	}
+
+	help := fmt.Sprintf("[d] drop  [l] delay %s  [u] duplicate  [a/A] next/previous action  [n] no action  [q] quit\n%s",
+		t.delay, t.status)
+	return lipgloss.JoinVertical(lipgloss.Left, header,
+		tuiSection.Render(strings.Join(traffic, "\n")),
+		tuiSection.Render(strings.Join(messages, "\n")),
+		tuiSection.Render(help))
+}
+
+// describeAttack renders the action and the hooks that are switched on.
+func describeAttack(attack engine.Attack) string {
+	parts := []string{string(attack.Action)}
+	if attack.Drop {
+		parts = append(parts, "+drop")
+	}
+	if attack.Delay > 0 {
+		parts = append(parts, "+delay "+attack.Delay.String())
+	}
+	if attack.Duplicate {
+		parts = append(parts, "+duplicate")
+	}
+	return strings.Join(parts, " ")
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
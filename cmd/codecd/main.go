@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "codec/adapters"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:8090", "Address to serve the HTTP API on")
+	maxBody := flag.Int64("max-body", 4<<20, "Largest request body accepted, in bytes")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: codecd [-listen 127.0.0.1:8090]")
+		fmt.Fprintln(os.Stderr, "Serves the chain mappers, validators, and byzantine engines over HTTP:")
+		fmt.Fprintln(os.Stderr, "  GET  /chains     chains with their message types and byzantine actions")
+		fmt.Fprintln(os.Stderr, "  POST /convert    decode a raw message, encode a canonical one, or both")
+		fmt.Fprintln(os.Stderr, "  POST /validate   run the chain's validator and lint rules on a message")
+		fmt.Fprintln(os.Stderr, "  POST /byzantine  apply a byzantine action and encode the forged messages")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	s := &server{logger: logger, maxBody: *maxBody}
+	srv := &http.Server{Addr: *listen, Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		shutdown, done := context.WithTimeout(context.Background(), 5*time.Second)
+		defer done()
+		_ = srv.Shutdown(shutdown)
+	}()
+
+	logger.Info("codecd listening", "addr", *listen)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "codecd failed: %v\n", err)
+		os.Exit(1)
+	}
+}
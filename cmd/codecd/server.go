@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	cometbftAdapter "codec/cometbft/adapter"
+	ethereumAdapter "codec/ethereum/adapter"
+	besuAdapter "codec/hyperledger/besu/adapter"
+	fabricAdapter "codec/hyperledger/fabric/adapter"
+	kaiaAdapter "codec/kaia/adapter"
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+	"codec/message/abstraction/lint"
+	"codec/message/abstraction/validator"
+	"codec/scenario"
+)
+
+// chains are the chains the service serves, in the order GET /chains lists them.
+var chains = []abstraction.ChainType{
+	abstraction.ChainTypeCometBFT, abstraction.ChainTypeHyperledger, abstraction.ChainTypeKaia,
+	abstraction.ChainTypeFabric, abstraction.ChainTypeFabricRaft, abstraction.ChainTypeEthereum,
+	abstraction.ChainTypeAptos, abstraction.ChainTypeAvalanche, abstraction.ChainTypeHotStuff,
+}
+
+// convertRequest carries either a raw message, which is decoded to canonical form, or a canonical message.
+// With TargetChain the canonical message is then encoded for that chain.
+type convertRequest struct {
+	Raw         *abstraction.RawConsensusMessage `json:"raw,omitempty"`
+	Canonical   *abstraction.CanonicalMessage    `json:"canonical,omitempty"`
+	TargetChain string                           `json:"target_chain,omitempty"`
+	// ChainID is the chain ID the target mapper encodes for; it defaults to the canonical message's.
+	ChainID string `json:"chain_id,omitempty"`
+}
+
+type convertResponse struct {
+	Canonical *abstraction.CanonicalMessage    `json:"canonical"`
+	Raw       *abstraction.RawConsensusMessage `json:"raw,omitempty"`
+}
+
+// validateRequest carries a raw message, or a canonical message of Chain.
+type validateRequest struct {
+	Raw       *abstraction.RawConsensusMessage `json:"raw,omitempty"`
+	Canonical *abstraction.CanonicalMessage    `json:"canonical,omitempty"`
+	Chain     string                           `json:"chain,omitempty"`
+}
+
+// validateResponse reports the first rule the message breaks as Error, and every lint finding as Issues. A raw
+// message that does not decode is invalid with code DECODE_FAILURE.
+type validateResponse struct {
+	Valid     bool                                `json:"valid"`
+	Chain     abstraction.ChainType               `json:"chain"`
+	Canonical *abstraction.CanonicalMessage       `json:"canonical,omitempty"`
+	Error     *abstraction.MessageValidationError `json:"error,omitempty"`
+	Issues    []lint.Issue                        `json:"issues,omitempty"`
+}
+
+// byzantineRequest applies Action to a raw message, or a canonical message of Chain, and encodes the forged
+// messages for the same chain. The options are those of cmd/byzantine.
+type byzantineRequest struct {
+	Raw       *abstraction.RawConsensusMessage `json:"raw,omitempty"`
+	Canonical *abstraction.CanonicalMessage    `json:"canonical,omitempty"`
+	Chain     string                           `json:"chain,omitempty"`
+	ChainID   string                           `json:"chain_id,omitempty"`
+	Action    string                           `json:"action"`
+	Options   scenario.StepOptions             `json:"options,omitempty"`
+	// FloodRange is the height_flood range as N..M.
+	FloodRange string            `json:"flood_range,omitempty"`
+	FuzzSeed   int64             `json:"fuzz_seed,omitempty"`
+	Params     map[string]string `json:"params,omitempty"`
+}
+
+type byzantineResponse struct {
+	Canonicals []*abstraction.CanonicalMessage    `json:"canonicals"`
+	Raws       []*abstraction.RawConsensusMessage `json:"raws"`
+}
+
+// chainInfo describes a chain for GET /chains. Actions is empty for chains without a byzantine engine.
+type chainInfo struct {
+	Chain    abstraction.ChainType `json:"chain"`
+	MsgTypes []abstraction.MsgType `json:"message_types"`
+	Actions  []byzantine.Action    `json:"actions,omitempty"`
+}
+
+// server answers the HTTP API. Every request builds its own mappers, so requests share no state.
+type server struct {
+	logger *slog.Logger
+	// maxBody bounds request bodies in bytes.
+	maxBody int64
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /chains", s.handleChains)
+	mux.HandleFunc("POST /convert", s.handleConvert)
+	mux.HandleFunc("POST /validate", s.handleValidate)
+	mux.HandleFunc("POST /byzantine", s.handleByzantine)
+	return mux
+}
+
+func (s *server) handleChains(w http.ResponseWriter, r *http.Request) {
+	infos := make([]chainInfo, 0, len(chains))
+	for _, chain := range chains {
+		mapper, err := abstraction.NewMapper(chain, "")
+		if err != nil {
+			continue
+		}
+		info := chainInfo{Chain: chain, MsgTypes: mapper.GetSupportedTypes()}
+		if engine := byzantineEngine(chain); engine != nil {
+			info.Actions = engine.Actions()
+		}
+		infos = append(infos, info)
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (s *server) handleConvert(w http.ResponseWriter, r *http.Request) {
+	var req convertRequest
+	if !s.decode(w, r, &req) {
+		return
+	}
+	if (req.Raw == nil) == (req.Canonical == nil) {
+		http.Error(w, "convert needs either raw or canonical", http.StatusBadRequest)
+		return
+	}
+	if req.Canonical != nil && strings.TrimSpace(req.TargetChain) == "" {
+		http.Error(w, "converting a canonical message needs target_chain", http.StatusBadRequest)
+		return
+	}
+
+	resp := &convertResponse{Canonical: req.Canonical}
+	if req.Raw != nil {
+		canonical, status, err := toCanonical(req.Raw)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		resp.Canonical = canonical
+	}
+	if strings.TrimSpace(req.TargetChain) != "" {
+		chainID := req.ChainID
+		if chainID == "" {
+			chainID = resp.Canonical.ChainID
+		}
+		target, err := abstraction.NewMapper(abstraction.ParseChainType(req.TargetChain), chainID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if resp.Raw, err = target.FromCanonical(resp.Canonical); err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode %s message: %v", target.GetChainType(), err), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	var req validateRequest
+	if !s.decode(w, r, &req) {
+		return
+	}
+	if (req.Raw == nil) == (req.Canonical == nil) {
+		http.Error(w, "validate needs either raw or canonical", http.StatusBadRequest)
+		return
+	}
+
+	resp := &validateResponse{Canonical: req.Canonical}
+	if req.Raw != nil {
+		resp.Chain = req.Raw.ChainType
+		canonical, status, err := toCanonical(req.Raw)
+		if status == http.StatusBadRequest {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		if err != nil {
+			resp.Error = &abstraction.MessageValidationError{Field: "payload", Message: err.Error(), Code: abstraction.ErrDecodeFailure.Code}
+			writeJSON(w, http.StatusOK, resp)
+			return
+		}
+		resp.Canonical = canonical
+	} else {
+		if strings.TrimSpace(req.Chain) == "" {
+			http.Error(w, "validating a canonical message needs chain", http.StatusBadRequest)
+			return
+		}
+		resp.Chain = abstraction.ParseChainType(req.Chain)
+		if _, err := abstraction.NewMapper(resp.Chain, ""); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := validator.NewValidator(resp.Chain).Validate(resp.Canonical); err != nil {
+		var verr *abstraction.MessageValidationError
+		if !errors.As(err, &verr) {
+			verr = &abstraction.MessageValidationError{Message: err.Error()}
+		}
+		resp.Error = verr
+	}
+	resp.Issues = lint.Lint(resp.Canonical, lint.DefaultProfile(resp.Chain), byzantine.ActionNone)
+	resp.Valid = resp.Error == nil
+	for _, issue := range resp.Issues {
+		if issue.Severity == lint.SeverityError {
+			resp.Valid = false
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *server) handleByzantine(w http.ResponseWriter, r *http.Request) {
+	var req byzantineRequest
+	if !s.decode(w, r, &req) {
+		return
+	}
+	if (req.Raw == nil) == (req.Canonical == nil) {
+		http.Error(w, "byzantine needs either raw or canonical", http.StatusBadRequest)
+		return
+	}
+
+	msg, chain := req.Canonical, abstraction.ParseChainType(req.Chain)
+	if req.Raw != nil {
+		canonical, status, err := toCanonical(req.Raw)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		msg, chain = canonical, req.Raw.ChainType
+	}
+	engine := byzantineEngine(chain)
+	if engine == nil {
+		http.Error(w, fmt.Sprintf("no byzantine engine for chain %q", chain), http.StatusBadRequest)
+		return
+	}
+	action, err := engine.Parse(req.Action)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts := req.Options.Options()
+	opts.FuzzSeed = req.FuzzSeed
+	opts.Params = req.Params
+	if strings.TrimSpace(req.FloodRange) != "" {
+		if opts.FloodFrom, opts.FloodTo, err = byzantine.ParseRange(req.FloodRange); err != nil {
+			http.Error(w, fmt.Sprintf("invalid flood_range: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	chainID := req.ChainID
+	if chainID == "" {
+		chainID = msg.ChainID
+	}
+	mapper, err := abstraction.NewMapper(chain, chainID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	canonicals, err := engine.Apply(msg, action, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	raws, err := byzantine.Encode(mapper, canonicals)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	for _, raw := range raws {
+		if raw.Payload, err = engine.MutatePayload(action, raw.Payload, opts); err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+	s.logger.Info("forged messages", "chain", chain, "action", action, "messages", len(raws))
+	writeJSON(w, http.StatusOK, &byzantineResponse{Canonicals: canonicals, Raws: raws})
+}
+
+// decode reads the JSON body into v, answering 400 and returning false when it cannot. Unknown fields are
+// rejected so that a misspelt option is not silently ignored.
+func (s *server) decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// toCanonical decodes raw with the mapper of its chain. The status is 400 when the chain is unknown and 422
+// when the payload does not decode.
+func toCanonical(raw *abstraction.RawConsensusMessage) (*abstraction.CanonicalMessage, int, error) {
+	raw.ChainType = abstraction.ParseChainType(string(raw.ChainType))
+	mapper, err := abstraction.NewMapper(raw.ChainType, raw.ChainID)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	canonical, err := mapper.ToCanonical(*raw)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity, fmt.Errorf("failed to decode %s message: %w", raw.ChainType, err)
+	}
+	return canonical, http.StatusOK, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// byzantineEngine returns the chain's byzantine engine, or nil for chains without one.
+func byzantineEngine(chain abstraction.ChainType) *byzantine.Engine {
+	switch chain {
+	case abstraction.ChainTypeCometBFT:
+		return cometbftAdapter.ByzantineEngine.Engine
+	case abstraction.ChainTypeHyperledger:
+		return besuAdapter.ByzantineEngine
+	case abstraction.ChainTypeKaia:
+		return kaiaAdapter.ByzantineEngine
+	case abstraction.ChainTypeFabric:
+		return fabricAdapter.ByzantineEngine
+	case abstraction.ChainTypeFabricRaft:
+		return fabricAdapter.RaftByzantineEngine
+	case abstraction.ChainTypeEthereum:
+		return ethereumAdapter.ByzantineEngine
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+func prevote() *abstraction.CanonicalMessage {
+	return &abstraction.CanonicalMessage{
+		ChainID:    "codecd-test",
+		Height:     big.NewInt(12),
+		Round:      big.NewInt(1),
+		Timestamp:  time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+		Type:       abstraction.MsgTypePrevote,
+		BlockHash:  strings.Repeat("AB", 32),
+		Validator:  strings.Repeat("CD", 20),
+		Signature:  "c2lnbmF0dXJl",
+		Extensions: abstraction.Extensions{"validator_index": int32(3)},
+	}
+}
+
+func post(t *testing.T, srv *httptest.Server, path string, body any, out any) int {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(srv.URL+path, "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		text, _ := io.ReadAll(resp.Body)
+		t.Logf("%s: %d %s", path, resp.StatusCode, text)
+		return resp.StatusCode
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	return resp.StatusCode
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer((&server{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), maxBody: 1 << 20}).handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestConvertEncodesAndDecodes(t *testing.T) {
+	srv := newTestServer(t)
+
+	var encoded convertResponse
+	if status := post(t, srv, "/convert", convertRequest{Canonical: prevote(), TargetChain: "cometbft"}, &encoded); status != http.StatusOK {
+		t.Fatalf("encode: status %d", status)
+	}
+	if encoded.Raw == nil || encoded.Raw.ChainType != abstraction.ChainTypeCometBFT {
+		t.Fatalf("expected a cometbft raw message, got %+v", encoded.Raw)
+	}
+
+	var decoded convertResponse
+	if status := post(t, srv, "/convert", convertRequest{Raw: encoded.Raw}, &decoded); status != http.StatusOK {
+		t.Fatalf("decode: status %d", status)
+	}
+	if decoded.Canonical.Type != abstraction.MsgTypePrevote || decoded.Canonical.Height.Int64() != 12 {
+		t.Fatalf("unexpected canonical message %+v", decoded.Canonical)
+	}
+
+	if status := post(t, srv, "/convert", convertRequest{Canonical: prevote()}, &decoded); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 without target_chain, got %d", status)
+	}
+}
+
+func TestValidateReportsBrokenRules(t *testing.T) {
+	srv := newTestServer(t)
+
+	// The validator rejects messages that are too old.
+	msg := prevote()
+	msg.Timestamp = time.Now().UTC()
+	var resp validateResponse
+	if status := post(t, srv, "/validate", validateRequest{Canonical: msg, Chain: "cometbft"}, &resp); status != http.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	if !resp.Valid || resp.Error != nil {
+		t.Fatalf("expected the prevote to be valid, got %+v", resp)
+	}
+
+	msg.Height = nil
+	resp = validateResponse{}
+	if status := post(t, srv, "/validate", validateRequest{Canonical: msg, Chain: "cometbft"}, &resp); status != http.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	if resp.Valid || resp.Error == nil || resp.Error.Code != "MISSING_FIELD" {
+		t.Fatalf("expected a missing height, got %+v", resp)
+	}
+
+	raw := &abstraction.RawConsensusMessage{ChainType: abstraction.ChainTypeCometBFT, Payload: []byte("not a vote"), Encoding: "json"}
+	resp = validateResponse{}
+	if status := post(t, srv, "/validate", validateRequest{Raw: raw}, &resp); status != http.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	if resp.Valid || resp.Error == nil || resp.Error.Code != abstraction.ErrDecodeFailure.Code {
+		t.Fatalf("expected a decode failure, got %+v", resp)
+	}
+}
+
+func TestByzantineForgesDoubleVote(t *testing.T) {
+	srv := newTestServer(t)
+
+	var resp byzantineResponse
+	req := byzantineRequest{Canonical: prevote(), Chain: "cometbft", Action: "double_vote"}
+	if status := post(t, srv, "/byzantine", req, &resp); status != http.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	if len(resp.Canonicals) != 2 || len(resp.Raws) != 2 {
+		t.Fatalf("expected two conflicting votes, got %d canonical and %d raw", len(resp.Canonicals), len(resp.Raws))
+	}
+	if resp.Canonicals[0].BlockHash == resp.Canonicals[1].BlockHash {
+		t.Fatal("expected the votes to name different blocks")
+	}
+
+	req.Action = "no_such_action"
+	if status := post(t, srv, "/byzantine", req, &resp); status != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown action, got %d", status)
+	}
+}
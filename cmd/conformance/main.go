@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cometbftAdapter "codec/cometbft/adapter"
+	besuAdapter "codec/hyperledger/besu/adapter"
+	fabricAdapter "codec/hyperledger/fabric/adapter"
+	kaiaAdapter "codec/kaia/adapter"
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+func main() {
+	outputPath := flag.String("output", "", "Optional path to write the coverage matrix as JSON")
+	format := flag.String("format", "table", "Console output format (table|json)")
+	flag.Parse()
+
+	report := byzantine.Coverage(subjects())
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode coverage report: %v\n", err)
+		os.Exit(1)
+	}
+	if strings.TrimSpace(*outputPath) != "" {
+		if err := os.WriteFile(*outputPath, append(data, '\n'), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write coverage report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	switch strings.ToLower(*format) {
+	case "json":
+		fmt.Println(string(data))
+	case "table":
+		printTable(report)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		os.Exit(1)
+	}
+}
+
+// subjects lists every chain adapter with the probe data needed to exercise its actions.
+func subjects() []byzantine.CoverageSubject {
+	opts := byzantine.Options{
+		AlternateValidator: "probe-alternate",
+		TimestampShift:     2 * time.Second,
+		FloodFrom:          1,
+		FloodTo:            2,
+		// Probes are single messages, so no proposal history exists to tell whose block a precommit is for.
+		Params: map[string]string{cometbftAdapter.WithholdScopeParam: "all"},
+	}
+	fabricOpts := opts
+	fabricOpts.AlternateValidator = "EvilOrdererMSP/9"
+
+	return []byzantine.CoverageSubject{
+		{
+			Name:    string(abstraction.ChainTypeCometBFT),
+			Mapper:  cometbftAdapter.NewCometBFTMapper("coverage-probe"),
+			Engine:  cometbftAdapter.ByzantineEngine.Engine,
+			Options: opts,
+		},
+		// Only SmartBFT orderers; the etcdraft mapper has its own chain type and is not probed.
+		{
+			Name:    string(abstraction.ChainTypeFabric),
+			Mapper:  fabricAdapter.NewFabricMapper("coverage-probe"),
+			Engine:  fabricAdapter.ByzantineEngine,
+			Probe:   fabricProbe,
+			Options: fabricOpts,
+		},
+		{
+			Name:    "besu",
+			Mapper:  besuAdapter.NewBesuMapper("coverage-probe"),
+			Engine:  besuAdapter.ByzantineEngine,
+			Options: opts,
+		},
+		{
+			Name:    string(abstraction.ChainTypeKaia),
+			Mapper:  kaiaAdapter.NewKaiaMapper("coverage-probe"),
+			Engine:  kaiaAdapter.ByzantineEngine,
+			Options: opts,
+		},
+	}
+}
+
+// fabricProbe carries the channel and orderer identity extensions SmartBFT messages require; it has views but no rounds.
+func fabricProbe(t abstraction.MsgType) *abstraction.CanonicalMessage {
+	msg := byzantine.DefaultProbe(t)
+	msg.Round = nil
+	msg.Proposer = "OrdererMSP/1"
+	msg.Validator = "OrdererMSP/2"
+	msg.Extensions["channel_id"] = "probechannel"
+	msg.Extensions["config_seq"] = uint64(1)
+	msg.Extensions["msp_id"] = "OrdererMSP"
+	return msg
+}
+
+func printTable(report *byzantine.CoverageReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := []string{"ACTION"}
+	for _, chain := range report.Chains {
+		header = append(header, strings.ToUpper(chain.Chain))
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, action := range report.Actions {
+		row := []string{string(action)}
+		for _, chain := range report.Chains {
+			row = append(row, string(report.Status(chain.Chain, action)))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+
+	fmt.Println()
+	for _, chain := range report.Chains {
+		types := make([]string, len(chain.MessageTypes))
+		for i, t := range chain.MessageTypes {
+			types[i] = string(t)
+		}
+		fmt.Printf("%s message types: %s\n", chain.Chain, strings.Join(types, ", "))
+	}
+}
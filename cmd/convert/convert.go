@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+	"codec/message/abstraction/lint"
+	"codec/message/abstraction/roundtrip"
+)
+
+// encodings are the payload encodings -format accepts. The target mapper decides which of them it reads.
+var encodings = []string{"json", "rlp", "proto"}
+
+// phases group the message types that play the same part in different protocols. A type the target chain
+// lacks is translated to the first type of its phase the target supports; vote counts as a prepare before
+// it counts as a commit.
+var phases = [][]abstraction.MsgType{
+	{abstraction.MsgTypeProposal},
+	{abstraction.MsgTypePrevote, abstraction.MsgTypePrepare, abstraction.MsgTypeVote},
+	{abstraction.MsgTypePrecommit, abstraction.MsgTypeCommit, abstraction.MsgTypeVote},
+	{abstraction.MsgTypeRoundChange, abstraction.MsgTypeViewChange},
+	{abstraction.MsgTypeNewView},
+	{abstraction.MsgTypeBlock},
+}
+
+// converter decodes raw messages of one chain and re-encodes them for another.
+type converter struct {
+	from, to abstraction.Mapper
+	chainID  string
+	// msgType replaces the translated message type when set; format replaces the target's encoding.
+	msgType abstraction.MsgType
+	format  string
+}
+
+// conversion is the outcome of converting one message. Lost lists the fields the source message set that
+// did not survive the target chain's encoding; Warnings are the lint findings on the translated message.
+type conversion struct {
+	Source    *abstraction.CanonicalMessage
+	Canonical *abstraction.CanonicalMessage
+	Raw       *abstraction.RawConsensusMessage
+	Lost      []roundtrip.Diff
+	Warnings  []lint.Issue
+}
+
+// convert runs raw through the source mapper, translates the canonical message for the target chain, and
+// encodes it with the target mapper. The output is decoded again to check that the target accepts it and
+// to find the fields the conversion lost.
+func (c *converter) convert(raw abstraction.RawConsensusMessage) (*conversion, error) {
+	source, err := c.from.ToCanonical(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s message: %w", c.from.GetChainType(), err)
+	}
+	msg := byzantine.Clone(source)
+	if c.chainID != "" {
+		msg.ChainID = c.chainID
+	}
+	if c.msgType != "" {
+		msg.Type = c.msgType
+	} else if msg.Type, err = translateType(msg.Type, c.to.GetSupportedTypes()); err != nil {
+		return nil, err
+	}
+
+	// Lint fixes move round into view and back, and render hashes the way the target does.
+	profile := lint.DefaultProfile(c.to.GetChainType())
+	(&lint.Result{Message: msg, Issues: lint.Lint(msg, profile, byzantine.ActionNone)}).Fix()
+	result := &conversion{Source: source, Canonical: msg}
+	var errs []string
+	for _, issue := range lint.Lint(msg, profile, byzantine.ActionNone) {
+		if issue.Severity == lint.SeverityError {
+			errs = append(errs, fmt.Sprintf("%s: %s", issue.Field, issue.Message))
+			continue
+		}
+		result.Warnings = append(result.Warnings, issue)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("message is not valid for %s:\n  %s", c.to.GetChainType(), strings.Join(errs, "\n  "))
+	}
+
+	out, err := c.to.FromCanonical(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s message: %w", c.to.GetChainType(), err)
+	}
+	if c.format != "" {
+		out.Encoding = c.format
+	}
+	decoded, err := c.to.ToCanonical(*out)
+	if err != nil {
+		return nil, fmt.Errorf("%s cannot decode the converted message with encoding %s: %w", c.to.GetChainType(), out.Encoding, err)
+	}
+	result.Raw = out
+	result.Lost = roundtrip.Differences(msg, decoded, carried(msg))
+	return result, nil
+}
+
+// translateType returns t when the target supports it, else the first supported type of t's phase.
+func translateType(t abstraction.MsgType, supported []abstraction.MsgType) (abstraction.MsgType, error) {
+	if containsType(supported, t) {
+		return t, nil
+	}
+	for _, phase := range phases {
+		if !containsType(phase, t) {
+			continue
+		}
+		for _, candidate := range phase {
+			if containsType(supported, candidate) {
+				return candidate, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no counterpart for message type %q among %v; choose one with -type", t, supported)
+}
+
+// carried is the profile of the fields msg sets, so that only what the source held counts as lost. Extensions
+// holding zero values are defaults the source decoder filled in and are left out.
+func carried(msg *abstraction.CanonicalMessage) roundtrip.Profile {
+	p := roundtrip.Profile{
+		ChainID:     msg.ChainID != "",
+		Type:        true,
+		Height:      msg.Height != nil,
+		Round:       msg.Round != nil,
+		View:        msg.View != nil,
+		Timestamp:   !msg.Timestamp.IsZero(),
+		BlockHash:   msg.BlockHash != "",
+		PrevHash:    msg.PrevHash != "",
+		Proposer:    msg.Proposer != "",
+		Validator:   msg.Validator != "",
+		Signature:   msg.Signature != "",
+		CommitSeals: len(msg.CommitSeals) > 0,
+		ViewChanges: len(msg.ViewChanges) > 0,
+	}
+	for key, value := range msg.Extensions {
+		if value != nil && !reflect.ValueOf(value).IsZero() {
+			p.Extensions = append(p.Extensions, key)
+		}
+	}
+	sort.Strings(p.Extensions)
+	return p
+}
+
+func containsType(types []abstraction.MsgType, t abstraction.MsgType) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
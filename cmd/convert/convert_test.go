@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	cometbftAdapter "codec/cometbft/adapter"
+	besuAdapter "codec/hyperledger/besu/adapter"
+	kaiaAdapter "codec/kaia/adapter"
+	"codec/message/abstraction"
+)
+
+func cometbftPrevote(t *testing.T) abstraction.RawConsensusMessage {
+	t.Helper()
+	msg := &abstraction.CanonicalMessage{
+		ChainID:    "convert-test",
+		Height:     big.NewInt(12),
+		Round:      big.NewInt(1),
+		Timestamp:  time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+		Type:       abstraction.MsgTypePrevote,
+		BlockHash:  strings.Repeat("AB", 32),
+		Validator:  strings.Repeat("CD", 20),
+		Signature:  "c2lnbmF0dXJl",
+		Extensions: abstraction.Extensions{"validator_index": int32(3)},
+	}
+	raw, err := cometbftAdapter.NewCometBFTMapper("convert-test").FromCanonical(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *raw
+}
+
+func TestConvertCometBFTPrevoteToBesu(t *testing.T) {
+	c := &converter{
+		from:    cometbftAdapter.NewCometBFTMapper("convert-test"),
+		to:      besuAdapter.NewBesuMapper("convert-test"),
+		chainID: "convert-test",
+	}
+	result, err := c.convert(cometbftPrevote(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Canonical.Type != abstraction.MsgTypePrepare || result.Raw.ChainType != abstraction.ChainTypeHyperledger {
+		t.Fatalf("expected a besu prepare, got %s %s", result.Raw.ChainType, result.Canonical.Type)
+	}
+	if want := "0x" + strings.Repeat("ab", 32); result.Canonical.BlockHash != want {
+		t.Fatalf("expected the block hash in besu form, got %s", result.Canonical.BlockHash)
+	}
+
+	lost := map[string]bool{}
+	for _, d := range result.Lost {
+		lost[d.Field] = true
+	}
+	if !lost["Signature"] || !lost["Extensions[validator_index]"] || lost["Height"] || lost["BlockHash"] {
+		t.Fatalf("unexpected lost fields %v", result.Lost)
+	}
+}
+
+func TestConvertRejectsEncodingTheTargetCannotRead(t *testing.T) {
+	c := &converter{
+		from:    cometbftAdapter.NewCometBFTMapper("convert-test"),
+		to:      kaiaAdapter.NewKaiaMapper("convert-test"),
+		chainID: "convert-test",
+		format:  "proto",
+	}
+	if _, err := c.convert(cometbftPrevote(t)); err == nil || !strings.Contains(err.Error(), "encoding proto") {
+		t.Fatalf("expected kaia to reject proto payloads, got %v", err)
+	}
+	c.format = "rlp"
+	if _, err := c.convert(cometbftPrevote(t)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTranslateType(t *testing.T) {
+	besu := besuAdapter.NewBesuMapper("").GetSupportedTypes()
+	cometbft := cometbftAdapter.NewCometBFTMapper("").GetSupportedTypes()
+	tests := []struct {
+		in        abstraction.MsgType
+		supported []abstraction.MsgType
+		want      abstraction.MsgType
+	}{
+		{abstraction.MsgTypePrevote, besu, abstraction.MsgTypePrepare},
+		{abstraction.MsgTypePrecommit, besu, abstraction.MsgTypeCommit},
+		{abstraction.MsgTypeRoundChange, besu, abstraction.MsgTypeRoundChange},
+		{abstraction.MsgTypeVote, cometbft, abstraction.MsgTypePrevote},
+		{abstraction.MsgTypeCommit, cometbft, abstraction.MsgTypePrecommit},
+	}
+	for _, tt := range tests {
+		got, err := translateType(tt.in, tt.supported)
+		if err != nil || got != tt.want {
+			t.Errorf("translateType(%s) = %s, %v; want %s", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := translateType(abstraction.MsgTypeNewView, besu); err == nil {
+		t.Error("expected new_view to have no besu counterpart")
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	_ "codec/adapters"
+	"codec/message/abstraction"
+)
+
+func main() {
+	log.SetFlags(0)
+	from := flag.String("from", "", "Source chain (cometbft, besu, kaia, fabric, ...); defaults to the input's chain_type")
+	to := flag.String("to", "", "Target chain")
+	inputPath := flag.String("in", "", "Raw message to convert: a JSON raw message, or a bare payload of the -from chain")
+	outputPath := flag.String("out", "", "Path to write the converted raw message to (default stdout)")
+	chainID := flag.String("chain-id", "", "Chain ID of the converted message; defaults to the input's")
+	format := flag.String("format", "", "Payload encoding of the converted message ("+strings.Join(encodings, "|")+"); defaults to the target's own")
+	msgType := flag.String("type", "", "Canonical message type of the converted message, overriding the translated one")
+	payloadType := flag.String("message-type", "", "Chain message type of a bare payload, for chains whose payload does not name it")
+	payloadEncoding := flag.String("payload-encoding", "json", "Encoding of a bare payload")
+	strict := flag.Bool("strict", false, "Fail instead of warning when the target chain drops fields the input set")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: convert -from cometbft -to besu -in vote.json [-out prepare.json] [-format json|rlp|proto] [-strict]")
+		fmt.Fprintln(os.Stderr, "Decodes a raw message to canonical form, translates it for the target chain, and encodes it with the target's mapper.")
+		fmt.Fprintln(os.Stderr, "Exits 1 when the message cannot be converted, or with -strict when the conversion loses fields, and 2 on bad flags or inputs.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if strings.TrimSpace(*inputPath) == "" || strings.TrimSpace(*to) == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format != "" && !containsString(encodings, *format) {
+		log.Printf("unknown -format %q (want %s)", *format, strings.Join(encodings, ", "))
+		os.Exit(2)
+	}
+	raw, err := readRaw(*inputPath, abstraction.ParseChainType(*from), *chainID, *payloadType, *payloadEncoding)
+	if err != nil {
+		log.Print(err)
+		os.Exit(2)
+	}
+	id := *chainID
+	if id == "" {
+		id = raw.ChainID
+	}
+	source, err := abstraction.NewMapper(raw.ChainType, raw.ChainID)
+	if err != nil {
+		log.Print(err)
+		os.Exit(2)
+	}
+	target, err := abstraction.NewMapper(abstraction.ParseChainType(*to), id)
+	if err != nil {
+		log.Print(err)
+		os.Exit(2)
+	}
+
+	c := &converter{from: source, to: target, chainID: id, msgType: abstraction.MsgType(strings.ToLower(*msgType)), format: *format}
+	result, err := c.convert(*raw)
+	if err != nil {
+		log.Printf("%s -> %s: %v", raw.ChainType, target.GetChainType(), err)
+		os.Exit(1)
+	}
+	for _, issue := range result.Warnings {
+		log.Printf("warning: %s: %s", issue.Field, issue.Message)
+	}
+	if result.Source.Type != result.Canonical.Type {
+		log.Printf("translated %s to %s", result.Source.Type, result.Canonical.Type)
+	}
+	for _, d := range result.Lost {
+		log.Printf("lost: %s", d)
+	}
+	if *strict && len(result.Lost) > 0 {
+		log.Printf("%s -> %s loses %d field(s)", raw.ChainType, target.GetChainType(), len(result.Lost))
+		os.Exit(1)
+	}
+
+	out, err := json.MarshalIndent(result.Raw, "", "  ")
+	if err != nil {
+		log.Printf("failed to marshal output: %v", err)
+		os.Exit(1)
+	}
+	out = append(out, '\n')
+	if strings.TrimSpace(*outputPath) == "" {
+		os.Stdout.Write(out)
+		return
+	}
+	if err := os.WriteFile(*outputPath, out, 0o644); err != nil {
+		log.Printf("failed to write output: %v", err)
+		os.Exit(2)
+	}
+	fmt.Fprintf(os.Stderr, "Converted %s %s to %s %s and wrote it to %s\n", raw.ChainType, result.Source.Type, target.GetChainType(), result.Canonical.Type, *outputPath)
+}
+
+// readRaw reads a raw message. A JSON object with chain_type and payload is taken as is; anything else is the
+// payload of a from message on chainID.
+func readRaw(path string, from abstraction.ChainType, chainID, messageType, encoding string) (*abstraction.RawConsensusMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) == nil && fields["chain_type"] != nil && fields["payload"] != nil {
+		var raw abstraction.RawConsensusMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse raw message: %w", err)
+		}
+		if from != "" && raw.ChainType != from {
+			return nil, fmt.Errorf("input is a %s message, not %s", raw.ChainType, from)
+		}
+		return &raw, nil
+	}
+	if from == "" {
+		return nil, fmt.Errorf("input is a bare payload; name its chain with -from")
+	}
+	return &abstraction.RawConsensusMessage{
+		ChainType:   from,
+		ChainID:     chainID,
+		MessageType: messageType,
+		Payload:     data,
+		Encoding:    encoding,
+		Timestamp:   abstraction.Now(),
+	}, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"strings"
+	"time"
+
+	cometbftAdapter "codec/cometbft/adapter"
+	besuAdapter "codec/hyperledger/besu/adapter"
+	fabricAdapter "codec/hyperledger/fabric/adapter"
+	kaiaAdapter "codec/kaia/adapter"
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+	"codec/message/abstraction/lint"
+)
+
+// chainSpec is what the generator needs to know about a chain: how to forge and encode its messages, the
+// formats its hashes and validator identities take, and the extensions its encoder requires.
+type chainSpec struct {
+	name      string
+	engine    *byzantine.Engine
+	mapper    abstraction.Mapper
+	profile   lint.Profile
+	validator func(i int) string
+	decorate  func(msg *abstraction.CanonicalMessage)
+
+	// actions lists the actions generated for the chain, each with the message types it forges.
+	actions []byzantine.Action
+	types   map[byzantine.Action][]abstraction.MsgType
+}
+
+func newChainSpec(name string) (*chainSpec, error) {
+	chainID := "corpus-" + name
+	switch abstraction.ChainType(name) {
+	case abstraction.ChainTypeCometBFT:
+		return &chainSpec{
+			name:      name,
+			engine:    cometbftAdapter.ByzantineEngine.Engine,
+			mapper:    cometbftAdapter.NewCometBFTMapper(chainID),
+			profile:   lint.DefaultProfile(abstraction.ChainTypeCometBFT),
+			validator: func(i int) string { return validatorAddress(i) },
+		}, nil
+	case "besu", abstraction.ChainTypeHyperledger:
+		return &chainSpec{
+			name:      "besu",
+			engine:    besuAdapter.ByzantineEngine,
+			mapper:    besuAdapter.NewBesuMapper(chainID),
+			profile:   lint.DefaultProfile(abstraction.ChainTypeHyperledger),
+			validator: func(i int) string { return "0x" + validatorAddress(i) },
+		}, nil
+	case abstraction.ChainTypeKaia:
+		return &chainSpec{
+			name:      name,
+			engine:    kaiaAdapter.ByzantineEngine,
+			mapper:    kaiaAdapter.NewKaiaMapper(chainID),
+			profile:   lint.DefaultProfile(abstraction.ChainTypeKaia),
+			validator: func(i int) string { return "0x" + validatorAddress(i) },
+		}, nil
+	case abstraction.ChainTypeFabric:
+		return &chainSpec{
+			name:      name,
+			engine:    fabricAdapter.ByzantineEngine,
+			mapper:    fabricAdapter.NewFabricMapper(chainID),
+			profile:   lint.DefaultProfile(abstraction.ChainTypeFabric),
+			validator: func(i int) string { return fmt.Sprintf("OrdererMSP/%d", i+1) },
+			decorate: func(msg *abstraction.CanonicalMessage) {
+				msg.Extensions["channel_id"] = "corpuschannel"
+				msg.Extensions["config_seq"] = uint64(1)
+				msg.Extensions["msp_id"] = "OrdererMSP"
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported chain %q (want cometbft, besu, kaia, or fabric)", name)
+	}
+}
+
+// validatorAddress derives a stable 20-byte address for validator i.
+func validatorAddress(i int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("corpus-validator-%d", i)))
+	return hex.EncodeToString(sum[:20])
+}
+
+// generator draws benign messages and forges byzantine versions of them.
+type generator struct {
+	chains     []*chainSpec
+	from, to   int64
+	validators int
+	rng        *rand.Rand
+}
+
+type corpusStats struct {
+	pairs int
+	rows  int
+}
+
+func newGenerator(chains, actions []string, from, to int64, validators int) (*generator, error) {
+	if len(chains) == 0 {
+		return nil, fmt.Errorf("no chains given")
+	}
+	g := &generator{from: from, to: to, validators: validators, rng: abstraction.NewRand()}
+	for _, name := range chains {
+		spec, err := newChainSpec(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := g.selectActions(spec, actions); err != nil {
+			return nil, err
+		}
+		g.chains = append(g.chains, spec)
+	}
+	return g, nil
+}
+
+// selectActions keeps the requested actions the chain supports and probes which message types each one forges
+// into at least one message. Actions that reject every type, or only withhold messages and so leave nothing
+// to label, are left out.
+func (g *generator) selectActions(spec *chainSpec, requested []string) error {
+	var candidates []byzantine.Action
+	if len(requested) == 1 && requested[0] == "all" {
+		candidates = spec.engine.Actions()
+	} else {
+		for _, name := range requested {
+			action, err := spec.engine.Parse(name)
+			if err != nil {
+				log.Printf("%s: leaving out %s: %v", spec.name, name, err)
+				continue
+			}
+			candidates = append(candidates, action)
+		}
+	}
+	spec.types = make(map[byzantine.Action][]abstraction.MsgType)
+	for _, action := range candidates {
+		if action == byzantine.ActionNone {
+			continue
+		}
+		for _, t := range spec.mapper.GetSupportedTypes() {
+			benign := g.benign(spec, t, g.from)
+			if _, _, err := g.forge(spec, benign, action); err == nil {
+				spec.types[action] = append(spec.types[action], t)
+			}
+		}
+		if len(spec.types[action]) == 0 {
+			log.Printf("%s: leaving out %s: it forges no message from any type", spec.name, action)
+			continue
+		}
+		spec.actions = append(spec.actions, action)
+	}
+	if len(spec.actions) == 0 {
+		return fmt.Errorf("%s: none of the requested actions apply", spec.name)
+	}
+	return nil
+}
+
+// run generates count pairs, cycling through the chains and through each chain's actions so every
+// combination is equally represented, and passes each row to emit. A pair is one benign row followed by
+// the byzantine rows forged from it, all sharing a sample number.
+func (g *generator) run(count int, emit func(row) error) (corpusStats, error) {
+	var stats corpusStats
+	for i := 0; i < count; i++ {
+		spec := g.chains[i%len(g.chains)]
+		action := spec.actions[(i/len(g.chains))%len(spec.actions)]
+		types := spec.types[action]
+		height := g.from + g.rng.Int63n(g.to-g.from+1)
+		benign := g.benign(spec, types[g.rng.Intn(len(types))], height)
+
+		benignRaw, err := spec.mapper.FromCanonical(benign)
+		if err != nil {
+			return stats, fmt.Errorf("%s: failed to encode benign %s: %w", spec.name, benign.Type, err)
+		}
+		forged, raws, err := g.forge(spec, benign, action)
+		if err != nil {
+			return stats, fmt.Errorf("%s: %s of %s: %w", spec.name, action, benign.Type, err)
+		}
+
+		sample := int64(i)
+		if err := emit(newRow(sample, spec.name, byzantine.ActionNone, benign, benignRaw)); err != nil {
+			return stats, err
+		}
+		for j, msg := range forged {
+			if err := emit(newRow(sample, spec.name, action, msg, raws[j])); err != nil {
+				return stats, err
+			}
+		}
+		stats.pairs++
+		stats.rows += 1 + len(forged)
+	}
+	return stats, nil
+}
+
+// forge applies action to benign and encodes the messages that differ from it. Payload actions leave the
+// canonical message alone, so all of their outputs are kept.
+func (g *generator) forge(spec *chainSpec, benign *abstraction.CanonicalMessage, action byzantine.Action) ([]*abstraction.CanonicalMessage, []*abstraction.RawConsensusMessage, error) {
+	opts := g.options(spec, benign)
+	outputs, err := spec.engine.Apply(byzantine.Clone(benign), action, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	forged := byzantine.Conflicting(benign, outputs)
+	if len(forged) == 0 {
+		return nil, nil, fmt.Errorf("%s withheld the message", action)
+	}
+	// Conflicting returns copies only when nothing changed, which labels nothing unless the payload is damaged.
+	if !spec.engine.IsPayloadAction(action) && reflect.DeepEqual(forged[0], benign) {
+		return nil, nil, fmt.Errorf("%s left the message unchanged", action)
+	}
+	raws, err := byzantine.Encode(spec.mapper, forged)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, raw := range raws {
+		if raw.Payload, err = spec.engine.MutatePayload(action, raw.Payload, opts); err != nil {
+			return nil, nil, err
+		}
+	}
+	return forged, raws, nil
+}
+
+// options draws the knobs of one forgery: the identity alter_validator assumes, the timestamp skew, the
+// flood range, and the fuzz seed.
+func (g *generator) options(spec *chainSpec, benign *abstraction.CanonicalMessage) byzantine.Options {
+	index, _ := benign.Extensions.GetInt64("validator_index")
+	other := (int(index) + 1 + g.rng.Intn(g.validators-1)) % g.validators
+	skew := time.Duration(1+g.rng.Intn(30)) * time.Second
+	if g.rng.Intn(2) == 0 {
+		skew = -skew
+	}
+	floodFrom := int64(1 + g.rng.Intn(5))
+	floodTo := floodFrom + int64(g.rng.Intn(3))
+	if g.rng.Intn(2) == 0 && benign.Height.Int64() > floodTo {
+		floodFrom, floodTo = -floodTo, -floodFrom
+	}
+	return byzantine.Options{
+		AlternateValidator: spec.validator(other),
+		TimestampShift:     skew,
+		FloodFrom:          floodFrom,
+		FloodTo:            floodTo,
+		FuzzSeed:           g.rng.Int63(),
+	}
+}
+
+// benign builds an honest message of type t at height, in the chain's formats.
+func (g *generator) benign(spec *chainSpec, t abstraction.MsgType, height int64) *abstraction.CanonicalMessage {
+	round := int64(0)
+	if g.rng.Intn(4) == 0 {
+		round = int64(1 + g.rng.Intn(3))
+	}
+	validator := g.rng.Intn(g.validators)
+	proposer := int((height + round) % int64(g.validators))
+	if t == abstraction.MsgTypeProposal {
+		validator = proposer
+	}
+
+	msg := byzantine.DefaultProbe(t)
+	msg.ChainID = "corpus-" + spec.name
+	msg.Height = big.NewInt(height)
+	msg.Round, msg.View = nil, nil
+	switch {
+	case spec.profile.RequiresView:
+		msg.View = big.NewInt(round)
+	default:
+		msg.Round = big.NewInt(round)
+	}
+	msg.Timestamp = abstraction.Now().UTC()
+	msg.BlockHash = g.hash(spec)
+	msg.PrevHash = g.hash(spec)
+	msg.Proposer = spec.validator(proposer)
+	msg.Validator = spec.validator(validator)
+	msg.Signature = g.signature(spec)
+	msg.Extensions = map[string]interface{}{"validator_index": int32(validator)}
+	if spec.decorate != nil {
+		spec.decorate(msg)
+	}
+	return msg
+}
+
+// hash draws a block hash rendered the way the chain renders them.
+func (g *generator) hash(spec *chainSpec) string {
+	size := spec.profile.HashBytes
+	if size == 0 {
+		size = 32
+	}
+	b := make([]byte, size)
+	g.rng.Read(b)
+	h := hex.EncodeToString(b)
+	if spec.profile.HashUpper {
+		h = strings.ToUpper(h)
+	}
+	return spec.profile.HashPrefix + h
+}
+
+// signature draws a signature: base64 for chains with bare hashes, as CometBFT's JSON has it, and 0x-prefixed
+// hex for the Ethereum-style chains.
+func (g *generator) signature(spec *chainSpec) string {
+	b := make([]byte, 64)
+	g.rng.Read(b)
+	if spec.profile.HashPrefix == "0x" {
+		return "0x" + hex.EncodeToString(b)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func (g *generator) chainNames() []string {
+	names := make([]string, len(g.chains))
+	for i, spec := range g.chains {
+		names[i] = spec.name
+	}
+	return names
+}
+
+// actionNames lists every generated action per chain.
+func (g *generator) actionNames() map[string][]byzantine.Action {
+	actions := make(map[string][]byzantine.Action, len(g.chains))
+	for _, spec := range g.chains {
+		actions[spec.name] = spec.actions
+	}
+	return actions
+}
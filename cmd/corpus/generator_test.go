@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"codec/message/abstraction"
+)
+
+func generate(t *testing.T, seed int64) []row {
+	t.Helper()
+	abstraction.Seed(seed)
+	gen, err := newGenerator([]string{"cometbft", "besu", "kaia", "fabric"}, []string{"all"}, 1, 100, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []row
+	stats, err := gen.run(400, func(r row) error {
+		rows = append(rows, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if stats.pairs != 400 || stats.rows != len(rows) {
+		t.Fatalf("unexpected stats %+v for %d rows", stats, len(rows))
+	}
+	return rows
+}
+
+func TestGeneratorLabelsPairs(t *testing.T) {
+	rows := generate(t, 1)
+
+	actions := make(map[string]map[string]bool)
+	for i := 0; i < len(rows); {
+		benign := rows[i]
+		if benign.Label != 0 || benign.Action != "none" || len(benign.Payload) == 0 {
+			t.Fatalf("sample %d does not start with a benign row: %+v", benign.Sample, benign)
+		}
+		j := i + 1
+		for ; j < len(rows) && rows[j].Sample == benign.Sample; j++ {
+			forged := rows[j]
+			if forged.Label != 1 || forged.Action == "none" || forged.Chain != benign.Chain {
+				t.Fatalf("sample %d has an unlabelled forgery: %+v", benign.Sample, forged)
+			}
+			if actions[forged.Chain] == nil {
+				actions[forged.Chain] = make(map[string]bool)
+			}
+			actions[forged.Chain][forged.Action] = true
+		}
+		if j == i+1 {
+			t.Fatalf("sample %d has no byzantine row", benign.Sample)
+		}
+		i = j
+	}
+
+	for _, want := range []struct{ chain, action string }{
+		{"cometbft", "amnesia"}, {"besu", "double_vote"}, {"kaia", "alter_validator"}, {"fabric", "forge_identity"},
+	} {
+		if !actions[want.chain][want.action] {
+			t.Errorf("no %s rows for %s; got %v", want.action, want.chain, actions[want.chain])
+		}
+	}
+	if actions["cometbft"]["withhold_commit"] || actions["besu"]["timestamp_skew"] {
+		t.Error("actions that forge nothing were generated")
+	}
+
+	if !reflect.DeepEqual(rows, generate(t, 1)) {
+		t.Error("the same seed produced a different corpus")
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"codec/cmd/internal/cli"
+	"codec/experiment"
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+func main() {
+	log.SetFlags(0)
+	outputPath := flag.String("o", "", "Path to write the corpus to")
+	format := flag.String("format", "", "Output format (jsonl|parquet); defaults to parquet for a .parquet output and jsonl otherwise")
+	count := flag.Int("count", 1000, "Number of benign/byzantine pairs to generate")
+	chainsFlag := flag.String("chains", "cometbft,besu,kaia,fabric", "Comma-separated chains to generate messages for")
+	actionsFlag := flag.String("actions", "all", "Comma-separated byzantine actions, or all for every action each chain supports; chains skip actions they lack")
+	heights := flag.String("heights", "1..10000", "Height range as N..M that benign messages are drawn from")
+	validators := flag.Int("validators", 4, "Number of validators per chain")
+	seed := flag.Int64("seed", 0, "Seed for timestamps and random draws so the corpus replays exactly (0 uses the clock)")
+	manifestPath := flag.String("manifest", "", "Optional path to write a run manifest listing the corpus")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: corpus -o corpus.parquet [-count N] [-chains cometbft,...] [-actions double_vote,...]")
+		fmt.Fprintln(os.Stderr, "Generates labelled pairs of benign messages and byzantine forgeries of them for training and evaluating anomaly detectors.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if strings.TrimSpace(*outputPath) == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *count <= 0 || *validators < 2 {
+		log.Print("-count must be positive and -validators at least 2")
+		os.Exit(2)
+	}
+	if *format == "" {
+		*format = "jsonl"
+		if strings.EqualFold(filepath.Ext(*outputPath), ".parquet") {
+			*format = "parquet"
+		}
+	}
+	from, to, err := byzantine.ParseRange(*heights)
+	if err != nil || from < 1 {
+		log.Printf("invalid -heights %q: want N..M with N >= 1", *heights)
+		os.Exit(2)
+	}
+	if *seed != 0 {
+		abstraction.Seed(*seed)
+	}
+	manifest := experiment.NewManifest("corpus")
+
+	gen, err := newGenerator(cli.SplitList(strings.ToLower(*chainsFlag)), cli.SplitList(strings.ToLower(*actionsFlag)), from, to, *validators)
+	if err != nil {
+		log.Print(err)
+		os.Exit(2)
+	}
+
+	f, err := os.Create(*outputPath)
+	if err != nil {
+		log.Printf("failed to create output: %v", err)
+		os.Exit(2)
+	}
+	out := bufio.NewWriter(f)
+	var w rowWriter
+	switch strings.ToLower(*format) {
+	case "jsonl":
+		w = newJSONLWriter(out)
+	case "parquet":
+		w, err = newParquetRowWriter(out)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		f.Close()
+		log.Print(err)
+		os.Exit(2)
+	}
+
+	stats, err := gen.run(*count, w.Write)
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		log.Printf("failed to write corpus: %v", err)
+		os.Exit(1)
+	}
+
+	if strings.TrimSpace(*manifestPath) != "" {
+		manifest.SetParameter("chains", gen.chainNames())
+		manifest.SetParameter("actions", gen.actionNames())
+		manifest.SetParameter("heights", *heights)
+		manifest.SetParameter("validators", *validators)
+		manifest.SetParameter("pairs", stats.pairs)
+		manifest.SetParameter("rows", stats.rows)
+		manifest.SetParameter("format", *format)
+		if *seed != 0 {
+			manifest.SetParameter("seed", *seed)
+		}
+		manifest.Finish(nil)
+		name, err := filepath.Rel(filepath.Dir(*manifestPath), *outputPath)
+		if err == nil {
+			err = manifest.AddArtifact(name, *outputPath)
+		}
+		if err == nil {
+			err = manifest.WriteFile(*manifestPath)
+		}
+		if err != nil {
+			log.Printf("failed to write manifest: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("Generated %d pairs (%d benign and %d byzantine rows) and wrote them to %s\n", stats.pairs, stats.pairs, stats.rows-stats.pairs, *outputPath)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestParquetRowWriterRoundTrip(t *testing.T) {
+	generated := generate(t, 1)
+	var rows []row
+	for len(rows) <= parquetRowGroupRows {
+		rows = append(rows, generated...)
+	}
+
+	var buf bytes.Buffer
+	w, err := newParquetRowWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rows {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("not a parquet file: %v", err)
+	}
+	if file.NumRows() != int64(len(rows)) || len(file.RowGroups()) != 2 {
+		t.Fatalf("file has %d rows in %d row groups, want %d rows in 2", file.NumRows(), len(file.RowGroups()), len(rows))
+	}
+	fields := file.Schema().Fields()
+	if len(fields) != 17 || fields[0].Name() != "sample" || fields[16].Name() != "payload" {
+		t.Fatalf("unexpected schema %v", file.Schema())
+	}
+	if chain, ok := file.Schema().Lookup("chain"); !ok || chain.Node.Type().LogicalType() == nil || chain.Node.Type().LogicalType().UTF8 == nil {
+		t.Fatalf("chain column is not a UTF-8 string: %v", file.Schema())
+	}
+
+	reader := parquet.NewGenericReader[row](bytes.NewReader(buf.Bytes()))
+	defer reader.Close()
+	read := make([]row, 0, len(rows))
+	batch := make([]row, 1000)
+	for {
+		n, err := reader.Read(batch)
+		read = append(read, batch[:n]...)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+	}
+	if !reflect.DeepEqual(read, rows) {
+		t.Fatalf("read back %d rows that differ from the %d written", len(read), len(rows))
+	}
+}
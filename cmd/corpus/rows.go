@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// row is one labelled message of the corpus. Label is 0 for the benign message of a pair and 1 for the
+// byzantine messages forged from it; Action names the attack, or none. Round holds the view for chains that
+// count views instead. Payload is the message as the chain's mapper encodes it.
+type row struct {
+	Sample      int64  `json:"sample" parquet:"sample"`
+	Label       int32  `json:"label" parquet:"label"`
+	Action      string `json:"action" parquet:"action"`
+	Chain       string `json:"chain" parquet:"chain"`
+	ChainID     string `json:"chain_id" parquet:"chain_id"`
+	Type        string `json:"type" parquet:"type"`
+	Height      int64  `json:"height" parquet:"height"`
+	Round       int64  `json:"round" parquet:"round"`
+	Validator   string `json:"validator" parquet:"validator"`
+	Proposer    string `json:"proposer" parquet:"proposer"`
+	BlockHash   string `json:"block_hash" parquet:"block_hash"`
+	PrevHash    string `json:"prev_hash" parquet:"prev_hash"`
+	Signature   string `json:"signature" parquet:"signature"`
+	TimestampMS int64  `json:"timestamp_ms" parquet:"timestamp_ms"`
+	MessageType string `json:"message_type" parquet:"message_type"`
+	Encoding    string `json:"encoding" parquet:"encoding"`
+	Payload     []byte `json:"payload" parquet:"payload"`
+}
+
+func newRow(sample int64, chain string, action byzantine.Action, msg *abstraction.CanonicalMessage, raw *abstraction.RawConsensusMessage) row {
+	r := row{
+		Sample:      sample,
+		Action:      string(action),
+		Chain:       chain,
+		ChainID:     msg.ChainID,
+		Type:        string(msg.Type),
+		Validator:   msg.Validator,
+		Proposer:    msg.Proposer,
+		BlockHash:   msg.BlockHash,
+		PrevHash:    msg.PrevHash,
+		Signature:   msg.Signature,
+		TimestampMS: msg.Timestamp.UnixMilli(),
+		MessageType: raw.MessageType,
+		Encoding:    raw.Encoding,
+		Payload:     raw.Payload,
+	}
+	if action != byzantine.ActionNone {
+		r.Label = 1
+	}
+	r.Height, _ = msg.HeightInt64()
+	if round, ok := msg.RoundInt64(); ok {
+		r.Round = round
+	} else if view, ok := msg.ViewInt64(); ok {
+		r.Round = view
+	}
+	return r
+}
+
+// rowWriter writes the corpus in one output format.
+type rowWriter interface {
+	Write(r row) error
+	Close() error
+}
+
+// jsonlWriter writes one JSON object per row; the payload is base64.
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	return &jsonlWriter{encoder: json.NewEncoder(w)}
+}
+
+func (w *jsonlWriter) Write(r row) error { return w.encoder.Encode(r) }
+
+func (w *jsonlWriter) Close() error { return nil }
+
+// parquetRowGroupRows bounds the rows buffered in memory before a row group is written.
+const parquetRowGroupRows = 64 * 1024
+
+// parquetRowWriter writes the corpus as Apache Parquet, so it loads straight into pandas, Spark, or DuckDB. The
+// schema follows the parquet tags of row; strings are UTF-8 byte arrays.
+type parquetRowWriter struct {
+	w *parquet.GenericWriter[row]
+}
+
+func newParquetRowWriter(w io.Writer) (*parquetRowWriter, error) {
+	return &parquetRowWriter{w: parquet.NewGenericWriter[row](w, parquet.MaxRowsPerRowGroup(parquetRowGroupRows))}, nil
+}
+
+func (w *parquetRowWriter) Write(r row) error {
+	_, err := w.w.Write([]row{r})
+	return err
+}
+
+func (w *parquetRowWriter) Close() error { return w.w.Close() }
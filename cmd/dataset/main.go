@@ -0,0 +1,170 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"codec/experiment"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "keygen":
+		os.Exit(runKeygen(os.Args[2:]))
+	case "sign":
+		os.Exit(runSign(os.Args[2:]))
+	case "verify":
+		os.Exit(runVerify(os.Args[2:]))
+	case "help", "-h", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: dataset <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  keygen  Create a lab signing key (minisign compatible)")
+	fmt.Fprintln(os.Stderr, "  sign    Sign a run manifest and the artifacts it lists")
+	fmt.Fprintln(os.Stderr, "  verify  Verify a published dataset against a lab public key")
+}
+
+func runKeygen(args []string) int {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	out := fs.String("o", "lab", "Key path prefix; writes <prefix>.key and <prefix>.pub")
+	force := fs.Bool("force", false, "Overwrite existing key files")
+	fs.Parse(args)
+
+	secretPath, publicPath := *out+".key", *out+".pub"
+	if !*force {
+		for _, path := range []string{secretPath, publicPath} {
+			if _, err := os.Stat(path); err == nil {
+				log.Printf("%s already exists; use -force to replace it", path)
+				return 2
+			}
+		}
+	}
+
+	key, err := experiment.GenerateSigningKey()
+	if err != nil {
+		log.Printf("failed to generate key: %v", err)
+		return 1
+	}
+	if err := key.WriteFile(secretPath); err != nil {
+		log.Printf("failed to write secret key: %v", err)
+		return 1
+	}
+	if err := key.Public().WriteFile(publicPath); err != nil {
+		log.Printf("failed to write public key: %v", err)
+		return 1
+	}
+	fmt.Printf("Key %s written to %s (keep private) and %s (publish)\n", experiment.KeyIDString(key.KeyID), secretPath, publicPath)
+	return 0
+}
+
+func runSign(args []string) int {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	keyPath := fs.String("key", "", "Lab secret key (from dataset keygen or minisign -G -W)")
+	manifestPath := fs.String("manifest", "", "Run manifest to sign")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: dataset sign -key lab.key -manifest manifest.json [artifact...]")
+		fmt.Fprintln(os.Stderr, "Artifacts given on the command line are added to the manifest before signing.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *keyPath == "" || *manifestPath == "" {
+		fs.Usage()
+		return 2
+	}
+	key, err := experiment.LoadSigningKey(*keyPath)
+	if err != nil {
+		log.Printf("%v", err)
+		return 2
+	}
+
+	if fs.NArg() > 0 {
+		manifest, err := experiment.LoadManifest(*manifestPath)
+		if err != nil {
+			log.Printf("%v", err)
+			return 2
+		}
+		for _, path := range fs.Args() {
+			name, err := filepath.Rel(filepath.Dir(*manifestPath), path)
+			if err != nil {
+				log.Printf("%v", err)
+				return 2
+			}
+			if err := manifest.AddArtifact(name, path); err != nil {
+				log.Printf("%v", err)
+				return 1
+			}
+		}
+		if err := manifest.WriteFile(*manifestPath); err != nil {
+			log.Printf("%v", err)
+			return 1
+		}
+	}
+
+	if err := experiment.SignDataset(key, *manifestPath); err != nil {
+		log.Printf("%v", err)
+		return 1
+	}
+	fmt.Printf("Signed %s with key %s\n", *manifestPath, experiment.KeyIDString(key.KeyID))
+	return 0
+}
+
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	pubPath := fs.String("pub", "", "Lab public key file")
+	pubKey := fs.String("P", "", "Lab public key as a base64 string (instead of -pub)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: dataset verify (-pub lab.pub | -P <key>) manifest.json...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 || (*pubPath == "") == (*pubKey == "") {
+		fs.Usage()
+		return 2
+	}
+	var pub experiment.PublicKey
+	var err error
+	if *pubPath != "" {
+		pub, err = experiment.LoadPublicKey(*pubPath)
+	} else {
+		pub, err = experiment.ParsePublicKey(*pubKey)
+	}
+	if err != nil {
+		log.Printf("%v", err)
+		return 2
+	}
+
+	failed := false
+	for _, path := range fs.Args() {
+		manifest, err := experiment.VerifyDataset(pub, path)
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("OK   %s (run %s, %d artifacts, key %s)\n", path, manifest.RunID, len(manifest.Artifacts), experiment.KeyIDString(pub.KeyID))
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
@@ -9,9 +9,10 @@ import (
 
 	cometbftAdapter "codec/cometbft/adapter"
 	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
 )
 
-func runByzantineScenario(mapper *cometbftAdapter.CometBFTMapper, actionFlag, canonicalPath, alternateBlock, alternatePrev, alternateSig, alternateValidator string, roundOffset, heightOffset int64, timestampSkew time.Duration) {
+func runByzantineScenario(mapper *cometbftAdapter.CometBFTMapper, actionFlag, canonicalPath, alternateBlock, alternatePrev, alternateSig, alternateValidator string, roundOffset, heightOffset int64, timestampSkew time.Duration, floodRange string) {
 	fmt.Println("🧨 Byzantine Message Emission")
 	fmt.Println("============================")
 
@@ -30,6 +31,15 @@ func runByzantineScenario(mapper *cometbftAdapter.CometBFTMapper, actionFlag, ca
 	fmt.Printf("Using canonical message from %s\n", sourceDescription)
 	printCanonicalMessage(canonical)
 
+	var floodFrom, floodTo int64
+	if strings.TrimSpace(floodRange) != "" {
+		floodFrom, floodTo, err = byzantine.ParseRange(floodRange)
+		if err != nil {
+			fmt.Printf("invalid flood range %q: %v\n", floodRange, err)
+			return
+		}
+	}
+
 	opts := cometbftAdapter.ByzantineOptions{
 		AlternateBlockHash: alternateBlock,
 		AlternatePrevHash:  alternatePrev,
@@ -38,6 +48,8 @@ func runByzantineScenario(mapper *cometbftAdapter.CometBFTMapper, actionFlag, ca
 		RoundOffset:        roundOffset,
 		HeightOffset:       heightOffset,
 		TimestampShift:     timestampSkew,
+		FloodFrom:          floodFrom,
+		FloodTo:            floodTo,
 	}
 
 	byzCanonicals, err := cometbftAdapter.ApplyByzantineCanonical(canonical, action, opts)
@@ -70,7 +82,7 @@ func loadCanonicalForScenario(mapper *cometbftAdapter.CometBFTMapper, canonicalP
 			return nil, "file://" + canonicalPath, err
 		}
 		if canonical.Timestamp.IsZero() {
-			canonical.Timestamp = time.Now().UTC()
+			canonical.Timestamp = abstraction.Now().UTC()
 		}
 		return canonical, "file://" + canonicalPath, nil
 	}
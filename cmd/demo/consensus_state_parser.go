@@ -9,116 +9,10 @@ import (
 	"time"
 
 	cometbftAdapter "codec/cometbft/adapter"
+	"codec/cometbft/rpcstate"
 	"codec/message/abstraction"
 )
 
-// ConsensusState represents the structure of CometBFT consensus state
-type ConsensusState struct {
-	Jsonrpc string `json:"jsonrpc"`
-	ID      int    `json:"id"`
-	Result  struct {
-		RoundState struct {
-			Height     string `json:"height"`
-			Round      int    `json:"round"`
-			Step       int    `json:"step"`
-			StartTime  string `json:"start_time"`
-			CommitTime string `json:"commit_time"`
-			Validators struct {
-				Validators []struct {
-					Address string `json:"address"`
-					PubKey  struct {
-						Type  string `json:"type"`
-						Value string `json:"value"`
-					} `json:"pub_key"`
-					VotingPower      string `json:"voting_power"`
-					ProposerPriority string `json:"proposer_priority"`
-				} `json:"validators"`
-				Proposer struct {
-					Address string `json:"address"`
-					PubKey  struct {
-						Type  string `json:"type"`
-						Value string `json:"value"`
-					} `json:"pub_key"`
-					VotingPower      string `json:"voting_power"`
-					ProposerPriority string `json:"proposer_priority"`
-				} `json:"proposer"`
-			} `json:"validators"`
-			Proposal           interface{} `json:"proposal"`
-			ProposalBlock      interface{} `json:"proposal_block"`
-			ProposalBlockParts interface{} `json:"proposal_block_parts"`
-			LockedRound        int         `json:"locked_round"`
-			LockedBlock        interface{} `json:"locked_block"`
-			LockedBlockParts   interface{} `json:"locked_block_parts"`
-			ValidRound         int         `json:"valid_round"`
-			ValidBlock         interface{} `json:"valid_block"`
-			ValidBlockParts    interface{} `json:"valid_block_parts"`
-			Votes              []struct {
-				Round              int      `json:"round"`
-				Prevotes           []string `json:"prevotes"`
-				PrevotesBitArray   string   `json:"prevotes_bit_array"`
-				Precommits         []string `json:"precommits"`
-				PrecommitsBitArray string   `json:"precommits_bit_array"`
-			} `json:"votes"`
-			CommitRound int `json:"commit_round"`
-			LastCommit  struct {
-				Votes         []string `json:"votes"`
-				VotesBitArray string   `json:"votes_bit_array"`
-				PeerMaj23s    struct{} `json:"peer_maj_23s"`
-			} `json:"last_commit"`
-			LastValidators struct {
-				Validators []struct {
-					Address string `json:"address"`
-					PubKey  struct {
-						Type  string `json:"type"`
-						Value string `json:"value"`
-					} `json:"pub_key"`
-					VotingPower      string `json:"voting_power"`
-					ProposerPriority string `json:"proposer_priority"`
-				} `json:"validators"`
-				Proposer struct {
-					Address string `json:"address"`
-					PubKey  struct {
-						Type  string `json:"type"`
-						Value string `json:"value"`
-					} `json:"pub_key"`
-					VotingPower      string `json:"voting_power"`
-					ProposerPriority string `json:"proposer_priority"`
-				} `json:"proposer"`
-			} `json:"last_validators"`
-			TriggeredTimeoutPrecommit bool `json:"triggered_timeout_precommit"`
-		} `json:"round_state"`
-		Peers []struct {
-			NodeAddress string `json:"node_address"`
-			PeerState   struct {
-				RoundState struct {
-					Height                     string `json:"height"`
-					Round                      int    `json:"round"`
-					Step                       int    `json:"step"`
-					StartTime                  string `json:"start_time"`
-					Proposal                   bool   `json:"proposal"`
-					ProposalBlockPartSetHeader struct {
-						Total int    `json:"total"`
-						Hash  string `json:"hash"`
-					} `json:"proposal_block_part_set_header"`
-					ProposalBlockParts interface{} `json:"proposal_block_parts"`
-					ProposalPolRound   int         `json:"proposal_pol_round"`
-					ProposalPol        string      `json:"proposal_pol"`
-					Prevotes           string      `json:"prevotes"`
-					Precommits         string      `json:"precommits"`
-					LastCommitRound    int         `json:"last_commit_round"`
-					LastCommit         string      `json:"last_commit"`
-					CatchupCommitRound int         `json:"catchup_commit_round"`
-					CatchupCommit      string      `json:"catchup_commit"`
-				} `json:"round_state"`
-				Stats struct {
-					Votes      string `json:"votes"`
-					BlockParts string `json:"block_parts"`
-				} `json:"stats"`
-			} `json:"peer_state"`
-		} `json:"peers"`
-	} `json:"result"`
-}
-
 func RunConsensusStateParser() {
 	fmt.Println("🔍 CometBFT Consensus State 파서")
 	fmt.Println("=================================")
@@ -132,40 +26,42 @@ func RunConsensusStateParser() {
 	fmt.Println("✅ Consensus State JSON 파일 읽기 완료")
 
 	// JSON 파싱
-	var consensusState ConsensusState
-	if err := json.Unmarshal(consensusData, &consensusState); err != nil {
+	consensusState, err := rpcstate.Decode(consensusData)
+	if err != nil {
 		fmt.Printf("❌ JSON 파싱 실패: %v\n", err)
 		return
 	}
+	rs := consensusState.RoundState
 
 	// Consensus State 분석
 	fmt.Println("\n📊 Consensus State 분석:")
-	fmt.Printf("   Height: %s\n", consensusState.Result.RoundState.Height)
-	fmt.Printf("   Round: %d\n", consensusState.Result.RoundState.Round)
-	fmt.Printf("   Step: %d\n", consensusState.Result.RoundState.Step)
-	fmt.Printf("   Start Time: %s\n", consensusState.Result.RoundState.StartTime)
-	fmt.Printf("   Commit Time: %s\n", consensusState.Result.RoundState.CommitTime)
-	fmt.Printf("   Validators: %d개\n", len(consensusState.Result.RoundState.Validators.Validators))
-	fmt.Printf("   Peers: %d개\n", len(consensusState.Result.Peers))
+	fmt.Printf("   Height: %d\n", rs.Height)
+	fmt.Printf("   Round: %d\n", rs.Round)
+	fmt.Printf("   Step: %d\n", rs.Step)
+	fmt.Printf("   Start Time: %s\n", rs.StartTime)
+	fmt.Printf("   Commit Time: %s\n", rs.CommitTime)
+	fmt.Printf("   Validators: %d개\n", len(rs.Validators.Validators))
+	fmt.Printf("   Peers: %d개\n", len(consensusState.Peers))
 
 	// Validator 정보 출력
 	fmt.Println("\n👥 Validator 정보:")
-	for i, validator := range consensusState.Result.RoundState.Validators.Validators {
+	for i, validator := range rs.Validators.Validators {
 		fmt.Printf("   [%d] Address: %s\n", i+1, validator.Address[:12]+"...")
-		fmt.Printf("       Voting Power: %s\n", validator.VotingPower)
-		fmt.Printf("       Proposer Priority: %s\n", validator.ProposerPriority)
+		fmt.Printf("       Voting Power: %d\n", validator.VotingPower)
+		fmt.Printf("       Proposer Priority: %d\n", validator.ProposerPriority)
 	}
 
 	// Proposer 정보
 	fmt.Println("\n🎯 Current Proposer:")
-	proposer := consensusState.Result.RoundState.Validators.Proposer
-	fmt.Printf("   Address: %s\n", proposer.Address[:12]+"...")
-	fmt.Printf("   Voting Power: %s\n", proposer.VotingPower)
-	fmt.Printf("   Proposer Priority: %s\n", proposer.ProposerPriority)
+	if proposer := rs.Validators.Proposer; proposer != nil {
+		fmt.Printf("   Address: %s\n", proposer.Address[:12]+"...")
+		fmt.Printf("   Voting Power: %d\n", proposer.VotingPower)
+		fmt.Printf("   Proposer Priority: %d\n", proposer.ProposerPriority)
+	}
 
 	// Votes 분석
 	fmt.Println("\n🗳️ Votes 분석:")
-	for i, vote := range consensusState.Result.RoundState.Votes {
+	for i, vote := range rs.Votes {
 		fmt.Printf("   Round %d:\n", vote.Round)
 		fmt.Printf("     Prevotes: %s\n", vote.PrevotesBitArray)
 		fmt.Printf("     Precommits: %s\n", vote.PrecommitsBitArray)
@@ -179,10 +75,10 @@ func RunConsensusStateParser() {
 
 	// Last Commit 분석
 	fmt.Println("\n📝 Last Commit 분석:")
-	fmt.Printf("   Votes Bit Array: %s\n", consensusState.Result.RoundState.LastCommit.VotesBitArray)
-	fmt.Printf("   Total Votes: %d개\n", len(consensusState.Result.RoundState.LastCommit.Votes))
+	fmt.Printf("   Votes Bit Array: %s\n", rs.LastCommit.VotesBitArray)
+	fmt.Printf("   Total Votes: %d개\n", len(rs.LastCommit.Votes))
 
-	for i, vote := range consensusState.Result.RoundState.LastCommit.Votes {
+	for i, vote := range rs.LastCommit.Votes {
 		if i < 3 { // 처음 3개만 출력
 			fmt.Printf("   Vote[%d]: %s\n", i, vote[:50]+"...")
 		}
@@ -194,7 +90,7 @@ func RunConsensusStateParser() {
 
 	// Last Commit Votes를 RawConsensusMessage로 변환
 	successCount := 0
-	for i, voteStr := range consensusState.Result.RoundState.LastCommit.Votes {
+	for i, voteStr := range rs.LastCommit.Votes {
 		if i >= 2 { // 처음 2개만 테스트
 			break
 		}
@@ -202,7 +98,7 @@ func RunConsensusStateParser() {
 		fmt.Printf("\n📦 Last Commit Vote %d 변환:\n", i+1)
 
 		// Vote 문자열 파싱
-		rawMsg, err := parseVoteString(voteStr, consensusState.Result.RoundState.Height)
+		rawMsg, err := parseVoteString(voteStr)
 		if err != nil {
 			fmt.Printf("   ❌ Vote 파싱 실패: %v\n", err)
 			continue
@@ -226,7 +122,7 @@ func RunConsensusStateParser() {
 		fmt.Printf("   ✅ 변환 성공!\n")
 	}
 
-	fmt.Printf("\n📊 변환 결과: %d/%d 성공\n", successCount, min(2, len(consensusState.Result.RoundState.LastCommit.Votes)))
+	fmt.Printf("\n📊 변환 결과: %d/%d 성공\n", successCount, min(2, len(rs.LastCommit.Votes)))
 }
 
 func readConsensusStateJSON() ([]byte, error) {
@@ -250,85 +146,33 @@ func readConsensusStateJSON() ([]byte, error) {
 	return data, nil
 }
 
-func parseVoteString(voteStr, height string) (abstraction.RawConsensusMessage, error) {
+func parseVoteString(voteStr string) (abstraction.RawConsensusMessage, error) {
 	// Vote{0:20CA1B3031F4 162/00/SIGNED_MSG_TYPE_PRECOMMIT(Precommit) 5DC0096D27B5 D55807B92BE1 000000000000 @ 2025-10-19T07:45:15.586964Z}
 	// 형식에서 정보 추출
-
-	parts := strings.Split(voteStr, " ")
-	if len(parts) < 8 {
-		return abstraction.RawConsensusMessage{}, fmt.Errorf("invalid vote format")
-	}
-
-	// Validator index 추출
-	validatorIndexStr := strings.TrimPrefix(parts[0], "Vote{")
-	validatorIndexStr = strings.TrimSuffix(validatorIndexStr, ":")
-	validatorIndex, err := strconv.Atoi(validatorIndexStr)
+	vote, err := cometbftAdapter.ParseVoteSummary(voteStr)
 	if err != nil {
 		return abstraction.RawConsensusMessage{}, err
 	}
-
-	// Validator address 추출
-	validatorAddress := parts[1]
-
-	// Height/Round 추출
-	heightRound := parts[2] // "162/00"
-	heightRoundParts := strings.Split(heightRound, "/")
-	if len(heightRoundParts) != 2 {
-		return abstraction.RawConsensusMessage{}, fmt.Errorf("invalid height/round format")
-	}
-
-	// Round 추출
-	roundStr := heightRoundParts[1]
-	round, err := strconv.Atoi(roundStr)
-	if err != nil {
-		return abstraction.RawConsensusMessage{}, err
-	}
-
-	// Message type 추출
-	msgTypeStr := parts[3] // "SIGNED_MSG_TYPE_PRECOMMIT(Precommit)"
-	var msgType string
-	var typeNum int32
-	if strings.Contains(msgTypeStr, "PRECOMMIT") {
-		msgType = "precommit"
-		typeNum = 2
-	} else if strings.Contains(msgTypeStr, "PREVOTE") {
-		msgType = "prevote"
-		typeNum = 1
-	} else {
-		msgType = "vote"
-		typeNum = 0
-	}
-
-	// Block hash 추출
-	blockHash := parts[4]
-
-	// Signature 추출
-	signature := parts[5]
-
-	// Timestamp 추출
-	timestampStr := parts[7] // "@ 2025-10-19T07:45:15.586964Z"
-	timestampStr = strings.TrimPrefix(timestampStr, "@ ")
-	timestamp, err := time.Parse(time.RFC3339Nano, timestampStr)
-	if err != nil {
-		return abstraction.RawConsensusMessage{}, err
+	if vote.Absent {
+		return abstraction.RawConsensusMessage{}, fmt.Errorf("validator did not vote")
 	}
 
 	// RawConsensusMessage 생성
 	voteData := map[string]interface{}{
-		"type":   typeNum,
-		"height": height,
-		"round":  fmt.Sprintf("%d", round),
+		"type":   vote.Type,
+		"height": strconv.FormatInt(vote.Height, 10),
+		"round":  fmt.Sprintf("%d", vote.Round),
 		"block_id": map[string]interface{}{
-			"hash": blockHash,
+			"hash": vote.BlockHash,
 			"parts": map[string]interface{}{
 				"total": 1,
 				"hash":  "",
 			},
 		},
-		"validator_address": validatorAddress,
-		"validator_index":   validatorIndex,
-		"signature":         signature,
-		"timestamp":         timestamp.Format(time.RFC3339Nano),
+		"validator_address": vote.ValidatorAddress,
+		"validator_index":   vote.ValidatorIndex,
+		"signature":         vote.Signature,
+		"timestamp":         vote.Timestamp.Format(time.RFC3339Nano),
 	}
 
 	jsonPayload, err := json.Marshal(voteData)
@@ -339,13 +183,13 @@ func parseVoteString(voteStr, height string) (abstraction.RawConsensusMessage, e
 	return abstraction.RawConsensusMessage{
 		ChainType:   abstraction.ChainTypeCometBFT,
 		ChainID:     "cosmos-hub-4",
-		MessageType: msgType,
+		MessageType: string(vote.MessageType()),
 		Payload:     jsonPayload,
 		Encoding:    "json",
-		Timestamp:   timestamp,
+		Timestamp:   vote.Timestamp,
 		Metadata: map[string]interface{}{
 			"source":          "consensus_state",
-			"validator_index": validatorIndex,
+			"validator_index": vote.ValidatorIndex,
 		},
 	}, nil
 }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"codec/scenario"
+)
+
+// runLibraryScenario runs a scenario from the attack library (or a scenario file) on one chain, or lists the
+// library when no name is given.
+func runLibraryScenario(ref, chain, chainID string) bool {
+	if ref == "library" || ref == scenario.LibraryPrefix {
+		fmt.Println("📚 Attack Scenario Library")
+		fmt.Println("=========================")
+		for _, name := range scenario.LibraryNames() {
+			s, err := scenario.Library(name)
+			if err != nil {
+				fmt.Printf("  - %s: %v\n", name, err)
+				continue
+			}
+			fmt.Printf("  - %s%s: %s (chains: %s)\n", scenario.LibraryPrefix, name, s.Title, strings.Join(s.ChainNames(), ", "))
+		}
+		return true
+	}
+
+	s, err := scenario.Load(ref)
+	if err != nil {
+		fmt.Printf("failed to load scenario: %v\n", err)
+		return false
+	}
+	target, err := scenario.DefaultTarget(chain, chainID)
+	if err != nil {
+		fmt.Printf("invalid chain: %v\n", err)
+		return false
+	}
+
+	fmt.Printf("📚 %s (%s on %s)\n", s.Title, s.Name, chain)
+	fmt.Println(strings.Repeat("=", 40))
+	fmt.Println(s.Description)
+	fmt.Println()
+	fmt.Println("References:")
+	for _, citation := range s.Citations {
+		fmt.Printf("  - %s\n", citation)
+	}
+
+	result, err := s.Run(chain, target)
+	if err != nil {
+		fmt.Printf("\nscenario failed to run: %v\n", err)
+		return false
+	}
+
+	for i, out := range result.Outputs {
+		fmt.Printf("\nStep %d, message #%d (%s)\n", out.Step, i+1, strings.ToUpper(out.Raw.MessageType))
+		printCanonicalMessage(out.Canonical)
+	}
+
+	fmt.Println("\nAssertions:")
+	for _, check := range result.Checks {
+		status := "✅"
+		if !check.Passed {
+			status = "❌"
+		}
+		fmt.Printf("  %s %s: %s\n", status, check.Kind, check.Detail)
+	}
+	return result.Passed()
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	cometbftAdapter "codec/cometbft/adapter"
+	"codec/message/abstraction"
 )
 
 const (
@@ -18,9 +19,10 @@ const (
 )
 
 func main() {
-	scenario := flag.String("scenario", scenarioOverview, "Scenario to run (overview|simulation|vote-batch|byzantine)")
+	scenario := flag.String("scenario", scenarioOverview, "Scenario to run (overview|simulation|vote-batch|byzantine|library|library:<name>|<scenario file>.json)")
+	chain := flag.String("chain", "cometbft", "Chain used by library scenarios (cometbft|fabric)")
 	duration := flag.Duration("duration", 12*time.Second, "Duration for the live simulation scenario")
-	actionFlag := flag.String("action", string(cometbftAdapter.ByzantineActionDoubleVote), "Byzantine action to apply (double_vote|double_proposal|alter_validator|drop_signature|timestamp_skew|none)")
+	actionFlag := flag.String("action", string(cometbftAdapter.ByzantineActionDoubleVote), "Byzantine action to apply (double_vote|double_proposal|alter_validator|drop_signature|timestamp_skew|nil_flip|height_flood|none)")
 	canonicalPath := flag.String("canonical", "", "Path to a canonical message JSON file for the byzantine scenario")
 	chainID := flag.String("chain-id", "cosmos-hub-4", "Chain identifier used when re-encoding messages")
 	alternateBlock := flag.String("alternate-block", "", "Alternate block hash used for forged outputs")
@@ -30,8 +32,14 @@ func main() {
 	roundOffset := flag.Int("round-offset", 0, "Offset (positive or negative) applied to the canonical round")
 	heightOffset := flag.Int("height-offset", 0, "Offset (positive or negative) applied to the canonical height")
 	timestampSkew := flag.Duration("timestamp-skew", 0, "Duration added to canonical timestamps during mutation")
+	floodRange := flag.String("flood-range", "", "Height offsets emitted by height_flood as N..M")
+	seed := flag.Int64("seed", 0, "Seed for generated hashes, addresses and timestamps; the same seed replays the same messages (0 uses the clock)")
 	flag.Parse()
 
+	if *seed != 0 {
+		abstraction.Seed(*seed)
+	}
+
 	mapper := cometbftAdapter.NewCometBFTMapper(*chainID)
 
 	switch strings.ToLower(*scenario) {
@@ -42,8 +50,14 @@ func main() {
 	case scenarioVoteBatch:
 		runVoteBatchScenario(mapper)
 	case scenarioByzantine:
-		runByzantineScenario(mapper, *actionFlag, *canonicalPath, *alternateBlock, *alternatePrev, *alternateSig, *alternateValidator, int64(*roundOffset), int64(*heightOffset), *timestampSkew)
+		runByzantineScenario(mapper, *actionFlag, *canonicalPath, *alternateBlock, *alternatePrev, *alternateSig, *alternateValidator, int64(*roundOffset), int64(*heightOffset), *timestampSkew, *floodRange)
 	default:
+		if ref := strings.TrimSpace(*scenario); ref == "library" || strings.HasPrefix(ref, "library:") || strings.HasSuffix(ref, ".json") {
+			if !runLibraryScenario(ref, strings.ToLower(*chain), *chainID) {
+				os.Exit(1)
+			}
+			return
+		}
 		fmt.Fprintf(os.Stderr, "unknown scenario %q\n", *scenario)
 		os.Exit(1)
 	}
@@ -57,9 +71,12 @@ func printOverview() {
 	fmt.Println("  - simulation: Stream randomly generated CometBFT messages through the canonical mapper.")
 	fmt.Println("  - vote-batch: Replay vote samples from examples/cometbft/Vote.json and verify round-trips.")
 	fmt.Println("  - byzantine:  Emit forged CometBFT payloads from a canonical message using the byzantine pipeline.")
+	fmt.Println("  - library:    List published attack scenarios; run one with -scenario=library:<name> -chain=<chain>.")
 	fmt.Println()
 	fmt.Println("Example usage:")
 	fmt.Println("  go run cmd/demo/main.go -scenario=simulation -duration=15s")
+	fmt.Println("  go run cmd/demo/main.go -scenario=simulation -duration=15s -seed=42")
 	fmt.Println("  go run cmd/demo/main.go -scenario=vote-batch")
 	fmt.Println("  go run cmd/demo/main.go -scenario=byzantine -action=double_proposal")
+	fmt.Println("  go run cmd/demo/main.go -scenario=library:equivocation-fork -chain=fabric")
 }
@@ -3,7 +3,6 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"strings"
 	"time"
 
@@ -58,7 +57,7 @@ func (ms *CometBFTMessageSimulator) Run(duration time.Duration) {
 
 func (ms *CometBFTMessageSimulator) generateAndProcessMessage(count int) {
 	msgTypes := []string{"proposal", "prevote", "precommit", "new_round_step"}
-	msgType := msgTypes[rand.Intn(len(msgTypes))]
+	msgType := msgTypes[abstraction.Intn(len(msgTypes))]
 
 	fmt.Printf("Message #%d → %s\n", count, strings.ToUpper(msgType))
 
@@ -106,20 +105,20 @@ func (ms *CometBFTMessageSimulator) generateRawMessage(msgType string) abstracti
 	baseMsg := map[string]interface{}{
 		"height":    fmt.Sprintf("%d", ms.height),
 		"round":     fmt.Sprintf("%d", ms.round),
-		"timestamp": time.Now().Format(time.RFC3339),
+		"timestamp": abstraction.Now().Format(time.RFC3339),
 		"type":      typeNum,
 	}
 
 	baseMsg["block_id"] = map[string]interface{}{
-		"hash": fmt.Sprintf("0x%x", rand.Int63()),
+		"hash": fmt.Sprintf("0x%x", abstraction.Int63()),
 		"parts": map[string]interface{}{
 			"total": 1,
-			"hash":  fmt.Sprintf("0x%x", rand.Int63()),
+			"hash":  fmt.Sprintf("0x%x", abstraction.Int63()),
 		},
 	}
-	baseMsg["proposer_address"] = fmt.Sprintf("node%d", rand.Intn(10)+1)
-	baseMsg["validator_address"] = fmt.Sprintf("validator%d", rand.Intn(10)+1)
-	baseMsg["signature"] = fmt.Sprintf("sig_%d", rand.Int63())
+	baseMsg["proposer_address"] = fmt.Sprintf("node%d", abstraction.Intn(10)+1)
+	baseMsg["validator_address"] = fmt.Sprintf("validator%d", abstraction.Intn(10)+1)
+	baseMsg["signature"] = fmt.Sprintf("sig_%d", abstraction.Int63())
 
 	payload, _ := json.Marshal(baseMsg)
 
@@ -129,6 +128,6 @@ func (ms *CometBFTMessageSimulator) generateRawMessage(msgType string) abstracti
 		MessageType: msgType,
 		Payload:     payload,
 		Encoding:    "json",
-		Timestamp:   time.Now(),
+		Timestamp:   abstraction.Now(),
 	}
 }
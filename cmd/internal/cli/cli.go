@@ -0,0 +1,15 @@
+// Package cli holds flag helpers shared by the commands under cmd.
+package cli
+
+import "strings"
+
+// SplitList splits a comma-separated flag value, trimming spaces and dropping empty items.
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	_ "codec/adapters"
+	"codec/cmd/internal/cli"
+	"codec/message/abstraction"
+	"codec/message/abstraction/roundtrip"
+)
+
+// Exit codes: equal messages exit 0, differing ones 1, and bad flags or unreadable inputs 2, so CI can tell a
+// fidelity regression from a broken invocation.
+const (
+	exitEqual   = 0
+	exitDiffer  = 1
+	exitInvalid = 2
+)
+
+// input is one decoded file: its messages, and the chain of the raw messages it held, if any.
+type input struct {
+	path     string
+	messages []*abstraction.CanonicalMessage
+	chain    abstraction.ChainType
+}
+
+// messageDiff is one field that differs between the two inputs' messages at Index.
+type messageDiff struct {
+	Index int `json:"index"`
+	roundtrip.Diff
+}
+
+func main() {
+	log.SetFlags(0)
+	profileName := flag.String("profile", "", "Chain whose round-trip profile selects the compared fields, or full; defaults to the chain of raw inputs, else full")
+	ignore := flag.String("ignore", "", "Comma-separated fields to leave out, e.g. Signature,Timestamp,Extensions[pol_round]")
+	extensions := flag.String("extensions", "", "Comma-separated extension keys to compare in place of the profile's, or all for every key either message sets")
+	precision := flag.Duration("precision", 0, "Truncate timestamps to this precision before comparing them (0 keeps the profile's)")
+	chainID := flag.String("chain-id", "", "Chain ID handed to the mapper that decodes raw inputs; defaults to the raw message's")
+	jsonOutput := flag.Bool("json", false, "Print the differences as a JSON array instead of one line each")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: msgdiff [-profile chain|full] [-ignore Field,...] [-extensions all|key,...] [-json] a.json b.json")
+		fmt.Fprintln(os.Stderr, "Compares two canonical or raw message files field by field. Raw messages are decoded with the mapper for their")
+		fmt.Fprintln(os.Stderr, "chain_type; files holding a JSON array are compared element by element. Exits 0 when the messages match,")
+		fmt.Fprintln(os.Stderr, "1 when they differ, and 2 on invalid flags or inputs.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(exitInvalid)
+	}
+	a, err := readInput(flag.Arg(0), *chainID)
+	if err != nil {
+		log.Print(err)
+		os.Exit(exitInvalid)
+	}
+	b, err := readInput(flag.Arg(1), *chainID)
+	if err != nil {
+		log.Print(err)
+		os.Exit(exitInvalid)
+	}
+
+	p, err := profile(*profileName, a, b)
+	if err == nil && strings.TrimSpace(*ignore) != "" {
+		p, err = p.Without(cli.SplitList(*ignore)...)
+	}
+	if err != nil {
+		log.Printf("invalid profile: %v", err)
+		os.Exit(exitInvalid)
+	}
+	switch list := cli.SplitList(*extensions); {
+	case len(list) == 1 && strings.EqualFold(list[0], "all"):
+		p.AllExtensions = true
+	case len(list) > 0:
+		p.Extensions = list
+	}
+	if *precision > 0 {
+		p.TimestampPrecision = *precision
+	}
+
+	diffs := compare(a.messages, b.messages, p)
+	if *jsonOutput {
+		if diffs == nil {
+			diffs = []messageDiff{}
+		}
+		out, _ := json.MarshalIndent(diffs, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		for _, d := range diffs {
+			if d.Index >= 0 && (len(a.messages) > 1 || len(b.messages) > 1) {
+				fmt.Printf("[%d] ", d.Index)
+			}
+			fmt.Println(d.String())
+		}
+	}
+	if len(diffs) > 0 {
+		if !*jsonOutput {
+			fmt.Fprintf(os.Stderr, "%s and %s differ in %d field(s)\n", a.path, b.path, len(diffs))
+		}
+		os.Exit(exitDiffer)
+	}
+	os.Exit(exitEqual)
+}
+
+// compare diffs the messages pairwise. A message missing from one side is reported as a difference in the
+// message count.
+func compare(a, b []*abstraction.CanonicalMessage, p roundtrip.Profile) []messageDiff {
+	var diffs []messageDiff
+	if len(a) != len(b) {
+		diffs = append(diffs, messageDiff{Index: -1, Diff: roundtrip.Diff{Field: "len(messages)", Want: fmt.Sprint(len(a)), Got: fmt.Sprint(len(b))}})
+	}
+	for i := 0; i < len(a) && i < len(b); i++ {
+		for _, d := range roundtrip.Differences(a[i], b[i], p) {
+			diffs = append(diffs, messageDiff{Index: i, Diff: d})
+		}
+	}
+	return diffs
+}
+
+// profile resolves -profile. Without one, inputs holding raw messages of a chain select that chain's profile,
+// since those are the fields its wire format carries.
+func profile(name string, a, b *input) (roundtrip.Profile, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch {
+	case name == "full":
+		return roundtrip.Full, nil
+	case name != "":
+		if _, err := abstraction.NewMapper(abstraction.ParseChainType(name), ""); err != nil {
+			return roundtrip.Profile{}, err
+		}
+		return roundtrip.ProfileFor(abstraction.ParseChainType(name)), nil
+	case a.chain != "" && b.chain != "" && a.chain != b.chain:
+		return roundtrip.Profile{}, fmt.Errorf("inputs hold %s and %s messages; choose one with -profile", a.chain, b.chain)
+	case a.chain != "":
+		return roundtrip.ProfileFor(a.chain), nil
+	case b.chain != "":
+		return roundtrip.ProfileFor(b.chain), nil
+	default:
+		return roundtrip.Full, nil
+	}
+}
+
+// readInput decodes a file holding one message or a JSON array of them. Objects with a chain_type and a
+// payload are raw messages and are decoded to canonical form; anything else is read as a canonical message.
+func readInput(path, chainID string) (*input, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var items []json.RawMessage
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	} else {
+		items = []json.RawMessage{data}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%s holds no messages", path)
+	}
+
+	in := &input{path: path}
+	for i, item := range items {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(item, &fields); err != nil {
+			return nil, fmt.Errorf("failed to parse message %d of %s: %w", i, path, err)
+		}
+		if fields["chain_type"] == nil || fields["payload"] == nil {
+			var msg abstraction.CanonicalMessage
+			if err := json.Unmarshal(item, &msg); err != nil {
+				return nil, fmt.Errorf("failed to parse canonical message %d of %s: %w", i, path, err)
+			}
+			in.messages = append(in.messages, &msg)
+			continue
+		}
+
+		var raw abstraction.RawConsensusMessage
+		if err := json.Unmarshal(item, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse raw message %d of %s: %w", i, path, err)
+		}
+		if in.chain != "" && in.chain != raw.ChainType {
+			return nil, fmt.Errorf("%s mixes %s and %s messages", path, in.chain, raw.ChainType)
+		}
+		in.chain = raw.ChainType
+		id := chainID
+		if id == "" {
+			id = raw.ChainID
+		}
+		mapper, err := abstraction.NewMapper(abstraction.ParseChainType(string(raw.ChainType)), id)
+		if err != nil {
+			return nil, fmt.Errorf("message %d of %s: %w", i, path, err)
+		}
+		msg, err := mapper.ToCanonical(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s message %d of %s: %w", raw.ChainType, i, path, err)
+		}
+		in.messages = append(in.messages, msg)
+	}
+	return in, nil
+}
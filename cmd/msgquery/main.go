@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"codec/capture"
+	"codec/cmd/internal/cli"
+	"codec/message/abstraction"
+)
+
+// Exit codes: a query that ran exits 0 whether or not it matched anything, a capture that could not be read
+// or written 1, and bad flags 2.
+const (
+	exitOK      = 0
+	exitFailed  = 1
+	exitInvalid = 2
+)
+
+// knownTypes are the message types -type accepts.
+var knownTypes = []abstraction.MsgType{
+	abstraction.MsgTypeProposal, abstraction.MsgTypePrepare, abstraction.MsgTypeVote, abstraction.MsgTypeCommit,
+	abstraction.MsgTypeViewChange, abstraction.MsgTypeNewView, abstraction.MsgTypeBlock, abstraction.MsgTypePrevote,
+	abstraction.MsgTypePrecommit, abstraction.MsgTypeRoundChange,
+}
+
+// output receives the matching records: JSON Lines on stdout, or a new capture with -o.
+type output interface {
+	Write(rec *capture.Record) error
+}
+
+// stdoutOutput prints records, or only their canonical messages, one JSON value per line.
+type stdoutOutput struct {
+	encoder   *json.Encoder
+	canonical bool
+}
+
+func (o stdoutOutput) Write(rec *capture.Record) error {
+	if o.canonical {
+		return o.encoder.Encode(rec.Canonical)
+	}
+	return o.encoder.Encode(rec)
+}
+
+func main() {
+	log.SetFlags(0)
+	chains := flag.String("chain", "", "Comma-separated chain IDs to keep")
+	from := flag.Int64("from", -1, "Lowest height to keep")
+	to := flag.Int64("to", -1, "Highest height to keep")
+	types := flag.String("type", "", "Comma-separated message types to keep, e.g. prevote,precommit")
+	validators := flag.String("validator", "", "Comma-separated validators to keep; proposals match their proposer")
+	mutated := flag.Bool("mutated", false, "Keep only the messages a byzantine proxy sent in place of what it received")
+	slack := flag.Int("slack", 0, "With -to, stop reading a capture after this many consecutive records above it (0 reads to the end)")
+	limit := flag.Int("limit", 0, "Stop after this many records across all captures (0 for no limit)")
+	canonical := flag.Bool("canonical", false, "Print only each record's canonical message")
+	count := flag.Bool("count", false, "Print the number of matching records per capture instead of the records")
+	outPath := flag.String("o", "", "Write the records to a new capture, with its height index, instead of stdout")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: msgquery [-chain id,...] [-from N] [-to M] [-type t,...] [-validator v,...] [-mutated] [-o slice.capture] capture...")
+		fmt.Fprintln(os.Stderr, "Extracts the records of experiment captures that match every given filter, in file order, as JSON Lines.")
+		fmt.Fprintln(os.Stderr, "A height range is found through each capture's index, so slices of large captures are read without a full scan.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(exitInvalid)
+	}
+	filter, err := buildFilter(*chains, *from, *to, *types, *validators, *mutated, *slack)
+	if err != nil {
+		log.Print(err)
+		os.Exit(exitInvalid)
+	}
+	if *count && *outPath != "" {
+		log.Print("-count and -o cannot be combined")
+		os.Exit(exitInvalid)
+	}
+
+	var out output = stdoutOutput{encoder: json.NewEncoder(os.Stdout), canonical: *canonical}
+	var writer *capture.Writer
+	if *outPath != "" {
+		if writer, err = capture.Create(*outPath); err != nil {
+			log.Print(err)
+			os.Exit(exitFailed)
+		}
+		out = writer
+	}
+
+	code := exitOK
+	remaining := *limit
+	for _, path := range flag.Args() {
+		n, err := query(path, filter, out, *count, remaining)
+		if *count {
+			fmt.Printf("%s: %d\n", path, n)
+		}
+		if err != nil {
+			log.Printf("%s: %v", path, err)
+			code = exitFailed
+			break
+		}
+		if *limit > 0 {
+			if remaining -= n; remaining <= 0 {
+				break
+			}
+		}
+	}
+	if writer != nil {
+		if err := writer.Close(); err != nil {
+			log.Print(err)
+			code = exitFailed
+		}
+	}
+	os.Exit(code)
+}
+
+// buildFilter turns the flags into a capture filter, rejecting unknown message types and an empty height range.
+func buildFilter(chains string, from, to int64, types, validators string, mutated bool, slack int) (capture.Filter, error) {
+	f := capture.Filter{Chains: cli.SplitList(chains), Validators: cli.SplitList(validators), OnlyMutated: mutated, Slack: slack}
+	if from >= 0 {
+		f.FromHeight = &from
+	}
+	if to >= 0 {
+		f.ToHeight = &to
+	}
+	if from >= 0 && to >= 0 && to < from {
+		return f, fmt.Errorf("-to must not be below -from")
+	}
+	if slack < 0 {
+		return f, fmt.Errorf("-slack must not be negative")
+	}
+	for _, name := range cli.SplitList(types) {
+		t := abstraction.MsgType(strings.ToLower(name))
+		if !slices.Contains(knownTypes, t) {
+			names := make([]string, len(knownTypes))
+			for i, known := range knownTypes {
+				names[i] = string(known)
+			}
+			return f, fmt.Errorf("unknown message type %q (%s)", name, strings.Join(names, ", "))
+		}
+		f.Types = append(f.Types, t)
+	}
+	return f, nil
+}
+
+// query writes the records of the capture at path that match f to out, or only counts them, stopping after
+// limit records when limit is positive. It returns how many matched.
+func query(path string, f capture.Filter, out output, countOnly bool, limit int) (int, error) {
+	q, err := capture.Query(path, f)
+	if err != nil {
+		return 0, err
+	}
+	defer q.Close()
+	n := 0
+	for limit <= 0 || n < limit {
+		rec, err := q.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+		if countOnly {
+			continue
+		}
+		if err := out.Write(rec); err != nil {
+			return n, fmt.Errorf("failed to write record: %w", err)
+		}
+	}
+	return n, nil
+}
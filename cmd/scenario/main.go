@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"codec/scenario"
+)
+
+func main() {
+	filePath := flag.String("file", "", "Experiment file (YAML or JSON) describing validators, roles, per-height attacks and expected outcomes")
+	target := flag.String("target", scenario.TargetSimnet, "Where the experiment runs (simnet|byzproxy)")
+	phasesPath := flag.String("phases", "", "byzproxy: write the attacks as a phase file for byzproxy --scenario")
+	capturePath := flag.String("capture", "", "byzproxy: judge the outcome from the capture byzproxy wrote with --record")
+	reportPath := flag.String("report", "", "Optional path to write the pass/fail report as JSON")
+	verbose := flag.Bool("v", false, "simnet: print every honest validator's consensus log")
+	flag.Parse()
+
+	if strings.TrimSpace(*filePath) == "" {
+		fmt.Fprintln(os.Stderr, "-file is required")
+		os.Exit(2)
+	}
+	experiment, err := scenario.LoadExperiment(*filePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	var report *scenario.ExperimentReport
+	switch strings.ToLower(*target) {
+	case scenario.TargetSimnet:
+		var log io.Writer
+		if *verbose {
+			log = os.Stdout
+		}
+		report, err = experiment.RunSimnet(log)
+	case scenario.TargetByzproxy:
+		if *phasesPath == "" && *capturePath == "" {
+			fmt.Fprintln(os.Stderr, "byzproxy needs -phases to prepare a run, -capture to judge one, or both")
+			os.Exit(2)
+		}
+		if *phasesPath != "" {
+			if err := writePhases(experiment, *phasesPath); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+		}
+		if *capturePath == "" {
+			return
+		}
+		report, err = experiment.EvaluateCapture(*capturePath)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown target %q (simnet|byzproxy)\n", *target)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "experiment failed to run: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Print(report)
+	if strings.TrimSpace(*reportPath) != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(*reportPath, append(data, '\n'), 0o644)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if !report.Passed() {
+		os.Exit(1)
+	}
+}
+
+// writePhases writes the byzproxy phase file and tells how to start the proxy with it.
+func writePhases(experiment *scenario.Experiment, path string) error {
+	phases, err := experiment.ProxyScenario()
+	if err != nil {
+		return fmt.Errorf("cannot run %s on byzproxy: %w", experiment.Name, err)
+	}
+	data, err := json.MarshalIndent(phases, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write phases: %w", err)
+	}
+	command := fmt.Sprintf("byzproxy --scenario %s --record <capture>", path)
+	if experiment.SplitsPeers() {
+		command += " --split-peers"
+	}
+	fmt.Printf("Wrote %d phases to %s. Start the proxy in front of the byzantine validators with\n  %s\nthen judge the run with -capture <capture>.\n", len(phases.Phases), path, command)
+	return nil
+}
@@ -0,0 +1,112 @@
+package adapter
+
+import (
+	"fmt"
+	"sync"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+// ByzantineActionAmnesia forgets the lock taken by a precommit: after precommitting block A in round r the
+// validator prevotes for a different block B in round r+1, violating the Tendermint locking rule.
+const ByzantineActionAmnesia ByzantineAction = "amnesia"
+
+// AmnesiaTracker remembers the block each height is locked on so amnesia prevotes are emitted only after a
+// precommit and only once per round. It is safe for concurrent use.
+type AmnesiaTracker struct {
+	mu    sync.Mutex
+	locks map[int64]*amnesiaLock
+}
+
+type amnesiaLock struct {
+	round     int64
+	blockHash string
+	forged    map[int64]bool
+}
+
+// NewAmnesiaTracker creates an empty tracker.
+func NewAmnesiaTracker() *AmnesiaTracker {
+	return &AmnesiaTracker{locks: make(map[int64]*amnesiaLock)}
+}
+
+// Locked returns the round and block a height is locked on.
+func (t *AmnesiaTracker) Locked(height int64) (int64, string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lock, ok := t.locks[height]
+	if !ok {
+		return 0, "", false
+	}
+	return lock.round, lock.blockHash, true
+}
+
+// Mutate implements the amnesia action. A precommit for a block takes the lock and is followed by a prevote for
+// another block in the next round; later prevotes above the locked round are rewritten to that other block, or
+// withheld if the forged prevote for their round was already emitted. Everything else passes through unchanged.
+func (t *AmnesiaTracker) Mutate(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
+	height, hasHeight := msg.HeightInt64()
+	round, hasRound := msg.RoundInt64()
+	if !hasHeight || !hasRound {
+		return nil, fmt.Errorf("amnesia action requires height and round")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch msg.Type {
+	case abstraction.MsgTypePrecommit:
+		if msg.BlockHash == "" {
+			return []*abstraction.CanonicalMessage{byzantine.Clone(msg)}, nil
+		}
+		lock := &amnesiaLock{round: round, blockHash: msg.BlockHash, forged: make(map[int64]bool)}
+		t.locks[height] = lock
+		t.prune(height)
+
+		prevote := amnesiaPrevote(msg, lock, round+1, opts)
+		lock.forged[round+1] = true
+		return []*abstraction.CanonicalMessage{byzantine.Clone(msg), prevote}, nil
+
+	case abstraction.MsgTypePrevote:
+		lock, ok := t.locks[height]
+		if !ok || round <= lock.round {
+			return []*abstraction.CanonicalMessage{byzantine.Clone(msg)}, nil
+		}
+		if lock.forged[round] {
+			return nil, nil
+		}
+		lock.forged[round] = true
+		return []*abstraction.CanonicalMessage{amnesiaPrevote(msg, lock, round, opts)}, nil
+
+	default:
+		return []*abstraction.CanonicalMessage{byzantine.Clone(msg)}, nil
+	}
+}
+
+// prune drops locks for heights that can no longer receive votes.
+func (t *AmnesiaTracker) prune(current int64) {
+	for height := range t.locks {
+		if height < current-1 {
+			delete(t.locks, height)
+		}
+	}
+}
+
+func amnesiaPrevote(msg *abstraction.CanonicalMessage, lock *amnesiaLock, round int64, opts ByzantineOptions) *abstraction.CanonicalMessage {
+	prevote := byzantine.Clone(msg)
+	prevote.Type = abstraction.MsgTypePrevote
+	prevote.Round.SetInt64(round)
+	prevote.BlockHash = byzantine.AlternateHash(lock.blockHash, opts.AlternateBlockHash)
+	if opts.AlternateSignature != "" {
+		prevote.Signature = opts.AlternateSignature
+	}
+	if prevote.Extensions != nil {
+		// Only precommits carry vote extensions.
+		delete(prevote.Extensions, "extension")
+		delete(prevote.Extensions, "extension_signature")
+	}
+
+	byzantine.ApplyCommon(prevote, ByzantineOptions{HeightOffset: opts.HeightOffset, TimestampShift: opts.TimestampShift})
+	byzantine.EnsureTimestampProgress(prevote, msg.Timestamp)
+	return prevote
+}
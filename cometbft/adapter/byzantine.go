@@ -1,293 +1,97 @@
 package adapter
 
 import (
-	"fmt"
-	"math/big"
-	"strings"
-	"time"
-
 	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+
+	"github.com/cometbft/cometbft/crypto/tmhash"
 )
 
 // ByzantineAction describes the manipulation to apply when converting back to a CometBFT message.
-type ByzantineAction string
+type ByzantineAction = byzantine.Action
+
+// ByzantineOptions contains optional overrides for the mutated messages.
+type ByzantineOptions = byzantine.Options
 
 const (
 	// ByzantineActionNone returns the canonical message without any manipulation.
-	ByzantineActionNone ByzantineAction = "none"
+	ByzantineActionNone = byzantine.ActionNone
 	// ByzantineActionDoubleVote emits two vote messages with conflicting block hashes.
-	ByzantineActionDoubleVote ByzantineAction = "double_vote"
+	ByzantineActionDoubleVote = byzantine.ActionDoubleVote
 	// ByzantineActionDoubleProposal emits two proposal messages that reference different blocks.
-	ByzantineActionDoubleProposal ByzantineAction = "double_proposal"
+	ByzantineActionDoubleProposal = byzantine.ActionDoubleProposal
 	// ByzantineActionAlterValidator rewrites the validator or proposer identity.
-	ByzantineActionAlterValidator ByzantineAction = "alter_validator"
+	ByzantineActionAlterValidator = byzantine.ActionAlterValidator
 	// ByzantineActionDropSignature removes the signature from the message.
-	ByzantineActionDropSignature ByzantineAction = "drop_signature"
+	ByzantineActionDropSignature = byzantine.ActionDropSignature
 	// ByzantineActionTimestampSkew applies a timestamp shift to the message.
-	ByzantineActionTimestampSkew ByzantineAction = "timestamp_skew"
+	ByzantineActionTimestampSkew = byzantine.ActionTimestampSkew
+	// ByzantineActionNilFlip turns a block vote into a nil vote and vice versa.
+	ByzantineActionNilFlip = byzantine.ActionNilFlip
+	// ByzantineActionFuzzPayload damages the encoded message to fuzz peer decoders.
+	ByzantineActionFuzzPayload = byzantine.ActionFuzzPayload
 )
 
-// ByzantineOptions contains optional overrides for the mutated messages.
-type ByzantineOptions struct {
-	AlternateBlockHash string
-	AlternatePrevHash  string
-	AlternateSignature string
-	AlternateValidator string
-	RoundOffset        int64
-	HeightOffset       int64
-	TimestampShift     time.Duration
+// Engine is a CometBFT byzantine engine. The amnesia and withhold-commit actions keep their state on the
+// engine, so separate engines do not see each other's locks or proposals.
+type Engine struct {
+	*byzantine.Engine
+	// Amnesia holds the lock state used by ByzantineActionAmnesia.
+	Amnesia *AmnesiaTracker
+	// Withholder holds the proposals seen by ByzantineActionWithholdCommit.
+	Withholder *CommitWithholder
 }
 
-// ParseByzantineAction converts a CLI string to the typed action.
-func ParseByzantineAction(value string) (ByzantineAction, error) {
-	switch strings.ToLower(value) {
-	case "", string(ByzantineActionNone):
-		return ByzantineActionNone, nil
-	case string(ByzantineActionDoubleVote):
-		return ByzantineActionDoubleVote, nil
-	case string(ByzantineActionDoubleProposal):
-		return ByzantineActionDoubleProposal, nil
-	case string(ByzantineActionAlterValidator):
-		return ByzantineActionAlterValidator, nil
-	case string(ByzantineActionDropSignature):
-		return ByzantineActionDropSignature, nil
-	case string(ByzantineActionTimestampSkew):
-		return ByzantineActionTimestampSkew, nil
-	default:
-		return ByzantineActionNone, fmt.Errorf("unknown byzantine action: %s", value)
-	}
+// NewByzantineEngine creates an engine with the CometBFT actions registered and empty action state.
+func NewByzantineEngine() *Engine {
+	e := &Engine{
+		Engine:     byzantine.NewEngine(),
+		Amnesia:    NewAmnesiaTracker(),
+		Withholder: NewCommitWithholder(),
+	}
+	e.Register(ByzantineActionAmnesia, e.Amnesia.Mutate)
+	e.Register(ByzantineActionWithholdCommit, e.Withholder.Mutate)
+	e.Register(ByzantineActionNilFlip, nilFlip)
+	e.Register(ByzantineActionCorruptExtension, corruptExtension)
+	return e
 }
 
-// ApplyByzantineCanonical mutates a canonical message according to the requested action.
-// It returns the set of canonical messages that should subsequently be encoded.
-func ApplyByzantineCanonical(msg *abstraction.CanonicalMessage, action ByzantineAction, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
-	if msg == nil {
-		return nil, fmt.Errorf("canonical message cannot be nil")
-	}
-
-	switch action {
-	case ByzantineActionNone:
-		return []*abstraction.CanonicalMessage{cloneCanonicalMessage(msg)}, nil
-	case ByzantineActionDoubleVote:
-		return applyDoubleVoteMutation(msg, opts)
-	case ByzantineActionDoubleProposal:
-		return applyDoubleProposalMutation(msg, opts)
-	case ByzantineActionAlterValidator:
-		return applyAlterValidatorMutation(msg, opts)
-	case ByzantineActionDropSignature:
-		return applyDropSignatureMutation(msg, opts)
-	case ByzantineActionTimestampSkew:
-		return applyTimestampSkewMutation(msg, opts)
-	default:
-		return nil, fmt.Errorf("unsupported byzantine action: %s", action)
-	}
-}
+// ByzantineEngine is the set of actions supported for CometBFT.
+var ByzantineEngine = NewByzantineEngine()
 
-// FromCanonicalByzantine converts a canonical message back to CometBFT format while applying a byzantine action.
-func (m *CometBFTMapper) FromCanonicalByzantine(msg *abstraction.CanonicalMessage, action ByzantineAction, opts ByzantineOptions) ([]*abstraction.RawConsensusMessage, error) {
-	canonicals, err := ApplyByzantineCanonical(msg, action, opts)
+// nilFlip keeps the block ID consistent with the flipped hash: nil votes carry a zero BlockID and no vote
+// extension, while votes for a block need a part set header.
+func nilFlip(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
+	out, err := byzantine.NilFlip(msg, opts)
 	if err != nil {
 		return nil, err
 	}
-
-	raws := make([]*abstraction.RawConsensusMessage, len(canonicals))
-	for i, canonical := range canonicals {
-		raw, err := m.FromCanonical(canonical)
-		if err != nil {
-			return nil, err
-		}
-		raws[i] = raw
-	}
-
-	return raws, nil
-}
-
-func chooseAlternateHash(original, provided string) string {
-	if provided != "" {
-		return provided
-	}
-	if original == "" {
-		return "0000000000000000000000000000000000000000000000000000000000000000"
-	}
-
-	runes := []rune(original)
-	for i := len(runes) - 1; i >= 0; i-- {
-		switch runes[i] {
-		case '0':
-			runes[i] = '1'
-			return string(runes)
-		case '1':
-			runes[i] = '0'
-			return string(runes)
-		case 'a', 'A':
-			runes[i] = 'b'
-			return string(runes)
-		case 'f', 'F':
-			runes[i] = 'e'
-			return string(runes)
-		}
-	}
-	return original + "0"
-}
-
-func cloneCanonicalMessage(msg *abstraction.CanonicalMessage) *abstraction.CanonicalMessage {
-	if msg == nil {
-		return nil
-	}
-	cloned := *msg
-	if msg.Height != nil {
-		cloned.Height = new(big.Int).Set(msg.Height)
-	}
-	if msg.Round != nil {
-		cloned.Round = new(big.Int).Set(msg.Round)
-	}
-	if msg.View != nil {
-		cloned.View = new(big.Int).Set(msg.View)
-	}
-	if msg.CommitSeals != nil {
-		cloned.CommitSeals = append([]string(nil), msg.CommitSeals...)
-	}
-	if msg.ViewChanges != nil {
-		cloned.ViewChanges = make([]abstraction.ViewChangeEntry, len(msg.ViewChanges))
-		for i, vc := range msg.ViewChanges {
-			entry := vc
-			if vc.View != nil {
-				entry.View = new(big.Int).Set(vc.View)
-			}
-			if vc.Height != nil {
-				entry.Height = new(big.Int).Set(vc.Height)
-			}
-			cloned.ViewChanges[i] = entry
-		}
-	}
-	if msg.Extensions != nil {
-		copied := make(map[string]interface{}, len(msg.Extensions))
-		for k, v := range msg.Extensions {
-			copied[k] = v
-		}
-		cloned.Extensions = copied
-	}
-	if msg.RawPayload != nil {
-		cloned.RawPayload = append([]byte(nil), msg.RawPayload...)
-	}
-	return &cloned
-}
-
-func applyDoubleVoteMutation(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
-	if msg.Type != abstraction.MsgTypePrevote && msg.Type != abstraction.MsgTypePrecommit && msg.Type != abstraction.MsgTypeVote {
-		return nil, fmt.Errorf("double_vote action requires a vote canonical message")
-	}
-
-	original := cloneCanonicalMessage(msg)
-	mutated := cloneCanonicalMessage(msg)
-	mutated.BlockHash = chooseAlternateHash(msg.BlockHash, opts.AlternateBlockHash)
-	if opts.AlternateSignature != "" {
-		mutated.Signature = opts.AlternateSignature
-	}
-
-	applyCommonMutations(mutated, opts)
-	ensureTimestampProgress(mutated, msg.Timestamp)
-
-	return []*abstraction.CanonicalMessage{original, mutated}, nil
-}
-
-func applyDoubleProposalMutation(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
-	if msg.Type != abstraction.MsgTypeProposal {
-		return nil, fmt.Errorf("double_proposal action requires a proposal canonical message")
-	}
-
-	original := cloneCanonicalMessage(msg)
-	mutated := cloneCanonicalMessage(msg)
-	mutated.BlockHash = chooseAlternateHash(msg.BlockHash, opts.AlternateBlockHash)
-	if opts.AlternatePrevHash != "" {
-		mutated.PrevHash = opts.AlternatePrevHash
-	} else if mutated.PrevHash == msg.PrevHash {
-		mutated.PrevHash = chooseAlternateHash(msg.PrevHash, "")
-	}
-	if opts.AlternateSignature != "" {
-		mutated.Signature = opts.AlternateSignature
-	}
-
-	applyCommonMutations(mutated, opts)
-	ensureTimestampProgress(mutated, msg.Timestamp)
-
-	return []*abstraction.CanonicalMessage{original, mutated}, nil
-}
-
-func applyAlterValidatorMutation(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
-	if opts.AlternateValidator == "" {
-		return nil, fmt.Errorf("alter_validator action requires AlternateValidator to be set")
-	}
-
-	mutated := cloneCanonicalMessage(msg)
-	switch msg.Type {
-	case abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit, abstraction.MsgTypeVote:
-		mutated.Validator = opts.AlternateValidator
-	case abstraction.MsgTypeProposal:
-		mutated.Proposer = opts.AlternateValidator
-	default:
-		return nil, fmt.Errorf("alter_validator action requires a proposal or vote canonical message")
+	flipped := out[len(out)-1]
+	if flipped.Extensions == nil {
+		flipped.Extensions = make(map[string]interface{})
 	}
-
-	applyCommonMutations(mutated, opts)
-	ensureTimestampProgress(mutated, msg.Timestamp)
-
-	return []*abstraction.CanonicalMessage{mutated}, nil
-}
-
-func applyDropSignatureMutation(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
-	mutated := cloneCanonicalMessage(msg)
-	mutated.Signature = ""
-
-	applyCommonMutations(mutated, opts)
-	ensureTimestampProgress(mutated, msg.Timestamp)
-
-	return []*abstraction.CanonicalMessage{mutated}, nil
-}
-
-func applyTimestampSkewMutation(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
-	if opts.TimestampShift == 0 {
-		return nil, fmt.Errorf("timestamp_skew action requires TimestampShift to be non-zero")
+	if flipped.BlockHash == "" {
+		delete(flipped.Extensions, "part_set_header")
+		delete(flipped.Extensions, "extension")
+		delete(flipped.Extensions, "extension_signature")
+	} else if psh, ok := PartSetHeaderFromExtensions(flipped); !ok || psh.Total == 0 {
+		flipped.Extensions["part_set_header"] = PartSetHeader{Total: 1, Hash: tmhash.Sum([]byte(flipped.BlockHash))}
 	}
-
-	mutated := cloneCanonicalMessage(msg)
-	applyCommonMutations(mutated, opts)
-	ensureTimestampProgress(mutated, msg.Timestamp)
-
-	return []*abstraction.CanonicalMessage{mutated}, nil
+	return out, nil
 }
 
-func applyCommonMutations(target *abstraction.CanonicalMessage, opts ByzantineOptions) {
-	if opts.HeightOffset != 0 {
-		target.Height = shiftBigInt(target.Height, opts.HeightOffset)
-	}
-	if opts.RoundOffset != 0 {
-		target.Round = shiftBigInt(target.Round, opts.RoundOffset)
-	}
-	if opts.TimestampShift != 0 {
-		target.Timestamp = target.Timestamp.Add(opts.TimestampShift)
-	}
+// ParseByzantineAction converts a CLI string to the typed action.
+func ParseByzantineAction(value string) (ByzantineAction, error) {
+	return ByzantineEngine.Parse(value)
 }
 
-func ensureTimestampProgress(mutated *abstraction.CanonicalMessage, original time.Time) {
-	if original.IsZero() {
-		return
-	}
-	if mutated.Timestamp.Equal(original) {
-		mutated.Timestamp = mutated.Timestamp.Add(1 * time.Millisecond)
-	}
+// ApplyByzantineCanonical mutates a canonical message according to the requested action.
+// It returns the set of canonical messages that should subsequently be encoded.
+func ApplyByzantineCanonical(msg *abstraction.CanonicalMessage, action ByzantineAction, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
+	return ByzantineEngine.Apply(msg, action, opts)
 }
 
-func shiftBigInt(value *big.Int, offset int64) *big.Int {
-	if offset == 0 {
-		if value == nil {
-			return nil
-		}
-		return new(big.Int).Set(value)
-	}
-
-	if value == nil {
-		return big.NewInt(offset)
-	}
-	return new(big.Int).Add(value, big.NewInt(offset))
+// FromCanonicalByzantine converts a canonical message back to CometBFT format while applying a byzantine action.
+func (m *CometBFTMapper) FromCanonicalByzantine(msg *abstraction.CanonicalMessage, action ByzantineAction, opts ByzantineOptions) ([]*abstraction.RawConsensusMessage, error) {
+	return ByzantineEngine.ApplyAndEncode(m, msg, action, opts)
 }
@@ -1,12 +1,18 @@
 package adapter
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"math/big"
 	"testing"
 	"time"
 
 	"codec/message/abstraction"
+
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	cmttypes "github.com/cometbft/cometbft/types"
 )
 
 func TestApplyByzantineCanonical(t *testing.T) {
@@ -450,3 +456,347 @@ func TestFromCanonicalByzantine(t *testing.T) {
 		})
 	}
 }
+
+func TestDoubleVoteSignedWithPrivVal(t *testing.T) {
+	privKey := ed25519.GenPrivKey()
+	signer := NewPrivValSigner("signed-chain", privKey)
+
+	vote := &abstraction.CanonicalMessage{
+		ChainID:   "signed-chain",
+		Height:    big.NewInt(12),
+		Round:     big.NewInt(0),
+		Timestamp: time.Unix(1700000000, 0).UTC(),
+		Type:      abstraction.MsgTypePrevote,
+		BlockHash: "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+		Validator: signer.Address(),
+		Signature: "stale",
+		Extensions: map[string]interface{}{
+			"validator_index": int32(2),
+			"part_set_header": PartSetHeader{Total: 1, Hash: make([]byte, 32)},
+		},
+	}
+
+	canonicals, err := ApplyByzantineCanonical(vote, ByzantineActionDoubleVote, ByzantineOptions{Signer: signer})
+	if err != nil {
+		t.Fatalf("ApplyByzantineCanonical returned error: %v", err)
+	}
+	if canonicals[0].Signature != "stale" {
+		t.Fatalf("expected the untouched original to keep its signature")
+	}
+
+	forged := canonicals[1]
+	sig, err := base64.StdEncoding.DecodeString(forged.Signature)
+	if err != nil {
+		t.Fatalf("forged signature is not base64: %v", err)
+	}
+	psh, _ := PartSetHeaderFromExtensions(forged)
+	hash, _ := hex.DecodeString(forged.BlockHash)
+	signBytes := cmttypes.VoteSignBytes("signed-chain", &cmtproto.Vote{
+		Type:             cmtproto.PrevoteType,
+		Height:           12,
+		Round:            0,
+		BlockID:          cmtproto.BlockID{Hash: hash, PartSetHeader: cmtproto.PartSetHeader{Total: psh.Total, Hash: psh.Hash}},
+		Timestamp:        forged.Timestamp,
+		ValidatorAddress: privKey.PubKey().Address(),
+		ValidatorIndex:   2,
+	})
+	if !privKey.PubKey().VerifySignature(signBytes, sig) {
+		t.Fatalf("forged vote signature does not verify")
+	}
+
+	dropped, err := ApplyByzantineCanonical(vote, ByzantineActionDropSignature, ByzantineOptions{Signer: signer})
+	if err != nil {
+		t.Fatalf("drop_signature returned error: %v", err)
+	}
+	if dropped[0].Signature != "" {
+		t.Fatalf("signer must not restore a deliberately dropped signature")
+	}
+}
+
+func TestAmnesiaSequence(t *testing.T) {
+	tracker := NewAmnesiaTracker()
+	blockA := "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	vote := func(msgType abstraction.MsgType, round int64, hash string) *abstraction.CanonicalMessage {
+		return &abstraction.CanonicalMessage{
+			ChainID:   "amnesia-chain",
+			Height:    big.NewInt(20),
+			Round:     big.NewInt(round),
+			Timestamp: time.Unix(1700000000+round, 0).UTC(),
+			Type:      msgType,
+			BlockHash: hash,
+			Validator: "validator-1",
+			Extensions: map[string]interface{}{
+				"extension":           "ext",
+				"extension_signature": "ext-sig",
+			},
+		}
+	}
+
+	// A prevote before any lock passes through.
+	out, err := tracker.Mutate(vote(abstraction.MsgTypePrevote, 0, blockA), ByzantineOptions{})
+	if err != nil || len(out) != 1 || out[0].BlockHash != blockA {
+		t.Fatalf("expected unlocked prevote to pass through, got %v (%v)", out, err)
+	}
+
+	out, err = tracker.Mutate(vote(abstraction.MsgTypePrecommit, 0, blockA), ByzantineOptions{})
+	if err != nil {
+		t.Fatalf("precommit: %v", err)
+	}
+	if len(out) != 2 || out[0].Type != abstraction.MsgTypePrecommit {
+		t.Fatalf("expected precommit followed by forged prevote, got %d messages", len(out))
+	}
+	forged := out[1]
+	if forged.Type != abstraction.MsgTypePrevote || forged.Round.Int64() != 1 || forged.BlockHash == blockA {
+		t.Fatalf("expected prevote for another block in round 1, got type=%s round=%v hash=%s", forged.Type, forged.Round, forged.BlockHash)
+	}
+	if _, ok := forged.Extensions["extension"]; ok {
+		t.Fatalf("prevotes must not carry vote extensions")
+	}
+	if round, hash, ok := tracker.Locked(20); !ok || round != 0 || hash != blockA {
+		t.Fatalf("expected lock on %s at round 0, got %v %s %v", blockA, round, hash, ok)
+	}
+
+	// The honest round-1 prevote is withheld because the forged one was already sent.
+	out, err = tracker.Mutate(vote(abstraction.MsgTypePrevote, 1, blockA), ByzantineOptions{})
+	if err != nil || len(out) != 0 {
+		t.Fatalf("expected honest round 1 prevote to be withheld, got %d (%v)", len(out), err)
+	}
+
+	// Later rounds keep ignoring the lock.
+	out, err = tracker.Mutate(vote(abstraction.MsgTypePrevote, 2, blockA), ByzantineOptions{AlternateBlockHash: "BB"})
+	if err != nil || len(out) != 1 || out[0].BlockHash != "BB" {
+		t.Fatalf("expected round 2 prevote to be rewritten, got %v (%v)", out, err)
+	}
+}
+
+func TestWithholdCommit(t *testing.T) {
+	withholder := NewCommitWithholder()
+	own := "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	other := "BBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB"
+	msg := func(msgType abstraction.MsgType, height int64, hash string) *abstraction.CanonicalMessage {
+		return &abstraction.CanonicalMessage{
+			ChainID:   "withhold-chain",
+			Height:    big.NewInt(height),
+			Round:     big.NewInt(0),
+			Timestamp: time.Unix(1700000000, 0).UTC(),
+			Type:      msgType,
+			BlockHash: hash,
+			Validator: "validator-1",
+		}
+	}
+
+	proposal := msg(abstraction.MsgTypeProposal, 40, own)
+	proposal.Proposer = "validator-1"
+	out, err := withholder.Mutate(proposal, ByzantineOptions{})
+	if err != nil || len(out) != 1 || out[0].Type != abstraction.MsgTypeProposal {
+		t.Fatalf("expected proposal to pass through, got %v (%v)", out, err)
+	}
+	if proposer, ok := withholder.Proposed(40, own); !ok || proposer != "validator-1" {
+		t.Fatalf("expected proposal by validator-1 to be recorded, got %q %v", proposer, ok)
+	}
+
+	out, err = withholder.Mutate(msg(abstraction.MsgTypePrevote, 40, own), ByzantineOptions{})
+	if err != nil || len(out) != 1 || out[0].BlockHash != own {
+		t.Fatalf("expected prevote for own block to pass through, got %v (%v)", out, err)
+	}
+	out, err = withholder.Mutate(msg(abstraction.MsgTypePrecommit, 40, own), ByzantineOptions{})
+	if err != nil || len(out) != 0 {
+		t.Fatalf("expected precommit for own block to be withheld, got %d (%v)", len(out), err)
+	}
+	out, err = withholder.Mutate(msg(abstraction.MsgTypePrecommit, 40, ""), ByzantineOptions{})
+	if err != nil || len(out) != 1 {
+		t.Fatalf("expected nil precommit to pass through, got %d (%v)", len(out), err)
+	}
+
+	// Blocks proposed by other validators, or not seen at all, are precommitted as usual.
+	foreign := msg(abstraction.MsgTypeProposal, 41, other)
+	foreign.Proposer = "validator-2"
+	if _, err := withholder.Mutate(foreign, ByzantineOptions{}); err != nil {
+		t.Fatalf("foreign proposal: %v", err)
+	}
+	out, err = withholder.Mutate(msg(abstraction.MsgTypePrecommit, 41, other), ByzantineOptions{})
+	if err != nil || len(out) != 1 || out[0].BlockHash != other {
+		t.Fatalf("expected precommit for another proposer's block to pass through, got %v (%v)", out, err)
+	}
+	out, err = withholder.Mutate(msg(abstraction.MsgTypePrecommit, 42, own), ByzantineOptions{})
+	if err != nil || len(out) != 1 {
+		t.Fatalf("expected precommit without a recorded proposal to pass through, got %d (%v)", len(out), err)
+	}
+
+	all := ByzantineOptions{Params: map[string]string{WithholdScopeParam: "all"}}
+	out, err = withholder.Mutate(msg(abstraction.MsgTypePrecommit, 42, own), all)
+	if err != nil || len(out) != 0 {
+		t.Fatalf("expected withhold_scope=all to withhold every block precommit, got %d (%v)", len(out), err)
+	}
+	if _, err := withholder.Mutate(msg(abstraction.MsgTypePrecommit, 42, own), ByzantineOptions{Params: map[string]string{WithholdScopeParam: "some"}}); err == nil {
+		t.Fatalf("expected an unknown scope to be rejected")
+	}
+
+	if _, err := ParseByzantineAction("withhold_commit"); err != nil {
+		t.Fatalf("withhold_commit should be registered: %v", err)
+	}
+}
+
+func TestByzantineEngineState(t *testing.T) {
+	blockA := "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	precommit := &abstraction.CanonicalMessage{
+		ChainID:   "engine-chain",
+		Height:    big.NewInt(30),
+		Round:     big.NewInt(0),
+		Timestamp: time.Unix(1700000000, 0).UTC(),
+		Type:      abstraction.MsgTypePrecommit,
+		BlockHash: blockA,
+		Validator: "validator-1",
+	}
+
+	first, second := NewByzantineEngine(), NewByzantineEngine()
+	if _, err := first.Apply(precommit, ByzantineActionAmnesia, ByzantineOptions{}); err != nil {
+		t.Fatalf("amnesia: %v", err)
+	}
+	if _, _, ok := first.Amnesia.Locked(30); !ok {
+		t.Fatalf("expected the engine's tracker to record the lock")
+	}
+	if _, _, ok := second.Amnesia.Locked(30); ok {
+		t.Fatalf("expected another engine not to see the lock")
+	}
+
+	proposal := *precommit
+	proposal.Type, proposal.Proposer = abstraction.MsgTypeProposal, "validator-1"
+	if _, err := first.Apply(&proposal, ByzantineActionWithholdCommit, ByzantineOptions{}); err != nil {
+		t.Fatalf("withhold_commit: %v", err)
+	}
+	if _, ok := first.Withholder.Proposed(30, blockA); !ok {
+		t.Fatalf("expected the engine's withholder to record the proposal")
+	}
+	if _, ok := second.Withholder.Proposed(30, blockA); ok {
+		t.Fatalf("expected another engine not to see the proposal")
+	}
+}
+
+func TestNilFlip(t *testing.T) {
+	mapper := NewCometBFTMapper("nil-flip-chain")
+	blockHash := "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	precommit := &abstraction.CanonicalMessage{
+		ChainID:   "nil-flip-chain",
+		Height:    big.NewInt(30),
+		Round:     big.NewInt(0),
+		Timestamp: time.Unix(1700000000, 0).UTC(),
+		Type:      abstraction.MsgTypePrecommit,
+		BlockHash: blockHash,
+		Validator: "validator-1",
+		Signature: "sig-1",
+		Extensions: map[string]interface{}{
+			"part_set_header":     PartSetHeader{Total: 1, Hash: make([]byte, 32)},
+			"extension":           "ext",
+			"extension_signature": "ext-sig",
+		},
+	}
+
+	out, err := ApplyByzantineCanonical(precommit, ByzantineActionNilFlip, ByzantineOptions{EmitBoth: true})
+	if err != nil {
+		t.Fatalf("nil flip: %v", err)
+	}
+	if len(out) != 2 || out[0].BlockHash != blockHash {
+		t.Fatalf("expected original precommit followed by flipped one, got %d messages", len(out))
+	}
+	flipped := out[1]
+	if flipped.BlockHash != "" {
+		t.Fatalf("expected nil precommit, got %s", flipped.BlockHash)
+	}
+	for _, key := range []string{"part_set_header", "extension", "extension_signature"} {
+		if _, ok := flipped.Extensions[key]; ok {
+			t.Fatalf("nil precommit must not carry %s", key)
+		}
+	}
+	if _, ok := precommit.Extensions["part_set_header"]; !ok {
+		t.Fatalf("input message must not be modified")
+	}
+
+	raw, err := mapper.FromCanonical(flipped)
+	if err != nil {
+		t.Fatalf("encode nil precommit: %v", err)
+	}
+	var encoded CometBFTConsensusMessage
+	if err := json.Unmarshal(raw.Payload, &encoded); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if encoded.BlockID.Hash != "" || encoded.BlockID.PartSetHeader.Total != 0 {
+		t.Fatalf("expected zero block id, got %+v", encoded.BlockID)
+	}
+
+	// Flipping back requires a block to vote for and gets a part set header.
+	if _, err := ApplyByzantineCanonical(flipped, ByzantineActionNilFlip, ByzantineOptions{}); err == nil {
+		t.Fatalf("expected nil vote without alternate block to be rejected")
+	}
+	out, err = ApplyByzantineCanonical(flipped, ByzantineActionNilFlip, ByzantineOptions{AlternateBlockHash: blockHash})
+	if err != nil || len(out) != 1 || out[0].BlockHash != blockHash {
+		t.Fatalf("expected vote for %s, got %v (%v)", blockHash, out, err)
+	}
+	if psh, ok := PartSetHeaderFromExtensions(out[0]); !ok || psh.Total != 1 || len(psh.Hash) != 32 {
+		t.Fatalf("expected part set header on block vote, got %+v", psh)
+	}
+}
+
+func TestCorruptExtension(t *testing.T) {
+	extension := base64.StdEncoding.EncodeToString([]byte("oracle-price:42"))
+	precommit := &abstraction.CanonicalMessage{
+		ChainID:   "extension-chain",
+		Height:    big.NewInt(40),
+		Round:     big.NewInt(0),
+		Timestamp: time.Unix(1700000000, 0).UTC(),
+		Type:      abstraction.MsgTypePrecommit,
+		BlockHash: "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+		Validator: "validator-1",
+		Signature: "sig-1",
+		Extensions: map[string]interface{}{
+			"extension":           extension,
+			"extension_signature": "ext-sig",
+		},
+	}
+
+	tests := []struct {
+		mode          string
+		wantExtension string
+		wantSignature bool
+	}{
+		{mode: "", wantExtension: base64.StdEncoding.EncodeToString([]byte("oracle-price:43")), wantSignature: true},
+		{mode: ExtensionModeSignature, wantExtension: extension, wantSignature: false},
+		{mode: ExtensionModeBoth, wantExtension: base64.StdEncoding.EncodeToString([]byte("oracle-price:43")), wantSignature: false},
+	}
+	for _, tt := range tests {
+		t.Run("mode="+tt.mode, func(t *testing.T) {
+			opts := ByzantineOptions{Params: map[string]string{ExtensionModeParam: tt.mode}}
+			out, err := ApplyByzantineCanonical(precommit, ByzantineActionCorruptExtension, opts)
+			if err != nil {
+				t.Fatalf("corrupt extension: %v", err)
+			}
+			if got := out[0].Extensions["extension"]; got != tt.wantExtension {
+				t.Fatalf("expected extension %v, got %v", tt.wantExtension, got)
+			}
+			if _, ok := out[0].Extensions["extension_signature"]; ok != tt.wantSignature {
+				t.Fatalf("expected extension signature present=%v", tt.wantSignature)
+			}
+			if precommit.Extensions["extension"] != extension || precommit.Extensions["extension_signature"] != "ext-sig" {
+				t.Fatalf("input message must not be modified")
+			}
+		})
+	}
+
+	opts := ByzantineOptions{Params: map[string]string{ExtensionPayloadParam: "0xdeadbeef"}}
+	out, err := ApplyByzantineCanonical(precommit, ByzantineActionCorruptExtension, opts)
+	if err != nil {
+		t.Fatalf("corrupt extension with payload: %v", err)
+	}
+	if got := out[0].Extensions["extension"]; got != base64.StdEncoding.EncodeToString([]byte{0xde, 0xad, 0xbe, 0xef}) {
+		t.Fatalf("expected explicit payload, got %v", got)
+	}
+
+	nilPrecommit := *precommit
+	nilPrecommit.BlockHash = ""
+	if _, err := ApplyByzantineCanonical(&nilPrecommit, ByzantineActionCorruptExtension, ByzantineOptions{}); err == nil {
+		t.Fatalf("expected nil precommit to be rejected")
+	}
+	if _, err := ApplyByzantineCanonical(precommit, ByzantineActionCorruptExtension, ByzantineOptions{Params: map[string]string{ExtensionModeParam: "all"}}); err == nil {
+		t.Fatalf("expected unknown mode to be rejected")
+	}
+}
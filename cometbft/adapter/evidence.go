@@ -0,0 +1,79 @@
+package adapter
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"codec/message/abstraction"
+
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+)
+
+// BuildDuplicateVoteEvidence assembles the DuplicateVoteEvidence a node's evidence reactor accepts from two
+// signed canonical votes by the same validator for different blocks, such as the pair ByzantineActionDoubleVote
+// returns once both are signed. blockTime is the time of the block at the votes' height and valSet the validator
+// set at that height; the offender must be in it. Use cmttypes.EvidenceToProto or Bytes to submit the result.
+func BuildDuplicateVoteEvidence(voteA, voteB *abstraction.CanonicalMessage, blockTime time.Time, valSet *cmttypes.ValidatorSet) (*cmttypes.DuplicateVoteEvidence, error) {
+	if valSet == nil {
+		return nil, fmt.Errorf("validator set is required")
+	}
+	a, err := signedVote(voteA)
+	if err != nil {
+		return nil, fmt.Errorf("first vote: %w", err)
+	}
+	b, err := signedVote(voteB)
+	if err != nil {
+		return nil, fmt.Errorf("second vote: %w", err)
+	}
+
+	switch {
+	case a.Height != b.Height || a.Round != b.Round || a.Type != b.Type:
+		return nil, fmt.Errorf("votes are for different steps: %d/%d/%v and %d/%d/%v",
+			a.Height, a.Round, a.Type, b.Height, b.Round, b.Type)
+	case !bytes.Equal(a.ValidatorAddress, b.ValidatorAddress):
+		return nil, fmt.Errorf("votes are from different validators: %X and %X", a.ValidatorAddress, b.ValidatorAddress)
+	case a.BlockID.Equals(b.BlockID):
+		return nil, fmt.Errorf("votes are for the same block %v", a.BlockID)
+	}
+	if _, val := valSet.GetByAddress(a.ValidatorAddress); val == nil {
+		return nil, fmt.Errorf("validator %X is not in the validator set", a.ValidatorAddress)
+	}
+
+	ev, err := cmttypes.NewDuplicateVoteEvidence(a, b, blockTime, valSet)
+	if err != nil {
+		return nil, err
+	}
+	if err := ev.ValidateBasic(); err != nil {
+		return nil, fmt.Errorf("invalid duplicate vote evidence: %w", err)
+	}
+	return ev, nil
+}
+
+// signedVote converts a canonical prevote or precommit into the vote its signature covers.
+func signedVote(msg *abstraction.CanonicalMessage) (*cmttypes.Vote, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("vote is nil")
+	}
+	if msg.Type != abstraction.MsgTypePrevote && msg.Type != abstraction.MsgTypePrecommit {
+		return nil, fmt.Errorf("unsupported message type %s", msg.Type)
+	}
+	if msg.Signature == "" {
+		return nil, fmt.Errorf("vote is not signed")
+	}
+	pb := protoVote(msg)
+	if err := validateBlockID(pb.BlockID); err != nil {
+		return nil, err
+	}
+	pb.Signature = decodeBase64OrRaw(msg.Signature)
+	if pb.Type == cmtproto.PrecommitType && msg.Extensions != nil {
+		if ext, ok := msg.Extensions.GetString("extension"); ok {
+			pb.Extension = decodeBase64OrRaw(ext)
+		}
+		if extSig, ok := msg.Extensions.GetString("extension_signature"); ok {
+			pb.ExtensionSignature = decodeBase64OrRaw(extSig)
+		}
+	}
+	return cmttypes.VoteFromProto(pb)
+}
@@ -0,0 +1,73 @@
+package adapter
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/cometbft/cometbft/evidence"
+	cmttypes "github.com/cometbft/cometbft/types"
+)
+
+func TestBuildDuplicateVoteEvidence(t *testing.T) {
+	privKey := ed25519.GenPrivKey()
+	signer := NewPrivValSigner("evidence-chain", privKey)
+	valSet := cmttypes.NewValidatorSet([]*cmttypes.Validator{cmttypes.NewValidator(privKey.PubKey(), 10)})
+
+	vote := &abstraction.CanonicalMessage{
+		ChainID:   "evidence-chain",
+		Height:    big.NewInt(20),
+		Round:     big.NewInt(1),
+		Timestamp: time.Unix(1700000000, 0).UTC(),
+		Type:      abstraction.MsgTypePrecommit,
+		BlockHash: "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+		Extensions: map[string]interface{}{
+			"validator_index": int32(0),
+			"part_set_header": PartSetHeader{Total: 1, Hash: make([]byte, 32)},
+		},
+	}
+	if err := signer.Sign(vote); err != nil {
+		t.Fatalf("Sign returned error: %v", err)
+	}
+	votes, err := ApplyByzantineCanonical(vote, ByzantineActionDoubleVote, ByzantineOptions{Signer: signer})
+	if err != nil {
+		t.Fatalf("ApplyByzantineCanonical returned error: %v", err)
+	}
+
+	blockTime := time.Unix(1700000005, 0).UTC()
+	ev, err := BuildDuplicateVoteEvidence(votes[0], votes[1], blockTime, valSet)
+	if err != nil {
+		t.Fatalf("BuildDuplicateVoteEvidence returned error: %v", err)
+	}
+	if ev.Height() != 20 || ev.ValidatorPower != 10 || ev.TotalVotingPower != 10 || !ev.Time().Equal(blockTime) {
+		t.Fatalf("unexpected evidence %v", ev)
+	}
+	if err := evidence.VerifyDuplicateVote(ev, "evidence-chain", valSet); err != nil {
+		t.Fatalf("evidence does not verify: %v", err)
+	}
+
+	pb, err := cmttypes.EvidenceToProto(ev)
+	if err != nil {
+		t.Fatalf("EvidenceToProto returned error: %v", err)
+	}
+	decoded, err := cmttypes.EvidenceFromProto(pb)
+	if err != nil || decoded.Hash() == nil || string(decoded.Hash()) != string(ev.Hash()) {
+		t.Fatalf("evidence does not survive the protobuf roundtrip: %v", err)
+	}
+
+	if _, err := BuildDuplicateVoteEvidence(votes[0], votes[0], blockTime, valSet); err == nil {
+		t.Fatalf("expected votes for the same block to be rejected")
+	}
+	unsigned := *votes[1]
+	unsigned.Signature = ""
+	if _, err := BuildDuplicateVoteEvidence(votes[0], &unsigned, blockTime, valSet); err == nil {
+		t.Fatalf("expected an unsigned vote to be rejected")
+	}
+	other := cmttypes.NewValidatorSet([]*cmttypes.Validator{cmttypes.NewValidator(ed25519.GenPrivKey().PubKey(), 10)})
+	if _, err := BuildDuplicateVoteEvidence(votes[0], votes[1], blockTime, other); err == nil {
+		t.Fatalf("expected a validator outside the set to be rejected")
+	}
+}
@@ -0,0 +1,84 @@
+package adapter
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+// ByzantineActionCorruptExtension tampers with the ABCI++ vote extension of a precommit so that
+// VerifyVoteExtension and extension signature checks can be exercised.
+const ByzantineActionCorruptExtension ByzantineAction = "corrupt_extension"
+
+// Params understood by ByzantineActionCorruptExtension.
+const (
+	// ExtensionModeParam selects what is corrupted: "bytes" (default), "signature", or "both".
+	ExtensionModeParam = "extension_mode"
+	// ExtensionPayloadParam replaces the extension with the given base64 bytes, or hex bytes when prefixed with
+	// "0x", instead of flipping a bit.
+	ExtensionPayloadParam = "extension"
+)
+
+// Extension corruption modes.
+const (
+	ExtensionModeBytes     = "bytes"
+	ExtensionModeSignature = "signature"
+	ExtensionModeBoth      = "both"
+)
+
+// corruptExtension mutates the extension bytes, strips the extension signature, or both. Corrupted bytes keep
+// the original signature, which then no longer verifies; with Options.Signer set the extension is re-signed
+// instead, so the application receives a validly signed extension it must reject on content.
+func corruptExtension(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
+	if msg.Type != abstraction.MsgTypePrecommit || msg.BlockHash == "" {
+		return nil, fmt.Errorf("corrupt_extension action requires a precommit for a block")
+	}
+
+	mode := strings.ToLower(strings.TrimSpace(opts.Params[ExtensionModeParam]))
+	if mode == "" {
+		mode = ExtensionModeBytes
+	}
+	if mode != ExtensionModeBytes && mode != ExtensionModeSignature && mode != ExtensionModeBoth {
+		return nil, fmt.Errorf("invalid %s %q (expected bytes|signature|both)", ExtensionModeParam, mode)
+	}
+
+	mutated := byzantine.Clone(msg)
+	if mutated.Extensions == nil {
+		mutated.Extensions = make(map[string]interface{})
+	}
+
+	if mode == ExtensionModeBytes || mode == ExtensionModeBoth {
+		ext, _ := mutated.Extensions.GetString("extension")
+		corrupted := flipLastBit(decodeBase64OrRaw(ext))
+		if payload := opts.Params[ExtensionPayloadParam]; strings.HasPrefix(payload, "0x") {
+			corrupted = decodeHexOrRaw(payload)
+		} else if payload != "" {
+			corrupted = decodeBase64OrRaw(payload)
+		}
+		mutated.Extensions["extension"] = base64.StdEncoding.EncodeToString(corrupted)
+	}
+	if mode == ExtensionModeSignature || mode == ExtensionModeBoth {
+		delete(mutated.Extensions, "extension_signature")
+	}
+	if opts.AlternateSignature != "" {
+		mutated.Signature = opts.AlternateSignature
+	}
+
+	byzantine.ApplyCommon(mutated, opts)
+	byzantine.EnsureTimestampProgress(mutated, msg.Timestamp)
+
+	return []*abstraction.CanonicalMessage{mutated}, nil
+}
+
+// flipLastBit returns a copy of data with its lowest bit inverted, or a single byte when data is empty.
+func flipLastBit(data []byte) []byte {
+	if len(data) == 0 {
+		return []byte{0x01}
+	}
+	out := append([]byte(nil), data...)
+	out[len(out)-1] ^= 0x01
+	return out
+}
@@ -3,6 +3,7 @@ package adapter
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"math/big"
 	"strconv"
 	"time"
@@ -22,10 +23,21 @@ func NewCometBFTMapper(chainID string) *CometBFTMapper {
 	}
 }
 
+var _ abstraction.PooledMapper = (*CometBFTMapper)(nil)
+
 // ToCanonical converts a CometBFT raw message to canonical format
 func (m *CometBFTMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abstraction.CanonicalMessage, error) {
+	canonical := &abstraction.CanonicalMessage{}
+	if err := m.ToCanonicalInto(raw, canonical); err != nil {
+		return nil, err
+	}
+	return canonical, nil
+}
+
+// ToCanonicalInto converts a CometBFT raw message into canonical, reusing its Extensions map
+func (m *CometBFTMapper) ToCanonicalInto(raw abstraction.RawConsensusMessage, canonical *abstraction.CanonicalMessage) error {
 	if raw.ChainType != abstraction.ChainTypeCometBFT {
-		return nil, abstraction.ErrChainMismatch
+		return abstraction.ErrChainMismatch
 	}
 
 	// Parse the payload based on encoding
@@ -33,7 +45,7 @@ func (m *CometBFTMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abst
 	switch raw.Encoding {
 	case "json":
 		if err := json.Unmarshal(raw.Payload, &cometMsg); err != nil {
-			return nil, &abstraction.MessageValidationError{
+			return &abstraction.MessageValidationError{
 				Field:   "payload",
 				Message: fmt.Sprintf("failed to parse JSON: %v", err),
 				Code:    "DECODE_FAILURE",
@@ -46,14 +58,14 @@ func (m *CometBFTMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abst
 	case "proto":
 		// For protobuf, we'll parse as JSON for now since codec is not available
 		if err := json.Unmarshal(raw.Payload, &cometMsg); err != nil {
-			return nil, &abstraction.MessageValidationError{
+			return &abstraction.MessageValidationError{
 				Field:   "payload",
 				Message: fmt.Sprintf("failed to parse protobuf as JSON: %v", err),
 				Code:    "DECODE_FAILURE",
 			}
 		}
 	default:
-		return nil, &abstraction.MessageValidationError{
+		return &abstraction.MessageValidationError{
 			Field:   "encoding",
 			Message: fmt.Sprintf("unsupported encoding: %s", raw.Encoding),
 			Code:    "DECODE_FAILURE",
@@ -61,18 +73,22 @@ func (m *CometBFTMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abst
 	}
 
 	// Convert to canonical message based on message type
-	canonical := &abstraction.CanonicalMessage{
+	extensions := canonical.Extensions
+	if extensions == nil {
+		extensions = make(abstraction.Extensions)
+	}
+	clear(extensions)
+	extensions["cometbft_version"] = cometMsg.Version
+	extensions["step"] = cometMsg.Step
+	extensions["last_commit_round"] = cometMsg.LastCommitRound
+	*canonical = abstraction.CanonicalMessage{
 		ChainID:    m.chainID,
 		Height:     parseStringToBigInt(cometMsg.Height), // 문자열을 big.Int로 변환
 		Round:      parseStringToBigInt(cometMsg.Round),  // 문자열을 big.Int로 변환
 		Timestamp:  cometMsg.Timestamp,
 		Type:       m.mapMessageType(cometMsg.MessageType),
 		RawPayload: raw.Payload,
-		Extensions: map[string]interface{}{
-			"cometbft_version":  cometMsg.Version,
-			"step":              cometMsg.Step,
-			"last_commit_round": cometMsg.LastCommitRound,
-		},
+		Extensions: extensions,
 	}
 
 	// Set specific fields based on message type
@@ -96,6 +112,7 @@ func (m *CometBFTMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abst
 		canonical.Signature = cometMsg.Signature
 		canonical.Extensions["vote_type"] = cometMsg.VoteType
 		canonical.Extensions["validator_index"] = cometMsg.ValidatorIndex
+		canonical.Extensions["part_set_header"] = cometMsg.BlockID.PartSetHeader
 		canonical.Extensions["extension"] = cometMsg.Extension
 		canonical.Extensions["extension_signature"] = cometMsg.ExtensionSignature
 
@@ -124,6 +141,7 @@ func (m *CometBFTMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abst
 	case "VoteSetMaj23", "VoteSetBits":
 		canonical.BlockHash = cometMsg.BlockID.Hash
 		canonical.Extensions["vote_type"] = cometMsg.VoteType
+		canonical.Extensions["part_set_header"] = cometMsg.BlockID.PartSetHeader
 		if cometMsg.MessageType == "VoteSetBits" {
 			canonical.Extensions["votes_bit_array"] = cometMsg.VotesBitArray
 		}
@@ -136,18 +154,26 @@ func (m *CometBFTMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abst
 		canonical.Extensions["proposal_pol_round"] = cometMsg.ProposalPOLRound
 		canonical.Extensions["proposal_pol"] = cometMsg.ProposalPOL
 	}
+	if stateMessageTypes[cometMsg.MessageType] {
+		canonical.Extensions["message_type"] = cometMsg.MessageType
+	}
 
-	return canonical, nil
+	return nil
 }
 
 // FromCanonical converts a canonical message to CometBFT format
 func (m *CometBFTMapper) FromCanonical(msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
-	return m.rawFromCanonicalMessage(msg)
+	raw := &abstraction.RawConsensusMessage{}
+	if err := m.FromCanonicalInto(msg, raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
 }
 
-func (m *CometBFTMapper) rawFromCanonicalMessage(msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
+// FromCanonicalInto converts a canonical message into raw, reusing its Metadata map
+func (m *CometBFTMapper) FromCanonicalInto(msg *abstraction.CanonicalMessage, raw *abstraction.RawConsensusMessage) error {
 	if msg == nil {
-		return nil, &abstraction.MessageValidationError{
+		return &abstraction.MessageValidationError{
 			Field:   "message",
 			Message: "message cannot be nil",
 			Code:    "MISSING_FIELD",
@@ -156,10 +182,10 @@ func (m *CometBFTMapper) rawFromCanonicalMessage(msg *abstraction.CanonicalMessa
 
 	cometMsg, err := m.canonicalToCometMessage(msg)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return m.encodeCometMessage(cometMsg)
+	return m.encodeCometMessage(cometMsg, raw)
 }
 
 func (m *CometBFTMapper) canonicalToCometMessage(msg *abstraction.CanonicalMessage) (CometBFTConsensusMessage, error) {
@@ -169,6 +195,9 @@ func (m *CometBFTMapper) canonicalToCometMessage(msg *abstraction.CanonicalMessa
 		Timestamp: msg.Timestamp,
 		Version:   "0.38.17",
 	}
+	if stateMessageFromCanonical(&cometMsg, msg) {
+		return cometMsg, nil
+	}
 
 	switch msg.Type {
 	case abstraction.MsgTypeProposal:
@@ -178,12 +207,13 @@ func (m *CometBFTMapper) canonicalToCometMessage(msg *abstraction.CanonicalMessa
 			PrevHash:      msg.PrevHash,
 			PartSetHeader: PartSetHeader{Total: 1, Hash: []byte(msg.BlockHash)},
 		}
+		if psh, ok := PartSetHeaderFromExtensions(msg); ok {
+			cometMsg.BlockID.PartSetHeader = psh
+		}
 		cometMsg.ProposerAddress = msg.Proposer
 		cometMsg.Signature = msg.Signature
-		if msg.Extensions != nil {
-			if polRound, ok := msg.Extensions["pol_round"].(int32); ok {
-				cometMsg.POLRound = polRound
-			}
+		if polRound, ok := msg.Extensions.GetInt64("pol_round"); ok {
+			cometMsg.POLRound = int32(polRound)
 		}
 
 	case abstraction.MsgTypePrevote:
@@ -192,6 +222,7 @@ func (m *CometBFTMapper) canonicalToCometMessage(msg *abstraction.CanonicalMessa
 		cometMsg.BlockID = BlockID{Hash: msg.BlockHash}
 		cometMsg.ValidatorAddress = msg.Validator
 		cometMsg.Signature = msg.Signature
+		applyVoteExtensions(&cometMsg, msg)
 
 	case abstraction.MsgTypePrecommit:
 		cometMsg.MessageType = "Vote"
@@ -199,66 +230,100 @@ func (m *CometBFTMapper) canonicalToCometMessage(msg *abstraction.CanonicalMessa
 		cometMsg.BlockID = BlockID{Hash: msg.BlockHash}
 		cometMsg.ValidatorAddress = msg.Validator
 		cometMsg.Signature = msg.Signature
-		if msg.Extensions != nil {
-			if ext, ok := msg.Extensions["extension"].(string); ok {
-				cometMsg.Extension = ext
-			}
-			if extSig, ok := msg.Extensions["extension_signature"].(string); ok {
-				cometMsg.ExtensionSignature = extSig
-			}
+		applyVoteExtensions(&cometMsg, msg)
+		if ext, ok := msg.Extensions.GetString("extension"); ok {
+			cometMsg.Extension = ext
+		}
+		if extSig, ok := msg.Extensions.GetString("extension_signature"); ok {
+			cometMsg.ExtensionSignature = extSig
 		}
 
 	case abstraction.MsgTypeBlock:
 		cometMsg.MessageType = "BlockPart"
 		cometMsg.BlockID = BlockID{Hash: msg.BlockHash}
-		if msg.Extensions != nil {
-			if partIndex, ok := msg.Extensions["part_index"].(uint32); ok {
-				cometMsg.PartIndex = partIndex
-			}
-			if partBytes, ok := msg.Extensions["part_bytes"].([]byte); ok {
-				cometMsg.PartBytes = partBytes
-			}
+		if partIndex, ok := msg.Extensions.GetUint64("part_index"); ok {
+			cometMsg.PartIndex = uint32(partIndex)
+		}
+		if partBytes, ok := msg.Extensions.GetBytes("part_bytes"); ok {
+			cometMsg.PartBytes = partBytes
 		}
 
 	default:
 		cometMsg.MessageType = "NewRoundStep"
-		if msg.Extensions != nil {
-			if step, ok := msg.Extensions["step"].(uint32); ok {
-				cometMsg.Step = step
-			}
-			if lastCommitRound, ok := msg.Extensions["last_commit_round"].(int32); ok {
-				cometMsg.LastCommitRound = lastCommitRound
-			}
+		if step, ok := msg.Extensions.GetUint64("step"); ok {
+			cometMsg.Step = uint32(step)
+		}
+		if lastCommitRound, ok := msg.Extensions.GetInt64("last_commit_round"); ok {
+			cometMsg.LastCommitRound = int32(lastCommitRound)
 		}
 	}
 
 	return cometMsg, nil
 }
 
-func (m *CometBFTMapper) encodeCometMessage(cometMsg CometBFTConsensusMessage) (*abstraction.RawConsensusMessage, error) {
+// applyVoteExtensions copies the vote fields that are only carried as canonical extensions.
+func applyVoteExtensions(cometMsg *CometBFTConsensusMessage, msg *abstraction.CanonicalMessage) {
+	if psh, ok := PartSetHeaderFromExtensions(msg); ok {
+		cometMsg.BlockID.PartSetHeader = psh
+	}
+	if index, ok := ValidatorIndexFromExtensions(msg); ok {
+		cometMsg.ValidatorIndex = index
+	}
+}
+
+// PartSetHeaderFromExtensions reads the part_set_header extension, which is a PartSetHeader when produced by
+// ToCanonical or a generic map when the canonical message was loaded from JSON.
+func PartSetHeaderFromExtensions(msg *abstraction.CanonicalMessage) (PartSetHeader, bool) {
+	if msg == nil {
+		return PartSetHeader{}, false
+	}
+	var psh PartSetHeader
+	if !msg.Extensions.Decode("part_set_header", &psh) {
+		return PartSetHeader{}, false
+	}
+	return psh, true
+}
+
+// ValidatorIndexFromExtensions reads the validator_index extension regardless of its numeric type.
+func ValidatorIndexFromExtensions(msg *abstraction.CanonicalMessage) (int32, bool) {
+	if msg == nil {
+		return 0, false
+	}
+	index, ok := msg.Extensions.GetInt64("validator_index")
+	if !ok || index < math.MinInt32 || index > math.MaxInt32 {
+		return 0, false
+	}
+	return int32(index), true
+}
+
+func (m *CometBFTMapper) encodeCometMessage(cometMsg CometBFTConsensusMessage, raw *abstraction.RawConsensusMessage) error {
 	payload, err := json.Marshal(cometMsg)
 	if err != nil {
-		return nil, &abstraction.MessageValidationError{
+		return &abstraction.MessageValidationError{
 			Field:   "payload",
 			Message: fmt.Sprintf("failed to serialize: %v", err),
 			Code:    "DECODE_FAILURE",
 		}
 	}
 
-	raw := &abstraction.RawConsensusMessage{
+	metadata := raw.Metadata
+	if metadata == nil {
+		metadata = make(map[string]interface{}, 2)
+	}
+	clear(metadata)
+	metadata["version"] = cometMsg.Version
+	metadata["step"] = cometMsg.Step
+	*raw = abstraction.RawConsensusMessage{
 		ChainType:   abstraction.ChainTypeCometBFT,
 		ChainID:     m.chainID,
 		MessageType: cometMsg.MessageType,
 		Payload:     payload,
 		Encoding:    "json",
-		Timestamp:   time.Now(),
-		Metadata: map[string]interface{}{
-			"version": cometMsg.Version,
-			"step":    cometMsg.Step,
-		},
+		Timestamp:   abstraction.Now(),
+		Metadata:    metadata,
 	}
 
-	return raw, nil
+	return nil
 }
 
 // GetSupportedTypes returns the message types supported by CometBFT
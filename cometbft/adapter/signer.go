@@ -0,0 +1,223 @@
+package adapter
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"codec/message/abstraction"
+
+	"github.com/cometbft/cometbft/crypto"
+	cmtjson "github.com/cometbft/cometbft/libs/json"
+	"github.com/cometbft/cometbft/privval"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	cmttypes "github.com/cometbft/cometbft/types"
+)
+
+// PrivValSigner signs canonical CometBFT votes and proposals with a validator private key, producing the
+// same sign bytes a real validator would so forged equivocations are accepted and yield evidence.
+type PrivValSigner struct {
+	chainID string
+	privKey crypto.PrivKey
+}
+
+// NewPrivValSigner creates a signer for chainID using privKey.
+func NewPrivValSigner(chainID string, privKey crypto.PrivKey) *PrivValSigner {
+	return &PrivValSigner{chainID: chainID, privKey: privKey}
+}
+
+// LoadPrivValSigner reads a priv_validator_key.json file.
+func LoadPrivValSigner(chainID, keyFile string) (*PrivValSigner, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read privval key: %w", err)
+	}
+	var key privval.FilePVKey
+	if err := cmtjson.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("failed to decode privval key %s: %w", keyFile, err)
+	}
+	if key.PrivKey == nil {
+		return nil, fmt.Errorf("privval key %s has no private key", keyFile)
+	}
+	return NewPrivValSigner(chainID, key.PrivKey), nil
+}
+
+// Address returns the hex-encoded validator address of the signing key.
+func (s *PrivValSigner) Address() string {
+	return strings.ToUpper(hex.EncodeToString(s.privKey.PubKey().Address()))
+}
+
+// Sign replaces the canonical signature with one over the message's CometBFT sign bytes.
+// Votes without a validator are attributed to the signing key.
+func (s *PrivValSigner) Sign(msg *abstraction.CanonicalMessage) error {
+	if msg == nil {
+		return fmt.Errorf("canonical message cannot be nil")
+	}
+	if isStateMessage(msg) {
+		// HasVote, BlockPart and the other state messages are not signed.
+		return nil
+	}
+	switch msg.Type {
+	case abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit:
+		return s.signVote(msg)
+	case abstraction.MsgTypeProposal:
+		return s.signProposal(msg)
+	default:
+		return fmt.Errorf("cannot sign %s messages", msg.Type)
+	}
+}
+
+func (s *PrivValSigner) signVote(msg *abstraction.CanonicalMessage) error {
+	if msg.Validator == "" {
+		msg.Validator = s.Address()
+	}
+	vote := protoVote(msg)
+	if err := validateBlockID(vote.BlockID); err != nil {
+		return err
+	}
+
+	sig, err := s.privKey.Sign(cmttypes.VoteSignBytes(s.chainID, vote))
+	if err != nil {
+		return fmt.Errorf("failed to sign vote: %w", err)
+	}
+	msg.Signature = base64.StdEncoding.EncodeToString(sig)
+
+	// Precommits for a block carry a signed extension when vote extensions are enabled; keep it consistent.
+	if vote.Type == cmtproto.PrecommitType && len(vote.BlockID.Hash) > 0 && msg.Extensions != nil {
+		if extSig, _ := msg.Extensions.GetString("extension_signature"); extSig != "" {
+			ext, _ := msg.Extensions.GetString("extension")
+			vote.Extension = decodeBase64OrRaw(ext)
+			extSigBytes, err := s.privKey.Sign(cmttypes.VoteExtensionSignBytes(s.chainID, vote))
+			if err != nil {
+				return fmt.Errorf("failed to sign vote extension: %w", err)
+			}
+			msg.Extensions["extension_signature"] = base64.StdEncoding.EncodeToString(extSigBytes)
+		}
+	}
+	return nil
+}
+
+func (s *PrivValSigner) signProposal(msg *abstraction.CanonicalMessage) error {
+	proposal := protoProposal(msg)
+	if err := validateBlockID(proposal.BlockID); err != nil {
+		return err
+	}
+
+	sig, err := s.privKey.Sign(cmttypes.ProposalSignBytes(s.chainID, proposal))
+	if err != nil {
+		return fmt.Errorf("failed to sign proposal: %w", err)
+	}
+	msg.Signature = base64.StdEncoding.EncodeToString(sig)
+	return nil
+}
+
+// SignBytes returns the bytes a validator signs for a canonical prevote, precommit or proposal on chainID,
+// so signatures can be checked without re-encoding the message.
+func SignBytes(chainID string, msg *abstraction.CanonicalMessage) ([]byte, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("canonical message cannot be nil")
+	}
+	switch msg.Type {
+	case abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit:
+		vote := protoVote(msg)
+		if err := validateBlockID(vote.BlockID); err != nil {
+			return nil, err
+		}
+		return cmttypes.VoteSignBytes(chainID, vote), nil
+	case abstraction.MsgTypeProposal:
+		proposal := protoProposal(msg)
+		if err := validateBlockID(proposal.BlockID); err != nil {
+			return nil, err
+		}
+		return cmttypes.ProposalSignBytes(chainID, proposal), nil
+	default:
+		return nil, fmt.Errorf("%s messages are not signed", msg.Type)
+	}
+}
+
+// protoProposal builds the unsigned proposal whose sign bytes cover a canonical proposal.
+func protoProposal(msg *abstraction.CanonicalMessage) *cmtproto.Proposal {
+	proposal := &cmtproto.Proposal{
+		Type:      cmtproto.ProposalType,
+		Height:    bigIntToInt64(msg.Height),
+		Round:     int32(bigIntToInt64(msg.Round)),
+		BlockID:   protoBlockID(msg),
+		Timestamp: msg.Timestamp,
+	}
+	if polRound, ok := msg.Extensions.GetInt64("pol_round"); ok {
+		proposal.PolRound = int32(polRound)
+	}
+	return proposal
+}
+
+// protoVote builds the unsigned vote whose sign bytes cover a canonical prevote or precommit.
+func protoVote(msg *abstraction.CanonicalMessage) *cmtproto.Vote {
+	vote := &cmtproto.Vote{
+		Type:             cmtproto.PrevoteType,
+		Height:           bigIntToInt64(msg.Height),
+		Round:            int32(bigIntToInt64(msg.Round)),
+		BlockID:          protoBlockID(msg),
+		Timestamp:        msg.Timestamp,
+		ValidatorAddress: decodeHexOrRaw(msg.Validator),
+	}
+	if msg.Type == abstraction.MsgTypePrecommit {
+		vote.Type = cmtproto.PrecommitType
+	}
+	if index, ok := ValidatorIndexFromExtensions(msg); ok {
+		vote.ValidatorIndex = index
+	}
+	return vote
+}
+
+// protoBlockID mirrors how FromCanonical encodes the block ID so the signature covers what is sent.
+func protoBlockID(msg *abstraction.CanonicalMessage) cmtproto.BlockID {
+	blockID := cmtproto.BlockID{Hash: decodeHexOrRaw(msg.BlockHash)}
+	psh, ok := PartSetHeaderFromExtensions(msg)
+	if !ok && msg.Type == abstraction.MsgTypeProposal {
+		psh = PartSetHeader{Total: 1, Hash: []byte(msg.BlockHash)}
+	}
+	blockID.PartSetHeader = cmtproto.PartSetHeader{Total: psh.Total, Hash: psh.Hash}
+	return blockID
+}
+
+// validateBlockID rejects block IDs the sign-bytes canonicalisation would panic on.
+func validateBlockID(pb cmtproto.BlockID) error {
+	blockID, err := cmttypes.BlockIDFromProto(&pb)
+	if err != nil {
+		return fmt.Errorf("invalid block id: %w", err)
+	}
+	if err := blockID.ValidateBasic(); err != nil {
+		return fmt.Errorf("invalid block id: %w", err)
+	}
+	return nil
+}
+
+func bigIntToInt64(value *big.Int) int64 {
+	if value == nil || !value.IsInt64() {
+		return 0
+	}
+	return value.Int64()
+}
+
+func decodeHexOrRaw(value string) []byte {
+	if value == "" {
+		return nil
+	}
+	if data, err := hex.DecodeString(strings.TrimPrefix(value, "0x")); err == nil {
+		return data
+	}
+	return []byte(value)
+}
+
+func decodeBase64OrRaw(value string) []byte {
+	if value == "" {
+		return nil
+	}
+	if data, err := base64.StdEncoding.DecodeString(value); err == nil {
+		return data
+	}
+	return decodeHexOrRaw(value)
+}
@@ -0,0 +1,73 @@
+package adapter
+
+import "codec/message/abstraction"
+
+// The state and data channel messages below have no canonical type of their own: ToCanonical maps them onto
+// the nearest one and records the CometBFT type in the message_type extension, which FromCanonical uses to
+// rebuild the original message from the remaining extensions.
+var stateMessageTypes = map[string]bool{
+	"NewRoundStep": true,
+	"BlockPart":    true,
+	"HasVote":      true,
+	"VoteSetMaj23": true,
+	"VoteSetBits":  true,
+}
+
+// MessageTypeFromExtensions returns the CometBFT message type recorded for messages such as HasVote or
+// BlockPart that share a canonical type with other messages.
+func MessageTypeFromExtensions(msg *abstraction.CanonicalMessage) (string, bool) {
+	if msg == nil {
+		return "", false
+	}
+	messageType, ok := msg.Extensions.GetString("message_type")
+	return messageType, ok && messageType != ""
+}
+
+// isStateMessage reports whether msg is one of the state or data channel messages rather than the proposal or
+// vote its canonical type suggests.
+func isStateMessage(msg *abstraction.CanonicalMessage) bool {
+	messageType, ok := MessageTypeFromExtensions(msg)
+	return ok && stateMessageTypes[messageType]
+}
+
+// stateMessageFromCanonical fills in the fields of the message named by the message_type extension. It
+// reports false when msg does not carry one of the state messages.
+func stateMessageFromCanonical(cometMsg *CometBFTConsensusMessage, msg *abstraction.CanonicalMessage) bool {
+	if !isStateMessage(msg) {
+		return false
+	}
+	messageType, _ := MessageTypeFromExtensions(msg)
+	ext := msg.Extensions
+	cometMsg.MessageType = messageType
+
+	switch messageType {
+	case "NewRoundStep":
+		step, _ := ext.GetInt64("step")
+		lastCommitRound, _ := ext.GetInt64("last_commit_round")
+		cometMsg.Step = uint32(step)
+		cometMsg.LastCommitRound = int32(lastCommitRound)
+		cometMsg.SecondsSinceStartTime, _ = ext.GetInt64("seconds_since_start_time")
+
+	case "BlockPart":
+		cometMsg.BlockID = BlockID{Hash: msg.BlockHash}
+		partIndex, _ := ext.GetInt64("part_index")
+		cometMsg.PartIndex = uint32(partIndex)
+		cometMsg.PartBytes, _ = ext.GetBytes("part_bytes")
+		cometMsg.PartProof, _ = ext.GetBytes("part_proof")
+
+	case "HasVote":
+		cometMsg.VoteType, _ = ext.GetString("vote_type")
+		cometMsg.ValidatorIndex, _ = ValidatorIndexFromExtensions(msg)
+
+	case "VoteSetMaj23", "VoteSetBits":
+		cometMsg.VoteType, _ = ext.GetString("vote_type")
+		cometMsg.BlockID = BlockID{Hash: msg.BlockHash}
+		if psh, ok := PartSetHeaderFromExtensions(msg); ok {
+			cometMsg.BlockID.PartSetHeader = psh
+		}
+		if messageType == "VoteSetBits" {
+			cometMsg.VotesBitArray, _ = ext.GetStrings("votes_bit_array")
+		}
+	}
+	return true
+}
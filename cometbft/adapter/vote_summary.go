@@ -0,0 +1,122 @@
+package adapter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+// VoteSummary is a vote as CometBFT prints it in /dump_consensus_state, /consensus_state, and its logs:
+//
+//	Vote{0:20CA1B3031F4 162/00/SIGNED_MSG_TYPE_PRECOMMIT(Precommit) 5DC0096D27B5 D55807B92BE1 000000000000 @ 2025-10-19T07:45:15.586964Z}
+//
+// Addresses, hashes, and signatures are only fingerprints there, the first six bytes in hex. They are kept in
+// lower case, so they are prefixes of the hex the adapter uses elsewhere. An all-zero fingerprint is the
+// printed form of an empty value and is returned as "".
+type VoteSummary struct {
+	// Absent is set for "nil-Vote", the placeholder for a validator that has not voted; the other fields are zero.
+	Absent           bool
+	ValidatorIndex   int32
+	ValidatorAddress string
+	Height           int64
+	Round            int32
+	// Type is 1 for prevotes and 2 for precommits, as in CometBFTConsensusMessage.
+	Type int32
+	// BlockHash is empty for a vote for nil.
+	BlockHash string
+	// Signature is empty for an unsigned vote.
+	Signature string
+	// Extension is empty without a vote extension and for versions that do not print one.
+	Extension string
+	Timestamp time.Time
+}
+
+// voteSummaryPattern covers the layouts printed since Tendermint 0.33: the message type as a number or as a
+// SignedMsgType name, with or without its short name in parentheses, and the extension fingerprint that
+// CometBFT 0.38 added. Fingerprints may be empty or longer than six bytes.
+var voteSummaryPattern = regexp.MustCompile(
+	`^Vote\{(\d+):([0-9A-Fa-f]*) (\d+)/(\d+)/([A-Za-z0-9_]+)(?:\(([A-Za-z]+)\))? ([0-9A-Fa-f]*) ([0-9A-Fa-f]*)(?: ([0-9A-Fa-f]*))? @ ([^ }]+)\}$`)
+
+// ParseVoteSummary parses one vote string of a consensus state dump. "nil-Vote" yields a summary with Absent set.
+func ParseVoteSummary(s string) (*VoteSummary, error) {
+	s = strings.TrimSpace(s)
+	if s == "nil-Vote" {
+		return &VoteSummary{Absent: true}, nil
+	}
+	m := voteSummaryPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid vote summary %q", s)
+	}
+
+	index, err := strconv.ParseInt(m[1], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validator index in vote summary %q", s)
+	}
+	height, err := strconv.ParseInt(m[3], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid height in vote summary %q", s)
+	}
+	round, err := strconv.ParseInt(m[4], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid round in vote summary %q", s)
+	}
+	voteType := summaryVoteType(m[5], m[6])
+	if voteType == 0 {
+		return nil, fmt.Errorf("unknown vote type %q in vote summary %q", m[5], s)
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, m[10])
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp in vote summary %q: %v", s, err)
+	}
+
+	return &VoteSummary{
+		ValidatorIndex:   int32(index),
+		ValidatorAddress: fingerprint(m[2]),
+		Height:           height,
+		Round:            int32(round),
+		Type:             voteType,
+		BlockHash:        fingerprint(m[7]),
+		Signature:        fingerprint(m[8]),
+		Extension:        fingerprint(m[9]),
+		Timestamp:        timestamp,
+	}, nil
+}
+
+// IsNil reports whether the summary is a vote for nil rather than for a block.
+func (v *VoteSummary) IsNil() bool {
+	return !v.Absent && v.BlockHash == ""
+}
+
+// MessageType returns the canonical type of the vote.
+func (v *VoteSummary) MessageType() abstraction.MsgType {
+	if v.Type == 1 {
+		return abstraction.MsgTypePrevote
+	}
+	return abstraction.MsgTypePrecommit
+}
+
+// summaryVoteType reads the vote type from its printed name or number, falling back to the short name in
+// parentheses. It returns 0 for anything that is not a prevote or precommit.
+func summaryVoteType(name, short string) int32 {
+	for _, s := range []string{name, short} {
+		upper := strings.ToUpper(s)
+		switch {
+		case upper == "1" || strings.Contains(upper, "PREVOTE"):
+			return 1
+		case upper == "2" || strings.Contains(upper, "PRECOMMIT"):
+			return 2
+		}
+	}
+	return 0
+}
+
+func fingerprint(s string) string {
+	if strings.Trim(s, "0") == "" {
+		return ""
+	}
+	return strings.ToLower(s)
+}
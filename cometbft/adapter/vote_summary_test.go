@@ -0,0 +1,78 @@
+package adapter
+
+import (
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+func TestParseVoteSummary(t *testing.T) {
+	ts := time.Date(2025, 10, 19, 7, 45, 15, 586964000, time.UTC)
+	cases := []struct {
+		name string
+		in   string
+		want VoteSummary
+	}{
+		{
+			name: "cometbft 0.38 precommit",
+			in:   "Vote{0:20CA1B3031F4 162/00/SIGNED_MSG_TYPE_PRECOMMIT(Precommit) 5DC0096D27B5 D55807B92BE1 000000000000 @ 2025-10-19T07:45:15.586964Z}",
+			want: VoteSummary{ValidatorAddress: "20ca1b3031f4", Height: 162, Type: 2, BlockHash: "5dc0096d27b5", Signature: "d55807b92be1", Timestamp: ts},
+		},
+		{
+			name: "nil prevote with extension column",
+			in:   "Vote{3:A1B2C3D4E5F6 7/02/SIGNED_MSG_TYPE_PREVOTE(Prevote) 000000000000 0102030405FF 000000000000 @ 2025-10-19T07:45:15.586964Z}",
+			want: VoteSummary{ValidatorIndex: 3, ValidatorAddress: "a1b2c3d4e5f6", Height: 7, Round: 2, Type: 1, Signature: "0102030405ff", Timestamp: ts},
+		},
+		{
+			name: "tendermint 0.34 without extension, unsigned",
+			in:   "Vote{1:A1B2C3D4E5F6 9/00/SIGNED_MSG_TYPE_PRECOMMIT(Precommit) 5DC0096D27B5 000000000000 @ 2025-10-19T07:45:15.586964Z}",
+			want: VoteSummary{ValidatorIndex: 1, ValidatorAddress: "a1b2c3d4e5f6", Height: 9, Type: 2, BlockHash: "5dc0096d27b5", Timestamp: ts},
+		},
+		{
+			name: "numeric type and extension",
+			in:   "Vote{12:A1B2C3D4E5F6 100/11/2(Precommit) 5DC0096D27B5 D55807B92BE1 ABCDEF012345 @ 2025-10-19T07:45:15.586964Z}",
+			want: VoteSummary{ValidatorIndex: 12, ValidatorAddress: "a1b2c3d4e5f6", Height: 100, Round: 11, Type: 2, BlockHash: "5dc0096d27b5", Signature: "d55807b92be1", Extension: "abcdef012345", Timestamp: ts},
+		},
+		{
+			name: "empty fingerprints",
+			in:   " Vote{0: 5/00/SIGNED_MSG_TYPE_PREVOTE   @ 2025-10-19T07:45:15.586964Z}\n",
+			want: VoteSummary{Height: 5, Type: 1, Timestamp: ts},
+		},
+		{
+			name: "absent",
+			in:   "nil-Vote",
+			want: VoteSummary{Absent: true},
+		},
+	}
+	for _, tc := range cases {
+		got, err := ParseVoteSummary(tc.in)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !got.Timestamp.Equal(tc.want.Timestamp) {
+			t.Fatalf("%s: timestamp %v, want %v", tc.name, got.Timestamp, tc.want.Timestamp)
+		}
+		got.Timestamp = tc.want.Timestamp
+		if *got != tc.want {
+			t.Fatalf("%s: got %+v, want %+v", tc.name, *got, tc.want)
+		}
+	}
+
+	nilVote, _ := ParseVoteSummary(cases[1].in)
+	if !nilVote.IsNil() || nilVote.MessageType() != abstraction.MsgTypePrevote {
+		t.Fatalf("expected a nil prevote, got %+v", nilVote)
+	}
+
+	for _, bad := range []string{
+		"",
+		"Vote{}",
+		"Vote{0:20CA1B3031F4 162/00/SIGNED_MSG_TYPE_PROPOSAL(Proposal) 5DC0096D27B5 D55807B92BE1 @ 2025-10-19T07:45:15Z}",
+		"Vote{0:20CA1B3031F4 162/00/SIGNED_MSG_TYPE_PRECOMMIT(Precommit) 5DC0096D27B5 D55807B92BE1 @ yesterday}",
+		"Vote{0:20CA1B3031F4 99999999999999999999/00/SIGNED_MSG_TYPE_PRECOMMIT(Precommit) 5DC0096D27B5 D55807B92BE1 @ 2025-10-19T07:45:15Z}",
+	} {
+		if _, err := ParseVoteSummary(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package adapter
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+// ByzantineActionWithholdCommit keeps prevoting honestly but never precommits the block the validator proposed
+// itself. With enough withholding validators the proposal gathers a prevote polka yet never reaches +2/3
+// precommits, so the height stalls at the precommit step until the proposer rotates.
+const ByzantineActionWithholdCommit ByzantineAction = "withhold_commit"
+
+// WithholdScopeParam selects which block precommits are withheld: "own" (default) only withholds precommits
+// for blocks this validator proposed, "all" withholds every block precommit. Use "all" when a trigger step
+// keeps proposals from reaching the action, since their proposer cannot be known then.
+const WithholdScopeParam = "withhold_scope"
+
+// CommitWithholder remembers the blocks proposed at each height so only precommits for the proposer's own
+// block are suppressed. It is safe for concurrent use.
+type CommitWithholder struct {
+	mu        sync.Mutex
+	proposals map[int64]map[string]string
+}
+
+// NewCommitWithholder creates an empty withholder.
+func NewCommitWithholder() *CommitWithholder {
+	return &CommitWithholder{proposals: make(map[int64]map[string]string)}
+}
+
+// Proposed reports whether a proposal for blockHash was seen at height, and by whom. The proposer is empty
+// when the proposal did not name one, as with proposals decoded off the wire.
+func (w *CommitWithholder) Proposed(height int64, blockHash string) (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	proposer, ok := w.proposals[height][blockHash]
+	return proposer, ok
+}
+
+// Mutate implements the withhold_commit action. Proposals are recorded and forwarded, precommits for a block
+// the same validator proposed at that height are withheld, and everything else, including prevotes and nil
+// precommits, passes through unchanged.
+func (w *CommitWithholder) Mutate(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
+	height, ok := msg.HeightInt64()
+	if !ok {
+		return nil, fmt.Errorf("withhold_commit action requires a height")
+	}
+
+	scope := strings.ToLower(opts.Params[WithholdScopeParam])
+	switch scope {
+	case "", "own", "all":
+	default:
+		return nil, fmt.Errorf("unknown %s %q (want own or all)", WithholdScopeParam, scope)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch msg.Type {
+	case abstraction.MsgTypeProposal:
+		// VoteSetMaj23 and VoteSetBits share the proposal type but name a block nobody proposed here.
+		if msg.BlockHash != "" && !isStateMessage(msg) {
+			if w.proposals[height] == nil {
+				w.proposals[height] = make(map[string]string)
+			}
+			w.proposals[height][msg.BlockHash] = msg.Proposer
+			w.prune(height)
+		}
+		return []*abstraction.CanonicalMessage{byzantine.Clone(msg)}, nil
+
+	case abstraction.MsgTypePrecommit:
+		if msg.BlockHash == "" {
+			return []*abstraction.CanonicalMessage{byzantine.Clone(msg)}, nil
+		}
+		if scope == "all" {
+			return nil, nil
+		}
+		proposer, ok := w.proposals[height][msg.BlockHash]
+		if ok && (proposer == "" || msg.Validator == "" || proposer == msg.Validator) {
+			return nil, nil
+		}
+		return []*abstraction.CanonicalMessage{byzantine.Clone(msg)}, nil
+
+	default:
+		return []*abstraction.CanonicalMessage{byzantine.Clone(msg)}, nil
+	}
+}
+
+// prune drops proposals for heights that can no longer receive precommits.
+func (w *CommitWithholder) prune(current int64) {
+	for height := range w.proposals {
+		if height < current-1 {
+			delete(w.proposals, height)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Package blockstore reads historical consensus from a CometBFT node's data directory. The block store keeps,
+// for every committed height, the block header and the commit: the precommit signatures that finalized it. A
+// Reader turns those into the canonical proposal and precommits a live capture of the height would show, so past
+// behavior can go through the evidence detector, the property checker, or the bridge without a running node.
+package blockstore
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	dbm "github.com/cometbft/cometbft-db"
+	sm "github.com/cometbft/cometbft/state"
+	"github.com/cometbft/cometbft/store"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+
+	"codec/cometbft/adapter"
+	"codec/message/abstraction"
+	"codec/message/abstraction/quorum"
+)
+
+// ErrNoState is returned for validator queries when the reader has no state database.
+var ErrNoState = errors.New("no state database")
+
+// Reader reads committed heights from a block store and, when given one, validator sets from a state store.
+type Reader struct {
+	blocks *store.BlockStore
+	state  sm.Store
+	dbs    []dbm.DB
+}
+
+// Open opens blockstore.db and, when present, state.db in a node's data directory (usually $CMTHOME/data). The
+// databases must be goleveldb, the default backend. They are opened read-only, but goleveldb still locks them,
+// so stop the node or open a copy of its data directory.
+func Open(dataDir string) (*Reader, error) {
+	blockDB, err := openDB(dataDir, "blockstore")
+	if err != nil {
+		return nil, err
+	}
+	var stateDB dbm.DB
+	if _, err := os.Stat(filepath.Join(dataDir, "state.db")); err == nil {
+		if stateDB, err = openDB(dataDir, "state"); err != nil {
+			blockDB.Close()
+			return nil, err
+		}
+	}
+	r := NewReader(blockDB, stateDB)
+	r.dbs = append(r.dbs, blockDB)
+	if stateDB != nil {
+		r.dbs = append(r.dbs, stateDB)
+	}
+	return r, nil
+}
+
+func openDB(dataDir, name string) (dbm.DB, error) {
+	db, err := dbm.NewGoLevelDBWithOpts(name, dataDir, &opt.Options{ReadOnly: true, ErrorIfMissing: true})
+	if err != nil {
+		return nil, fmt.Errorf("open %s.db in %s: %w", name, dataDir, err)
+	}
+	return db, nil
+}
+
+// NewReader reads from already opened databases; stateDB may be nil. Close does not close them.
+func NewReader(blockDB, stateDB dbm.DB) *Reader {
+	r := &Reader{blocks: store.NewBlockStore(blockDB)}
+	if stateDB != nil {
+		r.state = sm.NewStore(stateDB, sm.StoreOptions{})
+	}
+	return r
+}
+
+// Close closes the databases Open opened.
+func (r *Reader) Close() error {
+	var err error
+	for _, db := range r.dbs {
+		err = errors.Join(err, db.Close())
+	}
+	return err
+}
+
+// Heights returns the lowest and highest height the block store holds. Heights below base were pruned.
+func (r *Reader) Heights() (base, height int64) {
+	return r.blocks.Base(), r.blocks.Height()
+}
+
+// Messages returns the committed height as a proposal of its block followed by one precommit per validator
+// that signed the commit, in validator-set order. Precommits for nil keep an empty block hash and absent
+// validators are left out. The proposal carries no signature, since the block store does not keep it.
+//
+// The commit comes from the next block's last commit, which is the one the chain agreed on, and for the latest
+// height from the node's own seen commit.
+func (r *Reader) Messages(height int64) ([]*abstraction.CanonicalMessage, error) {
+	meta := r.blocks.LoadBlockMeta(height)
+	if meta == nil {
+		base, top := r.Heights()
+		return nil, fmt.Errorf("height %d is not in the block store (heights %d to %d)", height, base, top)
+	}
+	commit := r.blocks.LoadBlockCommit(height)
+	if commit == nil {
+		commit = r.blocks.LoadSeenCommit(height)
+	}
+	if commit == nil {
+		return nil, fmt.Errorf("no commit stored for height %d", height)
+	}
+	var extended *cmttypes.ExtendedCommit
+	if ec := r.blocks.LoadBlockExtendedCommit(height); ec != nil && ec.Round == commit.Round && len(ec.ExtendedSignatures) == len(commit.Signatures) {
+		extended = ec
+	}
+
+	mapper := adapter.NewCometBFTMapper(meta.Header.ChainID)
+	toCanonical := func(msg adapter.CometBFTConsensusMessage) (*abstraction.CanonicalMessage, error) {
+		payload, err := json.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+		return mapper.ToCanonical(abstraction.RawConsensusMessage{
+			ChainType:   abstraction.ChainTypeCometBFT,
+			ChainID:     meta.Header.ChainID,
+			MessageType: msg.MessageType,
+			Payload:     payload,
+			Encoding:    "json",
+			Timestamp:   msg.Timestamp,
+		})
+	}
+
+	proposal, err := toCanonical(adapter.CometBFTConsensusMessage{
+		MessageType:     "Proposal",
+		Height:          strconv.FormatInt(height, 10),
+		Round:           strconv.FormatInt(int64(commit.Round), 10),
+		Timestamp:       meta.Header.Time,
+		BlockID:         blockID(commit.BlockID, meta.Header.LastBlockID.Hash),
+		ProposerAddress: hex.EncodeToString(meta.Header.ProposerAddress),
+		POLRound:        -1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("height %d proposal: %w", height, err)
+	}
+	msgs := []*abstraction.CanonicalMessage{proposal}
+
+	for i, sig := range commit.Signatures {
+		if sig.BlockIDFlag == cmttypes.BlockIDFlagAbsent {
+			continue
+		}
+		vote := adapter.CometBFTConsensusMessage{
+			MessageType:      "Vote",
+			Type:             2,
+			VoteType:         "precommit",
+			Height:           strconv.FormatInt(height, 10),
+			Round:            strconv.FormatInt(int64(commit.Round), 10),
+			Timestamp:        sig.Timestamp,
+			ValidatorAddress: hex.EncodeToString(sig.ValidatorAddress),
+			ValidatorIndex:   int32(i),
+			Signature:        encodeBase64(sig.Signature),
+		}
+		if sig.BlockIDFlag == cmttypes.BlockIDFlagCommit {
+			vote.BlockID = blockID(commit.BlockID, nil)
+			if extended != nil {
+				vote.Extension = encodeBase64(extended.ExtendedSignatures[i].Extension)
+				vote.ExtensionSignature = encodeBase64(extended.ExtendedSignatures[i].ExtensionSignature)
+			}
+		}
+		msg, err := toCanonical(vote)
+		if err != nil {
+			return nil, fmt.Errorf("height %d precommit %d: %w", height, i, err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// Range calls fn with the messages of every height from from to to, both included. A zero bound means the
+// lowest or highest height in the block store. It stops at the first error fn returns.
+func (r *Reader) Range(from, to int64, fn func(*abstraction.CanonicalMessage) error) error {
+	base, top := r.Heights()
+	if from == 0 || from < base {
+		from = base
+	}
+	if to == 0 || to > top {
+		to = top
+	}
+	for h := from; h <= to; h++ {
+		msgs, err := r.Messages(h)
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			if err := fn(msg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Validators returns the validator set that signed height, for adapter.BuildDuplicateVoteEvidence.
+func (r *Reader) Validators(height int64) (*cmttypes.ValidatorSet, error) {
+	if r.state == nil {
+		return nil, ErrNoState
+	}
+	return r.state.LoadValidators(height)
+}
+
+// VotingPowers returns the validator set at height keyed the way Messages names validators, for the evidence
+// detector and the property checker.
+func (r *Reader) VotingPowers(height int64) (quorum.ValidatorSet, error) {
+	vals, err := r.Validators(height)
+	if err != nil {
+		return nil, err
+	}
+	set := make(quorum.ValidatorSet, len(vals.Validators))
+	for _, v := range vals.Validators {
+		set[hex.EncodeToString(v.Address)] = v.VotingPower
+	}
+	return set, nil
+}
+
+func blockID(id cmttypes.BlockID, prevHash []byte) adapter.BlockID {
+	return adapter.BlockID{
+		Hash:     hex.EncodeToString(id.Hash),
+		PrevHash: hex.EncodeToString(prevHash),
+		PartSetHeader: adapter.PartSetHeader{
+			Total: id.PartSetHeader.Total,
+			Hash:  append([]byte(nil), id.PartSetHeader.Hash...),
+		},
+	}
+}
+
+func encodeBase64(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
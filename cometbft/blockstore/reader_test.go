@@ -0,0 +1,235 @@
+package blockstore
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	dbm "github.com/cometbft/cometbft-db"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	cmtversion "github.com/cometbft/cometbft/proto/tendermint/version"
+	sm "github.com/cometbft/cometbft/state"
+	"github.com/cometbft/cometbft/store"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/cometbft/cometbft/version"
+
+	"codec/cometbft/adapter"
+	"codec/message/abstraction"
+	"codec/message/abstraction/evidence"
+)
+
+const testChainID = "blockstore-chain"
+
+type testChain struct {
+	blockDB, stateDB dbm.DB
+	valSet           *cmttypes.ValidatorSet
+	privVals         []cmttypes.PrivValidator
+	blockIDs         []cmttypes.BlockID
+}
+
+// newTestChain stores heights 1 to n. Validator 0 proposes, the last validator stays away, the one before it
+// precommits nil and everyone else commits the block.
+func newTestChain(t *testing.T, n int64) *testChain {
+	return newTestChainIn(t, n, dbm.NewMemDB(), dbm.NewMemDB())
+}
+
+func newTestChainIn(t *testing.T, n int64, blockDB, stateDB dbm.DB) *testChain {
+	t.Helper()
+	c := &testChain{blockDB: blockDB, stateDB: stateDB}
+	var vals []*cmttypes.Validator
+	for i := 0; i < 7; i++ {
+		pv := cmttypes.NewMockPV()
+		pub, _ := pv.GetPubKey()
+		vals = append(vals, cmttypes.NewValidator(pub, 10))
+		c.privVals = append(c.privVals, pv)
+	}
+	c.valSet = cmttypes.NewValidatorSet(vals)
+	// NewValidatorSet sorts by address; keep the signers in the same order.
+	byAddr := map[string]cmttypes.PrivValidator{}
+	for _, pv := range c.privVals {
+		pub, _ := pv.GetPubKey()
+		byAddr[string(pub.Address())] = pv
+	}
+	for i, v := range c.valSet.Validators {
+		c.privVals[i] = byAddr[string(v.Address)]
+	}
+
+	genesis := &cmttypes.GenesisDoc{ChainID: testChainID, GenesisTime: time.Unix(1700000000, 0).UTC()}
+	for _, v := range c.valSet.Validators {
+		genesis.Validators = append(genesis.Validators, cmttypes.GenesisValidator{Address: v.Address, PubKey: v.PubKey, Power: v.VotingPower})
+	}
+	state, err := sm.MakeGenesisState(genesis)
+	if err != nil {
+		t.Fatalf("MakeGenesisState: %v", err)
+	}
+	if err := sm.NewStore(c.stateDB, sm.StoreOptions{}).Save(state); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+
+	blocks := store.NewBlockStore(c.blockDB)
+	lastCommit := &cmttypes.Commit{}
+	var lastBlockID cmttypes.BlockID
+	for h := int64(1); h <= n; h++ {
+		block := cmttypes.MakeBlock(h, nil, lastCommit, nil)
+		block.Header.Populate(cmtversion.Consensus{Block: version.BlockProtocol}, testChainID,
+			genesis.GenesisTime.Add(time.Duration(h)*time.Second), lastBlockID,
+			c.valSet.Hash(), c.valSet.Hash(), nil, nil, nil, c.valSet.Validators[0].Address)
+		parts, err := block.MakePartSet(cmttypes.BlockPartSizeBytes)
+		if err != nil {
+			t.Fatalf("MakePartSet: %v", err)
+		}
+		blockID := cmttypes.BlockID{Hash: block.Hash(), PartSetHeader: parts.Header()}
+		lastCommit = c.commit(t, h, blockID, block.Time)
+		blocks.SaveBlock(block, parts, lastCommit)
+		c.blockIDs = append(c.blockIDs, blockID)
+		lastBlockID = blockID
+	}
+	return c
+}
+
+func (c *testChain) commit(t *testing.T, height int64, blockID cmttypes.BlockID, now time.Time) *cmttypes.Commit {
+	t.Helper()
+	voteSet := cmttypes.NewVoteSet(testChainID, height, 0, cmtproto.PrecommitType, c.valSet)
+	for i, pv := range c.privVals[:len(c.privVals)-1] {
+		vote := &cmttypes.Vote{
+			Type:             cmtproto.PrecommitType,
+			Height:           height,
+			BlockID:          blockID,
+			Timestamp:        now.Add(time.Duration(i) * time.Millisecond),
+			ValidatorAddress: c.valSet.Validators[i].Address,
+			ValidatorIndex:   int32(i),
+		}
+		if i == len(c.privVals)-2 {
+			vote.BlockID = cmttypes.BlockID{}
+		}
+		pb := vote.ToProto()
+		if err := pv.SignVote(testChainID, pb); err != nil {
+			t.Fatalf("SignVote: %v", err)
+		}
+		vote.Signature = pb.Signature
+		if _, err := voteSet.AddVote(vote); err != nil {
+			t.Fatalf("AddVote: %v", err)
+		}
+	}
+	return voteSet.MakeExtendedCommit(cmttypes.ABCIParams{}).ToCommit()
+}
+
+func TestMessages(t *testing.T) {
+	c := newTestChain(t, 3)
+	r := NewReader(c.blockDB, c.stateDB)
+	if base, top := r.Heights(); base != 1 || top != 3 {
+		t.Fatalf("Heights = %d, %d", base, top)
+	}
+
+	for _, height := range []int64{2, 3} { // 2 has a next block, 3 only the seen commit
+		msgs, err := r.Messages(height)
+		if err != nil {
+			t.Fatalf("Messages(%d): %v", height, err)
+		}
+		if len(msgs) != 7 {
+			t.Fatalf("height %d: got %d messages, want a proposal and 6 precommits", height, len(msgs))
+		}
+		blockHash := hex.EncodeToString(c.blockIDs[height-1].Hash)
+		proposal := msgs[0]
+		if proposal.Type != abstraction.MsgTypeProposal || proposal.Height.Int64() != height || proposal.BlockHash != blockHash ||
+			proposal.PrevHash != hex.EncodeToString(c.blockIDs[height-2].Hash) || proposal.Proposer != hex.EncodeToString(c.valSet.Validators[0].Address) {
+			t.Fatalf("height %d: unexpected proposal %+v", height, proposal)
+		}
+
+		for i, msg := range msgs[1:] {
+			if msg.Type != abstraction.MsgTypePrecommit || msg.Height.Int64() != height || msg.Round.Int64() != 0 {
+				t.Fatalf("height %d precommit %d: unexpected %+v", height, i, msg)
+			}
+			if msg.Validator != hex.EncodeToString(c.valSet.Validators[i].Address) {
+				t.Fatalf("height %d precommit %d: validator %s", height, i, msg.Validator)
+			}
+			wantHash := blockHash
+			if i == 5 {
+				wantHash = ""
+			}
+			if msg.BlockHash != wantHash {
+				t.Fatalf("height %d precommit %d: block hash %q, want %q", height, i, msg.BlockHash, wantHash)
+			}
+			signBytes, err := adapter.SignBytes(testChainID, msg)
+			if err != nil {
+				t.Fatalf("SignBytes: %v", err)
+			}
+			sig, err := base64.StdEncoding.DecodeString(msg.Signature)
+			if err != nil {
+				t.Fatalf("signature: %v", err)
+			}
+			if !c.valSet.Validators[i].PubKey.VerifySignature(signBytes, sig) {
+				t.Fatalf("height %d precommit %d: signature does not verify", height, i)
+			}
+		}
+	}
+
+	if _, err := r.Messages(4); err == nil {
+		t.Fatal("expected an error for a height beyond the store")
+	}
+}
+
+func TestRangeFeedsDetector(t *testing.T) {
+	c := newTestChain(t, 3)
+	r := NewReader(c.blockDB, c.stateDB)
+	powers, err := r.VotingPowers(1)
+	if err != nil {
+		t.Fatalf("VotingPowers: %v", err)
+	}
+	if len(powers) != 7 {
+		t.Fatalf("got %d validators", len(powers))
+	}
+
+	detector := evidence.NewDetector(powers)
+	count := 0
+	err = r.Range(0, 0, func(msg *abstraction.CanonicalMessage) error {
+		count++
+		if found := detector.Observe(msg); len(found) != 0 {
+			t.Fatalf("unexpected evidence %+v", found)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Range: %v", err)
+	}
+	if count != 21 {
+		t.Fatalf("Range visited %d messages, want 21", count)
+	}
+
+	if _, err := NewReader(c.blockDB, nil).VotingPowers(1); err != ErrNoState {
+		t.Fatalf("expected ErrNoState, got %v", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	blockDB, err := dbm.NewGoLevelDB("blockstore", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateDB, err := dbm.NewGoLevelDB("state", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestChainIn(t, 2, blockDB, stateDB)
+	blockDB.Close()
+	stateDB.Close()
+
+	r, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+	msgs, err := r.Messages(2)
+	if err != nil || len(msgs) != 7 || msgs[0].BlockHash != hex.EncodeToString(c.blockIDs[1].Hash) {
+		t.Fatalf("Messages(2) = %d messages, %v", len(msgs), err)
+	}
+	if _, err := r.VotingPowers(2); err != nil {
+		t.Fatalf("VotingPowers: %v", err)
+	}
+
+	if _, err := Open(t.TempDir()); err == nil {
+		t.Fatal("expected an error for a directory without a block store")
+	}
+}
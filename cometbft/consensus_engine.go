@@ -4,6 +4,8 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"io"
+	"log/slog"
 	"math/big"
 	"time"
 
@@ -25,6 +27,15 @@ type Validator struct {
 	ProposerPriority int64  `json:"proposer_priority"`
 }
 
+// ValidatorSource supplies the validator sets of a real chain, such as a validatorset.Tracker fed by a node's
+// RPC, in place of the fixed set the engine was created with
+type ValidatorSource interface {
+	// ValidatorsAt returns the validator set in force at height
+	ValidatorsAt(height int64) ([]Validator, bool)
+	// ProposerAt returns the address of the proposer of a round
+	ProposerAt(height int64, round int32) (string, bool)
+}
+
 // ConsensusState represents the current consensus state
 type ConsensusState struct {
 	Height           int64        `json:"height"`
@@ -34,13 +45,98 @@ type ConsensusState struct {
 	Validators       ValidatorSet `json:"validators"`
 	LastCommitRound  int32        `json:"last_commit_round"`
 	LastCommitHeight int64        `json:"last_commit_height"`
+	// ProposalBlock is the block hash of the first proposal accepted in the current round and
+	// ProposalPOLRound the round of the polka it claims, -1 if none
+	ProposalBlock    string `json:"proposal_block"`
+	ProposalPOLRound int32  `json:"proposal_pol_round"`
+	// LockedRound and LockedBlock hold the last block seen with a polka and its proposal, -1 and empty when
+	// unlocked. ValidRound and ValidBlock hold the most recent such block, which an honest proposer re-proposes.
+	LockedRound int32  `json:"locked_round"`
+	LockedBlock string `json:"locked_block"`
+	ValidRound  int32  `json:"valid_round"`
+	ValidBlock  string `json:"valid_block"`
+}
+
+// TimeoutConfig sets how long each step waits for quorum before the engine gives up on it. Round r waits
+// r times the delta longer than round 0, as in CometBFT. A zero timeout never fires.
+type TimeoutConfig struct {
+	Propose        time.Duration `json:"propose"`
+	ProposeDelta   time.Duration `json:"propose_delta"`
+	Prevote        time.Duration `json:"prevote"`
+	PrevoteDelta   time.Duration `json:"prevote_delta"`
+	Precommit      time.Duration `json:"precommit"`
+	PrecommitDelta time.Duration `json:"precommit_delta"`
+}
+
+// DefaultTimeoutConfig returns CometBFT's default consensus timeouts
+func DefaultTimeoutConfig() TimeoutConfig {
+	return TimeoutConfig{
+		Propose:        3 * time.Second,
+		ProposeDelta:   500 * time.Millisecond,
+		Prevote:        time.Second,
+		PrevoteDelta:   500 * time.Millisecond,
+		Precommit:      time.Second,
+		PrecommitDelta: 500 * time.Millisecond,
+	}
+}
+
+// EvidenceType names the misbehaviour an Evidence item proves
+type EvidenceType string
+
+const (
+	// EvidenceDuplicateVote is two prevotes or two precommits for different blocks in one round
+	EvidenceDuplicateVote EvidenceType = "duplicate_vote"
+	// EvidenceDuplicateProposal is two proposals for different blocks in one round
+	EvidenceDuplicateProposal EvidenceType = "duplicate_proposal"
+)
+
+// Evidence records a validator signing two conflicting messages at the same height and round. Only the
+// first was counted.
+type Evidence struct {
+	Type      EvidenceType                  `json:"type"`
+	Validator string                        `json:"validator"`
+	Height    int64                         `json:"height"`
+	Round     int32                         `json:"round"`
+	First     *abstraction.CanonicalMessage `json:"first"`
+	Second    *abstraction.CanonicalMessage `json:"second"`
+	// Power is the validator's voting power when the evidence was found and Slashed the part of it removed
+	Power     int64     `json:"power"`
+	Slashed   int64     `json:"slashed"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // ConsensusEngine simulates CometBFT consensus engine behavior
 type ConsensusEngine struct {
 	state      ConsensusState
+	timeouts   TimeoutConfig
+	now        func() time.Time
+	logger     *slog.Logger
+	stepStart  time.Time
 	validators map[string]Validator
 	proposer   string
+	proposal   *abstraction.CanonicalMessage
+	votes      map[voteSetKey]*voteSet
+	commits    map[int64]string
+	evidence   []Evidence
+	// slashFraction is the share of voting power a validator loses for its first evidence; it is then
+	// tombstoned, as in the Cosmos SDK, and never slashed again
+	slashFraction float64
+	tombstoned    map[string]bool
+	source        ValidatorSource
+}
+
+// voteSetKey identifies the prevotes or precommits of one round
+type voteSetKey struct {
+	height  int64
+	round   int32
+	msgType abstraction.MsgType
+}
+
+// voteSet counts each validator's first vote of a round by block hash; an empty hash is a nil vote. Later
+// conflicting votes add no power, as in CometBFT's VoteSet.
+type voteSet struct {
+	votes map[string]*abstraction.CanonicalMessage
+	power map[string]int64
 }
 
 // NewConsensusEngine creates a new CometBFT consensus engine
@@ -57,23 +153,135 @@ func NewConsensusEngine(validators []Validator) *ConsensusEngine {
 		}
 	}
 
+	now := abstraction.Now()
 	return &ConsensusEngine{
 		state: ConsensusState{
 			Height:           0,
 			Round:            0,
 			Step:             0,
-			StartTime:        time.Now(),
+			StartTime:        now,
 			Validators:       ValidatorSet{Validators: validators, Proposer: proposer, TotalPower: totalPower},
 			LastCommitRound:  -1,
 			LastCommitHeight: -1,
+			ProposalPOLRound: -1,
+			LockedRound:      -1,
+			ValidRound:       -1,
 		},
 		validators: validatorMap,
 		proposer:   proposer.Address,
+		now:        abstraction.Now,
+		logger:     slog.Default(),
+		stepStart:  now,
+		votes:      make(map[voteSetKey]*voteSet),
+		commits:    make(map[int64]string),
+		tombstoned: make(map[string]bool),
+	}
+}
+
+// SetLogger sends the engine's progress log to logger. Messages it processes are logged at debug level,
+// locks, polkas, commits and timeouts at info, and evidence at warn. The default is slog.Default.
+func (ce *ConsensusEngine) SetLogger(logger *slog.Logger) {
+	ce.logger = logger
+}
+
+// SetOutput sends the engine's progress log to w as text, at every level; io.Discard silences it
+func (ce *ConsensusEngine) SetOutput(w io.Writer) {
+	if w == io.Discard {
+		ce.logger = slog.New(slog.DiscardHandler)
+		return
+	}
+	ce.logger = slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// SetTimeouts configures the step timeouts; the zero config, the default, disables them
+func (ce *ConsensusEngine) SetTimeouts(timeouts TimeoutConfig) {
+	ce.timeouts = timeouts
+}
+
+// SetSlashFraction makes evidence cost a validator fraction of its voting power, once; zero disables slashing
+func (ce *ConsensusEngine) SetSlashFraction(fraction float64) {
+	ce.slashFraction = fraction
+}
+
+// SetValidatorSource makes the engine load the validator set and proposer of every height and round from
+// source. Heights and rounds the source does not know keep the previous set and the round-robin proposer.
+// A set loaded from the source replaces the voting power slashing removed.
+func (ce *ConsensusEngine) SetValidatorSource(source ValidatorSource) {
+	ce.source = source
+	ce.loadValidators()
+	ce.updateProposer()
+}
+
+// SetClock replaces the clock the engine reads, so tests and replays can drive timeouts deterministically
+func (ce *ConsensusEngine) SetClock(now func() time.Time) {
+	ce.now = now
+	ce.stepStart = now()
+}
+
+// Tick fires every step timeout that expired by now and returns how many did. A propose timeout moves to
+// the prevote step, a prevote timeout to the precommit step and a precommit timeout to the next round.
+// Unlike a CometBFT node, which starts the prevote and precommit timeouts only once +2/3 of any votes
+// arrived, the engine starts them on entering the step, so dropped votes show up as round changes.
+func (ce *ConsensusEngine) Tick() int {
+	now := ce.now()
+	fired := 0
+	for {
+		timeout := ce.stepTimeout()
+		if timeout <= 0 {
+			return fired
+		}
+		deadline := ce.stepStart.Add(timeout)
+		if now.Before(deadline) {
+			return fired
+		}
+		fired++
+		ce.logger.Info("timeout", "height", ce.state.Height, "round", ce.state.Round, "step", ce.state.Step)
+		switch ce.state.Step {
+		case 0:
+			ce.enterStep(2) // Prevote nil
+		case 1, 2:
+			ce.enterStep(3) // Precommit nil
+		default:
+			ce.AdvanceRound()
+			ce.state.StartTime = deadline
+		}
+		// Catch up from the deadline, not from now, so a late tick fires the same timeouts as timely ones
+		ce.stepStart = deadline
+	}
+}
+
+// stepTimeout returns how long the current step of the current round waits
+func (ce *ConsensusEngine) stepTimeout() time.Duration {
+	round := time.Duration(ce.state.Round)
+	switch ce.state.Step {
+	case 0:
+		if ce.timeouts.Propose <= 0 {
+			return 0
+		}
+		return ce.timeouts.Propose + round*ce.timeouts.ProposeDelta
+	case 1, 2:
+		if ce.timeouts.Prevote <= 0 {
+			return 0
+		}
+		return ce.timeouts.Prevote + round*ce.timeouts.PrevoteDelta
+	default:
+		if ce.timeouts.Precommit <= 0 {
+			return 0
+		}
+		return ce.timeouts.Precommit + round*ce.timeouts.PrecommitDelta
 	}
 }
 
-// ProcessMessage processes a consensus message and updates state
+// enterStep moves to step and restarts its timeout
+func (ce *ConsensusEngine) enterStep(step uint32) {
+	ce.state.Step = step
+	ce.stepStart = ce.now()
+}
+
+// ProcessMessage processes a consensus message and updates state. Expired timeouts fire first, so a
+// message delayed past them is judged against the round the engine moved on to.
 func (ce *ConsensusEngine) ProcessMessage(msg *abstraction.CanonicalMessage) error {
+	ce.Tick()
 	switch msg.Type {
 	case abstraction.MsgTypeProposal:
 		return ce.processProposal(msg)
@@ -104,16 +312,78 @@ func (ce *ConsensusEngine) processProposal(msg *abstraction.CanonicalMessage) er
 		return fmt.Errorf("invalid round: expected %d, got %v", ce.state.Round, msg.Round)
 	}
 
+	// Only the first proposal of a round counts; a different one is a byzantine double proposal
+	if ce.proposal != nil {
+		if ce.proposal.BlockHash != msg.BlockHash {
+			ce.addEvidence(EvidenceDuplicateProposal, msg.Proposer, ce.proposal, msg)
+			ce.logger.Warn("conflicting proposal ignored",
+				append(abstraction.LogAttrs(msg), "block_hash", msg.BlockHash, "accepted", ce.proposal.BlockHash)...)
+		}
+		return nil
+	}
+
 	// Update state
-	ce.state.Step = 1 // Propose step
+	if ce.state.Step < 1 {
+		ce.enterStep(1) // Propose step
+	}
 	ce.state.StartTime = msg.Timestamp
+	ce.proposal = msg
+	ce.state.ProposalBlock = msg.BlockHash
+	ce.state.ProposalPOLRound = -1
+	if polRound, ok := msg.Extensions.GetInt64("pol_round"); ok {
+		ce.state.ProposalPOLRound = int32(polRound)
+	}
 
-	fmt.Printf("✅ Proposal processed: height=%v, round=%v, proposer=%s\n",
-		msg.Height, msg.Round, msg.Proposer)
+	ce.logger.Debug("proposal processed", append(abstraction.LogAttrs(msg), "proposer", msg.Proposer, "prevote", ce.PrevoteFor())...)
+
+	// The polka may have arrived before the proposal
+	ce.updateLocks()
 
 	return nil
 }
 
+// PrevoteFor returns the block hash the engine prevotes for the current round's proposal under the
+// Tendermint locking rules; an empty hash is a nil prevote. A locked engine only prevotes a different block
+// when the proposal carries a polka from a round at or after its lock.
+func (ce *ConsensusEngine) PrevoteFor() string {
+	if ce.proposal == nil || ce.state.ProposalBlock == "" {
+		return ""
+	}
+	blockHash, polRound := ce.state.ProposalBlock, ce.state.ProposalPOLRound
+	if polRound < 0 {
+		if ce.state.LockedRound == -1 || ce.state.LockedBlock == blockHash {
+			return blockHash
+		}
+		return ""
+	}
+	if polRound >= ce.state.Round {
+		return ""
+	}
+	if polka, ok := ce.HasTwoThirdsMajority(polRound, abstraction.MsgTypePrevote); !ok || polka != blockHash {
+		return ""
+	}
+	if ce.state.LockedRound <= polRound || ce.state.LockedBlock == blockHash {
+		return blockHash
+	}
+	return ""
+}
+
+// updateLocks locks on and marks valid the current round's proposal once it has +2/3 prevotes
+func (ce *ConsensusEngine) updateLocks() {
+	round := ce.state.Round
+	blockHash, ok := ce.HasTwoThirdsMajority(round, abstraction.MsgTypePrevote)
+	if !ok || blockHash == "" || ce.proposal == nil || blockHash != ce.state.ProposalBlock {
+		return
+	}
+	if ce.state.ValidRound < round {
+		ce.state.ValidRound, ce.state.ValidBlock = round, blockHash
+	}
+	if ce.state.LockedRound < round {
+		ce.state.LockedRound, ce.state.LockedBlock = round, blockHash
+		ce.logger.Info("locked", "height", ce.state.Height, "round", round, "block_hash", blockHash)
+	}
+}
+
 // processPrevote processes a prevote message
 func (ce *ConsensusEngine) processPrevote(msg *abstraction.CanonicalMessage) error {
 	// Validate validator
@@ -133,11 +403,18 @@ func (ce *ConsensusEngine) processPrevote(msg *abstraction.CanonicalMessage) err
 
 	// Update state
 	if ce.state.Step < 2 {
-		ce.state.Step = 2 // Prevote step
+		ce.enterStep(2) // Prevote step
 	}
+	ce.addVote(msg, validator)
 
-	fmt.Printf("✅ Prevote processed: height=%v, round=%v, validator=%s, power=%d\n",
-		msg.Height, msg.Round, msg.Validator, validator.VotingPower)
+	ce.logger.Debug("prevote processed", append(abstraction.LogAttrs(msg), "validator", msg.Validator, "power", validator.VotingPower)...)
+
+	// +2/3 prevotes for one block, or for nil, move the round to precommit
+	if blockHash, ok := ce.HasTwoThirdsMajority(ce.state.Round, abstraction.MsgTypePrevote); ok && ce.state.Step < 3 {
+		ce.enterStep(3) // Precommit step
+		ce.logger.Info("polka", "height", ce.state.Height, "round", ce.state.Round, "block_hash", blockHash)
+	}
+	ce.updateLocks()
 
 	return nil
 }
@@ -150,6 +427,13 @@ func (ce *ConsensusEngine) processPrecommit(msg *abstraction.CanonicalMessage) e
 		return fmt.Errorf("unknown validator: %s", msg.Validator)
 	}
 
+	// Precommits that arrive after their height committed still belong to its commit
+	if ce.isLateCommitVote(msg) {
+		ce.addVote(msg, validator)
+		ce.logger.Debug("late precommit processed", append(abstraction.LogAttrs(msg), "validator", msg.Validator, "power", validator.VotingPower)...)
+		return nil
+	}
+
 	// Validate height and round
 	if msg.Height.Cmp(big.NewInt(ce.state.Height)) != 0 {
 		return fmt.Errorf("invalid height: expected %d, got %v", ce.state.Height, msg.Height)
@@ -159,17 +443,140 @@ func (ce *ConsensusEngine) processPrecommit(msg *abstraction.CanonicalMessage) e
 		return fmt.Errorf("invalid round: expected %d, got %v", ce.state.Round, msg.Round)
 	}
 
-	// Update state
-	if ce.state.Step < 3 {
-		ce.state.Step = 3 // Precommit step
-	}
+	ce.addVote(msg, validator)
 
-	fmt.Printf("✅ Precommit processed: height=%v, round=%v, validator=%s, power=%d\n",
-		msg.Height, msg.Round, msg.Validator, validator.VotingPower)
+	ce.logger.Debug("precommit processed", append(abstraction.LogAttrs(msg), "validator", msg.Validator, "power", validator.VotingPower)...)
+
+	blockHash, ok := ce.HasTwoThirdsMajority(ce.state.Round, abstraction.MsgTypePrecommit)
+	switch {
+	case !ok:
+	case blockHash == "":
+		// +2/3 precommits for nil end the round without a block
+		ce.logger.Info("nil commit", "height", ce.state.Height, "round", ce.state.Round)
+		ce.AdvanceRound()
+	default:
+		ce.commit(blockHash)
+	}
 
 	return nil
 }
 
+// addVote counts a prevote or precommit in its round's vote set
+func (ce *ConsensusEngine) addVote(msg *abstraction.CanonicalMessage, validator Validator) {
+	key := voteSetKey{height: msg.Height.Int64(), round: int32(msg.Round.Int64()), msgType: msg.Type}
+	set, ok := ce.votes[key]
+	if !ok {
+		set = &voteSet{votes: make(map[string]*abstraction.CanonicalMessage), power: make(map[string]int64)}
+		ce.votes[key] = set
+	}
+	if previous, voted := set.votes[msg.Validator]; voted {
+		if previous.BlockHash != msg.BlockHash {
+			ce.addEvidence(EvidenceDuplicateVote, msg.Validator, previous, msg)
+		}
+		return
+	}
+	set.votes[msg.Validator] = msg
+	set.power[msg.BlockHash] += validator.VotingPower
+}
+
+// addEvidence records that address signed both first and second, and slashes it if it was not slashed before.
+// Votes it already cast keep their power.
+func (ce *ConsensusEngine) addEvidence(evidenceType EvidenceType, address string, first, second *abstraction.CanonicalMessage) {
+	validator := ce.validators[address]
+	ev := Evidence{
+		Type:      evidenceType,
+		Validator: address,
+		Height:    second.Height.Int64(),
+		Round:     int32(second.Round.Int64()),
+		First:     first,
+		Second:    second,
+		Power:     validator.VotingPower,
+		Timestamp: ce.now(),
+	}
+	if ce.slashFraction > 0 && !ce.tombstoned[address] {
+		ce.tombstoned[address] = true
+		ev.Slashed = ce.slash(address, int64(float64(validator.VotingPower)*ce.slashFraction))
+	}
+	ce.evidence = append(ce.evidence, ev)
+	ce.logger.Warn("evidence", "evidence_type", ev.Type, "validator", ev.Validator, "height", ev.Height, "round", ev.Round,
+		"type", second.Type, "slashed", ev.Slashed)
+}
+
+// slash removes up to amount of voting power from a validator and returns how much it removed
+func (ce *ConsensusEngine) slash(address string, amount int64) int64 {
+	validator, ok := ce.validators[address]
+	if !ok || amount <= 0 {
+		return 0
+	}
+	if amount > validator.VotingPower {
+		amount = validator.VotingPower
+	}
+	validator.VotingPower -= amount
+	ce.validators[address] = validator
+	for i := range ce.state.Validators.Validators {
+		if ce.state.Validators.Validators[i].Address == address {
+			ce.state.Validators.Validators[i].VotingPower = validator.VotingPower
+		}
+	}
+	if ce.state.Validators.Proposer.Address == address {
+		ce.state.Validators.Proposer.VotingPower = validator.VotingPower
+	}
+	ce.state.Validators.TotalPower -= amount
+	return amount
+}
+
+// isLateCommitVote reports whether msg is a precommit for the block already committed at its height
+func (ce *ConsensusEngine) isLateCommitVote(msg *abstraction.CanonicalMessage) bool {
+	if height, ok := msg.HeightInt64(); !ok || height != ce.state.LastCommitHeight {
+		return false
+	}
+	_, committed := ce.commits[ce.state.LastCommitHeight]
+	return committed && msg.Round.Cmp(big.NewInt(int64(ce.state.LastCommitRound))) == 0
+}
+
+// commit finalizes blockHash at the current height and round and moves on to the next height
+func (ce *ConsensusEngine) commit(blockHash string) {
+	height, round := ce.state.Height, ce.state.Round
+	ce.commits[height] = blockHash
+	ce.logger.Info("commit", "height", height, "round", round, "block_hash", blockHash)
+
+	// Keep the committed height's votes for late precommits and drop everything older
+	for key := range ce.votes {
+		if key.height < height {
+			delete(ce.votes, key)
+		}
+	}
+
+	ce.AdvanceHeight(height + 1)
+	ce.state.LastCommitRound = round
+}
+
+// HasTwoThirdsMajority returns the block hash that more than two thirds of the voting power prevoted or
+// precommitted in a round of the current height; an empty hash is a majority for nil.
+func (ce *ConsensusEngine) HasTwoThirdsMajority(round int32, msgType abstraction.MsgType) (string, bool) {
+	set, ok := ce.votes[voteSetKey{height: ce.state.Height, round: round, msgType: msgType}]
+	if !ok {
+		return "", false
+	}
+	for blockHash, power := range set.power {
+		if power*3 > ce.state.Validators.TotalPower*2 {
+			return blockHash, true
+		}
+	}
+	return "", false
+}
+
+// CommittedBlock returns the block hash committed at height, if the engine saw it commit
+func (ce *ConsensusEngine) CommittedBlock(height int64) (string, bool) {
+	blockHash, ok := ce.commits[height]
+	return blockHash, ok
+}
+
+// GetEvidence returns the evidence found so far, in the order the conflicting messages arrived
+func (ce *ConsensusEngine) GetEvidence() []Evidence {
+	return append([]Evidence(nil), ce.evidence...)
+}
+
 // processBlockPart processes a block part message
 func (ce *ConsensusEngine) processBlockPart(msg *abstraction.CanonicalMessage) error {
 	// Validate height and round
@@ -181,8 +588,7 @@ func (ce *ConsensusEngine) processBlockPart(msg *abstraction.CanonicalMessage) e
 		return fmt.Errorf("invalid round: expected %d, got %v", ce.state.Round, msg.Round)
 	}
 
-	fmt.Printf("✅ BlockPart processed: height=%v, round=%v, block_hash=%s\n",
-		msg.Height, msg.Round, msg.BlockHash)
+	ce.logger.Debug("block part processed", append(abstraction.LogAttrs(msg), "block_hash", msg.BlockHash)...)
 
 	return nil
 }
@@ -202,16 +608,19 @@ func (ce *ConsensusEngine) GetCurrentRound() int32 {
 	return ce.state.Round
 }
 
-// IsConsensusReached checks if consensus has been reached
+// IsConsensusReached reports whether the previous height committed, that is whether more than two thirds
+// of the voting power precommitted one of its blocks
 func (ce *ConsensusEngine) IsConsensusReached() bool {
-	return ce.state.Step >= 3 // Precommit step indicates consensus
+	_, ok := ce.commits[ce.state.Height-1]
+	return ok
 }
 
 // AdvanceRound advances to the next round
 func (ce *ConsensusEngine) AdvanceRound() {
 	ce.state.Round++
-	ce.state.Step = 0
-	ce.state.StartTime = time.Now()
+	ce.enterStep(0)
+	ce.state.StartTime = ce.stepStart
+	ce.resetProposal()
 
 	// Update proposer (round-robin)
 	ce.updateProposer()
@@ -221,17 +630,53 @@ func (ce *ConsensusEngine) AdvanceRound() {
 func (ce *ConsensusEngine) AdvanceHeight(height int64) {
 	ce.state.Height = height
 	ce.state.Round = 0
-	ce.state.Step = 0
-	ce.state.StartTime = time.Now()
+	ce.enterStep(0)
+	ce.state.StartTime = ce.stepStart
 	ce.state.LastCommitHeight = height - 1
 	ce.state.LastCommitRound = ce.state.Round
+	ce.resetProposal()
+	ce.state.LockedRound, ce.state.LockedBlock = -1, ""
+	ce.state.ValidRound, ce.state.ValidBlock = -1, ""
 
-	// Update proposer
+	// Update validators and proposer
+	ce.loadValidators()
 	ce.updateProposer()
 }
 
-// updateProposer updates the proposer based on round-robin
+// loadValidators replaces the validator set with the source's set for the current height
+func (ce *ConsensusEngine) loadValidators() {
+	if ce.source == nil {
+		return
+	}
+	validators, ok := ce.source.ValidatorsAt(ce.state.Height)
+	if !ok {
+		return
+	}
+	ce.validators = make(map[string]Validator, len(validators))
+	var totalPower int64
+	for _, val := range validators {
+		ce.validators[val.Address] = val
+		totalPower += val.VotingPower
+	}
+	ce.state.Validators = ValidatorSet{Validators: validators, Proposer: ce.state.Validators.Proposer, TotalPower: totalPower}
+}
+
+// resetProposal forgets the proposal of the round being left; locks carry over to the next round
+func (ce *ConsensusEngine) resetProposal() {
+	ce.proposal = nil
+	ce.state.ProposalBlock = ""
+	ce.state.ProposalPOLRound = -1
+}
+
+// updateProposer updates the proposer from the validator source, or based on round-robin
 func (ce *ConsensusEngine) updateProposer() {
+	if ce.source != nil {
+		if address, ok := ce.source.ProposerAt(ce.state.Height, ce.state.Round); ok {
+			ce.proposer = address
+			ce.state.Validators.Proposer = ce.validators[address]
+			return
+		}
+	}
 	if len(ce.state.Validators.Validators) == 0 {
 		return
 	}
@@ -243,7 +688,7 @@ func (ce *ConsensusEngine) updateProposer() {
 
 // GenerateBlockHash generates a deterministic block hash
 func (ce *ConsensusEngine) GenerateBlockHash(height int64, round int32, proposer string) string {
-	data := fmt.Sprintf("%d:%d:%s:%d", height, round, proposer, time.Now().Unix())
+	data := fmt.Sprintf("%d:%d:%s:%d", height, round, proposer, ce.now().Unix())
 	hash := sha256.Sum256([]byte(data))
 	return hex.EncodeToString(hash[:])
 }
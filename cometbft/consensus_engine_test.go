@@ -0,0 +1,286 @@
+package cometbft
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+func engineVote(msgType abstraction.MsgType, height int64, round int32, validator, blockHash string) *abstraction.CanonicalMessage {
+	return &abstraction.CanonicalMessage{
+		Type:      msgType,
+		Height:    big.NewInt(height),
+		Round:     big.NewInt(int64(round)),
+		Timestamp: time.Now(),
+		Validator: validator,
+		BlockHash: blockHash,
+	}
+}
+
+func newTestEngine() *ConsensusEngine {
+	engine := NewConsensusEngine([]Validator{
+		{Address: "v1", VotingPower: 10},
+		{Address: "v2", VotingPower: 10},
+		{Address: "v3", VotingPower: 10},
+		{Address: "v4", VotingPower: 10},
+	})
+	engine.AdvanceHeight(5)
+	return engine
+}
+
+func TestConsensusEngineCommitsOnTwoThirdsPrecommits(t *testing.T) {
+	engine := newTestEngine()
+	for _, v := range []string{"v1", "v2"} {
+		if err := engine.ProcessMessage(engineVote(abstraction.MsgTypePrevote, 5, 0, v, "A")); err != nil {
+			t.Fatalf("prevote: %v", err)
+		}
+	}
+	// v1 equivocates; its second prevote must not count towards a polka.
+	if err := engine.ProcessMessage(engineVote(abstraction.MsgTypePrevote, 5, 0, "v1", "B")); err != nil {
+		t.Fatalf("conflicting prevote: %v", err)
+	}
+	if engine.GetState().Step != 2 {
+		t.Fatalf("half of the power must not reach precommit, step %d", engine.GetState().Step)
+	}
+	if evidence := engine.GetEvidence(); len(evidence) != 1 || evidence[0].Type != EvidenceDuplicateVote || evidence[0].Second.BlockHash != "B" {
+		t.Fatalf("expected evidence of v1's duplicate prevote, got %+v", evidence)
+	}
+	engine.ProcessMessage(engineVote(abstraction.MsgTypePrevote, 5, 0, "v3", "A"))
+	if hash, ok := engine.HasTwoThirdsMajority(0, abstraction.MsgTypePrevote); !ok || hash != "A" || engine.GetState().Step != 3 {
+		t.Fatalf("expected a polka for A and the precommit step, got %q %v step %d", hash, ok, engine.GetState().Step)
+	}
+
+	for _, v := range []string{"v1", "v2"} {
+		engine.ProcessMessage(engineVote(abstraction.MsgTypePrecommit, 5, 0, v, "A"))
+	}
+	if engine.IsConsensusReached() || engine.GetCurrentHeight() != 5 {
+		t.Fatalf("two of four precommits must not commit")
+	}
+	engine.ProcessMessage(engineVote(abstraction.MsgTypePrecommit, 5, 0, "v3", "A"))
+	if !engine.IsConsensusReached() || engine.GetCurrentHeight() != 6 || engine.GetState().LastCommitHeight != 5 {
+		t.Fatalf("expected a commit at height 5, got %+v", engine.GetState())
+	}
+	if hash, ok := engine.CommittedBlock(5); !ok || hash != "A" {
+		t.Fatalf("expected A committed at height 5, got %q", hash)
+	}
+	if err := engine.ProcessMessage(engineVote(abstraction.MsgTypePrecommit, 5, 0, "v4", "A")); err != nil {
+		t.Fatalf("late precommit for the committed block rejected: %v", err)
+	}
+	if err := engine.ProcessMessage(engineVote(abstraction.MsgTypePrecommit, 4, 0, "v4", "A")); err == nil {
+		t.Fatalf("expected a precommit for an older height to be rejected")
+	}
+}
+
+func TestConsensusEngineNilPrecommitsAdvanceRound(t *testing.T) {
+	engine := newTestEngine()
+	for _, v := range []string{"v1", "v2", "v3"} {
+		engine.ProcessMessage(engineVote(abstraction.MsgTypePrecommit, 5, 0, v, ""))
+	}
+	if engine.IsConsensusReached() || engine.GetCurrentHeight() != 5 || engine.GetCurrentRound() != 1 {
+		t.Fatalf("expected +2/3 nil precommits to move to round 1 without a commit, got %+v", engine.GetState())
+	}
+}
+
+func TestConsensusEngineLockingRules(t *testing.T) {
+	engine := newTestEngine()
+	proposal := func(round int32, proposer, blockHash string, polRound int64) *abstraction.CanonicalMessage {
+		msg := engineVote(abstraction.MsgTypeProposal, 5, round, "", blockHash)
+		msg.Proposer = proposer
+		msg.Extensions = map[string]interface{}{"pol_round": polRound}
+		return msg
+	}
+	nilRound := func(round int32) {
+		for _, v := range []string{"v1", "v2", "v3"} {
+			engine.ProcessMessage(engineVote(abstraction.MsgTypePrecommit, 5, round, v, ""))
+		}
+	}
+
+	// Round 0: a polka for A locks the engine on A
+	engine.ProcessMessage(proposal(0, "v1", "A", -1))
+	if engine.PrevoteFor() != "A" {
+		t.Fatalf("an unlocked engine must prevote the proposal, got %q", engine.PrevoteFor())
+	}
+	for _, v := range []string{"v1", "v2", "v3"} {
+		engine.ProcessMessage(engineVote(abstraction.MsgTypePrevote, 5, 0, v, "A"))
+	}
+	if state := engine.GetState(); state.LockedRound != 0 || state.LockedBlock != "A" || state.ValidRound != 0 || state.ValidBlock != "A" {
+		t.Fatalf("expected a lock on A in round 0, got %+v", state)
+	}
+	nilRound(0)
+
+	// Round 1: the proposer sends B, then A; the engine keeps B, prevotes nil and relocks on B's polka
+	if err := engine.ProcessMessage(proposal(1, "v2", "B", -1)); err != nil {
+		t.Fatalf("proposal: %v", err)
+	}
+	engine.ProcessMessage(proposal(1, "v2", "A", -1))
+	if state := engine.GetState(); state.ProposalBlock != "B" || len(engine.GetEvidence()) != 1 {
+		t.Fatalf("expected the first proposal to be kept and the second recorded, got %+v", state)
+	}
+	if engine.PrevoteFor() != "" {
+		t.Fatalf("an engine locked on A must prevote nil for B, got %q", engine.PrevoteFor())
+	}
+	for _, v := range []string{"v2", "v3", "v4"} {
+		engine.ProcessMessage(engineVote(abstraction.MsgTypePrevote, 5, 1, v, "B"))
+	}
+	if state := engine.GetState(); state.LockedRound != 1 || state.LockedBlock != "B" {
+		t.Fatalf("expected a relock on B in round 1, got %+v", state)
+	}
+	nilRound(1)
+
+	// Round 2: A's older polka does not unlock B, B's does
+	engine.ProcessMessage(proposal(2, "v3", "A", 0))
+	if engine.PrevoteFor() != "" {
+		t.Fatalf("a polka older than the lock must not unlock, got %q", engine.PrevoteFor())
+	}
+	engine.AdvanceRound()
+	engine.ProcessMessage(proposal(3, "v4", "B", 1))
+	if engine.PrevoteFor() != "B" {
+		t.Fatalf("expected a prevote for the locked block, got %q", engine.PrevoteFor())
+	}
+
+	engine.AdvanceHeight(6)
+	if state := engine.GetState(); state.LockedRound != -1 || state.LockedBlock != "" || state.ValidRound != -1 || state.ProposalBlock != "" {
+		t.Fatalf("a new height must start unlocked, got %+v", state)
+	}
+}
+
+func TestConsensusEngineTimeoutsAdvanceRounds(t *testing.T) {
+	engine := newTestEngine()
+	now := time.Unix(1700000000, 0)
+	engine.SetClock(func() time.Time { return now })
+	if engine.Tick() != 0 {
+		t.Fatalf("timeouts must be disabled by default")
+	}
+	engine.SetTimeouts(DefaultTimeoutConfig())
+
+	// Round 0: no proposal within 3s, then neither prevote nor precommit quorum within 1s each
+	now = now.Add(2 * time.Second)
+	if engine.Tick() != 0 {
+		t.Fatalf("no timeout may fire before the propose timeout")
+	}
+	now = now.Add(time.Second)
+	if fired := engine.Tick(); fired != 1 || engine.GetState().Step != 2 {
+		t.Fatalf("expected the propose timeout to move to prevote, fired %d step %d", fired, engine.GetState().Step)
+	}
+	now = now.Add(2 * time.Second)
+	if fired := engine.Tick(); fired != 2 || engine.GetCurrentRound() != 1 || engine.GetState().Step != 0 {
+		t.Fatalf("expected prevote and precommit timeouts to end round 0, fired %d state %+v", fired, engine.GetState())
+	}
+
+	// Round 1 waits 500ms longer to propose; a delayed prevote for round 0 is now stale
+	now = now.Add(3 * time.Second)
+	if engine.Tick() != 0 {
+		t.Fatalf("round 1's propose timeout must include the delta")
+	}
+	if err := engine.ProcessMessage(engineVote(abstraction.MsgTypePrevote, 5, 0, "v1", "A")); err == nil {
+		t.Fatalf("expected a prevote delayed past its round to be rejected")
+	}
+	now = now.Add(500 * time.Millisecond)
+	if engine.Tick() != 1 || engine.GetState().Step != 2 {
+		t.Fatalf("expected round 1's propose timeout to fire, got %+v", engine.GetState())
+	}
+
+	// Messages that reach quorum in time keep the engine in the round
+	for _, v := range []string{"v1", "v2", "v3"} {
+		engine.ProcessMessage(engineVote(abstraction.MsgTypePrecommit, 5, 1, v, "A"))
+	}
+	if !engine.IsConsensusReached() || engine.GetCurrentHeight() != 6 || engine.GetCurrentRound() != 0 {
+		t.Fatalf("expected a commit in round 1, got %+v", engine.GetState())
+	}
+}
+
+func TestConsensusEngineEvidenceSlashesOnce(t *testing.T) {
+	engine := newTestEngine()
+	engine.SetSlashFraction(0.5)
+	engine.ProcessMessage(engineVote(abstraction.MsgTypePrecommit, 5, 0, "v2", "A"))
+	engine.ProcessMessage(engineVote(abstraction.MsgTypePrecommit, 5, 0, "v2", "B"))
+	engine.ProcessMessage(engineVote(abstraction.MsgTypePrecommit, 5, 0, "v2", ""))
+	// The same vote twice is a retransmission, not evidence
+	engine.ProcessMessage(engineVote(abstraction.MsgTypePrecommit, 5, 0, "v3", "A"))
+	engine.ProcessMessage(engineVote(abstraction.MsgTypePrecommit, 5, 0, "v3", "A"))
+
+	evidence := engine.GetEvidence()
+	if len(evidence) != 2 {
+		t.Fatalf("expected two pieces of evidence against v2, got %+v", evidence)
+	}
+	first := evidence[0]
+	if first.Validator != "v2" || first.Height != 5 || first.Round != 0 || first.First.BlockHash != "A" || first.Power != 10 || first.Slashed != 5 {
+		t.Fatalf("unexpected evidence %+v", first)
+	}
+	if evidence[1].Slashed != 0 || evidence[1].Power != 5 {
+		t.Fatalf("a tombstoned validator must not be slashed again, got %+v", evidence[1])
+	}
+	if engine.GetValidatorPower("v2") != 5 || engine.GetTotalPower() != 35 {
+		t.Fatalf("expected v2 at 5 of 35 power, got %d of %d", engine.GetValidatorPower("v2"), engine.GetTotalPower())
+	}
+}
+
+// stubSource serves one validator set from height 6 on, proposed by v2 in every round
+type stubSource struct{}
+
+func (stubSource) ValidatorsAt(height int64) ([]Validator, bool) {
+	if height < 6 {
+		return nil, false
+	}
+	return []Validator{{Address: "v1", VotingPower: 70}, {Address: "v2", VotingPower: 10}, {Address: "v3", VotingPower: 20}}, true
+}
+
+func (stubSource) ProposerAt(height int64, round int32) (string, bool) {
+	return "v2", height >= 6
+}
+
+func TestConsensusEngineLoadsValidatorSource(t *testing.T) {
+	engine := newTestEngine()
+	engine.SetValidatorSource(stubSource{})
+	if engine.GetTotalPower() != 40 {
+		t.Fatalf("heights the source does not know must keep the configured set, total %d", engine.GetTotalPower())
+	}
+
+	engine.AdvanceHeight(6)
+	if engine.GetTotalPower() != 100 || engine.GetValidatorPower("v4") != 0 || engine.GetState().Validators.Proposer.Address != "v2" {
+		t.Fatalf("expected the source's set and proposer at height 6, got %+v", engine.GetState().Validators)
+	}
+	engine.ProcessMessage(engineVote(abstraction.MsgTypePrecommit, 6, 0, "v1", "A"))
+	if !engine.IsConsensusReached() || engine.GetState().LastCommitHeight != 6 {
+		t.Fatalf("expected v1's 70 of 100 to commit, got %+v", engine.GetState())
+	}
+}
+
+func TestConsensusEngineLogsLeveledRecords(t *testing.T) {
+	var buf bytes.Buffer
+	engine := newTestEngine()
+	engine.SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	for _, v := range []string{"v1", "v2", "v3"} {
+		engine.ProcessMessage(engineVote(abstraction.MsgTypePrevote, 5, 0, v, "A"))
+	}
+	engine.ProcessMessage(engineVote(abstraction.MsgTypePrevote, 5, 0, "v1", "B"))
+	for _, v := range []string{"v1", "v2", "v3"} {
+		engine.ProcessMessage(engineVote(abstraction.MsgTypePrecommit, 5, 0, v, "A"))
+	}
+
+	var records []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var record map[string]any
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("expected JSON records, got %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	want := []struct{ level, msg string }{{"INFO", "polka"}, {"WARN", "evidence"}, {"INFO", "commit"}}
+	if len(records) != len(want) {
+		t.Fatalf("expected only the polka, evidence and commit above debug level, got %v", records)
+	}
+	for i, w := range want {
+		if records[i]["level"] != w.level || records[i]["msg"] != w.msg || records[i]["height"] != float64(5) {
+			t.Errorf("record %d: expected %s %q at height 5, got %v", i, w.level, w.msg, records[i])
+		}
+	}
+	if records[1]["validator"] != "v1" || records[1]["type"] != string(abstraction.MsgTypePrevote) {
+		t.Errorf("expected the evidence to name v1's prevote, got %v", records[1])
+	}
+}
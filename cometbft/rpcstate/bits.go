@@ -0,0 +1,124 @@
+package rpcstate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// BitArray is a CometBFT bit array, one entry per validator index.
+type BitArray []bool
+
+// ParseBitArray reads a bit array in any of the forms the RPC prints: the JSON form "x_x", the String form
+// "BA{3:x_x}", possibly followed by a vote tally, and "nil-BitArray" or "" for no array.
+func ParseBitArray(s string) (BitArray, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "nil-BitArray" {
+		return nil, nil
+	}
+	bits := s
+	size := -1
+	if strings.HasPrefix(s, "BA{") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid bit array %q", s)
+		}
+		n, body, ok := strings.Cut(s[len("BA{"):end], ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid bit array %q", s)
+		}
+		var err error
+		if size, err = strconv.Atoi(n); err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid bit array size in %q", s)
+		}
+		bits = body
+	}
+
+	var out BitArray
+	for _, c := range bits {
+		switch c {
+		case 'x':
+			out = append(out, true)
+		case '_':
+			out = append(out, false)
+		case ' ', '\t', '\n':
+			// String inserts its indent every 10 bits.
+		default:
+			return nil, fmt.Errorf("invalid bit %q in bit array %q", c, s)
+		}
+	}
+	if size >= 0 && len(out) != size {
+		return nil, fmt.Errorf("bit array %q has %d bits, want %d", s, len(out), size)
+	}
+	return out, nil
+}
+
+// Get reports whether bit i is set; bits past the end are unset.
+func (b BitArray) Get(i int) bool {
+	return i >= 0 && i < len(b) && b[i]
+}
+
+// Count returns the number of set bits.
+func (b BitArray) Count() int {
+	n := 0
+	for _, set := range b {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// String returns the JSON form, such as "x_x".
+func (b BitArray) String() string {
+	var sb strings.Builder
+	for _, set := range b {
+		if set {
+			sb.WriteByte('x')
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// VoteTally is a vote set's bit array with the voting power that has voted, as in "BA{4:xx__} 2/4 = 0.50".
+type VoteTally struct {
+	Bits  BitArray
+	Voted int64
+	Total int64
+}
+
+var tallyPattern = regexp.MustCompile(`^(.*\})\s+(\d+)/(\d+)\s*=\s*[0-9.]+$`)
+
+// ParseVoteTally reads a vote set bit array. A bare bit array is accepted and leaves the power at zero.
+func ParseVoteTally(s string) (VoteTally, error) {
+	s = strings.TrimSpace(s)
+	var tally VoteTally
+	bits := s
+	if m := tallyPattern.FindStringSubmatch(s); m != nil {
+		bits = m[1]
+		tally.Voted, _ = strconv.ParseInt(m[2], 10, 64)
+		tally.Total, _ = strconv.ParseInt(m[3], 10, 64)
+	}
+	var err error
+	if tally.Bits, err = ParseBitArray(bits); err != nil {
+		return VoteTally{}, err
+	}
+	return tally, nil
+}
+
+// HasTwoThirds reports whether more than two thirds of the voting power has voted.
+func (t VoteTally) HasTwoThirds() bool {
+	return t.Total > 0 && t.Voted*3 > t.Total*2
+}
+
+// PrevoteBits returns the prevotes the peer has for its round.
+func (rs *PeerRoundState) PrevoteBits() (BitArray, error) { return ParseBitArray(rs.Prevotes) }
+
+// PrecommitBits returns the precommits the peer has for its round.
+func (rs *PeerRoundState) PrecommitBits() (BitArray, error) { return ParseBitArray(rs.Precommits) }
+
+// LastCommitBits returns the precommits of the peer's last commit.
+func (rs *PeerRoundState) LastCommitBits() (BitArray, error) { return ParseBitArray(rs.LastCommit) }
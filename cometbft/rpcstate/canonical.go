@@ -0,0 +1,169 @@
+package rpcstate
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"codec/cometbft/adapter"
+	"codec/message/abstraction"
+)
+
+// VotePresence is one validator's entry in a vote set.
+type VotePresence struct {
+	Index   int32
+	Address string // lower-case hex
+	Power   int64
+	// Voted is set when the bit array or the vote strings show a vote from the validator.
+	Voted bool
+	// Vote is the parsed vote string, nil when the validator has not voted.
+	Vote *adapter.VoteSummary
+}
+
+// Prevotes returns the prevote presence of every current validator in round.
+func (rs *RoundState) Prevotes(round int32) ([]VotePresence, error) {
+	votes, ok := rs.RoundVotes(round)
+	if !ok {
+		return nil, fmt.Errorf("no votes for round %d at height %d", round, rs.Height)
+	}
+	return presence(&rs.Validators, votes.Prevotes, votes.PrevotesBitArray)
+}
+
+// Precommits returns the precommit presence of every current validator in round.
+func (rs *RoundState) Precommits(round int32) ([]VotePresence, error) {
+	votes, ok := rs.RoundVotes(round)
+	if !ok {
+		return nil, fmt.Errorf("no votes for round %d at height %d", round, rs.Height)
+	}
+	return presence(&rs.Validators, votes.Precommits, votes.PrecommitsBitArray)
+}
+
+// LastCommitVotes returns the presence of every last validator in the commit of the previous height.
+func (rs *RoundState) LastCommitVotes() ([]VotePresence, error) {
+	return presence(&rs.LastValidators, rs.LastCommit.Votes, rs.LastCommit.VotesBitArray)
+}
+
+func presence(vals *ValidatorSet, votes []string, bitArray string) ([]VotePresence, error) {
+	tally, err := ParseVoteTally(bitArray)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]VotePresence, len(vals.Validators))
+	for i, v := range vals.Validators {
+		out[i] = VotePresence{Index: int32(i), Address: strings.ToLower(v.Address), Power: v.VotingPower, Voted: tally.Bits.Get(i)}
+		if i >= len(votes) {
+			continue
+		}
+		summary, err := adapter.ParseVoteSummary(votes[i])
+		if err != nil {
+			return nil, err
+		}
+		if summary.Absent {
+			continue
+		}
+		if summary.ValidatorIndex != int32(i) || !strings.HasPrefix(out[i].Address, summary.ValidatorAddress) {
+			return nil, fmt.Errorf("vote %q is not from validator %d (%s)", votes[i], i, v.Address)
+		}
+		out[i].Vote, out[i].Voted = summary, true
+	}
+	return out, nil
+}
+
+// CanonicalMessages converts the round state into canonical messages: the accepted proposal, the prevotes and
+// precommits of every round at the current height, and the precommits of the last commit. Votes name their
+// validator by full address, taken from the validator set, but vote strings only carry fingerprints of block
+// hashes, so a vote's BlockHash is the first six bytes of the hash in lower-case hex and its signature is left
+// empty. The proposal carries its full hash and signature.
+func (rs *RoundState) CanonicalMessages(chainID string) ([]*abstraction.CanonicalMessage, error) {
+	mapper := adapter.NewCometBFTMapper(chainID)
+	toCanonical := func(msg adapter.CometBFTConsensusMessage) (*abstraction.CanonicalMessage, error) {
+		payload, err := json.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+		return mapper.ToCanonical(abstraction.RawConsensusMessage{
+			ChainType:   abstraction.ChainTypeCometBFT,
+			ChainID:     chainID,
+			MessageType: msg.MessageType,
+			Payload:     payload,
+			Encoding:    "json",
+			Timestamp:   msg.Timestamp,
+		})
+	}
+
+	var msgs []*abstraction.CanonicalMessage
+	if p := rs.Proposal; p != nil {
+		partsHash, err := hex.DecodeString(p.BlockID.Parts.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("proposal part set hash: %w", err)
+		}
+		proposal := adapter.CometBFTConsensusMessage{
+			MessageType: "Proposal",
+			Height:      strconv.FormatInt(p.Height, 10),
+			Round:       strconv.FormatInt(int64(p.Round), 10),
+			Timestamp:   p.Timestamp,
+			BlockID: adapter.BlockID{
+				Hash:          strings.ToLower(p.BlockID.Hash),
+				PartSetHeader: adapter.PartSetHeader{Total: p.BlockID.Parts.Total, Hash: partsHash},
+			},
+			POLRound: p.POLRound,
+		}
+		if len(p.Signature) > 0 {
+			proposal.Signature = base64.StdEncoding.EncodeToString(p.Signature)
+		}
+		if rs.Validators.Proposer != nil && p.Round == rs.Round {
+			proposal.ProposerAddress = strings.ToLower(rs.Validators.Proposer.Address)
+		}
+		msg, err := toCanonical(proposal)
+		if err != nil {
+			return nil, fmt.Errorf("proposal: %w", err)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	var sets [][]VotePresence
+	for _, round := range rs.Votes {
+		prevotes, err := rs.Prevotes(round.Round)
+		if err != nil {
+			return nil, err
+		}
+		precommits, err := rs.Precommits(round.Round)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, prevotes, precommits)
+	}
+	lastCommit, err := rs.LastCommitVotes()
+	if err != nil {
+		return nil, err
+	}
+	sets = append(sets, lastCommit)
+
+	for _, set := range sets {
+		for _, p := range set {
+			if p.Vote == nil {
+				continue
+			}
+			vote := adapter.CometBFTConsensusMessage{
+				MessageType:      "Vote",
+				Type:             p.Vote.Type,
+				VoteType:         string(p.Vote.MessageType()),
+				Height:           strconv.FormatInt(p.Vote.Height, 10),
+				Round:            strconv.FormatInt(int64(p.Vote.Round), 10),
+				Timestamp:        p.Vote.Timestamp,
+				BlockID:          adapter.BlockID{Hash: p.Vote.BlockHash},
+				ValidatorAddress: p.Address,
+				ValidatorIndex:   p.Index,
+			}
+			msg, err := toCanonical(vote)
+			if err != nil {
+				return nil, fmt.Errorf("vote of validator %d: %w", p.Index, err)
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
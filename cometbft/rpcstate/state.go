@@ -0,0 +1,227 @@
+// Package rpcstate decodes the consensus state a CometBFT node reports on /dump_consensus_state: its round state,
+// with the votes of every round at the current height and the last commit, and what it knows of each peer.
+// Vote sets come as bit arrays and vote strings; the package turns them into per-validator vote presence and
+// canonical messages, so tools can follow a live node without subscribing to its events.
+package rpcstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"codec/message/abstraction/quorum"
+)
+
+// DumpConsensusState is the result of /dump_consensus_state.
+type DumpConsensusState struct {
+	RoundState RoundState `json:"round_state"`
+	Peers      []Peer     `json:"peers"`
+}
+
+// Decode reads a /dump_consensus_state response, either the JSON-RPC envelope or its bare result. An error
+// response is returned as an error.
+func Decode(data []byte) (*DumpConsensusState, error) {
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+			Data    string `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode consensus state: %w", err)
+	}
+	if envelope.Error != nil {
+		return nil, fmt.Errorf("consensus state request failed: %s (code %d): %s", envelope.Error.Message, envelope.Error.Code, envelope.Error.Data)
+	}
+	if len(envelope.Result) > 0 {
+		data = envelope.Result
+	}
+	var state DumpConsensusState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode consensus state: %w", err)
+	}
+	return &state, nil
+}
+
+// RoundState is the node's own view of consensus. The blocks and part sets are kept as raw JSON.
+type RoundState struct {
+	Height                    int64           `json:"height,string"`
+	Round                     int32           `json:"round"`
+	Step                      uint8           `json:"step"`
+	StartTime                 time.Time       `json:"start_time"`
+	CommitTime                time.Time       `json:"commit_time"`
+	Validators                ValidatorSet    `json:"validators"`
+	Proposal                  *Proposal       `json:"proposal"`
+	ProposalBlock             json.RawMessage `json:"proposal_block"`
+	ProposalBlockParts        json.RawMessage `json:"proposal_block_parts"`
+	LockedRound               int32           `json:"locked_round"`
+	LockedBlock               json.RawMessage `json:"locked_block"`
+	LockedBlockParts          json.RawMessage `json:"locked_block_parts"`
+	ValidRound                int32           `json:"valid_round"`
+	ValidBlock                json.RawMessage `json:"valid_block"`
+	ValidBlockParts           json.RawMessage `json:"valid_block_parts"`
+	Votes                     []RoundVotes    `json:"votes"`
+	CommitRound               int32           `json:"commit_round"`
+	LastCommit                LastCommit      `json:"last_commit"`
+	LastValidators            ValidatorSet    `json:"last_validators"`
+	TriggeredTimeoutPrecommit bool            `json:"triggered_timeout_precommit"`
+}
+
+// stepNames are CometBFT's RoundStepType names, indexed by value.
+var stepNames = []string{"", "NewHeight", "NewRound", "Propose", "Prevote", "PrevoteWait", "Precommit", "PrecommitWait", "Commit"}
+
+// StepName returns the name of the round step, such as "Prevote".
+func (rs *RoundState) StepName() string {
+	if int(rs.Step) < len(stepNames) && rs.Step > 0 {
+		return stepNames[rs.Step]
+	}
+	return fmt.Sprintf("Unknown(%d)", rs.Step)
+}
+
+// HasProposalBlock reports whether the node has the full proposal block.
+func (rs *RoundState) HasProposalBlock() bool { return present(rs.ProposalBlock) }
+
+// HasLockedBlock reports whether the node is locked on a block.
+func (rs *RoundState) HasLockedBlock() bool { return present(rs.LockedBlock) }
+
+// HasValidBlock reports whether the node has seen a polka for a block it holds.
+func (rs *RoundState) HasValidBlock() bool { return present(rs.ValidBlock) }
+
+// RoundVotes returns the vote sets of round, and false when the node keeps none for it.
+func (rs *RoundState) RoundVotes(round int32) (RoundVotes, bool) {
+	for _, v := range rs.Votes {
+		if v.Round == round {
+			return v, true
+		}
+	}
+	return RoundVotes{}, false
+}
+
+func present(raw json.RawMessage) bool {
+	return len(raw) > 0 && string(raw) != "null"
+}
+
+// Proposal is the proposal the node accepted for its current round.
+type Proposal struct {
+	Type      int32     `json:"type"`
+	Height    int64     `json:"height,string"`
+	Round     int32     `json:"round"`
+	POLRound  int32     `json:"pol_round"`
+	BlockID   BlockID   `json:"block_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Signature []byte    `json:"signature"`
+}
+
+// BlockID identifies a block by its hash and part set header, both upper-case hex as the RPC prints them.
+type BlockID struct {
+	Hash  string        `json:"hash"`
+	Parts PartSetHeader `json:"parts"`
+}
+
+// PartSetHeader is the header of a block's part set.
+type PartSetHeader struct {
+	Total uint32 `json:"total"`
+	Hash  string `json:"hash"`
+}
+
+// RoundVotes holds the prevotes and precommits of one round: one vote string per validator, "nil-Vote" for
+// those not yet received, and the bit array with the voting power tally.
+type RoundVotes struct {
+	Round              int32    `json:"round"`
+	Prevotes           []string `json:"prevotes"`
+	PrevotesBitArray   string   `json:"prevotes_bit_array"`
+	Precommits         []string `json:"precommits"`
+	PrecommitsBitArray string   `json:"precommits_bit_array"`
+}
+
+// LastCommit holds the precommits that committed the previous height.
+type LastCommit struct {
+	Votes         []string        `json:"votes"`
+	VotesBitArray string          `json:"votes_bit_array"`
+	PeerMaj23s    json.RawMessage `json:"peer_maj_23s"`
+}
+
+// ValidatorSet is a validator set in validator index order, with the proposer of the node's current round.
+type ValidatorSet struct {
+	Validators []Validator `json:"validators"`
+	Proposer   *Validator  `json:"proposer"`
+}
+
+// Validator is one member of a validator set.
+type Validator struct {
+	Address          string `json:"address"`
+	PubKey           PubKey `json:"pub_key"`
+	VotingPower      int64  `json:"voting_power,string"`
+	ProposerPriority int64  `json:"proposer_priority,string"`
+}
+
+// PubKey is an amino-JSON public key, such as {"type": "tendermint/PubKeyEd25519", "value": "<base64>"}.
+type PubKey struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// TotalVotingPower returns the sum of the validators' voting power.
+func (vs *ValidatorSet) TotalVotingPower() int64 {
+	var total int64
+	for _, v := range vs.Validators {
+		total += v.VotingPower
+	}
+	return total
+}
+
+// VotingPowers returns the set keyed by lower-case hex address, the validator ids of canonical messages.
+func (vs *ValidatorSet) VotingPowers() quorum.ValidatorSet {
+	set := make(quorum.ValidatorSet, len(vs.Validators))
+	for _, v := range vs.Validators {
+		set[strings.ToLower(v.Address)] = v.VotingPower
+	}
+	return set
+}
+
+// Peer is the node's view of one peer.
+type Peer struct {
+	NodeAddress string    `json:"node_address"`
+	PeerState   PeerState `json:"peer_state"`
+}
+
+// ID returns the peer's node ID, the part of its address before '@'.
+func (p *Peer) ID() string {
+	id, _, _ := strings.Cut(p.NodeAddress, "@")
+	return id
+}
+
+// PeerState is what the node has learned about a peer's progress and sent it.
+type PeerState struct {
+	RoundState PeerRoundState `json:"round_state"`
+	Stats      PeerStats      `json:"stats"`
+}
+
+// PeerRoundState is a peer's round as the node tracks it. The vote fields are bit arrays of the votes the node
+// knows the peer has; ParseBitArray reads them.
+type PeerRoundState struct {
+	Height                     int64           `json:"height,string"`
+	Round                      int32           `json:"round"`
+	Step                       uint8           `json:"step"`
+	StartTime                  time.Time       `json:"start_time"`
+	Proposal                   bool            `json:"proposal"`
+	ProposalBlockPartSetHeader PartSetHeader   `json:"proposal_block_part_set_header"`
+	ProposalBlockParts         json.RawMessage `json:"proposal_block_parts"`
+	ProposalPOLRound           int32           `json:"proposal_pol_round"`
+	ProposalPOL                string          `json:"proposal_pol"`
+	Prevotes                   string          `json:"prevotes"`
+	Precommits                 string          `json:"precommits"`
+	LastCommitRound            int32           `json:"last_commit_round"`
+	LastCommit                 string          `json:"last_commit"`
+	CatchupCommitRound         int32           `json:"catchup_commit_round"`
+	CatchupCommit              string          `json:"catchup_commit"`
+}
+
+// PeerStats counts the useful votes and block parts received from a peer.
+type PeerStats struct {
+	Votes      int64 `json:"votes,string"`
+	BlockParts int64 `json:"block_parts,string"`
+}
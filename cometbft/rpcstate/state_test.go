@@ -0,0 +1,142 @@
+package rpcstate
+
+import (
+	"os"
+	"testing"
+
+	"codec/message/abstraction"
+)
+
+func loadDump(t *testing.T) *DumpConsensusState {
+	t.Helper()
+	data, err := os.ReadFile("testdata/dump_consensus_state.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	return state
+}
+
+func TestDecode(t *testing.T) {
+	state := loadDump(t)
+	rs := &state.RoundState
+	if rs.Height != 664 || rs.Round != 0 || rs.StepName() != "Precommit" || rs.StartTime.IsZero() {
+		t.Fatalf("unexpected round state header %+v", rs)
+	}
+	if len(rs.Validators.Validators) != 4 || rs.Validators.TotalVotingPower() != 40 || rs.Validators.Proposer == nil {
+		t.Fatalf("unexpected validators %+v", rs.Validators)
+	}
+	if powers := rs.Validators.VotingPowers(); powers["20ca1b3031f4fc0baf83ce694b2623cfe79fefae"] != 10 {
+		t.Fatalf("unexpected voting powers %v", powers)
+	}
+	if !rs.HasProposalBlock() || rs.HasLockedBlock() || !rs.HasValidBlock() {
+		t.Fatal("unexpected block presence")
+	}
+
+	peer := state.Peers[0]
+	if peer.ID() != "4b3ee219326b90ba70a808590c5d65342699a323" || peer.PeerState.Stats.Votes != 1205 {
+		t.Fatalf("unexpected peer %+v", peer)
+	}
+	prevotes, err := peer.PeerState.RoundState.PrevoteBits()
+	if err != nil || prevotes.String() != "xx_x" || prevotes.Count() != 3 {
+		t.Fatalf("peer prevotes = %v, %v", prevotes, err)
+	}
+
+	bare, err := Decode([]byte(`{"round_state": {"height": "7"}}`))
+	if err != nil || bare.RoundState.Height != 7 {
+		t.Fatalf("bare result: %+v, %v", bare, err)
+	}
+	if _, err := Decode([]byte(`{"jsonrpc": "2.0", "id": 1, "error": {"code": -32601, "message": "Method not found"}}`)); err == nil {
+		t.Fatal("expected an error response to fail")
+	}
+}
+
+func TestParseBitArray(t *testing.T) {
+	for in, want := range map[string]string{
+		"x_x":                   "x_x",
+		"BA{3:x_x}":             "x_x",
+		"BA{12:xxxxxxxxxx xx}":  "xxxxxxxxxxxx",
+		"BA{4:xx__} 2/4 = 0.50": "xx__",
+		"nil-BitArray":          "",
+		"":                      "",
+	} {
+		got, err := ParseBitArray(in)
+		if err != nil || got.String() != want {
+			t.Fatalf("ParseBitArray(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"BA{3:x_}", "BA{3:x_x", "x-x", "BA{three:x_x}"} {
+		if _, err := ParseBitArray(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+
+	tally, err := ParseVoteTally("BA{4:xxx_} 30/40 = 0.75")
+	if err != nil || tally.Voted != 30 || tally.Total != 40 || !tally.HasTwoThirds() || tally.Bits.Count() != 3 {
+		t.Fatalf("unexpected tally %+v, %v", tally, err)
+	}
+}
+
+func TestPresence(t *testing.T) {
+	rs := &loadDump(t).RoundState
+	precommits, err := rs.Precommits(0)
+	if err != nil {
+		t.Fatalf("Precommits: %v", err)
+	}
+	voted := []bool{true, false, true, false}
+	for i, p := range precommits {
+		if p.Voted != voted[i] || (p.Vote != nil) != voted[i] || p.Power != 10 {
+			t.Fatalf("precommit %d: unexpected presence %+v", i, p)
+		}
+	}
+	if !precommits[2].Vote.IsNil() || precommits[0].Vote.IsNil() {
+		t.Fatal("validator 2 precommitted nil, validator 0 the block")
+	}
+
+	last, err := rs.LastCommitVotes()
+	if err != nil || last[3].Voted || last[0].Vote.Height != 663 {
+		t.Fatalf("unexpected last commit %+v, %v", last, err)
+	}
+	if _, err := rs.Prevotes(5); err == nil {
+		t.Fatal("expected an error for a round without votes")
+	}
+
+	rs.Votes[0].Prevotes[1] = rs.Votes[0].Prevotes[0]
+	if _, err := rs.Prevotes(0); err == nil {
+		t.Fatal("expected a vote in the wrong slot to be rejected")
+	}
+}
+
+func TestCanonicalMessages(t *testing.T) {
+	rs := &loadDump(t).RoundState
+	msgs, err := rs.CanonicalMessages("rpcstate-chain")
+	if err != nil {
+		t.Fatalf("CanonicalMessages: %v", err)
+	}
+	// A proposal, 3 prevotes and 2 precommits in round 0, and 3 last commit precommits.
+	if len(msgs) != 9 {
+		t.Fatalf("got %d messages, want 9", len(msgs))
+	}
+	proposal := msgs[0]
+	if proposal.Type != abstraction.MsgTypeProposal || proposal.Height.Int64() != 664 || len(proposal.BlockHash) != 64 ||
+		proposal.Proposer != "97581fd6c96b392cb3929752142546d2dbca6f6b" || proposal.Signature == "" {
+		t.Fatalf("unexpected proposal %+v", proposal)
+	}
+
+	counts := map[abstraction.MsgType]int{}
+	for _, msg := range msgs[1:] {
+		counts[msg.Type]++
+		if len(msg.Validator) != 40 || msg.ChainID != "rpcstate-chain" {
+			t.Fatalf("unexpected vote %+v", msg)
+		}
+	}
+	if counts[abstraction.MsgTypePrevote] != 3 || counts[abstraction.MsgTypePrecommit] != 5 {
+		t.Fatalf("unexpected vote counts %v", counts)
+	}
+	if msgs[4].BlockHash != "ec3cb9ab09fa" || msgs[5].BlockHash != "" || msgs[6].Height.Int64() != 663 {
+		t.Fatalf("unexpected precommits %+v %+v %+v", msgs[4], msgs[5], msgs[6])
+	}
+}
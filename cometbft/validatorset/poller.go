@@ -0,0 +1,184 @@
+package validatorset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"codec/cometbft/rpcstate"
+	"codec/message/abstraction/quorum"
+)
+
+// perPage is the largest page /validators serves.
+const perPage = 100
+
+// maxBackfill bounds how many skipped heights one poll fetches, so a poller that fell far behind catches up
+// with the latest set instead of replaying the whole gap.
+const maxBackfill = 100
+
+// Poller fetches validator sets from a node's RPC endpoint into a Tracker.
+type Poller struct {
+	// URL is the node's RPC address, e.g. http://127.0.0.1:26657.
+	URL string
+	// Client sends the requests; nil uses a client with a ten second timeout.
+	Client *http.Client
+	// Interval is the time between polls of the latest set; zero polls every second, about once per block.
+	Interval time.Duration
+	// Logger receives failed polls and fetches; nil uses slog.Default.
+	Logger *slog.Logger
+
+	tracker *Tracker
+	last    int64
+}
+
+// NewPoller returns a poller that records into tracker.
+func NewPoller(rpcURL string, tracker *Tracker) *Poller {
+	return &Poller{URL: strings.TrimSuffix(rpcURL, "/"), tracker: tracker}
+}
+
+// Fetch reads the validator set at height, or at the latest height when height is zero, records it, and
+// returns it.
+func (p *Poller) Fetch(ctx context.Context, height int64) (*Set, error) {
+	var (
+		validators []Validator
+		at         int64
+	)
+	for page := 1; ; page++ {
+		query := url.Values{"page": {strconv.Itoa(page)}, "per_page": {strconv.Itoa(perPage)}}
+		if height > 0 {
+			query.Set("height", strconv.FormatInt(height, 10))
+		}
+		var result struct {
+			BlockHeight int64                `json:"block_height,string"`
+			Validators  []rpcstate.Validator `json:"validators"`
+			Total       int                  `json:"total,string"`
+		}
+		if err := p.call(ctx, "validators", query, &result); err != nil {
+			return nil, err
+		}
+		// Pin the later pages to the height of the first, which a new block could otherwise move.
+		at, height = result.BlockHeight, result.BlockHeight
+		for _, v := range result.Validators {
+			validators = append(validators, Validator{
+				Address:          v.Address,
+				PubKeyType:       v.PubKey.Type,
+				PubKey:           v.PubKey.Value,
+				VotingPower:      v.VotingPower,
+				ProposerPriority: v.ProposerPriority,
+			})
+		}
+		if len(result.Validators) == 0 || len(validators) >= result.Total {
+			break
+		}
+	}
+	p.tracker.Record(at, validators)
+	set, _ := p.tracker.At(at)
+	return set, nil
+}
+
+// Run polls the latest validator set until ctx ends. Heights skipped since the previous poll are fetched too,
+// up to a hundred, so every height has the priorities its proposers are derived from. Failed polls are logged and retried at the
+// next interval.
+func (p *Poller) Run(ctx context.Context) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := p.poll(ctx); err != nil && ctx.Err() == nil {
+			p.logger().Warn("failed to poll validator set", "url", p.URL, "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Poller) logger() *slog.Logger {
+	if p.Logger == nil {
+		return slog.Default()
+	}
+	return p.Logger
+}
+
+func (p *Poller) poll(ctx context.Context) error {
+	set, err := p.Fetch(ctx, 0)
+	if err != nil {
+		return err
+	}
+	// The first poll also fetches the height before, which the proposer of round 0 is derived from.
+	from := set.Height - 1
+	if p.last > 0 {
+		from = max(p.last+1, set.Height-maxBackfill)
+	}
+	for h := max(from, 1); h < set.Height; h++ {
+		if _, err := p.Fetch(ctx, h); err != nil {
+			return err
+		}
+	}
+	p.last = max(p.last, set.Height)
+	return nil
+}
+
+func (p *Poller) call(ctx context.Context, method string, query url.Values, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL+"/"+method+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var frame struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+			Data    string `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&frame); err != nil {
+		return fmt.Errorf("%s: %s: %v", method, resp.Status, err)
+	}
+	if frame.Error != nil {
+		return fmt.Errorf("%s: %s (code %d): %s", method, frame.Error.Message, frame.Error.Code, frame.Error.Data)
+	}
+	if err := json.Unmarshal(frame.Result, result); err != nil {
+		return fmt.Errorf("%s: %v", method, err)
+	}
+	return nil
+}
+
+// Source returns a quorum.SetSource that fetches each height's set from the node the first time it is asked
+// for, for replaying captures whose heights the poller never saw. A height that cannot be fetched falls back
+// to the nearest set below it.
+func (p *Poller) Source(ctx context.Context) quorum.SetSource {
+	return func(height *big.Int) quorum.ValidatorSet {
+		if height == nil || !height.IsInt64() {
+			return nil
+		}
+		h := height.Int64()
+		if set, ok := p.tracker.At(h); !ok || set.Height != h || !set.Priorities {
+			if _, err := p.Fetch(ctx, h); err != nil {
+				p.logger().Warn("failed to fetch validator set", "url", p.URL, "height", h, "err", err)
+			}
+		}
+		return p.tracker.VotingPowers(height)
+	}
+}
@@ -0,0 +1,275 @@
+// Package validatorset follows the validator set of a CometBFT chain height by height. A Tracker is fed full
+// sets polled from a node's /validators endpoint, and the ValidatorSetUpdates events the ingress collector
+// receives, and answers what the set, the voting power, and the proposer were at any height it has seen. It
+// plugs into quorum trackers, the evidence detector, and the consensus engine, so they weigh votes by the
+// chain's real voting power.
+package validatorset
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	cmttypes "github.com/cometbft/cometbft/types"
+
+	"codec/cometbft"
+	"codec/message/abstraction/quorum"
+	"codec/message/ingress"
+)
+
+// ErrNoPriorities is returned for proposer queries at a height whose set came from updates rather than from
+// /validators, since update events do not carry the proposer priorities the proposer is chosen by. Round 0
+// also needs the priorities of the height before.
+var ErrNoPriorities = errors.New("proposer priorities unknown at this height")
+
+// Validator is one member of a validator set. Address is lower-case hex, the validator id canonical messages
+// carry.
+type Validator struct {
+	Address          string
+	PubKeyType       string
+	PubKey           string
+	VotingPower      int64
+	ProposerPriority int64
+}
+
+// Set is the validator set in force from Height until the next recorded set.
+type Set struct {
+	Height     int64
+	Validators []Validator
+	// Priorities is set when the proposer priorities are the node's own for Height, as /validators reports
+	// them, so the proposers of Height can be computed.
+	Priorities bool
+}
+
+// TotalPower returns the voting power of the whole set.
+func (s *Set) TotalPower() int64 {
+	var total int64
+	for _, v := range s.Validators {
+		total += v.VotingPower
+	}
+	return total
+}
+
+// VotingPowers returns the set as a quorum.ValidatorSet.
+func (s *Set) VotingPowers() quorum.ValidatorSet {
+	set := make(quorum.ValidatorSet, len(s.Validators))
+	for _, v := range s.Validators {
+		set[v.Address] = v.VotingPower
+	}
+	return set
+}
+
+// Proposer returns the address of the proposer of round at the set's height, for round 1 and later. It runs
+// CometBFT's proposer selection: every round raises each priority by the validator's voting power, and the
+// validator with the highest priority proposes and has its own lowered by the total. The priorities
+// /validators reports are those left after round 0's proposer was chosen, so round 0 cannot be recovered
+// from them; Tracker.Proposer derives it from the height before.
+func (s *Set) Proposer(round int32) (string, error) {
+	if !s.Priorities {
+		return "", ErrNoPriorities
+	}
+	if round < 1 {
+		return "", errors.New("round 0 proposer needs the previous height's priorities")
+	}
+	if len(s.Validators) == 0 {
+		return "", errors.New("empty validator set")
+	}
+	vals := &cmttypes.ValidatorSet{}
+	ids := make(map[string]string, len(s.Validators))
+	for _, v := range s.Validators {
+		address := addressBytes(v.Address)
+		ids[string(address)] = v.Address
+		vals.Validators = append(vals.Validators, &cmttypes.Validator{
+			Address:          address,
+			VotingPower:      v.VotingPower,
+			ProposerPriority: v.ProposerPriority,
+		})
+	}
+	for r := int32(0); r < round; r++ {
+		vals.IncrementProposerPriority(1)
+	}
+	return ids[string(vals.Proposer.Address)], nil
+}
+
+// Tracker holds the validator sets recorded for a chain. It is safe for concurrent use.
+type Tracker struct {
+	mu   sync.RWMutex
+	sets []*Set // ascending by height
+}
+
+// NewTracker returns an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Record stores the full validator set at height, with the node's proposer priorities, replacing any set
+// recorded for that height.
+func (t *Tracker) Record(height int64, validators []Validator) {
+	set := &Set{Height: height, Validators: normalize(validators), Priorities: true}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.insert(set, true)
+}
+
+// OnValidatorUpdates applies a ValidatorSetUpdates event, with the signature ingress.CometBFTConfig expects.
+// height is the height whose block produced the updates; as in CometBFT, they take effect two heights later.
+// A voting power of zero removes the validator. Updates before any recorded set are dropped, since there is no
+// set to apply them to, and a set already polled for the effective height is kept.
+func (t *Tracker) OnValidatorUpdates(height int64, updates []ingress.ValidatorUpdate) {
+	effective := height + 2
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	base := t.at(effective)
+	if base == nil {
+		return
+	}
+	members := make(map[string]Validator, len(base.Validators))
+	for _, v := range base.Validators {
+		members[v.Address] = v
+	}
+	for _, u := range updates {
+		address := strings.ToLower(u.Address)
+		if u.VotingPower == 0 {
+			delete(members, address)
+			continue
+		}
+		members[address] = Validator{Address: address, PubKeyType: u.PubKeyType, PubKey: u.PubKey, VotingPower: u.VotingPower, ProposerPriority: u.ProposerPriority}
+	}
+	validators := make([]Validator, 0, len(members))
+	for _, v := range members {
+		validators = append(validators, v)
+	}
+	t.insert(&Set{Height: effective, Validators: normalize(validators)}, false)
+}
+
+// At returns the set in force at height: the latest one recorded at or below it.
+func (t *Tracker) At(height int64) (*Set, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	set := t.at(height)
+	return set, set != nil
+}
+
+// VotingPowers returns the voting power in force at height, or nil when no set covers it. It is a
+// quorum.SetSource, for quorum.NewTrackerFrom and evidence.NewDetectorFrom.
+func (t *Tracker) VotingPowers(height *big.Int) quorum.ValidatorSet {
+	if height == nil || !height.IsInt64() {
+		return nil
+	}
+	set, ok := t.At(height.Int64())
+	if !ok {
+		return nil
+	}
+	return set.VotingPowers()
+}
+
+// Proposer returns the proposer of a round. It needs the set polled at exactly that height; round 0 also
+// needs the set polled at the height before, with the same voting powers, whose next proposer it is.
+func (t *Tracker) Proposer(height int64, round int32) (string, error) {
+	t.mu.RLock()
+	set, prev := t.at(height), t.at(height-1)
+	t.mu.RUnlock()
+	if set == nil || set.Height != height {
+		return "", ErrNoPriorities
+	}
+	if round > 0 {
+		return set.Proposer(round)
+	}
+	if prev == nil || prev.Height != height-1 || !samePowers(prev, set) {
+		return "", ErrNoPriorities
+	}
+	return prev.Proposer(1)
+}
+
+// Prune forgets the sets replaced before height; the one in force at height is kept.
+func (t *Tracker) Prune(height int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	i := sort.Search(len(t.sets), func(i int) bool { return t.sets[i].Height > height })
+	if i > 1 {
+		t.sets = append([]*Set(nil), t.sets[i-1:]...)
+	}
+}
+
+// ValidatorsAt implements cometbft.ValidatorSource.
+func (t *Tracker) ValidatorsAt(height int64) ([]cometbft.Validator, bool) {
+	set, ok := t.At(height)
+	if !ok {
+		return nil, false
+	}
+	validators := make([]cometbft.Validator, len(set.Validators))
+	for i, v := range set.Validators {
+		validators[i] = cometbft.Validator{Address: v.Address, PubKey: v.PubKey, VotingPower: v.VotingPower, ProposerPriority: v.ProposerPriority}
+	}
+	return validators, true
+}
+
+// ProposerAt implements cometbft.ValidatorSource.
+func (t *Tracker) ProposerAt(height int64, round int32) (string, bool) {
+	proposer, err := t.Proposer(height, round)
+	return proposer, err == nil
+}
+
+func (t *Tracker) at(height int64) *Set {
+	i := sort.Search(len(t.sets), func(i int) bool { return t.sets[i].Height > height })
+	if i == 0 {
+		return nil
+	}
+	return t.sets[i-1]
+}
+
+// insert adds set in height order. An existing set at the same height is replaced only when overwrite is set.
+func (t *Tracker) insert(set *Set, overwrite bool) {
+	i := sort.Search(len(t.sets), func(i int) bool { return t.sets[i].Height >= set.Height })
+	if i < len(t.sets) && t.sets[i].Height == set.Height {
+		if overwrite {
+			t.sets[i] = set
+		}
+		return
+	}
+	t.sets = append(t.sets, nil)
+	copy(t.sets[i+1:], t.sets[i:])
+	t.sets[i] = set
+}
+
+// samePowers reports whether two sets have the same members with the same voting power.
+func samePowers(a, b *Set) bool {
+	if len(a.Validators) != len(b.Validators) {
+		return false
+	}
+	for i, v := range a.Validators {
+		if v.Address != b.Validators[i].Address || v.VotingPower != b.Validators[i].VotingPower {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize copies validators with lower-case addresses, in CometBFT's set order: by voting power, highest
+// first, then by address.
+func normalize(validators []Validator) []Validator {
+	out := make([]Validator, len(validators))
+	for i, v := range validators {
+		v.Address = strings.ToLower(v.Address)
+		out[i] = v
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].VotingPower != out[j].VotingPower {
+			return out[i].VotingPower > out[j].VotingPower
+		}
+		return bytes.Compare(addressBytes(out[i].Address), addressBytes(out[j].Address)) < 0
+	})
+	return out
+}
+
+// addressBytes decodes a hex address; ids that are not hex, as in simulations, are compared as text.
+func addressBytes(address string) []byte {
+	if b, err := hex.DecodeString(address); err == nil {
+		return b
+	}
+	return []byte(address)
+}
@@ -0,0 +1,180 @@
+package validatorset
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	cmttypes "github.com/cometbft/cometbft/types"
+
+	"codec/message/ingress"
+)
+
+func TestProposerMatchesCometBFT(t *testing.T) {
+	var vals []*cmttypes.Validator
+	for _, power := range []int64{10, 20, 30, 40} {
+		pub, err := cmttypes.NewMockPV().GetPubKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		vals = append(vals, cmttypes.NewValidator(pub, power))
+	}
+	prev := cmttypes.NewValidatorSet(vals)
+	current := prev.CopyIncrementProposerPriority(1)
+
+	tracker := NewTracker()
+	tracker.Record(4, fromCometBFT(prev))
+	tracker.Record(5, fromCometBFT(current))
+	for round := int32(0); round < 8; round++ {
+		got, err := tracker.Proposer(5, round)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := current.Proposer
+		if round > 0 {
+			want = current.CopyIncrementProposerPriority(round).Proposer
+		}
+		if expected := hex.EncodeToString(want.Address); got != expected {
+			t.Fatalf("round %d: proposer %s, want %s", round, got, expected)
+		}
+	}
+	if _, err := tracker.Proposer(4, 0); err != ErrNoPriorities {
+		t.Fatalf("expected ErrNoPriorities without the height before, got %v", err)
+	}
+}
+
+func fromCometBFT(vals *cmttypes.ValidatorSet) []Validator {
+	var validators []Validator
+	for _, v := range vals.Validators {
+		validators = append(validators, Validator{
+			Address:          hex.EncodeToString(v.Address),
+			VotingPower:      v.VotingPower,
+			ProposerPriority: v.ProposerPriority,
+		})
+	}
+	return validators
+}
+
+func TestTrackerHistory(t *testing.T) {
+	tracker := NewTracker()
+	tracker.Record(10, []Validator{{Address: "AA", VotingPower: 10}, {Address: "BB", VotingPower: 20}})
+	tracker.Record(20, []Validator{{Address: "aa", VotingPower: 10}})
+
+	if _, ok := tracker.At(9); ok {
+		t.Fatal("expected no set below the first recorded height")
+	}
+	set, ok := tracker.At(15)
+	if !ok || set.Height != 10 || set.TotalPower() != 30 || set.Validators[0].Address != "bb" {
+		t.Fatalf("unexpected set at 15: %+v", set)
+	}
+	if powers := tracker.VotingPowers(big.NewInt(25)); len(powers) != 1 || powers["aa"] != 10 {
+		t.Fatalf("unexpected powers at 25: %v", powers)
+	}
+	if _, err := tracker.Proposer(15, 0); err != ErrNoPriorities {
+		t.Fatalf("expected ErrNoPriorities between recorded heights, got %v", err)
+	}
+	if proposer, ok := tracker.ProposerAt(20, 3); !ok || proposer != "aa" {
+		t.Fatalf("unexpected proposer %q", proposer)
+	}
+
+	tracker.Prune(25)
+	if _, ok := tracker.At(15); ok {
+		t.Fatal("expected the set at 10 to be pruned")
+	}
+	if _, ok := tracker.At(25); !ok {
+		t.Fatal("expected the set in force at 25 to be kept")
+	}
+}
+
+func TestTrackerAppliesUpdatesTwoHeightsLater(t *testing.T) {
+	tracker := NewTracker()
+	tracker.OnValidatorUpdates(1, []ingress.ValidatorUpdate{{Address: "cc", VotingPower: 5}})
+	if _, ok := tracker.At(10); ok {
+		t.Fatal("expected updates without a base set to be dropped")
+	}
+
+	tracker.Record(10, []Validator{{Address: "aa", VotingPower: 10}, {Address: "bb", VotingPower: 20}})
+	tracker.OnValidatorUpdates(11, []ingress.ValidatorUpdate{
+		{Address: "BB", VotingPower: 0},
+		{Address: "cc", VotingPower: 5},
+	})
+	if set, _ := tracker.At(12); set.Height != 10 {
+		t.Fatalf("updates applied too early: %+v", set)
+	}
+	set, _ := tracker.At(13)
+	if set.Height != 13 || set.Priorities || set.TotalPower() != 15 {
+		t.Fatalf("unexpected set at 13: %+v", set)
+	}
+	if _, err := tracker.Proposer(13, 0); err != ErrNoPriorities {
+		t.Fatalf("expected ErrNoPriorities for an updated set, got %v", err)
+	}
+
+	// A polled set wins over one derived from updates.
+	tracker.Record(15, []Validator{{Address: "aa", VotingPower: 1}})
+	tracker.OnValidatorUpdates(13, []ingress.ValidatorUpdate{{Address: "dd", VotingPower: 1}})
+	if set, _ := tracker.At(15); !set.Priorities || set.TotalPower() != 1 {
+		t.Fatalf("polled set overwritten: %+v", set)
+	}
+}
+
+func TestPollerFetchPaginates(t *testing.T) {
+	const total = 150
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/validators" {
+			http.NotFound(w, r)
+			return
+		}
+		height := r.URL.Query().Get("height")
+		if height == "" {
+			height = "42"
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		var items string
+		for i := (page - 1) * perPage; i < total && i < page*perPage; i++ {
+			if items != "" {
+				items += ","
+			}
+			items += fmt.Sprintf(`{"address":"%040X","pub_key":{"type":"tendermint/PubKeyEd25519","value":"AA=="},"voting_power":"%d","proposer_priority":"0"}`, i, i+1)
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":-1,"result":{"block_height":"%s","validators":[%s],"count":"0","total":"%d"}}`, height, items, total)
+	}))
+	defer server.Close()
+
+	tracker := NewTracker()
+	poller := NewPoller(server.URL+"/", tracker)
+	set, err := poller.Fetch(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if set.Height != 42 || len(set.Validators) != total || set.Validators[0].VotingPower != total {
+		t.Fatalf("unexpected set: height %d, %d validators", set.Height, len(set.Validators))
+	}
+	if set.Validators[0].Address != fmt.Sprintf("%040x", total-1) || set.Validators[0].PubKeyType != "tendermint/PubKeyEd25519" {
+		t.Fatalf("unexpected first validator %+v", set.Validators[0])
+	}
+
+	powers := poller.Source(context.Background())(big.NewInt(7))
+	if len(powers) != total {
+		t.Fatalf("expected the source to fetch height 7, got %d validators", len(powers))
+	}
+	if set, _ := tracker.At(7); set.Height != 7 {
+		t.Fatalf("expected a set recorded at 7, got %+v", set)
+	}
+}
+
+func TestPollerReportsRPCErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":-1,"error":{"code":-32603,"message":"Internal error","data":"height 1 must be less than or equal to the current blockchain height 0"}}`)
+	}))
+	defer server.Close()
+
+	if _, err := NewPoller(server.URL, NewTracker()).Fetch(context.Background(), 1); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,100 @@
+package adapter
+
+import (
+	"fmt"
+	"math/big"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+// ByzantineAction describes the manipulation to apply when converting back to a beacon-chain message.
+type ByzantineAction = byzantine.Action
+
+// ByzantineOptions contains optional overrides for the mutated messages.
+type ByzantineOptions = byzantine.Options
+
+const (
+	// ByzantineActionDoubleVote emits two attestations for the same target epoch with different block roots,
+	// the first Casper FFG slashing condition.
+	ByzantineActionDoubleVote = byzantine.ActionDoubleVote
+	// ByzantineActionSurroundVote emits a second attestation whose source and target surround the original's,
+	// the second Casper FFG slashing condition.
+	ByzantineActionSurroundVote ByzantineAction = "surround_vote"
+)
+
+// ByzantineEngine is the set of actions supported for the beacon chain.
+var ByzantineEngine = newByzantineEngine()
+
+func newByzantineEngine() *byzantine.Engine {
+	e := byzantine.NewEngine()
+	e.Register(ByzantineActionDoubleVote, applyDoubleVoteMutation)
+	e.Register(ByzantineActionSurroundVote, applySurroundVoteMutation)
+	return e
+}
+
+// FromCanonicalByzantine converts a canonical message back to SSZ while applying a byzantine action.
+func (m *EthereumMapper) FromCanonicalByzantine(msg *abstraction.CanonicalMessage, action ByzantineAction, opts ByzantineOptions) ([]*abstraction.RawConsensusMessage, error) {
+	return ByzantineEngine.ApplyAndEncode(m, msg, action, opts)
+}
+
+// applyDoubleVoteMutation changes the target root along with the head, since two attestations for one
+// target epoch are only slashable when their data differs.
+func applyDoubleVoteMutation(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
+	canonicals, err := byzantine.DoubleVote(msg, opts)
+	if err != nil {
+		return nil, err
+	}
+	mutated := canonicals[1]
+	if opts.AlternateBlockHash == "" {
+		mutated.BlockHash = alternateRoot(msg.BlockHash)
+	}
+	if mutated.Extensions != nil {
+		mutated.Extensions["target_root"] = alternateRoot(stringExtension(msg.Extensions, "target_root"))
+	}
+	return canonicals, nil
+}
+
+func applySurroundVoteMutation(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
+	if msg.Type != abstraction.MsgTypeVote || stringExtension(msg.Extensions, "beacon_message_type") != MessageAttestation {
+		return nil, fmt.Errorf("surround_vote action requires an attestation canonical message")
+	}
+	source, _ := msg.Extensions.GetUint64("source_epoch")
+	target, _ := msg.Extensions.GetUint64("target_epoch")
+	if source == 0 || msg.Height == nil {
+		return nil, fmt.Errorf("surround_vote action requires a source epoch after genesis to surround")
+	}
+
+	original := byzantine.Clone(msg)
+	mutated := byzantine.Clone(msg)
+	// The surrounding vote reaches one epoch further back and one further ahead, and is cast in its target epoch.
+	mutated.Extensions["source_epoch"] = source - 1
+	mutated.Extensions["source_root"] = alternateRoot(stringExtension(msg.Extensions, "source_root"))
+	mutated.Extensions["target_epoch"] = target + 1
+	mutated.Extensions["target_root"] = alternateRoot(stringExtension(msg.Extensions, "target_root"))
+	mutated.Height = new(big.Int).Add(msg.Height, big.NewInt(SlotsPerEpoch))
+	mutated.Extensions["epoch"] = mutated.Height.Uint64() / SlotsPerEpoch
+	mutated.BlockHash = opts.AlternateBlockHash
+	if mutated.BlockHash == "" {
+		mutated.BlockHash = alternateRoot(msg.BlockHash)
+	}
+	if opts.AlternateSignature != "" {
+		mutated.Signature = opts.AlternateSignature
+	}
+
+	byzantine.ApplyCommon(mutated, opts)
+	byzantine.EnsureTimestampProgress(mutated, msg.Timestamp)
+
+	return []*abstraction.CanonicalMessage{original, mutated}, nil
+}
+
+// alternateRoot returns a different root of the same length. byzantine.AlternateHash only rewrites some hex
+// digits and would turn a root like 0xcc..cc into 1xcc..cc, which no longer decodes.
+func alternateRoot(value string) string {
+	root, err := ParseRoot(value)
+	if err != nil {
+		return byzantine.AlternateHash(value, "")
+	}
+	root[len(root)-1] ^= 0x01
+	return formatRoot(root)
+}
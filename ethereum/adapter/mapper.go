@@ -0,0 +1,331 @@
+package adapter
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"codec/message/abstraction"
+)
+
+// Beacon message types as carried in RawConsensusMessage.MessageType
+const (
+	MessageAttestation   = "Attestation"
+	MessageBlockHeader   = "SignedBeaconBlockHeader"
+	MessageSyncCommittee = "SyncCommitteeMessage"
+)
+
+// SlotsPerEpoch is the mainnet epoch length
+const SlotsPerEpoch = 32
+
+// EthereumMapper implements the Mapper interface for Ethereum beacon-chain (Gasper) messages. Payloads are
+// SSZ-encoded. A slot becomes the canonical height; Gasper has no rounds, so round and view stay empty and
+// the epoch goes into the extensions.
+type EthereumMapper struct {
+	chainID string
+}
+
+// NewEthereumMapper creates a new Ethereum mapper
+func NewEthereumMapper(chainID string) *EthereumMapper {
+	return &EthereumMapper{
+		chainID: chainID,
+	}
+}
+
+// ToCanonical converts a beacon-chain message to canonical format. Attestations and sync committee messages
+// become votes. A block header becomes a proposal whose block hash is the header's hash tree root.
+func (m *EthereumMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abstraction.CanonicalMessage, error) {
+	if raw.ChainType != abstraction.ChainTypeEthereum {
+		return nil, abstraction.ErrChainMismatch
+	}
+	if raw.Encoding != "ssz" {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "encoding",
+			Message: fmt.Sprintf("unsupported encoding: %s", raw.Encoding),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+	decodeFailure := func(err error) error {
+		return &abstraction.MessageValidationError{
+			Field:   "payload",
+			Message: fmt.Sprintf("failed to parse ssz: %v", err),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+
+	canonical := &abstraction.CanonicalMessage{
+		ChainID:    m.chainID,
+		Timestamp:  raw.Timestamp,
+		RawPayload: raw.Payload,
+		Extensions: map[string]interface{}{
+			"beacon_message_type": raw.MessageType,
+		},
+	}
+	var slot uint64
+
+	switch raw.MessageType {
+	case MessageAttestation:
+		att, err := DecodeAttestation(raw.Payload)
+		if err != nil {
+			return nil, decodeFailure(err)
+		}
+		slot = att.Data.Slot
+		canonical.Type = abstraction.MsgTypeVote
+		canonical.BlockHash = formatRoot(att.Data.BeaconBlockRoot)
+		canonical.Validator = FormatAttesters(att.Data.Index, att.AggregationBits)
+		canonical.Signature = formatBytes(att.Signature[:])
+		canonical.Extensions["committee_index"] = att.Data.Index
+		canonical.Extensions["committee_size"] = len(att.AggregationBits)
+		canonical.Extensions["source_epoch"] = att.Data.Source.Epoch
+		canonical.Extensions["source_root"] = formatRoot(att.Data.Source.Root)
+		canonical.Extensions["target_epoch"] = att.Data.Target.Epoch
+		canonical.Extensions["target_root"] = formatRoot(att.Data.Target.Root)
+
+	case MessageBlockHeader:
+		header, err := DecodeSignedBeaconBlockHeader(raw.Payload)
+		if err != nil {
+			return nil, decodeFailure(err)
+		}
+		slot = header.Message.Slot
+		canonical.Type = abstraction.MsgTypeProposal
+		canonical.BlockHash = formatRoot(header.Message.HashTreeRoot())
+		canonical.PrevHash = formatRoot(header.Message.ParentRoot)
+		canonical.Proposer = strconv.FormatUint(header.Message.ProposerIndex, 10)
+		canonical.Signature = formatBytes(header.Signature[:])
+		canonical.Extensions["state_root"] = formatRoot(header.Message.StateRoot)
+		canonical.Extensions["body_root"] = formatRoot(header.Message.BodyRoot)
+
+	case MessageSyncCommittee:
+		msg, err := DecodeSyncCommitteeMessage(raw.Payload)
+		if err != nil {
+			return nil, decodeFailure(err)
+		}
+		slot = msg.Slot
+		canonical.Type = abstraction.MsgTypeVote
+		canonical.BlockHash = formatRoot(msg.BeaconBlockRoot)
+		canonical.Validator = strconv.FormatUint(msg.ValidatorIndex, 10)
+		canonical.Signature = formatBytes(msg.Signature[:])
+
+	default:
+		return nil, &abstraction.MessageValidationError{
+			Field:   "message_type",
+			Message: fmt.Sprintf("unsupported beacon message type: %s", raw.MessageType),
+			Code:    "UNSUPPORTED_TYPE",
+		}
+	}
+
+	canonical.Height = new(big.Int).SetUint64(slot)
+	canonical.Extensions["epoch"] = slot / SlotsPerEpoch
+	return canonical, nil
+}
+
+// FromCanonical converts a canonical message to SSZ. A proposal's block hash is not encoded: the block root
+// is the hash tree root of the header, so it follows from the other fields.
+func (m *EthereumMapper) FromCanonical(msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
+	if msg == nil {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "message",
+			Message: "message cannot be nil",
+			Code:    "MISSING_FIELD",
+		}
+	}
+	var slot uint64
+	if msg.Height != nil {
+		slot = msg.Height.Uint64()
+	}
+	invalid := func(field string, err error) error {
+		return &abstraction.MessageValidationError{
+			Field:   field,
+			Message: err.Error(),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+	signature, err := parseSignature(msg.Signature)
+	if err != nil {
+		return nil, invalid("signature", err)
+	}
+
+	var messageType string
+	var payload []byte
+	switch msg.Type {
+	case abstraction.MsgTypeProposal, abstraction.MsgTypeBlock:
+		header := SignedBeaconBlockHeader{Message: BeaconBlockHeader{Slot: slot}, Signature: signature}
+		if header.Message.ProposerIndex, err = parseIndex(msg.Proposer); err != nil {
+			return nil, invalid("proposer", err)
+		}
+		if header.Message.ParentRoot, err = ParseRoot(msg.PrevHash); err != nil {
+			return nil, invalid("prev_hash", err)
+		}
+		if header.Message.StateRoot, err = ParseRoot(stringExtension(msg.Extensions, "state_root")); err != nil {
+			return nil, invalid("state_root", err)
+		}
+		if header.Message.BodyRoot, err = ParseRoot(stringExtension(msg.Extensions, "body_root")); err != nil {
+			return nil, invalid("body_root", err)
+		}
+		messageType, payload = MessageBlockHeader, header.MarshalSSZ()
+
+	case abstraction.MsgTypeVote, abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit:
+		root, err := ParseRoot(msg.BlockHash)
+		if err != nil {
+			return nil, invalid("block_hash", err)
+		}
+		if stringExtension(msg.Extensions, "beacon_message_type") == MessageSyncCommittee {
+			index, err := parseIndex(msg.Validator)
+			if err != nil {
+				return nil, invalid("validator", err)
+			}
+			sync := SyncCommitteeMessage{Slot: slot, BeaconBlockRoot: root, ValidatorIndex: index, Signature: signature}
+			messageType, payload = MessageSyncCommittee, sync.MarshalSSZ()
+			break
+		}
+		size, _ := msg.Extensions.GetUint64("committee_size")
+		committee, bits, err := ParseAttesters(msg.Validator, int(size))
+		if err != nil {
+			return nil, invalid("validator", err)
+		}
+		att := Attestation{
+			AggregationBits: bits,
+			Data:            AttestationData{Slot: slot, Index: committee, BeaconBlockRoot: root},
+			Signature:       signature,
+		}
+		att.Data.Source.Epoch, _ = msg.Extensions.GetUint64("source_epoch")
+		att.Data.Target.Epoch, _ = msg.Extensions.GetUint64("target_epoch")
+		if att.Data.Source.Root, err = ParseRoot(stringExtension(msg.Extensions, "source_root")); err != nil {
+			return nil, invalid("source_root", err)
+		}
+		if att.Data.Target.Root, err = ParseRoot(stringExtension(msg.Extensions, "target_root")); err != nil {
+			return nil, invalid("target_root", err)
+		}
+		messageType, payload = MessageAttestation, att.MarshalSSZ()
+
+	default:
+		return nil, &abstraction.MessageValidationError{
+			Field:   "type",
+			Message: fmt.Sprintf("unsupported canonical message type: %s", msg.Type),
+			Code:    "UNSUPPORTED_TYPE",
+		}
+	}
+
+	return &abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeEthereum,
+		ChainID:     m.chainID,
+		MessageType: messageType,
+		Payload:     payload,
+		Encoding:    "ssz",
+		Timestamp:   abstraction.Now(),
+		Metadata: map[string]interface{}{
+			"epoch": slot / SlotsPerEpoch,
+		},
+	}, nil
+}
+
+// GetSupportedTypes returns the message types supported by the beacon chain
+func (m *EthereumMapper) GetSupportedTypes() []abstraction.MsgType {
+	return []abstraction.MsgType{
+		abstraction.MsgTypeProposal, // SignedBeaconBlockHeader
+		abstraction.MsgTypeVote,     // Attestation and SyncCommitteeMessage
+	}
+}
+
+// GetChainType returns the chain type this mapper handles
+func (m *EthereumMapper) GetChainType() abstraction.ChainType {
+	return abstraction.ChainTypeEthereum
+}
+
+// FormatAttesters renders the committee members an attestation aggregates as "<committee>:<positions>", e.g.
+// "3:0,5,17". Positions index the committee, not the validator registry, which needs the epoch's shuffling.
+func FormatAttesters(committee uint64, bits []bool) string {
+	positions := make([]string, 0, 1)
+	for i, set := range bits {
+		if set {
+			positions = append(positions, strconv.Itoa(i))
+		}
+	}
+	return fmt.Sprintf("%d:%s", committee, strings.Join(positions, ","))
+}
+
+// ParseAttesters parses a FormatAttesters value into a committee index and aggregation bits. size is the
+// committee size; a size of zero or one too small for the positions is extended to the highest position.
+func ParseAttesters(value string, size int) (uint64, []bool, error) {
+	committeePart, positionPart, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, nil, fmt.Errorf("invalid attesters %q: expected <committee>:<positions>", value)
+	}
+	committee, err := strconv.ParseUint(committeePart, 10, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid attesters %q: %v", value, err)
+	}
+	var positions []int
+	if positionPart != "" {
+		for _, p := range strings.Split(positionPart, ",") {
+			pos, err := strconv.Atoi(p)
+			if err != nil || pos < 0 || pos >= maxValidatorsPerCommit {
+				return 0, nil, fmt.Errorf("invalid attesters %q: bad position %q", value, p)
+			}
+			positions = append(positions, pos)
+		}
+	}
+	sort.Ints(positions)
+	if n := len(positions); n > 0 && positions[n-1] >= size {
+		size = positions[n-1] + 1
+	}
+	bits := make([]bool, size)
+	for _, pos := range positions {
+		bits[pos] = true
+	}
+	return committee, bits, nil
+}
+
+// ParseRoot parses a 32-byte hex root with or without a 0x prefix; an empty string is the zero root.
+func ParseRoot(value string) (Root, error) {
+	var root Root
+	if value == "" {
+		return root, nil
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil || len(data) != rootSize {
+		return root, fmt.Errorf("invalid root %q: expected %d hex bytes", value, rootSize)
+	}
+	copy(root[:], data)
+	return root, nil
+}
+
+func parseSignature(value string) (Signature, error) {
+	var sig Signature
+	if value == "" {
+		return sig, nil
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil || len(data) != signatureSize {
+		return sig, fmt.Errorf("invalid BLS signature %q: expected %d hex bytes", value, signatureSize)
+	}
+	copy(sig[:], data)
+	return sig, nil
+}
+
+func parseIndex(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	index, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid validator index %q", value)
+	}
+	return index, nil
+}
+
+func formatRoot(root Root) string {
+	return formatBytes(root[:])
+}
+
+func formatBytes(data []byte) string {
+	return "0x" + hex.EncodeToString(data)
+}
+
+func stringExtension(extensions abstraction.Extensions, key string) string {
+	s, _ := extensions.GetString(key)
+	return s
+}
@@ -0,0 +1,155 @@
+package adapter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/validator"
+)
+
+func root(b byte) (r Root) {
+	for i := range r {
+		r[i] = b
+	}
+	return r
+}
+
+func beaconRaw(messageType string, payload []byte) abstraction.RawConsensusMessage {
+	return abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeEthereum,
+		ChainID:     "ethereum",
+		MessageType: messageType,
+		Payload:     payload,
+		Encoding:    "ssz",
+		Timestamp:   time.Now(),
+	}
+}
+
+func TestBlockHeaderHashTreeRoot(t *testing.T) {
+	// An all-zero header merkleizes to the zero hash of an eight-leaf tree.
+	if got := formatRoot(BeaconBlockHeader{}.HashTreeRoot()); got != "0xc78009fdf07fc56a11f122370658a353aaa542ed63e44c4bc15ff4cd105ab33c" {
+		t.Fatalf("unexpected zero header root %s", got)
+	}
+}
+
+func TestEthereumRoundTrip(t *testing.T) {
+	mapper := NewEthereumMapper("ethereum-test")
+	sig := Signature{1, 2, 3}
+	attestation := &Attestation{
+		AggregationBits: []bool{false, true, false, false, false, true, false, false, false},
+		Data: AttestationData{Slot: 6432, Index: 3, BeaconBlockRoot: root(0xaa),
+			Source: Checkpoint{Epoch: 199, Root: root(0xbb)}, Target: Checkpoint{Epoch: 201, Root: root(0xcc)}},
+		Signature: sig,
+	}
+	header := &SignedBeaconBlockHeader{
+		Message:   BeaconBlockHeader{Slot: 6433, ProposerIndex: 812, ParentRoot: root(0xaa), StateRoot: root(0x01), BodyRoot: root(0x02)},
+		Signature: sig,
+	}
+	sync := &SyncCommitteeMessage{Slot: 6433, BeaconBlockRoot: root(0xdd), ValidatorIndex: 77, Signature: sig}
+
+	tests := []struct {
+		name        string
+		raw         abstraction.RawConsensusMessage
+		msgType     abstraction.MsgType
+		height      int64
+		blockHash   string
+		participant string
+	}{
+		{"attestation", beaconRaw(MessageAttestation, attestation.MarshalSSZ()), abstraction.MsgTypeVote, 6432, formatRoot(root(0xaa)), "3:1,5"},
+		{"block header", beaconRaw(MessageBlockHeader, header.MarshalSSZ()), abstraction.MsgTypeProposal, 6433, formatRoot(header.Message.HashTreeRoot()), "812"},
+		{"sync committee", beaconRaw(MessageSyncCommittee, sync.MarshalSSZ()), abstraction.MsgTypeVote, 6433, formatRoot(root(0xdd)), "77"},
+	}
+
+	v := validator.NewValidator(abstraction.ChainTypeEthereum)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canonical, err := mapper.ToCanonical(tt.raw)
+			if err != nil {
+				t.Fatalf("ToCanonical: %v", err)
+			}
+			participant := canonical.Validator
+			if canonical.Type == abstraction.MsgTypeProposal {
+				participant = canonical.Proposer
+			}
+			if canonical.Type != tt.msgType || canonical.Height.Int64() != tt.height || canonical.BlockHash != tt.blockHash ||
+				participant != tt.participant || canonical.Round != nil || canonical.Extensions["epoch"] != uint64(tt.height/SlotsPerEpoch) {
+				t.Fatalf("unexpected canonical message %+v", canonical)
+			}
+			if err := v.Validate(canonical); err != nil {
+				t.Fatalf("validation failed: %v", err)
+			}
+
+			raw, err := mapper.FromCanonical(canonical)
+			if err != nil {
+				t.Fatalf("FromCanonical: %v", err)
+			}
+			if raw.MessageType != tt.raw.MessageType || !bytes.Equal(raw.Payload, tt.raw.Payload) {
+				t.Fatalf("round trip changed the payload:\n got %x\nwant %x", raw.Payload, tt.raw.Payload)
+			}
+		})
+	}
+
+	if got := attestation.MarshalSSZ()[attestationFixedSize:]; !bytes.Equal(got, []byte{0x22, 0x02}) {
+		t.Fatalf("unexpected aggregation bitlist %x", got)
+	}
+	for name, raw := range map[string]abstraction.RawConsensusMessage{
+		"truncated header":    beaconRaw(MessageBlockHeader, header.MarshalSSZ()[:100]),
+		"bitlist without end": beaconRaw(MessageAttestation, append(attestation.MarshalSSZ()[:attestationFixedSize], 0x22, 0x00)),
+		"unknown type":        beaconRaw("AggregateAndProof", nil),
+	} {
+		if _, err := mapper.ToCanonical(raw); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSurroundVote(t *testing.T) {
+	mapper := NewEthereumMapper("ethereum-test")
+	att := &Attestation{
+		AggregationBits: []bool{true, false},
+		Data: AttestationData{Slot: 6432, Index: 1, BeaconBlockRoot: root(0xaa),
+			Source: Checkpoint{Epoch: 200, Root: root(0xbb)}, Target: Checkpoint{Epoch: 201, Root: root(0xcc)}},
+	}
+	canonical, err := mapper.ToCanonical(beaconRaw(MessageAttestation, att.MarshalSSZ()))
+	if err != nil {
+		t.Fatalf("ToCanonical: %v", err)
+	}
+
+	raws, err := mapper.FromCanonicalByzantine(canonical, ByzantineActionSurroundVote, ByzantineOptions{})
+	if err != nil {
+		t.Fatalf("surround_vote: %v", err)
+	}
+	if len(raws) != 2 {
+		t.Fatalf("expected the original and the surrounding attestation, got %d", len(raws))
+	}
+	first, err := DecodeAttestation(raws[0].Payload)
+	if err != nil {
+		t.Fatalf("decode original: %v", err)
+	}
+	second, err := DecodeAttestation(raws[1].Payload)
+	if err != nil {
+		t.Fatalf("decode surrounding: %v", err)
+	}
+	if !(second.Data.Source.Epoch < first.Data.Source.Epoch && first.Data.Target.Epoch < second.Data.Target.Epoch) {
+		t.Fatalf("attestation (%d, %d) does not surround (%d, %d)", second.Data.Source.Epoch, second.Data.Target.Epoch,
+			first.Data.Source.Epoch, first.Data.Target.Epoch)
+	}
+	if second.Data.Slot/SlotsPerEpoch != second.Data.Target.Epoch || second.Data.Index != 1 || !second.AggregationBits[0] {
+		t.Fatalf("unexpected surrounding attestation %+v", second)
+	}
+
+	double, err := mapper.FromCanonicalByzantine(canonical, ByzantineActionDoubleVote, ByzantineOptions{})
+	if err != nil {
+		t.Fatalf("double_vote: %v", err)
+	}
+	conflicting, err := DecodeAttestation(double[1].Payload)
+	if err != nil {
+		t.Fatalf("decode double vote: %v", err)
+	}
+	if conflicting.Data.Target.Epoch != att.Data.Target.Epoch || conflicting.Data.Target.Root == att.Data.Target.Root ||
+		conflicting.Data.BeaconBlockRoot == att.Data.BeaconBlockRoot {
+		t.Fatalf("expected a conflicting vote for the same target epoch, got %+v", conflicting.Data)
+	}
+}
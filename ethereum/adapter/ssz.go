@@ -0,0 +1,219 @@
+package adapter
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// SSZ sizes of the phase0 beacon containers this adapter reads. Electra's attestations add committee bits
+// and are not covered.
+const (
+	rootSize               = 32
+	signatureSize          = 96
+	checkpointSize         = 8 + rootSize
+	attestationDataSize    = 8 + 8 + rootSize + 2*checkpointSize
+	attestationFixedSize   = 4 + attestationDataSize + signatureSize
+	blockHeaderSize        = 8 + 8 + 3*rootSize
+	signedBlockHeaderSize  = blockHeaderSize + signatureSize
+	syncCommitteeMsgSize   = 8 + rootSize + 8 + signatureSize
+	maxValidatorsPerCommit = 2048
+)
+
+// Root is a 32-byte SSZ root
+type Root [rootSize]byte
+
+// Signature is a 96-byte BLS signature
+type Signature [signatureSize]byte
+
+// Checkpoint is an FFG checkpoint
+type Checkpoint struct {
+	Epoch uint64
+	Root  Root
+}
+
+// AttestationData is what an attester votes for: the LMD-GHOST head and the FFG source and target
+type AttestationData struct {
+	Slot            uint64
+	Index           uint64 // committee index
+	BeaconBlockRoot Root
+	Source          Checkpoint
+	Target          Checkpoint
+}
+
+// Attestation is a (possibly aggregated) attestation. AggregationBits has one entry per committee member.
+type Attestation struct {
+	AggregationBits []bool
+	Data            AttestationData
+	Signature       Signature
+}
+
+// BeaconBlockHeader is the header of a beacon block; its hash tree root is the block root
+type BeaconBlockHeader struct {
+	Slot          uint64
+	ProposerIndex uint64
+	ParentRoot    Root
+	StateRoot     Root
+	BodyRoot      Root
+}
+
+// SignedBeaconBlockHeader is a block header with the proposer's signature
+type SignedBeaconBlockHeader struct {
+	Message   BeaconBlockHeader
+	Signature Signature
+}
+
+// SyncCommitteeMessage is a sync committee member's signature over the head block root
+type SyncCommitteeMessage struct {
+	Slot            uint64
+	BeaconBlockRoot Root
+	ValidatorIndex  uint64
+	Signature       Signature
+}
+
+// sszReader reads fixed-size SSZ fields in order
+type sszReader struct {
+	buf []byte
+}
+
+func (r *sszReader) uint64() uint64 {
+	v := binary.LittleEndian.Uint64(r.buf)
+	r.buf = r.buf[8:]
+	return v
+}
+
+func (r *sszReader) root() (root Root) {
+	r.buf = r.buf[copy(root[:], r.buf):]
+	return root
+}
+
+func (r *sszReader) signature() (sig Signature) {
+	r.buf = r.buf[copy(sig[:], r.buf):]
+	return sig
+}
+
+func (r *sszReader) checkpoint() Checkpoint {
+	return Checkpoint{Epoch: r.uint64(), Root: r.root()}
+}
+
+func (r *sszReader) attestationData() AttestationData {
+	return AttestationData{Slot: r.uint64(), Index: r.uint64(), BeaconBlockRoot: r.root(), Source: r.checkpoint(), Target: r.checkpoint()}
+}
+
+func sszUint64(out []byte, v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(out, v)
+}
+
+func (c Checkpoint) appendSSZ(out []byte) []byte {
+	return append(sszUint64(out, c.Epoch), c.Root[:]...)
+}
+
+func (d AttestationData) appendSSZ(out []byte) []byte {
+	out = sszUint64(sszUint64(out, d.Slot), d.Index)
+	out = append(out, d.BeaconBlockRoot[:]...)
+	return d.Target.appendSSZ(d.Source.appendSSZ(out))
+}
+
+// DecodeAttestation decodes an SSZ-encoded phase0 Attestation
+func DecodeAttestation(data []byte) (*Attestation, error) {
+	if len(data) < attestationFixedSize+1 {
+		return nil, fmt.Errorf("attestation is %d bytes, expected at least %d", len(data), attestationFixedSize+1)
+	}
+	if offset := binary.LittleEndian.Uint32(data); offset != attestationFixedSize {
+		return nil, fmt.Errorf("attestation aggregation_bits offset is %d, expected %d", offset, attestationFixedSize)
+	}
+	bitlist, err := decodeBitlist(data[attestationFixedSize:], maxValidatorsPerCommit)
+	if err != nil {
+		return nil, fmt.Errorf("attestation aggregation_bits: %w", err)
+	}
+	r := &sszReader{buf: data[4:attestationFixedSize]}
+	return &Attestation{AggregationBits: bitlist, Data: r.attestationData(), Signature: r.signature()}, nil
+}
+
+// MarshalSSZ encodes the attestation
+func (a *Attestation) MarshalSSZ() []byte {
+	out := binary.LittleEndian.AppendUint32(nil, attestationFixedSize)
+	out = a.Data.appendSSZ(out)
+	out = append(out, a.Signature[:]...)
+	return append(out, encodeBitlist(a.AggregationBits)...)
+}
+
+// DecodeSignedBeaconBlockHeader decodes an SSZ-encoded SignedBeaconBlockHeader
+func DecodeSignedBeaconBlockHeader(data []byte) (*SignedBeaconBlockHeader, error) {
+	if len(data) != signedBlockHeaderSize {
+		return nil, fmt.Errorf("signed block header is %d bytes, expected %d", len(data), signedBlockHeaderSize)
+	}
+	r := &sszReader{buf: data}
+	header := BeaconBlockHeader{Slot: r.uint64(), ProposerIndex: r.uint64(), ParentRoot: r.root(), StateRoot: r.root(), BodyRoot: r.root()}
+	return &SignedBeaconBlockHeader{Message: header, Signature: r.signature()}, nil
+}
+
+// MarshalSSZ encodes the signed header
+func (h *SignedBeaconBlockHeader) MarshalSSZ() []byte {
+	out := sszUint64(sszUint64(nil, h.Message.Slot), h.Message.ProposerIndex)
+	out = append(out, h.Message.ParentRoot[:]...)
+	out = append(out, h.Message.StateRoot[:]...)
+	out = append(out, h.Message.BodyRoot[:]...)
+	return append(out, h.Signature[:]...)
+}
+
+// HashTreeRoot returns the header's SSZ hash tree root, which is the root of the block it heads
+func (h BeaconBlockHeader) HashTreeRoot() Root {
+	leaves := make([]Root, 8)
+	binary.LittleEndian.PutUint64(leaves[0][:], h.Slot)
+	binary.LittleEndian.PutUint64(leaves[1][:], h.ProposerIndex)
+	leaves[2], leaves[3], leaves[4] = h.ParentRoot, h.StateRoot, h.BodyRoot
+	for len(leaves) > 1 {
+		next := make([]Root, len(leaves)/2)
+		for i := range next {
+			next[i] = sha256.Sum256(append(leaves[2*i][:], leaves[2*i+1][:]...))
+		}
+		leaves = next
+	}
+	return leaves[0]
+}
+
+// DecodeSyncCommitteeMessage decodes an SSZ-encoded SyncCommitteeMessage
+func DecodeSyncCommitteeMessage(data []byte) (*SyncCommitteeMessage, error) {
+	if len(data) != syncCommitteeMsgSize {
+		return nil, fmt.Errorf("sync committee message is %d bytes, expected %d", len(data), syncCommitteeMsgSize)
+	}
+	r := &sszReader{buf: data}
+	return &SyncCommitteeMessage{Slot: r.uint64(), BeaconBlockRoot: r.root(), ValidatorIndex: r.uint64(), Signature: r.signature()}, nil
+}
+
+// MarshalSSZ encodes the sync committee message
+func (m *SyncCommitteeMessage) MarshalSSZ() []byte {
+	out := sszUint64(nil, m.Slot)
+	out = append(out, m.BeaconBlockRoot[:]...)
+	out = sszUint64(out, m.ValidatorIndex)
+	return append(out, m.Signature[:]...)
+}
+
+// decodeBitlist decodes an SSZ bitlist, whose length is marked by a delimiter bit after the last entry
+func decodeBitlist(data []byte, limit int) ([]bool, error) {
+	if len(data) == 0 || data[len(data)-1] == 0 {
+		return nil, fmt.Errorf("bitlist has no delimiter bit")
+	}
+	length := (len(data)-1)*8 + bits.Len8(data[len(data)-1]) - 1
+	if length > limit {
+		return nil, fmt.Errorf("bitlist has %d bits, limit is %d", length, limit)
+	}
+	out := make([]bool, length)
+	for i := range out {
+		out[i] = data[i/8]&(1<<(i%8)) != 0
+	}
+	return out, nil
+}
+
+func encodeBitlist(list []bool) []byte {
+	out := make([]byte, len(list)/8+1)
+	for i, set := range list {
+		if set {
+			out[i/8] |= 1 << (i % 8)
+		}
+	}
+	out[len(list)/8] |= 1 << (len(list) % 8)
+	return out
+}
@@ -0,0 +1,73 @@
+package experiment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SignDataset signs every artifact listed in the manifest and then the manifest itself. The manifest is
+// signed last so its signature covers the artifact digests that were just checked.
+func SignDataset(key *SigningKey, manifestPath string) error {
+	m, err := LoadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(manifestPath)
+	for _, artifact := range m.Artifacts {
+		path := filepath.Join(dir, filepath.FromSlash(artifact.Path))
+		if err := checkArtifact(path, artifact); err != nil {
+			return err
+		}
+		if err := key.SignFile(path); err != nil {
+			return fmt.Errorf("failed to sign %s: %w", artifact.Path, err)
+		}
+	}
+	if err := key.SignFile(manifestPath); err != nil {
+		return fmt.Errorf("failed to sign manifest: %w", err)
+	}
+	return nil
+}
+
+// VerifyDataset checks the manifest signature, then the signature and digest of every artifact it lists.
+// It returns the verified manifest.
+func VerifyDataset(pub PublicKey, manifestPath string) (*Manifest, error) {
+	if _, err := pub.VerifyFile(manifestPath); err != nil {
+		return nil, err
+	}
+	m, err := LoadManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(manifestPath)
+	for _, artifact := range m.Artifacts {
+		path := filepath.Join(dir, filepath.FromSlash(artifact.Path))
+		if err := checkArtifact(path, artifact); err != nil {
+			return nil, err
+		}
+		if _, err := pub.VerifyFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func checkArtifact(path string, artifact Artifact) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("artifact %s: %w", artifact.Path, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return fmt.Errorf("artifact %s: %w", artifact.Path, err)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != artifact.SHA256 || n != artifact.Bytes {
+		return fmt.Errorf("artifact %s does not match the manifest digest", artifact.Path)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package experiment
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Manifest records what ran during an experiment so results can be reproduced and audited.
+type Manifest struct {
+	RunID      string                 `json:"run_id"`
+	Tool       string                 `json:"tool"`
+	Args       []string               `json:"args,omitempty"`
+	StartedAt  time.Time              `json:"started_at"`
+	FinishedAt time.Time              `json:"finished_at,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Resources  *ResourceReport        `json:"resources,omitempty"`
+	Artifacts  []Artifact             `json:"artifacts,omitempty"`
+}
+
+// Artifact is a file produced by the run. Its digest ties the file to the manifest, so signing the manifest
+// vouches for every artifact it lists.
+type Artifact struct {
+	// Path is relative to the directory holding the manifest.
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// NewManifest starts a manifest for the named tool using the current process arguments.
+func NewManifest(tool string) *Manifest {
+	return &Manifest{
+		RunID:      newRunID(),
+		Tool:       tool,
+		Args:       append([]string(nil), os.Args[1:]...),
+		StartedAt:  time.Now().UTC(),
+		Parameters: make(map[string]interface{}),
+	}
+}
+
+// SetParameter records an experiment parameter in the manifest.
+func (m *Manifest) SetParameter(key string, value interface{}) {
+	if m.Parameters == nil {
+		m.Parameters = make(map[string]interface{})
+	}
+	m.Parameters[key] = value
+}
+
+// Finish stamps the completion time and attaches the resource report, if any.
+func (m *Manifest) Finish(resources *ResourceAccountant) {
+	m.FinishedAt = time.Now().UTC()
+	if resources != nil {
+		m.Resources = resources.Report()
+	}
+}
+
+// AddArtifact records the file at path under name, which should be relative to the manifest's directory.
+// Adding a name twice replaces the earlier entry.
+func (m *Manifest) AddArtifact(name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open artifact: %w", err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return fmt.Errorf("failed to hash artifact %s: %w", path, err)
+	}
+	artifact := Artifact{Path: filepath.ToSlash(name), SHA256: hex.EncodeToString(h.Sum(nil)), Bytes: n}
+	for i := range m.Artifacts {
+		if m.Artifacts[i].Path == artifact.Path {
+			m.Artifacts[i] = artifact
+			return nil
+		}
+	}
+	m.Artifacts = append(m.Artifacts, artifact)
+	return nil
+}
+
+// WriteFile writes the manifest as indented JSON.
+func (m *Manifest) WriteFile(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// LoadManifest reads a manifest previously written with WriteFile.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return &m, nil
+}
+
+func newRunID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().UTC().Format("20060102T150405.000000000")
+	}
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(buf)
+}
@@ -0,0 +1,376 @@
+package experiment
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"runtime"
+	"runtime/metrics"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	metricCPUTotal   = "/cpu/classes/total:cpu-seconds"
+	metricCPUIdle    = "/cpu/classes/idle:cpu-seconds"
+	metricHeapBytes  = "/memory/classes/heap/objects:bytes"
+	metricTotalBytes = "/memory/classes/total:bytes"
+	metricGoroutines = "/sched/goroutines:goroutines"
+)
+
+// maxResourceSamples bounds the sample history a report carries. Longer runs keep every other sample each
+// time the history fills up, so it still spans the whole run at a coarser interval.
+const maxResourceSamples = 512
+
+// ResourceSample is a point-in-time view of process resource usage.
+type ResourceSample struct {
+	At          time.Time `json:"at"`
+	CPUSeconds  float64   `json:"cpu_seconds"`
+	HeapBytes   uint64    `json:"heap_bytes"`
+	MemoryBytes uint64    `json:"memory_bytes"`
+	RSSBytes    uint64    `json:"rss_bytes,omitempty"`
+	Goroutines  uint64    `json:"goroutines"`
+	NetRxBytes  uint64    `json:"net_rx_bytes,omitempty"`
+	NetTxBytes  uint64    `json:"net_tx_bytes,omitempty"`
+}
+
+// ComponentUsage summarises the work attributed to one pipeline component.
+type ComponentUsage struct {
+	Name     string        `json:"name"`
+	Messages int64         `json:"messages"`
+	BytesIn  int64         `json:"bytes_in"`
+	BytesOut int64         `json:"bytes_out"`
+	BusyTime time.Duration `json:"busy_time_ns"`
+	// CPUSeconds is the process CPU time apportioned by the component's share of total busy time.
+	CPUSeconds float64 `json:"cpu_seconds"`
+}
+
+// ResourceReport is the resource section of a run manifest.
+type ResourceReport struct {
+	SampleInterval time.Duration    `json:"sample_interval_ns"`
+	Duration       time.Duration    `json:"duration_ns"`
+	CPUSeconds     float64          `json:"cpu_seconds"`
+	PeakHeapBytes  uint64           `json:"peak_heap_bytes"`
+	PeakRSSBytes   uint64           `json:"peak_rss_bytes,omitempty"`
+	PeakGoroutines uint64           `json:"peak_goroutines"`
+	NetRxBytes     uint64           `json:"net_rx_bytes,omitempty"`
+	NetTxBytes     uint64           `json:"net_tx_bytes,omitempty"`
+	Components     []ComponentUsage `json:"components"`
+	// Samples is the sample history, evenly thinned to at most maxResourceSamples entries, followed by the
+	// latest sample.
+	Samples []ResourceSample `json:"samples,omitempty"`
+}
+
+// ResourceAccountant samples process usage and collects per-component counters during a run.
+type ResourceAccountant struct {
+	interval time.Duration
+
+	mu         sync.Mutex
+	components map[string]*ComponentMeter
+	// samples holds every stride-th sample; first, last, and peak cover all of them.
+	samples []ResourceSample
+	stride  int
+	seen    int
+	first   ResourceSample
+	last    ResourceSample
+	peak    ResourceSample
+	started bool
+	stop    context.CancelFunc
+	done    chan struct{}
+}
+
+// NewResourceAccountant creates an accountant that samples every interval once started.
+func NewResourceAccountant(interval time.Duration) *ResourceAccountant {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &ResourceAccountant{
+		interval:   interval,
+		components: make(map[string]*ComponentMeter),
+	}
+}
+
+// Start begins periodic sampling until Stop is called or the context ends.
+func (a *ResourceAccountant) Start(ctx context.Context) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	if a.started {
+		a.mu.Unlock()
+		return
+	}
+	a.started = true
+	a.first = takeSample()
+	a.observe(a.first)
+	ctx, a.stop = context.WithCancel(ctx)
+	a.done = make(chan struct{})
+	a.mu.Unlock()
+
+	go func() {
+		defer close(a.done)
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.record(takeSample())
+			}
+		}
+	}()
+}
+
+// Stop halts sampling and records a final sample.
+func (a *ResourceAccountant) Stop() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	stop, done := a.stop, a.done
+	a.stop = nil
+	a.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	stop()
+	<-done
+	a.record(takeSample())
+}
+
+// Component returns the meter for the named component, creating it on first use.
+func (a *ResourceAccountant) Component(name string) *ComponentMeter {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	meter, ok := a.components[name]
+	if !ok {
+		meter = &ComponentMeter{name: name}
+		a.components[name] = meter
+	}
+	return meter
+}
+
+// Report summarises the samples and component counters collected so far.
+func (a *ResourceAccountant) Report() *ResourceReport {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	report := &ResourceReport{
+		SampleInterval: a.interval,
+		PeakHeapBytes:  a.peak.HeapBytes,
+		PeakRSSBytes:   a.peak.RSSBytes,
+		PeakGoroutines: a.peak.Goroutines,
+	}
+	if a.seen > 0 {
+		report.Samples = make([]ResourceSample, len(a.samples), len(a.samples)+1)
+		copy(report.Samples, a.samples)
+		if last := a.samples[len(a.samples)-1]; !last.At.Equal(a.last.At) {
+			report.Samples = append(report.Samples, a.last)
+		}
+		report.Duration = a.last.At.Sub(a.first.At)
+		report.CPUSeconds = a.last.CPUSeconds - a.first.CPUSeconds
+		report.NetRxBytes = a.last.NetRxBytes - a.first.NetRxBytes
+		report.NetTxBytes = a.last.NetTxBytes - a.first.NetTxBytes
+	}
+
+	var totalBusy time.Duration
+	for _, meter := range a.components {
+		totalBusy += time.Duration(meter.busy.Load())
+	}
+	for _, meter := range a.components {
+		usage := meter.usage()
+		if totalBusy > 0 {
+			usage.CPUSeconds = report.CPUSeconds * float64(usage.BusyTime) / float64(totalBusy)
+		}
+		report.Components = append(report.Components, usage)
+	}
+	sort.Slice(report.Components, func(i, j int) bool {
+		return report.Components[i].Name < report.Components[j].Name
+	})
+	return report
+}
+
+func (a *ResourceAccountant) record(sample ResourceSample) {
+	a.mu.Lock()
+	a.observe(sample)
+	a.mu.Unlock()
+}
+
+// observe folds sample into the running aggregates and the bounded history. The caller holds a.mu.
+func (a *ResourceAccountant) observe(sample ResourceSample) {
+	a.last = sample
+	a.peak.HeapBytes = max(a.peak.HeapBytes, sample.HeapBytes)
+	a.peak.RSSBytes = max(a.peak.RSSBytes, sample.RSSBytes)
+	a.peak.Goroutines = max(a.peak.Goroutines, sample.Goroutines)
+
+	if a.stride == 0 {
+		a.stride = 1
+	}
+	a.seen++
+	if (a.seen-1)%a.stride != 0 {
+		return
+	}
+	if len(a.samples) == maxResourceSamples {
+		// The kept samples are those whose position is a multiple of the stride; doubling it keeps every other.
+		kept := a.samples[:0]
+		for i := 0; i < len(a.samples); i += 2 {
+			kept = append(kept, a.samples[i])
+		}
+		a.samples = kept
+		a.stride *= 2
+	}
+	a.samples = append(a.samples, sample)
+}
+
+// ComponentMeter accumulates counters for a single pipeline component. A nil meter is a no-op.
+type ComponentMeter struct {
+	name     string
+	messages atomic.Int64
+	bytesIn  atomic.Int64
+	bytesOut atomic.Int64
+	busy     atomic.Int64
+}
+
+// Observe records one processed message with its input and output sizes.
+func (m *ComponentMeter) Observe(bytesIn, bytesOut int) {
+	if m == nil {
+		return
+	}
+	m.messages.Add(1)
+	m.bytesIn.Add(int64(bytesIn))
+	m.bytesOut.Add(int64(bytesOut))
+}
+
+// AddOutput records bytes emitted by the component without counting a new message.
+func (m *ComponentMeter) AddOutput(bytesOut int) {
+	if m == nil {
+		return
+	}
+	m.bytesOut.Add(int64(bytesOut))
+}
+
+// Track measures the wall time spent in the component; call the returned func when the work ends.
+func (m *ComponentMeter) Track() func() {
+	if m == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		m.busy.Add(int64(time.Since(start)))
+	}
+}
+
+func (m *ComponentMeter) usage() ComponentUsage {
+	return ComponentUsage{
+		Name:     m.name,
+		Messages: m.messages.Load(),
+		BytesIn:  m.bytesIn.Load(),
+		BytesOut: m.bytesOut.Load(),
+		BusyTime: time.Duration(m.busy.Load()),
+	}
+}
+
+func takeSample() ResourceSample {
+	samples := []metrics.Sample{
+		{Name: metricCPUTotal},
+		{Name: metricCPUIdle},
+		{Name: metricHeapBytes},
+		{Name: metricTotalBytes},
+		{Name: metricGoroutines},
+	}
+	metrics.Read(samples)
+
+	sample := ResourceSample{At: time.Now().UTC()}
+	values := make(map[string]metrics.Value, len(samples))
+	for _, s := range samples {
+		values[s.Name] = s.Value
+	}
+	if v := values[metricCPUTotal]; v.Kind() == metrics.KindFloat64 {
+		sample.CPUSeconds = v.Float64()
+		if idle := values[metricCPUIdle]; idle.Kind() == metrics.KindFloat64 {
+			sample.CPUSeconds -= idle.Float64()
+		}
+	}
+	if v := values[metricHeapBytes]; v.Kind() == metrics.KindUint64 {
+		sample.HeapBytes = v.Uint64()
+	}
+	if v := values[metricTotalBytes]; v.Kind() == metrics.KindUint64 {
+		sample.MemoryBytes = v.Uint64()
+	}
+	if v := values[metricGoroutines]; v.Kind() == metrics.KindUint64 {
+		sample.Goroutines = v.Uint64()
+	}
+
+	if runtime.GOOS == "linux" {
+		sample.RSSBytes = readProcRSS()
+		sample.NetRxBytes, sample.NetTxBytes = readProcNetDev()
+	}
+	return sample
+}
+
+// readProcRSS returns the resident set size from /proc/self/status, or zero when unavailable.
+func readProcRSS() uint64 {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "VmRSS:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return 0
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return kb * 1024
+	}
+	return 0
+}
+
+// readProcNetDev sums received and transmitted bytes across non-loopback interfaces in the process network namespace.
+func readProcNetDev() (uint64, uint64) {
+	f, err := os.Open("/proc/self/net/dev")
+	if err != nil {
+		return 0, 0
+	}
+	defer f.Close()
+
+	var rx, tx uint64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || strings.TrimSpace(name) == "lo" {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 9 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[0], 10, 64); err == nil {
+			rx += v
+		}
+		if v, err := strconv.ParseUint(fields[8], 10, 64); err == nil {
+			tx += v
+		}
+	}
+	return rx, tx
+}
@@ -0,0 +1,117 @@
+package experiment
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResourceAccountantReport(t *testing.T) {
+	acct := NewResourceAccountant(5 * time.Millisecond)
+	acct.Start(context.Background())
+
+	mutation := acct.Component("mutation")
+	done := mutation.Track()
+	time.Sleep(10 * time.Millisecond)
+	done()
+	mutation.Observe(100, 250)
+	mutation.Observe(50, 0)
+
+	encode := acct.Component("encode")
+	encode.Observe(10, 20)
+	encode.AddOutput(5)
+
+	acct.Stop()
+	report := acct.Report()
+
+	if len(report.Samples) < 2 {
+		t.Fatalf("expected at least an initial and final sample, got %d", len(report.Samples))
+	}
+	if report.PeakGoroutines == 0 {
+		t.Fatalf("expected goroutine count to be sampled")
+	}
+	if len(report.Components) != 2 || report.Components[0].Name != "encode" {
+		t.Fatalf("expected components sorted by name, got %+v", report.Components)
+	}
+
+	got := report.Components[1]
+	if got.Messages != 2 || got.BytesIn != 150 || got.BytesOut != 250 {
+		t.Fatalf("unexpected mutation usage %+v", got)
+	}
+	if got.BusyTime < 10*time.Millisecond {
+		t.Fatalf("expected busy time to be tracked, got %v", got.BusyTime)
+	}
+	if report.Components[0].BytesOut != 25 {
+		t.Fatalf("expected AddOutput to count bytes, got %d", report.Components[0].BytesOut)
+	}
+}
+
+func TestResourceHistoryIsBounded(t *testing.T) {
+	acct := NewResourceAccountant(time.Second)
+	start := time.Unix(0, 0).UTC()
+	total := 5*maxResourceSamples + 3
+	for i := 0; i < total; i++ {
+		sample := ResourceSample{At: start.Add(time.Duration(i) * time.Second), CPUSeconds: float64(i), HeapBytes: uint64(i % 700)}
+		acct.record(sample)
+	}
+	acct.first = ResourceSample{At: start}
+	report := acct.Report()
+
+	if len(report.Samples) > maxResourceSamples+1 {
+		t.Fatalf("expected at most %d samples, got %d", maxResourceSamples+1, len(report.Samples))
+	}
+	if !report.Samples[0].At.Equal(start) {
+		t.Fatalf("expected the history to start with the first sample, got %v", report.Samples[0].At)
+	}
+	last := report.Samples[len(report.Samples)-1]
+	if last.CPUSeconds != float64(total-1) || report.Duration != time.Duration(total-1)*time.Second {
+		t.Fatalf("expected the latest sample to end the report, got %+v over %v", last, report.Duration)
+	}
+	step := report.Samples[1].At.Sub(report.Samples[0].At)
+	for i := 2; i < len(report.Samples)-1; i++ {
+		if gap := report.Samples[i].At.Sub(report.Samples[i-1].At); gap != step {
+			t.Fatalf("expected evenly spaced samples, got gaps %v and %v", step, gap)
+		}
+	}
+	if report.PeakHeapBytes != 699 {
+		t.Fatalf("expected the peak to cover thinned samples, got %d", report.PeakHeapBytes)
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	acct := NewResourceAccountant(time.Millisecond)
+	acct.Start(context.Background())
+	acct.Component("proxy.upstream").Observe(1, 1)
+	acct.Stop()
+
+	manifest := NewManifest("test")
+	manifest.SetParameter("action", "double_vote")
+	manifest.Finish(acct)
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err := manifest.WriteFile(path); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	loaded, err := LoadManifest(path)
+	if err != nil {
+		t.Fatalf("load manifest: %v", err)
+	}
+	if loaded.RunID != manifest.RunID || loaded.Parameters["action"] != "double_vote" {
+		t.Fatalf("unexpected manifest %+v", loaded)
+	}
+	if loaded.Resources == nil || len(loaded.Resources.Components) != 1 {
+		t.Fatalf("expected resource report to be persisted")
+	}
+}
+
+func TestNilMeterIsNoop(t *testing.T) {
+	var acct *ResourceAccountant
+	meter := acct.Component("anything")
+	meter.Observe(1, 2)
+	meter.AddOutput(3)
+	meter.Track()()
+	if acct.Report() != nil {
+		t.Fatalf("expected nil report from nil accountant")
+	}
+}
@@ -0,0 +1,253 @@
+package experiment
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Signatures use the minisign format so published datasets can also be checked with the minisign tool
+// (`minisign -Vm <file> -p <key>.pub`).
+const (
+	// SignatureSuffix is appended to a file name to locate its detached signature.
+	SignatureSuffix = ".minisig"
+
+	commentPrefix        = "untrusted comment: "
+	trustedCommentPrefix = "trusted comment: "
+)
+
+var (
+	algEd         = [2]byte{'E', 'd'} // signature over the file contents
+	algEdPrehash  = [2]byte{'E', 'D'} // signature over the BLAKE2b-512 digest of the file
+	checksumBlake = [2]byte{'B', '2'}
+)
+
+// PublicKey verifies dataset signatures.
+type PublicKey struct {
+	KeyID [8]byte
+	Key   ed25519.PublicKey
+}
+
+// SigningKey signs dataset files. It is an ed25519 key with a minisign key ID.
+type SigningKey struct {
+	KeyID [8]byte
+	Key   ed25519.PrivateKey
+}
+
+// GenerateSigningKey creates a new lab key.
+func GenerateSigningKey() (*SigningKey, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	k := &SigningKey{Key: priv}
+	if _, err := rand.Read(k.KeyID[:]); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Public returns the verification key.
+func (k *SigningKey) Public() PublicKey {
+	return PublicKey{KeyID: k.KeyID, Key: k.Key.Public().(ed25519.PublicKey)}
+}
+
+// KeyIDString renders a key ID the way minisign prints it.
+func KeyIDString(id [8]byte) string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(id[:]))
+}
+
+// String encodes the public key in minisign's base64 form.
+func (p PublicKey) String() string {
+	buf := make([]byte, 0, 42)
+	buf = append(buf, algEd[:]...)
+	buf = append(buf, p.KeyID[:]...)
+	buf = append(buf, p.Key...)
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+// WriteFile writes the public key in minisign's .pub format.
+func (p PublicKey) WriteFile(path string) error {
+	content := fmt.Sprintf("%sminisign public key %s\n%s\n", commentPrefix, KeyIDString(p.KeyID), p)
+	return os.WriteFile(path, []byte(content), 0o644)
+}
+
+// ParsePublicKey decodes a base64 minisign public key.
+func ParsePublicKey(encoded string) (PublicKey, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key encoding: %w", err)
+	}
+	if len(data) != 2+8+ed25519.PublicKeySize || !bytes.Equal(data[:2], algEd[:]) {
+		return PublicKey{}, fmt.Errorf("not an ed25519 minisign public key")
+	}
+	var p PublicKey
+	copy(p.KeyID[:], data[2:10])
+	p.Key = ed25519.PublicKey(append([]byte(nil), data[10:]...))
+	return p, nil
+}
+
+// LoadPublicKey reads a minisign .pub file or a file holding only the base64 key.
+func LoadPublicKey(path string) (PublicKey, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	for _, line := range lines {
+		if line != "" && !strings.HasPrefix(line, commentPrefix) {
+			return ParsePublicKey(line)
+		}
+	}
+	return PublicKey{}, fmt.Errorf("%s holds no public key", path)
+}
+
+// WriteFile stores the secret key as an unencrypted minisign secret key (the format of `minisign -G -W`).
+// Keep it out of published datasets.
+func (k *SigningKey) WriteFile(path string) error {
+	buf := make([]byte, 0, 158)
+	buf = append(buf, algEd[:]...)
+	buf = append(buf, 0, 0) // no key derivation: the key is stored unencrypted
+	buf = append(buf, checksumBlake[:]...)
+	buf = append(buf, make([]byte, 32+8+8)...) // unused salt, opslimit, memlimit
+	buf = append(buf, k.KeyID[:]...)
+	buf = append(buf, k.Key...)
+	sum := k.checksum()
+	buf = append(buf, sum[:]...)
+
+	content := fmt.Sprintf("%sminisign secret key %s\n%s\n", commentPrefix, KeyIDString(k.KeyID), base64.StdEncoding.EncodeToString(buf))
+	return os.WriteFile(path, []byte(content), 0o600)
+}
+
+// LoadSigningKey reads an unencrypted minisign secret key. Password-protected keys are rejected because the
+// tools run unattended; create keys with `dataset keygen` or `minisign -G -W`.
+func LoadSigningKey(path string) (*SigningKey, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, err
+	}
+	var encoded string
+	for _, line := range lines {
+		if line != "" && !strings.HasPrefix(line, commentPrefix) {
+			encoded = line
+			break
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) != 158 {
+		return nil, fmt.Errorf("%s is not a minisign secret key", path)
+	}
+	if !bytes.Equal(data[0:2], algEd[:]) || !bytes.Equal(data[4:6], checksumBlake[:]) {
+		return nil, fmt.Errorf("%s uses an unsupported minisign key algorithm", path)
+	}
+	if data[2] != 0 || data[3] != 0 {
+		return nil, fmt.Errorf("%s is password protected; create an unencrypted key with `minisign -G -W`", path)
+	}
+
+	k := &SigningKey{Key: ed25519.PrivateKey(append([]byte(nil), data[62:126]...))}
+	copy(k.KeyID[:], data[54:62])
+	if sum := k.checksum(); !bytes.Equal(sum[:], data[126:158]) {
+		return nil, fmt.Errorf("%s failed its checksum", path)
+	}
+	return k, nil
+}
+
+func (k *SigningKey) checksum() [32]byte {
+	input := make([]byte, 0, 2+8+ed25519.PrivateKeySize)
+	input = append(input, algEd[:]...)
+	input = append(input, k.KeyID[:]...)
+	input = append(input, k.Key...)
+	return blake2b.Sum256(input)
+}
+
+// SignFile writes a detached signature next to path. The trusted comment, which is covered by the
+// signature, records the signing time and file name.
+func (k *SigningKey) SignFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	digest := blake2b.Sum512(data)
+	sig := ed25519.Sign(k.Key, digest[:])
+
+	trusted := fmt.Sprintf("timestamp:%d\tfile:%s\thashed", time.Now().Unix(), filepath.Base(path))
+	global := ed25519.Sign(k.Key, append(append([]byte(nil), sig...), trusted...))
+
+	blob := make([]byte, 0, 74)
+	blob = append(blob, algEdPrehash[:]...)
+	blob = append(blob, k.KeyID[:]...)
+	blob = append(blob, sig...)
+
+	content := fmt.Sprintf("%ssignature from lab key %s\n%s\n%s%s\n%s\n",
+		commentPrefix, KeyIDString(k.KeyID),
+		base64.StdEncoding.EncodeToString(blob),
+		trustedCommentPrefix, trusted,
+		base64.StdEncoding.EncodeToString(global))
+	return os.WriteFile(path+SignatureSuffix, []byte(content), 0o644)
+}
+
+// VerifyFile checks the detached signature of path and returns its trusted comment.
+func (p PublicKey) VerifyFile(path string) (string, error) {
+	lines, err := readLines(path + SignatureSuffix)
+	if err != nil {
+		return "", fmt.Errorf("missing signature: %w", err)
+	}
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return "", fmt.Errorf("%s%s is not a minisign signature", path, SignatureSuffix)
+	}
+	blob, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(blob) != 2+8+ed25519.SignatureSize {
+		return "", fmt.Errorf("malformed signature for %s", path)
+	}
+	if !bytes.Equal(blob[2:10], p.KeyID[:]) {
+		var id [8]byte
+		copy(id[:], blob[2:10])
+		return "", fmt.Errorf("%s was signed by key %s, not %s", path, KeyIDString(id), KeyIDString(p.KeyID))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sig := blob[10:]
+	switch {
+	case bytes.Equal(blob[:2], algEdPrehash[:]):
+		digest := blake2b.Sum512(data)
+		data = digest[:]
+	case !bytes.Equal(blob[:2], algEd[:]):
+		return "", fmt.Errorf("unsupported signature algorithm for %s", path)
+	}
+	if !ed25519.Verify(p.Key, data, sig) {
+		return "", fmt.Errorf("signature verification failed for %s", path)
+	}
+
+	trusted := strings.TrimPrefix(lines[2], trustedCommentPrefix)
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || !ed25519.Verify(p.Key, append(append([]byte(nil), sig...), trusted...), global) {
+		return "", fmt.Errorf("trusted comment verification failed for %s", path)
+	}
+	return trusted, nil
+}
+
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,104 @@
+package experiment
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSignAndVerifyDataset(t *testing.T) {
+	dir := t.TempDir()
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	// Keys survive a round trip through minisign's file formats.
+	keyPath, pubPath := filepath.Join(dir, "lab.key"), filepath.Join(dir, "lab.pub")
+	if err := key.WriteFile(keyPath); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	if err := key.Public().WriteFile(pubPath); err != nil {
+		t.Fatalf("write public key: %v", err)
+	}
+	loaded, err := LoadSigningKey(keyPath)
+	if err != nil {
+		t.Fatalf("load key: %v", err)
+	}
+	pub, err := LoadPublicKey(pubPath)
+	if err != nil {
+		t.Fatalf("load public key: %v", err)
+	}
+
+	segment := filepath.Join(dir, "segments", "0001.json")
+	if err := os.MkdirAll(filepath.Dir(segment), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(segment, []byte(`[{"height":"100"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	manifest := NewManifest("test")
+	if err := manifest.AddArtifact("segments/0001.json", segment); err != nil {
+		t.Fatalf("add artifact: %v", err)
+	}
+	manifestPath := filepath.Join(dir, "manifest.json")
+	if err := manifest.WriteFile(manifestPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SignDataset(loaded, manifestPath); err != nil {
+		t.Fatalf("sign dataset: %v", err)
+	}
+	verified, err := VerifyDataset(pub, manifestPath)
+	if err != nil {
+		t.Fatalf("verify dataset: %v", err)
+	}
+	if len(verified.Artifacts) != 1 || verified.RunID != manifest.RunID {
+		t.Fatalf("unexpected verified manifest %+v", verified)
+	}
+	trusted, err := pub.VerifyFile(segment)
+	if err != nil || !strings.Contains(trusted, "file:0001.json") {
+		t.Fatalf("expected trusted comment naming the file, got %q (%v)", trusted, err)
+	}
+
+	// A different key is rejected.
+	other, _ := GenerateSigningKey()
+	if _, err := VerifyDataset(other.Public(), manifestPath); err == nil {
+		t.Fatalf("expected verification with another key to fail")
+	}
+
+	// Tampering with a segment is caught even when it is re-signed, because the manifest pins its digest.
+	if err := os.WriteFile(segment, []byte(`[{"height":"101"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyDataset(pub, manifestPath); err == nil || !strings.Contains(err.Error(), "digest") {
+		t.Fatalf("expected digest mismatch, got %v", err)
+	}
+	if err := loaded.SignFile(segment); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyDataset(pub, manifestPath); err == nil {
+		t.Fatalf("expected re-signed tampered segment to fail verification")
+	}
+}
+
+func TestVerifyRejectsEditedTrustedComment(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := GenerateSigningKey()
+	path := filepath.Join(dir, "data.json")
+	if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := key.SignFile(path); err != nil {
+		t.Fatal(err)
+	}
+	sig, _ := os.ReadFile(path + SignatureSuffix)
+	edited := strings.Replace(string(sig), "file:data.json", "file:other.json", 1)
+	if err := os.WriteFile(path+SignatureSuffix, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := key.Public().VerifyFile(path); err == nil || !strings.Contains(err.Error(), "trusted comment") {
+		t.Fatalf("expected trusted comment verification failure, got %v", err)
+	}
+}
@@ -0,0 +1,330 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+// HotStuff message types as carried in HotStuffMessage.MessageType
+const (
+	MessageProposal    = "Proposal"    // Leader's block proposal, justified by the highest known QC
+	MessageVote        = "Vote"        // Replica's partial signature for one phase of a block
+	MessagePrepareQC   = "PrepareQC"   // Leader's pre-commit broadcast carrying the prepare QC
+	MessagePreCommitQC = "PreCommitQC" // Leader's commit broadcast carrying the pre-commit QC
+	MessageCommitQC    = "CommitQC"    // Leader's decide broadcast carrying the commit QC
+	MessageNewView     = "NewView"     // Replica's hand-off of its highest QC to the next leader
+)
+
+// HotStuff voting phases as carried in votes and quorum certificates
+const (
+	PhasePrepare   = "prepare"
+	PhasePreCommit = "pre-commit"
+	PhaseCommit    = "commit"
+)
+
+// HotStuffMessage represents a HotStuff/LibraBFT consensus message exchanged between replicas
+type HotStuffMessage struct {
+	MessageType string    `json:"message_type"`
+	View        uint64    `json:"view"`
+	Height      uint64    `json:"height"`
+	BlockHash   string    `json:"block_hash,omitempty"`
+	ParentHash  string    `json:"parent_hash,omitempty"`
+	Sender      string    `json:"sender"`
+	Signature   string    `json:"signature,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+
+	// Vote specific; LibraBFT votes have no phase
+	Phase string `json:"phase,omitempty"`
+
+	// Justify is the QC a proposal extends or a new-view hands over; QC is the certificate a leader broadcasts
+	Justify *QuorumCert `json:"justify,omitempty"`
+	QC      *QuorumCert `json:"qc,omitempty"`
+}
+
+// QuorumCert is a quorum certificate: the votes of 2f+1 replicas for one phase of a block
+type QuorumCert struct {
+	Phase      string   `json:"phase"`
+	View       uint64   `json:"view"`
+	BlockHash  string   `json:"block_hash"`
+	Signers    []string `json:"signers"`
+	Signatures []string `json:"signatures"`
+}
+
+// HotStuffMapper implements the Mapper interface for HotStuff-style chains
+type HotStuffMapper struct {
+	chainID string
+}
+
+// NewHotStuffMapper creates a new HotStuff mapper
+func NewHotStuffMapper(chainID string) *HotStuffMapper {
+	return &HotStuffMapper{
+		chainID: chainID,
+	}
+}
+
+// ToCanonical converts a HotStuff message to canonical format. HotStuff has no rounds, so the canonical
+// message carries its view; a QC broadcast maps to the phase it certifies, with the QC's signatures as its
+// commit seals.
+func (m *HotStuffMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abstraction.CanonicalMessage, error) {
+	if raw.ChainType != abstraction.ChainTypeHotStuff {
+		return nil, abstraction.ErrChainMismatch
+	}
+	if raw.Encoding != "json" {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "encoding",
+			Message: fmt.Sprintf("unsupported encoding: %s", raw.Encoding),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+
+	var hsMsg HotStuffMessage
+	if err := json.Unmarshal(raw.Payload, &hsMsg); err != nil {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "payload",
+			Message: fmt.Sprintf("failed to parse json: %v", err),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+	if hsMsg.MessageType == "" {
+		hsMsg.MessageType = raw.MessageType
+	}
+
+	timestamp := hsMsg.Timestamp
+	if timestamp.IsZero() {
+		timestamp = raw.Timestamp
+	}
+
+	canonical := &abstraction.CanonicalMessage{
+		ChainID:    m.chainID,
+		Height:     new(big.Int).SetUint64(hsMsg.Height),
+		View:       new(big.Int).SetUint64(hsMsg.View),
+		Timestamp:  timestamp,
+		BlockHash:  hsMsg.BlockHash,
+		PrevHash:   hsMsg.ParentHash,
+		Signature:  hsMsg.Signature,
+		RawPayload: raw.Payload,
+		Extensions: map[string]interface{}{
+			"hotstuff_message_type": hsMsg.MessageType,
+		},
+	}
+	if hsMsg.Justify != nil {
+		canonical.Extensions["justify_phase"] = hsMsg.Justify.Phase
+		canonical.Extensions["justify_view"] = hsMsg.Justify.View
+		canonical.Extensions["justify_block_hash"] = hsMsg.Justify.BlockHash
+	}
+
+	switch hsMsg.MessageType {
+	case MessageProposal:
+		canonical.Type = abstraction.MsgTypeProposal
+		canonical.Proposer = hsMsg.Sender
+	case MessageVote:
+		msgType, err := phaseType(hsMsg.Phase)
+		if err != nil {
+			return nil, err
+		}
+		canonical.Type = msgType
+		canonical.Validator = hsMsg.Sender
+		if hsMsg.Phase != "" {
+			canonical.Extensions["phase"] = hsMsg.Phase
+		}
+	case MessagePrepareQC, MessagePreCommitQC, MessageCommitQC:
+		if hsMsg.QC == nil {
+			return nil, &abstraction.MessageValidationError{
+				Field:   "qc",
+				Message: fmt.Sprintf("%s carries no quorum certificate", hsMsg.MessageType),
+				Code:    "MISSING_FIELD",
+			}
+		}
+		canonical.Type = qcTypes[hsMsg.MessageType]
+		canonical.Proposer = hsMsg.Sender
+		if canonical.BlockHash == "" {
+			canonical.BlockHash = hsMsg.QC.BlockHash
+		}
+		canonical.CommitSeals = hsMsg.QC.Signatures
+		canonical.Extensions["phase"] = hsMsg.QC.Phase
+		canonical.Extensions["qc_view"] = hsMsg.QC.View
+		canonical.Extensions["qc_signers"] = hsMsg.QC.Signers
+	case MessageNewView:
+		canonical.Type = abstraction.MsgTypeNewView
+		canonical.Validator = hsMsg.Sender
+	default:
+		return nil, &abstraction.MessageValidationError{
+			Field:   "message_type",
+			Message: fmt.Sprintf("unsupported HotStuff message type: %s", hsMsg.MessageType),
+			Code:    "UNSUPPORTED_TYPE",
+		}
+	}
+
+	return canonical, nil
+}
+
+// FromCanonical converts a canonical message to HotStuff format. Prepare, pre-commit, and commit messages
+// with commit seals become QC broadcasts; without seals they are votes.
+func (m *HotStuffMapper) FromCanonical(msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
+	if msg == nil {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "message",
+			Message: "message cannot be nil",
+			Code:    "MISSING_FIELD",
+		}
+	}
+
+	hsMsg := HotStuffMessage{
+		BlockHash:  msg.BlockHash,
+		ParentHash: msg.PrevHash,
+		Signature:  msg.Signature,
+		Timestamp:  msg.Timestamp,
+	}
+	if msg.Height != nil {
+		hsMsg.Height = msg.Height.Uint64()
+	}
+	// Chains with rounds rather than views map them onto HotStuff views one to one.
+	if msg.View != nil {
+		hsMsg.View = msg.View.Uint64()
+	} else if msg.Round != nil {
+		hsMsg.View = msg.Round.Uint64()
+	}
+
+	switch msg.Type {
+	case abstraction.MsgTypeProposal:
+		hsMsg.MessageType = MessageProposal
+		hsMsg.Sender = msg.Proposer
+	case abstraction.MsgTypePrepare, abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit, abstraction.MsgTypeCommit:
+		phase := phaseOf(msg.Type)
+		if len(msg.CommitSeals) == 0 {
+			hsMsg.MessageType = MessageVote
+			hsMsg.Phase = phase
+			hsMsg.Sender = msg.Validator
+			break
+		}
+		hsMsg.MessageType = qcMessage(phase)
+		hsMsg.Sender = msg.Proposer
+		hsMsg.QC = &QuorumCert{
+			Phase:      phase,
+			View:       hsMsg.View,
+			BlockHash:  msg.BlockHash,
+			Signatures: msg.CommitSeals,
+		}
+		hsMsg.QC.Signers, _ = msg.Extensions.GetStrings("qc_signers")
+		if view, ok := msg.Extensions.GetUint64("qc_view"); ok {
+			hsMsg.QC.View = view
+		}
+	case abstraction.MsgTypeVote:
+		hsMsg.MessageType = MessageVote
+		hsMsg.Sender = msg.Validator
+	case abstraction.MsgTypeNewView, abstraction.MsgTypeViewChange, abstraction.MsgTypeRoundChange:
+		hsMsg.MessageType = MessageNewView
+		hsMsg.Sender = msg.Validator
+	default:
+		return nil, &abstraction.MessageValidationError{
+			Field:   "type",
+			Message: fmt.Sprintf("unsupported canonical message type: %s", msg.Type),
+			Code:    "UNSUPPORTED_TYPE",
+		}
+	}
+
+	if msg.Extensions != nil {
+		if phase, ok := msg.Extensions.GetString("justify_phase"); ok {
+			hsMsg.Justify = &QuorumCert{Phase: phase}
+			if view, ok := msg.Extensions.GetUint64("justify_view"); ok {
+				hsMsg.Justify.View = view
+			}
+			if hash, ok := msg.Extensions.GetString("justify_block_hash"); ok {
+				hsMsg.Justify.BlockHash = hash
+			}
+		}
+	}
+
+	payload, err := json.Marshal(hsMsg)
+	if err != nil {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "payload",
+			Message: fmt.Sprintf("failed to serialize: %v", err),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+
+	return &abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeHotStuff,
+		ChainID:     m.chainID,
+		MessageType: hsMsg.MessageType,
+		Payload:     payload,
+		Encoding:    "json",
+		Timestamp:   abstraction.Now(),
+		Metadata: map[string]interface{}{
+			"view": hsMsg.View,
+		},
+	}, nil
+}
+
+// GetSupportedTypes returns the message types supported by HotStuff
+func (m *HotStuffMapper) GetSupportedTypes() []abstraction.MsgType {
+	return []abstraction.MsgType{
+		abstraction.MsgTypeProposal,  // Proposal
+		abstraction.MsgTypePrepare,   // Vote (prepare) and PrepareQC
+		abstraction.MsgTypePrecommit, // Vote (pre-commit) and PreCommitQC
+		abstraction.MsgTypeCommit,    // Vote (commit) and CommitQC
+		abstraction.MsgTypeVote,      // LibraBFT Vote
+		abstraction.MsgTypeNewView,   // NewView
+	}
+}
+
+// GetChainType returns the chain type this mapper handles
+func (m *HotStuffMapper) GetChainType() abstraction.ChainType {
+	return abstraction.ChainTypeHotStuff
+}
+
+// qcTypes maps QC broadcasts to the canonical type of the phase they certify
+var qcTypes = map[string]abstraction.MsgType{
+	MessagePrepareQC:   abstraction.MsgTypePrepare,
+	MessagePreCommitQC: abstraction.MsgTypePrecommit,
+	MessageCommitQC:    abstraction.MsgTypeCommit,
+}
+
+// phaseType maps a vote phase to its canonical type
+func phaseType(phase string) (abstraction.MsgType, error) {
+	switch phase {
+	case PhasePrepare:
+		return abstraction.MsgTypePrepare, nil
+	case PhasePreCommit:
+		return abstraction.MsgTypePrecommit, nil
+	case PhaseCommit:
+		return abstraction.MsgTypeCommit, nil
+	case "":
+		return abstraction.MsgTypeVote, nil
+	default:
+		return "", &abstraction.MessageValidationError{
+			Field:   "phase",
+			Message: fmt.Sprintf("unknown HotStuff phase: %s", phase),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+}
+
+// phaseOf maps a canonical voting type to its HotStuff phase
+func phaseOf(t abstraction.MsgType) string {
+	switch t {
+	case abstraction.MsgTypePrecommit:
+		return PhasePreCommit
+	case abstraction.MsgTypeCommit:
+		return PhaseCommit
+	default:
+		return PhasePrepare
+	}
+}
+
+// qcMessage returns the QC broadcast for a phase
+func qcMessage(phase string) string {
+	switch phase {
+	case PhasePreCommit:
+		return MessagePreCommitQC
+	case PhaseCommit:
+		return MessageCommitQC
+	default:
+		return MessagePrepareQC
+	}
+}
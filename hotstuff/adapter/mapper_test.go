@@ -0,0 +1,115 @@
+package adapter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/validator"
+)
+
+func hotstuffRaw(t *testing.T, msg HotStuffMessage) abstraction.RawConsensusMessage {
+	t.Helper()
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeHotStuff,
+		ChainID:     "hotstuff",
+		MessageType: msg.MessageType,
+		Payload:     payload,
+		Encoding:    "json",
+		Timestamp:   time.Now(),
+	}
+}
+
+func TestHotStuffToCanonical(t *testing.T) {
+	mapper := NewHotStuffMapper("hotstuff-test")
+	now := time.Now().UTC()
+	qc := &QuorumCert{Phase: PhasePrepare, View: 7, BlockHash: "0xb7", Signers: []string{"r1", "r2", "r3"}, Signatures: []string{"s1", "s2", "s3"}}
+
+	tests := []struct {
+		name      string
+		msg       HotStuffMessage
+		msgType   abstraction.MsgType
+		proposer  string
+		validator string
+		seals     int
+	}{
+		{"proposal", HotStuffMessage{MessageType: MessageProposal, View: 7, Height: 40, BlockHash: "0xb7", ParentHash: "0xb6", Sender: "r1",
+			Justify: &QuorumCert{Phase: PhasePrepare, View: 6, BlockHash: "0xb6"}}, abstraction.MsgTypeProposal, "r1", "", 0},
+		{"prepare vote", HotStuffMessage{MessageType: MessageVote, Phase: PhasePrepare, View: 7, Height: 40, BlockHash: "0xb7", Sender: "r2"},
+			abstraction.MsgTypePrepare, "", "r2", 0},
+		{"pre-commit vote", HotStuffMessage{MessageType: MessageVote, Phase: PhasePreCommit, View: 7, Height: 40, BlockHash: "0xb7", Sender: "r2"},
+			abstraction.MsgTypePrecommit, "", "r2", 0},
+		{"libra vote", HotStuffMessage{MessageType: MessageVote, View: 7, Height: 40, BlockHash: "0xb7", Sender: "r3"},
+			abstraction.MsgTypeVote, "", "r3", 0},
+		{"prepare qc", HotStuffMessage{MessageType: MessagePrepareQC, View: 7, Height: 40, BlockHash: "0xb7", Sender: "r1", QC: qc},
+			abstraction.MsgTypePrepare, "r1", "", 3},
+		{"commit qc", HotStuffMessage{MessageType: MessageCommitQC, View: 7, Height: 40, BlockHash: "0xb7", Sender: "r1",
+			QC: &QuorumCert{Phase: PhaseCommit, View: 7, BlockHash: "0xb7", Signatures: []string{"s1", "s2", "s3"}}},
+			abstraction.MsgTypeCommit, "r1", "", 3},
+		{"new view", HotStuffMessage{MessageType: MessageNewView, View: 8, Height: 40, Sender: "r4", Justify: qc},
+			abstraction.MsgTypeNewView, "", "r4", 0},
+	}
+
+	v := validator.NewValidator(abstraction.ChainTypeHotStuff)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.msg.Timestamp = now
+			canonical, err := mapper.ToCanonical(hotstuffRaw(t, tt.msg))
+			if err != nil {
+				t.Fatalf("ToCanonical: %v", err)
+			}
+			if canonical.Type != tt.msgType || canonical.Proposer != tt.proposer || canonical.Validator != tt.validator ||
+				len(canonical.CommitSeals) != tt.seals {
+				t.Fatalf("unexpected canonical message %+v", canonical)
+			}
+			if canonical.Round != nil || canonical.View.Uint64() != tt.msg.View || canonical.Height.Uint64() != 40 || canonical.BlockHash != tt.msg.BlockHash {
+				t.Fatalf("unexpected view %v, round %v, height %v, or hash %q", canonical.View, canonical.Round, canonical.Height, canonical.BlockHash)
+			}
+			if err := v.Validate(canonical); err != nil {
+				t.Fatalf("validation failed: %v", err)
+			}
+
+			raw, err := mapper.FromCanonical(canonical)
+			if err != nil {
+				t.Fatalf("FromCanonical: %v", err)
+			}
+			var back HotStuffMessage
+			if err := json.Unmarshal(raw.Payload, &back); err != nil {
+				t.Fatalf("decode round trip: %v", err)
+			}
+			back.Timestamp = tt.msg.Timestamp
+			if back.Justify != nil && tt.msg.Justify != nil {
+				// Justify QCs keep their phase, view, and block but not their signatures.
+				back.Justify.Signers, back.Justify.Signatures = tt.msg.Justify.Signers, tt.msg.Justify.Signatures
+			}
+			if !reflect.DeepEqual(back, tt.msg) {
+				t.Fatalf("round trip changed the message:\n got %+v\nwant %+v", back, tt.msg)
+			}
+		})
+	}
+}
+
+func TestHotStuffRejectsMalformed(t *testing.T) {
+	mapper := NewHotStuffMapper("hotstuff-test")
+	for name, msg := range map[string]HotStuffMessage{
+		"qc without certificate": {MessageType: MessagePreCommitQC, View: 1, Height: 1},
+		"unknown phase":          {MessageType: MessageVote, Phase: "decide", View: 1, Height: 1},
+		"unknown type":           {MessageType: "Timeout", View: 1, Height: 1},
+	} {
+		if _, err := mapper.ToCanonical(hotstuffRaw(t, msg)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	raw := hotstuffRaw(t, HotStuffMessage{MessageType: MessageProposal})
+	raw.ChainType = abstraction.ChainTypeFabric
+	if _, err := mapper.ToCanonical(raw); err != abstraction.ErrChainMismatch {
+		t.Fatalf("expected a chain mismatch, got %v", err)
+	}
+}
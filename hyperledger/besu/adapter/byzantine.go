@@ -0,0 +1,36 @@
+package adapter
+
+import (
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+// ByzantineAction describes the manipulation to apply when converting back to a Besu IBFT 2.0/QBFT message.
+type ByzantineAction = byzantine.Action
+
+// ByzantineOptions contains optional overrides for the mutated messages.
+type ByzantineOptions = byzantine.Options
+
+// ByzantineEngine is the set of actions supported for Besu. The encoded message carries neither a timestamp
+// nor the validator, whose address is recovered from the signature, and BesuMapper writes placeholder
+// signatures, so timestamp_skew, alter_validator, and drop_signature would leave the payload unchanged and are
+// left out.
+var ByzantineEngine = newByzantineEngine()
+
+func newByzantineEngine() *byzantine.Engine {
+	e := byzantine.NewEngine()
+	e.Unregister(byzantine.ActionTimestampSkew)
+	e.Unregister(byzantine.ActionAlterValidator)
+	e.Unregister(byzantine.ActionDropSignature)
+	return e
+}
+
+// ParseByzantineAction converts a CLI string to the typed action.
+func ParseByzantineAction(value string) (ByzantineAction, error) {
+	return ByzantineEngine.Parse(value)
+}
+
+// FromCanonicalByzantine converts a canonical message back to Besu format while applying a byzantine action.
+func (m *BesuMapper) FromCanonicalByzantine(msg *abstraction.CanonicalMessage, action ByzantineAction, opts ByzantineOptions) ([]*abstraction.RawConsensusMessage, error) {
+	return ByzantineEngine.ApplyAndEncode(m, msg, action, opts)
+}
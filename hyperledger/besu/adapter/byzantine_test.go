@@ -0,0 +1,45 @@
+package adapter
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+func TestFromCanonicalByzantine(t *testing.T) {
+	mapper := NewBesuMapper("besu-test")
+	commit := &abstraction.CanonicalMessage{
+		ChainID:   "besu-test",
+		Height:    big.NewInt(42),
+		Round:     big.NewInt(1),
+		Timestamp: time.Unix(1700005000, 0).UTC(),
+		Type:      abstraction.MsgTypeCommit,
+		BlockHash: "0x1111111111111111111111111111111111111111111111111111111111111111",
+		Validator: "0x2222222222222222222222222222222222222222",
+	}
+
+	raws, err := mapper.FromCanonicalByzantine(commit, byzantine.ActionDoubleVote, ByzantineOptions{})
+	if err != nil {
+		t.Fatalf("double_vote: %v", err)
+	}
+	if len(raws) != 2 || bytes.Equal(raws[0].Payload, raws[1].Payload) {
+		t.Fatalf("expected two commits with different payloads, got %d", len(raws))
+	}
+	forged, err := mapper.ToCanonical(*raws[1])
+	if err != nil {
+		t.Fatalf("decode forged commit: %v", err)
+	}
+	if forged.Height.Int64() != 42 || forged.BlockHash == commit.BlockHash {
+		t.Fatalf("expected a commit for another block at height 42, got %+v", forged)
+	}
+
+	for _, action := range []string{"timestamp_skew", "alter_validator", "drop_signature"} {
+		if _, err := ParseByzantineAction(action); err == nil {
+			t.Fatalf("expected %s to be unsupported", action)
+		}
+	}
+}
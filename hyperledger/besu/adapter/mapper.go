@@ -163,19 +163,19 @@ func (m *BesuMapper) FromCanonical(canonical *abstraction.CanonicalMessage) (*ab
 	consensusType := "IBFT2.0"
 
 	if canonical.Extensions != nil {
-		if gl, ok := canonical.Extensions["gas_limit"].(uint64); ok {
+		if gl, ok := canonical.Extensions.GetUint64("gas_limit"); ok {
 			gasLimit = gl
 		}
-		if gu, ok := canonical.Extensions["gas_used"].(uint64); ok {
+		if gu, ok := canonical.Extensions.GetUint64("gas_used"); ok {
 			gasUsed = gu
 		}
-		if tc, ok := canonical.Extensions["tx_count"].(int); ok {
-			txCount = tc
+		if tc, ok := canonical.Extensions.GetInt64("tx_count"); ok {
+			txCount = int(tc)
 		}
-		if vc, ok := canonical.Extensions["validator_count"].(int); ok {
-			validatorCount = vc
+		if vc, ok := canonical.Extensions.GetInt64("validator_count"); ok {
+			validatorCount = int(vc)
 		}
-		if ct, ok := canonical.Extensions["consensus_type"].(string); ok {
+		if ct, ok := canonical.Extensions.GetString("consensus_type"); ok {
 			consensusType = ct
 		}
 	}
@@ -0,0 +1,113 @@
+package adapter
+
+import (
+	"fmt"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+// ByzantineAction describes the manipulation to apply when converting back to a Fabric orderer message.
+type ByzantineAction = byzantine.Action
+
+// ByzantineOptions contains optional overrides for the mutated messages.
+type ByzantineOptions = byzantine.Options
+
+const (
+	// ByzantineActionNone returns the canonical message without any manipulation.
+	ByzantineActionNone = byzantine.ActionNone
+	// ByzantineActionDoubleVote emits two Prepare or Commit messages for conflicting digests.
+	ByzantineActionDoubleVote = byzantine.ActionDoubleVote
+	// ByzantineActionDoubleProposal emits two PrePrepare messages on the same channel that reference different blocks.
+	ByzantineActionDoubleProposal = byzantine.ActionDoubleProposal
+	// ByzantineActionDropConfigSeq strips the config sequence so the message is verified against a stale channel config.
+	ByzantineActionDropConfigSeq ByzantineAction = "drop_config_seq"
+	// ByzantineActionForgeIdentity rewrites the signing orderer identity, including its MSP ID.
+	ByzantineActionForgeIdentity ByzantineAction = "forge_identity"
+	// ByzantineActionDropSignature removes the signature from the message.
+	ByzantineActionDropSignature = byzantine.ActionDropSignature
+	// ByzantineActionTimestampSkew applies a timestamp shift to the message.
+	ByzantineActionTimestampSkew = byzantine.ActionTimestampSkew
+	// ByzantineActionFuzzPayload damages the encoded message to fuzz orderer decoders.
+	ByzantineActionFuzzPayload = byzantine.ActionFuzzPayload
+)
+
+// ByzantineEngine is the set of actions supported for Fabric orderers.
+var ByzantineEngine = newByzantineEngine()
+
+func newByzantineEngine() *byzantine.Engine {
+	e := byzantine.NewEngine()
+	e.Register(ByzantineActionDoubleProposal, applyDoubleProposalMutation)
+	e.Register(ByzantineActionDropConfigSeq, applyDropConfigSeqMutation)
+	e.Register(ByzantineActionForgeIdentity, applyForgeIdentityMutation)
+	// Fabric identities carry an MSP ID, so the generic rewrite is replaced by forge_identity.
+	e.Unregister(byzantine.ActionAlterValidator)
+	e.Alias(string(byzantine.ActionAlterValidator), ByzantineActionForgeIdentity)
+	return e
+}
+
+// ParseByzantineAction converts a CLI string to the typed action.
+func ParseByzantineAction(value string) (ByzantineAction, error) {
+	return ByzantineEngine.Parse(value)
+}
+
+// ApplyByzantineCanonical mutates a canonical Fabric message according to the requested action.
+// It returns the set of canonical messages that should subsequently be encoded.
+func ApplyByzantineCanonical(msg *abstraction.CanonicalMessage, action ByzantineAction, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
+	return ByzantineEngine.Apply(msg, action, opts)
+}
+
+// FromCanonicalByzantine converts a canonical message back to Fabric format while applying a byzantine action.
+func (m *FabricMapper) FromCanonicalByzantine(msg *abstraction.CanonicalMessage, action ByzantineAction, opts ByzantineOptions) ([]*abstraction.RawConsensusMessage, error) {
+	return ByzantineEngine.ApplyAndEncode(m, msg, action, opts)
+}
+
+func applyDoubleProposalMutation(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
+	if channel, _ := msg.Extensions.GetString("channel_id"); channel == "" && msg.Type == abstraction.MsgTypeProposal {
+		return nil, fmt.Errorf("double_proposal action requires a channel_id extension")
+	}
+	return byzantine.DoubleProposal(msg, opts)
+}
+
+func applyDropConfigSeqMutation(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
+	mutated := byzantine.Clone(msg)
+	if mutated.Extensions != nil {
+		delete(mutated.Extensions, "config_seq")
+	}
+
+	byzantine.ApplyCommon(mutated, opts)
+	byzantine.EnsureTimestampProgress(mutated, msg.Timestamp)
+
+	return []*abstraction.CanonicalMessage{mutated}, nil
+}
+
+func applyForgeIdentityMutation(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
+	if opts.AlternateValidator == "" {
+		return nil, fmt.Errorf("forge_identity action requires AlternateValidator to be set")
+	}
+	identity, err := ParseOrdererIdentity(opts.AlternateValidator)
+	if err != nil {
+		return nil, err
+	}
+
+	mutated := byzantine.Clone(msg)
+	if msg.Type == abstraction.MsgTypeProposal {
+		mutated.Proposer = opts.AlternateValidator
+	} else {
+		mutated.Validator = opts.AlternateValidator
+	}
+	if identity.MSPID != "" {
+		if mutated.Extensions == nil {
+			mutated.Extensions = make(map[string]interface{})
+		}
+		mutated.Extensions["msp_id"] = identity.MSPID
+	}
+	if opts.AlternateSignature != "" {
+		mutated.Signature = opts.AlternateSignature
+	}
+
+	byzantine.ApplyCommon(mutated, opts)
+	byzantine.EnsureTimestampProgress(mutated, msg.Timestamp)
+
+	return []*abstraction.CanonicalMessage{mutated}, nil
+}
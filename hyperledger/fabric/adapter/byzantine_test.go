@@ -0,0 +1,188 @@
+package adapter
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+func TestApplyByzantineCanonical(t *testing.T) {
+	proposalTimestamp := time.Unix(1700005000, 0).UTC()
+
+	proposalCanonical := &abstraction.CanonicalMessage{
+		ChainID:   "fabric-test",
+		Height:    big.NewInt(42),
+		View:      big.NewInt(3),
+		Timestamp: proposalTimestamp,
+		Type:      abstraction.MsgTypeProposal,
+		BlockHash: "1111111111111111111111111111111111111111111111111111111111111111",
+		PrevHash:  "2222222222222222222222222222222222222222222222222222222222222222",
+		Proposer:  "OrdererMSP/1",
+		Signature: "sig-1",
+		Extensions: map[string]interface{}{
+			"channel_id": "mychannel",
+			"config_seq": uint64(7),
+			"msp_id":     "OrdererMSP",
+		},
+	}
+
+	commitCanonical := &abstraction.CanonicalMessage{
+		ChainID:   "fabric-test",
+		Height:    big.NewInt(42),
+		View:      big.NewInt(3),
+		Timestamp: proposalTimestamp,
+		Type:      abstraction.MsgTypeCommit,
+		BlockHash: "1111111111111111111111111111111111111111111111111111111111111111",
+		Validator: "OrdererMSP/2",
+		Signature: "sig-2",
+		Extensions: map[string]interface{}{
+			"channel_id": "mychannel",
+			"config_seq": uint64(7),
+			"msp_id":     "OrdererMSP",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		msg     *abstraction.CanonicalMessage
+		action  ByzantineAction
+		opts    ByzantineOptions
+		wantLen int
+		wantErr bool
+		assert  func(t *testing.T, canonicals []*abstraction.CanonicalMessage)
+	}{
+		{
+			name:    "double proposal keeps channel",
+			msg:     proposalCanonical,
+			action:  ByzantineActionDoubleProposal,
+			opts:    ByzantineOptions{RoundOffset: 1},
+			wantLen: 2,
+			assert: func(t *testing.T, canonicals []*abstraction.CanonicalMessage) {
+				mutated := canonicals[1]
+				if mutated.BlockHash == proposalCanonical.BlockHash {
+					t.Fatalf("expected conflicting block hash")
+				}
+				if mutated.Extensions["channel_id"] != "mychannel" {
+					t.Fatalf("expected channel to be preserved, got %v", mutated.Extensions["channel_id"])
+				}
+				if mutated.View.Cmp(big.NewInt(4)) != 0 {
+					t.Fatalf("expected view 4, got %v", mutated.View)
+				}
+			},
+		},
+		{
+			name:    "double proposal requires proposal",
+			msg:     commitCanonical,
+			action:  ByzantineActionDoubleProposal,
+			wantErr: true,
+		},
+		{
+			name:    "drop config seq",
+			msg:     commitCanonical,
+			action:  ByzantineActionDropConfigSeq,
+			wantLen: 1,
+			assert: func(t *testing.T, canonicals []*abstraction.CanonicalMessage) {
+				if _, ok := canonicals[0].Extensions["config_seq"]; ok {
+					t.Fatalf("expected config_seq to be removed")
+				}
+				if _, ok := commitCanonical.Extensions["config_seq"]; !ok {
+					t.Fatalf("original message must not be modified")
+				}
+			},
+		},
+		{
+			name:    "forge identity rewrites msp",
+			msg:     commitCanonical,
+			action:  ByzantineActionForgeIdentity,
+			opts:    ByzantineOptions{AlternateValidator: "EvilOrdererMSP/9"},
+			wantLen: 1,
+			assert: func(t *testing.T, canonicals []*abstraction.CanonicalMessage) {
+				if canonicals[0].Validator != "EvilOrdererMSP/9" {
+					t.Fatalf("expected forged validator, got %s", canonicals[0].Validator)
+				}
+				if canonicals[0].Extensions["msp_id"] != "EvilOrdererMSP" {
+					t.Fatalf("expected forged msp id, got %v", canonicals[0].Extensions["msp_id"])
+				}
+			},
+		},
+		{
+			name:    "forge identity rejects malformed identity",
+			msg:     commitCanonical,
+			action:  ByzantineActionForgeIdentity,
+			opts:    ByzantineOptions{AlternateValidator: "not-an-identity"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canonicals, err := ApplyByzantineCanonical(tt.msg, tt.action, tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(canonicals) != tt.wantLen {
+				t.Fatalf("expected %d messages, got %d", tt.wantLen, len(canonicals))
+			}
+			if tt.assert != nil {
+				tt.assert(t, canonicals)
+			}
+		})
+	}
+}
+
+func TestFromCanonicalByzantineDropConfigSeq(t *testing.T) {
+	mapper := NewFabricMapper("fabric-test")
+	payload, err := json.Marshal(FabricConsensusMessage{
+		MessageType: "Commit",
+		ChannelID:   "mychannel",
+		View:        2,
+		Seq:         10,
+		Digest:      "abcd",
+		ConfigSeq:   5,
+		Signer:      OrdererIdentity{MSPID: "OrdererMSP", ID: 3},
+		Signature:   "sig",
+		Timestamp:   time.Unix(1700000000, 0).UTC(),
+	})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	canonical, err := mapper.ToCanonical(abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeFabric,
+		ChainID:     "fabric-test",
+		MessageType: "Commit",
+		Payload:     payload,
+		Encoding:    "json",
+	})
+	if err != nil {
+		t.Fatalf("to canonical: %v", err)
+	}
+	if canonical.Validator != "OrdererMSP/3" {
+		t.Fatalf("unexpected validator %s", canonical.Validator)
+	}
+
+	raws, err := mapper.FromCanonicalByzantine(canonical, ByzantineActionDropConfigSeq, ByzantineOptions{})
+	if err != nil {
+		t.Fatalf("from canonical byzantine: %v", err)
+	}
+
+	var forged FabricConsensusMessage
+	if err := json.Unmarshal(raws[0].Payload, &forged); err != nil {
+		t.Fatalf("unmarshal forged: %v", err)
+	}
+	if forged.ConfigSeq != 0 {
+		t.Fatalf("expected config seq to be dropped, got %d", forged.ConfigSeq)
+	}
+	if forged.ChannelID != "mychannel" || forged.Signer.ID != 3 || forged.Seq != 10 {
+		t.Fatalf("unexpected forged message %+v", forged)
+	}
+}
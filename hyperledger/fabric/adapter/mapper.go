@@ -0,0 +1,274 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+// FabricMapper implements the Mapper interface for Hyperledger Fabric SmartBFT ordering service messages.
+// Crash-fault-tolerant etcdraft orderers are mapped by FabricRaftMapper instead.
+type FabricMapper struct {
+	chainID string
+}
+
+// NewFabricMapper creates a new Fabric mapper
+func NewFabricMapper(chainID string) *FabricMapper {
+	return &FabricMapper{
+		chainID: chainID,
+	}
+}
+
+// ToCanonical converts a Fabric BFT orderer message to canonical format
+func (m *FabricMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abstraction.CanonicalMessage, error) {
+	if raw.ChainType != abstraction.ChainTypeFabric {
+		return nil, abstraction.ErrChainMismatch
+	}
+
+	var fabricMsg FabricConsensusMessage
+	switch raw.Encoding {
+	case "json", "proto":
+		// Orderer protobufs are relayed as their JSON projection until a proto codec is wired in
+		if err := json.Unmarshal(raw.Payload, &fabricMsg); err != nil {
+			return nil, &abstraction.MessageValidationError{
+				Field:   "payload",
+				Message: fmt.Sprintf("failed to parse %s: %v", raw.Encoding, err),
+				Code:    "DECODE_FAILURE",
+			}
+		}
+	default:
+		return nil, &abstraction.MessageValidationError{
+			Field:   "encoding",
+			Message: fmt.Sprintf("unsupported encoding: %s", raw.Encoding),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+	if fabricMsg.MessageType == "" {
+		fabricMsg.MessageType = raw.MessageType
+	}
+
+	timestamp := fabricMsg.Timestamp
+	if timestamp.IsZero() {
+		timestamp = raw.Timestamp
+	}
+
+	canonical := &abstraction.CanonicalMessage{
+		ChainID:    m.chainID,
+		Height:     new(big.Int).SetUint64(fabricMsg.Seq),
+		View:       new(big.Int).SetUint64(fabricMsg.View),
+		Timestamp:  timestamp,
+		Type:       m.mapMessageType(fabricMsg.MessageType),
+		BlockHash:  fabricMsg.Digest,
+		PrevHash:   fabricMsg.PrevHash,
+		Signature:  fabricMsg.Signature,
+		RawPayload: raw.Payload,
+		Extensions: map[string]interface{}{
+			"fabric_message_type": fabricMsg.MessageType,
+			"channel_id":          fabricMsg.ChannelID,
+			"config_seq":          fabricMsg.ConfigSeq,
+			"msp_id":              fabricMsg.Signer.MSPID,
+		},
+	}
+
+	identity := FormatOrdererIdentity(fabricMsg.Signer)
+	switch fabricMsg.MessageType {
+	case "PrePrepare":
+		canonical.Proposer = identity
+	case "ViewChange":
+		canonical.Validator = identity
+		canonical.View = new(big.Int).SetUint64(fabricMsg.NextView)
+		canonical.Extensions["current_view"] = fabricMsg.View
+		canonical.Extensions["reason"] = fabricMsg.Reason
+	default:
+		canonical.Validator = identity
+	}
+
+	return canonical, nil
+}
+
+// FromCanonical converts a canonical message to Fabric format
+func (m *FabricMapper) FromCanonical(msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
+	if msg == nil {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "message",
+			Message: "message cannot be nil",
+			Code:    "MISSING_FIELD",
+		}
+	}
+
+	fabricMsg := FabricConsensusMessage{
+		MessageType: m.mapToFabricType(msg.Type),
+		Digest:      msg.BlockHash,
+		PrevHash:    msg.PrevHash,
+		Signature:   msg.Signature,
+		Timestamp:   msg.Timestamp,
+	}
+	if msg.Height != nil {
+		fabricMsg.Seq = msg.Height.Uint64()
+	}
+	if msg.View != nil {
+		fabricMsg.View = msg.View.Uint64()
+	}
+
+	identity := msg.Validator
+	if fabricMsg.MessageType == "PrePrepare" {
+		identity = msg.Proposer
+	}
+	signer, err := ParseOrdererIdentity(identity)
+	if err != nil {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "validator",
+			Message: err.Error(),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+	fabricMsg.Signer = signer
+
+	if channel, ok := msg.Extensions.GetString("channel_id"); ok {
+		fabricMsg.ChannelID = channel
+	}
+	if seq, ok := msg.Extensions.GetUint64("config_seq"); ok {
+		fabricMsg.ConfigSeq = seq
+	}
+	if mspID, ok := msg.Extensions.GetString("msp_id"); ok && mspID != "" {
+		fabricMsg.Signer.MSPID = mspID
+	}
+	if fabricMsg.MessageType == "ViewChange" {
+		fabricMsg.NextView = fabricMsg.View
+		if current, ok := msg.Extensions.GetUint64("current_view"); ok {
+			fabricMsg.View = current
+		}
+		if reason, ok := msg.Extensions.GetString("reason"); ok {
+			fabricMsg.Reason = reason
+		}
+	}
+
+	payload, err := json.Marshal(fabricMsg)
+	if err != nil {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "payload",
+			Message: fmt.Sprintf("failed to serialize: %v", err),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+
+	return &abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeFabric,
+		ChainID:     m.chainID,
+		MessageType: fabricMsg.MessageType,
+		Payload:     payload,
+		Encoding:    "json",
+		Timestamp:   abstraction.Now(),
+		Metadata: map[string]interface{}{
+			"channel_id": fabricMsg.ChannelID,
+			"config_seq": fabricMsg.ConfigSeq,
+		},
+	}, nil
+}
+
+// GetSupportedTypes returns the message types supported by the Fabric BFT orderer
+func (m *FabricMapper) GetSupportedTypes() []abstraction.MsgType {
+	return []abstraction.MsgType{
+		abstraction.MsgTypeProposal,   // PrePrepare
+		abstraction.MsgTypePrepare,    // Prepare
+		abstraction.MsgTypeCommit,     // Commit
+		abstraction.MsgTypeViewChange, // ViewChange
+		abstraction.MsgTypeNewView,    // NewView
+	}
+}
+
+// GetChainType returns the chain type this mapper handles
+func (m *FabricMapper) GetChainType() abstraction.ChainType {
+	return abstraction.ChainTypeFabric
+}
+
+// mapMessageType maps SmartBFT message types to canonical types
+func (m *FabricMapper) mapMessageType(fabricType string) abstraction.MsgType {
+	switch fabricType {
+	case "PrePrepare":
+		return abstraction.MsgTypeProposal
+	case "Prepare":
+		return abstraction.MsgTypePrepare
+	case "Commit":
+		return abstraction.MsgTypeCommit
+	case "ViewChange":
+		return abstraction.MsgTypeViewChange
+	case "NewView":
+		return abstraction.MsgTypeNewView
+	default:
+		return abstraction.MsgType(fabricType)
+	}
+}
+
+// mapToFabricType maps canonical message types to SmartBFT types
+func (m *FabricMapper) mapToFabricType(canonicalType abstraction.MsgType) string {
+	switch canonicalType {
+	case abstraction.MsgTypeProposal:
+		return "PrePrepare"
+	case abstraction.MsgTypePrepare, abstraction.MsgTypePrevote, abstraction.MsgTypeVote:
+		return "Prepare"
+	case abstraction.MsgTypeCommit, abstraction.MsgTypePrecommit:
+		return "Commit"
+	case abstraction.MsgTypeViewChange:
+		return "ViewChange"
+	case abstraction.MsgTypeNewView:
+		return "NewView"
+	default:
+		return string(canonicalType)
+	}
+}
+
+// FabricConsensusMessage represents a SmartBFT consensus message exchanged between orderers
+type FabricConsensusMessage struct {
+	MessageType string          `json:"message_type"`
+	ChannelID   string          `json:"channel_id"`
+	View        uint64          `json:"view"`
+	Seq         uint64          `json:"seq"`
+	Digest      string          `json:"digest,omitempty"`
+	PrevHash    string          `json:"prev_hash,omitempty"`
+	ConfigSeq   uint64          `json:"config_seq"` // Verification sequence of the channel config
+	Signer      OrdererIdentity `json:"signer"`
+	Signature   string          `json:"signature,omitempty"`
+	Timestamp   time.Time       `json:"timestamp"`
+
+	// ViewChange specific
+	NextView uint64 `json:"next_view,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// OrdererIdentity identifies the orderer node that signed a message
+type OrdererIdentity struct {
+	MSPID string `json:"msp_id"`
+	ID    uint64 `json:"id"`
+}
+
+// FormatOrdererIdentity renders an orderer identity as "<msp_id>/<id>"
+func FormatOrdererIdentity(identity OrdererIdentity) string {
+	if identity.MSPID == "" {
+		return strconv.FormatUint(identity.ID, 10)
+	}
+	return fmt.Sprintf("%s/%d", identity.MSPID, identity.ID)
+}
+
+// ParseOrdererIdentity parses an identity produced by FormatOrdererIdentity
+func ParseOrdererIdentity(value string) (OrdererIdentity, error) {
+	if value == "" {
+		return OrdererIdentity{}, nil
+	}
+	mspID := ""
+	idPart := value
+	if idx := strings.LastIndex(value, "/"); idx >= 0 {
+		mspID = value[:idx]
+		idPart = value[idx+1:]
+	}
+	id, err := strconv.ParseUint(idPart, 10, 64)
+	if err != nil {
+		return OrdererIdentity{}, fmt.Errorf("invalid orderer identity %q: expected <msp_id>/<id>", value)
+	}
+	return OrdererIdentity{MSPID: mspID, ID: id}, nil
+}
@@ -0,0 +1,361 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+// etcd/raft message types relayed between Fabric etcdraft orderers
+const (
+	RaftMsgApp         = "MsgApp"         // AppendEntries from the leader
+	RaftMsgAppResp     = "MsgAppResp"     // AppendEntries acknowledgement
+	RaftMsgVote        = "MsgVote"        // RequestVote from a candidate
+	RaftMsgVoteResp    = "MsgVoteResp"    // RequestVote answer
+	RaftMsgPreVote     = "MsgPreVote"     // RequestVote of the pre-vote phase, which does not bump the term
+	RaftMsgPreVoteResp = "MsgPreVoteResp" // Pre-vote answer
+	RaftMsgSnap        = "MsgSnap"        // InstallSnapshot from the leader
+)
+
+// FabricRaftMessage is the JSON projection of a raftpb.Message wrapped in an orderer ConsensusRequest.
+// Consenters are identified by their Raft IDs.
+type FabricRaftMessage struct {
+	MessageType string      `json:"message_type"`
+	ChannelID   string      `json:"channel_id"`
+	From        uint64      `json:"from"`
+	To          uint64      `json:"to"`
+	Term        uint64      `json:"term"`
+	LogTerm     uint64      `json:"log_term"`
+	Index       uint64      `json:"index"`
+	Commit      uint64      `json:"commit"`
+	Entries     []RaftEntry `json:"entries,omitempty"`
+	Snapshot    *RaftSnap   `json:"snapshot,omitempty"`
+	Reject      bool        `json:"reject,omitempty"`
+	RejectHint  uint64      `json:"reject_hint,omitempty"`
+	Timestamp   time.Time   `json:"timestamp"`
+}
+
+// RaftEntry is a log entry; DataHash identifies the block the entry carries
+type RaftEntry struct {
+	Term     uint64 `json:"term"`
+	Index    uint64 `json:"index"`
+	DataHash string `json:"data_hash,omitempty"`
+}
+
+// RaftSnap is the metadata of a snapshot; DataHash identifies the block it was taken at
+type RaftSnap struct {
+	Index    uint64 `json:"index"`
+	Term     uint64 `json:"term"`
+	DataHash string `json:"data_hash,omitempty"`
+}
+
+// FabricRaftMapper implements the Mapper interface for Fabric's crash-fault-tolerant etcdraft orderers. The
+// Raft term becomes the canonical view and the log index the height. AppendEntries maps to a proposal and its
+// acknowledgement to a commit, RequestVote to a view change and its answer to a vote, and InstallSnapshot to
+// a block.
+type FabricRaftMapper struct {
+	chainID string
+}
+
+// NewFabricRaftMapper creates a new Fabric Raft mapper
+func NewFabricRaftMapper(chainID string) *FabricRaftMapper {
+	return &FabricRaftMapper{
+		chainID: chainID,
+	}
+}
+
+// ToCanonical converts an etcdraft orderer message to canonical format
+func (m *FabricRaftMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abstraction.CanonicalMessage, error) {
+	if raw.ChainType != abstraction.ChainTypeFabricRaft {
+		return nil, abstraction.ErrChainMismatch
+	}
+
+	var raftMsg FabricRaftMessage
+	switch raw.Encoding {
+	case "json", "proto":
+		if err := json.Unmarshal(raw.Payload, &raftMsg); err != nil {
+			return nil, &abstraction.MessageValidationError{
+				Field:   "payload",
+				Message: fmt.Sprintf("failed to parse %s: %v", raw.Encoding, err),
+				Code:    "DECODE_FAILURE",
+			}
+		}
+	default:
+		return nil, &abstraction.MessageValidationError{
+			Field:   "encoding",
+			Message: fmt.Sprintf("unsupported encoding: %s", raw.Encoding),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+	if raftMsg.MessageType == "" {
+		raftMsg.MessageType = raw.MessageType
+	}
+
+	timestamp := raftMsg.Timestamp
+	if timestamp.IsZero() {
+		timestamp = raw.Timestamp
+	}
+
+	sender := strconv.FormatUint(raftMsg.From, 10)
+	height := raftMsg.Index
+	canonical := &abstraction.CanonicalMessage{
+		ChainID:    m.chainID,
+		View:       new(big.Int).SetUint64(raftMsg.Term),
+		Timestamp:  timestamp,
+		RawPayload: raw.Payload,
+		Extensions: map[string]interface{}{
+			"raft_message_type": raftMsg.MessageType,
+			"channel_id":        raftMsg.ChannelID,
+			"to":                raftMsg.To,
+			"log_term":          raftMsg.LogTerm,
+			"commit":            raftMsg.Commit,
+		},
+	}
+
+	switch raftMsg.MessageType {
+	case RaftMsgApp:
+		canonical.Type = abstraction.MsgTypeProposal
+		canonical.Proposer = sender
+		// Index is the entry preceding the ones sent; the message proposes up to its last entry.
+		height += uint64(len(raftMsg.Entries))
+		if n := len(raftMsg.Entries); n > 0 {
+			canonical.BlockHash = raftMsg.Entries[n-1].DataHash
+		}
+		canonical.Extensions["prev_index"] = raftMsg.Index
+		canonical.Extensions["entries"] = len(raftMsg.Entries)
+	case RaftMsgAppResp:
+		canonical.Type = abstraction.MsgTypeCommit
+		canonical.Validator = sender
+		canonical.Extensions["reject"] = raftMsg.Reject
+		canonical.Extensions["reject_hint"] = raftMsg.RejectHint
+	case RaftMsgVote, RaftMsgPreVote:
+		canonical.Type = abstraction.MsgTypeViewChange
+		canonical.Validator = sender
+		canonical.Extensions["pre_vote"] = raftMsg.MessageType == RaftMsgPreVote
+	case RaftMsgVoteResp, RaftMsgPreVoteResp:
+		canonical.Type = abstraction.MsgTypeVote
+		canonical.Validator = sender
+		canonical.Extensions["reject"] = raftMsg.Reject
+		canonical.Extensions["pre_vote"] = raftMsg.MessageType == RaftMsgPreVoteResp
+	case RaftMsgSnap:
+		if raftMsg.Snapshot == nil {
+			return nil, &abstraction.MessageValidationError{
+				Field:   "snapshot",
+				Message: "MsgSnap carries no snapshot",
+				Code:    "MISSING_FIELD",
+			}
+		}
+		canonical.Type = abstraction.MsgTypeBlock
+		canonical.Proposer = sender
+		canonical.BlockHash = raftMsg.Snapshot.DataHash
+		height = raftMsg.Snapshot.Index
+		canonical.Extensions["snapshot_term"] = raftMsg.Snapshot.Term
+	default:
+		return nil, &abstraction.MessageValidationError{
+			Field:   "message_type",
+			Message: fmt.Sprintf("unsupported Raft message type: %s", raftMsg.MessageType),
+			Code:    "UNSUPPORTED_TYPE",
+		}
+	}
+	canonical.Height = new(big.Int).SetUint64(height)
+
+	return canonical, nil
+}
+
+// FromCanonical converts a canonical message to an etcdraft message. A proposal is sent as the number of
+// entries it had, all in its term, with the block hash on the last one.
+func (m *FabricRaftMapper) FromCanonical(msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
+	if msg == nil {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "message",
+			Message: "message cannot be nil",
+			Code:    "MISSING_FIELD",
+		}
+	}
+
+	raftMsg := FabricRaftMessage{Timestamp: msg.Timestamp}
+	if msg.View != nil {
+		raftMsg.Term = msg.View.Uint64()
+	} else if msg.Round != nil {
+		raftMsg.Term = msg.Round.Uint64()
+	}
+	var height uint64
+	if msg.Height != nil {
+		height = msg.Height.Uint64()
+	}
+	raftMsg.Index = height
+	preVote, _ := msg.Extensions.GetBool("pre_vote")
+	raftMsg.Reject, _ = msg.Extensions.GetBool("reject")
+
+	sender := msg.Validator
+	switch msg.Type {
+	case abstraction.MsgTypeProposal:
+		raftMsg.MessageType = RaftMsgApp
+		sender = msg.Proposer
+		entries := uint64(1)
+		if n, ok := msg.Extensions.GetUint64("entries"); ok {
+			entries = n
+		}
+		if entries > height {
+			entries = height
+		}
+		raftMsg.Index = height - entries
+		for i := uint64(1); i <= entries; i++ {
+			raftMsg.Entries = append(raftMsg.Entries, RaftEntry{Term: raftMsg.Term, Index: raftMsg.Index + i})
+		}
+		if entries > 0 {
+			raftMsg.Entries[entries-1].DataHash = msg.BlockHash
+		}
+	case abstraction.MsgTypeCommit, abstraction.MsgTypePrepare:
+		raftMsg.MessageType = RaftMsgAppResp
+		raftMsg.RejectHint, _ = msg.Extensions.GetUint64("reject_hint")
+	case abstraction.MsgTypeViewChange, abstraction.MsgTypeRoundChange:
+		raftMsg.MessageType = RaftMsgVote
+		if preVote {
+			raftMsg.MessageType = RaftMsgPreVote
+		}
+	case abstraction.MsgTypeVote, abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit:
+		raftMsg.MessageType = RaftMsgVoteResp
+		if preVote {
+			raftMsg.MessageType = RaftMsgPreVoteResp
+		}
+	case abstraction.MsgTypeBlock:
+		raftMsg.MessageType = RaftMsgSnap
+		sender = msg.Proposer
+		raftMsg.Index = 0
+		raftMsg.Snapshot = &RaftSnap{Index: height, Term: raftMsg.Term, DataHash: msg.BlockHash}
+		if term, ok := msg.Extensions.GetUint64("snapshot_term"); ok {
+			raftMsg.Snapshot.Term = term
+		}
+	default:
+		return nil, &abstraction.MessageValidationError{
+			Field:   "type",
+			Message: fmt.Sprintf("unsupported canonical message type: %s", msg.Type),
+			Code:    "UNSUPPORTED_TYPE",
+		}
+	}
+
+	from, err := parseRaftID(sender)
+	if err != nil {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "validator",
+			Message: err.Error(),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+	raftMsg.From = from
+	if msg.Extensions != nil {
+		if channel, ok := msg.Extensions.GetString("channel_id"); ok {
+			raftMsg.ChannelID = channel
+		}
+		raftMsg.To, _ = msg.Extensions.GetUint64("to")
+		raftMsg.LogTerm, _ = msg.Extensions.GetUint64("log_term")
+		raftMsg.Commit, _ = msg.Extensions.GetUint64("commit")
+	}
+
+	payload, err := json.Marshal(raftMsg)
+	if err != nil {
+		return nil, &abstraction.MessageValidationError{
+			Field:   "payload",
+			Message: fmt.Sprintf("failed to serialize: %v", err),
+			Code:    "DECODE_FAILURE",
+		}
+	}
+
+	return &abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeFabricRaft,
+		ChainID:     m.chainID,
+		MessageType: raftMsg.MessageType,
+		Payload:     payload,
+		Encoding:    "json",
+		Timestamp:   abstraction.Now(),
+		Metadata: map[string]interface{}{
+			"channel_id": raftMsg.ChannelID,
+			"term":       raftMsg.Term,
+		},
+	}, nil
+}
+
+// GetSupportedTypes returns the message types supported by etcdraft orderers
+func (m *FabricRaftMapper) GetSupportedTypes() []abstraction.MsgType {
+	return []abstraction.MsgType{
+		abstraction.MsgTypeProposal,   // MsgApp
+		abstraction.MsgTypeCommit,     // MsgAppResp
+		abstraction.MsgTypeViewChange, // MsgVote, MsgPreVote
+		abstraction.MsgTypeVote,       // MsgVoteResp, MsgPreVoteResp
+		abstraction.MsgTypeBlock,      // MsgSnap
+	}
+}
+
+// GetChainType returns the chain type this mapper handles
+func (m *FabricRaftMapper) GetChainType() abstraction.ChainType {
+	return abstraction.ChainTypeFabricRaft
+}
+
+func parseRaftID(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid raft consenter ID %q", value)
+	}
+	return id, nil
+}
+
+// ByzantineActionInflateTerm turns a RequestVote into one for a much later term. etcdraft followers that
+// receive it step down and restart elections, the disruptive-server problem pre-vote exists to prevent.
+const ByzantineActionInflateTerm ByzantineAction = "inflate_term"
+
+// InflateTermParam names the Options.Params entry holding the number of terms to add; it defaults to 100.
+const InflateTermParam = "term_offset"
+
+// RaftByzantineEngine is the set of actions supported for etcdraft orderers. Raft assumes crash faults only,
+// so the generic actions show what a single byzantine consenter can do to the cluster.
+var RaftByzantineEngine = newRaftByzantineEngine()
+
+func newRaftByzantineEngine() *byzantine.Engine {
+	e := byzantine.NewEngine()
+	e.Register(ByzantineActionInflateTerm, applyInflateTermMutation)
+	return e
+}
+
+// FromCanonicalByzantine converts a canonical message back to etcdraft format while applying a byzantine action.
+func (m *FabricRaftMapper) FromCanonicalByzantine(msg *abstraction.CanonicalMessage, action ByzantineAction, opts ByzantineOptions) ([]*abstraction.RawConsensusMessage, error) {
+	return RaftByzantineEngine.ApplyAndEncode(m, msg, action, opts)
+}
+
+func applyInflateTermMutation(msg *abstraction.CanonicalMessage, opts ByzantineOptions) ([]*abstraction.CanonicalMessage, error) {
+	if msg.Type != abstraction.MsgTypeViewChange {
+		return nil, fmt.Errorf("inflate_term action requires a view change (RequestVote) canonical message")
+	}
+	offset := int64(100)
+	if value, ok := opts.Params[InflateTermParam]; ok {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: expected a positive integer", InflateTermParam, value)
+		}
+		offset = parsed
+	}
+
+	mutated := byzantine.Clone(msg)
+	mutated.View = byzantine.ShiftBigInt(msg.View, offset)
+	if mutated.Extensions == nil {
+		mutated.Extensions = make(map[string]interface{})
+	}
+	// A pre-vote never bumps anyone's term, so the forged request is a real vote.
+	mutated.Extensions["pre_vote"] = false
+	if opts.AlternateSignature != "" {
+		mutated.Signature = opts.AlternateSignature
+	}
+
+	byzantine.ApplyCommon(mutated, opts)
+	byzantine.EnsureTimestampProgress(mutated, msg.Timestamp)
+
+	return []*abstraction.CanonicalMessage{mutated}, nil
+}
@@ -0,0 +1,116 @@
+package adapter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+	"codec/message/abstraction/validator"
+)
+
+func raftRaw(t *testing.T, msg FabricRaftMessage) abstraction.RawConsensusMessage {
+	t.Helper()
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeFabricRaft,
+		ChainID:     "fabric-raft",
+		MessageType: msg.MessageType,
+		Payload:     payload,
+		Encoding:    "json",
+		Timestamp:   time.Now(),
+	}
+}
+
+func TestFabricRaftRoundTrip(t *testing.T) {
+	mapper := NewFabricRaftMapper("raft-test")
+	now := time.Now().UTC()
+
+	tests := []struct {
+		msg     FabricRaftMessage
+		msgType abstraction.MsgType
+		height  uint64
+		hash    string
+	}{
+		{FabricRaftMessage{MessageType: RaftMsgApp, ChannelID: "mychannel", From: 1, To: 2, Term: 4, LogTerm: 4, Index: 9, Commit: 9,
+			Entries: []RaftEntry{{Term: 4, Index: 10}, {Term: 4, Index: 11, DataHash: "0xb11"}}}, abstraction.MsgTypeProposal, 11, "0xb11"},
+		{FabricRaftMessage{MessageType: RaftMsgAppResp, ChannelID: "mychannel", From: 2, To: 1, Term: 4, Index: 11, Commit: 9},
+			abstraction.MsgTypeCommit, 11, ""},
+		{FabricRaftMessage{MessageType: RaftMsgAppResp, ChannelID: "mychannel", From: 3, To: 1, Term: 4, Index: 9, Reject: true, RejectHint: 7},
+			abstraction.MsgTypeCommit, 9, ""},
+		{FabricRaftMessage{MessageType: RaftMsgPreVote, ChannelID: "mychannel", From: 3, To: 1, Term: 5, LogTerm: 4, Index: 11},
+			abstraction.MsgTypeViewChange, 11, ""},
+		{FabricRaftMessage{MessageType: RaftMsgVoteResp, ChannelID: "mychannel", From: 1, To: 3, Term: 5},
+			abstraction.MsgTypeVote, 0, ""},
+		{FabricRaftMessage{MessageType: RaftMsgSnap, ChannelID: "mychannel", From: 1, To: 3, Term: 5,
+			Snapshot: &RaftSnap{Index: 50, Term: 3, DataHash: "0xb50"}}, abstraction.MsgTypeBlock, 50, "0xb50"},
+	}
+
+	v := validator.NewValidator(abstraction.ChainTypeFabricRaft)
+	for _, tt := range tests {
+		t.Run(tt.msg.MessageType, func(t *testing.T) {
+			tt.msg.Timestamp = now
+			canonical, err := mapper.ToCanonical(raftRaw(t, tt.msg))
+			if err != nil {
+				t.Fatalf("ToCanonical: %v", err)
+			}
+			if canonical.Type != tt.msgType || canonical.Height.Uint64() != tt.height || canonical.View.Uint64() != tt.msg.Term ||
+				canonical.Round != nil || canonical.BlockHash != tt.hash {
+				t.Fatalf("unexpected canonical message %+v", canonical)
+			}
+			if err := v.Validate(canonical); err != nil {
+				t.Fatalf("validation failed: %v", err)
+			}
+
+			raw, err := mapper.FromCanonical(canonical)
+			if err != nil {
+				t.Fatalf("FromCanonical: %v", err)
+			}
+			var back FabricRaftMessage
+			if err := json.Unmarshal(raw.Payload, &back); err != nil {
+				t.Fatalf("decode round trip: %v", err)
+			}
+			back.Timestamp = tt.msg.Timestamp
+			if !reflect.DeepEqual(back, tt.msg) {
+				t.Fatalf("round trip changed the message:\n got %+v\nwant %+v", back, tt.msg)
+			}
+		})
+	}
+
+	if _, err := mapper.ToCanonical(raftRaw(t, FabricRaftMessage{MessageType: "MsgHeartbeat"})); err == nil {
+		t.Fatalf("expected heartbeats to be rejected")
+	}
+}
+
+func TestInflateTerm(t *testing.T) {
+	mapper := NewFabricRaftMapper("raft-test")
+	canonical, err := mapper.ToCanonical(raftRaw(t, FabricRaftMessage{MessageType: RaftMsgPreVote, From: 3, Term: 5, LogTerm: 4, Index: 11}))
+	if err != nil {
+		t.Fatalf("ToCanonical: %v", err)
+	}
+
+	raws, err := mapper.FromCanonicalByzantine(canonical, ByzantineActionInflateTerm, ByzantineOptions{Params: map[string]string{InflateTermParam: "40"}})
+	if err != nil {
+		t.Fatalf("inflate_term: %v", err)
+	}
+	var forged FabricRaftMessage
+	if err := json.Unmarshal(raws[0].Payload, &forged); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(raws) != 1 || forged.MessageType != RaftMsgVote || forged.Term != 45 || forged.From != 3 || forged.Index != 11 {
+		t.Fatalf("unexpected forged vote %+v", forged)
+	}
+
+	if _, err := mapper.FromCanonicalByzantine(canonical, ByzantineActionInflateTerm, ByzantineOptions{Params: map[string]string{InflateTermParam: "-1"}}); err == nil {
+		t.Fatalf("expected a negative term offset to be rejected")
+	}
+	canonical.Type = abstraction.MsgTypeCommit
+	if _, err := RaftByzantineEngine.Apply(canonical, ByzantineActionInflateTerm, byzantine.Options{}); err == nil {
+		t.Fatalf("expected inflate_term to require a RequestVote")
+	}
+}
@@ -0,0 +1,26 @@
+package adapter
+
+import (
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+// ByzantineAction describes the manipulation to apply when converting back to a Kaia IBFT message.
+type ByzantineAction = byzantine.Action
+
+// ByzantineOptions contains optional overrides for the mutated messages.
+type ByzantineOptions = byzantine.Options
+
+// ByzantineEngine is the set of actions supported for Kaia. Kaia messages name their validator and carry
+// their timestamp and committed seal, so every generic action applies.
+var ByzantineEngine = byzantine.NewEngine()
+
+// ParseByzantineAction converts a CLI string to the typed action.
+func ParseByzantineAction(value string) (ByzantineAction, error) {
+	return ByzantineEngine.Parse(value)
+}
+
+// FromCanonicalByzantine converts a canonical message back to Kaia format while applying a byzantine action.
+func (m *KaiaMapper) FromCanonicalByzantine(msg *abstraction.CanonicalMessage, action ByzantineAction, opts ByzantineOptions) ([]*abstraction.RawConsensusMessage, error) {
+	return ByzantineEngine.ApplyAndEncode(m, msg, action, opts)
+}
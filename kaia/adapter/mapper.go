@@ -3,7 +3,6 @@ package adapter
 import (
 	"encoding/json"
 	"fmt"
-	"math/big"
 	"time"
 
 	"codec/message/abstraction"
@@ -61,7 +60,7 @@ func (m *KaiaMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abstract
 		ChainID:    m.chainID,
 		Height:     nil,
 		Round:      nil,
-		Timestamp:  time.Now(),
+		Timestamp:  abstraction.Now(),
 		Type:       m.mapMessageType(kaiaMsg.MessageType),
 		BlockHash:  "",
 		PrevHash:   "",
@@ -79,8 +78,8 @@ func (m *KaiaMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abstract
 	switch kaiaMsg.MessageType {
 	case "Preprepare":
 		if kaiaMsg.View != nil {
-			canonical.Height = big.NewInt(kaiaMsg.View.Sequence)
-			canonical.Round = big.NewInt(int64(kaiaMsg.View.Round))
+			canonical.SetHeight(kaiaMsg.View.Sequence)
+			canonical.SetRound(int64(kaiaMsg.View.Round))
 		}
 		if kaiaMsg.Proposal != nil {
 			canonical.BlockHash = kaiaMsg.Proposal.Hash
@@ -91,8 +90,8 @@ func (m *KaiaMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abstract
 	case "Prepare", "Commit", "RoundChange":
 		if kaiaMsg.Subject != nil {
 			if kaiaMsg.Subject.View != nil {
-				canonical.Height = big.NewInt(kaiaMsg.Subject.View.Sequence)
-				canonical.Round = big.NewInt(int64(kaiaMsg.Subject.View.Round))
+				canonical.SetHeight(kaiaMsg.Subject.View.Sequence)
+				canonical.SetRound(int64(kaiaMsg.Subject.View.Round))
 			}
 			canonical.BlockHash = kaiaMsg.Subject.Digest
 			canonical.PrevHash = kaiaMsg.Subject.PrevHash
@@ -123,22 +122,25 @@ func (m *KaiaMapper) FromCanonical(msg *abstraction.CanonicalMessage) (*abstract
 
 	// Convert canonical message to Kaia format
 	kaiaMsg := KaiaMessage{
-		MessageType:   m.mapToKaiaType(msg.Type),
+		MessageType:   m.mapToKaiaType(msg),
 		Validator:     msg.Validator,
 		CommittedSeal: msg.Signature,
 		Timestamp:     msg.Timestamp.Format(time.RFC3339),
 	}
 
 	// Add View based on message type
-	if msg.Height != nil && msg.Round != nil {
+	height, hasHeight := msg.HeightInt64()
+	round, hasRound := msg.RoundInt64()
+	if hasHeight && hasRound {
 		kaiaMsg.View = &KaiaView{
-			Round:    int32(msg.Round.Int64()),
-			Sequence: msg.Height.Int64(),
+			Round:    int32(round),
+			Sequence: height,
 		}
 	}
 
 	// Add Subject for Prepare/Commit/RoundChange
-	if msg.Type == abstraction.MsgTypeVote || msg.Type == abstraction.MsgTypeBlock {
+	switch kaiaMsg.MessageType {
+	case "Prepare", "Commit", "RoundChange":
 		kaiaMsg.Subject = &KaiaSubject{
 			View:     kaiaMsg.View,
 			Digest:   msg.BlockHash,
@@ -149,14 +151,14 @@ func (m *KaiaMapper) FromCanonical(msg *abstraction.CanonicalMessage) (*abstract
 	// Add Proposal for Preprepare
 	if msg.Type == abstraction.MsgTypeProposal {
 		kaiaMsg.Proposal = &KaiaProposal{
-			Number:     msg.Height.Int64(),
+			Number:     height,
 			Hash:       msg.BlockHash,
 			ParentHash: msg.PrevHash,
 			Timestamp:  msg.Timestamp.Unix(),
 			GasLimit:   30000000,
 			GasUsed:    15000000,
 			ExtraData:  "kaia-ibft-consensus",
-			MixHash:    fmt.Sprintf("0x%x", time.Now().UnixNano()),
+			MixHash:    fmt.Sprintf("0x%x", abstraction.Now().UnixNano()),
 			Nonce:      "0x0000000000000000",
 			BaseFee:    "25000000000",
 		}
@@ -178,7 +180,7 @@ func (m *KaiaMapper) FromCanonical(msg *abstraction.CanonicalMessage) (*abstract
 		MessageType: kaiaMsg.MessageType,
 		Payload:     payload,
 		Encoding:    "rlp",
-		Timestamp:   time.Now(),
+		Timestamp:   abstraction.Now(),
 		Metadata: map[string]interface{}{
 			"kaia_message_type": kaiaMsg.MessageType,
 			"timestamp":         kaiaMsg.Timestamp,
@@ -192,8 +194,9 @@ func (m *KaiaMapper) FromCanonical(msg *abstraction.CanonicalMessage) (*abstract
 func (m *KaiaMapper) GetSupportedTypes() []abstraction.MsgType {
 	return []abstraction.MsgType{
 		abstraction.MsgTypeProposal, // Preprepare
-		abstraction.MsgTypeVote,     // Prepare, Commit
-		abstraction.MsgTypeBlock,    // RoundChange
+		abstraction.MsgTypePrepare,
+		abstraction.MsgTypeCommit,
+		abstraction.MsgTypeRoundChange,
 	}
 }
 
@@ -207,26 +210,36 @@ func (m *KaiaMapper) mapMessageType(kaiaType string) abstraction.MsgType {
 	switch kaiaType {
 	case "Preprepare":
 		return abstraction.MsgTypeProposal
-	case "Prepare", "Commit":
-		return abstraction.MsgTypeVote
+	case "Prepare":
+		return abstraction.MsgTypePrepare
+	case "Commit":
+		return abstraction.MsgTypeCommit
 	case "RoundChange":
-		return abstraction.MsgTypeBlock
+		return abstraction.MsgTypeRoundChange
 	default:
 		return abstraction.MsgType(kaiaType)
 	}
 }
 
-// mapToKaiaType maps canonical message types to Kaia IBFT types
-func (m *KaiaMapper) mapToKaiaType(canonicalType abstraction.MsgType) string {
-	switch canonicalType {
+// mapToKaiaType maps canonical message types to Kaia IBFT types. Messages decoded before Prepare and Commit
+// had their own canonical types arrive as votes; their kaia_message_type extension names the phase.
+func (m *KaiaMapper) mapToKaiaType(msg *abstraction.CanonicalMessage) string {
+	switch msg.Type {
 	case abstraction.MsgTypeProposal:
 		return "Preprepare"
+	case abstraction.MsgTypePrepare:
+		return "Prepare"
+	case abstraction.MsgTypeCommit:
+		return "Commit"
+	case abstraction.MsgTypeRoundChange, abstraction.MsgTypeBlock:
+		return "RoundChange"
 	case abstraction.MsgTypeVote:
+		if phase, _ := msg.Extensions.GetString("kaia_message_type"); phase == "Commit" {
+			return "Commit"
+		}
 		return "Prepare" // 기본값으로 Prepare 사용
-	case abstraction.MsgTypeBlock:
-		return "RoundChange"
 	default:
-		return string(canonicalType)
+		return string(msg.Type)
 	}
 }
 
@@ -0,0 +1,106 @@
+package adapter
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+func kaiaRaw(t *testing.T, msg KaiaMessage) abstraction.RawConsensusMessage {
+	t.Helper()
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("encode %s: %v", msg.MessageType, err)
+	}
+	return abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeKaia,
+		ChainID:     "kaia-1",
+		MessageType: msg.MessageType,
+		Payload:     payload,
+		Encoding:    "json",
+		Timestamp:   time.Now(),
+	}
+}
+
+func decodeKaia(t *testing.T, raw *abstraction.RawConsensusMessage) KaiaMessage {
+	t.Helper()
+	var msg KaiaMessage
+	if err := json.Unmarshal(raw.Payload, &msg); err != nil {
+		t.Fatalf("decode %s: %v", raw.MessageType, err)
+	}
+	return msg
+}
+
+func TestPhasesRoundTrip(t *testing.T) {
+	mapper := NewKaiaMapper("kaia-1")
+	tests := map[string]abstraction.MsgType{
+		"Prepare":     abstraction.MsgTypePrepare,
+		"Commit":      abstraction.MsgTypeCommit,
+		"RoundChange": abstraction.MsgTypeRoundChange,
+	}
+	for phase, want := range tests {
+		view := &KaiaView{Round: 2, Sequence: 40}
+		canonical, err := mapper.ToCanonical(kaiaRaw(t, KaiaMessage{
+			MessageType: phase,
+			Subject:     &KaiaSubject{View: view, Digest: "0xab", PrevHash: "0xcd"},
+			Validator:   "0x11",
+			Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		}))
+		if err != nil {
+			t.Fatalf("%s: %v", phase, err)
+		}
+		if canonical.Type != want {
+			t.Fatalf("%s decoded as %s, want %s", phase, canonical.Type, want)
+		}
+		if h, _ := canonical.HeightInt64(); h != 40 || canonical.BlockHash != "0xab" || canonical.PrevHash != "0xcd" {
+			t.Fatalf("%s lost its subject: %+v", phase, canonical)
+		}
+
+		raw, err := mapper.FromCanonical(canonical)
+		if err != nil {
+			t.Fatalf("%s: %v", phase, err)
+		}
+		back := decodeKaia(t, raw)
+		if raw.MessageType != phase || back.MessageType != phase {
+			t.Fatalf("%s encoded as %s", phase, back.MessageType)
+		}
+		if back.Subject == nil || back.Subject.Digest != "0xab" || back.Subject.View == nil || *back.Subject.View != *view {
+			t.Fatalf("%s lost its subject on the way back: %+v", phase, back.Subject)
+		}
+	}
+}
+
+func TestLegacyTypesKeepTheirPhase(t *testing.T) {
+	mapper := NewKaiaMapper("kaia-1")
+	tests := []struct {
+		name       string
+		msgType    abstraction.MsgType
+		extensions abstraction.Extensions
+		want       string
+	}{
+		{"vote", abstraction.MsgTypeVote, nil, "Prepare"},
+		{"vote from a prepare", abstraction.MsgTypeVote, abstraction.Extensions{"kaia_message_type": "Prepare"}, "Prepare"},
+		{"vote from a commit", abstraction.MsgTypeVote, abstraction.Extensions{"kaia_message_type": "Commit"}, "Commit"},
+		{"block", abstraction.MsgTypeBlock, nil, "RoundChange"},
+	}
+	for _, tt := range tests {
+		msg := &abstraction.CanonicalMessage{
+			ChainID:    "kaia-1",
+			Timestamp:  time.Now(),
+			Type:       tt.msgType,
+			BlockHash:  "0xab",
+			Extensions: tt.extensions,
+		}
+		msg.SetHeight(7)
+		msg.SetRound(0)
+		raw, err := mapper.FromCanonical(msg)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if back := decodeKaia(t, raw); back.MessageType != tt.want || back.Subject == nil || back.Subject.Digest != "0xab" {
+			t.Fatalf("%s: encoded as %s with subject %+v, want %s", tt.name, back.MessageType, back.Subject, tt.want)
+		}
+	}
+}
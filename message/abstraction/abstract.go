@@ -0,0 +1,34 @@
+package abstraction
+
+import (
+	"math/big"
+	"time"
+)
+
+// AbstractMessage is the format-neutral message the codec package parses into and serializes from. Unlike
+// CanonicalMessage it keeps what a format carried beyond the standard fields as raw bytes, together with the
+// names the source used, so a message can be re-encoded in another format without loss.
+type AbstractMessage struct {
+	Type      MsgType   `json:"type"`                 // Normalized message type
+	Height    *big.Int  `json:"height,omitempty"`     // Block height
+	Round     *big.Int  `json:"round,omitempty"`      // Consensus round
+	View      *big.Int  `json:"view,omitempty"`       // View number (for PBFT-style protocols)
+	Timestamp time.Time `json:"timestamp,omitempty"`  // Message creation time
+	BlockHash string    `json:"block_hash,omitempty"` // Proposed block hash
+	PrevHash  string    `json:"prev_hash,omitempty"`  // Previous block hash
+	Proposer  string    `json:"proposer,omitempty"`   // Proposer node ID
+	Validator string    `json:"validator,omitempty"`  // Validator node ID
+	Signature string    `json:"signature,omitempty"`  // Message signature
+
+	CommitSeals []string          `json:"commit_seals,omitempty"` // Commit signatures
+	ViewChanges []ViewChangeEntry `json:"view_changes,omitempty"` // View change entries
+
+	// Fields outside the standard set, keyed by their original name, with the bytes the format held
+	Extras map[string][]byte `json:"extras,omitempty"`
+
+	// Metadata about the source
+	RawPayload         []byte            `json:"raw_payload,omitempty"`          // Original message bytes
+	OriginalFormat     string            `json:"original_format,omitempty"`      // Format the message was parsed from
+	OriginalMsgName    string            `json:"original_msg_name,omitempty"`    // Message name as the source spelled it
+	OriginalFieldNames map[string]string `json:"original_field_names,omitempty"` // Standard field name -> source field name
+}
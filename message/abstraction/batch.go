@@ -0,0 +1,66 @@
+package abstraction
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// BatchOptions tunes ConvertBatch.
+type BatchOptions struct {
+	// Workers bounds the number of concurrent conversions; zero or less uses GOMAXPROCS.
+	Workers int
+}
+
+// BatchResult is the outcome of converting one message of a batch.
+type BatchResult struct {
+	Message *CanonicalMessage
+	Err     error
+}
+
+// ConvertBatch converts raws to canonical form concurrently and returns one result per input, in input order.
+// A message that fails to convert does not stop the others. When ctx is cancelled the messages not yet
+// converted report ctx.Err(), which ConvertBatch also returns; a ContextMapper also sees ctx, so it can abandon
+// the conversions in flight. The mapper must be safe for concurrent use, as the built-in mappers are.
+func ConvertBatch(ctx context.Context, mapper Mapper, raws []RawConsensusMessage, opts BatchOptions) ([]BatchResult, error) {
+	results := make([]BatchResult, len(raws))
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(raws) {
+		workers = len(raws)
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i].Message, results[i].Err = ToCanonicalContext(ctx, mapper, raws[i])
+			}
+		}()
+	}
+
+	var err error
+	for i := range raws {
+		if err = ctx.Err(); err == nil {
+			select {
+			case next <- i:
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		}
+		if err != nil {
+			for j := i; j < len(raws); j++ {
+				results[j].Err = err
+			}
+			break
+		}
+	}
+	close(next)
+	wg.Wait()
+	return results, err
+}
@@ -0,0 +1,110 @@
+package abstraction_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+// heightMapper parses the payload as a height and rejects odd heights.
+type heightMapper struct {
+	running, peak int32
+	delay         time.Duration
+}
+
+func (m *heightMapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abstraction.CanonicalMessage, error) {
+	n := atomic.AddInt32(&m.running, 1)
+	defer atomic.AddInt32(&m.running, -1)
+	for {
+		peak := atomic.LoadInt32(&m.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&m.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(m.delay)
+
+	height, ok := new(big.Int).SetString(string(raw.Payload), 10)
+	if !ok || height.Bit(0) == 1 {
+		return nil, fmt.Errorf("bad height %q", raw.Payload)
+	}
+	return &abstraction.CanonicalMessage{ChainID: raw.ChainID, Height: height}, nil
+}
+
+func (m *heightMapper) FromCanonical(*abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *heightMapper) GetSupportedTypes() []abstraction.MsgType { return nil }
+
+func (m *heightMapper) GetChainType() abstraction.ChainType { return "heights" }
+
+func rawHeights(n int) []abstraction.RawConsensusMessage {
+	raws := make([]abstraction.RawConsensusMessage, n)
+	for i := range raws {
+		raws[i] = abstraction.RawConsensusMessage{ChainID: "batch", Payload: []byte(fmt.Sprint(i))}
+	}
+	return raws
+}
+
+func TestConvertBatchKeepsOrderAndPerItemErrors(t *testing.T) {
+	mapper := &heightMapper{delay: time.Millisecond}
+	results, err := abstraction.ConvertBatch(context.Background(), mapper, rawHeights(200), abstraction.BatchOptions{Workers: 4})
+	if err != nil {
+		t.Fatalf("ConvertBatch: %v", err)
+	}
+	if len(results) != 200 {
+		t.Fatalf("expected 200 results, got %d", len(results))
+	}
+	for i, r := range results {
+		if i%2 == 1 {
+			if r.Err == nil || r.Message != nil {
+				t.Fatalf("result %d: expected an error, got %+v", i, r)
+			}
+			continue
+		}
+		if r.Err != nil || r.Message.Height.Int64() != int64(i) {
+			t.Fatalf("result %d out of order or failed: %+v", i, r)
+		}
+	}
+	if peak := atomic.LoadInt32(&mapper.peak); peak > 4 || peak < 2 {
+		t.Fatalf("expected up to 4 concurrent conversions, saw %d", peak)
+	}
+}
+
+func TestConvertBatchStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := abstraction.ConvertBatch(ctx, &heightMapper{}, rawHeights(10), abstraction.BatchOptions{Workers: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	skipped := 0
+	for _, r := range results {
+		if errors.Is(r.Err, context.Canceled) {
+			skipped++
+		}
+	}
+	if skipped == 0 {
+		t.Fatalf("expected unconverted messages to report the cancellation")
+	}
+
+	if results, err := abstraction.ConvertBatch(context.Background(), &heightMapper{}, nil, abstraction.BatchOptions{}); err != nil || len(results) != 0 {
+		t.Fatalf("expected an empty batch to succeed, got %v, %v", results, err)
+	}
+}
+
+func BenchmarkConvertBatch(b *testing.B) {
+	raws := rawHeights(5000)
+	mapper := &heightMapper{}
+	for i := 0; i < b.N; i++ {
+		if _, err := abstraction.ConvertBatch(context.Background(), mapper, raws, abstraction.BatchOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// Package bridgeapi defines the bridge's gRPC surface. It is split into two services so read-only consumers
+// can be given access without being able to inject traffic:
+//
+//   - codec.bridge.v1.Viewer (SubscribeCanonical, Query, Explain) only observes what the bridge has processed.
+//   - codec.bridge.v1.Operator (Submit, IngestRaw, Convert, Attack) feeds messages into the bridge or forges new ones.
+//
+// Both services are defined in message/proto/bridge.proto, with generated code in bridgepb; this package wraps
+// them in the abstraction types. A server that only registers the Viewer answers every Operator call with
+// codes.Unimplemented.
+package bridgeapi
+
+//go:generate protoc -I ../../proto --go_out=bridgepb --go_opt=paths=source_relative --go-grpc_out=bridgepb --go-grpc_opt=paths=source_relative bridge.proto
+
+import (
+	"context"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/bridgeapi/bridgepb"
+	"codec/message/abstraction/detect"
+	"codec/message/abstraction/lint"
+	"codec/scenario"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	// ViewerServiceName is the fully qualified name of the read-only service.
+	ViewerServiceName = "codec.bridge.v1.Viewer"
+	// OperatorServiceName is the fully qualified name of the mutating service.
+	OperatorServiceName = "codec.bridge.v1.Operator"
+)
+
+// Role names the service a method belongs to.
+type Role string
+
+const (
+	// RoleViewer methods never change bridge state.
+	RoleViewer Role = "viewer"
+	// RoleOperator methods ingest, convert, or forge traffic.
+	RoleOperator Role = "operator"
+)
+
+// ServiceName returns the gRPC service that serves the role's methods.
+func (r Role) ServiceName() string {
+	if r == RoleOperator {
+		return OperatorServiceName
+	}
+	return ViewerServiceName
+}
+
+// Filter selects processed messages for SubscribeCanonical and Query. Empty fields match everything.
+type Filter struct {
+	// Chain matches the bridge's chain name or the canonical chain ID.
+	Chain      string                `json:"chain,omitempty"`
+	Types      []abstraction.MsgType `json:"types,omitempty"`
+	FromHeight *int64                `json:"from_height,omitempty"`
+	ToHeight   *int64                `json:"to_height,omitempty"`
+}
+
+// Matches reports whether an event passes the filter.
+func (f Filter) Matches(ev *Event) bool {
+	if ev == nil || ev.Canonical == nil {
+		return false
+	}
+	msg := ev.Canonical
+	if f.Chain != "" && f.Chain != ev.Chain && f.Chain != msg.ChainID {
+		return false
+	}
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if t == msg.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.FromHeight != nil || f.ToHeight != nil {
+		height, ok := msg.HeightInt64()
+		if !ok {
+			return false
+		}
+		if f.FromHeight != nil && height < *f.FromHeight {
+			return false
+		}
+		if f.ToHeight != nil && height > *f.ToHeight {
+			return false
+		}
+	}
+	return true
+}
+
+// Event is a canonical message the bridge has processed.
+type Event struct {
+	// Seq increases by one for every processed message, so gaps reveal events a slow subscriber missed.
+	Seq       uint64                        `json:"seq"`
+	Chain     string                        `json:"chain"`
+	Received  time.Time                     `json:"received"`
+	Canonical *abstraction.CanonicalMessage `json:"canonical"`
+	// Forged marks messages produced by Attack rather than ingested.
+	Forged bool `json:"forged,omitempty"`
+	// Duplicate marks a message already seen within the bridge's deduplication window. It is recorded but
+	// was not forwarded to routing targets or sinks again.
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+// Stages at which the bridge can fail a message, recorded in DeadLetter.Stage.
+const (
+	// StageResolve is a message no configured chain or detected origin could be matched to.
+	StageResolve = "resolve"
+	// StageConvert is a message the chain's mapper could not turn into a canonical message.
+	StageConvert = "convert"
+	// StageValidate is a canonical message the chain's validator rejected.
+	StageValidate = "validate"
+	// StageForward is a routing-rule target that failed to accept the message.
+	StageForward = "forward"
+	// StageEgress is an egress target of the message's chain that failed to accept it.
+	StageEgress = "egress"
+)
+
+// DeadLetter is a message the bridge failed to process, as written to its dead-letter sink. Raw is the
+// message as it was ingested, so it can be fed back through IngestRaw once the cause is fixed.
+type DeadLetter struct {
+	Time  time.Time `json:"time"`
+	Stage string    `json:"stage"`
+	Error string    `json:"error"`
+	// Chain is the bridge chain the message was resolved to, empty when resolution failed.
+	Chain string `json:"chain,omitempty"`
+	// Target is the routing or egress target that failed, for the forward and egress stages.
+	Target string                          `json:"target,omitempty"`
+	Raw    abstraction.RawConsensusMessage `json:"raw"`
+	// Canonical is set once conversion succeeded.
+	Canonical *abstraction.CanonicalMessage `json:"canonical,omitempty"`
+}
+
+// SubscribeRequest subscribes to processed messages.
+type SubscribeRequest struct {
+	Filter Filter `json:"filter"`
+	// Replay sends the retained history that matches the filter before live events.
+	Replay bool `json:"replay,omitempty"`
+}
+
+// QueryRequest reads the retained history.
+type QueryRequest struct {
+	Filter Filter `json:"filter"`
+	// Limit keeps only the most recent matches; zero returns every match.
+	Limit int `json:"limit,omitempty"`
+}
+
+// QueryResponse holds matching events, oldest first.
+type QueryResponse struct {
+	Events []*Event `json:"events"`
+}
+
+// ExplainRequest asks how the bridge would interpret a raw message without processing it.
+type ExplainRequest struct {
+	Raw abstraction.RawConsensusMessage `json:"raw"`
+}
+
+// ExplainResponse describes each stage a raw message would pass through. Error is set at the first stage that
+// failed; earlier fields are still filled in.
+type ExplainResponse struct {
+	Chain     string                        `json:"chain,omitempty"`
+	Detection *detect.Result                `json:"detection,omitempty"`
+	Canonical *abstraction.CanonicalMessage `json:"canonical,omitempty"`
+	Issues    []lint.Issue                  `json:"issues,omitempty"`
+	Error     string                        `json:"error,omitempty"`
+}
+
+// SubmitRequest ingests a raw message as if a collector had delivered it.
+type SubmitRequest struct {
+	Raw abstraction.RawConsensusMessage `json:"raw"`
+}
+
+// SubmitResponse is the event the submitted message became.
+type SubmitResponse struct {
+	Event *Event `json:"event"`
+}
+
+// IngestResponse summarises an IngestRaw stream once the collector closes it.
+type IngestResponse struct {
+	Accepted int `json:"accepted"`
+	// Rejected lists the messages the bridge could not process; they do not end the stream.
+	Rejected []IngestError `json:"rejected,omitempty"`
+	// LastSeq is the event sequence number of the last accepted message.
+	LastSeq uint64 `json:"last_seq,omitempty"`
+}
+
+// IngestError reports why a streamed message was rejected.
+type IngestError struct {
+	// Index counts the stream's messages from zero.
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// ConvertRequest encodes a canonical message for a configured chain.
+type ConvertRequest struct {
+	Canonical   *abstraction.CanonicalMessage `json:"canonical"`
+	TargetChain string                        `json:"target_chain"`
+}
+
+// ConvertResponse holds the encoded message.
+type ConvertResponse struct {
+	Raw *abstraction.RawConsensusMessage `json:"raw"`
+}
+
+// AttackRequest applies a byzantine action to a canonical message and encodes the result for a configured chain.
+type AttackRequest struct {
+	Canonical   *abstraction.CanonicalMessage `json:"canonical"`
+	TargetChain string                        `json:"target_chain"`
+	Action      string                        `json:"action"`
+	Options     scenario.StepOptions          `json:"options,omitempty"`
+	Params      map[string]string             `json:"params,omitempty"`
+	// Route feeds the forged messages through the bridge's routing rules and history, marked as forged.
+	Route bool `json:"route,omitempty"`
+}
+
+// AttackResponse holds the forged messages in canonical and encoded form.
+type AttackResponse struct {
+	Canonicals []*abstraction.CanonicalMessage    `json:"canonicals"`
+	Raws       []*abstraction.RawConsensusMessage `json:"raws"`
+}
+
+// Viewer is the read-only half of the bridge API.
+type Viewer interface {
+	// SubscribeCanonical calls send for every matching event until ctx ends or send fails.
+	SubscribeCanonical(ctx context.Context, req *SubscribeRequest, send func(*Event) error) error
+	Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error)
+	Explain(ctx context.Context, req *ExplainRequest) (*ExplainResponse, error)
+}
+
+// Operator is the mutating half of the bridge API.
+type Operator interface {
+	Submit(ctx context.Context, req *SubmitRequest) (*SubmitResponse, error)
+	// IngestRaw processes messages from recv until it returns io.EOF, which marks the end of the collector's
+	// stream; any other error from recv ends the call.
+	IngestRaw(ctx context.Context, recv func() (*abstraction.RawConsensusMessage, error)) (*IngestResponse, error)
+	Convert(ctx context.Context, req *ConvertRequest) (*ConvertResponse, error)
+	Attack(ctx context.Context, req *AttackRequest) (*AttackResponse, error)
+}
+
+// Methods lists the full method names served for a role.
+func Methods(role Role) []string {
+	desc := &bridgepb.Viewer_ServiceDesc
+	if role == RoleOperator {
+		desc = &bridgepb.Operator_ServiceDesc
+	}
+	var names []string
+	for _, m := range desc.Methods {
+		names = append(names, "/"+desc.ServiceName+"/"+m.MethodName)
+	}
+	for _, stream := range desc.Streams {
+		names = append(names, "/"+desc.ServiceName+"/"+stream.StreamName)
+	}
+	return names
+}
+
+// RegisterViewer serves the read-only service on srv. Register only this on listeners handed to dashboards or
+// students.
+func RegisterViewer(srv *grpc.Server, impl Viewer) {
+	bridgepb.RegisterViewerServer(srv, viewerServer{impl: impl})
+}
+
+// RegisterOperator serves the mutating service on srv.
+func RegisterOperator(srv *grpc.Server, impl Operator) {
+	bridgepb.RegisterOperatorServer(srv, operatorServer{impl: impl})
+}
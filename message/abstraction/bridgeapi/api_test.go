@@ -0,0 +1,219 @@
+package bridgeapi
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeBridge records submissions and serves them back to viewers.
+type fakeBridge struct {
+	events chan *Event
+	seen   []*Event
+}
+
+func (b *fakeBridge) SubscribeCanonical(ctx context.Context, req *SubscribeRequest, send func(*Event) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-b.events:
+			if !req.Filter.Matches(ev) {
+				continue
+			}
+			if err := send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (b *fakeBridge) Query(_ context.Context, req *QueryRequest) (*QueryResponse, error) {
+	resp := &QueryResponse{}
+	for _, ev := range b.seen {
+		if req.Filter.Matches(ev) {
+			resp.Events = append(resp.Events, ev)
+		}
+	}
+	return resp, nil
+}
+
+func (b *fakeBridge) Explain(_ context.Context, req *ExplainRequest) (*ExplainResponse, error) {
+	return &ExplainResponse{Chain: req.Raw.ChainID}, nil
+}
+
+func (b *fakeBridge) Submit(_ context.Context, req *SubmitRequest) (*SubmitResponse, error) {
+	ev := &Event{Seq: uint64(len(b.seen) + 1), Chain: req.Raw.ChainID, Canonical: &abstraction.CanonicalMessage{
+		ChainID: req.Raw.ChainID, Height: big.NewInt(int64(len(b.seen) + 1)), Type: abstraction.MsgTypePrevote,
+	}}
+	b.seen = append(b.seen, ev)
+	b.events <- ev
+	return &SubmitResponse{Event: ev}, nil
+}
+
+func (b *fakeBridge) IngestRaw(ctx context.Context, recv func() (*abstraction.RawConsensusMessage, error)) (*IngestResponse, error) {
+	resp := &IngestResponse{}
+	for index := 0; ; index++ {
+		raw, err := recv()
+		if err == io.EOF {
+			return resp, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if raw.ChainID == "" {
+			resp.Rejected = append(resp.Rejected, IngestError{Index: index, Error: "no chain"})
+			continue
+		}
+		submitted, _ := b.Submit(ctx, &SubmitRequest{Raw: *raw})
+		resp.Accepted++
+		resp.LastSeq = submitted.Event.Seq
+	}
+}
+
+func (b *fakeBridge) Convert(context.Context, *ConvertRequest) (*ConvertResponse, error) {
+	return &ConvertResponse{}, nil
+}
+
+func (b *fakeBridge) Attack(context.Context, *AttackRequest) (*AttackResponse, error) {
+	return &AttackResponse{}, nil
+}
+
+// dial serves the bridge in memory with the Viewer and, optionally, the Operator registered.
+func dial(t *testing.T, bridge *fakeBridge, operator bool) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	RegisterViewer(srv, bridge)
+	if operator {
+		RegisterOperator(srv, bridge)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestViewerCannotReachOperator(t *testing.T) {
+	bridge := &fakeBridge{events: make(chan *Event, 8)}
+	conn := dial(t, bridge, false)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if resp, err := NewViewerClient(conn).Explain(ctx, &ExplainRequest{Raw: abstraction.RawConsensusMessage{ChainID: "cometbft"}}); err != nil || resp.Chain != "cometbft" {
+		t.Fatalf("expected explain to be served, got %+v (%v)", resp, err)
+	}
+	operator := NewOperatorClient(conn)
+	if _, err := operator.Submit(ctx, &SubmitRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("expected submit on a viewer-only server to be unimplemented, got %v", err)
+	}
+	if _, err := operator.Attack(ctx, &AttackRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Fatalf("expected attack on a viewer-only server to be unimplemented, got %v", err)
+	}
+	if len(bridge.seen) != 0 {
+		t.Fatalf("viewer-only server accepted %d submissions", len(bridge.seen))
+	}
+}
+
+func TestOperatorSubmitReachesViewers(t *testing.T) {
+	bridge := &fakeBridge{events: make(chan *Event, 8)}
+	conn := dial(t, bridge, true)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	viewer := NewViewerClient(conn)
+	stream, err := viewer.SubscribeCanonical(ctx, &SubscribeRequest{Filter: Filter{Chain: "cometbft"}})
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	submitted, err := NewOperatorClient(conn).Submit(ctx, &SubmitRequest{Raw: abstraction.RawConsensusMessage{ChainID: "cometbft"}})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	ev, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+	if ev.Seq != submitted.Event.Seq || ev.Canonical.Height.Int64() != 1 {
+		t.Fatalf("expected streamed event %d at height 1, got %+v", submitted.Event.Seq, ev)
+	}
+
+	from := int64(2)
+	resp, err := viewer.Query(ctx, &QueryRequest{Filter: Filter{FromHeight: &from}})
+	if err != nil || len(resp.Events) != 0 {
+		t.Fatalf("expected no events at height >= 2, got %+v (%v)", resp, err)
+	}
+}
+
+func TestOperatorIngestStream(t *testing.T) {
+	bridge := &fakeBridge{events: make(chan *Event, 8)}
+	conn := dial(t, bridge, true)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := NewOperatorClient(conn).IngestRaw(ctx)
+	if err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	for _, chain := range []string{"cometbft", "", "kaia"} {
+		if err := stream.Send(&abstraction.RawConsensusMessage{ChainID: chain}); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if resp.Accepted != 2 || resp.LastSeq != 2 || len(resp.Rejected) != 1 || resp.Rejected[0].Index != 1 {
+		t.Fatalf("unexpected ingest summary %+v", resp)
+	}
+	if len(bridge.seen) != 2 || bridge.seen[1].Chain != "kaia" {
+		t.Fatalf("expected the accepted messages in order, got %+v", bridge.seen)
+	}
+
+	viewerOnly := dial(t, &fakeBridge{events: make(chan *Event, 8)}, false)
+	stream, err = NewOperatorClient(viewerOnly).IngestRaw(ctx)
+	if err == nil {
+		_, err = stream.CloseAndRecv()
+	}
+	if status.Code(err) != codes.Unimplemented {
+		t.Fatalf("expected ingest on a viewer-only server to be unimplemented, got %v", err)
+	}
+}
+
+func TestMethodsBelongToOneRole(t *testing.T) {
+	viewer, operator := Methods(RoleViewer), Methods(RoleOperator)
+	if len(viewer) != 3 || len(operator) != 4 {
+		t.Fatalf("expected three viewer and four operator methods, got %v and %v", viewer, operator)
+	}
+	for _, name := range viewer {
+		if !strings.HasPrefix(name, "/"+ViewerServiceName+"/") {
+			t.Fatalf("viewer method %s is not on the viewer service", name)
+		}
+	}
+	for _, name := range operator {
+		if !strings.HasPrefix(name, "/"+OperatorServiceName+"/") {
+			t.Fatalf("operator method %s is not on the operator service", name)
+		}
+	}
+}
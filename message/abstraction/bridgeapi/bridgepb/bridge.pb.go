@@ -0,0 +1,1362 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: bridge.proto
+
+// Bridge service API, split so read-only consumers can be given access without being able to inject
+// traffic. message/abstraction/bridgeapi wraps the generated code; see docs/bridge_api.md.
+
+package bridgepb
+
+import (
+	canonicalpb "codec/message/abstraction/canonical/canonicalpb"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Selects processed messages. Empty fields match everything.
+type Filter struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Matches the bridge's chain name or the canonical chain ID.
+	Chain         string   `protobuf:"bytes,1,opt,name=chain,proto3" json:"chain,omitempty"`
+	Types         []string `protobuf:"bytes,2,rep,name=types,proto3" json:"types,omitempty"`
+	FromHeight    *int64   `protobuf:"varint,3,opt,name=from_height,json=fromHeight,proto3,oneof" json:"from_height,omitempty"`
+	ToHeight      *int64   `protobuf:"varint,4,opt,name=to_height,json=toHeight,proto3,oneof" json:"to_height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Filter) Reset() {
+	*x = Filter{}
+	mi := &file_bridge_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Filter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Filter) ProtoMessage() {}
+
+func (x *Filter) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Filter.ProtoReflect.Descriptor instead.
+func (*Filter) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Filter) GetChain() string {
+	if x != nil {
+		return x.Chain
+	}
+	return ""
+}
+
+func (x *Filter) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *Filter) GetFromHeight() int64 {
+	if x != nil && x.FromHeight != nil {
+		return *x.FromHeight
+	}
+	return 0
+}
+
+func (x *Filter) GetToHeight() int64 {
+	if x != nil && x.ToHeight != nil {
+		return *x.ToHeight
+	}
+	return 0
+}
+
+// A canonical message the bridge has processed.
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Increases by one for every processed message, so gaps reveal events a slow subscriber missed.
+	Seq       uint64                        `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Chain     string                        `protobuf:"bytes,2,opt,name=chain,proto3" json:"chain,omitempty"`
+	Received  *timestamppb.Timestamp        `protobuf:"bytes,3,opt,name=received,proto3" json:"received,omitempty"`
+	Canonical *canonicalpb.CanonicalMessage `protobuf:"bytes,4,opt,name=canonical,proto3" json:"canonical,omitempty"`
+	// Produced by Attack rather than ingested.
+	Forged bool `protobuf:"varint,5,opt,name=forged,proto3" json:"forged,omitempty"`
+	// Already seen within the deduplication window; recorded but not forwarded again.
+	Duplicate     bool `protobuf:"varint,6,opt,name=duplicate,proto3" json:"duplicate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_bridge_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Event) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Event) GetChain() string {
+	if x != nil {
+		return x.Chain
+	}
+	return ""
+}
+
+func (x *Event) GetReceived() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Received
+	}
+	return nil
+}
+
+func (x *Event) GetCanonical() *canonicalpb.CanonicalMessage {
+	if x != nil {
+		return x.Canonical
+	}
+	return nil
+}
+
+func (x *Event) GetForged() bool {
+	if x != nil {
+		return x.Forged
+	}
+	return false
+}
+
+func (x *Event) GetDuplicate() bool {
+	if x != nil {
+		return x.Duplicate
+	}
+	return false
+}
+
+type SubscribeRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *Filter                `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Sends the retained history that matches the filter before live events.
+	Replay        bool `protobuf:"varint,2,opt,name=replay,proto3" json:"replay,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_bridge_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SubscribeRequest) GetFilter() *Filter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *SubscribeRequest) GetReplay() bool {
+	if x != nil {
+		return x.Replay
+	}
+	return false
+}
+
+type QueryRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *Filter                `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Keeps only the most recent matches; zero returns every match.
+	Limit         int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryRequest) Reset() {
+	*x = QueryRequest{}
+	mi := &file_bridge_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryRequest) ProtoMessage() {}
+
+func (x *QueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryRequest.ProtoReflect.Descriptor instead.
+func (*QueryRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *QueryRequest) GetFilter() *Filter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *QueryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// Matching events, oldest first.
+type QueryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*Event               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryResponse) Reset() {
+	*x = QueryResponse{}
+	mi := &file_bridge_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryResponse) ProtoMessage() {}
+
+func (x *QueryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryResponse.ProtoReflect.Descriptor instead.
+func (*QueryResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *QueryResponse) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type ExplainRequest struct {
+	state         protoimpl.MessageState           `protogen:"open.v1"`
+	Raw           *canonicalpb.RawConsensusMessage `protobuf:"bytes,1,opt,name=raw,proto3" json:"raw,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExplainRequest) Reset() {
+	*x = ExplainRequest{}
+	mi := &file_bridge_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplainRequest) ProtoMessage() {}
+
+func (x *ExplainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplainRequest.ProtoReflect.Descriptor instead.
+func (*ExplainRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ExplainRequest) GetRaw() *canonicalpb.RawConsensusMessage {
+	if x != nil {
+		return x.Raw
+	}
+	return nil
+}
+
+// Guess of a payload's chain and encoding.
+type Detection struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChainType     string                 `protobuf:"bytes,1,opt,name=chain_type,json=chainType,proto3" json:"chain_type,omitempty"`
+	Encoding      string                 `protobuf:"bytes,2,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,3,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Detection) Reset() {
+	*x = Detection{}
+	mi := &file_bridge_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Detection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Detection) ProtoMessage() {}
+
+func (x *Detection) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Detection.ProtoReflect.Descriptor instead.
+func (*Detection) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Detection) GetChainType() string {
+	if x != nil {
+		return x.ChainType
+	}
+	return ""
+}
+
+func (x *Detection) GetEncoding() string {
+	if x != nil {
+		return x.Encoding
+	}
+	return ""
+}
+
+func (x *Detection) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *Detection) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// A lint finding on a canonical message.
+type LintIssue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Severity      string                 `protobuf:"bytes,2,opt,name=severity,proto3" json:"severity,omitempty"`
+	Code          string                 `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	Suggestion    string                 `protobuf:"bytes,5,opt,name=suggestion,proto3" json:"suggestion,omitempty"`
+	Fixable       bool                   `protobuf:"varint,6,opt,name=fixable,proto3" json:"fixable,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LintIssue) Reset() {
+	*x = LintIssue{}
+	mi := &file_bridge_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LintIssue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LintIssue) ProtoMessage() {}
+
+func (x *LintIssue) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LintIssue.ProtoReflect.Descriptor instead.
+func (*LintIssue) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LintIssue) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *LintIssue) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *LintIssue) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *LintIssue) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *LintIssue) GetSuggestion() string {
+	if x != nil {
+		return x.Suggestion
+	}
+	return ""
+}
+
+func (x *LintIssue) GetFixable() bool {
+	if x != nil {
+		return x.Fixable
+	}
+	return false
+}
+
+// Each stage a raw message would pass through. error is set at the first stage that failed; earlier fields
+// are still filled in.
+type ExplainResponse struct {
+	state         protoimpl.MessageState        `protogen:"open.v1"`
+	Chain         string                        `protobuf:"bytes,1,opt,name=chain,proto3" json:"chain,omitempty"`
+	Detection     *Detection                    `protobuf:"bytes,2,opt,name=detection,proto3" json:"detection,omitempty"`
+	Canonical     *canonicalpb.CanonicalMessage `protobuf:"bytes,3,opt,name=canonical,proto3" json:"canonical,omitempty"`
+	Issues        []*LintIssue                  `protobuf:"bytes,4,rep,name=issues,proto3" json:"issues,omitempty"`
+	Error         string                        `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExplainResponse) Reset() {
+	*x = ExplainResponse{}
+	mi := &file_bridge_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExplainResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExplainResponse) ProtoMessage() {}
+
+func (x *ExplainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExplainResponse.ProtoReflect.Descriptor instead.
+func (*ExplainResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ExplainResponse) GetChain() string {
+	if x != nil {
+		return x.Chain
+	}
+	return ""
+}
+
+func (x *ExplainResponse) GetDetection() *Detection {
+	if x != nil {
+		return x.Detection
+	}
+	return nil
+}
+
+func (x *ExplainResponse) GetCanonical() *canonicalpb.CanonicalMessage {
+	if x != nil {
+		return x.Canonical
+	}
+	return nil
+}
+
+func (x *ExplainResponse) GetIssues() []*LintIssue {
+	if x != nil {
+		return x.Issues
+	}
+	return nil
+}
+
+func (x *ExplainResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type SubmitRequest struct {
+	state         protoimpl.MessageState           `protogen:"open.v1"`
+	Raw           *canonicalpb.RawConsensusMessage `protobuf:"bytes,1,opt,name=raw,proto3" json:"raw,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitRequest) Reset() {
+	*x = SubmitRequest{}
+	mi := &file_bridge_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitRequest) ProtoMessage() {}
+
+func (x *SubmitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitRequest.ProtoReflect.Descriptor instead.
+func (*SubmitRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SubmitRequest) GetRaw() *canonicalpb.RawConsensusMessage {
+	if x != nil {
+		return x.Raw
+	}
+	return nil
+}
+
+// The event the submitted message became.
+type SubmitResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Event         *Event                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitResponse) Reset() {
+	*x = SubmitResponse{}
+	mi := &file_bridge_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitResponse) ProtoMessage() {}
+
+func (x *SubmitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitResponse.ProtoReflect.Descriptor instead.
+func (*SubmitResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SubmitResponse) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+// Summary of an IngestRaw stream once the collector closes it.
+type IngestResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Accepted int32                  `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	// Messages the bridge could not process; they do not end the stream.
+	Rejected []*IngestError `protobuf:"bytes,2,rep,name=rejected,proto3" json:"rejected,omitempty"`
+	// Event sequence number of the last accepted message.
+	LastSeq       uint64 `protobuf:"varint,3,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestResponse) Reset() {
+	*x = IngestResponse{}
+	mi := &file_bridge_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestResponse) ProtoMessage() {}
+
+func (x *IngestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestResponse.ProtoReflect.Descriptor instead.
+func (*IngestResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *IngestResponse) GetAccepted() int32 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *IngestResponse) GetRejected() []*IngestError {
+	if x != nil {
+		return x.Rejected
+	}
+	return nil
+}
+
+func (x *IngestResponse) GetLastSeq() uint64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+type IngestError struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Counts the stream's messages from zero.
+	Index         int32  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Error         string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestError) Reset() {
+	*x = IngestError{}
+	mi := &file_bridge_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestError) ProtoMessage() {}
+
+func (x *IngestError) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestError.ProtoReflect.Descriptor instead.
+func (*IngestError) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *IngestError) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *IngestError) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ConvertRequest struct {
+	state         protoimpl.MessageState        `protogen:"open.v1"`
+	Canonical     *canonicalpb.CanonicalMessage `protobuf:"bytes,1,opt,name=canonical,proto3" json:"canonical,omitempty"`
+	TargetChain   string                        `protobuf:"bytes,2,opt,name=target_chain,json=targetChain,proto3" json:"target_chain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertRequest) Reset() {
+	*x = ConvertRequest{}
+	mi := &file_bridge_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertRequest) ProtoMessage() {}
+
+func (x *ConvertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertRequest.ProtoReflect.Descriptor instead.
+func (*ConvertRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ConvertRequest) GetCanonical() *canonicalpb.CanonicalMessage {
+	if x != nil {
+		return x.Canonical
+	}
+	return nil
+}
+
+func (x *ConvertRequest) GetTargetChain() string {
+	if x != nil {
+		return x.TargetChain
+	}
+	return ""
+}
+
+type ConvertResponse struct {
+	state         protoimpl.MessageState           `protogen:"open.v1"`
+	Raw           *canonicalpb.RawConsensusMessage `protobuf:"bytes,1,opt,name=raw,proto3" json:"raw,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertResponse) Reset() {
+	*x = ConvertResponse{}
+	mi := &file_bridge_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertResponse) ProtoMessage() {}
+
+func (x *ConvertResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertResponse.ProtoReflect.Descriptor instead.
+func (*ConvertResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ConvertResponse) GetRaw() *canonicalpb.RawConsensusMessage {
+	if x != nil {
+		return x.Raw
+	}
+	return nil
+}
+
+// Scenario step options of a byzantine action.
+type StepOptions struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	AlternateBlockHash string                 `protobuf:"bytes,1,opt,name=alternate_block_hash,json=alternateBlockHash,proto3" json:"alternate_block_hash,omitempty"`
+	AlternatePrevHash  string                 `protobuf:"bytes,2,opt,name=alternate_prev_hash,json=alternatePrevHash,proto3" json:"alternate_prev_hash,omitempty"`
+	AlternateSignature string                 `protobuf:"bytes,3,opt,name=alternate_signature,json=alternateSignature,proto3" json:"alternate_signature,omitempty"`
+	AlternateValidator string                 `protobuf:"bytes,4,opt,name=alternate_validator,json=alternateValidator,proto3" json:"alternate_validator,omitempty"`
+	RoundOffset        int64                  `protobuf:"varint,5,opt,name=round_offset,json=roundOffset,proto3" json:"round_offset,omitempty"`
+	HeightOffset       int64                  `protobuf:"varint,6,opt,name=height_offset,json=heightOffset,proto3" json:"height_offset,omitempty"`
+	TimestampShift     *durationpb.Duration   `protobuf:"bytes,7,opt,name=timestamp_shift,json=timestampShift,proto3" json:"timestamp_shift,omitempty"`
+	EmitBoth           bool                   `protobuf:"varint,8,opt,name=emit_both,json=emitBoth,proto3" json:"emit_both,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *StepOptions) Reset() {
+	*x = StepOptions{}
+	mi := &file_bridge_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StepOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StepOptions) ProtoMessage() {}
+
+func (x *StepOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StepOptions.ProtoReflect.Descriptor instead.
+func (*StepOptions) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *StepOptions) GetAlternateBlockHash() string {
+	if x != nil {
+		return x.AlternateBlockHash
+	}
+	return ""
+}
+
+func (x *StepOptions) GetAlternatePrevHash() string {
+	if x != nil {
+		return x.AlternatePrevHash
+	}
+	return ""
+}
+
+func (x *StepOptions) GetAlternateSignature() string {
+	if x != nil {
+		return x.AlternateSignature
+	}
+	return ""
+}
+
+func (x *StepOptions) GetAlternateValidator() string {
+	if x != nil {
+		return x.AlternateValidator
+	}
+	return ""
+}
+
+func (x *StepOptions) GetRoundOffset() int64 {
+	if x != nil {
+		return x.RoundOffset
+	}
+	return 0
+}
+
+func (x *StepOptions) GetHeightOffset() int64 {
+	if x != nil {
+		return x.HeightOffset
+	}
+	return 0
+}
+
+func (x *StepOptions) GetTimestampShift() *durationpb.Duration {
+	if x != nil {
+		return x.TimestampShift
+	}
+	return nil
+}
+
+func (x *StepOptions) GetEmitBoth() bool {
+	if x != nil {
+		return x.EmitBoth
+	}
+	return false
+}
+
+type AttackRequest struct {
+	state       protoimpl.MessageState        `protogen:"open.v1"`
+	Canonical   *canonicalpb.CanonicalMessage `protobuf:"bytes,1,opt,name=canonical,proto3" json:"canonical,omitempty"`
+	TargetChain string                        `protobuf:"bytes,2,opt,name=target_chain,json=targetChain,proto3" json:"target_chain,omitempty"`
+	Action      string                        `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"`
+	Options     *StepOptions                  `protobuf:"bytes,4,opt,name=options,proto3" json:"options,omitempty"`
+	Params      map[string]string             `protobuf:"bytes,5,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Feeds the forged messages through the bridge's routing rules and history, marked as forged.
+	Route         bool `protobuf:"varint,6,opt,name=route,proto3" json:"route,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttackRequest) Reset() {
+	*x = AttackRequest{}
+	mi := &file_bridge_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttackRequest) ProtoMessage() {}
+
+func (x *AttackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttackRequest.ProtoReflect.Descriptor instead.
+func (*AttackRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AttackRequest) GetCanonical() *canonicalpb.CanonicalMessage {
+	if x != nil {
+		return x.Canonical
+	}
+	return nil
+}
+
+func (x *AttackRequest) GetTargetChain() string {
+	if x != nil {
+		return x.TargetChain
+	}
+	return ""
+}
+
+func (x *AttackRequest) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *AttackRequest) GetOptions() *StepOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *AttackRequest) GetParams() map[string]string {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *AttackRequest) GetRoute() bool {
+	if x != nil {
+		return x.Route
+	}
+	return false
+}
+
+// The forged messages in canonical and encoded form.
+type AttackResponse struct {
+	state         protoimpl.MessageState             `protogen:"open.v1"`
+	Canonicals    []*canonicalpb.CanonicalMessage    `protobuf:"bytes,1,rep,name=canonicals,proto3" json:"canonicals,omitempty"`
+	Raws          []*canonicalpb.RawConsensusMessage `protobuf:"bytes,2,rep,name=raws,proto3" json:"raws,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttackResponse) Reset() {
+	*x = AttackResponse{}
+	mi := &file_bridge_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttackResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttackResponse) ProtoMessage() {}
+
+func (x *AttackResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttackResponse.ProtoReflect.Descriptor instead.
+func (*AttackResponse) Descriptor() ([]byte, []int) {
+	return file_bridge_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *AttackResponse) GetCanonicals() []*canonicalpb.CanonicalMessage {
+	if x != nil {
+		return x.Canonicals
+	}
+	return nil
+}
+
+func (x *AttackResponse) GetRaws() []*canonicalpb.RawConsensusMessage {
+	if x != nil {
+		return x.Raws
+	}
+	return nil
+}
+
+var File_bridge_proto protoreflect.FileDescriptor
+
+const file_bridge_proto_rawDesc = "" +
+	"\n" +
+	"\fbridge.proto\x12\x0fcodec.bridge.v1\x1a\x0fcanonical.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9a\x01\n" +
+	"\x06Filter\x12\x14\n" +
+	"\x05chain\x18\x01 \x01(\tR\x05chain\x12\x14\n" +
+	"\x05types\x18\x02 \x03(\tR\x05types\x12$\n" +
+	"\vfrom_height\x18\x03 \x01(\x03H\x00R\n" +
+	"fromHeight\x88\x01\x01\x12 \n" +
+	"\tto_height\x18\x04 \x01(\x03H\x01R\btoHeight\x88\x01\x01B\x0e\n" +
+	"\f_from_heightB\f\n" +
+	"\n" +
+	"_to_height\"\xe5\x01\n" +
+	"\x05Event\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x14\n" +
+	"\x05chain\x18\x02 \x01(\tR\x05chain\x126\n" +
+	"\breceived\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\breceived\x12F\n" +
+	"\tcanonical\x18\x04 \x01(\v2(.byzantine.canonical.v1.CanonicalMessageR\tcanonical\x12\x16\n" +
+	"\x06forged\x18\x05 \x01(\bR\x06forged\x12\x1c\n" +
+	"\tduplicate\x18\x06 \x01(\bR\tduplicate\"[\n" +
+	"\x10SubscribeRequest\x12/\n" +
+	"\x06filter\x18\x01 \x01(\v2\x17.codec.bridge.v1.FilterR\x06filter\x12\x16\n" +
+	"\x06replay\x18\x02 \x01(\bR\x06replay\"U\n" +
+	"\fQueryRequest\x12/\n" +
+	"\x06filter\x18\x01 \x01(\v2\x17.codec.bridge.v1.FilterR\x06filter\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"?\n" +
+	"\rQueryResponse\x12.\n" +
+	"\x06events\x18\x01 \x03(\v2\x16.codec.bridge.v1.EventR\x06events\"O\n" +
+	"\x0eExplainRequest\x12=\n" +
+	"\x03raw\x18\x01 \x01(\v2+.byzantine.canonical.v1.RawConsensusMessageR\x03raw\"~\n" +
+	"\tDetection\x12\x1d\n" +
+	"\n" +
+	"chain_type\x18\x01 \x01(\tR\tchainType\x12\x1a\n" +
+	"\bencoding\x18\x02 \x01(\tR\bencoding\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x03 \x01(\x01R\n" +
+	"confidence\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\xa5\x01\n" +
+	"\tLintIssue\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x1a\n" +
+	"\bseverity\x18\x02 \x01(\tR\bseverity\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12\x1e\n" +
+	"\n" +
+	"suggestion\x18\x05 \x01(\tR\n" +
+	"suggestion\x12\x18\n" +
+	"\afixable\x18\x06 \x01(\bR\afixable\"\xf3\x01\n" +
+	"\x0fExplainResponse\x12\x14\n" +
+	"\x05chain\x18\x01 \x01(\tR\x05chain\x128\n" +
+	"\tdetection\x18\x02 \x01(\v2\x1a.codec.bridge.v1.DetectionR\tdetection\x12F\n" +
+	"\tcanonical\x18\x03 \x01(\v2(.byzantine.canonical.v1.CanonicalMessageR\tcanonical\x122\n" +
+	"\x06issues\x18\x04 \x03(\v2\x1a.codec.bridge.v1.LintIssueR\x06issues\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\"N\n" +
+	"\rSubmitRequest\x12=\n" +
+	"\x03raw\x18\x01 \x01(\v2+.byzantine.canonical.v1.RawConsensusMessageR\x03raw\">\n" +
+	"\x0eSubmitResponse\x12,\n" +
+	"\x05event\x18\x01 \x01(\v2\x16.codec.bridge.v1.EventR\x05event\"\x81\x01\n" +
+	"\x0eIngestResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\x05R\baccepted\x128\n" +
+	"\brejected\x18\x02 \x03(\v2\x1c.codec.bridge.v1.IngestErrorR\brejected\x12\x19\n" +
+	"\blast_seq\x18\x03 \x01(\x04R\alastSeq\"9\n" +
+	"\vIngestError\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"{\n" +
+	"\x0eConvertRequest\x12F\n" +
+	"\tcanonical\x18\x01 \x01(\v2(.byzantine.canonical.v1.CanonicalMessageR\tcanonical\x12!\n" +
+	"\ftarget_chain\x18\x02 \x01(\tR\vtargetChain\"P\n" +
+	"\x0fConvertResponse\x12=\n" +
+	"\x03raw\x18\x01 \x01(\v2+.byzantine.canonical.v1.RawConsensusMessageR\x03raw\"\xfa\x02\n" +
+	"\vStepOptions\x120\n" +
+	"\x14alternate_block_hash\x18\x01 \x01(\tR\x12alternateBlockHash\x12.\n" +
+	"\x13alternate_prev_hash\x18\x02 \x01(\tR\x11alternatePrevHash\x12/\n" +
+	"\x13alternate_signature\x18\x03 \x01(\tR\x12alternateSignature\x12/\n" +
+	"\x13alternate_validator\x18\x04 \x01(\tR\x12alternateValidator\x12!\n" +
+	"\fround_offset\x18\x05 \x01(\x03R\vroundOffset\x12#\n" +
+	"\rheight_offset\x18\x06 \x01(\x03R\fheightOffset\x12B\n" +
+	"\x0ftimestamp_shift\x18\a \x01(\v2\x19.google.protobuf.DurationR\x0etimestampShift\x12\x1b\n" +
+	"\temit_both\x18\b \x01(\bR\bemitBoth\"\xdf\x02\n" +
+	"\rAttackRequest\x12F\n" +
+	"\tcanonical\x18\x01 \x01(\v2(.byzantine.canonical.v1.CanonicalMessageR\tcanonical\x12!\n" +
+	"\ftarget_chain\x18\x02 \x01(\tR\vtargetChain\x12\x16\n" +
+	"\x06action\x18\x03 \x01(\tR\x06action\x126\n" +
+	"\aoptions\x18\x04 \x01(\v2\x1c.codec.bridge.v1.StepOptionsR\aoptions\x12B\n" +
+	"\x06params\x18\x05 \x03(\v2*.codec.bridge.v1.AttackRequest.ParamsEntryR\x06params\x12\x14\n" +
+	"\x05route\x18\x06 \x01(\bR\x05route\x1a9\n" +
+	"\vParamsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x9b\x01\n" +
+	"\x0eAttackResponse\x12H\n" +
+	"\n" +
+	"canonicals\x18\x01 \x03(\v2(.byzantine.canonical.v1.CanonicalMessageR\n" +
+	"canonicals\x12?\n" +
+	"\x04raws\x18\x02 \x03(\v2+.byzantine.canonical.v1.RawConsensusMessageR\x04raws2\xf1\x01\n" +
+	"\x06Viewer\x12Q\n" +
+	"\x12SubscribeCanonical\x12!.codec.bridge.v1.SubscribeRequest\x1a\x16.codec.bridge.v1.Event0\x01\x12F\n" +
+	"\x05Query\x12\x1d.codec.bridge.v1.QueryRequest\x1a\x1e.codec.bridge.v1.QueryResponse\x12L\n" +
+	"\aExplain\x12\x1f.codec.bridge.v1.ExplainRequest\x1a .codec.bridge.v1.ExplainResponse2\xcb\x02\n" +
+	"\bOperator\x12I\n" +
+	"\x06Submit\x12\x1e.codec.bridge.v1.SubmitRequest\x1a\x1f.codec.bridge.v1.SubmitResponse\x12[\n" +
+	"\tIngestRaw\x12+.byzantine.canonical.v1.RawConsensusMessage\x1a\x1f.codec.bridge.v1.IngestResponse(\x01\x12L\n" +
+	"\aConvert\x12\x1f.codec.bridge.v1.ConvertRequest\x1a .codec.bridge.v1.ConvertResponse\x12I\n" +
+	"\x06Attack\x12\x1e.codec.bridge.v1.AttackRequest\x1a\x1f.codec.bridge.v1.AttackResponseB.Z,codec/message/abstraction/bridgeapi/bridgepbb\x06proto3"
+
+var (
+	file_bridge_proto_rawDescOnce sync.Once
+	file_bridge_proto_rawDescData []byte
+)
+
+func file_bridge_proto_rawDescGZIP() []byte {
+	file_bridge_proto_rawDescOnce.Do(func() {
+		file_bridge_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_bridge_proto_rawDesc), len(file_bridge_proto_rawDesc)))
+	})
+	return file_bridge_proto_rawDescData
+}
+
+var file_bridge_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_bridge_proto_goTypes = []any{
+	(*Filter)(nil),                          // 0: codec.bridge.v1.Filter
+	(*Event)(nil),                           // 1: codec.bridge.v1.Event
+	(*SubscribeRequest)(nil),                // 2: codec.bridge.v1.SubscribeRequest
+	(*QueryRequest)(nil),                    // 3: codec.bridge.v1.QueryRequest
+	(*QueryResponse)(nil),                   // 4: codec.bridge.v1.QueryResponse
+	(*ExplainRequest)(nil),                  // 5: codec.bridge.v1.ExplainRequest
+	(*Detection)(nil),                       // 6: codec.bridge.v1.Detection
+	(*LintIssue)(nil),                       // 7: codec.bridge.v1.LintIssue
+	(*ExplainResponse)(nil),                 // 8: codec.bridge.v1.ExplainResponse
+	(*SubmitRequest)(nil),                   // 9: codec.bridge.v1.SubmitRequest
+	(*SubmitResponse)(nil),                  // 10: codec.bridge.v1.SubmitResponse
+	(*IngestResponse)(nil),                  // 11: codec.bridge.v1.IngestResponse
+	(*IngestError)(nil),                     // 12: codec.bridge.v1.IngestError
+	(*ConvertRequest)(nil),                  // 13: codec.bridge.v1.ConvertRequest
+	(*ConvertResponse)(nil),                 // 14: codec.bridge.v1.ConvertResponse
+	(*StepOptions)(nil),                     // 15: codec.bridge.v1.StepOptions
+	(*AttackRequest)(nil),                   // 16: codec.bridge.v1.AttackRequest
+	(*AttackResponse)(nil),                  // 17: codec.bridge.v1.AttackResponse
+	nil,                                     // 18: codec.bridge.v1.AttackRequest.ParamsEntry
+	(*timestamppb.Timestamp)(nil),           // 19: google.protobuf.Timestamp
+	(*canonicalpb.CanonicalMessage)(nil),    // 20: byzantine.canonical.v1.CanonicalMessage
+	(*canonicalpb.RawConsensusMessage)(nil), // 21: byzantine.canonical.v1.RawConsensusMessage
+	(*durationpb.Duration)(nil),             // 22: google.protobuf.Duration
+}
+var file_bridge_proto_depIdxs = []int32{
+	19, // 0: codec.bridge.v1.Event.received:type_name -> google.protobuf.Timestamp
+	20, // 1: codec.bridge.v1.Event.canonical:type_name -> byzantine.canonical.v1.CanonicalMessage
+	0,  // 2: codec.bridge.v1.SubscribeRequest.filter:type_name -> codec.bridge.v1.Filter
+	0,  // 3: codec.bridge.v1.QueryRequest.filter:type_name -> codec.bridge.v1.Filter
+	1,  // 4: codec.bridge.v1.QueryResponse.events:type_name -> codec.bridge.v1.Event
+	21, // 5: codec.bridge.v1.ExplainRequest.raw:type_name -> byzantine.canonical.v1.RawConsensusMessage
+	6,  // 6: codec.bridge.v1.ExplainResponse.detection:type_name -> codec.bridge.v1.Detection
+	20, // 7: codec.bridge.v1.ExplainResponse.canonical:type_name -> byzantine.canonical.v1.CanonicalMessage
+	7,  // 8: codec.bridge.v1.ExplainResponse.issues:type_name -> codec.bridge.v1.LintIssue
+	21, // 9: codec.bridge.v1.SubmitRequest.raw:type_name -> byzantine.canonical.v1.RawConsensusMessage
+	1,  // 10: codec.bridge.v1.SubmitResponse.event:type_name -> codec.bridge.v1.Event
+	12, // 11: codec.bridge.v1.IngestResponse.rejected:type_name -> codec.bridge.v1.IngestError
+	20, // 12: codec.bridge.v1.ConvertRequest.canonical:type_name -> byzantine.canonical.v1.CanonicalMessage
+	21, // 13: codec.bridge.v1.ConvertResponse.raw:type_name -> byzantine.canonical.v1.RawConsensusMessage
+	22, // 14: codec.bridge.v1.StepOptions.timestamp_shift:type_name -> google.protobuf.Duration
+	20, // 15: codec.bridge.v1.AttackRequest.canonical:type_name -> byzantine.canonical.v1.CanonicalMessage
+	15, // 16: codec.bridge.v1.AttackRequest.options:type_name -> codec.bridge.v1.StepOptions
+	18, // 17: codec.bridge.v1.AttackRequest.params:type_name -> codec.bridge.v1.AttackRequest.ParamsEntry
+	20, // 18: codec.bridge.v1.AttackResponse.canonicals:type_name -> byzantine.canonical.v1.CanonicalMessage
+	21, // 19: codec.bridge.v1.AttackResponse.raws:type_name -> byzantine.canonical.v1.RawConsensusMessage
+	2,  // 20: codec.bridge.v1.Viewer.SubscribeCanonical:input_type -> codec.bridge.v1.SubscribeRequest
+	3,  // 21: codec.bridge.v1.Viewer.Query:input_type -> codec.bridge.v1.QueryRequest
+	5,  // 22: codec.bridge.v1.Viewer.Explain:input_type -> codec.bridge.v1.ExplainRequest
+	9,  // 23: codec.bridge.v1.Operator.Submit:input_type -> codec.bridge.v1.SubmitRequest
+	21, // 24: codec.bridge.v1.Operator.IngestRaw:input_type -> byzantine.canonical.v1.RawConsensusMessage
+	13, // 25: codec.bridge.v1.Operator.Convert:input_type -> codec.bridge.v1.ConvertRequest
+	16, // 26: codec.bridge.v1.Operator.Attack:input_type -> codec.bridge.v1.AttackRequest
+	1,  // 27: codec.bridge.v1.Viewer.SubscribeCanonical:output_type -> codec.bridge.v1.Event
+	4,  // 28: codec.bridge.v1.Viewer.Query:output_type -> codec.bridge.v1.QueryResponse
+	8,  // 29: codec.bridge.v1.Viewer.Explain:output_type -> codec.bridge.v1.ExplainResponse
+	10, // 30: codec.bridge.v1.Operator.Submit:output_type -> codec.bridge.v1.SubmitResponse
+	11, // 31: codec.bridge.v1.Operator.IngestRaw:output_type -> codec.bridge.v1.IngestResponse
+	14, // 32: codec.bridge.v1.Operator.Convert:output_type -> codec.bridge.v1.ConvertResponse
+	17, // 33: codec.bridge.v1.Operator.Attack:output_type -> codec.bridge.v1.AttackResponse
+	27, // [27:34] is the sub-list for method output_type
+	20, // [20:27] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
+}
+
+func init() { file_bridge_proto_init() }
+func file_bridge_proto_init() {
+	if File_bridge_proto != nil {
+		return
+	}
+	file_bridge_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bridge_proto_rawDesc), len(file_bridge_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_bridge_proto_goTypes,
+		DependencyIndexes: file_bridge_proto_depIdxs,
+		MessageInfos:      file_bridge_proto_msgTypes,
+	}.Build()
+	File_bridge_proto = out.File
+	file_bridge_proto_goTypes = nil
+	file_bridge_proto_depIdxs = nil
+}
@@ -0,0 +1,437 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: bridge.proto
+
+// Bridge service API, split so read-only consumers can be given access without being able to inject
+// traffic. message/abstraction/bridgeapi wraps the generated code; see docs/bridge_api.md.
+
+package bridgepb
+
+import (
+	canonicalpb "codec/message/abstraction/canonical/canonicalpb"
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Viewer_SubscribeCanonical_FullMethodName = "/codec.bridge.v1.Viewer/SubscribeCanonical"
+	Viewer_Query_FullMethodName              = "/codec.bridge.v1.Viewer/Query"
+	Viewer_Explain_FullMethodName            = "/codec.bridge.v1.Viewer/Explain"
+)
+
+// ViewerClient is the client API for Viewer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Viewer only observes what the bridge has processed.
+type ViewerClient interface {
+	// Live processed messages matching a filter, optionally after the retained history.
+	SubscribeCanonical(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	// Retained history matching a filter.
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error)
+	// How the bridge would interpret a raw message, without processing it.
+	Explain(ctx context.Context, in *ExplainRequest, opts ...grpc.CallOption) (*ExplainResponse, error)
+}
+
+type viewerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewViewerClient(cc grpc.ClientConnInterface) ViewerClient {
+	return &viewerClient{cc}
+}
+
+func (c *viewerClient) SubscribeCanonical(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Viewer_ServiceDesc.Streams[0], Viewer_SubscribeCanonical_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Viewer_SubscribeCanonicalClient = grpc.ServerStreamingClient[Event]
+
+func (c *viewerClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueryResponse)
+	err := c.cc.Invoke(ctx, Viewer_Query_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *viewerClient) Explain(ctx context.Context, in *ExplainRequest, opts ...grpc.CallOption) (*ExplainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExplainResponse)
+	err := c.cc.Invoke(ctx, Viewer_Explain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ViewerServer is the server API for Viewer service.
+// All implementations must embed UnimplementedViewerServer
+// for forward compatibility.
+//
+// Viewer only observes what the bridge has processed.
+type ViewerServer interface {
+	// Live processed messages matching a filter, optionally after the retained history.
+	SubscribeCanonical(*SubscribeRequest, grpc.ServerStreamingServer[Event]) error
+	// Retained history matching a filter.
+	Query(context.Context, *QueryRequest) (*QueryResponse, error)
+	// How the bridge would interpret a raw message, without processing it.
+	Explain(context.Context, *ExplainRequest) (*ExplainResponse, error)
+	mustEmbedUnimplementedViewerServer()
+}
+
+// UnimplementedViewerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedViewerServer struct{}
+
+func (UnimplementedViewerServer) SubscribeCanonical(*SubscribeRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeCanonical not implemented")
+}
+func (UnimplementedViewerServer) Query(context.Context, *QueryRequest) (*QueryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedViewerServer) Explain(context.Context, *ExplainRequest) (*ExplainResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Explain not implemented")
+}
+func (UnimplementedViewerServer) mustEmbedUnimplementedViewerServer() {}
+func (UnimplementedViewerServer) testEmbeddedByValue()                {}
+
+// UnsafeViewerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ViewerServer will
+// result in compilation errors.
+type UnsafeViewerServer interface {
+	mustEmbedUnimplementedViewerServer()
+}
+
+func RegisterViewerServer(s grpc.ServiceRegistrar, srv ViewerServer) {
+	// If the following call pancis, it indicates UnimplementedViewerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Viewer_ServiceDesc, srv)
+}
+
+func _Viewer_SubscribeCanonical_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ViewerServer).SubscribeCanonical(m, &grpc.GenericServerStream[SubscribeRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Viewer_SubscribeCanonicalServer = grpc.ServerStreamingServer[Event]
+
+func _Viewer_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ViewerServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Viewer_Query_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ViewerServer).Query(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Viewer_Explain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExplainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ViewerServer).Explain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Viewer_Explain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ViewerServer).Explain(ctx, req.(*ExplainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Viewer_ServiceDesc is the grpc.ServiceDesc for Viewer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Viewer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "codec.bridge.v1.Viewer",
+	HandlerType: (*ViewerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Query",
+			Handler:    _Viewer_Query_Handler,
+		},
+		{
+			MethodName: "Explain",
+			Handler:    _Viewer_Explain_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeCanonical",
+			Handler:       _Viewer_SubscribeCanonical_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "bridge.proto",
+}
+
+const (
+	Operator_Submit_FullMethodName    = "/codec.bridge.v1.Operator/Submit"
+	Operator_IngestRaw_FullMethodName = "/codec.bridge.v1.Operator/IngestRaw"
+	Operator_Convert_FullMethodName   = "/codec.bridge.v1.Operator/Convert"
+	Operator_Attack_FullMethodName    = "/codec.bridge.v1.Operator/Attack"
+)
+
+// OperatorClient is the client API for Operator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Operator feeds messages into the bridge or forges new ones.
+type OperatorClient interface {
+	// Ingests a raw message as if a collector had delivered it.
+	Submit(ctx context.Context, in *SubmitRequest, opts ...grpc.CallOption) (*SubmitResponse, error)
+	// Ingests a collector's stream of raw messages.
+	IngestRaw(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[canonicalpb.RawConsensusMessage, IngestResponse], error)
+	// Encodes a canonical message for a configured chain.
+	Convert(ctx context.Context, in *ConvertRequest, opts ...grpc.CallOption) (*ConvertResponse, error)
+	// Applies a byzantine action to a canonical message and encodes the result for a configured chain.
+	Attack(ctx context.Context, in *AttackRequest, opts ...grpc.CallOption) (*AttackResponse, error)
+}
+
+type operatorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOperatorClient(cc grpc.ClientConnInterface) OperatorClient {
+	return &operatorClient{cc}
+}
+
+func (c *operatorClient) Submit(ctx context.Context, in *SubmitRequest, opts ...grpc.CallOption) (*SubmitResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitResponse)
+	err := c.cc.Invoke(ctx, Operator_Submit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) IngestRaw(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[canonicalpb.RawConsensusMessage, IngestResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Operator_ServiceDesc.Streams[0], Operator_IngestRaw_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[canonicalpb.RawConsensusMessage, IngestResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Operator_IngestRawClient = grpc.ClientStreamingClient[canonicalpb.RawConsensusMessage, IngestResponse]
+
+func (c *operatorClient) Convert(ctx context.Context, in *ConvertRequest, opts ...grpc.CallOption) (*ConvertResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConvertResponse)
+	err := c.cc.Invoke(ctx, Operator_Convert_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) Attack(ctx context.Context, in *AttackRequest, opts ...grpc.CallOption) (*AttackResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AttackResponse)
+	err := c.cc.Invoke(ctx, Operator_Attack_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OperatorServer is the server API for Operator service.
+// All implementations must embed UnimplementedOperatorServer
+// for forward compatibility.
+//
+// Operator feeds messages into the bridge or forges new ones.
+type OperatorServer interface {
+	// Ingests a raw message as if a collector had delivered it.
+	Submit(context.Context, *SubmitRequest) (*SubmitResponse, error)
+	// Ingests a collector's stream of raw messages.
+	IngestRaw(grpc.ClientStreamingServer[canonicalpb.RawConsensusMessage, IngestResponse]) error
+	// Encodes a canonical message for a configured chain.
+	Convert(context.Context, *ConvertRequest) (*ConvertResponse, error)
+	// Applies a byzantine action to a canonical message and encodes the result for a configured chain.
+	Attack(context.Context, *AttackRequest) (*AttackResponse, error)
+	mustEmbedUnimplementedOperatorServer()
+}
+
+// UnimplementedOperatorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOperatorServer struct{}
+
+func (UnimplementedOperatorServer) Submit(context.Context, *SubmitRequest) (*SubmitResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Submit not implemented")
+}
+func (UnimplementedOperatorServer) IngestRaw(grpc.ClientStreamingServer[canonicalpb.RawConsensusMessage, IngestResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method IngestRaw not implemented")
+}
+func (UnimplementedOperatorServer) Convert(context.Context, *ConvertRequest) (*ConvertResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Convert not implemented")
+}
+func (UnimplementedOperatorServer) Attack(context.Context, *AttackRequest) (*AttackResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Attack not implemented")
+}
+func (UnimplementedOperatorServer) mustEmbedUnimplementedOperatorServer() {}
+func (UnimplementedOperatorServer) testEmbeddedByValue()                  {}
+
+// UnsafeOperatorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OperatorServer will
+// result in compilation errors.
+type UnsafeOperatorServer interface {
+	mustEmbedUnimplementedOperatorServer()
+}
+
+func RegisterOperatorServer(s grpc.ServiceRegistrar, srv OperatorServer) {
+	// If the following call pancis, it indicates UnimplementedOperatorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Operator_ServiceDesc, srv)
+}
+
+func _Operator_Submit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).Submit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Operator_Submit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).Submit(ctx, req.(*SubmitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_IngestRaw_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(OperatorServer).IngestRaw(&grpc.GenericServerStream[canonicalpb.RawConsensusMessage, IngestResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Operator_IngestRawServer = grpc.ClientStreamingServer[canonicalpb.RawConsensusMessage, IngestResponse]
+
+func _Operator_Convert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConvertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).Convert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Operator_Convert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).Convert(ctx, req.(*ConvertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_Attack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AttackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).Attack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Operator_Attack_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).Attack(ctx, req.(*AttackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Operator_ServiceDesc is the grpc.ServiceDesc for Operator service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Operator_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "codec.bridge.v1.Operator",
+	HandlerType: (*OperatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Submit",
+			Handler:    _Operator_Submit_Handler,
+		},
+		{
+			MethodName: "Convert",
+			Handler:    _Operator_Convert_Handler,
+		},
+		{
+			MethodName: "Attack",
+			Handler:    _Operator_Attack_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "IngestRaw",
+			Handler:       _Operator_IngestRaw_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "bridge.proto",
+}
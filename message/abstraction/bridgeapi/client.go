@@ -0,0 +1,154 @@
+package bridgeapi
+
+import (
+	"context"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/bridgeapi/bridgepb"
+
+	"google.golang.org/grpc"
+)
+
+// ViewerClient calls the read-only service.
+type ViewerClient struct {
+	client bridgepb.ViewerClient
+}
+
+// NewViewerClient wraps a connection to a bridge serving the Viewer.
+func NewViewerClient(conn grpc.ClientConnInterface) *ViewerClient {
+	return &ViewerClient{client: bridgepb.NewViewerClient(conn)}
+}
+
+// EventStream receives events from SubscribeCanonical.
+type EventStream struct {
+	stream bridgepb.Viewer_SubscribeCanonicalClient
+}
+
+// Recv returns the next event, or io.EOF once the server ends the stream.
+func (s *EventStream) Recv() (*Event, error) {
+	pb, err := s.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	return eventFromProto(pb)
+}
+
+// SubscribeCanonical subscribes to processed messages until ctx is cancelled.
+func (c *ViewerClient) SubscribeCanonical(ctx context.Context, req *SubscribeRequest) (*EventStream, error) {
+	stream, err := c.client.SubscribeCanonical(ctx, &bridgepb.SubscribeRequest{Filter: filterToProto(req.Filter), Replay: req.Replay})
+	if err != nil {
+		return nil, err
+	}
+	return &EventStream{stream: stream}, nil
+}
+
+// Query reads the bridge's retained history.
+func (c *ViewerClient) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	resp, err := c.client.Query(ctx, &bridgepb.QueryRequest{Filter: filterToProto(req.Filter), Limit: int32(req.Limit)})
+	if err != nil {
+		return nil, err
+	}
+	events, err := eventsFromProto(resp.GetEvents())
+	if err != nil {
+		return nil, err
+	}
+	return &QueryResponse{Events: events}, nil
+}
+
+// Explain shows how the bridge would interpret a raw message.
+func (c *ViewerClient) Explain(ctx context.Context, req *ExplainRequest) (*ExplainResponse, error) {
+	raw, err := rawToProto(&req.Raw)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Explain(ctx, &bridgepb.ExplainRequest{Raw: raw})
+	if err != nil {
+		return nil, err
+	}
+	return explainFromProto(resp)
+}
+
+// OperatorClient calls the mutating service.
+type OperatorClient struct {
+	client bridgepb.OperatorClient
+}
+
+// NewOperatorClient wraps a connection to a bridge serving the Operator.
+func NewOperatorClient(conn grpc.ClientConnInterface) *OperatorClient {
+	return &OperatorClient{client: bridgepb.NewOperatorClient(conn)}
+}
+
+// Submit ingests a raw message.
+func (c *OperatorClient) Submit(ctx context.Context, req *SubmitRequest) (*SubmitResponse, error) {
+	raw, err := rawToProto(&req.Raw)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Submit(ctx, &bridgepb.SubmitRequest{Raw: raw})
+	if err != nil {
+		return nil, err
+	}
+	ev, err := eventFromProto(resp.GetEvent())
+	if err != nil {
+		return nil, err
+	}
+	return &SubmitResponse{Event: ev}, nil
+}
+
+// IngestStream sends raw messages to IngestRaw.
+type IngestStream struct {
+	stream bridgepb.Operator_IngestRawClient
+}
+
+// Send streams one raw message to the bridge.
+func (s *IngestStream) Send(raw *abstraction.RawConsensusMessage) error {
+	pb, err := rawToProto(raw)
+	if err != nil {
+		return err
+	}
+	return s.stream.Send(pb)
+}
+
+// CloseAndRecv ends the stream and returns the bridge's summary of it.
+func (s *IngestStream) CloseAndRecv() (*IngestResponse, error) {
+	resp, err := s.stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+	return ingestFromProto(resp), nil
+}
+
+// IngestRaw opens a stream for a collector to feed raw messages into the bridge.
+func (c *OperatorClient) IngestRaw(ctx context.Context) (*IngestStream, error) {
+	stream, err := c.client.IngestRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &IngestStream{stream: stream}, nil
+}
+
+// Convert encodes a canonical message for a configured chain.
+func (c *OperatorClient) Convert(ctx context.Context, req *ConvertRequest) (*ConvertResponse, error) {
+	msg, err := canonicalToProto(req.Canonical)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Convert(ctx, &bridgepb.ConvertRequest{Canonical: msg, TargetChain: req.TargetChain})
+	if err != nil {
+		return nil, err
+	}
+	return &ConvertResponse{Raw: rawFromProto(resp.GetRaw())}, nil
+}
+
+// Attack forges messages with a byzantine action.
+func (c *OperatorClient) Attack(ctx context.Context, req *AttackRequest) (*AttackResponse, error) {
+	pb, err := attackToProto(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Attack(ctx, pb)
+	if err != nil {
+		return nil, err
+	}
+	return attackResponseFromProto(resp)
+}
@@ -0,0 +1,282 @@
+package bridgeapi
+
+import (
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/bridgeapi/bridgepb"
+	"codec/message/abstraction/canonical"
+	"codec/message/abstraction/canonical/canonicalpb"
+	"codec/message/abstraction/detect"
+	"codec/message/abstraction/lint"
+	"codec/scenario"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The functions below map the API types to and from their bridgepb wire types. Canonical and raw messages
+// go through package canonical, so they keep the same encoding as the protobuf sinks.
+
+func canonicalToProto(msg *abstraction.CanonicalMessage) (*canonicalpb.CanonicalMessage, error) {
+	if msg == nil {
+		return nil, nil
+	}
+	return canonical.ToProto(msg)
+}
+
+func canonicalFromProto(pb *canonicalpb.CanonicalMessage) (*abstraction.CanonicalMessage, error) {
+	if pb == nil {
+		return nil, nil
+	}
+	return canonical.FromProto(pb)
+}
+
+func rawToProto(raw *abstraction.RawConsensusMessage) (*canonicalpb.RawConsensusMessage, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	return canonical.RawToProto(raw)
+}
+
+func rawFromProto(pb *canonicalpb.RawConsensusMessage) *abstraction.RawConsensusMessage {
+	if pb == nil {
+		return nil
+	}
+	return canonical.RawFromProto(pb)
+}
+
+// rawOrEmpty converts a request's raw message, which the API types hold by value.
+func rawOrEmpty(pb *canonicalpb.RawConsensusMessage) *abstraction.RawConsensusMessage {
+	return canonical.RawFromProto(pb)
+}
+
+func filterToProto(f Filter) *bridgepb.Filter {
+	pb := &bridgepb.Filter{Chain: f.Chain, FromHeight: f.FromHeight, ToHeight: f.ToHeight}
+	for _, t := range f.Types {
+		pb.Types = append(pb.Types, string(t))
+	}
+	return pb
+}
+
+func filterFromProto(pb *bridgepb.Filter) Filter {
+	f := Filter{Chain: pb.GetChain()}
+	for _, t := range pb.GetTypes() {
+		f.Types = append(f.Types, abstraction.MsgType(t))
+	}
+	if pb.FromHeight != nil {
+		from := pb.GetFromHeight()
+		f.FromHeight = &from
+	}
+	if pb.ToHeight != nil {
+		to := pb.GetToHeight()
+		f.ToHeight = &to
+	}
+	return f
+}
+
+func eventToProto(ev *Event) (*bridgepb.Event, error) {
+	if ev == nil {
+		return nil, nil
+	}
+	msg, err := canonicalToProto(ev.Canonical)
+	if err != nil {
+		return nil, err
+	}
+	pb := &bridgepb.Event{Seq: ev.Seq, Chain: ev.Chain, Canonical: msg, Forged: ev.Forged, Duplicate: ev.Duplicate}
+	if !ev.Received.IsZero() {
+		pb.Received = timestamppb.New(ev.Received)
+	}
+	return pb, nil
+}
+
+func eventFromProto(pb *bridgepb.Event) (*Event, error) {
+	if pb == nil {
+		return nil, nil
+	}
+	msg, err := canonicalFromProto(pb.GetCanonical())
+	if err != nil {
+		return nil, err
+	}
+	ev := &Event{Seq: pb.GetSeq(), Chain: pb.GetChain(), Canonical: msg, Forged: pb.GetForged(), Duplicate: pb.GetDuplicate()}
+	if pb.Received != nil {
+		ev.Received = pb.GetReceived().AsTime()
+	}
+	return ev, nil
+}
+
+func eventsToProto(events []*Event) ([]*bridgepb.Event, error) {
+	pbs := make([]*bridgepb.Event, 0, len(events))
+	for _, ev := range events {
+		pb, err := eventToProto(ev)
+		if err != nil {
+			return nil, err
+		}
+		pbs = append(pbs, pb)
+	}
+	return pbs, nil
+}
+
+func eventsFromProto(pbs []*bridgepb.Event) ([]*Event, error) {
+	events := make([]*Event, 0, len(pbs))
+	for _, pb := range pbs {
+		ev, err := eventFromProto(pb)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+func explainToProto(resp *ExplainResponse) (*bridgepb.ExplainResponse, error) {
+	msg, err := canonicalToProto(resp.Canonical)
+	if err != nil {
+		return nil, err
+	}
+	pb := &bridgepb.ExplainResponse{Chain: resp.Chain, Canonical: msg, Error: resp.Error}
+	if d := resp.Detection; d != nil {
+		pb.Detection = &bridgepb.Detection{ChainType: string(d.ChainType), Encoding: d.Encoding, Confidence: d.Confidence, Reason: d.Reason}
+	}
+	for _, issue := range resp.Issues {
+		pb.Issues = append(pb.Issues, &bridgepb.LintIssue{
+			Field:      issue.Field,
+			Severity:   string(issue.Severity),
+			Code:       issue.Code,
+			Message:    issue.Message,
+			Suggestion: issue.Suggestion,
+			Fixable:    issue.Fixable,
+		})
+	}
+	return pb, nil
+}
+
+func explainFromProto(pb *bridgepb.ExplainResponse) (*ExplainResponse, error) {
+	msg, err := canonicalFromProto(pb.GetCanonical())
+	if err != nil {
+		return nil, err
+	}
+	resp := &ExplainResponse{Chain: pb.GetChain(), Canonical: msg, Error: pb.GetError()}
+	if d := pb.GetDetection(); d != nil {
+		resp.Detection = &detect.Result{
+			ChainType:  abstraction.ChainType(d.GetChainType()),
+			Encoding:   d.GetEncoding(),
+			Confidence: d.GetConfidence(),
+			Reason:     d.GetReason(),
+		}
+	}
+	for _, issue := range pb.GetIssues() {
+		resp.Issues = append(resp.Issues, lint.Issue{
+			Field:      issue.GetField(),
+			Severity:   lint.Severity(issue.GetSeverity()),
+			Code:       issue.GetCode(),
+			Message:    issue.GetMessage(),
+			Suggestion: issue.GetSuggestion(),
+			Fixable:    issue.GetFixable(),
+		})
+	}
+	return resp, nil
+}
+
+func ingestToProto(resp *IngestResponse) *bridgepb.IngestResponse {
+	pb := &bridgepb.IngestResponse{Accepted: int32(resp.Accepted), LastSeq: resp.LastSeq}
+	for _, rejected := range resp.Rejected {
+		pb.Rejected = append(pb.Rejected, &bridgepb.IngestError{Index: int32(rejected.Index), Error: rejected.Error})
+	}
+	return pb
+}
+
+func ingestFromProto(pb *bridgepb.IngestResponse) *IngestResponse {
+	resp := &IngestResponse{Accepted: int(pb.GetAccepted()), LastSeq: pb.GetLastSeq()}
+	for _, rejected := range pb.GetRejected() {
+		resp.Rejected = append(resp.Rejected, IngestError{Index: int(rejected.GetIndex()), Error: rejected.GetError()})
+	}
+	return resp
+}
+
+func attackToProto(req *AttackRequest) (*bridgepb.AttackRequest, error) {
+	msg, err := canonicalToProto(req.Canonical)
+	if err != nil {
+		return nil, err
+	}
+	o := req.Options
+	pb := &bridgepb.AttackRequest{
+		Canonical:   msg,
+		TargetChain: req.TargetChain,
+		Action:      req.Action,
+		Options: &bridgepb.StepOptions{
+			AlternateBlockHash: o.AlternateBlockHash,
+			AlternatePrevHash:  o.AlternatePrevHash,
+			AlternateSignature: o.AlternateSignature,
+			AlternateValidator: o.AlternateValidator,
+			RoundOffset:        o.RoundOffset,
+			HeightOffset:       o.HeightOffset,
+			EmitBoth:           o.EmitBoth,
+		},
+		Params: req.Params,
+		Route:  req.Route,
+	}
+	if o.TimestampShift != 0 {
+		pb.Options.TimestampShift = durationpb.New(time.Duration(o.TimestampShift))
+	}
+	return pb, nil
+}
+
+func attackFromProto(pb *bridgepb.AttackRequest) (*AttackRequest, error) {
+	msg, err := canonicalFromProto(pb.GetCanonical())
+	if err != nil {
+		return nil, err
+	}
+	o := pb.GetOptions()
+	return &AttackRequest{
+		Canonical:   msg,
+		TargetChain: pb.GetTargetChain(),
+		Action:      pb.GetAction(),
+		Options: scenario.StepOptions{
+			AlternateBlockHash: o.GetAlternateBlockHash(),
+			AlternatePrevHash:  o.GetAlternatePrevHash(),
+			AlternateSignature: o.GetAlternateSignature(),
+			AlternateValidator: o.GetAlternateValidator(),
+			RoundOffset:        o.GetRoundOffset(),
+			HeightOffset:       o.GetHeightOffset(),
+			TimestampShift:     scenario.Duration(o.GetTimestampShift().AsDuration()),
+			EmitBoth:           o.GetEmitBoth(),
+		},
+		Params: pb.GetParams(),
+		Route:  pb.GetRoute(),
+	}, nil
+}
+
+func attackResponseToProto(resp *AttackResponse) (*bridgepb.AttackResponse, error) {
+	pb := &bridgepb.AttackResponse{}
+	for _, msg := range resp.Canonicals {
+		msgPB, err := canonicalToProto(msg)
+		if err != nil {
+			return nil, err
+		}
+		pb.Canonicals = append(pb.Canonicals, msgPB)
+	}
+	for _, raw := range resp.Raws {
+		rawPB, err := rawToProto(raw)
+		if err != nil {
+			return nil, err
+		}
+		pb.Raws = append(pb.Raws, rawPB)
+	}
+	return pb, nil
+}
+
+func attackResponseFromProto(pb *bridgepb.AttackResponse) (*AttackResponse, error) {
+	resp := &AttackResponse{}
+	for _, msgPB := range pb.GetCanonicals() {
+		msg, err := canonicalFromProto(msgPB)
+		if err != nil {
+			return nil, err
+		}
+		resp.Canonicals = append(resp.Canonicals, msg)
+	}
+	for _, rawPB := range pb.GetRaws() {
+		resp.Raws = append(resp.Raws, rawFromProto(rawPB))
+	}
+	return resp, nil
+}
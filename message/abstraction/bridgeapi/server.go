@@ -0,0 +1,105 @@
+package bridgeapi
+
+import (
+	"context"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/bridgeapi/bridgepb"
+)
+
+// viewerServer serves a Viewer as the generated Viewer service.
+type viewerServer struct {
+	bridgepb.UnimplementedViewerServer
+	impl Viewer
+}
+
+func (s viewerServer) SubscribeCanonical(req *bridgepb.SubscribeRequest, stream bridgepb.Viewer_SubscribeCanonicalServer) error {
+	sub := &SubscribeRequest{Filter: filterFromProto(req.GetFilter()), Replay: req.GetReplay()}
+	return s.impl.SubscribeCanonical(stream.Context(), sub, func(ev *Event) error {
+		pb, err := eventToProto(ev)
+		if err != nil {
+			return err
+		}
+		return stream.Send(pb)
+	})
+}
+
+func (s viewerServer) Query(ctx context.Context, req *bridgepb.QueryRequest) (*bridgepb.QueryResponse, error) {
+	resp, err := s.impl.Query(ctx, &QueryRequest{Filter: filterFromProto(req.GetFilter()), Limit: int(req.GetLimit())})
+	if err != nil {
+		return nil, err
+	}
+	events, err := eventsToProto(resp.Events)
+	if err != nil {
+		return nil, err
+	}
+	return &bridgepb.QueryResponse{Events: events}, nil
+}
+
+func (s viewerServer) Explain(ctx context.Context, req *bridgepb.ExplainRequest) (*bridgepb.ExplainResponse, error) {
+	resp, err := s.impl.Explain(ctx, &ExplainRequest{Raw: *rawOrEmpty(req.GetRaw())})
+	if err != nil {
+		return nil, err
+	}
+	return explainToProto(resp)
+}
+
+// operatorServer serves an Operator as the generated Operator service.
+type operatorServer struct {
+	bridgepb.UnimplementedOperatorServer
+	impl Operator
+}
+
+func (s operatorServer) Submit(ctx context.Context, req *bridgepb.SubmitRequest) (*bridgepb.SubmitResponse, error) {
+	resp, err := s.impl.Submit(ctx, &SubmitRequest{Raw: *rawOrEmpty(req.GetRaw())})
+	if err != nil {
+		return nil, err
+	}
+	ev, err := eventToProto(resp.Event)
+	if err != nil {
+		return nil, err
+	}
+	return &bridgepb.SubmitResponse{Event: ev}, nil
+}
+
+func (s operatorServer) IngestRaw(stream bridgepb.Operator_IngestRawServer) error {
+	resp, err := s.impl.IngestRaw(stream.Context(), func() (*abstraction.RawConsensusMessage, error) {
+		pb, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		return rawOrEmpty(pb), nil
+	})
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(ingestToProto(resp))
+}
+
+func (s operatorServer) Convert(ctx context.Context, req *bridgepb.ConvertRequest) (*bridgepb.ConvertResponse, error) {
+	msg, err := canonicalFromProto(req.GetCanonical())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.impl.Convert(ctx, &ConvertRequest{Canonical: msg, TargetChain: req.GetTargetChain()})
+	if err != nil {
+		return nil, err
+	}
+	raw, err := rawToProto(resp.Raw)
+	if err != nil {
+		return nil, err
+	}
+	return &bridgepb.ConvertResponse{Raw: raw}, nil
+}
+
+func (s operatorServer) Attack(ctx context.Context, req *bridgepb.AttackRequest) (*bridgepb.AttackResponse, error) {
+	attack, err := attackFromProto(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.impl.Attack(ctx, attack)
+	if err != nil {
+		return nil, err
+	}
+	return attackResponseToProto(resp)
+}
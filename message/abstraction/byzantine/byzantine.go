@@ -0,0 +1,588 @@
+// Package byzantine applies chain-agnostic byzantine mutations to canonical consensus messages.
+// Chain adapters only supply an encoder for the mutated messages and, where needed, register
+// extra actions that depend on chain-specific extensions.
+package byzantine
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+// Action describes the manipulation to apply to a canonical message.
+type Action string
+
+const (
+	// ActionNone returns the canonical message without any manipulation.
+	ActionNone Action = "none"
+	// ActionDoubleVote emits two vote messages with conflicting block hashes.
+	ActionDoubleVote Action = "double_vote"
+	// ActionDoubleProposal emits two proposal messages that reference different blocks.
+	ActionDoubleProposal Action = "double_proposal"
+	// ActionAlterValidator rewrites the validator or proposer identity.
+	ActionAlterValidator Action = "alter_validator"
+	// ActionDropSignature removes the signature from the message.
+	ActionDropSignature Action = "drop_signature"
+	// ActionTimestampSkew applies a timestamp shift to the message.
+	ActionTimestampSkew Action = "timestamp_skew"
+	// ActionNilFlip turns a vote for a block into a nil vote and a nil vote into a vote for a block.
+	ActionNilFlip Action = "nil_flip"
+	// ActionHeightFlood emits copies of the message at a range of future or stale heights.
+	ActionHeightFlood Action = "height_flood"
+	// ActionFuzzPayload flips bytes in, truncates, or appends junk to the encoded payload.
+	ActionFuzzPayload Action = "fuzz_payload"
+)
+
+// MaxFloodCopies bounds the number of copies a single height_flood can emit.
+const MaxFloodCopies = 10000
+
+// Options contains optional overrides for the mutated messages.
+type Options struct {
+	AlternateBlockHash string
+	AlternatePrevHash  string
+	AlternateSignature string
+	AlternateValidator string
+	RoundOffset        int64
+	HeightOffset       int64
+	TimestampShift     time.Duration
+	// EmitBoth makes nil_flip return the original vote ahead of the flipped one, so a proxy can send each
+	// version to a different peer.
+	EmitBoth bool
+	// FloodFrom and FloodTo are the inclusive range of height offsets height_flood emits copies at; negative
+	// offsets produce stale heights.
+	FloodFrom int64
+	FloodTo   int64
+	// FuzzSeed seeds fuzz_payload; together with the payload it determines the damage done.
+	FuzzSeed int64
+	// TargetPeers restricts mutations to the listed peers (node IDs or addresses, as the transport reports
+	// them). Targeted peers receive only the conflicting messages an action produces, while every other peer
+	// keeps receiving the original, which splits an equivocation across the network. Empty targets every peer.
+	TargetPeers []string
+	// Params carries parameters for chain-specific actions, keyed by names each action documents.
+	Params map[string]string
+	// Signer, when set, re-signs forged messages so they carry valid signatures. Unmodified copies of the
+	// input and messages whose signature was deliberately dropped are left alone.
+	Signer Signer
+}
+
+// ParseParams parses a comma separated "key=value" list for Options.Params. Only the first "=" splits an
+// entry, so base64 values keep their padding.
+func ParseParams(spec string) (map[string]string, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	params := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid parameter %q (expected key=value)", entry)
+		}
+		params[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return params, nil
+}
+
+// TargetsPeer reports whether mutations should reach a peer known by any of the given identities.
+func (o Options) TargetsPeer(identities ...string) bool {
+	if len(o.TargetPeers) == 0 {
+		return true
+	}
+	for _, target := range o.TargetPeers {
+		for _, id := range identities {
+			if id != "" && strings.EqualFold(strings.TrimSpace(target), id) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Conflicting drops outputs that are unmodified copies of the input, leaving what a targeted peer should see
+// in its place. Outputs are returned unchanged when every one of them is a copy.
+func Conflicting(input *abstraction.CanonicalMessage, outputs []*abstraction.CanonicalMessage) []*abstraction.CanonicalMessage {
+	conflicting := make([]*abstraction.CanonicalMessage, 0, len(outputs))
+	for _, out := range outputs {
+		if !reflect.DeepEqual(out, input) {
+			conflicting = append(conflicting, out)
+		}
+	}
+	if len(conflicting) == 0 {
+		return outputs
+	}
+	return conflicting
+}
+
+// Signer signs a canonical message in place. Implementations are chain specific because sign bytes are.
+type Signer interface {
+	Sign(msg *abstraction.CanonicalMessage) error
+}
+
+// Mutator turns one canonical message into the canonical messages that should be emitted instead.
+type Mutator func(msg *abstraction.CanonicalMessage, opts Options) ([]*abstraction.CanonicalMessage, error)
+
+// PayloadMutator rewrites an encoded payload. Payload actions run after encoding, so they can produce bytes
+// that no canonical message maps to.
+type PayloadMutator func(payload []byte, opts Options) ([]byte, error)
+
+// Encoder converts a canonical message back to a chain's wire format. Every abstraction.Mapper satisfies it.
+type Encoder interface {
+	FromCanonical(msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error)
+}
+
+// Engine holds the set of actions available for a chain.
+type Engine struct {
+	mutators map[Action]Mutator
+	payloads map[Action]PayloadMutator
+	aliases  map[string]Action
+}
+
+// NewEngine creates an engine with the chain-agnostic actions registered.
+func NewEngine() *Engine {
+	e := &Engine{
+		mutators: make(map[Action]Mutator),
+		payloads: make(map[Action]PayloadMutator),
+		aliases:  make(map[string]Action),
+	}
+	e.Register(ActionNone, func(msg *abstraction.CanonicalMessage, _ Options) ([]*abstraction.CanonicalMessage, error) {
+		return []*abstraction.CanonicalMessage{Clone(msg)}, nil
+	})
+	e.Register(ActionDoubleVote, DoubleVote)
+	e.Register(ActionDoubleProposal, DoubleProposal)
+	e.Register(ActionAlterValidator, AlterValidator)
+	e.Register(ActionDropSignature, DropSignature)
+	e.Register(ActionTimestampSkew, TimestampSkew)
+	e.Register(ActionNilFlip, NilFlip)
+	e.Register(ActionHeightFlood, HeightFlood)
+	e.RegisterPayload(ActionFuzzPayload, FuzzPayload)
+	return e
+}
+
+// Register adds or replaces the mutator for an action.
+func (e *Engine) Register(action Action, mutator Mutator) {
+	e.mutators[action] = mutator
+}
+
+// RegisterPayload adds an action that leaves the canonical message alone and rewrites its encoding instead.
+func (e *Engine) RegisterPayload(action Action, mutator PayloadMutator) {
+	e.mutators[action] = func(msg *abstraction.CanonicalMessage, _ Options) ([]*abstraction.CanonicalMessage, error) {
+		return []*abstraction.CanonicalMessage{Clone(msg)}, nil
+	}
+	e.payloads[action] = mutator
+}
+
+// Unregister removes an action that does not apply to the chain.
+func (e *Engine) Unregister(action Action) {
+	delete(e.mutators, action)
+	delete(e.payloads, action)
+}
+
+// IsPayloadAction reports whether the action rewrites encoded payloads rather than canonical messages.
+func (e *Engine) IsPayloadAction(action Action) bool {
+	_, ok := e.payloads[action]
+	return ok
+}
+
+// MutatePayload applies a payload action to an encoded message. Payloads are returned unchanged for other
+// actions, so callers can run every encoded message through it.
+func (e *Engine) MutatePayload(action Action, payload []byte, opts Options) ([]byte, error) {
+	mutator, ok := e.payloads[action]
+	if !ok {
+		return payload, nil
+	}
+	return mutator(payload, opts)
+}
+
+// Alias makes Parse accept name as another spelling of action.
+func (e *Engine) Alias(name string, action Action) {
+	e.aliases[strings.ToLower(name)] = action
+}
+
+// Actions lists the registered actions in sorted order.
+func (e *Engine) Actions() []Action {
+	actions := make([]Action, 0, len(e.mutators))
+	for action := range e.mutators {
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i] < actions[j] })
+	return actions
+}
+
+// Parse converts a CLI string to a registered action.
+func (e *Engine) Parse(value string) (Action, error) {
+	name := strings.ToLower(value)
+	if name == "" {
+		return ActionNone, nil
+	}
+	if action, ok := e.aliases[name]; ok {
+		return action, nil
+	}
+	if _, ok := e.mutators[Action(name)]; ok {
+		return Action(name), nil
+	}
+	return ActionNone, fmt.Errorf("unknown byzantine action: %s", value)
+}
+
+// Apply mutates a canonical message according to the requested action.
+// It returns the set of canonical messages that should subsequently be encoded.
+func (e *Engine) Apply(msg *abstraction.CanonicalMessage, action Action, opts Options) ([]*abstraction.CanonicalMessage, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("canonical message cannot be nil")
+	}
+	mutator, ok := e.mutators[action]
+	if !ok {
+		return nil, fmt.Errorf("unsupported byzantine action: %s", action)
+	}
+	out, err := mutator(msg, opts)
+	if err != nil || opts.Signer == nil {
+		return out, err
+	}
+	for i, mutated := range out {
+		dropped := msg.Signature != "" && mutated.Signature == ""
+		if dropped || reflect.DeepEqual(mutated, msg) {
+			continue
+		}
+		if err := opts.Signer.Sign(mutated); err != nil {
+			return nil, fmt.Errorf("failed to sign byzantine message %d: %w", i+1, err)
+		}
+	}
+	return out, nil
+}
+
+// ApplyAndEncode applies an action and encodes every resulting message with enc.
+func (e *Engine) ApplyAndEncode(enc Encoder, msg *abstraction.CanonicalMessage, action Action, opts Options) ([]*abstraction.RawConsensusMessage, error) {
+	canonicals, err := e.Apply(msg, action, opts)
+	if err != nil {
+		return nil, err
+	}
+	raws, err := Encode(enc, canonicals)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range raws {
+		if raw.Payload, err = e.MutatePayload(action, raw.Payload, opts); err != nil {
+			return nil, err
+		}
+	}
+	return raws, nil
+}
+
+// Encode converts each canonical message with enc.
+func Encode(enc Encoder, canonicals []*abstraction.CanonicalMessage) ([]*abstraction.RawConsensusMessage, error) {
+	raws := make([]*abstraction.RawConsensusMessage, len(canonicals))
+	for i, canonical := range canonicals {
+		raw, err := enc.FromCanonical(canonical)
+		if err != nil {
+			return nil, err
+		}
+		raws[i] = raw
+	}
+	return raws, nil
+}
+
+// IsVote reports whether the canonical type is any flavour of vote.
+func IsVote(t abstraction.MsgType) bool {
+	switch t {
+	case abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit, abstraction.MsgTypeVote,
+		abstraction.MsgTypePrepare, abstraction.MsgTypeCommit:
+		return true
+	}
+	return false
+}
+
+// DoubleVote emits the original vote alongside a copy that votes for a different block.
+func DoubleVote(msg *abstraction.CanonicalMessage, opts Options) ([]*abstraction.CanonicalMessage, error) {
+	if !IsVote(msg.Type) {
+		return nil, fmt.Errorf("double_vote action requires a vote canonical message")
+	}
+
+	original := Clone(msg)
+	mutated := Clone(msg)
+	mutated.BlockHash = AlternateHash(msg.BlockHash, opts.AlternateBlockHash)
+	if opts.AlternateSignature != "" {
+		mutated.Signature = opts.AlternateSignature
+	}
+
+	ApplyCommon(mutated, opts)
+	EnsureTimestampProgress(mutated, msg.Timestamp)
+
+	return []*abstraction.CanonicalMessage{original, mutated}, nil
+}
+
+// DoubleProposal emits the original proposal alongside a copy for a different block and parent.
+func DoubleProposal(msg *abstraction.CanonicalMessage, opts Options) ([]*abstraction.CanonicalMessage, error) {
+	if msg.Type != abstraction.MsgTypeProposal {
+		return nil, fmt.Errorf("double_proposal action requires a proposal canonical message")
+	}
+
+	original := Clone(msg)
+	mutated := Clone(msg)
+	mutated.BlockHash = AlternateHash(msg.BlockHash, opts.AlternateBlockHash)
+	if opts.AlternatePrevHash != "" {
+		mutated.PrevHash = opts.AlternatePrevHash
+	} else {
+		mutated.PrevHash = AlternateHash(msg.PrevHash, "")
+	}
+	if opts.AlternateSignature != "" {
+		mutated.Signature = opts.AlternateSignature
+	}
+
+	ApplyCommon(mutated, opts)
+	EnsureTimestampProgress(mutated, msg.Timestamp)
+
+	return []*abstraction.CanonicalMessage{original, mutated}, nil
+}
+
+// AlterValidator rewrites the voter of a vote or the proposer of a proposal.
+func AlterValidator(msg *abstraction.CanonicalMessage, opts Options) ([]*abstraction.CanonicalMessage, error) {
+	if opts.AlternateValidator == "" {
+		return nil, fmt.Errorf("alter_validator action requires AlternateValidator to be set")
+	}
+
+	mutated := Clone(msg)
+	switch {
+	case IsVote(msg.Type):
+		mutated.Validator = opts.AlternateValidator
+	case msg.Type == abstraction.MsgTypeProposal:
+		mutated.Proposer = opts.AlternateValidator
+	default:
+		return nil, fmt.Errorf("alter_validator action requires a proposal or vote canonical message")
+	}
+
+	ApplyCommon(mutated, opts)
+	EnsureTimestampProgress(mutated, msg.Timestamp)
+
+	return []*abstraction.CanonicalMessage{mutated}, nil
+}
+
+// DropSignature clears the signature.
+func DropSignature(msg *abstraction.CanonicalMessage, opts Options) ([]*abstraction.CanonicalMessage, error) {
+	mutated := Clone(msg)
+	mutated.Signature = ""
+
+	ApplyCommon(mutated, opts)
+	EnsureTimestampProgress(mutated, msg.Timestamp)
+
+	return []*abstraction.CanonicalMessage{mutated}, nil
+}
+
+// TimestampSkew shifts the timestamp by Options.TimestampShift.
+func TimestampSkew(msg *abstraction.CanonicalMessage, opts Options) ([]*abstraction.CanonicalMessage, error) {
+	if opts.TimestampShift == 0 {
+		return nil, fmt.Errorf("timestamp_skew action requires TimestampShift to be non-zero")
+	}
+
+	mutated := Clone(msg)
+	ApplyCommon(mutated, opts)
+	EnsureTimestampProgress(mutated, msg.Timestamp)
+
+	return []*abstraction.CanonicalMessage{mutated}, nil
+}
+
+// NilFlip withholds a vote by replacing its block with nil, or turns a nil vote into a vote for
+// Options.AlternateBlockHash. With Options.EmitBoth the original vote is returned first.
+func NilFlip(msg *abstraction.CanonicalMessage, opts Options) ([]*abstraction.CanonicalMessage, error) {
+	if !IsVote(msg.Type) {
+		return nil, fmt.Errorf("nil_flip action requires a vote canonical message")
+	}
+
+	mutated := Clone(msg)
+	if msg.BlockHash != "" {
+		mutated.BlockHash = ""
+	} else {
+		if opts.AlternateBlockHash == "" {
+			return nil, fmt.Errorf("nil_flip action on a nil vote requires AlternateBlockHash to be set")
+		}
+		mutated.BlockHash = opts.AlternateBlockHash
+	}
+	if opts.AlternateSignature != "" {
+		mutated.Signature = opts.AlternateSignature
+	}
+
+	ApplyCommon(mutated, opts)
+	EnsureTimestampProgress(mutated, msg.Timestamp)
+
+	if opts.EmitBoth {
+		return []*abstraction.CanonicalMessage{Clone(msg), mutated}, nil
+	}
+	return []*abstraction.CanonicalMessage{mutated}, nil
+}
+
+// HeightFlood emits one copy of the message per height offset in [FloodFrom, FloodTo], stressing peer state
+// and evidence pools with far-future and stale messages. Offsets that would produce a negative height are skipped.
+func HeightFlood(msg *abstraction.CanonicalMessage, opts Options) ([]*abstraction.CanonicalMessage, error) {
+	if msg.Height == nil {
+		return nil, fmt.Errorf("height_flood action requires a height")
+	}
+	if opts.FloodFrom == 0 && opts.FloodTo == 0 {
+		return nil, fmt.Errorf("height_flood action requires FloodFrom/FloodTo to be set")
+	}
+	if opts.FloodTo < opts.FloodFrom {
+		return nil, fmt.Errorf("height_flood range %d..%d is empty", opts.FloodFrom, opts.FloodTo)
+	}
+	if count := opts.FloodTo - opts.FloodFrom + 1; count > MaxFloodCopies {
+		return nil, fmt.Errorf("height_flood range %d..%d emits %d copies, more than %d", opts.FloodFrom, opts.FloodTo, count, MaxFloodCopies)
+	}
+
+	out := make([]*abstraction.CanonicalMessage, 0, opts.FloodTo-opts.FloodFrom+1)
+	for offset := opts.FloodFrom; offset <= opts.FloodTo; offset++ {
+		mutated := Clone(msg)
+		mutated.Height = ShiftBigInt(mutated.Height, offset)
+		if opts.AlternateSignature != "" {
+			mutated.Signature = opts.AlternateSignature
+		}
+		ApplyCommon(mutated, opts)
+		if mutated.Height.Sign() < 0 {
+			continue
+		}
+		out = append(out, mutated)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("height_flood range %d..%d only produces negative heights", opts.FloodFrom, opts.FloodTo)
+	}
+	return out, nil
+}
+
+// ParseRange parses an inclusive offset range written as "N..M" (for example "5..10" or "-3..-1"); a single
+// number is a range of one.
+func ParseRange(spec string) (int64, int64, error) {
+	spec = strings.TrimSpace(spec)
+	fromText, toText, ok := strings.Cut(spec, "..")
+	if !ok {
+		toText = fromText
+	}
+	from, err := strconv.ParseInt(strings.TrimSpace(fromText), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range %q: %w", spec, err)
+	}
+	to, err := strconv.ParseInt(strings.TrimSpace(toText), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range %q: %w", spec, err)
+	}
+	if to < from {
+		return 0, 0, fmt.Errorf("invalid range %q: end before start", spec)
+	}
+	return from, to, nil
+}
+
+// ApplyCommon applies the height, round and timestamp offsets shared by every action.
+// Protocols without a round (PBFT-style views) carry only View, so RoundOffset shifts the view instead.
+func ApplyCommon(target *abstraction.CanonicalMessage, opts Options) {
+	if opts.HeightOffset != 0 {
+		target.Height = ShiftBigInt(target.Height, opts.HeightOffset)
+	}
+	if opts.RoundOffset != 0 {
+		if target.Round == nil && target.View != nil {
+			target.View = ShiftBigInt(target.View, opts.RoundOffset)
+		} else {
+			target.Round = ShiftBigInt(target.Round, opts.RoundOffset)
+		}
+	}
+	if opts.TimestampShift != 0 {
+		target.Timestamp = target.Timestamp.Add(opts.TimestampShift)
+	}
+}
+
+// EnsureTimestampProgress nudges the mutated timestamp so it never equals the original.
+func EnsureTimestampProgress(mutated *abstraction.CanonicalMessage, original time.Time) {
+	if original.IsZero() {
+		return
+	}
+	if mutated.Timestamp.Equal(original) {
+		mutated.Timestamp = mutated.Timestamp.Add(1 * time.Millisecond)
+	}
+}
+
+// AlternateHash returns provided when set, otherwise a hash that differs from original in one character.
+func AlternateHash(original, provided string) string {
+	if provided != "" {
+		return provided
+	}
+	if original == "" {
+		return "0000000000000000000000000000000000000000000000000000000000000000"
+	}
+
+	runes := []rune(original)
+	for i := len(runes) - 1; i >= 0; i-- {
+		switch runes[i] {
+		case '0':
+			runes[i] = '1'
+			return string(runes)
+		case '1':
+			runes[i] = '0'
+			return string(runes)
+		case 'a', 'A':
+			runes[i] = 'b'
+			return string(runes)
+		case 'f', 'F':
+			runes[i] = 'e'
+			return string(runes)
+		}
+	}
+	return original + "0"
+}
+
+// Clone deep-copies a canonical message so mutations never alias the input.
+func Clone(msg *abstraction.CanonicalMessage) *abstraction.CanonicalMessage {
+	if msg == nil {
+		return nil
+	}
+	cloned := *msg
+	if msg.Height != nil {
+		cloned.Height = new(big.Int).Set(msg.Height)
+	}
+	if msg.Round != nil {
+		cloned.Round = new(big.Int).Set(msg.Round)
+	}
+	if msg.View != nil {
+		cloned.View = new(big.Int).Set(msg.View)
+	}
+	if msg.CommitSeals != nil {
+		cloned.CommitSeals = append([]string(nil), msg.CommitSeals...)
+	}
+	if msg.ViewChanges != nil {
+		cloned.ViewChanges = make([]abstraction.ViewChangeEntry, len(msg.ViewChanges))
+		for i, vc := range msg.ViewChanges {
+			entry := vc
+			if vc.View != nil {
+				entry.View = new(big.Int).Set(vc.View)
+			}
+			if vc.Height != nil {
+				entry.Height = new(big.Int).Set(vc.Height)
+			}
+			cloned.ViewChanges[i] = entry
+		}
+	}
+	if msg.Extensions != nil {
+		copied := make(map[string]interface{}, len(msg.Extensions))
+		for k, v := range msg.Extensions {
+			copied[k] = v
+		}
+		cloned.Extensions = copied
+	}
+	if msg.RawPayload != nil {
+		cloned.RawPayload = append([]byte(nil), msg.RawPayload...)
+	}
+	return &cloned
+}
+
+// ShiftBigInt returns value+offset as a new big.Int, treating nil as zero.
+func ShiftBigInt(value *big.Int, offset int64) *big.Int {
+	if offset == 0 {
+		if value == nil {
+			return nil
+		}
+		return new(big.Int).Set(value)
+	}
+
+	if value == nil {
+		return big.NewInt(offset)
+	}
+	return new(big.Int).Add(value, big.NewInt(offset))
+}
@@ -0,0 +1,309 @@
+package byzantine
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+func TestEngineRegisterAndAlias(t *testing.T) {
+	e := NewEngine()
+	custom := Action("drop_extension")
+	e.Register(custom, func(msg *abstraction.CanonicalMessage, opts Options) ([]*abstraction.CanonicalMessage, error) {
+		mutated := Clone(msg)
+		delete(mutated.Extensions, "custom")
+		return []*abstraction.CanonicalMessage{mutated}, nil
+	})
+	e.Unregister(ActionAlterValidator)
+	e.Alias("strip", custom)
+
+	if _, err := e.Parse("alter_validator"); err == nil {
+		t.Fatalf("expected unregistered action to be rejected")
+	}
+	action, err := e.Parse("STRIP")
+	if err != nil || action != custom {
+		t.Fatalf("expected alias to resolve to %s, got %s (%v)", custom, action, err)
+	}
+	if action, err := e.Parse(""); err != nil || action != ActionNone {
+		t.Fatalf("expected empty action to parse as none")
+	}
+
+	msg := &abstraction.CanonicalMessage{Type: abstraction.MsgTypePrevote, Extensions: map[string]interface{}{"custom": 1}}
+	out, err := e.Apply(msg, custom, Options{})
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if _, ok := out[0].Extensions["custom"]; ok {
+		t.Fatalf("expected custom extension to be removed")
+	}
+	if _, ok := msg.Extensions["custom"]; !ok {
+		t.Fatalf("original message must not be modified")
+	}
+	if _, err := e.Apply(msg, ActionAlterValidator, Options{AlternateValidator: "v"}); err == nil {
+		t.Fatalf("expected unsupported action error")
+	}
+}
+
+func TestApplyCommonShiftsViewWithoutRound(t *testing.T) {
+	ts := time.Unix(1700000000, 0).UTC()
+	pbft := &abstraction.CanonicalMessage{Height: big.NewInt(5), View: big.NewInt(2), Timestamp: ts}
+	ApplyCommon(pbft, Options{RoundOffset: 1, HeightOffset: 2, TimestampShift: time.Second})
+	if pbft.Round != nil || pbft.View.Cmp(big.NewInt(3)) != 0 {
+		t.Fatalf("expected view to shift when round is absent, got round=%v view=%v", pbft.Round, pbft.View)
+	}
+	if pbft.Height.Cmp(big.NewInt(7)) != 0 || !pbft.Timestamp.Equal(ts.Add(time.Second)) {
+		t.Fatalf("unexpected height %v or timestamp %v", pbft.Height, pbft.Timestamp)
+	}
+
+	tendermint := &abstraction.CanonicalMessage{Round: big.NewInt(0), View: big.NewInt(9)}
+	ApplyCommon(tendermint, Options{RoundOffset: 2})
+	if tendermint.Round.Cmp(big.NewInt(2)) != 0 || tendermint.View.Cmp(big.NewInt(9)) != 0 {
+		t.Fatalf("expected round to shift and view to stay, got round=%v view=%v", tendermint.Round, tendermint.View)
+	}
+}
+
+func TestDoubleVoteAcceptsPBFTVotes(t *testing.T) {
+	msg := &abstraction.CanonicalMessage{Type: abstraction.MsgTypeCommit, BlockHash: "aa", Timestamp: time.Unix(1, 0)}
+	out, err := NewEngine().Apply(msg, ActionDoubleVote, Options{})
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if len(out) != 2 || out[1].BlockHash == msg.BlockHash {
+		t.Fatalf("expected conflicting commit, got %+v", out)
+	}
+}
+
+// jsonMapper encodes only the fields listed in keep, so mutations of other fields are lost.
+type jsonMapper struct {
+	types []abstraction.MsgType
+	keep  func(msg *abstraction.CanonicalMessage) interface{}
+}
+
+func (m jsonMapper) ToCanonical(abstraction.RawConsensusMessage) (*abstraction.CanonicalMessage, error) {
+	return nil, nil
+}
+
+func (m jsonMapper) FromCanonical(msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
+	payload, err := json.Marshal(m.keep(msg))
+	if err != nil {
+		return nil, err
+	}
+	return &abstraction.RawConsensusMessage{Payload: payload, Encoding: "json"}, nil
+}
+
+func (m jsonMapper) GetSupportedTypes() []abstraction.MsgType { return m.types }
+
+func (m jsonMapper) GetChainType() abstraction.ChainType { return abstraction.ChainTypeCometBFT }
+
+func TestCoverage(t *testing.T) {
+	full := jsonMapper{
+		types: []abstraction.MsgType{abstraction.MsgTypeProposal, abstraction.MsgTypePrevote},
+		keep:  func(msg *abstraction.CanonicalMessage) interface{} { return msg },
+	}
+	hashOnly := jsonMapper{
+		types: []abstraction.MsgType{abstraction.MsgTypePrevote},
+		keep:  func(msg *abstraction.CanonicalMessage) interface{} { return msg.BlockHash },
+	}
+
+	report := Coverage([]CoverageSubject{
+		{Name: "full", Mapper: full, Engine: NewEngine(), Options: Options{TimestampShift: time.Second}},
+		{Name: "hash-only", Mapper: hashOnly, Engine: NewEngine()},
+		{Name: "none", Mapper: full},
+	})
+
+	checks := []struct {
+		chain  string
+		action Action
+		want   CoverageStatus
+	}{
+		{"full", ActionDoubleVote, CoverageImplemented},
+		{"full", ActionTimestampSkew, CoverageImplemented},
+		{"hash-only", ActionDoubleVote, CoverageImplemented},
+		{"hash-only", ActionDropSignature, CoverageLossy},
+		{"hash-only", ActionDoubleProposal, CoverageNotApplicable},
+		{"none", ActionDoubleVote, CoverageMissing},
+	}
+	for _, c := range checks {
+		if got := report.Status(c.chain, c.action); got != c.want {
+			t.Fatalf("%s/%s: expected %s, got %s", c.chain, c.action, c.want, got)
+		}
+	}
+	if got := report.Chains[0].Actions[1].Types[abstraction.MsgTypePrevote]; got != ProbeRejected {
+		t.Fatalf("expected double_proposal on prevote to be rejected, got %s", got)
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	params, err := ParseParams("extension_mode=both, extension=b3JhY2xl==")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if params["extension_mode"] != "both" || params["extension"] != "b3JhY2xl==" {
+		t.Fatalf("unexpected params %v", params)
+	}
+	if params, err := ParseParams(""); err != nil || params != nil {
+		t.Fatalf("expected empty spec to yield no params, got %v (%v)", params, err)
+	}
+	if _, err := ParseParams("extension_mode"); err == nil {
+		t.Fatalf("expected entry without value to be rejected")
+	}
+}
+
+func TestHeightFlood(t *testing.T) {
+	msg := &abstraction.CanonicalMessage{
+		Height:    big.NewInt(3),
+		Round:     big.NewInt(0),
+		Type:      abstraction.MsgTypePrevote,
+		BlockHash: "AA",
+	}
+
+	out, err := NewEngine().Apply(msg, ActionHeightFlood, Options{FloodFrom: -5, FloodTo: 2})
+	if err != nil {
+		t.Fatalf("flood: %v", err)
+	}
+	// Offsets -5 and -4 would produce negative heights and are skipped.
+	want := []int64{0, 1, 2, 3, 4, 5}
+	if len(out) != len(want) {
+		t.Fatalf("expected %d copies, got %d", len(want), len(out))
+	}
+	for i, copy := range out {
+		if copy.Height.Int64() != want[i] {
+			t.Fatalf("copy %d: expected height %d, got %s", i, want[i], copy.Height)
+		}
+	}
+	if msg.Height.Int64() != 3 {
+		t.Fatalf("input message must not be modified")
+	}
+
+	if _, err := HeightFlood(msg, Options{}); err == nil {
+		t.Fatalf("expected missing range to be rejected")
+	}
+	if _, err := HeightFlood(msg, Options{FloodFrom: 1, FloodTo: MaxFloodCopies + 1}); err == nil {
+		t.Fatalf("expected oversized range to be rejected")
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	cases := map[string][2]int64{"5..10": {5, 10}, "-3..-1": {-3, -1}, " 7 ": {7, 7}, "-2..4": {-2, 4}}
+	for spec, want := range cases {
+		from, to, err := ParseRange(spec)
+		if err != nil || from != want[0] || to != want[1] {
+			t.Fatalf("%q: expected %v, got %d..%d (%v)", spec, want, from, to, err)
+		}
+	}
+	for _, spec := range []string{"", "10..5", "a..b", "1..."} {
+		if _, _, err := ParseRange(spec); err == nil {
+			t.Fatalf("%q: expected error", spec)
+		}
+	}
+}
+
+func TestFuzzPayload(t *testing.T) {
+	payload := []byte(`{"height":"10","round":"0","type":"prevote"}`)
+
+	for _, mode := range []string{FuzzModeFlip, FuzzModeTruncate, FuzzModeAppend, FuzzModeMixed} {
+		for seed := int64(0); seed < 50; seed++ {
+			opts := Options{FuzzSeed: seed, Params: map[string]string{FuzzModeParam: mode}}
+			out, err := FuzzPayload(payload, opts)
+			if err != nil {
+				t.Fatalf("%s/%d: %v", mode, seed, err)
+			}
+			if string(out) == string(payload) {
+				t.Fatalf("%s/%d: payload was not changed", mode, seed)
+			}
+			switch mode {
+			case FuzzModeFlip:
+				if len(out) != len(payload) {
+					t.Fatalf("flip must keep the length, got %d", len(out))
+				}
+			case FuzzModeTruncate:
+				if len(out) >= len(payload) {
+					t.Fatalf("truncate must shorten the payload, got %d bytes", len(out))
+				}
+			case FuzzModeAppend:
+				if len(out) <= len(payload) || string(out[:len(payload)]) != string(payload) {
+					t.Fatalf("append must keep the payload as a prefix")
+				}
+			}
+			again, _ := FuzzPayload(payload, opts)
+			if string(again) != string(out) {
+				t.Fatalf("%s/%d: same seed produced different output", mode, seed)
+			}
+		}
+	}
+
+	if _, err := FuzzPayload(payload, Options{Params: map[string]string{FuzzModeParam: "shuffle"}}); err == nil {
+		t.Fatalf("expected unknown mode to be rejected")
+	}
+	if out, err := FuzzPayload(nil, Options{Params: map[string]string{FuzzModeParam: FuzzModeTruncate}}); err != nil || len(out) == 0 {
+		t.Fatalf("expected empty payload to receive junk, got %v (%v)", out, err)
+	}
+}
+
+func TestPayloadActionRunsAfterEncoding(t *testing.T) {
+	e := NewEngine()
+	if !e.IsPayloadAction(ActionFuzzPayload) || e.IsPayloadAction(ActionDoubleVote) {
+		t.Fatalf("unexpected payload action classification")
+	}
+	msg := &abstraction.CanonicalMessage{Height: big.NewInt(1), Round: big.NewInt(0), Type: abstraction.MsgTypePrevote, BlockHash: "AA"}
+
+	canonicals, err := e.Apply(msg, ActionFuzzPayload, Options{})
+	if err != nil || len(canonicals) != 1 || canonicals[0].BlockHash != "AA" {
+		t.Fatalf("expected canonical message to pass through, got %v (%v)", canonicals, err)
+	}
+
+	raws, err := e.ApplyAndEncode(jsonEncoder{}, msg, ActionFuzzPayload, Options{FuzzSeed: 7})
+	if err != nil {
+		t.Fatalf("apply and encode: %v", err)
+	}
+	clean, _ := jsonEncoder{}.FromCanonical(msg)
+	if string(raws[0].Payload) == string(clean.Payload) {
+		t.Fatalf("expected encoded payload to be fuzzed")
+	}
+
+	unchanged, err := e.MutatePayload(ActionDoubleVote, clean.Payload, Options{})
+	if err != nil || string(unchanged) != string(clean.Payload) {
+		t.Fatalf("expected canonical actions to leave payloads alone")
+	}
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) FromCanonical(msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &abstraction.RawConsensusMessage{Payload: payload, Encoding: "json"}, nil
+}
+
+func TestTargetPeersSplitEquivocation(t *testing.T) {
+	opts := Options{TargetPeers: []string{"0a1b2c", " 10.0.0.7 "}}
+	if !opts.TargetsPeer("0A1B2C", "10.0.0.5:26656") || !opts.TargetsPeer("ffff", "10.0.0.7:26656", "10.0.0.7") {
+		t.Fatalf("expected node ID and host matches to be targeted")
+	}
+	if opts.TargetsPeer("ffff", "10.0.0.8:26656", "10.0.0.8") {
+		t.Fatalf("expected unlisted peer to be left alone")
+	}
+	if !(Options{}).TargetsPeer("anything") {
+		t.Fatalf("expected empty target list to target every peer")
+	}
+
+	msg := &abstraction.CanonicalMessage{Height: big.NewInt(5), Round: big.NewInt(0), Type: abstraction.MsgTypePrevote, BlockHash: "AA"}
+	out, err := NewEngine().Apply(msg, ActionDoubleVote, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflicting := Conflicting(msg, out)
+	if len(conflicting) != 1 || conflicting[0].BlockHash == msg.BlockHash {
+		t.Fatalf("expected only the conflicting vote, got %+v", conflicting)
+	}
+
+	unchanged, _ := NewEngine().Apply(msg, ActionNone, opts)
+	if got := Conflicting(msg, unchanged); len(got) != 1 {
+		t.Fatalf("expected a lone copy to be kept, got %d messages", len(got))
+	}
+}
@@ -0,0 +1,200 @@
+package byzantine
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+// CoverageStatus summarises how well a chain supports an action.
+type CoverageStatus string
+
+const (
+	// CoverageImplemented means the action mutates and re-encodes at least one supported message type.
+	CoverageImplemented CoverageStatus = "implemented"
+	// CoverageNotApplicable means the action is registered but rejects every supported message type.
+	CoverageNotApplicable CoverageStatus = "not_applicable"
+	// CoverageLossy means the action runs but its effect never survives encoding to the wire format.
+	CoverageLossy CoverageStatus = "lossy"
+	// CoverageMissing means the chain has no implementation of the action.
+	CoverageMissing CoverageStatus = "missing"
+)
+
+// Per-message-type outcomes recorded in ActionCoverage.Types.
+const (
+	ProbeOK           = "ok"
+	ProbeRejected     = "rejected"
+	ProbeEncodeFailed = "encode_failed"
+	ProbeLost         = "lost_in_encoding"
+)
+
+// CoverageSubject describes one chain adapter to probe.
+type CoverageSubject struct {
+	Name   string
+	Mapper abstraction.Mapper
+	// Engine is nil for adapters without byzantine support.
+	Engine *Engine
+	// Probe builds a representative canonical message; nil uses DefaultProbe.
+	Probe func(t abstraction.MsgType) *abstraction.CanonicalMessage
+	// Options are passed to every action so option-dependent actions can run.
+	Options Options
+}
+
+// CoverageReport is the machine-readable action × chain matrix.
+type CoverageReport struct {
+	Actions []Action        `json:"actions"`
+	Chains  []ChainCoverage `json:"chains"`
+}
+
+// ChainCoverage lists the message types and action coverage of a single adapter.
+type ChainCoverage struct {
+	Chain        string                `json:"chain"`
+	ChainType    abstraction.ChainType `json:"chain_type"`
+	MessageTypes []abstraction.MsgType `json:"message_types"`
+	Actions      []ActionCoverage      `json:"actions"`
+}
+
+// ActionCoverage records the status of one action and the outcome per message type.
+type ActionCoverage struct {
+	Action Action                         `json:"action"`
+	Status CoverageStatus                 `json:"status"`
+	Types  map[abstraction.MsgType]string `json:"types,omitempty"`
+}
+
+// Coverage probes every subject with every action registered on any subject.
+func Coverage(subjects []CoverageSubject) *CoverageReport {
+	seen := make(map[Action]bool)
+	for _, subject := range subjects {
+		if subject.Engine == nil {
+			continue
+		}
+		for _, action := range subject.Engine.Actions() {
+			seen[action] = true
+		}
+	}
+	report := &CoverageReport{}
+	for action := range seen {
+		report.Actions = append(report.Actions, action)
+	}
+	sort.Slice(report.Actions, func(i, j int) bool { return report.Actions[i] < report.Actions[j] })
+
+	for _, subject := range subjects {
+		report.Chains = append(report.Chains, probeSubject(subject, report.Actions))
+	}
+	return report
+}
+
+// Status looks up the status of an action for a chain, returning CoverageMissing when absent.
+func (r *CoverageReport) Status(chain string, action Action) CoverageStatus {
+	for _, c := range r.Chains {
+		if c.Chain != chain {
+			continue
+		}
+		for _, a := range c.Actions {
+			if a.Action == action {
+				return a.Status
+			}
+		}
+	}
+	return CoverageMissing
+}
+
+func probeSubject(subject CoverageSubject, actions []Action) ChainCoverage {
+	probe := subject.Probe
+	if probe == nil {
+		probe = DefaultProbe
+	}
+	types := append([]abstraction.MsgType(nil), subject.Mapper.GetSupportedTypes()...)
+	coverage := ChainCoverage{
+		Chain:        subject.Name,
+		ChainType:    subject.Mapper.GetChainType(),
+		MessageTypes: types,
+	}
+
+	for _, action := range actions {
+		entry := ActionCoverage{Action: action, Status: CoverageMissing}
+		if subject.Engine != nil {
+			if _, ok := subject.Engine.mutators[action]; ok {
+				entry.Types = make(map[abstraction.MsgType]string, len(types))
+				for _, t := range types {
+					entry.Types[t] = probeAction(subject, probe(t), action)
+				}
+				entry.Status = summarise(entry.Types)
+			}
+		}
+		coverage.Actions = append(coverage.Actions, entry)
+	}
+	return coverage
+}
+
+func probeAction(subject CoverageSubject, msg *abstraction.CanonicalMessage, action Action) string {
+	baseline, err := subject.Mapper.FromCanonical(msg)
+	if err != nil {
+		return ProbeEncodeFailed
+	}
+	mutated, err := subject.Engine.Apply(msg, action, subject.Options)
+	if err != nil {
+		return ProbeRejected
+	}
+	raws, err := Encode(subject.Mapper, mutated)
+	if err != nil {
+		return ProbeEncodeFailed
+	}
+	for _, raw := range raws {
+		if raw.Payload, err = subject.Engine.MutatePayload(action, raw.Payload, subject.Options); err != nil {
+			return ProbeRejected
+		}
+	}
+	if action == ActionNone {
+		return ProbeOK
+	}
+	if len(raws) == 0 {
+		// Withholding the message is itself the mutation.
+		return ProbeOK
+	}
+	for _, raw := range raws {
+		if !bytes.Equal(raw.Payload, baseline.Payload) {
+			return ProbeOK
+		}
+	}
+	return ProbeLost
+}
+
+func summarise(types map[abstraction.MsgType]string) CoverageStatus {
+	lost := false
+	for _, outcome := range types {
+		switch outcome {
+		case ProbeOK:
+			return CoverageImplemented
+		case ProbeLost:
+			lost = true
+		}
+	}
+	if lost {
+		return CoverageLossy
+	}
+	return CoverageNotApplicable
+}
+
+// DefaultProbe returns a fully populated canonical message of the given type.
+func DefaultProbe(t abstraction.MsgType) *abstraction.CanonicalMessage {
+	return &abstraction.CanonicalMessage{
+		ChainID:   "coverage-probe",
+		Height:    big.NewInt(100),
+		Round:     big.NewInt(1),
+		View:      big.NewInt(1),
+		Timestamp: time.Unix(1700000000, 0).UTC(),
+		Type:      t,
+		BlockHash: "1111111111111111111111111111111111111111111111111111111111111111",
+		PrevHash:  "2222222222222222222222222222222222222222222222222222222222222222",
+		Proposer:  "probe-proposer",
+		Validator: "probe-validator",
+		Signature: "probe-signature",
+		Extensions: map[string]interface{}{
+			"validator_index": int32(0),
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package byzantine
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+)
+
+// FuzzModeParam selects how fuzz_payload damages a payload: flip, truncate, append, or mixed (the default),
+// which picks one of the three per message.
+const FuzzModeParam = "fuzz_mode"
+
+const (
+	FuzzModeFlip     = "flip"
+	FuzzModeTruncate = "truncate"
+	FuzzModeAppend   = "append"
+	FuzzModeMixed    = "mixed"
+)
+
+// maxFuzzJunk bounds the number of bytes appended in append mode.
+const maxFuzzJunk = 64
+
+// FuzzPayload damages an encoded payload so it can be used to fuzz node decoders. The random source is seeded
+// from opts.FuzzSeed and the payload itself, so the same seed and input always produce the same output and a
+// crashing input can be replayed. The result always differs from the input.
+func FuzzPayload(payload []byte, opts Options) ([]byte, error) {
+	mode := strings.ToLower(strings.TrimSpace(opts.Params[FuzzModeParam]))
+	if mode == "" {
+		mode = FuzzModeMixed
+	}
+
+	h := fnv.New64a()
+	h.Write(payload)
+	rng := rand.New(rand.NewSource(opts.FuzzSeed ^ int64(h.Sum64())))
+
+	switch mode {
+	case FuzzModeFlip, FuzzModeTruncate, FuzzModeAppend:
+	case FuzzModeMixed:
+		mode = []string{FuzzModeFlip, FuzzModeTruncate, FuzzModeAppend}[rng.Intn(3)]
+	default:
+		return nil, fmt.Errorf("unknown %s %q (expected %s, %s, %s or %s)", FuzzModeParam, mode, FuzzModeFlip, FuzzModeTruncate, FuzzModeAppend, FuzzModeMixed)
+	}
+	if len(payload) == 0 {
+		// Nothing to flip or cut.
+		mode = FuzzModeAppend
+	}
+
+	out := append([]byte(nil), payload...)
+	switch mode {
+	case FuzzModeFlip:
+		flips := 1 + rng.Intn(min(8, len(out)))
+		for i := 0; i < flips; i++ {
+			out[rng.Intn(len(out))] ^= byte(1 + rng.Intn(255))
+		}
+		if string(out) == string(payload) {
+			// Two flips of the same byte cancelled out.
+			out[0] ^= 0xFF
+		}
+	case FuzzModeTruncate:
+		out = out[:rng.Intn(len(out))]
+	case FuzzModeAppend:
+		junk := make([]byte, 1+rng.Intn(maxFuzzJunk))
+		rng.Read(junk)
+		out = append(out, junk...)
+	}
+	return out, nil
+}
@@ -0,0 +1,850 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: canonical.proto
+
+// Wire schema for canonical and raw consensus messages crossing process boundaries (bridge sinks, Kafka,
+// JetStream, analyzers). Unlike abstraction.proto it keeps heights as arbitrary-precision integers, bytes as
+// bytes, and chain and message types as strings so that types added with RegisterChainType survive.
+// The Go types are generated into message/abstraction/canonical/canonicalpb; message/abstraction/canonical
+// maps them to and from the abstraction types.
+
+package canonicalpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Arbitrary-precision integer. An unset BigInt field is a nil *big.Int.
+type BigInt struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Abs           []byte                 `protobuf:"bytes,1,opt,name=abs,proto3" json:"abs,omitempty"` // big-endian magnitude
+	Negative      bool                   `protobuf:"varint,2,opt,name=negative,proto3" json:"negative,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BigInt) Reset() {
+	*x = BigInt{}
+	mi := &file_canonical_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BigInt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BigInt) ProtoMessage() {}
+
+func (x *BigInt) ProtoReflect() protoreflect.Message {
+	mi := &file_canonical_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BigInt.ProtoReflect.Descriptor instead.
+func (*BigInt) Descriptor() ([]byte, []int) {
+	return file_canonical_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *BigInt) GetAbs() []byte {
+	if x != nil {
+		return x.Abs
+	}
+	return nil
+}
+
+func (x *BigInt) GetNegative() bool {
+	if x != nil {
+		return x.Negative
+	}
+	return false
+}
+
+// Extension and metadata value. A Value with no kind set is nil.
+type Value struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Kind:
+	//
+	//	*Value_StringValue
+	//	*Value_IntValue
+	//	*Value_UintValue
+	//	*Value_DoubleValue
+	//	*Value_BoolValue
+	//	*Value_BytesValue
+	//	*Value_ListValue
+	//	*Value_MapValue
+	//	*Value_BigValue
+	Kind          isValue_Kind `protobuf_oneof:"kind"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Value) Reset() {
+	*x = Value{}
+	mi := &file_canonical_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Value) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Value) ProtoMessage() {}
+
+func (x *Value) ProtoReflect() protoreflect.Message {
+	mi := &file_canonical_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Value.ProtoReflect.Descriptor instead.
+func (*Value) Descriptor() ([]byte, []int) {
+	return file_canonical_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Value) GetKind() isValue_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return nil
+}
+
+func (x *Value) GetStringValue() string {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_StringValue); ok {
+			return x.StringValue
+		}
+	}
+	return ""
+}
+
+func (x *Value) GetIntValue() int64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_IntValue); ok {
+			return x.IntValue
+		}
+	}
+	return 0
+}
+
+func (x *Value) GetUintValue() uint64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_UintValue); ok {
+			return x.UintValue
+		}
+	}
+	return 0
+}
+
+func (x *Value) GetDoubleValue() float64 {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_DoubleValue); ok {
+			return x.DoubleValue
+		}
+	}
+	return 0
+}
+
+func (x *Value) GetBoolValue() bool {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_BoolValue); ok {
+			return x.BoolValue
+		}
+	}
+	return false
+}
+
+func (x *Value) GetBytesValue() []byte {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_BytesValue); ok {
+			return x.BytesValue
+		}
+	}
+	return nil
+}
+
+func (x *Value) GetListValue() *ValueList {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_ListValue); ok {
+			return x.ListValue
+		}
+	}
+	return nil
+}
+
+func (x *Value) GetMapValue() *ValueMap {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_MapValue); ok {
+			return x.MapValue
+		}
+	}
+	return nil
+}
+
+func (x *Value) GetBigValue() *BigInt {
+	if x != nil {
+		if x, ok := x.Kind.(*Value_BigValue); ok {
+			return x.BigValue
+		}
+	}
+	return nil
+}
+
+type isValue_Kind interface {
+	isValue_Kind()
+}
+
+type Value_StringValue struct {
+	StringValue string `protobuf:"bytes,1,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+type Value_IntValue struct {
+	IntValue int64 `protobuf:"zigzag64,2,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type Value_UintValue struct {
+	UintValue uint64 `protobuf:"varint,3,opt,name=uint_value,json=uintValue,proto3,oneof"`
+}
+
+type Value_DoubleValue struct {
+	DoubleValue float64 `protobuf:"fixed64,4,opt,name=double_value,json=doubleValue,proto3,oneof"`
+}
+
+type Value_BoolValue struct {
+	BoolValue bool `protobuf:"varint,5,opt,name=bool_value,json=boolValue,proto3,oneof"`
+}
+
+type Value_BytesValue struct {
+	BytesValue []byte `protobuf:"bytes,6,opt,name=bytes_value,json=bytesValue,proto3,oneof"`
+}
+
+type Value_ListValue struct {
+	ListValue *ValueList `protobuf:"bytes,7,opt,name=list_value,json=listValue,proto3,oneof"`
+}
+
+type Value_MapValue struct {
+	MapValue *ValueMap `protobuf:"bytes,8,opt,name=map_value,json=mapValue,proto3,oneof"`
+}
+
+type Value_BigValue struct {
+	BigValue *BigInt `protobuf:"bytes,9,opt,name=big_value,json=bigValue,proto3,oneof"`
+}
+
+func (*Value_StringValue) isValue_Kind() {}
+
+func (*Value_IntValue) isValue_Kind() {}
+
+func (*Value_UintValue) isValue_Kind() {}
+
+func (*Value_DoubleValue) isValue_Kind() {}
+
+func (*Value_BoolValue) isValue_Kind() {}
+
+func (*Value_BytesValue) isValue_Kind() {}
+
+func (*Value_ListValue) isValue_Kind() {}
+
+func (*Value_MapValue) isValue_Kind() {}
+
+func (*Value_BigValue) isValue_Kind() {}
+
+type ValueList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []*Value               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValueList) Reset() {
+	*x = ValueList{}
+	mi := &file_canonical_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValueList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValueList) ProtoMessage() {}
+
+func (x *ValueList) ProtoReflect() protoreflect.Message {
+	mi := &file_canonical_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValueList.ProtoReflect.Descriptor instead.
+func (*ValueList) Descriptor() ([]byte, []int) {
+	return file_canonical_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ValueList) GetValues() []*Value {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type ValueMap struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Fields        map[string]*Value      `protobuf:"bytes,1,rep,name=fields,proto3" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValueMap) Reset() {
+	*x = ValueMap{}
+	mi := &file_canonical_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValueMap) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValueMap) ProtoMessage() {}
+
+func (x *ValueMap) ProtoReflect() protoreflect.Message {
+	mi := &file_canonical_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValueMap.ProtoReflect.Descriptor instead.
+func (*ValueMap) Descriptor() ([]byte, []int) {
+	return file_canonical_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ValueMap) GetFields() map[string]*Value {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type ViewChangeEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	View          *BigInt                `protobuf:"bytes,1,opt,name=view,proto3" json:"view,omitempty"`
+	Height        *BigInt                `protobuf:"bytes,2,opt,name=height,proto3" json:"height,omitempty"`
+	Validator     string                 `protobuf:"bytes,3,opt,name=validator,proto3" json:"validator,omitempty"`
+	Signature     string                 `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ViewChangeEntry) Reset() {
+	*x = ViewChangeEntry{}
+	mi := &file_canonical_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ViewChangeEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ViewChangeEntry) ProtoMessage() {}
+
+func (x *ViewChangeEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_canonical_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ViewChangeEntry.ProtoReflect.Descriptor instead.
+func (*ViewChangeEntry) Descriptor() ([]byte, []int) {
+	return file_canonical_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ViewChangeEntry) GetView() *BigInt {
+	if x != nil {
+		return x.View
+	}
+	return nil
+}
+
+func (x *ViewChangeEntry) GetHeight() *BigInt {
+	if x != nil {
+		return x.Height
+	}
+	return nil
+}
+
+func (x *ViewChangeEntry) GetValidator() string {
+	if x != nil {
+		return x.Validator
+	}
+	return ""
+}
+
+func (x *ViewChangeEntry) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+type CanonicalMessage struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	ChainId     string                 `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Height      *BigInt                `protobuf:"bytes,2,opt,name=height,proto3" json:"height,omitempty"`
+	Round       *BigInt                `protobuf:"bytes,3,opt,name=round,proto3" json:"round,omitempty"`
+	View        *BigInt                `protobuf:"bytes,4,opt,name=view,proto3" json:"view,omitempty"`
+	Timestamp   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Type        string                 `protobuf:"bytes,6,opt,name=type,proto3" json:"type,omitempty"`
+	BlockHash   string                 `protobuf:"bytes,7,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	PrevHash    string                 `protobuf:"bytes,8,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	Proposer    string                 `protobuf:"bytes,9,opt,name=proposer,proto3" json:"proposer,omitempty"`
+	Validator   string                 `protobuf:"bytes,10,opt,name=validator,proto3" json:"validator,omitempty"`
+	Signature   string                 `protobuf:"bytes,11,opt,name=signature,proto3" json:"signature,omitempty"`
+	CommitSeals []string               `protobuf:"bytes,12,rep,name=commit_seals,json=commitSeals,proto3" json:"commit_seals,omitempty"`
+	ViewChanges []*ViewChangeEntry     `protobuf:"bytes,13,rep,name=view_changes,json=viewChanges,proto3" json:"view_changes,omitempty"`
+	Extensions  map[string]*Value      `protobuf:"bytes,14,rep,name=extensions,proto3" json:"extensions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	RawPayload  []byte                 `protobuf:"bytes,15,opt,name=raw_payload,json=rawPayload,proto3" json:"raw_payload,omitempty"`
+	// Schema version of the layout (abstraction.CurrentVersion); 0 is the unversioned layout.
+	Version       uint32 `protobuf:"varint,16,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CanonicalMessage) Reset() {
+	*x = CanonicalMessage{}
+	mi := &file_canonical_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CanonicalMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CanonicalMessage) ProtoMessage() {}
+
+func (x *CanonicalMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_canonical_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CanonicalMessage.ProtoReflect.Descriptor instead.
+func (*CanonicalMessage) Descriptor() ([]byte, []int) {
+	return file_canonical_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CanonicalMessage) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *CanonicalMessage) GetHeight() *BigInt {
+	if x != nil {
+		return x.Height
+	}
+	return nil
+}
+
+func (x *CanonicalMessage) GetRound() *BigInt {
+	if x != nil {
+		return x.Round
+	}
+	return nil
+}
+
+func (x *CanonicalMessage) GetView() *BigInt {
+	if x != nil {
+		return x.View
+	}
+	return nil
+}
+
+func (x *CanonicalMessage) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *CanonicalMessage) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CanonicalMessage) GetBlockHash() string {
+	if x != nil {
+		return x.BlockHash
+	}
+	return ""
+}
+
+func (x *CanonicalMessage) GetPrevHash() string {
+	if x != nil {
+		return x.PrevHash
+	}
+	return ""
+}
+
+func (x *CanonicalMessage) GetProposer() string {
+	if x != nil {
+		return x.Proposer
+	}
+	return ""
+}
+
+func (x *CanonicalMessage) GetValidator() string {
+	if x != nil {
+		return x.Validator
+	}
+	return ""
+}
+
+func (x *CanonicalMessage) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *CanonicalMessage) GetCommitSeals() []string {
+	if x != nil {
+		return x.CommitSeals
+	}
+	return nil
+}
+
+func (x *CanonicalMessage) GetViewChanges() []*ViewChangeEntry {
+	if x != nil {
+		return x.ViewChanges
+	}
+	return nil
+}
+
+func (x *CanonicalMessage) GetExtensions() map[string]*Value {
+	if x != nil {
+		return x.Extensions
+	}
+	return nil
+}
+
+func (x *CanonicalMessage) GetRawPayload() []byte {
+	if x != nil {
+		return x.RawPayload
+	}
+	return nil
+}
+
+func (x *CanonicalMessage) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type RawConsensusMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChainType     string                 `protobuf:"bytes,1,opt,name=chain_type,json=chainType,proto3" json:"chain_type,omitempty"`
+	ChainId       string                 `protobuf:"bytes,2,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	MessageType   string                 `protobuf:"bytes,3,opt,name=message_type,json=messageType,proto3" json:"message_type,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	Encoding      string                 `protobuf:"bytes,5,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Metadata      map[string]*Value      `protobuf:"bytes,7,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RawConsensusMessage) Reset() {
+	*x = RawConsensusMessage{}
+	mi := &file_canonical_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RawConsensusMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RawConsensusMessage) ProtoMessage() {}
+
+func (x *RawConsensusMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_canonical_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RawConsensusMessage.ProtoReflect.Descriptor instead.
+func (*RawConsensusMessage) Descriptor() ([]byte, []int) {
+	return file_canonical_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RawConsensusMessage) GetChainType() string {
+	if x != nil {
+		return x.ChainType
+	}
+	return ""
+}
+
+func (x *RawConsensusMessage) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *RawConsensusMessage) GetMessageType() string {
+	if x != nil {
+		return x.MessageType
+	}
+	return ""
+}
+
+func (x *RawConsensusMessage) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *RawConsensusMessage) GetEncoding() string {
+	if x != nil {
+		return x.Encoding
+	}
+	return ""
+}
+
+func (x *RawConsensusMessage) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *RawConsensusMessage) GetMetadata() map[string]*Value {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+var File_canonical_proto protoreflect.FileDescriptor
+
+const file_canonical_proto_rawDesc = "" +
+	"\n" +
+	"\x0fcanonical.proto\x12\x16byzantine.canonical.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"6\n" +
+	"\x06BigInt\x12\x10\n" +
+	"\x03abs\x18\x01 \x01(\fR\x03abs\x12\x1a\n" +
+	"\bnegative\x18\x02 \x01(\bR\bnegative\"\xa1\x03\n" +
+	"\x05Value\x12#\n" +
+	"\fstring_value\x18\x01 \x01(\tH\x00R\vstringValue\x12\x1d\n" +
+	"\tint_value\x18\x02 \x01(\x12H\x00R\bintValue\x12\x1f\n" +
+	"\n" +
+	"uint_value\x18\x03 \x01(\x04H\x00R\tuintValue\x12#\n" +
+	"\fdouble_value\x18\x04 \x01(\x01H\x00R\vdoubleValue\x12\x1f\n" +
+	"\n" +
+	"bool_value\x18\x05 \x01(\bH\x00R\tboolValue\x12!\n" +
+	"\vbytes_value\x18\x06 \x01(\fH\x00R\n" +
+	"bytesValue\x12B\n" +
+	"\n" +
+	"list_value\x18\a \x01(\v2!.byzantine.canonical.v1.ValueListH\x00R\tlistValue\x12?\n" +
+	"\tmap_value\x18\b \x01(\v2 .byzantine.canonical.v1.ValueMapH\x00R\bmapValue\x12=\n" +
+	"\tbig_value\x18\t \x01(\v2\x1e.byzantine.canonical.v1.BigIntH\x00R\bbigValueB\x06\n" +
+	"\x04kind\"B\n" +
+	"\tValueList\x125\n" +
+	"\x06values\x18\x01 \x03(\v2\x1d.byzantine.canonical.v1.ValueR\x06values\"\xaa\x01\n" +
+	"\bValueMap\x12D\n" +
+	"\x06fields\x18\x01 \x03(\v2,.byzantine.canonical.v1.ValueMap.FieldsEntryR\x06fields\x1aX\n" +
+	"\vFieldsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x123\n" +
+	"\x05value\x18\x02 \x01(\v2\x1d.byzantine.canonical.v1.ValueR\x05value:\x028\x01\"\xb9\x01\n" +
+	"\x0fViewChangeEntry\x122\n" +
+	"\x04view\x18\x01 \x01(\v2\x1e.byzantine.canonical.v1.BigIntR\x04view\x126\n" +
+	"\x06height\x18\x02 \x01(\v2\x1e.byzantine.canonical.v1.BigIntR\x06height\x12\x1c\n" +
+	"\tvalidator\x18\x03 \x01(\tR\tvalidator\x12\x1c\n" +
+	"\tsignature\x18\x04 \x01(\tR\tsignature\"\x93\x06\n" +
+	"\x10CanonicalMessage\x12\x19\n" +
+	"\bchain_id\x18\x01 \x01(\tR\achainId\x126\n" +
+	"\x06height\x18\x02 \x01(\v2\x1e.byzantine.canonical.v1.BigIntR\x06height\x124\n" +
+	"\x05round\x18\x03 \x01(\v2\x1e.byzantine.canonical.v1.BigIntR\x05round\x122\n" +
+	"\x04view\x18\x04 \x01(\v2\x1e.byzantine.canonical.v1.BigIntR\x04view\x128\n" +
+	"\ttimestamp\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x12\n" +
+	"\x04type\x18\x06 \x01(\tR\x04type\x12\x1d\n" +
+	"\n" +
+	"block_hash\x18\a \x01(\tR\tblockHash\x12\x1b\n" +
+	"\tprev_hash\x18\b \x01(\tR\bprevHash\x12\x1a\n" +
+	"\bproposer\x18\t \x01(\tR\bproposer\x12\x1c\n" +
+	"\tvalidator\x18\n" +
+	" \x01(\tR\tvalidator\x12\x1c\n" +
+	"\tsignature\x18\v \x01(\tR\tsignature\x12!\n" +
+	"\fcommit_seals\x18\f \x03(\tR\vcommitSeals\x12J\n" +
+	"\fview_changes\x18\r \x03(\v2'.byzantine.canonical.v1.ViewChangeEntryR\vviewChanges\x12X\n" +
+	"\n" +
+	"extensions\x18\x0e \x03(\v28.byzantine.canonical.v1.CanonicalMessage.ExtensionsEntryR\n" +
+	"extensions\x12\x1f\n" +
+	"\vraw_payload\x18\x0f \x01(\fR\n" +
+	"rawPayload\x12\x18\n" +
+	"\aversion\x18\x10 \x01(\rR\aversion\x1a\\\n" +
+	"\x0fExtensionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x123\n" +
+	"\x05value\x18\x02 \x01(\v2\x1d.byzantine.canonical.v1.ValueR\x05value:\x028\x01\"\x95\x03\n" +
+	"\x13RawConsensusMessage\x12\x1d\n" +
+	"\n" +
+	"chain_type\x18\x01 \x01(\tR\tchainType\x12\x19\n" +
+	"\bchain_id\x18\x02 \x01(\tR\achainId\x12!\n" +
+	"\fmessage_type\x18\x03 \x01(\tR\vmessageType\x12\x18\n" +
+	"\apayload\x18\x04 \x01(\fR\apayload\x12\x1a\n" +
+	"\bencoding\x18\x05 \x01(\tR\bencoding\x128\n" +
+	"\ttimestamp\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12U\n" +
+	"\bmetadata\x18\a \x03(\v29.byzantine.canonical.v1.RawConsensusMessage.MetadataEntryR\bmetadata\x1aZ\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x123\n" +
+	"\x05value\x18\x02 \x01(\v2\x1d.byzantine.canonical.v1.ValueR\x05value:\x028\x01B1Z/codec/message/abstraction/canonical/canonicalpbb\x06proto3"
+
+var (
+	file_canonical_proto_rawDescOnce sync.Once
+	file_canonical_proto_rawDescData []byte
+)
+
+func file_canonical_proto_rawDescGZIP() []byte {
+	file_canonical_proto_rawDescOnce.Do(func() {
+		file_canonical_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_canonical_proto_rawDesc), len(file_canonical_proto_rawDesc)))
+	})
+	return file_canonical_proto_rawDescData
+}
+
+var file_canonical_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_canonical_proto_goTypes = []any{
+	(*BigInt)(nil),                // 0: byzantine.canonical.v1.BigInt
+	(*Value)(nil),                 // 1: byzantine.canonical.v1.Value
+	(*ValueList)(nil),             // 2: byzantine.canonical.v1.ValueList
+	(*ValueMap)(nil),              // 3: byzantine.canonical.v1.ValueMap
+	(*ViewChangeEntry)(nil),       // 4: byzantine.canonical.v1.ViewChangeEntry
+	(*CanonicalMessage)(nil),      // 5: byzantine.canonical.v1.CanonicalMessage
+	(*RawConsensusMessage)(nil),   // 6: byzantine.canonical.v1.RawConsensusMessage
+	nil,                           // 7: byzantine.canonical.v1.ValueMap.FieldsEntry
+	nil,                           // 8: byzantine.canonical.v1.CanonicalMessage.ExtensionsEntry
+	nil,                           // 9: byzantine.canonical.v1.RawConsensusMessage.MetadataEntry
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_canonical_proto_depIdxs = []int32{
+	2,  // 0: byzantine.canonical.v1.Value.list_value:type_name -> byzantine.canonical.v1.ValueList
+	3,  // 1: byzantine.canonical.v1.Value.map_value:type_name -> byzantine.canonical.v1.ValueMap
+	0,  // 2: byzantine.canonical.v1.Value.big_value:type_name -> byzantine.canonical.v1.BigInt
+	1,  // 3: byzantine.canonical.v1.ValueList.values:type_name -> byzantine.canonical.v1.Value
+	7,  // 4: byzantine.canonical.v1.ValueMap.fields:type_name -> byzantine.canonical.v1.ValueMap.FieldsEntry
+	0,  // 5: byzantine.canonical.v1.ViewChangeEntry.view:type_name -> byzantine.canonical.v1.BigInt
+	0,  // 6: byzantine.canonical.v1.ViewChangeEntry.height:type_name -> byzantine.canonical.v1.BigInt
+	0,  // 7: byzantine.canonical.v1.CanonicalMessage.height:type_name -> byzantine.canonical.v1.BigInt
+	0,  // 8: byzantine.canonical.v1.CanonicalMessage.round:type_name -> byzantine.canonical.v1.BigInt
+	0,  // 9: byzantine.canonical.v1.CanonicalMessage.view:type_name -> byzantine.canonical.v1.BigInt
+	10, // 10: byzantine.canonical.v1.CanonicalMessage.timestamp:type_name -> google.protobuf.Timestamp
+	4,  // 11: byzantine.canonical.v1.CanonicalMessage.view_changes:type_name -> byzantine.canonical.v1.ViewChangeEntry
+	8,  // 12: byzantine.canonical.v1.CanonicalMessage.extensions:type_name -> byzantine.canonical.v1.CanonicalMessage.ExtensionsEntry
+	10, // 13: byzantine.canonical.v1.RawConsensusMessage.timestamp:type_name -> google.protobuf.Timestamp
+	9,  // 14: byzantine.canonical.v1.RawConsensusMessage.metadata:type_name -> byzantine.canonical.v1.RawConsensusMessage.MetadataEntry
+	1,  // 15: byzantine.canonical.v1.ValueMap.FieldsEntry.value:type_name -> byzantine.canonical.v1.Value
+	1,  // 16: byzantine.canonical.v1.CanonicalMessage.ExtensionsEntry.value:type_name -> byzantine.canonical.v1.Value
+	1,  // 17: byzantine.canonical.v1.RawConsensusMessage.MetadataEntry.value:type_name -> byzantine.canonical.v1.Value
+	18, // [18:18] is the sub-list for method output_type
+	18, // [18:18] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_canonical_proto_init() }
+func file_canonical_proto_init() {
+	if File_canonical_proto != nil {
+		return
+	}
+	file_canonical_proto_msgTypes[1].OneofWrappers = []any{
+		(*Value_StringValue)(nil),
+		(*Value_IntValue)(nil),
+		(*Value_UintValue)(nil),
+		(*Value_DoubleValue)(nil),
+		(*Value_BoolValue)(nil),
+		(*Value_BytesValue)(nil),
+		(*Value_ListValue)(nil),
+		(*Value_MapValue)(nil),
+		(*Value_BigValue)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_canonical_proto_rawDesc), len(file_canonical_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_canonical_proto_goTypes,
+		DependencyIndexes: file_canonical_proto_depIdxs,
+		MessageInfos:      file_canonical_proto_msgTypes,
+	}.Build()
+	File_canonical_proto = out.File
+	file_canonical_proto_goTypes = nil
+	file_canonical_proto_depIdxs = nil
+}
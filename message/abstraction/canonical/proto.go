@@ -0,0 +1,336 @@
+// Package canonical serializes canonical and raw consensus messages with the protobuf schema in
+// message/proto/canonical.proto. The wire types are generated into canonicalpb; this package maps them to
+// and from the abstraction types.
+//
+// Compared to JSON, heights, rounds and views keep their full precision, byte slices stay bytes, and the
+// integer extensions adapters write come back as int64 or uint64 instead of float64.
+package canonical
+
+//go:generate protoc -I ../../proto --go_out=canonicalpb --go_opt=paths=source_relative canonical.proto
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/canonical/canonicalpb"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// marshalOptions sorts map keys so equal messages encode identically.
+var marshalOptions = proto.MarshalOptions{Deterministic: true}
+
+// MarshalProto serializes a canonical message as a byzantine.canonical.v1.CanonicalMessage.
+func MarshalProto(msg *abstraction.CanonicalMessage) ([]byte, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("canonical message is nil")
+	}
+	pb, err := ToProto(msg)
+	if err != nil {
+		return nil, err
+	}
+	return marshalOptions.Marshal(pb)
+}
+
+// UnmarshalProto parses a message written by MarshalProto. Unknown fields are skipped.
+func UnmarshalProto(data []byte) (*abstraction.CanonicalMessage, error) {
+	var pb canonicalpb.CanonicalMessage
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return nil, err
+	}
+	return FromProto(&pb)
+}
+
+// MarshalRawProto serializes a raw consensus message as a byzantine.canonical.v1.RawConsensusMessage.
+func MarshalRawProto(raw *abstraction.RawConsensusMessage) ([]byte, error) {
+	if raw == nil {
+		return nil, fmt.Errorf("raw message is nil")
+	}
+	pb, err := RawToProto(raw)
+	if err != nil {
+		return nil, err
+	}
+	return marshalOptions.Marshal(pb)
+}
+
+// UnmarshalRawProto parses a message written by MarshalRawProto. Unknown fields are skipped.
+func UnmarshalRawProto(data []byte) (*abstraction.RawConsensusMessage, error) {
+	var pb canonicalpb.RawConsensusMessage
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return nil, err
+	}
+	return RawFromProto(&pb), nil
+}
+
+// ToProto converts a canonical message to its wire type. An unset version is written as
+// abstraction.CurrentVersion.
+func ToProto(msg *abstraction.CanonicalMessage) (*canonicalpb.CanonicalMessage, error) {
+	extensions, err := toValueMap(msg.Extensions)
+	if err != nil {
+		return nil, fmt.Errorf("extensions: %w", err)
+	}
+	version := msg.Version
+	if version == 0 {
+		version = abstraction.CurrentVersion
+	}
+	pb := &canonicalpb.CanonicalMessage{
+		ChainId:     msg.ChainID,
+		Height:      toBigInt(msg.Height),
+		Round:       toBigInt(msg.Round),
+		View:        toBigInt(msg.View),
+		Timestamp:   toTimestamp(msg.Timestamp),
+		Type:        string(msg.Type),
+		BlockHash:   msg.BlockHash,
+		PrevHash:    msg.PrevHash,
+		Proposer:    msg.Proposer,
+		Validator:   msg.Validator,
+		Signature:   msg.Signature,
+		CommitSeals: msg.CommitSeals,
+		Extensions:  extensions,
+		RawPayload:  msg.RawPayload,
+		Version:     uint32(version),
+	}
+	for _, entry := range msg.ViewChanges {
+		pb.ViewChanges = append(pb.ViewChanges, &canonicalpb.ViewChangeEntry{
+			View:      toBigInt(entry.View),
+			Height:    toBigInt(entry.Height),
+			Validator: entry.Validator,
+			Signature: entry.Signature,
+		})
+	}
+	return pb, nil
+}
+
+// FromProto converts a wire message to a canonical message, migrating older versions to
+// abstraction.CurrentVersion.
+func FromProto(pb *canonicalpb.CanonicalMessage) (*abstraction.CanonicalMessage, error) {
+	msg := &abstraction.CanonicalMessage{
+		ChainID:     pb.GetChainId(),
+		Height:      fromBigInt(pb.GetHeight()),
+		Round:       fromBigInt(pb.GetRound()),
+		View:        fromBigInt(pb.GetView()),
+		Timestamp:   fromTimestamp(pb.GetTimestamp()),
+		Type:        abstraction.MsgType(pb.GetType()),
+		BlockHash:   pb.GetBlockHash(),
+		PrevHash:    pb.GetPrevHash(),
+		Proposer:    pb.GetProposer(),
+		Validator:   pb.GetValidator(),
+		Signature:   pb.GetSignature(),
+		CommitSeals: pb.GetCommitSeals(),
+		Extensions:  fromValueMap(pb.GetExtensions()),
+		RawPayload:  pb.GetRawPayload(),
+		Version:     int(pb.GetVersion()),
+	}
+	for _, entry := range pb.GetViewChanges() {
+		msg.ViewChanges = append(msg.ViewChanges, abstraction.ViewChangeEntry{
+			View:      fromBigInt(entry.GetView()),
+			Height:    fromBigInt(entry.GetHeight()),
+			Validator: entry.GetValidator(),
+			Signature: entry.GetSignature(),
+		})
+	}
+	if msg.Version < abstraction.CurrentVersion {
+		return abstraction.Migrate(msg, abstraction.CurrentVersion)
+	}
+	return msg, nil
+}
+
+// RawToProto converts a raw consensus message to its wire type.
+func RawToProto(raw *abstraction.RawConsensusMessage) (*canonicalpb.RawConsensusMessage, error) {
+	metadata, err := toValueMap(raw.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("metadata: %w", err)
+	}
+	return &canonicalpb.RawConsensusMessage{
+		ChainType:   string(raw.ChainType),
+		ChainId:     raw.ChainID,
+		MessageType: raw.MessageType,
+		Payload:     raw.Payload,
+		Encoding:    raw.Encoding,
+		Timestamp:   toTimestamp(raw.Timestamp),
+		Metadata:    metadata,
+	}, nil
+}
+
+// RawFromProto converts a wire message to a raw consensus message.
+func RawFromProto(pb *canonicalpb.RawConsensusMessage) *abstraction.RawConsensusMessage {
+	return &abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainType(pb.GetChainType()),
+		ChainID:     pb.GetChainId(),
+		MessageType: pb.GetMessageType(),
+		Payload:     pb.GetPayload(),
+		Encoding:    pb.GetEncoding(),
+		Timestamp:   fromTimestamp(pb.GetTimestamp()),
+		Metadata:    fromValueMap(pb.GetMetadata()),
+	}
+}
+
+func toBigInt(v *big.Int) *canonicalpb.BigInt {
+	if v == nil {
+		return nil
+	}
+	return &canonicalpb.BigInt{Abs: v.Bytes(), Negative: v.Sign() < 0}
+}
+
+func fromBigInt(pb *canonicalpb.BigInt) *big.Int {
+	if pb == nil {
+		return nil
+	}
+	v := new(big.Int).SetBytes(pb.GetAbs())
+	if pb.GetNegative() {
+		v.Neg(v)
+	}
+	return v
+}
+
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func toValueMap(values map[string]interface{}) (map[string]*canonicalpb.Value, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	fields := make(map[string]*canonicalpb.Value, len(values))
+	for key, value := range values {
+		v, err := toValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		fields[key] = v
+	}
+	return fields, nil
+}
+
+func fromValueMap(fields map[string]*canonicalpb.Value) map[string]interface{} {
+	if len(fields) == 0 {
+		return nil
+	}
+	values := make(map[string]interface{}, len(fields))
+	for key, v := range fields {
+		values[key] = fromValue(v)
+	}
+	return values
+}
+
+// toValue converts an extension or metadata value. A nil value is a Value with no kind set, and types
+// without a Value kind of their own, such as structs, are written as their JSON form.
+func toValue(value interface{}) (*canonicalpb.Value, error) {
+	v := &canonicalpb.Value{}
+	switch value := value.(type) {
+	case nil:
+	case string:
+		v.Kind = &canonicalpb.Value_StringValue{StringValue: value}
+	case int:
+		v.Kind = &canonicalpb.Value_IntValue{IntValue: int64(value)}
+	case int8:
+		v.Kind = &canonicalpb.Value_IntValue{IntValue: int64(value)}
+	case int16:
+		v.Kind = &canonicalpb.Value_IntValue{IntValue: int64(value)}
+	case int32:
+		v.Kind = &canonicalpb.Value_IntValue{IntValue: int64(value)}
+	case int64:
+		v.Kind = &canonicalpb.Value_IntValue{IntValue: value}
+	case uint:
+		v.Kind = &canonicalpb.Value_UintValue{UintValue: uint64(value)}
+	case uint8:
+		v.Kind = &canonicalpb.Value_UintValue{UintValue: uint64(value)}
+	case uint16:
+		v.Kind = &canonicalpb.Value_UintValue{UintValue: uint64(value)}
+	case uint32:
+		v.Kind = &canonicalpb.Value_UintValue{UintValue: uint64(value)}
+	case uint64:
+		v.Kind = &canonicalpb.Value_UintValue{UintValue: value}
+	case float32:
+		v.Kind = &canonicalpb.Value_DoubleValue{DoubleValue: float64(value)}
+	case float64:
+		v.Kind = &canonicalpb.Value_DoubleValue{DoubleValue: value}
+	case bool:
+		v.Kind = &canonicalpb.Value_BoolValue{BoolValue: value}
+	case []byte:
+		v.Kind = &canonicalpb.Value_BytesValue{BytesValue: value}
+	case *big.Int:
+		if value != nil {
+			v.Kind = &canonicalpb.Value_BigValue{BigValue: toBigInt(value)}
+		}
+	case []interface{}:
+		list := &canonicalpb.ValueList{Values: make([]*canonicalpb.Value, len(value))}
+		for i, item := range value {
+			e, err := toValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			list.Values[i] = e
+		}
+		v.Kind = &canonicalpb.Value_ListValue{ListValue: list}
+	case []string:
+		items := make([]interface{}, len(value))
+		for i, s := range value {
+			items[i] = s
+		}
+		return toValue(items)
+	case map[string]interface{}:
+		fields, err := toValueMap(value)
+		if err != nil {
+			return nil, err
+		}
+		v.Kind = &canonicalpb.Value_MapValue{MapValue: &canonicalpb.ValueMap{Fields: fields}}
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return nil, err
+		}
+		return toValue(generic)
+	}
+	return v, nil
+}
+
+func fromValue(v *canonicalpb.Value) interface{} {
+	switch kind := v.GetKind().(type) {
+	case *canonicalpb.Value_StringValue:
+		return kind.StringValue
+	case *canonicalpb.Value_IntValue:
+		return kind.IntValue
+	case *canonicalpb.Value_UintValue:
+		return kind.UintValue
+	case *canonicalpb.Value_DoubleValue:
+		return kind.DoubleValue
+	case *canonicalpb.Value_BoolValue:
+		return kind.BoolValue
+	case *canonicalpb.Value_BytesValue:
+		return append([]byte{}, kind.BytesValue...)
+	case *canonicalpb.Value_ListValue:
+		list := make([]interface{}, len(kind.ListValue.GetValues()))
+		for i, item := range kind.ListValue.GetValues() {
+			list[i] = fromValue(item)
+		}
+		return list
+	case *canonicalpb.Value_MapValue:
+		fields := fromValueMap(kind.MapValue.GetFields())
+		if fields == nil {
+			fields = map[string]interface{}{}
+		}
+		return fields
+	case *canonicalpb.Value_BigValue:
+		return fromBigInt(kind.BigValue)
+	}
+	return nil
+}
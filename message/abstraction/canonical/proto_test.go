@@ -0,0 +1,106 @@
+package canonical
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCanonicalProtoRoundTrip(t *testing.T) {
+	height, _ := new(big.Int).SetString("340282366920938463463374607431768211457", 10)
+	msg := &abstraction.CanonicalMessage{
+		ChainID:     "cosmos-hub-4",
+		Height:      height,
+		Round:       big.NewInt(0),
+		Timestamp:   time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC),
+		Type:        abstraction.MsgTypePrecommit,
+		BlockHash:   "A1B2",
+		Validator:   "validator-1",
+		Signature:   "c2ln",
+		CommitSeals: []string{"seal-1", ""},
+		ViewChanges: []abstraction.ViewChangeEntry{{View: big.NewInt(-3), Height: big.NewInt(9), Validator: "v2"}},
+		Extensions: map[string]interface{}{
+			"uint":    uint64(1 << 63),
+			"int":     -7,
+			"float":   0.25,
+			"flag":    true,
+			"bytes":   []byte{0, 1},
+			"big":     big.NewInt(-1),
+			"list":    []string{"a", "b"},
+			"nested":  map[string]interface{}{"depth": uint64(2), "none": nil},
+			"struct":  struct{ Name string }{"x"},
+			"missing": nil,
+		},
+		RawPayload: []byte{0xff},
+	}
+
+	data, err := MarshalProto(msg)
+	if err != nil {
+		t.Fatalf("MarshalProto: %v", err)
+	}
+	got, err := UnmarshalProto(data)
+	if err != nil {
+		t.Fatalf("UnmarshalProto: %v", err)
+	}
+
+	want := *msg
+	want.Version = abstraction.CurrentVersion
+	want.Extensions = map[string]interface{}{
+		"uint":    uint64(1 << 63),
+		"int":     int64(-7),
+		"float":   0.25,
+		"flag":    true,
+		"bytes":   []byte{0, 1},
+		"big":     big.NewInt(-1),
+		"list":    []interface{}{"a", "b"},
+		"nested":  map[string]interface{}{"depth": uint64(2), "none": nil},
+		"struct":  map[string]interface{}{"Name": "x"},
+		"missing": nil,
+	}
+	if !reflect.DeepEqual(got, &want) {
+		t.Fatalf("round trip changed the message:\n got %+v\nwant %+v", got, &want)
+	}
+	if got.View != nil {
+		t.Fatalf("an unset view must stay nil, got %v", got.View)
+	}
+
+	again, err := MarshalProto(got)
+	if err != nil || string(again) != string(data) {
+		t.Fatalf("encoding is not deterministic")
+	}
+}
+
+func TestTimestampMatchesWellKnownType(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+	data, err := MarshalRawProto(&abstraction.RawConsensusMessage{ChainType: "narwhal", Timestamp: ts})
+	if err != nil {
+		t.Fatalf("MarshalRawProto: %v", err)
+	}
+
+	// Field 6 must decode as a google.protobuf.Timestamp.
+	var field []byte
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		data = data[n:]
+		n = protowire.ConsumeFieldValue(num, typ, data)
+		if num == 6 {
+			field, _ = protowire.ConsumeBytes(data)
+		}
+		data = data[n:]
+	}
+	var wkt timestamppb.Timestamp
+	if err := proto.Unmarshal(field, &wkt); err != nil || !wkt.AsTime().Equal(ts) {
+		t.Fatalf("timestamp field %x is not a google.protobuf.Timestamp for %v: %v", field, ts, err)
+	}
+
+	if _, err := UnmarshalRawProto([]byte{0x0a, 0x05, 'a'}); err == nil {
+		t.Fatalf("expected a truncated message to be rejected")
+	}
+}
@@ -0,0 +1,37 @@
+package abstraction
+
+import "context"
+
+// ContextMapper is a Mapper whose conversions take a context, so a caller can cancel or put a deadline on a
+// slow conversion, such as one served by another process. Mappers that convert in memory need not
+// implement it; ToCanonicalContext and FromCanonicalContext accept any Mapper.
+type ContextMapper interface {
+	Mapper
+	// ToCanonicalContext is ToCanonical, returning ctx's error once ctx ends.
+	ToCanonicalContext(ctx context.Context, raw RawConsensusMessage) (*CanonicalMessage, error)
+	// FromCanonicalContext is FromCanonical, returning ctx's error once ctx ends.
+	FromCanonicalContext(ctx context.Context, msg *CanonicalMessage) (*RawConsensusMessage, error)
+}
+
+// ToCanonicalContext converts raw with mapper under ctx. A ContextMapper is handed ctx; any other mapper is
+// not started once ctx has ended, but runs to completion once it has.
+func ToCanonicalContext(ctx context.Context, mapper Mapper, raw RawConsensusMessage) (*CanonicalMessage, error) {
+	if cm, ok := mapper.(ContextMapper); ok {
+		return cm.ToCanonicalContext(ctx, raw)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return mapper.ToCanonical(raw)
+}
+
+// FromCanonicalContext converts msg with mapper under ctx, like ToCanonicalContext.
+func FromCanonicalContext(ctx context.Context, mapper Mapper, msg *CanonicalMessage) (*RawConsensusMessage, error) {
+	if cm, ok := mapper.(ContextMapper); ok {
+		return cm.FromCanonicalContext(ctx, msg)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return mapper.FromCanonical(msg)
+}
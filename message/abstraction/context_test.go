@@ -0,0 +1,49 @@
+package abstraction_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"codec/message/abstraction"
+)
+
+// contextMapper records the context its conversions were given.
+type contextMapper struct {
+	heightMapper
+	got context.Context
+}
+
+func (m *contextMapper) ToCanonicalContext(ctx context.Context, raw abstraction.RawConsensusMessage) (*abstraction.CanonicalMessage, error) {
+	m.got = ctx
+	return m.ToCanonical(raw)
+}
+
+func (m *contextMapper) FromCanonicalContext(ctx context.Context, msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
+	m.got = ctx
+	return m.FromCanonical(msg)
+}
+
+func TestContextConversions(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "request")
+	mapper := &contextMapper{}
+	if msg, err := abstraction.ToCanonicalContext(ctx, mapper, abstraction.RawConsensusMessage{Payload: []byte("4")}); err != nil || msg.Height.Int64() != 4 {
+		t.Fatalf("ToCanonicalContext: %v, %v", msg, err)
+	}
+	if mapper.got != ctx {
+		t.Fatal("expected a ContextMapper to be handed the caller's context")
+	}
+
+	// A plain mapper is not started once the context has ended.
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	plain := &heightMapper{}
+	if _, err := abstraction.ToCanonicalContext(cancelled, plain, abstraction.RawConsensusMessage{Payload: []byte("4")}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, err := abstraction.FromCanonicalContext(cancelled, abstraction.WithLogger(plain, slog.New(slog.DiscardHandler)), &abstraction.CanonicalMessage{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a wrapped mapper to honour the context, got %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+// Package detect guesses the origin chain and encoding of payloads that arrive without reliable metadata.
+// It only sniffs structure: protobuf field layout, RLP list shapes, and the JSON field sets each adapter
+// produces. It never decodes a payload fully, so a positive result still has to be confirmed by the mapper.
+package detect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"codec/message/abstraction"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Encodings reported by Detect. They match RawConsensusMessage.Encoding.
+const (
+	EncodingJSON  = "json"
+	EncodingProto = "proto"
+	EncodingRLP   = "rlp"
+)
+
+// MinConfidence is the confidence below which a guess should not be acted on without other evidence.
+const MinConfidence = 0.5
+
+// Result is a detection with the evidence behind it.
+type Result struct {
+	ChainType  abstraction.ChainType `json:"chain_type,omitempty"`
+	Encoding   string                `json:"encoding,omitempty"`
+	Confidence float64               `json:"confidence"`
+	Reason     string                `json:"reason"`
+}
+
+// Detect guesses the chain and encoding of a payload. confidence is between 0 and 1; an empty chain type
+// with a non-empty encoding means the encoding was recognised but not the chain.
+func Detect(raw []byte) (abstraction.ChainType, string, float64) {
+	r := Sniff(raw)
+	return r.ChainType, r.Encoding, r.Confidence
+}
+
+// Sniff is Detect with an explanation of the guess.
+func Sniff(raw []byte) Result {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return Result{Reason: "empty payload"}
+	}
+	if trimmed[0] == '{' && json.Valid(trimmed) {
+		return sniffJSON(trimmed)
+	}
+	if r, ok := sniffRLP(raw); ok {
+		return r
+	}
+	if r, ok := sniffCometProto(raw); ok {
+		return r
+	}
+	return Result{Reason: "no known structure"}
+}
+
+// jsonMarkers are field paths that only one adapter's JSON form uses, with the weight of each as evidence.
+var jsonMarkers = map[abstraction.ChainType]map[string]int{
+	abstraction.ChainTypeCometBFT: {
+		"block_id": 2, "block_id.parts": 2, "block_id.part_set_header": 2, "validator_address": 2,
+		"validator_index": 1, "pol_round": 2, "proposer_address": 2, "extension_signature": 2,
+		"last_commit_round": 2, "seconds_since_start_time": 2, "part_index": 2, "votes_bit_array": 2,
+	},
+	abstraction.ChainTypeFabric: {
+		"channel_id": 3, "config_seq": 3, "signer.msp_id": 3, "seq": 1, "next_view": 1,
+	},
+	abstraction.ChainTypeKaia: {
+		"subject": 1, "subject.digest": 2, "subject.view.sequence": 3, "view.sequence": 3,
+		"committed_seal": 2, "proposal.gas_limit": 2, "proposal.mix_hash": 2, "consensus_msg": 2,
+	},
+	abstraction.ChainTypeHyperledger: {
+		"code": 1, "block_hash": 2, "commit_seal": 3, "body.code": 2, "body.block_hash": 2,
+		"vanity": 2, "seals": 2,
+	},
+}
+
+func sniffJSON(data []byte) Result {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return Result{Encoding: EncodingJSON, Reason: "JSON that is not an object"}
+	}
+
+	// Wrapped raw or canonical messages say where they came from.
+	if chain, ok := doc["chain_type"].(string); ok && chain != "" {
+		return Result{ChainType: abstraction.ChainType(chain), Encoding: EncodingJSON, Confidence: 1, Reason: "chain_type field"}
+	}
+
+	paths := make(map[string]bool)
+	collectPaths(doc, "", paths)
+
+	scores := make(map[abstraction.ChainType]int)
+	evidence := make(map[abstraction.ChainType][]string)
+	for chain, markers := range jsonMarkers {
+		for path, weight := range markers {
+			if paths[path] {
+				scores[chain] += weight
+				evidence[chain] = append(evidence[chain], path)
+			}
+		}
+	}
+	// CometBFT encodes heights as strings and vote types as numbers.
+	if h, ok := doc["height"].(string); ok && h != "" {
+		if _, ok := doc["type"].(float64); ok {
+			scores[abstraction.ChainTypeCometBFT] += 2
+			evidence[abstraction.ChainTypeCometBFT] = append(evidence[abstraction.ChainTypeCometBFT], "numeric type with string height")
+		}
+	}
+
+	best, second := rank(scores)
+	if best == "" {
+		return Result{Encoding: EncodingJSON, Reason: "JSON without known chain fields"}
+	}
+	sort.Strings(evidence[best])
+	return Result{
+		ChainType:  best,
+		Encoding:   EncodingJSON,
+		Confidence: confidence(scores[best], scores[second]),
+		Reason:     "fields " + strings.Join(evidence[best], ", "),
+	}
+}
+
+func collectPaths(value interface{}, prefix string, paths map[string]bool) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for key, child := range obj {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		paths[path] = true
+		collectPaths(child, path, paths)
+	}
+}
+
+// rank returns the best and second best chains; ties are broken by name so results are stable.
+func rank(scores map[abstraction.ChainType]int) (abstraction.ChainType, abstraction.ChainType) {
+	chains := make([]abstraction.ChainType, 0, len(scores))
+	for chain, score := range scores {
+		if score > 0 {
+			chains = append(chains, chain)
+		}
+	}
+	sort.Slice(chains, func(i, j int) bool {
+		if scores[chains[i]] != scores[chains[j]] {
+			return scores[chains[i]] > scores[chains[j]]
+		}
+		return chains[i] < chains[j]
+	})
+	var best, second abstraction.ChainType
+	if len(chains) > 0 {
+		best = chains[0]
+	}
+	if len(chains) > 1 {
+		second = chains[1]
+	}
+	return best, second
+}
+
+// confidence grows with the margin over the runner-up and with the amount of evidence.
+func confidence(best, second int) float64 {
+	c := float64(best) / float64(best+second)
+	if best < 4 {
+		c *= float64(best) / 4
+	}
+	return c
+}
+
+// sniffRLP recognises a payload that is exactly one RLP list. Kaia's istanbul message is
+// [code, msg, address(20), signature, committedSeal]; Besu's signed IBFT/QBFT payloads are [payload, signature(65)].
+func sniffRLP(raw []byte) (Result, bool) {
+	if len(raw) == 0 || raw[0] < 0xc0 {
+		return Result{}, false
+	}
+	content, rest, err := rlp.SplitList(raw)
+	if err != nil || len(rest) != 0 {
+		return Result{}, false
+	}
+	items, err := splitItems(content)
+	if err != nil {
+		return Result{}, false
+	}
+
+	switch {
+	case len(items) == 5 && items[0].kind != rlp.List && items[2].kind == rlp.String && len(items[2].content) == 20:
+		return Result{ChainType: abstraction.ChainTypeKaia, Encoding: EncodingRLP, Confidence: 0.85, Reason: "RLP list [code, msg, address, signature, committed seal]"}, true
+	case len(items) == 2 && items[0].kind == rlp.List && items[1].kind == rlp.String && len(items[1].content) == 65:
+		return Result{ChainType: abstraction.ChainTypeHyperledger, Encoding: EncodingRLP, Confidence: 0.8, Reason: "RLP list [payload, 65-byte signature]"}, true
+	default:
+		return Result{Encoding: EncodingRLP, Confidence: 0.3, Reason: fmt.Sprintf("RLP list of %d items with no known layout", len(items))}, true
+	}
+}
+
+type rlpItem struct {
+	kind    rlp.Kind
+	content []byte
+}
+
+func splitItems(content []byte) ([]rlpItem, error) {
+	var items []rlpItem
+	for len(content) > 0 {
+		kind, value, rest, err := rlp.Split(content)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, rlpItem{kind: kind, content: value})
+		content = rest
+	}
+	return items, nil
+}
+
+// cometMessageFields names the oneof fields of CometBFT's consensus Message.
+var cometMessageFields = map[protowire.Number]string{
+	1: "NewRoundStep", 2: "NewValidBlock", 3: "Proposal", 4: "ProposalPOL", 5: "BlockPart",
+	6: "Vote", 7: "HasVote", 8: "VoteSetMaj23", 9: "VoteSetBits",
+}
+
+// sniffCometProto recognises a CometBFT consensus Message: a single length-delimited field 1-9 that spans
+// the payload and itself holds well-formed protobuf. A leading uvarint length prefix, as written on the p2p
+// wire, is accepted.
+func sniffCometProto(raw []byte) (Result, bool) {
+	if name, ok := cometMessage(raw); ok {
+		return Result{ChainType: abstraction.ChainTypeCometBFT, Encoding: EncodingProto, Confidence: 0.8, Reason: "consensus Message oneof " + name}, true
+	}
+	if size, n := protowire.ConsumeVarint(raw); n > 0 && uint64(len(raw)-n) == size {
+		if name, ok := cometMessage(raw[n:]); ok {
+			return Result{ChainType: abstraction.ChainTypeCometBFT, Encoding: EncodingProto, Confidence: 0.8, Reason: "length-prefixed consensus Message oneof " + name}, true
+		}
+	}
+	if wellFormedProto(raw) {
+		return Result{Encoding: EncodingProto, Confidence: 0.2, Reason: "well-formed protobuf with no known layout"}, true
+	}
+	return Result{}, false
+}
+
+func cometMessage(raw []byte) (string, bool) {
+	num, typ, n := protowire.ConsumeTag(raw)
+	if n < 0 || typ != protowire.BytesType {
+		return "", false
+	}
+	name, ok := cometMessageFields[num]
+	if !ok {
+		return "", false
+	}
+	inner, m := protowire.ConsumeBytes(raw[n:])
+	if m < 0 || n+m != len(raw) || !wellFormedProto(inner) {
+		return "", false
+	}
+	return name, true
+}
+
+func wellFormedProto(b []byte) bool {
+	for len(b) > 0 {
+		num, _, n := protowire.ConsumeField(b)
+		if n < 0 || num == 0 {
+			return false
+		}
+		b = b[n:]
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package detect
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	cometbftAdapter "codec/cometbft/adapter"
+	besuAdapter "codec/hyperledger/besu/adapter"
+	fabricAdapter "codec/hyperledger/fabric/adapter"
+	kaiaAdapter "codec/kaia/adapter"
+	"codec/message/abstraction"
+
+	consensuspb "github.com/cometbft/cometbft/proto/tendermint/consensus"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestDetectAdapterJSON(t *testing.T) {
+	msg := &abstraction.CanonicalMessage{
+		ChainID:   "detect",
+		Height:    big.NewInt(100),
+		Round:     big.NewInt(0),
+		View:      big.NewInt(0),
+		Timestamp: time.Unix(1700000000, 0).UTC(),
+		BlockHash: "0x1111111111111111111111111111111111111111111111111111111111111111",
+		PrevHash:  "0x2222222222222222222222222222222222222222222222222222222222222222",
+		Validator: "validator-1",
+		Proposer:  "validator-1",
+	}
+	cases := []struct {
+		mapper    abstraction.Mapper
+		typ       abstraction.MsgType
+		validator string
+	}{
+		{cometbftAdapter.NewCometBFTMapper("detect"), abstraction.MsgTypePrevote, ""},
+		{cometbftAdapter.NewCometBFTMapper("detect"), abstraction.MsgTypeProposal, ""},
+		{fabricAdapter.NewFabricMapper("detect"), abstraction.MsgTypePrepare, "OrdererMSP/1"},
+		{kaiaAdapter.NewKaiaMapper("detect"), abstraction.MsgTypeProposal, ""},
+		{kaiaAdapter.NewKaiaMapper("detect"), abstraction.MsgTypeVote, ""},
+		{besuAdapter.NewBesuMapper("detect"), abstraction.MsgTypeCommit, ""},
+	}
+	for _, tc := range cases {
+		input := *msg
+		input.Type = tc.typ
+		if tc.validator != "" {
+			input.Validator, input.Proposer = tc.validator, tc.validator
+		}
+		raw, err := tc.mapper.FromCanonical(&input)
+		if err != nil {
+			t.Fatalf("%s %s: encode: %v", tc.mapper.GetChainType(), tc.typ, err)
+		}
+		r := Sniff(raw.Payload)
+		if r.ChainType != tc.mapper.GetChainType() || r.Encoding != EncodingJSON || r.Confidence < MinConfidence {
+			t.Fatalf("%s %s: got %+v for %s", tc.mapper.GetChainType(), tc.typ, r, raw.Payload)
+		}
+	}
+}
+
+func TestDetectNativeJSONFixture(t *testing.T) {
+	data, err := os.ReadFile("../../../examples/cometbft/Vote.json")
+	if err != nil {
+		t.Skipf("fixture unavailable: %v", err)
+	}
+	// The fixture file maps names to native CometBFT votes.
+	var fixtures map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+	chain, encoding, confidence := Detect(fixtures["prevote_for_block"])
+	if chain != abstraction.ChainTypeCometBFT || encoding != EncodingJSON || confidence < MinConfidence {
+		t.Fatalf("expected cometbft json, got %s %s %.2f", chain, encoding, confidence)
+	}
+}
+
+func TestDetectWireFormats(t *testing.T) {
+	vote := &consensuspb.Message{Sum: &consensuspb.Message_Vote{Vote: &consensuspb.Vote{Vote: &cmtproto.Vote{
+		Type:             cmtproto.PrevoteType,
+		Height:           100,
+		ValidatorAddress: make([]byte, 20),
+		Signature:        make([]byte, 64),
+	}}}}
+	frame, err := vote.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefixed := protowire.AppendVarint(nil, uint64(len(frame)))
+	prefixed = append(prefixed, frame...)
+
+	kaia, _ := rlp.EncodeToBytes(struct {
+		Code          uint64
+		Msg           []byte
+		Address       common.Address
+		Signature     []byte
+		CommittedSeal []byte
+	}{Code: 2, Msg: []byte{0xc0}, Signature: make([]byte, 65)})
+	besu, _ := rlp.EncodeToBytes([]interface{}{[]interface{}{uint64(100), uint64(0)}, make([]byte, 65)})
+	unknownRLP, _ := rlp.EncodeToBytes([]interface{}{uint64(1), uint64(2), uint64(3)})
+
+	cases := []struct {
+		name     string
+		payload  []byte
+		chain    abstraction.ChainType
+		encoding string
+		minConf  float64
+	}{
+		{"comet proto", frame, abstraction.ChainTypeCometBFT, EncodingProto, MinConfidence},
+		{"comet proto with length prefix", prefixed, abstraction.ChainTypeCometBFT, EncodingProto, MinConfidence},
+		{"kaia rlp", kaia, abstraction.ChainTypeKaia, EncodingRLP, MinConfidence},
+		{"besu rlp", besu, abstraction.ChainTypeHyperledger, EncodingRLP, MinConfidence},
+		{"unknown rlp", unknownRLP, "", EncodingRLP, 0},
+		{"wrapped raw message", []byte(`{"chain_type":"fabric","payload":"e30="}`), abstraction.ChainTypeFabric, EncodingJSON, 1},
+	}
+	for _, tc := range cases {
+		r := Sniff(tc.payload)
+		if r.ChainType != tc.chain || r.Encoding != tc.encoding || r.Confidence < tc.minConf {
+			t.Fatalf("%s: got %+v", tc.name, r)
+		}
+	}
+
+	for _, garbage := range [][]byte{nil, []byte("hello world"), {0xff, 0x00, 0x13}} {
+		if chain, _, confidence := Detect(garbage); chain != "" || confidence >= MinConfidence {
+			t.Fatalf("%q: expected no confident guess, got %s %.2f", garbage, chain, confidence)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package abstraction
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// SeedEpoch is the instant the clock reads right after Seed.
+var SeedEpoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// SeedClockStep is how far the seeded clock moves on every reading, so timestamps stay distinct and ordered.
+const SeedClockStep = time.Millisecond
+
+// The generators, the simulator and the byzantine engine take every timestamp and random draw from here, so
+// one call to Seed makes a whole experiment replay exactly. Without it they see the wall clock and a source
+// seeded from it.
+var determinism = struct {
+	sync.Mutex
+	seeded bool
+	clock  time.Time
+	rng    *rand.Rand
+}{rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// Seed makes Now and the random draws deterministic: the clock restarts at SeedEpoch and advances by
+// SeedClockStep per reading, and the random source restarts from seed. Replays are exact as long as the
+// experiment reads them in the same order, i.e. from a single goroutine.
+func Seed(seed int64) {
+	determinism.Lock()
+	defer determinism.Unlock()
+	determinism.seeded = true
+	determinism.clock = SeedEpoch
+	determinism.rng = rand.New(rand.NewSource(seed))
+}
+
+// Seeded reports whether Seed was called.
+func Seeded() bool {
+	determinism.Lock()
+	defer determinism.Unlock()
+	return determinism.seeded
+}
+
+// Now returns the wall clock, or the next reading of the seeded clock after Seed.
+func Now() time.Time {
+	determinism.Lock()
+	defer determinism.Unlock()
+	if !determinism.seeded {
+		return time.Now()
+	}
+	now := determinism.clock
+	determinism.clock = determinism.clock.Add(SeedClockStep)
+	return now
+}
+
+// Int63 returns a non-negative pseudo-random int64 from the shared source.
+func Int63() int64 {
+	determinism.Lock()
+	defer determinism.Unlock()
+	return determinism.rng.Int63()
+}
+
+// Intn returns a pseudo-random int in [0,n) from the shared source. It panics if n <= 0.
+func Intn(n int) int {
+	determinism.Lock()
+	defer determinism.Unlock()
+	return determinism.rng.Intn(n)
+}
+
+// NewRand returns a generator seeded from the shared source, for components that draw often or concurrently
+// and need their own. After Seed, the generators handed out are the same on every replay.
+func NewRand() *rand.Rand {
+	return rand.New(rand.NewSource(Int63()))
+}
@@ -0,0 +1,261 @@
+// Package evidence watches a stream of canonical messages and records the misbehaviour the byzantine package
+// forges: double votes, double proposals, and Tendermint lock violations. Each Evidence record carries both
+// conflicting messages, raw payloads included, so it can be replayed or submitted to the chain.
+package evidence
+
+import (
+	"math/big"
+	"sync"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/quorum"
+)
+
+// Kind names the rule a validator broke.
+type Kind string
+
+const (
+	// KindDoubleVote is two votes of the same type at the same height and round for different blocks.
+	KindDoubleVote Kind = "double_vote"
+	// KindDoubleProposal is two proposals at the same height and round for different blocks.
+	KindDoubleProposal Kind = "double_proposal"
+	// KindLockViolation is a prevote for another block after precommitting one, without a polka for the new
+	// block in a round in between.
+	KindLockViolation Kind = "lock_violation"
+)
+
+// Evidence is one piece of misbehaviour by one validator. First is the earlier message and Second the one
+// that conflicts with it; both keep the RawPayload the adapter decoded them from.
+type Evidence struct {
+	Kind      Kind                          `json:"kind"`
+	ChainID   string                        `json:"chain_id,omitempty"`
+	Validator string                        `json:"validator"`
+	Height    *big.Int                      `json:"height"`
+	Round     *big.Int                      `json:"round,omitempty"`
+	First     *abstraction.CanonicalMessage `json:"first"`
+	Second    *abstraction.CanonicalMessage `json:"second"`
+}
+
+// Detector checks each observed message against the ones seen before. It is safe for concurrent use.
+type Detector struct {
+	mu        sync.Mutex
+	votes     map[quorum.Step]map[signer][]*abstraction.CanonicalMessage
+	proposals map[quorum.Step]map[signer][]*abstraction.CanonicalMessage
+	locks     map[signerHeight]*abstraction.CanonicalMessage
+	prevotes  *quorum.Tracker
+	// polkas holds the rounds in which a block gathered two thirds of the prevotes.
+	polkas map[polkaKey][]*big.Int
+	found  []Evidence
+}
+
+type signer struct {
+	chain, id string
+}
+
+type signerHeight struct {
+	signer
+	height string
+}
+
+type polkaKey struct {
+	chain, height, blockHash string
+}
+
+// NewDetector returns a detector. With a validator set, a prevote that moves off a locked block is excused when
+// a polka for the new block was observed in a round after the lock; without one, every such prevote is
+// reported.
+func NewDetector(validators quorum.ValidatorSet) *Detector {
+	if len(validators) == 0 {
+		return NewDetectorFrom(nil)
+	}
+	return NewDetectorFrom(quorum.Static(validators))
+}
+
+// NewDetectorFrom returns a detector that looks up the validator set of each height in source, so polkas are
+// weighed by the voting power in force when they formed. A nil source behaves like NewDetector without a set.
+func NewDetectorFrom(source quorum.SetSource) *Detector {
+	d := &Detector{
+		votes:     map[quorum.Step]map[signer][]*abstraction.CanonicalMessage{},
+		proposals: map[quorum.Step]map[signer][]*abstraction.CanonicalMessage{},
+		locks:     map[signerHeight]*abstraction.CanonicalMessage{},
+		polkas:    map[polkaKey][]*big.Int{},
+	}
+	if source != nil {
+		d.prevotes = quorum.NewTrackerFrom(source)
+	}
+	return d
+}
+
+// Observe checks msg and returns the evidence it completes. Messages without a height, and repeats of a
+// message already seen, produce none.
+func (d *Detector) Observe(msg *abstraction.CanonicalMessage) []Evidence {
+	if msg == nil || msg.Height == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var found []Evidence
+	switch msg.Type {
+	case abstraction.MsgTypeProposal:
+		found, _ = d.conflicts(d.proposals, KindDoubleProposal, signerOf(msg, msg.Proposer), msg)
+	case abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit, abstraction.MsgTypePrepare,
+		abstraction.MsgTypeCommit, abstraction.MsgTypeVote:
+		who := signerOf(msg, msg.Validator)
+		var repeat bool
+		if found, repeat = d.conflicts(d.votes, KindDoubleVote, who, msg); !repeat {
+			found = append(found, d.checkLock(who, msg)...)
+		}
+	}
+	d.found = append(d.found, found...)
+	return found
+}
+
+// Evidence returns everything found so far, in the order it was found.
+func (d *Detector) Evidence() []Evidence {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Evidence(nil), d.found...)
+}
+
+// Prune forgets the messages of heights below height, so a long-running detector stays bounded. Evidence
+// already found is kept.
+func (d *Detector) Prune(height *big.Int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	below := func(h string) bool {
+		n, ok := new(big.Int).SetString(h, 10)
+		return ok && n.Cmp(height) < 0
+	}
+	for step := range d.votes {
+		if below(step.Height) {
+			delete(d.votes, step)
+		}
+	}
+	for step := range d.proposals {
+		if below(step.Height) {
+			delete(d.proposals, step)
+		}
+	}
+	for key := range d.locks {
+		if below(key.height) {
+			delete(d.locks, key)
+		}
+	}
+	for key := range d.polkas {
+		if below(key.height) {
+			delete(d.polkas, key)
+		}
+	}
+	if d.prevotes != nil {
+		d.prevotes.Prune(height)
+	}
+}
+
+// conflicts records msg under its step and signer and reports it against the signer's first message there
+// when the block hashes differ. repeat is set when the signer already sent a message for the same block.
+func (d *Detector) conflicts(seen map[quorum.Step]map[signer][]*abstraction.CanonicalMessage, kind Kind, who signer, msg *abstraction.CanonicalMessage) (found []Evidence, repeat bool) {
+	step := quorum.StepOf(msg)
+	bySigner, ok := seen[step]
+	if !ok {
+		bySigner = map[signer][]*abstraction.CanonicalMessage{}
+		seen[step] = bySigner
+	}
+	earlier := bySigner[who]
+	for _, prev := range earlier {
+		if prev.BlockHash == msg.BlockHash {
+			return nil, true
+		}
+	}
+	bySigner[who] = append(earlier, msg)
+	if len(earlier) == 0 {
+		return nil, false
+	}
+	return []Evidence{newEvidence(kind, who, earlier[0], msg)}, false
+}
+
+// checkLock applies the Tendermint locking rule: a validator that precommitted a block at round r may only
+// prevote for another block at a later round if a polka for that block formed in a round between the two.
+func (d *Detector) checkLock(who signer, msg *abstraction.CanonicalMessage) []Evidence {
+	if msg.Round == nil || msg.BlockHash == "" {
+		return nil
+	}
+	if msg.Type == abstraction.MsgTypePrevote {
+		d.recordPolka(msg)
+	}
+
+	key := signerHeight{signer: who, height: msg.Height.String()}
+	lock := d.locks[key]
+	switch msg.Type {
+	case abstraction.MsgTypePrecommit:
+		if lock == nil || msg.Round.Cmp(lock.Round) > 0 {
+			d.locks[key] = msg
+		}
+	case abstraction.MsgTypePrevote:
+		if lock == nil || msg.Round.Cmp(lock.Round) <= 0 || msg.BlockHash == lock.BlockHash {
+			return nil
+		}
+		if d.polkaBetween(msg, lock.Round, msg.Round) {
+			return nil
+		}
+		return []Evidence{newEvidence(KindLockViolation, who, lock, msg)}
+	}
+	return nil
+}
+
+// recordPolka tallies a prevote and remembers its round when it completes a polka for its block.
+func (d *Detector) recordPolka(msg *abstraction.CanonicalMessage) {
+	if d.prevotes == nil {
+		return
+	}
+	// A prevote from outside the validator set cannot help form a polka, so its error is ignored.
+	if _, err := d.prevotes.Add(msg); err != nil {
+		return
+	}
+	if hash, ok := d.prevotes.HasTwoThirds(quorum.StepOf(msg)); ok && hash == msg.BlockHash {
+		key := polkaKey{chain: msg.ChainID, height: msg.Height.String(), blockHash: hash}
+		for _, round := range d.polkas[key] {
+			if round.Cmp(msg.Round) == 0 {
+				return
+			}
+		}
+		d.polkas[key] = append(d.polkas[key], msg.Round)
+	}
+}
+
+// polkaBetween reports whether msg's block gathered two thirds of the prevotes in a round after from and
+// before to.
+func (d *Detector) polkaBetween(msg *abstraction.CanonicalMessage, from, to *big.Int) bool {
+	for _, round := range d.polkas[polkaKey{chain: msg.ChainID, height: msg.Height.String(), blockHash: msg.BlockHash}] {
+		if round.Cmp(from) > 0 && round.Cmp(to) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func signerOf(msg *abstraction.CanonicalMessage, id string) signer {
+	if id == "" {
+		id = msg.Validator
+		if id == "" {
+			id = msg.Proposer
+		}
+	}
+	return signer{chain: msg.ChainID, id: id}
+}
+
+func newEvidence(kind Kind, who signer, first, second *abstraction.CanonicalMessage) Evidence {
+	round := second.Round
+	if round == nil {
+		round = second.View
+	}
+	return Evidence{
+		Kind:      kind,
+		ChainID:   who.chain,
+		Validator: who.id,
+		Height:    second.Height,
+		Round:     round,
+		First:     first,
+		Second:    second,
+	}
+}
@@ -0,0 +1,98 @@
+package evidence
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/quorum"
+)
+
+func canonical(typ abstraction.MsgType, round int64, validator, hash string) *abstraction.CanonicalMessage {
+	msg := &abstraction.CanonicalMessage{
+		ChainID:    "cosmos-hub-4",
+		Type:       typ,
+		Height:     big.NewInt(100),
+		Round:      big.NewInt(round),
+		BlockHash:  hash,
+		RawPayload: []byte(validator + "/" + hash),
+	}
+	if typ == abstraction.MsgTypeProposal {
+		msg.Proposer = validator
+	} else {
+		msg.Validator = validator
+	}
+	return msg
+}
+
+func TestDetectorFindsDoubleVotesAndProposals(t *testing.T) {
+	d := NewDetector(nil)
+	if found := d.Observe(canonical(abstraction.MsgTypePrevote, 0, "v1", "A")); len(found) != 0 {
+		t.Fatalf("a single vote is not evidence: %+v", found)
+	}
+	if found := d.Observe(canonical(abstraction.MsgTypePrevote, 0, "v1", "A")); len(found) != 0 {
+		t.Fatalf("a replayed vote is not evidence: %+v", found)
+	}
+	if found := d.Observe(canonical(abstraction.MsgTypePrecommit, 0, "v1", "B")); len(found) != 0 {
+		t.Fatalf("votes of different types do not conflict: %+v", found)
+	}
+
+	found := d.Observe(canonical(abstraction.MsgTypePrevote, 0, "v1", "B"))
+	if len(found) != 1 || found[0].Kind != KindDoubleVote || found[0].Validator != "v1" ||
+		string(found[0].First.RawPayload) != "v1/A" || string(found[0].Second.RawPayload) != "v1/B" {
+		t.Fatalf("expected a double vote with both payloads, got %+v", found)
+	}
+
+	d.Observe(canonical(abstraction.MsgTypeProposal, 0, "v2", "A"))
+	found = d.Observe(canonical(abstraction.MsgTypeProposal, 0, "v2", "C"))
+	if len(found) != 1 || found[0].Kind != KindDoubleProposal || found[0].Validator != "v2" || found[0].Round.Int64() != 0 {
+		t.Fatalf("expected a double proposal, got %+v", found)
+	}
+
+	data, err := json.Marshal(d.Evidence())
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"kind":"double_vote"`) || !strings.Contains(string(data), `"raw_payload"`) {
+		t.Fatalf("unexpected evidence JSON %s", data)
+	}
+}
+
+func TestDetectorFindsLockViolations(t *testing.T) {
+	d := NewDetector(nil)
+	d.Observe(canonical(abstraction.MsgTypePrecommit, 0, "v1", "A"))
+	if found := d.Observe(canonical(abstraction.MsgTypePrevote, 1, "v1", "A")); len(found) != 0 {
+		t.Fatalf("prevoting the locked block is allowed: %+v", found)
+	}
+	if found := d.Observe(canonical(abstraction.MsgTypePrevote, 2, "v1", "")); len(found) != 0 {
+		t.Fatalf("a nil prevote does not break the lock: %+v", found)
+	}
+	found := d.Observe(canonical(abstraction.MsgTypePrevote, 3, "v1", "B"))
+	if len(found) != 1 || found[0].Kind != KindLockViolation || found[0].First.Type != abstraction.MsgTypePrecommit {
+		t.Fatalf("expected a lock violation, got %+v", found)
+	}
+
+	d.Prune(big.NewInt(101))
+	if found := d.Observe(canonical(abstraction.MsgTypePrevote, 4, "v1", "C")); len(found) != 0 {
+		t.Fatalf("pruned locks must be forgotten: %+v", found)
+	}
+	if len(d.Evidence()) != 1 {
+		t.Fatalf("prune must keep evidence, got %d", len(d.Evidence()))
+	}
+}
+
+func TestDetectorExcusesPolkaUnlock(t *testing.T) {
+	d := NewDetector(quorum.ValidatorSet{"v1": 1, "v2": 1, "v3": 1, "v4": 1})
+	d.Observe(canonical(abstraction.MsgTypePrecommit, 0, "v1", "A"))
+	for _, v := range []string{"v2", "v3", "v4"} {
+		d.Observe(canonical(abstraction.MsgTypePrevote, 1, v, "B"))
+	}
+	if found := d.Observe(canonical(abstraction.MsgTypePrevote, 2, "v1", "B")); len(found) != 0 {
+		t.Fatalf("a polka for B in round 1 unlocks v1: %+v", found)
+	}
+	if found := d.Observe(canonical(abstraction.MsgTypePrevote, 2, "v1", "C")); len(found) != 2 {
+		t.Fatalf("expected a double vote and a lock violation for C, got %+v", found)
+	}
+}
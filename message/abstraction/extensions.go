@@ -0,0 +1,200 @@
+package abstraction
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Extensions holds chain-specific fields of a CanonicalMessage. Values change type when a message crosses a
+// JSON boundary (an int32 becomes a float64, a []byte becomes a base64 string), so adapters read them through
+// the typed getters below, which accept every representation a value can arrive in.
+type Extensions map[string]interface{}
+
+// Set stores value under key, allocating the map if needed.
+func (e *Extensions) Set(key string, value interface{}) {
+	if *e == nil {
+		*e = Extensions{}
+	}
+	(*e)[key] = value
+}
+
+// Has reports whether key is present with a non-nil value.
+func (e Extensions) Has(key string) bool {
+	return e[key] != nil
+}
+
+// GetString returns the value under key as a string. Numbers, booleans and fmt.Stringers are formatted.
+func (e Extensions) GetString(key string) (string, bool) {
+	switch v := e[key].(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case fmt.Stringer:
+		return v.String(), true
+	case nil:
+		return "", false
+	}
+	if n, ok := e.GetInt64(key); ok {
+		return strconv.FormatInt(n, 10), true
+	}
+	if n, ok := e.GetUint64(key); ok {
+		return strconv.FormatUint(n, 10), true
+	}
+	return "", false
+}
+
+// GetInt64 returns the value under key as an int64. Integers of any width, integral floats, json.Number,
+// *big.Int and decimal strings are accepted as long as they fit.
+func (e Extensions) GetInt64(key string) (int64, bool) {
+	switch v := e[key].(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), uint64(v) <= math.MaxInt64
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	case float32:
+		return floatToInt64(float64(v))
+	case float64:
+		return floatToInt64(v)
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	case *big.Int:
+		if v == nil || !v.IsInt64() {
+			return 0, false
+		}
+		return v.Int64(), true
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// GetUint64 returns the value under key as a uint64, accepting the same representations as GetInt64.
+// Negative values are rejected.
+func (e Extensions) GetUint64(key string) (uint64, bool) {
+	switch v := e[key].(type) {
+	case uint:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case float64:
+		if v >= 0 && v < math.MaxUint64 && v == math.Trunc(v) {
+			return uint64(v), true
+		}
+		return 0, false
+	case json.Number:
+		n, err := strconv.ParseUint(string(v), 10, 64)
+		return n, err == nil
+	case *big.Int:
+		if v == nil || !v.IsUint64() {
+			return 0, false
+		}
+		return v.Uint64(), true
+	case string:
+		n, err := strconv.ParseUint(v, 10, 64)
+		return n, err == nil
+	}
+	n, ok := e.GetInt64(key)
+	if !ok || n < 0 {
+		return 0, false
+	}
+	return uint64(n), true
+}
+
+// GetBool returns the value under key as a bool. The strings accepted by strconv.ParseBool are accepted too.
+func (e Extensions) GetBool(key string) (bool, bool) {
+	switch v := e[key].(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	}
+	return false, false
+}
+
+// GetBytes returns the value under key as bytes. A string is decoded as 0x-prefixed hex or as the base64 that
+// encoding/json writes for a []byte.
+func (e Extensions) GetBytes(key string) ([]byte, bool) {
+	switch v := e[key].(type) {
+	case []byte:
+		return v, true
+	case string:
+		if strings.HasPrefix(v, "0x") || strings.HasPrefix(v, "0X") {
+			data, err := hex.DecodeString(v[2:])
+			return data, err == nil
+		}
+		data, err := base64.StdEncoding.DecodeString(v)
+		return data, err == nil
+	}
+	return nil, false
+}
+
+// GetStrings returns the value under key as a string slice. A []interface{} decoded from JSON is accepted when
+// every element is a string.
+func (e Extensions) GetStrings(key string) ([]string, bool) {
+	switch v := e[key].(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, s)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// Decode unmarshals the value under key into the value into points to. It goes through the value's JSON form,
+// so a struct stored by an adapter and the generic map it becomes once the message crosses JSON both decode.
+func (e Extensions) Decode(key string, into interface{}) bool {
+	value, ok := e[key]
+	if !ok || value == nil {
+		return false
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, into) == nil
+}
+
+func floatToInt64(v float64) (int64, bool) {
+	if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(v), true
+}
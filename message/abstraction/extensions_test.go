@@ -0,0 +1,98 @@
+package abstraction
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestExtensionsSurviveJSONRoundTrip(t *testing.T) {
+	var msg CanonicalMessage
+	msg.Extensions.Set("pol_round", int32(-1))
+	msg.Extensions.Set("part_index", uint32(3))
+	msg.Extensions.Set("part_bytes", []byte{0xde, 0xad})
+	msg.Extensions.Set("signers", []string{"a", "b"})
+	msg.Extensions.Set("pre_vote", true)
+
+	data, err := json.Marshal(&msg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var back CanonicalMessage
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	for _, ext := range []Extensions{msg.Extensions, back.Extensions} {
+		if v, ok := ext.GetInt64("pol_round"); !ok || v != -1 {
+			t.Fatalf("GetInt64(pol_round) = %v, %v", v, ok)
+		}
+		if v, ok := ext.GetUint64("part_index"); !ok || v != 3 {
+			t.Fatalf("GetUint64(part_index) = %v, %v", v, ok)
+		}
+		if v, ok := ext.GetBytes("part_bytes"); !ok || !reflect.DeepEqual(v, []byte{0xde, 0xad}) {
+			t.Fatalf("GetBytes(part_bytes) = %x, %v", v, ok)
+		}
+		if v, ok := ext.GetStrings("signers"); !ok || !reflect.DeepEqual(v, []string{"a", "b"}) {
+			t.Fatalf("GetStrings(signers) = %v, %v", v, ok)
+		}
+		if v, ok := ext.GetBool("pre_vote"); !ok || !v {
+			t.Fatalf("GetBool(pre_vote) = %v, %v", v, ok)
+		}
+	}
+}
+
+func TestExtensionsCoercion(t *testing.T) {
+	ext := Extensions{
+		"count":    "42",
+		"negative": int64(-5),
+		"fraction": 1.5,
+		"huge":     new(big.Int).Lsh(big.NewInt(1), 64),
+		"hex":      "0x0102",
+		"number":   json.Number("7"),
+	}
+	if v, ok := ext.GetUint64("count"); !ok || v != 42 {
+		t.Fatalf("expected a decimal string to parse, got %v, %v", v, ok)
+	}
+	if _, ok := ext.GetUint64("negative"); ok {
+		t.Fatalf("expected a negative value to be rejected as uint64")
+	}
+	if _, ok := ext.GetInt64("fraction"); ok {
+		t.Fatalf("expected a fractional float to be rejected")
+	}
+	if _, ok := ext.GetInt64("huge"); ok {
+		t.Fatalf("expected an overflowing big.Int to be rejected")
+	}
+	if v, ok := ext.GetBytes("hex"); !ok || !reflect.DeepEqual(v, []byte{1, 2}) {
+		t.Fatalf("GetBytes(hex) = %x, %v", v, ok)
+	}
+	if v, ok := ext.GetString("negative"); !ok || v != "-5" {
+		t.Fatalf("GetString(negative) = %q, %v", v, ok)
+	}
+	if v, ok := ext.GetString("number"); !ok || v != "7" {
+		t.Fatalf("GetString(number) = %q, %v", v, ok)
+	}
+	if _, ok := ext.GetString("missing"); ok {
+		t.Fatalf("expected a missing key to report false")
+	}
+}
+
+func TestExtensionsDecode(t *testing.T) {
+	type header struct {
+		Total uint32 `json:"total"`
+		Hash  []byte `json:"hash"`
+	}
+	want := header{Total: 2, Hash: []byte{0xab}}
+	ext := Extensions{"struct": want, "pointer": &want, "map": map[string]interface{}{"total": float64(2), "hash": "qw=="}, "text": "x"}
+	for _, key := range []string{"struct", "pointer", "map"} {
+		var got header
+		if !ext.Decode(key, &got) || !reflect.DeepEqual(got, want) {
+			t.Fatalf("Decode(%s) = %+v", key, got)
+		}
+	}
+	var got header
+	if ext.Decode("text", &got) || ext.Decode("missing", &got) {
+		t.Fatalf("expected a string and a missing key not to decode into a struct")
+	}
+}
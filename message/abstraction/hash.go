@@ -0,0 +1,45 @@
+package abstraction
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// CanonicalBytes returns a deterministic encoding of the message for comparison and hashing: compact JSON
+// with the timestamp in UTC, map keys sorted, and RawPayload left out, since the chain-specific bytes differ
+// between two conversions of the same consensus message. The encoding is a fixed point of a JSON round trip,
+// so a message read back from a JSON capture or sink encodes to the same bytes as the original; the exception
+// is integer extensions above 2^53, which JSON itself cannot carry exactly.
+func (m *CanonicalMessage) CanonicalBytes() ([]byte, error) {
+	if m == nil {
+		return nil, fmt.Errorf("canonical message is nil")
+	}
+	normalized := *m
+	normalized.Timestamp = m.Timestamp.UTC()
+	normalized.RawPayload = nil
+	if len(m.Extensions) > 0 {
+		// Going through JSON once turns every extension into the form it has after a round trip, e.g. a
+		// []byte into its base64 string and a struct into a map.
+		data, err := json.Marshal(m.Extensions)
+		if err != nil {
+			return nil, fmt.Errorf("extensions: %w", err)
+		}
+		normalized.Extensions = nil
+		if err := json.Unmarshal(data, &normalized.Extensions); err != nil {
+			return nil, fmt.Errorf("extensions: %w", err)
+		}
+	}
+	return json.Marshal(&normalized)
+}
+
+// Hash returns the hex-encoded SHA-256 digest of CanonicalBytes.
+func (m *CanonicalMessage) Hash() (string, error) {
+	data, err := m.CanonicalBytes()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
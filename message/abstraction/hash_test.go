@@ -0,0 +1,53 @@
+package abstraction
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestCanonicalBytesSurvivesJSONRoundTrip(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 5, time.UTC)
+	msg := &CanonicalMessage{
+		ChainID:   "cosmos-hub-4",
+		Height:    big.NewInt(100),
+		Round:     big.NewInt(0),
+		Timestamp: ts,
+		Type:      MsgTypePrevote,
+		BlockHash: "A1B2",
+		Validator: "validator-1",
+		Extensions: map[string]interface{}{
+			"vote_extension": []byte{1, 2, 3},
+			"validator_idx":  int32(4),
+			"nested":         map[string]interface{}{"b": uint64(2), "a": 1.5},
+		},
+		RawPayload: []byte("cometbft bytes"),
+	}
+	want, err := msg.Hash()
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var back CanonicalMessage
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	back.Timestamp = ts.In(time.FixedZone("KST", 9*3600))
+	back.RawPayload = []byte("besu bytes")
+	if got, _ := back.Hash(); got != want {
+		t.Fatalf("round trip changed the hash: %s != %s", got, want)
+	}
+
+	back.BlockHash = "A1B3"
+	if got, _ := back.Hash(); got == want {
+		t.Fatalf("expected a different block hash to change the digest")
+	}
+	if _, err := (&CanonicalMessage{Extensions: map[string]interface{}{"ch": make(chan int)}}).CanonicalBytes(); err == nil {
+		t.Fatalf("expected an unencodable extension to be rejected")
+	}
+}
@@ -0,0 +1,44 @@
+package abstraction
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestInt64Accessors(t *testing.T) {
+	msg := &CanonicalMessage{}
+	if _, ok := msg.HeightInt64(); ok {
+		t.Fatal("expected an unset height to be reported")
+	}
+	msg.Round, _ = new(big.Int).SetString("18446744073709551616", 10)
+	if _, ok := msg.RoundInt64(); ok {
+		t.Fatal("expected a round past int64 to be reported")
+	}
+
+	shared := big.NewInt(7)
+	msg.Height = shared
+	copied := *msg
+	msg.SetHeight(12)
+	msg.SetRound(3)
+	msg.SetView(1)
+	if shared.Int64() != 7 || copied.Height.Int64() != 7 {
+		t.Fatal("expected SetHeight to leave a shared big.Int alone")
+	}
+	height, _ := msg.HeightInt64()
+	round, _ := msg.RoundInt64()
+	view, ok := msg.ViewInt64()
+	if height != 12 || round != 3 || view != 1 || !ok {
+		t.Fatalf("expected 12/3/1, got %d/%d/%d", height, round, view)
+	}
+
+	// The fields stay *big.Int, so the JSON layout is unchanged.
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"height":12,"round":3,"view":1`) {
+		t.Fatalf("expected numeric height, round, and view, got %s", data)
+	}
+}
@@ -0,0 +1,444 @@
+// Package lint checks hand-written canonical messages before they are fed into byzantine experiments.
+package lint
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+// Severity ranks an issue.
+type Severity string
+
+const (
+	// SeverityError blocks the experiment; the message will be rejected or mutate incorrectly.
+	SeverityError Severity = "error"
+	// SeverityWarning flags input that works but is probably not what was intended.
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a single lint finding.
+type Issue struct {
+	Field    string   `json:"field"`
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+	// Suggestion describes how to fix the issue; Fixable reports whether Fix applies it automatically.
+	Suggestion string `json:"suggestion,omitempty"`
+	Fixable    bool   `json:"fixable"`
+
+	fix func(msg *abstraction.CanonicalMessage)
+}
+
+// Profile captures what a target chain expects from canonical input.
+type Profile struct {
+	Chain abstraction.ChainType
+	// HashBytes is the decoded length of block and previous-block hashes.
+	HashBytes int
+	// HashPrefix is "0x" for chains that render hashes with a prefix, empty otherwise.
+	HashPrefix string
+	// HashUpper reports whether hashes are rendered in upper-case hex, as CometBFT's HexBytes does.
+	HashUpper      bool
+	RequiresRound  bool
+	RequiresView   bool
+	SupportedTypes []abstraction.MsgType
+	// RecommendedExtensions lists extension keys the chain's encoder reads; missing ones fall back to defaults.
+	RecommendedExtensions []string
+}
+
+// DefaultProfile returns the built-in profile for a chain.
+func DefaultProfile(chain abstraction.ChainType) Profile {
+	switch chain {
+	case abstraction.ChainTypeCometBFT:
+		return Profile{
+			Chain:          chain,
+			HashBytes:      32,
+			HashUpper:      true,
+			RequiresRound:  true,
+			SupportedTypes: []abstraction.MsgType{abstraction.MsgTypeProposal, abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit, abstraction.MsgTypeBlock},
+		}
+	case abstraction.ChainTypeFabric:
+		return Profile{
+			Chain:                 chain,
+			HashBytes:             32,
+			HashPrefix:            "0x",
+			RequiresView:          true,
+			SupportedTypes:        []abstraction.MsgType{abstraction.MsgTypeProposal, abstraction.MsgTypePrepare, abstraction.MsgTypeCommit, abstraction.MsgTypeViewChange, abstraction.MsgTypeNewView},
+			RecommendedExtensions: []string{"channel_id"},
+		}
+	case abstraction.ChainTypeFabricRaft:
+		return Profile{
+			Chain:                 chain,
+			RequiresView:          true,
+			SupportedTypes:        []abstraction.MsgType{abstraction.MsgTypeProposal, abstraction.MsgTypeCommit, abstraction.MsgTypeViewChange, abstraction.MsgTypeVote, abstraction.MsgTypeBlock},
+			RecommendedExtensions: []string{"channel_id"},
+		}
+	case abstraction.ChainTypeHotStuff:
+		return Profile{
+			Chain:          chain,
+			RequiresView:   true,
+			SupportedTypes: []abstraction.MsgType{abstraction.MsgTypeProposal, abstraction.MsgTypePrepare, abstraction.MsgTypePrecommit, abstraction.MsgTypeCommit, abstraction.MsgTypeVote, abstraction.MsgTypeNewView},
+		}
+	case abstraction.ChainTypeEthereum:
+		return Profile{
+			Chain:                 chain,
+			HashBytes:             32,
+			HashPrefix:            "0x",
+			SupportedTypes:        []abstraction.MsgType{abstraction.MsgTypeProposal, abstraction.MsgTypeVote},
+			RecommendedExtensions: []string{"beacon_message_type"},
+		}
+	case abstraction.ChainTypeAptos:
+		return Profile{
+			Chain:                 chain,
+			HashBytes:             32,
+			HashPrefix:            "0x",
+			RequiresView:          true,
+			SupportedTypes:        []abstraction.MsgType{abstraction.MsgTypeProposal, abstraction.MsgTypeVote, abstraction.MsgTypeViewChange, abstraction.MsgTypeCommit},
+			RecommendedExtensions: []string{"aptos_message_type"},
+		}
+	case abstraction.ChainTypeAvalanche:
+		return Profile{
+			Chain:                 chain,
+			SupportedTypes:        []abstraction.MsgType{abstraction.MsgTypeProposal, abstraction.MsgTypeVote, abstraction.MsgTypeBlock},
+			RecommendedExtensions: []string{"snowman_message_type", "blockchain_id"},
+		}
+	case abstraction.ChainTypeHyperledger:
+		return Profile{
+			Chain:          chain,
+			HashBytes:      32,
+			HashPrefix:     "0x",
+			RequiresRound:  true,
+			SupportedTypes: []abstraction.MsgType{abstraction.MsgTypeProposal, abstraction.MsgTypePrepare, abstraction.MsgTypeCommit, abstraction.MsgTypeRoundChange},
+		}
+	case abstraction.ChainTypeKaia:
+		return Profile{
+			Chain:          chain,
+			HashBytes:      32,
+			HashPrefix:     "0x",
+			RequiresRound:  true,
+			// Vote and block are what Prepare/Commit and RoundChange decoded to before they had their own types.
+			SupportedTypes: []abstraction.MsgType{abstraction.MsgTypeProposal, abstraction.MsgTypePrepare, abstraction.MsgTypeCommit, abstraction.MsgTypeRoundChange, abstraction.MsgTypeVote, abstraction.MsgTypeBlock},
+		}
+	default:
+		if info, ok := abstraction.LookupChainType(chain); ok {
+			return Profile{
+				Chain:          chain,
+				RequiresRound:  info.RequiresRound,
+				RequiresView:   info.RequiresView,
+				SupportedTypes: info.MsgTypes,
+			}
+		}
+		return Profile{Chain: chain}
+	}
+}
+
+// Result is the outcome of linting one input.
+type Result struct {
+	Message *abstraction.CanonicalMessage `json:"-"`
+	Issues  []Issue                       `json:"issues"`
+}
+
+// HasErrors reports whether any issue is an error.
+func (r *Result) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Fix applies every automatic fix to the message and returns how many were applied.
+func (r *Result) Fix() int {
+	if r.Message == nil {
+		return 0
+	}
+	applied := 0
+	for _, issue := range r.Issues {
+		if issue.fix != nil {
+			issue.fix(r.Message)
+			applied++
+		}
+	}
+	return applied
+}
+
+// Now is the clock used for timestamp sanity checks; tests replace it.
+var Now = abstraction.Now
+
+var knownFields = map[string]bool{
+	"chain_id": true, "height": true, "round": true, "view": true, "timestamp": true, "type": true,
+	"block_hash": true, "prev_hash": true, "proposer": true, "validator": true, "signature": true,
+	"commit_seals": true, "view_changes": true, "extensions": true, "raw_payload": true,
+}
+
+// LintJSON decodes a canonical message and lints it for the profile and the action it will be used with.
+func LintJSON(data []byte, profile Profile, action byzantine.Action) *Result {
+	result := &Result{}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		result.add(Issue{Field: "message", Severity: SeverityError, Code: "INVALID_JSON", Message: err.Error()})
+		return result
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !knownFields[name] {
+			result.add(Issue{
+				Field: name, Severity: SeverityWarning, Code: "UNKNOWN_FIELD",
+				Message:    fmt.Sprintf("field %q is not part of the canonical message and will be ignored", name),
+				Suggestion: suggestField(name),
+			})
+		}
+	}
+
+	var msg abstraction.CanonicalMessage
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&msg); err != nil {
+		result.add(Issue{Field: "message", Severity: SeverityError, Code: "DECODE_FAILURE", Message: err.Error()})
+		return result
+	}
+	result.Message = &msg
+	result.Issues = append(result.Issues, Lint(&msg, profile, action)...)
+	return result
+}
+
+// Lint checks a decoded canonical message.
+func Lint(msg *abstraction.CanonicalMessage, profile Profile, action byzantine.Action) []Issue {
+	r := &Result{}
+	r.checkHeader(msg, profile)
+	r.checkHash("block_hash", msg.BlockHash, profile, func(m *abstraction.CanonicalMessage, v string) { m.BlockHash = v })
+	r.checkHash("prev_hash", msg.PrevHash, profile, func(m *abstraction.CanonicalMessage, v string) { m.PrevHash = v })
+	r.checkTimestamp(msg)
+	for _, key := range profile.RecommendedExtensions {
+		if !hasExtension(msg, key) {
+			r.add(Issue{Field: "extensions." + key, Severity: SeverityWarning, Code: "MISSING_EXTENSION",
+				Message: fmt.Sprintf("%s messages usually carry the %s extension", profile.Chain, key)})
+		}
+	}
+	r.checkAction(msg, profile, action)
+	return r.Issues
+}
+
+func (r *Result) add(issue Issue) {
+	issue.Fixable = issue.fix != nil
+	r.Issues = append(r.Issues, issue)
+}
+
+func (r *Result) checkHeader(msg *abstraction.CanonicalMessage, profile Profile) {
+	if strings.TrimSpace(msg.ChainID) == "" {
+		r.add(Issue{Field: "chain_id", Severity: SeverityWarning, Code: "MISSING_FIELD",
+			Message: "chain_id is empty", Suggestion: "set chain_id or pass -chain-id when running cmd/byzantine"})
+	}
+	if msg.Type == "" {
+		r.add(Issue{Field: "type", Severity: SeverityError, Code: "MISSING_FIELD", Message: "type is required"})
+	} else if len(profile.SupportedTypes) > 0 && !containsType(profile.SupportedTypes, msg.Type) {
+		issue := Issue{Field: "type", Severity: SeverityError, Code: "UNSUPPORTED_TYPE",
+			Message: fmt.Sprintf("%s does not support message type %q (supported: %s)", profile.Chain, msg.Type, joinTypes(profile.SupportedTypes))}
+		if lower := abstraction.MsgType(strings.ToLower(string(msg.Type))); lower != msg.Type && containsType(profile.SupportedTypes, lower) {
+			issue.Suggestion = fmt.Sprintf("use %q", lower)
+			issue.fix = func(m *abstraction.CanonicalMessage) { m.Type = lower }
+		}
+		r.add(issue)
+	}
+
+	if msg.Height == nil {
+		r.add(Issue{Field: "height", Severity: SeverityError, Code: "MISSING_FIELD", Message: "height is required"})
+	} else if msg.Height.Sign() < 0 {
+		r.add(Issue{Field: "height", Severity: SeverityError, Code: "NEGATIVE_VALUE", Message: "height must not be negative"})
+	}
+	if msg.Round != nil && msg.Round.Sign() < 0 {
+		r.add(Issue{Field: "round", Severity: SeverityError, Code: "NEGATIVE_VALUE", Message: "round must not be negative"})
+	}
+	if msg.View != nil && msg.View.Sign() < 0 {
+		r.add(Issue{Field: "view", Severity: SeverityError, Code: "NEGATIVE_VALUE", Message: "view must not be negative"})
+	}
+
+	if profile.RequiresRound && msg.Round == nil {
+		issue := Issue{Field: "round", Severity: SeverityError, Code: "MISSING_FIELD",
+			Message: fmt.Sprintf("%s messages need a round", profile.Chain)}
+		if msg.View != nil {
+			view := msg.View
+			issue.Suggestion = "this chain uses rounds; move view into round"
+			issue.fix = func(m *abstraction.CanonicalMessage) { m.Round, m.View = view, nil }
+		}
+		r.add(issue)
+	}
+	if profile.RequiresView && msg.View == nil {
+		issue := Issue{Field: "view", Severity: SeverityError, Code: "MISSING_FIELD",
+			Message: fmt.Sprintf("%s messages need a view", profile.Chain)}
+		if msg.Round != nil {
+			round := msg.Round
+			issue.Suggestion = "this chain uses views; move round into view"
+			issue.fix = func(m *abstraction.CanonicalMessage) { m.View, m.Round = round, nil }
+		}
+		r.add(issue)
+	}
+}
+
+func (r *Result) checkHash(field, value string, profile Profile, set func(*abstraction.CanonicalMessage, string)) {
+	if value == "" || profile.HashBytes == 0 {
+		return
+	}
+	body := strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")
+	decoded, err := hex.DecodeString(body)
+	if err != nil {
+		r.add(Issue{Field: field, Severity: SeverityError, Code: "INVALID_HASH",
+			Message: fmt.Sprintf("%s is not hex encoded", field)})
+		return
+	}
+	if len(decoded) != profile.HashBytes {
+		r.add(Issue{Field: field, Severity: SeverityError, Code: "INVALID_HASH_LENGTH",
+			Message:    fmt.Sprintf("%s is %d bytes, %s expects %d", field, len(decoded), profile.Chain, profile.HashBytes),
+			Suggestion: fmt.Sprintf("use %d hex characters", profile.HashBytes*2)})
+		return
+	}
+
+	want := profile.HashPrefix + strings.ToLower(body)
+	if profile.HashUpper {
+		want = profile.HashPrefix + strings.ToUpper(body)
+	}
+	if value != want {
+		r.add(Issue{Field: field, Severity: SeverityWarning, Code: "HASH_FORMAT",
+			Message:    fmt.Sprintf("%s is not in the form %s renders hashes", field, profile.Chain),
+			Suggestion: fmt.Sprintf("use %s", want),
+			fix:        func(m *abstraction.CanonicalMessage) { set(m, want) }})
+	}
+}
+
+func (r *Result) checkTimestamp(msg *abstraction.CanonicalMessage) {
+	now := Now().UTC()
+	switch {
+	case msg.Timestamp.IsZero():
+		stamp := now.Truncate(time.Millisecond)
+		r.add(Issue{Field: "timestamp", Severity: SeverityWarning, Code: "MISSING_FIELD",
+			Message:    "timestamp is missing; cmd/byzantine substitutes the current time, making runs irreproducible",
+			Suggestion: fmt.Sprintf("set timestamp to %s", stamp.Format(time.RFC3339Nano)),
+			fix:        func(m *abstraction.CanonicalMessage) { m.Timestamp = stamp }})
+	case msg.Timestamp.Year() < 2015:
+		r.add(Issue{Field: "timestamp", Severity: SeverityWarning, Code: "TIMESTAMP_SANITY",
+			Message: fmt.Sprintf("timestamp %s predates every supported chain; check the units", msg.Timestamp.Format(time.RFC3339))})
+	case msg.Timestamp.After(now.Add(time.Hour)):
+		r.add(Issue{Field: "timestamp", Severity: SeverityWarning, Code: "TIMESTAMP_SANITY",
+			Message: fmt.Sprintf("timestamp %s is more than an hour in the future; validators will reject it", msg.Timestamp.Format(time.RFC3339))})
+	}
+}
+
+func (r *Result) checkAction(msg *abstraction.CanonicalMessage, profile Profile, action byzantine.Action) {
+	need := func(field string, present bool, why string) {
+		if !present {
+			r.add(Issue{Field: field, Severity: SeverityError, Code: "ACTION_REQUIREMENT",
+				Message: fmt.Sprintf("%s action %s", action, why)})
+		}
+	}
+	switch action {
+	case byzantine.ActionDoubleVote:
+		need("type", byzantine.IsVote(msg.Type), "requires a vote message")
+		need("block_hash", msg.BlockHash != "", "needs block_hash so the conflicting vote differs from a nil vote")
+		need("validator", msg.Validator != "", "needs the equivocating validator")
+	case byzantine.ActionDoubleProposal:
+		need("type", msg.Type == abstraction.MsgTypeProposal, "requires a proposal message")
+		need("block_hash", msg.BlockHash != "", "needs block_hash for the first proposal")
+		need("proposer", msg.Proposer != "", "needs the equivocating proposer")
+		if profile.Chain == abstraction.ChainTypeFabric {
+			need("extensions.channel_id", hasExtension(msg, "channel_id"), "needs the channel_id extension on fabric")
+		}
+	case byzantine.ActionAlterValidator, "forge_identity":
+		need("type", byzantine.IsVote(msg.Type) || msg.Type == abstraction.MsgTypeProposal, "requires a proposal or vote message")
+		r.add(Issue{Field: "validator", Severity: SeverityWarning, Code: "ACTION_OPTION",
+			Message:    fmt.Sprintf("%s replaces the signer with -alternate-validator or a derived identity", action),
+			Suggestion: "pass -alternate-validator to control the forged identity"})
+	case "drop_config_seq":
+		if !hasExtension(msg, "config_seq") {
+			r.add(Issue{Field: "extensions.config_seq", Severity: SeverityWarning, Code: "ACTION_NOOP",
+				Message: "drop_config_seq on a message without config_seq changes nothing"})
+		}
+	case byzantine.ActionDropSignature:
+		if msg.Signature == "" {
+			r.add(Issue{Field: "signature", Severity: SeverityWarning, Code: "ACTION_NOOP",
+				Message: "drop_signature on a message without a signature changes nothing"})
+		}
+	case byzantine.ActionNilFlip:
+		need("type", byzantine.IsVote(msg.Type), "requires a vote message")
+		if msg.BlockHash == "" {
+			r.add(Issue{Field: "block_hash", Severity: SeverityWarning, Code: "ACTION_OPTION",
+				Message:    "nil_flip on a nil vote votes for -alternate-block, which must be set",
+				Suggestion: "pass -alternate-block with the block the flipped vote should commit to"})
+		}
+	case byzantine.ActionHeightFlood:
+		r.add(Issue{Field: "height", Severity: SeverityWarning, Code: "ACTION_OPTION",
+			Message:    "height_flood emits one copy per offset in -flood-range, which must be set",
+			Suggestion: "pass -flood-range N..M"})
+	case "corrupt_extension":
+		need("type", msg.Type == abstraction.MsgTypePrecommit, "requires a precommit; only precommits carry vote extensions")
+		need("block_hash", msg.BlockHash != "", "needs a precommit for a block; nil precommits carry no extension")
+	case "amnesia":
+		need("type", msg.Type == abstraction.MsgTypePrecommit, "starts from the precommit that takes the lock")
+		need("block_hash", msg.BlockHash != "", "needs the locked block hash; nil precommits do not lock")
+		need("round", msg.Round != nil, "needs the locked round")
+	case "withhold_commit":
+		need("type", msg.Type == abstraction.MsgTypePrecommit || msg.Type == abstraction.MsgTypeProposal, "withholds precommits for blocks recorded from the validator's own proposals")
+		need("block_hash", msg.BlockHash != "", "needs a block hash; nil precommits are never withheld")
+	}
+}
+
+func hasExtension(msg *abstraction.CanonicalMessage, key string) bool {
+	value, ok := msg.Extensions[key]
+	if !ok || value == nil {
+		return false
+	}
+	if text, isString := value.(string); isString {
+		return text != ""
+	}
+	return true
+}
+
+func containsType(types []abstraction.MsgType, t abstraction.MsgType) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+func joinTypes(types []abstraction.MsgType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
+
+// suggestField maps common misspellings to canonical field names.
+func suggestField(name string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "_", " ", "").Replace(name))
+	aliases := map[string]string{
+		"blockhash": "block_hash", "hash": "block_hash", "block_id": "block_hash",
+		"prevhash": "prev_hash", "parent_hash": "prev_hash",
+		"chainid": "chain_id", "msg_type": "type", "message_type": "type",
+		"validator_address": "validator", "proposer_address": "proposer", "sig": "signature",
+		"extension": "extensions",
+	}
+	if alias, ok := aliases[strings.ReplaceAll(normalized, "_", "")]; ok {
+		return fmt.Sprintf("did you mean %q?", alias)
+	}
+	if alias, ok := aliases[normalized]; ok {
+		return fmt.Sprintf("did you mean %q?", alias)
+	}
+	if knownFields[normalized] {
+		return fmt.Sprintf("did you mean %q?", normalized)
+	}
+	return ""
+}
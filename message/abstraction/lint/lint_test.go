@@ -0,0 +1,105 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+func init() {
+	Now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+}
+
+const validHash = "A1B2C3D4E5F60718293A4B5C6D7E8F90A1B2C3D4E5F60718293A4B5C6D7E8F90"
+
+func codes(issues []Issue) map[string]Issue {
+	out := make(map[string]Issue, len(issues))
+	for _, issue := range issues {
+		out[issue.Field+":"+issue.Code] = issue
+	}
+	return out
+}
+
+func TestLintCleanCometBFTPrevote(t *testing.T) {
+	input := `{"chain_id":"cosmos-hub-4","height":100,"round":0,"timestamp":"2024-05-01T11:59:00Z","type":"prevote",
+		"block_hash":"` + validHash + `","validator":"validator-1","signature":"c2ln"}`
+
+	result := LintJSON([]byte(input), DefaultProfile(abstraction.ChainTypeCometBFT), byzantine.ActionDoubleVote)
+	if len(result.Issues) != 0 {
+		t.Fatalf("expected no issues, got %+v", result.Issues)
+	}
+}
+
+func TestLintReportsStructuralAndActionIssues(t *testing.T) {
+	input := `{"chainid":"cosmos-hub-4","height":-1,"view":2,"type":"Proposal","block_hash":"0xabcd","timestamp":"2099-01-01T00:00:00Z"}`
+
+	result := LintJSON([]byte(input), DefaultProfile(abstraction.ChainTypeCometBFT), byzantine.ActionDoubleVote)
+	if !result.HasErrors() {
+		t.Fatalf("expected errors")
+	}
+	found := codes(result.Issues)
+	for _, key := range []string{
+		"chainid:UNKNOWN_FIELD",
+		"height:NEGATIVE_VALUE",
+		"round:MISSING_FIELD",
+		"type:UNSUPPORTED_TYPE",
+		"block_hash:INVALID_HASH_LENGTH",
+		"timestamp:TIMESTAMP_SANITY",
+		"type:ACTION_REQUIREMENT",
+		"validator:ACTION_REQUIREMENT",
+	} {
+		if _, ok := found[key]; !ok {
+			t.Errorf("expected issue %s, got %+v", key, result.Issues)
+		}
+	}
+	if !strings.Contains(found["chainid:UNKNOWN_FIELD"].Suggestion, "chain_id") {
+		t.Errorf("expected chain_id suggestion, got %q", found["chainid:UNKNOWN_FIELD"].Suggestion)
+	}
+}
+
+func TestLintFixNormalisesInput(t *testing.T) {
+	input := `{"chain_id":"kaia-1","height":5,"view":1,"type":"VOTE","block_hash":"` + validHash + `"}`
+
+	result := LintJSON([]byte(input), DefaultProfile(abstraction.ChainTypeKaia), byzantine.ActionNone)
+	if applied := result.Fix(); applied != 4 {
+		t.Fatalf("expected 4 fixes, got %d: %+v", applied, result.Issues)
+	}
+	msg := result.Message
+	if msg.Type != abstraction.MsgTypeVote {
+		t.Errorf("expected type to be lowercased, got %s", msg.Type)
+	}
+	if msg.Round == nil || msg.Round.Int64() != 1 || msg.View != nil {
+		t.Errorf("expected view to move into round, got round=%v view=%v", msg.Round, msg.View)
+	}
+	if msg.BlockHash != "0x"+strings.ToLower(validHash) {
+		t.Errorf("expected prefixed lowercase hash, got %s", msg.BlockHash)
+	}
+	if msg.Timestamp.IsZero() {
+		t.Errorf("expected timestamp to be filled in")
+	}
+
+	if issues := Lint(msg, DefaultProfile(abstraction.ChainTypeKaia), byzantine.ActionNone); len(issues) != 0 {
+		t.Fatalf("expected fixed message to be clean, got %+v", issues)
+	}
+}
+
+func TestLintFabricDoubleProposalNeedsChannel(t *testing.T) {
+	msg := &abstraction.CanonicalMessage{
+		ChainID:   "fabric-test",
+		Height:    nil,
+		Type:      abstraction.MsgTypeProposal,
+		BlockHash: strings.ToLower(validHash),
+		Proposer:  "orderer0",
+		Timestamp: Now(),
+	}
+
+	found := codes(Lint(msg, DefaultProfile(abstraction.ChainTypeFabric), byzantine.ActionDoubleProposal))
+	for _, key := range []string{"height:MISSING_FIELD", "view:MISSING_FIELD", "extensions.channel_id:ACTION_REQUIREMENT", "block_hash:HASH_FORMAT"} {
+		if _, ok := found[key]; !ok {
+			t.Errorf("expected issue %s, got %+v", key, found)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package abstraction
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// LogAttrs returns the fields that identify msg in a log record: its chain, height, round or view, and type.
+// Unset fields are left out, so the result can be passed straight to a *slog.Logger method or to With.
+func LogAttrs(msg *CanonicalMessage) []any {
+	if msg == nil {
+		return nil
+	}
+	attrs := []any{"chain", msg.ChainID}
+	if msg.Height != nil {
+		attrs = append(attrs, "height", msg.Height)
+	}
+	if msg.Round != nil {
+		attrs = append(attrs, "round", msg.Round)
+	}
+	if msg.View != nil {
+		attrs = append(attrs, "view", msg.View)
+	}
+	return append(attrs, "type", msg.Type)
+}
+
+// RawLogAttrs returns the fields that identify a raw message, which has no height or round until it is decoded.
+func RawLogAttrs(raw RawConsensusMessage) []any {
+	return []any{"chain", raw.ChainID, "chain_type", raw.ChainType, "message_type", raw.MessageType, "encoding", raw.Encoding}
+}
+
+// loggingMapper logs every conversion made by the mapper it wraps.
+type loggingMapper struct {
+	Mapper
+	logger *slog.Logger
+}
+
+// WithLogger wraps mapper so that each conversion is logged to logger: successes at debug level with the
+// message's LogAttrs, failures at warn level with the error. A nil logger uses slog.Default.
+func WithLogger(mapper Mapper, logger *slog.Logger) Mapper {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &loggingMapper{Mapper: mapper, logger: logger}
+}
+
+func (m *loggingMapper) ToCanonical(raw RawConsensusMessage) (*CanonicalMessage, error) {
+	return m.ToCanonicalContext(context.Background(), raw)
+}
+
+// ToCanonicalContext passes ctx on to the wrapped mapper, so wrapping a ContextMapper keeps it cancellable.
+func (m *loggingMapper) ToCanonicalContext(ctx context.Context, raw RawConsensusMessage) (*CanonicalMessage, error) {
+	start := time.Now()
+	msg, err := ToCanonicalContext(ctx, m.Mapper, raw)
+	if err != nil {
+		m.logger.Warn("failed to decode message", append(RawLogAttrs(raw), "err", err)...)
+		return nil, err
+	}
+	m.logger.Debug("decoded message", append(LogAttrs(msg), "elapsed", time.Since(start))...)
+	return msg, nil
+}
+
+func (m *loggingMapper) FromCanonical(msg *CanonicalMessage) (*RawConsensusMessage, error) {
+	return m.FromCanonicalContext(context.Background(), msg)
+}
+
+func (m *loggingMapper) FromCanonicalContext(ctx context.Context, msg *CanonicalMessage) (*RawConsensusMessage, error) {
+	start := time.Now()
+	raw, err := FromCanonicalContext(ctx, m.Mapper, msg)
+	if err != nil {
+		m.logger.Warn("failed to encode message", append(LogAttrs(msg), "err", err)...)
+		return nil, err
+	}
+	m.logger.Debug("encoded message", append(LogAttrs(msg), "elapsed", time.Since(start))...)
+	return raw, nil
+}
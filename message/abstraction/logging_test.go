@@ -0,0 +1,56 @@
+package abstraction_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"testing"
+
+	"codec/message/abstraction"
+)
+
+func TestWithLoggerLogsConversions(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	mapper := abstraction.WithLogger(&heightMapper{}, logger)
+	if mapper.GetChainType() != "heights" {
+		t.Fatalf("expected the wrapped mapper's chain type, got %q", mapper.GetChainType())
+	}
+
+	if _, err := mapper.ToCanonical(abstraction.RawConsensusMessage{ChainID: "logs", Payload: []byte("12")}); err != nil {
+		t.Fatalf("ToCanonical: %v", err)
+	}
+	if _, err := mapper.ToCanonical(abstraction.RawConsensusMessage{ChainID: "logs", Payload: []byte("13"), Encoding: "text"}); err == nil {
+		t.Fatal("expected the odd height to fail")
+	}
+
+	var decoded, failed map[string]any
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 || json.Unmarshal(lines[0], &decoded) != nil || json.Unmarshal(lines[1], &failed) != nil {
+		t.Fatalf("expected two JSON records, got %s", buf.Bytes())
+	}
+	if decoded["level"] != "DEBUG" || decoded["chain"] != "logs" || decoded["height"] != float64(12) {
+		t.Errorf("expected a debug record with the message's chain and height, got %v", decoded)
+	}
+	if failed["level"] != "WARN" || failed["encoding"] != "text" || failed["err"] == nil {
+		t.Errorf("expected a warning with the raw message and error, got %v", failed)
+	}
+}
+
+func TestLogAttrsSkipsUnsetFields(t *testing.T) {
+	msg := &abstraction.CanonicalMessage{ChainID: "kaia", Height: big.NewInt(7), View: big.NewInt(2), Type: abstraction.MsgTypeCommit}
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return a
+	}})).Info("msg", abstraction.LogAttrs(msg)...)
+	if got, want := buf.String(), "level=INFO msg=msg chain=kaia height=7 view=2 type=commit\n"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if abstraction.LogAttrs(nil) != nil {
+		t.Fatal("expected no attributes for a nil message")
+	}
+}
@@ -5,32 +5,43 @@ import (
 	"time"
 )
 
-// ChainType represents the supported blockchain platforms
+// ChainType represents the supported blockchain platforms. Adapters outside this module can add their own
+// with RegisterChainType.
 type ChainType string
 
 const (
 	ChainTypeCometBFT    ChainType = "cometbft"
 	ChainTypeHyperledger ChainType = "hyperledger"
 	ChainTypeKaia        ChainType = "kaia"
+	ChainTypeFabric      ChainType = "fabric"
+	ChainTypeFabricRaft  ChainType = "fabric-raft"
+	ChainTypeHotStuff    ChainType = "hotstuff"
+	ChainTypeEthereum    ChainType = "ethereum"
+	ChainTypeAptos       ChainType = "aptos"
+	ChainTypeAvalanche   ChainType = "avalanche"
 )
 
-// MsgType represents consensus message types across different chains
+// MsgType represents consensus message types across different chains. More can be added with RegisterMsgType.
 type MsgType string
 
 const (
-	MsgTypeProposal   MsgType = "proposal"
-	MsgTypePrepare    MsgType = "prepare"
-	MsgTypeVote       MsgType = "vote"
-	MsgTypeCommit     MsgType = "commit"
-	MsgTypeViewChange MsgType = "view_change"
-	MsgTypeNewView    MsgType = "new_view"
-	MsgTypeBlock      MsgType = "block"
-	MsgTypePrevote    MsgType = "prevote"
-	MsgTypePrecommit  MsgType = "precommit"
+	MsgTypeProposal    MsgType = "proposal"
+	MsgTypePrepare     MsgType = "prepare"
+	MsgTypeVote        MsgType = "vote"
+	MsgTypeCommit      MsgType = "commit"
+	MsgTypeViewChange  MsgType = "view_change"
+	MsgTypeNewView     MsgType = "new_view"
+	MsgTypeBlock       MsgType = "block"
+	MsgTypePrevote     MsgType = "prevote"
+	MsgTypePrecommit   MsgType = "precommit"
+	MsgTypeRoundChange MsgType = "round_change"
 )
 
 // CanonicalMessage represents the normalized consensus message format
 type CanonicalMessage struct {
+	// Schema version of the layout, see CurrentVersion and Migrate
+	Version int `json:"version,omitempty"`
+
 	// Common header fields
 	ChainID   string    `json:"chain_id"`        // Chain identifier
 	Height    *big.Int  `json:"height"`          // Block height
@@ -51,7 +62,7 @@ type CanonicalMessage struct {
 	ViewChanges []ViewChangeEntry `json:"view_changes,omitempty"` // View change entries
 
 	// Extension fields for chain-specific data
-	Extensions map[string]interface{} `json:"extensions,omitempty"`
+	Extensions Extensions `json:"extensions,omitempty"`
 
 	// Metadata
 	RawPayload []byte `json:"raw_payload,omitempty"` // Original message bytes
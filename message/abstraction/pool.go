@@ -0,0 +1,122 @@
+package abstraction
+
+import (
+	"context"
+	"sync"
+)
+
+// PooledMapper is a Mapper that can convert into messages it is handed, so a Pool can supply them instead of
+// the mapper allocating new ones. Mappers need not implement it; a Pool falls back to ToCanonical and
+// FromCanonical for those that do not.
+type PooledMapper interface {
+	Mapper
+	// ToCanonicalInto is ToCanonical, overwriting dst. Extensions already allocated on dst may be reused.
+	ToCanonicalInto(raw RawConsensusMessage, dst *CanonicalMessage) error
+	// FromCanonicalInto is FromCanonical, overwriting dst. Metadata already allocated on dst may be reused.
+	FromCanonicalInto(msg *CanonicalMessage, dst *RawConsensusMessage) error
+}
+
+// Reset clears msg for reuse. Extensions keeps its allocation, but the big.Int fields, CommitSeals,
+// ViewChanges, and RawPayload are dropped, since mappers share them with the raw message they decoded.
+func (msg *CanonicalMessage) Reset() {
+	ext := msg.Extensions
+	clear(ext)
+	*msg = CanonicalMessage{Extensions: ext}
+}
+
+// Reset clears raw for reuse. Metadata keeps its allocation; Payload is dropped, since canonical messages
+// decoded from raw share it.
+func (raw *RawConsensusMessage) Reset() {
+	metadata := raw.Metadata
+	clear(metadata)
+	*raw = RawConsensusMessage{Metadata: metadata}
+}
+
+// Pool recycles canonical and raw messages on paths that convert many messages per second, to cut the
+// garbage they leave behind. A message may only be put back once nothing references it or its Extensions or
+// Metadata any more: not the event history, a subscriber, a pending write, or a log record still being built.
+//
+// A nil *Pool is valid and pools nothing, so callers can hold an optional pool without checking it. Pool is
+// safe for concurrent use.
+type Pool struct {
+	canonical sync.Pool
+	raw       sync.Pool
+}
+
+// NewPool returns an empty pool.
+func NewPool() *Pool {
+	p := &Pool{}
+	p.canonical.New = func() any { return &CanonicalMessage{} }
+	p.raw.New = func() any { return &RawConsensusMessage{} }
+	return p
+}
+
+// GetCanonical returns a cleared canonical message, reused from the pool when one is available.
+func (p *Pool) GetCanonical() *CanonicalMessage {
+	if p == nil {
+		return &CanonicalMessage{}
+	}
+	return p.canonical.Get().(*CanonicalMessage)
+}
+
+// PutCanonical resets msg and returns it to the pool. A nil msg is ignored.
+func (p *Pool) PutCanonical(msg *CanonicalMessage) {
+	if p == nil || msg == nil {
+		return
+	}
+	msg.Reset()
+	p.canonical.Put(msg)
+}
+
+// GetRaw returns a cleared raw message, reused from the pool when one is available.
+func (p *Pool) GetRaw() *RawConsensusMessage {
+	if p == nil {
+		return &RawConsensusMessage{}
+	}
+	return p.raw.Get().(*RawConsensusMessage)
+}
+
+// PutRaw resets raw and returns it to the pool. A nil raw is ignored.
+func (p *Pool) PutRaw(raw *RawConsensusMessage) {
+	if p == nil || raw == nil {
+		return
+	}
+	raw.Reset()
+	p.raw.Put(raw)
+}
+
+// ToCanonicalContext is the package's ToCanonicalContext, decoding into a pooled message when mapper is a
+// PooledMapper. A ContextMapper is always called as such, since it may not finish with ctx's deadline.
+func (p *Pool) ToCanonicalContext(ctx context.Context, mapper Mapper, raw RawConsensusMessage) (*CanonicalMessage, error) {
+	pm, ok := mapper.(PooledMapper)
+	if _, cancellable := mapper.(ContextMapper); p == nil || !ok || cancellable {
+		return ToCanonicalContext(ctx, mapper, raw)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	msg := p.GetCanonical()
+	if err := pm.ToCanonicalInto(raw, msg); err != nil {
+		p.PutCanonical(msg)
+		return nil, err
+	}
+	return msg, nil
+}
+
+// FromCanonicalContext is the package's FromCanonicalContext, encoding into a pooled message when mapper is
+// a PooledMapper.
+func (p *Pool) FromCanonicalContext(ctx context.Context, mapper Mapper, msg *CanonicalMessage) (*RawConsensusMessage, error) {
+	pm, ok := mapper.(PooledMapper)
+	if _, cancellable := mapper.(ContextMapper); p == nil || !ok || cancellable {
+		return FromCanonicalContext(ctx, mapper, msg)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	raw := p.GetRaw()
+	if err := pm.FromCanonicalInto(msg, raw); err != nil {
+		p.PutRaw(raw)
+		return nil, err
+	}
+	return raw, nil
+}
@@ -0,0 +1,106 @@
+package abstraction_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"codec/message/abstraction"
+)
+
+// pooledMapper decodes like heightMapper, into the messages it is handed.
+type pooledMapper struct {
+	heightMapper
+	into int
+}
+
+func (m *pooledMapper) ToCanonicalInto(raw abstraction.RawConsensusMessage, dst *abstraction.CanonicalMessage) error {
+	m.into++
+	height, ok := new(big.Int).SetString(string(raw.Payload), 10)
+	if !ok {
+		return errors.New("bad height")
+	}
+	if dst.Extensions == nil {
+		dst.Extensions = make(abstraction.Extensions)
+	}
+	dst.ChainID, dst.Height, dst.RawPayload = raw.ChainID, height, raw.Payload
+	dst.Extensions["payload_size"] = len(raw.Payload)
+	return nil
+}
+
+func (m *pooledMapper) FromCanonicalInto(msg *abstraction.CanonicalMessage, dst *abstraction.RawConsensusMessage) error {
+	m.into++
+	dst.ChainID, dst.Payload = msg.ChainID, []byte(msg.Height.String())
+	return nil
+}
+
+func TestResetClearsMessages(t *testing.T) {
+	msg := &abstraction.CanonicalMessage{ChainID: "cometbft", Height: big.NewInt(7), BlockHash: "0xabc",
+		CommitSeals: []string{"seal"}, RawPayload: []byte("7"), Extensions: abstraction.Extensions{"step": 1}}
+	ext := msg.Extensions
+	msg.Reset()
+	if msg.ChainID != "" || msg.Height != nil || msg.BlockHash != "" || msg.CommitSeals != nil || msg.RawPayload != nil {
+		t.Fatalf("expected a cleared message, got %+v", msg)
+	}
+	if len(ext) != 0 || msg.Extensions == nil {
+		t.Fatalf("expected Extensions to be emptied and kept, got %v", msg.Extensions)
+	}
+
+	raw := &abstraction.RawConsensusMessage{ChainID: "cometbft", Payload: []byte("7"), Metadata: map[string]interface{}{"step": 1}}
+	raw.Reset()
+	if raw.ChainID != "" || raw.Payload != nil || raw.Metadata == nil || len(raw.Metadata) != 0 {
+		t.Fatalf("expected a cleared raw message, got %+v", raw)
+	}
+}
+
+func TestPoolConversions(t *testing.T) {
+	pool := abstraction.NewPool()
+	mapper := &pooledMapper{}
+	ctx := context.Background()
+
+	msg, err := pool.ToCanonicalContext(ctx, mapper, abstraction.RawConsensusMessage{ChainID: "a", Payload: []byte("12")})
+	if err != nil || msg.Height.Int64() != 12 || mapper.into != 1 {
+		t.Fatalf("expected a pooled decode, got %+v, %v", msg, err)
+	}
+	raw, err := pool.FromCanonicalContext(ctx, mapper, msg)
+	if err != nil || string(raw.Payload) != "12" || mapper.into != 2 {
+		t.Fatalf("expected a pooled encode, got %+v, %v", raw, err)
+	}
+	pool.PutRaw(raw)
+	pool.PutCanonical(msg)
+	if _, err := pool.ToCanonicalContext(ctx, mapper, abstraction.RawConsensusMessage{Payload: []byte("x")}); err == nil {
+		t.Fatal("expected a failed decode to return its error")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := pool.ToCanonicalContext(cancelled, mapper, abstraction.RawConsensusMessage{Payload: []byte("4")}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	// Mappers that cannot decode into a message, and a nil pool, convert as usual.
+	var none *abstraction.Pool
+	if msg, err := none.ToCanonicalContext(ctx, mapper, abstraction.RawConsensusMessage{Payload: []byte("4")}); err != nil || msg.Height.Int64() != 4 || mapper.into != 3 {
+		t.Fatalf("expected a nil pool to fall back to ToCanonical, got %+v, %v (into %d)", msg, err, mapper.into)
+	}
+	if msg, err := pool.ToCanonicalContext(ctx, &heightMapper{}, abstraction.RawConsensusMessage{Payload: []byte("6")}); err != nil || msg.Height.Int64() != 6 {
+		t.Fatalf("expected a plain mapper to decode, got %+v, %v", msg, err)
+	}
+	none.PutCanonical(none.GetCanonical())
+	none.PutRaw(none.GetRaw())
+}
+
+func BenchmarkPoolToCanonical(b *testing.B) {
+	pool := abstraction.NewPool()
+	mapper := &pooledMapper{}
+	raw := abstraction.RawConsensusMessage{ChainID: "a", Payload: []byte("12")}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		msg, err := pool.ToCanonicalContext(context.Background(), mapper, raw)
+		if err != nil {
+			b.Fatal(err)
+		}
+		pool.PutCanonical(msg)
+	}
+}
@@ -0,0 +1,404 @@
+// Package properties checks the canonical message log of an experiment against the properties consensus
+// promises: agreement (honest validators never commit different blocks at one height), validity (only
+// proposed blocks are committed), and progress (every height commits within a bound). The verdicts are plain
+// structs meant to be written as JSON next to the run's other artifacts.
+//
+// Commits are read off the votes: a block is committed in a round once precommits for it carry more than
+// two thirds of the voting power, and the commit is an honest one when an honest validator is among those
+// precommitters. PBFT-style chains that end a view with commit messages are read the same way.
+package properties
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/quorum"
+)
+
+// Property names a property the checker verifies.
+type Property string
+
+const (
+	// Agreement holds when no two honest commits at the same height are for different blocks.
+	Agreement Property = "agreement"
+	// Validity holds when every honestly committed block was proposed at its height, in the round of the
+	// commit or an earlier one.
+	Validity Property = "validity"
+	// Progress holds when every height in the checked range has an honest commit within the bound.
+	Progress Property = "progress"
+)
+
+// Config describes the experiment the log came from.
+type Config struct {
+	// Validators is the validator set. When it is empty, every validator that votes in the log counts with
+	// a voting power of 1.
+	Validators quorum.ValidatorSet
+	// Byzantine lists the faulty validators; everyone else is honest.
+	Byzantine []string
+	// From and To are the heights progress is checked over, both inclusive. They default to the lowest and
+	// highest height in the log, so a log cut off in the middle of a height reports it as not committed.
+	From, To int64
+	// MaxRounds bounds progress: a height must commit in a round below it. Zero leaves rounds unbounded.
+	MaxRounds int64
+	// MaxDuration bounds progress by the message timestamps: from the first message of a height to the
+	// precommit that completed its commit. Zero leaves time unbounded.
+	MaxDuration time.Duration
+}
+
+// Commit is a block that gathered more than two thirds of the precommits in a round.
+type Commit struct {
+	Height int64  `json:"height"`
+	Round  int64  `json:"round"`
+	Block  string `json:"block"`
+	Power  int64  `json:"power"`
+	// Validators lists everyone who precommitted the block in the round, Honest the honest ones among them.
+	Validators []string `json:"validators"`
+	Honest     []string `json:"honest,omitempty"`
+	// At is the timestamp of the precommit that took the block past two thirds.
+	At time.Time `json:"at"`
+}
+
+// Violation is one height at which a property failed.
+type Violation struct {
+	Height int64    `json:"height"`
+	Blocks []string `json:"blocks,omitempty"`
+	Detail string   `json:"detail"`
+}
+
+// Verdict is the outcome of checking one property.
+type Verdict struct {
+	Property Property `json:"property"`
+	Holds    bool     `json:"holds"`
+	// Checked counts the heights the property was checked at.
+	Checked    int         `json:"checked"`
+	Detail     string      `json:"detail"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Report holds a verdict for every property and the commits they were judged on.
+type Report struct {
+	Holds    bool      `json:"holds"`
+	Verdicts []Verdict `json:"verdicts"`
+	Commits  []Commit  `json:"commits,omitempty"`
+}
+
+// Verdict returns the verdict for p.
+func (r *Report) Verdict(p Property) (Verdict, bool) {
+	for _, v := range r.Verdicts {
+		if v.Property == p {
+			return v, true
+		}
+	}
+	return Verdict{}, false
+}
+
+// Checker collects the log one message at a time. It is safe for concurrent use.
+type Checker struct {
+	mu        sync.Mutex
+	cfg       Config
+	byzantine map[string]bool
+	heights   map[int64]*heightLog
+	voters    map[string]bool
+}
+
+type heightLog struct {
+	first    time.Time
+	maxRound int64
+	// proposed maps each proposed block to the earliest round it was proposed in.
+	proposed map[string]int64
+	// precommits holds each round's precommits for each block in the order they were observed.
+	precommits map[roundBlock][]precommit
+}
+
+type roundBlock struct {
+	round int64
+	block string
+}
+
+type precommit struct {
+	validator string
+	at        time.Time
+}
+
+// NewChecker returns a checker for an experiment described by cfg.
+func NewChecker(cfg Config) *Checker {
+	c := &Checker{
+		cfg:       cfg,
+		byzantine: make(map[string]bool, len(cfg.Byzantine)),
+		heights:   map[int64]*heightLog{},
+		voters:    map[string]bool{},
+	}
+	for _, id := range cfg.Byzantine {
+		c.byzantine[id] = true
+	}
+	return c
+}
+
+// Check runs a checker over a whole log.
+func Check(cfg Config, msgs []*abstraction.CanonicalMessage) *Report {
+	c := NewChecker(cfg)
+	for _, msg := range msgs {
+		c.Observe(msg)
+	}
+	return c.Report()
+}
+
+// Observe adds msg to the log. Messages without a height are ignored, and so are repeated precommits from a
+// validator for the same block and round.
+func (c *Checker) Observe(msg *abstraction.CanonicalMessage) {
+	if msg == nil {
+		return
+	}
+	height, ok := msg.HeightInt64()
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := c.heights[height]
+	if h == nil {
+		h = &heightLog{maxRound: -1, proposed: map[string]int64{}, precommits: map[roundBlock][]precommit{}}
+		c.heights[height] = h
+	}
+	if !msg.Timestamp.IsZero() && (h.first.IsZero() || msg.Timestamp.Before(h.first)) {
+		h.first = msg.Timestamp
+	}
+	round := roundOf(msg)
+	if round > h.maxRound {
+		h.maxRound = round
+	}
+
+	switch msg.Type {
+	case abstraction.MsgTypeProposal:
+		if msg.BlockHash == "" {
+			return
+		}
+		if earliest, ok := h.proposed[msg.BlockHash]; !ok || round < earliest {
+			h.proposed[msg.BlockHash] = round
+		}
+	case abstraction.MsgTypePrecommit, abstraction.MsgTypeCommit:
+		if msg.Validator == "" {
+			return
+		}
+		c.voters[msg.Validator] = true
+		key := roundBlock{round: round, block: msg.BlockHash}
+		for _, pc := range h.precommits[key] {
+			if pc.validator == msg.Validator {
+				return
+			}
+		}
+		h.precommits[key] = append(h.precommits[key], precommit{validator: msg.Validator, at: msg.Timestamp})
+	case abstraction.MsgTypePrevote, abstraction.MsgTypePrepare, abstraction.MsgTypeVote:
+		if msg.Validator != "" {
+			c.voters[msg.Validator] = true
+		}
+	}
+}
+
+// Report judges the log observed so far.
+func (c *Checker) Report() *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	validators := c.cfg.Validators
+	if len(validators) == 0 {
+		validators = quorum.ValidatorSet{}
+		for id := range c.voters {
+			validators[id] = 1
+		}
+	}
+	heights := make([]int64, 0, len(c.heights))
+	for height := range c.heights {
+		heights = append(heights, height)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+
+	report := &Report{}
+	for _, height := range heights {
+		report.Commits = append(report.Commits, c.commits(height, validators)...)
+	}
+	report.Verdicts = []Verdict{
+		c.agreement(heights, report.Commits),
+		c.validity(report.Commits),
+		c.progress(heights, report.Commits),
+	}
+	report.Holds = true
+	for _, v := range report.Verdicts {
+		report.Holds = report.Holds && v.Holds
+	}
+	return report
+}
+
+// commits returns the blocks that gathered more than two thirds of the precommits at height, ordered by
+// round and block
+func (c *Checker) commits(height int64, validators quorum.ValidatorSet) []Commit {
+	total := validators.TotalPower()
+	var found []Commit
+	for key, precommits := range c.heights[height].precommits {
+		if key.block == "" {
+			continue
+		}
+		commit := Commit{Height: height, Round: key.round, Block: key.block}
+		for _, pc := range precommits {
+			power, ok := validators[pc.validator]
+			if !ok {
+				continue
+			}
+			commit.Power += power
+			commit.Validators = append(commit.Validators, pc.validator)
+			if !c.byzantine[pc.validator] {
+				commit.Honest = append(commit.Honest, pc.validator)
+			}
+			if commit.At.IsZero() && commit.Power*3 > total*2 {
+				commit.At = pc.at
+			}
+		}
+		if commit.Power*3 <= total*2 {
+			continue
+		}
+		sort.Strings(commit.Validators)
+		sort.Strings(commit.Honest)
+		found = append(found, commit)
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Round != found[j].Round {
+			return found[i].Round < found[j].Round
+		}
+		return found[i].Block < found[j].Block
+	})
+	return found
+}
+
+func (c *Checker) agreement(heights []int64, commits []Commit) Verdict {
+	v := Verdict{Property: Agreement, Checked: len(heights)}
+	blocks := map[int64][]string{}
+	for _, commit := range commits {
+		if len(commit.Honest) > 0 && !containsString(blocks[commit.Height], commit.Block) {
+			blocks[commit.Height] = append(blocks[commit.Height], commit.Block)
+		}
+	}
+	for _, height := range heights {
+		if committed := blocks[height]; len(committed) > 1 {
+			sort.Strings(committed)
+			v.Violations = append(v.Violations, Violation{
+				Height: height,
+				Blocks: committed,
+				Detail: fmt.Sprintf("honest validators committed %d different blocks", len(committed)),
+			})
+		}
+	}
+	return conclude(v, "no two honest commits at one height disagree", "heights with conflicting honest commits")
+}
+
+func (c *Checker) validity(commits []Commit) Verdict {
+	v := Verdict{Property: Validity}
+	for _, commit := range commits {
+		if len(commit.Honest) == 0 {
+			continue
+		}
+		v.Checked++
+		round, ok := c.heights[commit.Height].proposed[commit.Block]
+		switch {
+		case !ok:
+			v.Violations = append(v.Violations, Violation{
+				Height: commit.Height,
+				Blocks: []string{commit.Block},
+				Detail: fmt.Sprintf("committed in round %d but never proposed", commit.Round),
+			})
+		case round > commit.Round:
+			v.Violations = append(v.Violations, Violation{
+				Height: commit.Height,
+				Blocks: []string{commit.Block},
+				Detail: fmt.Sprintf("committed in round %d before it was proposed in round %d", commit.Round, round),
+			})
+		}
+	}
+	return conclude(v, "every honestly committed block was proposed", "honest commits of blocks that were not proposed")
+}
+
+func (c *Checker) progress(heights []int64, commits []Commit) Verdict {
+	from, to := c.cfg.From, c.cfg.To
+	if from == 0 {
+		if len(heights) > 0 {
+			from = heights[0]
+		} else {
+			from = to
+		}
+	}
+	if to == 0 {
+		if len(heights) > 0 {
+			to = heights[len(heights)-1]
+		} else {
+			to = from - 1
+		}
+	}
+	// Commits are ordered by round within a height, so the first honest one is the decision
+	decided := map[int64]Commit{}
+	for _, commit := range commits {
+		if _, ok := decided[commit.Height]; !ok && len(commit.Honest) > 0 {
+			decided[commit.Height] = commit
+		}
+	}
+
+	v := Verdict{Property: Progress}
+	for height := from; height <= to; height++ {
+		v.Checked++
+		commit, ok := decided[height]
+		h := c.heights[height]
+		switch {
+		case !ok && h == nil:
+			v.Violations = append(v.Violations, Violation{Height: height, Detail: "no messages at this height"})
+		case !ok:
+			v.Violations = append(v.Violations, Violation{
+				Height: height,
+				Detail: fmt.Sprintf("no block committed by round %d", h.maxRound),
+			})
+		case c.cfg.MaxRounds > 0 && commit.Round >= c.cfg.MaxRounds:
+			v.Violations = append(v.Violations, Violation{
+				Height: height,
+				Blocks: []string{commit.Block},
+				Detail: fmt.Sprintf("committed in round %d, beyond the bound of %d rounds", commit.Round, c.cfg.MaxRounds),
+			})
+		case c.cfg.MaxDuration > 0 && !h.first.IsZero() && !commit.At.IsZero() && commit.At.Sub(h.first) > c.cfg.MaxDuration:
+			v.Violations = append(v.Violations, Violation{
+				Height: height,
+				Blocks: []string{commit.Block},
+				Detail: fmt.Sprintf("committed %s after the height started, beyond the bound of %s", commit.At.Sub(h.first), c.cfg.MaxDuration),
+			})
+		}
+	}
+	return conclude(v, "every height committed within the bound", "heights without a commit within the bound")
+}
+
+// conclude settles whether v holds and summarizes it
+func conclude(v Verdict, holds, violated string) Verdict {
+	v.Holds = len(v.Violations) == 0
+	if v.Holds {
+		v.Detail = fmt.Sprintf("%s (%d checked)", holds, v.Checked)
+	} else {
+		v.Detail = fmt.Sprintf("%d of %d %s", len(v.Violations), v.Checked, violated)
+	}
+	return v
+}
+
+// roundOf returns the round of msg, its view on chains without rounds, or 0 when it has neither
+func roundOf(msg *abstraction.CanonicalMessage) int64 {
+	if round, ok := msg.RoundInt64(); ok {
+		return round
+	}
+	view, _ := msg.ViewInt64()
+	return view
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
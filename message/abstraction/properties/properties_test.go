@@ -0,0 +1,167 @@
+package properties
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/quorum"
+)
+
+var (
+	validators = quorum.ValidatorSet{"v1": 10, "v2": 10, "v3": 10, "v4": 10}
+	start      = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func message(typ abstraction.MsgType, height, round int64, signer, hash string) *abstraction.CanonicalMessage {
+	msg := &abstraction.CanonicalMessage{
+		ChainID:   "cosmos-hub-4",
+		Type:      typ,
+		Height:    big.NewInt(height),
+		Round:     big.NewInt(round),
+		BlockHash: hash,
+		Timestamp: start.Add(time.Duration(height)*time.Minute + time.Duration(round)*time.Second),
+	}
+	if typ == abstraction.MsgTypeProposal {
+		msg.Proposer = signer
+	} else {
+		msg.Validator = signer
+	}
+	return msg
+}
+
+// height returns an honest height: a proposal and everyone's precommit for it.
+func height(h, round int64, hash string) []*abstraction.CanonicalMessage {
+	msgs := []*abstraction.CanonicalMessage{message(abstraction.MsgTypeProposal, h, round, "v1", hash)}
+	for _, v := range []string{"v1", "v2", "v3", "v4"} {
+		msgs = append(msgs, message(abstraction.MsgTypePrecommit, h, round, v, hash))
+	}
+	return msgs
+}
+
+func verdict(t *testing.T, r *Report, p Property) Verdict {
+	t.Helper()
+	v, ok := r.Verdict(p)
+	if !ok {
+		t.Fatalf("report has no %s verdict: %+v", p, r)
+	}
+	return v
+}
+
+func TestHonestRunHoldsEveryProperty(t *testing.T) {
+	var log []*abstraction.CanonicalMessage
+	log = append(log, height(1, 0, "A")...)
+	log = append(log, height(2, 0, "B")...)
+	log = append(log, height(2, 0, "B")...)
+
+	r := Check(Config{Validators: validators, MaxRounds: 1}, log)
+	if !r.Holds || len(r.Commits) != 2 {
+		t.Fatalf("expected every property to hold over two commits, got %+v", r)
+	}
+	if c := r.Commits[1]; c.Height != 2 || c.Block != "B" || c.Power != 40 || len(c.Honest) != 4 {
+		t.Fatalf("repeated precommits must count once, got %+v", c)
+	}
+	if v := verdict(t, r, Progress); v.Checked != 2 {
+		t.Fatalf("progress should check heights 1 and 2, got %+v", v)
+	}
+}
+
+func TestConflictingHonestCommitsBreakAgreement(t *testing.T) {
+	log := []*abstraction.CanonicalMessage{
+		message(abstraction.MsgTypeProposal, 1, 0, "v4", "A"),
+		message(abstraction.MsgTypeProposal, 1, 0, "v4", "B"),
+		message(abstraction.MsgTypePrecommit, 1, 0, "v1", "A"),
+		message(abstraction.MsgTypePrecommit, 1, 0, "v2", "A"),
+		message(abstraction.MsgTypePrecommit, 1, 0, "v3", "B"),
+		message(abstraction.MsgTypePrecommit, 1, 0, "v4", "A"),
+		message(abstraction.MsgTypePrecommit, 1, 0, "v4", "B"),
+	}
+	// v4 double-signs with enough power to complete both commits
+	set := quorum.ValidatorSet{"v1": 10, "v2": 10, "v3": 10, "v4": 40}
+	r := Check(Config{Validators: set, Byzantine: []string{"v4"}}, log)
+
+	v := verdict(t, r, Agreement)
+	if v.Holds || len(v.Violations) != 1 || v.Violations[0].Height != 1 ||
+		strings.Join(v.Violations[0].Blocks, ",") != "A,B" {
+		t.Fatalf("expected one agreement violation at height 1, got %+v", v)
+	}
+	if !verdict(t, r, Validity).Holds || !verdict(t, r, Progress).Holds || r.Holds {
+		t.Fatalf("only agreement should fail, got %+v", r.Verdicts)
+	}
+}
+
+func TestByzantineOnlyCommitIsNotAnHonestCommit(t *testing.T) {
+	log := []*abstraction.CanonicalMessage{message(abstraction.MsgTypeProposal, 1, 0, "v1", "A")}
+	for _, v := range []string{"v1", "v2", "v3"} {
+		log = append(log, message(abstraction.MsgTypePrecommit, 1, 0, v, "A"))
+	}
+	for _, v := range []string{"v2", "v3"} {
+		log = append(log, message(abstraction.MsgTypePrecommit, 1, 0, v, "Z"))
+	}
+	r := Check(Config{Validators: quorum.ValidatorSet{"v1": 10, "v2": 10, "v3": 10}, Byzantine: []string{"v2", "v3"}}, log)
+	if !verdict(t, r, Agreement).Holds || !verdict(t, r, Validity).Holds {
+		t.Fatalf("a block only byzantine validators precommitted is not an honest commit, got %+v", r.Verdicts)
+	}
+}
+
+func TestUnproposedCommitBreaksValidity(t *testing.T) {
+	log := height(1, 1, "A")
+	log[0] = message(abstraction.MsgTypeProposal, 1, 2, "v1", "A")
+	log = append(log, height(2, 0, "B")[1:]...)
+
+	v := verdict(t, Check(Config{Validators: validators}, log), Validity)
+	if v.Holds || len(v.Violations) != 2 || v.Checked != 2 {
+		t.Fatalf("expected both commits to break validity, got %+v", v)
+	}
+	if !strings.Contains(v.Violations[0].Detail, "before it was proposed") || !strings.Contains(v.Violations[1].Detail, "never proposed") {
+		t.Fatalf("unexpected details %+v", v.Violations)
+	}
+}
+
+func TestProgressBounds(t *testing.T) {
+	var log []*abstraction.CanonicalMessage
+	log = append(log, height(1, 0, "A")...)
+	log = append(log, message(abstraction.MsgTypePrevote, 2, 0, "v2", ""))
+	log = append(log, height(2, 3, "B")...)
+	log = append(log, message(abstraction.MsgTypePrevote, 4, 0, "v1", "D"))
+
+	v := verdict(t, Check(Config{Validators: validators, MaxRounds: 2}, log), Progress)
+	if v.Holds || v.Checked != 4 || len(v.Violations) != 3 {
+		t.Fatalf("expected heights 2, 3 and 4 to break progress, got %+v", v)
+	}
+	for i, want := range []string{"beyond the bound of 2 rounds", "no messages", "no block committed by round 0"} {
+		if !strings.Contains(v.Violations[i].Detail, want) {
+			t.Fatalf("violation %d: want %q, got %+v", i, want, v.Violations[i])
+		}
+	}
+
+	v = verdict(t, Check(Config{Validators: validators, From: 1, To: 2, MaxDuration: 2 * time.Second}, log), Progress)
+	if v.Holds || len(v.Violations) != 1 || v.Violations[0].Height != 2 {
+		t.Fatalf("the commit in round 3 took 3s and should break a 2s bound, got %+v", v)
+	}
+}
+
+func TestDerivedValidatorSetAndJSON(t *testing.T) {
+	log := []*abstraction.CanonicalMessage{
+		message(abstraction.MsgTypeProposal, 1, 0, "v1", "A"),
+		message(abstraction.MsgTypePrevote, 1, 0, "v4", "A"),
+		message(abstraction.MsgTypePrecommit, 1, 0, "v1", "A"),
+		message(abstraction.MsgTypePrecommit, 1, 0, "v2", "A"),
+	}
+	// Without a set, v1, v2 and v4 count equally and two of three is not more than two thirds
+	r := Check(Config{}, log)
+	if len(r.Commits) != 0 || verdict(t, r, Progress).Holds {
+		t.Fatalf("expected no commit, got %+v", r)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"property":"progress","holds":false`) {
+		t.Fatalf("unexpected report JSON %s", data)
+	}
+}
@@ -0,0 +1,265 @@
+// Package quorum tallies canonical votes against a validator set. It reports when a block gathers more than
+// two thirds of the voting power at a step, when two blocks do at the same step, and which validators signed
+// conflicting votes to make that possible.
+package quorum
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"codec/message/abstraction"
+)
+
+var (
+	// ErrNotVote is returned for messages that are not votes, such as proposals and view changes.
+	ErrNotVote = errors.New("message is not a vote")
+	// ErrUnknownValidator is returned for votes from validators outside the set.
+	ErrUnknownValidator = errors.New("validator is not in the validator set")
+)
+
+// ValidatorSet maps a validator ID, as it appears in CanonicalMessage.Validator, to its voting power.
+type ValidatorSet map[string]int64
+
+// TotalPower returns the voting power of the whole set.
+func (s ValidatorSet) TotalPower() int64 {
+	var total int64
+	for _, power := range s {
+		total += power
+	}
+	return total
+}
+
+// SetSource returns the validator set in force at a height, or nil when it is not known. It lets a tracker
+// follow a chain whose validator set changes, such as one fed by a node's RPC.
+type SetSource func(height *big.Int) ValidatorSet
+
+// Static returns a SetSource that answers every height with set.
+func Static(set ValidatorSet) SetSource {
+	return func(*big.Int) ValidatorSet { return set }
+}
+
+// Step identifies one round of voting. PBFT-style chains without rounds use their view; Type separates the
+// two voting phases of a round, such as prevotes and precommits.
+type Step struct {
+	Height string
+	Round  string
+	Type   abstraction.MsgType
+}
+
+// StepOf returns the step a vote belongs to.
+func StepOf(msg *abstraction.CanonicalMessage) Step {
+	step := Step{Type: msg.Type}
+	if msg.Height != nil {
+		step.Height = msg.Height.String()
+	}
+	if msg.Round != nil {
+		step.Round = msg.Round.String()
+	} else if msg.View != nil {
+		step.Round = "v" + msg.View.String()
+	}
+	return step
+}
+
+// Equivocation is a validator signing votes for two different blocks at the same step. An empty block hash is
+// a nil vote.
+type Equivocation struct {
+	Step      Step
+	Validator string
+	First     *abstraction.CanonicalMessage
+	Second    *abstraction.CanonicalMessage
+}
+
+func (e Equivocation) String() string {
+	return fmt.Sprintf("%s equivocated at height %s round %s %s: %q and %q",
+		e.Validator, e.Step.Height, e.Step.Round, e.Step.Type, e.First.BlockHash, e.Second.BlockHash)
+}
+
+// Conflict is a step at which more than one block gathered more than two thirds of the voting power, which
+// only equivocating validators can cause.
+type Conflict struct {
+	Step Step
+	// Majorities maps each block hash with a two-thirds majority to its voting power.
+	Majorities map[string]int64
+}
+
+// Tracker tallies votes. It is safe for concurrent use.
+type Tracker struct {
+	mu       sync.Mutex
+	source   SetSource
+	steps    map[Step]*tally
+	findings []Equivocation
+}
+
+type tally struct {
+	// validators is the set in force at the step's height and total its voting power.
+	validators ValidatorSet
+	total      int64
+	power      map[string]int64
+	// votes holds each validator's first vote for every block it voted for, in arrival order.
+	votes map[string][]*abstraction.CanonicalMessage
+}
+
+// NewTracker returns a tracker that weighs votes by validators' voting power.
+func NewTracker(validators ValidatorSet) *Tracker {
+	return NewTrackerFrom(Static(validators))
+}
+
+// NewTrackerFrom returns a tracker that weighs each vote by the validator set source returns for its height.
+// The set is looked up once per step, when the step's first vote arrives.
+func NewTrackerFrom(source SetSource) *Tracker {
+	return &Tracker{source: source, steps: map[Step]*tally{}}
+}
+
+// Add counts a vote and returns the equivocation it reveals, if any. A validator's power counts once for
+// every distinct block it votes for at a step, so equivocating validators can produce conflicting majorities.
+// Repeated votes for the same block are ignored.
+func (t *Tracker) Add(msg *abstraction.CanonicalMessage) (*Equivocation, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("vote is nil")
+	}
+	if !isVote(msg.Type) {
+		return nil, fmt.Errorf("%w: %s", ErrNotVote, msg.Type)
+	}
+	if msg.Height == nil {
+		return nil, fmt.Errorf("vote from %s has no height", msg.Validator)
+	}
+
+	step := StepOf(msg)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.steps[step]
+	if !ok {
+		validators := t.source(msg.Height)
+		s = &tally{validators: validators, total: validators.TotalPower(), power: map[string]int64{}, votes: map[string][]*abstraction.CanonicalMessage{}}
+	}
+	power, known := s.validators[msg.Validator]
+	if !known {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownValidator, msg.Validator)
+	}
+	t.steps[step] = s
+	earlier := s.votes[msg.Validator]
+	for _, vote := range earlier {
+		if vote.BlockHash == msg.BlockHash {
+			return nil, nil
+		}
+	}
+	s.votes[msg.Validator] = append(earlier, msg)
+	s.power[msg.BlockHash] += power
+	if len(earlier) == 0 {
+		return nil, nil
+	}
+	finding := Equivocation{Step: step, Validator: msg.Validator, First: earlier[0], Second: msg}
+	t.findings = append(t.findings, finding)
+	return &finding, nil
+}
+
+// HasTwoThirds returns the block that gathered more than two thirds of the voting power at a step. When
+// equivocation gave several blocks a majority, the one with the most power is returned.
+func (t *Tracker) HasTwoThirds(step Step) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	majorities := t.majorities(step)
+	if len(majorities) == 0 {
+		return "", false
+	}
+	return majorities[0], true
+}
+
+// ConflictingMajorities returns the steps at which more than one block has a two-thirds majority, ordered by
+// height, round and type.
+func (t *Tracker) ConflictingMajorities() []Conflict {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var conflicts []Conflict
+	for step, s := range t.steps {
+		hashes := t.majorities(step)
+		if len(hashes) < 2 {
+			continue
+		}
+		conflict := Conflict{Step: step, Majorities: map[string]int64{}}
+		for _, hash := range hashes {
+			conflict.Majorities[hash] = s.power[hash]
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return stepLess(conflicts[i].Step, conflicts[j].Step) })
+	return conflicts
+}
+
+// Equivocations returns every equivocation found so far, in the order the votes arrived.
+func (t *Tracker) Equivocations() []Equivocation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Equivocation(nil), t.findings...)
+}
+
+// Prune forgets the tallies of steps below height. Equivocations already found are kept.
+func (t *Tracker) Prune(height *big.Int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for step := range t.steps {
+		if h, ok := new(big.Int).SetString(step.Height, 10); ok && h.Cmp(height) < 0 {
+			delete(t.steps, step)
+		}
+	}
+}
+
+// majorities returns the block hashes with more than two thirds of the power at step, most power first.
+func (t *Tracker) majorities(step Step) []string {
+	s, ok := t.steps[step]
+	if !ok || s.total <= 0 {
+		return nil
+	}
+	var hashes []string
+	for hash, power := range s.power {
+		if power*3 > s.total*2 {
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		if s.power[hashes[i]] != s.power[hashes[j]] {
+			return s.power[hashes[i]] > s.power[hashes[j]]
+		}
+		return hashes[i] < hashes[j]
+	})
+	return hashes
+}
+
+func isVote(t abstraction.MsgType) bool {
+	switch t {
+	case abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit, abstraction.MsgTypePrepare,
+		abstraction.MsgTypeCommit, abstraction.MsgTypeVote:
+		return true
+	}
+	return false
+}
+
+func stepLess(a, b Step) bool {
+	if c := compareNumbers(a.Height, b.Height); c != 0 {
+		return c < 0
+	}
+	if c := compareNumbers(a.Round, b.Round); c != 0 {
+		return c < 0
+	}
+	return a.Type < b.Type
+}
+
+// compareNumbers orders decimal strings, including views with their "v" prefix, numerically.
+func compareNumbers(a, b string) int {
+	x, okA := new(big.Int).SetString(strings.TrimPrefix(a, "v"), 10)
+	y, okB := new(big.Int).SetString(strings.TrimPrefix(b, "v"), 10)
+	if okA && okB {
+		return x.Cmp(y)
+	}
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,124 @@
+package quorum
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"codec/message/abstraction"
+)
+
+func vote(typ abstraction.MsgType, height, round int64, validator, hash string) *abstraction.CanonicalMessage {
+	return &abstraction.CanonicalMessage{
+		Type:      typ,
+		Height:    big.NewInt(height),
+		Round:     big.NewInt(round),
+		Validator: validator,
+		BlockHash: hash,
+	}
+}
+
+func TestTrackerFindsMajorities(t *testing.T) {
+	tracker := NewTracker(ValidatorSet{"v1": 10, "v2": 10, "v3": 10, "v4": 10})
+	for _, v := range []string{"v1", "v2"} {
+		if _, err := tracker.Add(vote(abstraction.MsgTypePrevote, 5, 0, v, "A")); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	step := Step{Height: "5", Round: "0", Type: abstraction.MsgTypePrevote}
+	if _, ok := tracker.HasTwoThirds(step); ok {
+		t.Fatalf("half of the power is not a two-thirds majority")
+	}
+
+	// A repeated vote must not count twice.
+	if finding, _ := tracker.Add(vote(abstraction.MsgTypePrevote, 5, 0, "v2", "A")); finding != nil {
+		t.Fatalf("a repeated vote is not an equivocation")
+	}
+	if _, ok := tracker.HasTwoThirds(step); ok {
+		t.Fatalf("a repeated vote was counted twice")
+	}
+
+	tracker.Add(vote(abstraction.MsgTypePrevote, 5, 0, "v3", "A"))
+	if hash, ok := tracker.HasTwoThirds(step); !ok || hash != "A" {
+		t.Fatalf("expected a majority for A, got %q, %v", hash, ok)
+	}
+	if _, ok := tracker.HasTwoThirds(Step{Height: "5", Round: "0", Type: abstraction.MsgTypePrecommit}); ok {
+		t.Fatalf("prevotes must not count towards precommits")
+	}
+	if len(tracker.ConflictingMajorities()) != 0 || len(tracker.Equivocations()) != 0 {
+		t.Fatalf("honest votes produced findings")
+	}
+}
+
+func TestTrackerReportsEquivocationAndConflicts(t *testing.T) {
+	tracker := NewTracker(ValidatorSet{"v1": 1, "v2": 1, "v3": 1, "v4": 1})
+	for _, v := range []string{"v1", "v2", "v3"} {
+		tracker.Add(vote(abstraction.MsgTypePrecommit, 9, 1, v, "A"))
+	}
+	// v2 and v3 also sign B, which with v4 gives B a majority too.
+	tracker.Add(vote(abstraction.MsgTypePrecommit, 9, 1, "v4", "B"))
+	finding, err := tracker.Add(vote(abstraction.MsgTypePrecommit, 9, 1, "v2", "B"))
+	if err != nil || finding == nil || finding.Validator != "v2" || finding.First.BlockHash != "A" || finding.Second.BlockHash != "B" {
+		t.Fatalf("expected v2's equivocation, got %+v, %v", finding, err)
+	}
+	tracker.Add(vote(abstraction.MsgTypePrecommit, 9, 1, "v3", "B"))
+
+	conflicts := tracker.ConflictingMajorities()
+	if len(conflicts) != 1 || conflicts[0].Majorities["A"] != 3 || conflicts[0].Majorities["B"] != 3 {
+		t.Fatalf("expected conflicting majorities for A and B, got %+v", conflicts)
+	}
+	if len(tracker.Equivocations()) != 2 {
+		t.Fatalf("expected two equivocations, got %v", tracker.Equivocations())
+	}
+
+	tracker.Prune(big.NewInt(10))
+	if len(tracker.ConflictingMajorities()) != 0 || len(tracker.Equivocations()) != 2 {
+		t.Fatalf("prune must drop tallies and keep findings")
+	}
+}
+
+func TestTrackerRejectsInvalidVotes(t *testing.T) {
+	tracker := NewTracker(ValidatorSet{"v1": 1})
+	if _, err := tracker.Add(vote(abstraction.MsgTypeProposal, 1, 0, "v1", "A")); !errors.Is(err, ErrNotVote) {
+		t.Fatalf("expected ErrNotVote, got %v", err)
+	}
+	if _, err := tracker.Add(vote(abstraction.MsgTypePrevote, 1, 0, "v9", "A")); !errors.Is(err, ErrUnknownValidator) {
+		t.Fatalf("expected ErrUnknownValidator, got %v", err)
+	}
+	if _, err := tracker.Add(&abstraction.CanonicalMessage{Type: abstraction.MsgTypeVote, Validator: "v1"}); err == nil {
+		t.Fatalf("expected a vote without height to be rejected")
+	}
+}
+
+func TestStepOrderUsesNumbers(t *testing.T) {
+	if !stepLess(Step{Height: "9", Round: "v10"}, Step{Height: "10", Round: "v2"}) ||
+		!stepLess(Step{Height: "10", Round: "v2"}, Step{Height: "10", Round: "v10"}) {
+		t.Fatalf("steps must be ordered numerically")
+	}
+}
+
+func TestTrackerFollowsSetSource(t *testing.T) {
+	// v4 joins at height 10 with enough power that v1..v3 alone no longer reach two thirds.
+	tracker := NewTrackerFrom(func(height *big.Int) ValidatorSet {
+		if height.Int64() < 10 {
+			return ValidatorSet{"v1": 10, "v2": 10, "v3": 10}
+		}
+		return ValidatorSet{"v1": 10, "v2": 10, "v3": 10, "v4": 30}
+	})
+	for _, height := range []int64{9, 10} {
+		for _, v := range []string{"v1", "v2", "v3"} {
+			if _, err := tracker.Add(vote(abstraction.MsgTypePrecommit, height, 0, v, "A")); err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+		}
+	}
+	if _, ok := tracker.HasTwoThirds(Step{Height: "9", Round: "0", Type: abstraction.MsgTypePrecommit}); !ok {
+		t.Fatal("v1..v3 hold all the power at height 9")
+	}
+	if _, ok := tracker.HasTwoThirds(Step{Height: "10", Round: "0", Type: abstraction.MsgTypePrecommit}); ok {
+		t.Fatal("v1..v3 hold only half the power at height 10")
+	}
+	if _, err := tracker.Add(vote(abstraction.MsgTypePrecommit, 9, 0, "v4", "A")); !errors.Is(err, ErrUnknownValidator) {
+		t.Fatalf("v4 is not a validator at height 9, got %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package abstraction
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrTypeRegistered is returned when a registered type collides with a built-in or earlier registration
+var ErrTypeRegistered = errors.New("type already registered")
+
+// ChainTypeInfo describes a chain type registered by a third-party adapter
+type ChainTypeInfo struct {
+	Type ChainType
+	// MsgTypes lists the message types the chain's messages may carry; empty allows every known type.
+	MsgTypes []MsgType
+	// RequiresRound and RequiresView make the validator require a round or view.
+	RequiresRound bool
+	RequiresView  bool
+}
+
+var builtinChainTypes = []ChainType{
+	ChainTypeCometBFT, ChainTypeHyperledger, ChainTypeKaia, ChainTypeFabric, ChainTypeFabricRaft,
+	ChainTypeHotStuff, ChainTypeEthereum, ChainTypeAptos, ChainTypeAvalanche,
+}
+
+var builtinMsgTypes = []MsgType{
+	MsgTypeProposal, MsgTypePrepare, MsgTypeVote, MsgTypeCommit, MsgTypeViewChange, MsgTypeNewView,
+	MsgTypeBlock, MsgTypePrevote, MsgTypePrecommit, MsgTypeRoundChange,
+}
+
+// MapperFactory builds a mapper for one chain ID
+type MapperFactory func(chainID string) Mapper
+
+// chainTypeAliases maps the alternative names commands accept to a chain type
+var chainTypeAliases = map[string]ChainType{
+	"besu": ChainTypeHyperledger,
+}
+
+var registry = struct {
+	sync.RWMutex
+	chains   map[ChainType]ChainTypeInfo
+	msgTypes map[MsgType]bool
+	mappers  map[ChainType]MapperFactory
+}{
+	chains:   map[ChainType]ChainTypeInfo{},
+	msgTypes: map[MsgType]bool{},
+	mappers:  map[ChainType]MapperFactory{},
+}
+
+// RegisterChainType adds a chain type at runtime so an adapter outside this module can use it without a
+// built-in constant. Names are compared case-insensitively; a name that collides with a built-in or an
+// earlier registration is rejected with ErrTypeRegistered. The message types must already be known.
+func RegisterChainType(info ChainTypeInfo) error {
+	if strings.TrimSpace(string(info.Type)) == "" {
+		return fmt.Errorf("chain type name cannot be empty")
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	for _, known := range builtinChainTypes {
+		if strings.EqualFold(string(known), string(info.Type)) {
+			return fmt.Errorf("chain type %q collides with built-in %q: %w", info.Type, known, ErrTypeRegistered)
+		}
+	}
+	for known := range registry.chains {
+		if strings.EqualFold(string(known), string(info.Type)) {
+			return fmt.Errorf("chain type %q collides with registered %q: %w", info.Type, known, ErrTypeRegistered)
+		}
+	}
+	for _, msgType := range info.MsgTypes {
+		if !isKnownMsgTypeLocked(msgType) {
+			return fmt.Errorf("chain type %q lists unknown message type %q", info.Type, msgType)
+		}
+	}
+
+	info.MsgTypes = append([]MsgType(nil), info.MsgTypes...)
+	registry.chains[info.Type] = info
+	return nil
+}
+
+// RegisterMsgType adds a message type at runtime. Like chain types, names are compared case-insensitively
+// against the built-in and registered ones.
+func RegisterMsgType(msgType MsgType) error {
+	if strings.TrimSpace(string(msgType)) == "" {
+		return fmt.Errorf("message type name cannot be empty")
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	for _, known := range builtinMsgTypes {
+		if strings.EqualFold(string(known), string(msgType)) {
+			return fmt.Errorf("message type %q collides with built-in %q: %w", msgType, known, ErrTypeRegistered)
+		}
+	}
+	for known := range registry.msgTypes {
+		if strings.EqualFold(string(known), string(msgType)) {
+			return fmt.Errorf("message type %q collides with registered %q: %w", msgType, known, ErrTypeRegistered)
+		}
+	}
+
+	registry.msgTypes[msgType] = true
+	return nil
+}
+
+// LookupChainType returns the registration of a chain type added with RegisterChainType. Built-in chain
+// types are not reported.
+func LookupChainType(chainType ChainType) (ChainTypeInfo, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	info, ok := registry.chains[chainType]
+	if !ok {
+		return ChainTypeInfo{}, false
+	}
+	info.MsgTypes = append([]MsgType(nil), info.MsgTypes...)
+	return info, true
+}
+
+// IsKnownChainType reports whether a chain type is built in or registered
+func IsKnownChainType(chainType ChainType) bool {
+	for _, known := range builtinChainTypes {
+		if known == chainType {
+			return true
+		}
+	}
+	_, ok := LookupChainType(chainType)
+	return ok
+}
+
+// IsKnownMsgType reports whether a message type is built in or registered
+func IsKnownMsgType(msgType MsgType) bool {
+	registry.RLock()
+	defer registry.RUnlock()
+	return isKnownMsgTypeLocked(msgType)
+}
+
+// ChainTypes returns the built-in chain types followed by the registered ones in name order
+func ChainTypes() []ChainType {
+	registry.RLock()
+	defer registry.RUnlock()
+	registered := make([]ChainType, 0, len(registry.chains))
+	for chainType := range registry.chains {
+		registered = append(registered, chainType)
+	}
+	sort.Slice(registered, func(i, j int) bool { return registered[i] < registered[j] })
+	return append(append([]ChainType(nil), builtinChainTypes...), registered...)
+}
+
+// RegisterMapper makes NewMapper build mappers for a known chain type with factory. A second factory for
+// the same chain type is rejected with ErrTypeRegistered.
+func RegisterMapper(chainType ChainType, factory MapperFactory) error {
+	if factory == nil {
+		return fmt.Errorf("mapper factory for %q is nil", chainType)
+	}
+	if !IsKnownChainType(chainType) {
+		return fmt.Errorf("unknown chain type %q", chainType)
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.mappers[chainType]; ok {
+		return fmt.Errorf("mapper for chain type %q: %w", chainType, ErrTypeRegistered)
+	}
+	registry.mappers[chainType] = factory
+	return nil
+}
+
+// NewMapper builds a mapper with the factory registered for chainType
+func NewMapper(chainType ChainType, chainID string) (Mapper, error) {
+	registry.RLock()
+	factory, ok := registry.mappers[chainType]
+	registry.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain %q", chainType)
+	}
+	return factory(chainID), nil
+}
+
+// ParseChainType normalizes a chain name given on a command line or in a config file. Names are
+// case-insensitive and "besu" is accepted for ChainTypeHyperledger.
+func ParseChainType(name string) ChainType {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := chainTypeAliases[name]; ok {
+		return alias
+	}
+	return ChainType(name)
+}
+
+func isKnownMsgTypeLocked(msgType MsgType) bool {
+	for _, known := range builtinMsgTypes {
+		if known == msgType {
+			return true
+		}
+	}
+	return registry.msgTypes[msgType]
+}
@@ -0,0 +1,101 @@
+package abstraction_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/lint"
+	"codec/message/abstraction/validator"
+)
+
+func TestRegisterChainType(t *testing.T) {
+	const attest abstraction.MsgType = "attest"
+	const chain abstraction.ChainType = "narwhal"
+
+	if err := abstraction.RegisterChainType(abstraction.ChainTypeInfo{Type: chain, MsgTypes: []abstraction.MsgType{attest}}); err == nil {
+		t.Fatalf("expected a chain listing an unregistered message type to be rejected")
+	}
+	if err := abstraction.RegisterMsgType(attest); err != nil {
+		t.Fatalf("RegisterMsgType: %v", err)
+	}
+	if err := abstraction.RegisterChainType(abstraction.ChainTypeInfo{Type: chain, MsgTypes: []abstraction.MsgType{abstraction.MsgTypeProposal, attest}, RequiresRound: true}); err != nil {
+		t.Fatalf("RegisterChainType: %v", err)
+	}
+
+	for name, err := range map[string]error{
+		"built-in chain":  abstraction.RegisterChainType(abstraction.ChainTypeInfo{Type: "CometBFT"}),
+		"duplicate chain": abstraction.RegisterChainType(abstraction.ChainTypeInfo{Type: "Narwhal"}),
+		"built-in type":   abstraction.RegisterMsgType("Vote"),
+		"duplicate type":  abstraction.RegisterMsgType(attest),
+	} {
+		if !errors.Is(err, abstraction.ErrTypeRegistered) {
+			t.Errorf("%s: expected ErrTypeRegistered, got %v", name, err)
+		}
+	}
+	if !abstraction.IsKnownChainType(chain) || !abstraction.IsKnownMsgType(attest) || abstraction.IsKnownChainType("bullshark") {
+		t.Fatalf("unexpected registry contents %v", abstraction.ChainTypes())
+	}
+
+	msg := &abstraction.CanonicalMessage{
+		ChainID:   "narwhal-1",
+		Height:    big.NewInt(10),
+		Round:     big.NewInt(2),
+		Timestamp: time.Now(),
+		Type:      attest,
+	}
+	v := validator.NewValidator(chain)
+	if err := v.Validate(msg); err != nil {
+		t.Fatalf("validation failed: %v", err)
+	}
+	msg.Type = abstraction.MsgTypeVote
+	if err := v.Validate(msg); err == nil {
+		t.Fatalf("expected a type the chain did not register to be rejected")
+	}
+	msg.Type, msg.Round = attest, nil
+	if err := v.Validate(msg); err == nil {
+		t.Fatalf("expected the registered round requirement to apply")
+	}
+
+	if profile := lint.DefaultProfile(chain); !profile.RequiresRound || len(profile.SupportedTypes) != 2 {
+		t.Fatalf("unexpected lint profile %+v", profile)
+	}
+}
+
+func TestRegisterMapper(t *testing.T) {
+	const chain abstraction.ChainType = "heights"
+	factory := func(string) abstraction.Mapper { return &heightMapper{} }
+
+	if _, err := abstraction.NewMapper(chain, "batch"); err == nil {
+		t.Fatalf("expected a chain without a mapper to be rejected")
+	}
+	if err := abstraction.RegisterMapper(chain, factory); err == nil {
+		t.Fatalf("expected a mapper for an unknown chain type to be rejected")
+	}
+	if err := abstraction.RegisterChainType(abstraction.ChainTypeInfo{Type: chain}); err != nil {
+		t.Fatalf("RegisterChainType: %v", err)
+	}
+	if err := abstraction.RegisterMapper(chain, factory); err != nil {
+		t.Fatalf("RegisterMapper: %v", err)
+	}
+	if err := abstraction.RegisterMapper(chain, factory); !errors.Is(err, abstraction.ErrTypeRegistered) {
+		t.Fatalf("expected ErrTypeRegistered, got %v", err)
+	}
+	mapper, err := abstraction.NewMapper(chain, "batch")
+	if err != nil || mapper.GetChainType() != chain {
+		t.Fatalf("NewMapper: %v, %v", mapper, err)
+	}
+
+	for name, want := range map[string]abstraction.ChainType{
+		" Besu ":      abstraction.ChainTypeHyperledger,
+		"hyperledger": abstraction.ChainTypeHyperledger,
+		"Fabric-Raft": abstraction.ChainTypeFabricRaft,
+		"heights":     chain,
+	} {
+		if got := abstraction.ParseChainType(name); got != want {
+			t.Errorf("ParseChainType(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
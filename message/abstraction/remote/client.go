@@ -0,0 +1,303 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/canonical"
+	"codec/message/abstraction/remote/remotepb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Options tunes the remote mapper client.
+type Options struct {
+	// PoolSize is the number of connections, each carrying one stream; calls are spread round-robin. Defaults to 2.
+	PoolSize int
+	// Timeout bounds each call, including waiting for the response, within any deadline of the caller's
+	// context. Defaults to 5s.
+	Timeout time.Duration
+	// DialOptions are passed to grpc.NewClient; plaintext transport is used when none are given.
+	DialOptions []grpc.DialOption
+}
+
+// Mapper is an abstraction.Mapper whose conversions are served by a remote process.
+type Mapper struct {
+	target    string
+	timeout   time.Duration
+	pool      []*pooledStream
+	next      atomic.Uint64
+	ids       atomic.Uint64
+	chainType abstraction.ChainType
+	supported []abstraction.MsgType
+}
+
+var _ abstraction.ContextMapper = (*Mapper)(nil)
+
+// Dial connects to a remote mapper and asks it which chain it serves.
+func Dial(target string, opts Options) (*Mapper, error) {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 2
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	dialOpts := opts.DialOptions
+	if len(dialOpts) == 0 {
+		dialOpts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+
+	m := &Mapper{target: target, timeout: opts.Timeout}
+	for i := 0; i < opts.PoolSize; i++ {
+		conn, err := grpc.NewClient(target, dialOpts...)
+		if err != nil {
+			m.Close()
+			return nil, fmt.Errorf("failed to create remote mapper connection to %s: %w", target, err)
+		}
+		m.pool = append(m.pool, &pooledStream{conn: conn})
+	}
+
+	resp, err := m.call(context.Background(), &remotepb.MapRequest{Op: remotepb.Op_OP_DESCRIBE})
+	if err != nil {
+		m.Close()
+		return nil, fmt.Errorf("failed to describe remote mapper %s: %w", target, err)
+	}
+	m.chainType = abstraction.ChainType(resp.GetChainType())
+	for _, msgType := range resp.GetSupportedTypes() {
+		m.supported = append(m.supported, abstraction.MsgType(msgType))
+	}
+	return m, nil
+}
+
+// ToCanonical converts a raw consensus message on the remote mapper.
+func (m *Mapper) ToCanonical(raw abstraction.RawConsensusMessage) (*abstraction.CanonicalMessage, error) {
+	return m.ToCanonicalContext(context.Background(), raw)
+}
+
+// ToCanonicalContext converts a raw consensus message on the remote mapper, giving up when ctx ends.
+func (m *Mapper) ToCanonicalContext(ctx context.Context, raw abstraction.RawConsensusMessage) (*abstraction.CanonicalMessage, error) {
+	rawPB, err := canonical.RawToProto(&raw)
+	if err != nil {
+		return nil, fmt.Errorf("raw message: %w", err)
+	}
+	resp, err := m.call(ctx, &remotepb.MapRequest{Op: remotepb.Op_OP_TO_CANONICAL, Raw: rawPB})
+	if err != nil {
+		return nil, err
+	}
+	if resp.GetCanonical() == nil {
+		return nil, fmt.Errorf("remote mapper %s returned no canonical message", m.target)
+	}
+	return canonical.FromProto(resp.GetCanonical())
+}
+
+// FromCanonical converts a canonical message on the remote mapper.
+func (m *Mapper) FromCanonical(msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
+	return m.FromCanonicalContext(context.Background(), msg)
+}
+
+// FromCanonicalContext converts a canonical message on the remote mapper, giving up when ctx ends.
+func (m *Mapper) FromCanonicalContext(ctx context.Context, msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("canonical message cannot be nil")
+	}
+	msgPB, err := canonical.ToProto(msg)
+	if err != nil {
+		return nil, fmt.Errorf("canonical message: %w", err)
+	}
+	resp, err := m.call(ctx, &remotepb.MapRequest{Op: remotepb.Op_OP_FROM_CANONICAL, Canonical: msgPB})
+	if err != nil {
+		return nil, err
+	}
+	if resp.GetRaw() == nil {
+		return nil, fmt.Errorf("remote mapper %s returned no raw message", m.target)
+	}
+	return canonical.RawFromProto(resp.GetRaw()), nil
+}
+
+// GetSupportedTypes returns the message types the remote mapper reported when dialed.
+func (m *Mapper) GetSupportedTypes() []abstraction.MsgType {
+	return m.supported
+}
+
+// GetChainType returns the chain type the remote mapper reported when dialed.
+func (m *Mapper) GetChainType() abstraction.ChainType {
+	return m.chainType
+}
+
+// Close tears down every stream and connection.
+func (m *Mapper) Close() error {
+	var errs []error
+	for _, ps := range m.pool {
+		if err := ps.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Mapper) call(ctx context.Context, req *remotepb.MapRequest) (*remotepb.MapResponse, error) {
+	req.Id = m.ids.Add(1)
+	ps := m.pool[int(m.next.Add(1)-1)%len(m.pool)]
+
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	resp, err := ps.roundTrip(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("remote mapper %s %s: %w", m.target, req.Op, err)
+	}
+	if resp.GetError() != "" {
+		return nil, errors.New(resp.GetError())
+	}
+	return resp, nil
+}
+
+// pooledStream owns one connection and the Map stream opened on it. A broken stream is replaced on the next call.
+type pooledStream struct {
+	conn *grpc.ClientConn
+
+	mu     sync.Mutex
+	active *activeStream
+	closed bool
+}
+
+type activeStream struct {
+	stream remotepb.MapperService_MapClient
+	cancel context.CancelFunc
+
+	sendMu  sync.Mutex
+	mu      sync.Mutex
+	pending map[uint64]chan *remotepb.MapResponse
+	err     error
+}
+
+func (ps *pooledStream) roundTrip(ctx context.Context, req *remotepb.MapRequest) (*remotepb.MapResponse, error) {
+	as, err := ps.stream()
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *remotepb.MapResponse, 1)
+	if err := as.register(req.GetId(), ch); err != nil {
+		return nil, err
+	}
+	defer as.unregister(req.GetId())
+
+	as.sendMu.Lock()
+	err = as.stream.Send(req)
+	as.sendMu.Unlock()
+	if err != nil {
+		ps.drop(as, err)
+		return nil, err
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, as.failure()
+		}
+		return resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (ps *pooledStream) stream() (*activeStream, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.closed {
+		return nil, fmt.Errorf("remote mapper is closed")
+	}
+	if ps.active != nil {
+		return ps.active, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := remotepb.NewMapperServiceClient(ps.conn).Map(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	as := &activeStream{stream: stream, cancel: cancel, pending: make(map[uint64]chan *remotepb.MapResponse)}
+	ps.active = as
+	go ps.receive(as)
+	return as, nil
+}
+
+func (ps *pooledStream) receive(as *activeStream) {
+	for {
+		resp, err := as.stream.Recv()
+		if err != nil {
+			ps.drop(as, err)
+			return
+		}
+		as.mu.Lock()
+		ch, ok := as.pending[resp.GetId()]
+		delete(as.pending, resp.GetId())
+		as.mu.Unlock()
+		if ok {
+			ch <- resp
+		}
+	}
+}
+
+// drop retires a broken stream and fails its in-flight calls.
+func (ps *pooledStream) drop(as *activeStream, err error) {
+	ps.mu.Lock()
+	if ps.active == as {
+		ps.active = nil
+	}
+	ps.mu.Unlock()
+
+	as.cancel()
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	if as.err != nil {
+		return
+	}
+	as.err = fmt.Errorf("stream closed: %w", err)
+	for id, ch := range as.pending {
+		close(ch)
+		delete(as.pending, id)
+	}
+}
+
+func (ps *pooledStream) close() error {
+	ps.mu.Lock()
+	ps.closed = true
+	as := ps.active
+	ps.active = nil
+	ps.mu.Unlock()
+	if as != nil {
+		as.cancel()
+	}
+	return ps.conn.Close()
+}
+
+func (as *activeStream) register(id uint64, ch chan *remotepb.MapResponse) error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	if as.err != nil {
+		return as.err
+	}
+	as.pending[id] = ch
+	return nil
+}
+
+func (as *activeStream) unregister(id uint64) {
+	as.mu.Lock()
+	delete(as.pending, id)
+	as.mu.Unlock()
+}
+
+func (as *activeStream) failure() error {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	return as.err
+}
@@ -0,0 +1,19 @@
+// Package remote lets a Mapper run in another process. ToCanonical and FromCanonical calls travel over a
+// bidirectional gRPC stream so adapters written in other languages can join the bridge pipeline.
+//
+// The service is codec.remote.v1.MapperService, defined in message/proto/remote.proto on top of the canonical
+// messages of message/proto/canonical.proto, with generated code in remotepb. Its single bidirectional
+// streaming method, Map, carries MapRequest messages; a server answers each with a MapResponse carrying the
+// same id, and may answer out of order.
+package remote
+
+//go:generate protoc -I ../../proto --go_out=remotepb --go_opt=paths=source_relative --go-grpc_out=remotepb --go-grpc_opt=paths=source_relative remote.proto
+
+import "codec/message/abstraction/remote/remotepb"
+
+const (
+	// ServiceName is the fully qualified gRPC service name.
+	ServiceName = "codec.remote.v1.MapperService"
+	// MapMethod is the full method path of the bidirectional stream.
+	MapMethod = remotepb.MapperService_Map_FullMethodName
+)
@@ -0,0 +1,137 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cometbftAdapter "codec/cometbft/adapter"
+	"codec/message/abstraction"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// serve starts an in-memory MapperService and returns a client dialed to it.
+func serve(t *testing.T, mapper abstraction.Mapper, opts Options) *Mapper {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	Register(srv, mapper)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	opts.DialOptions = []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	client, err := Dial("passthrough:///bufnet", opts)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestRemoteMapperRoundTrip(t *testing.T) {
+	local := cometbftAdapter.NewCometBFTMapper("remote-chain")
+	client := serve(t, local, Options{PoolSize: 3})
+
+	if client.GetChainType() != abstraction.ChainTypeCometBFT {
+		t.Fatalf("expected cometbft chain type, got %s", client.GetChainType())
+	}
+	if len(client.GetSupportedTypes()) != len(local.GetSupportedTypes()) {
+		t.Fatalf("expected supported types %v, got %v", local.GetSupportedTypes(), client.GetSupportedTypes())
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 32)
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(height int64) {
+			defer wg.Done()
+			msg := &abstraction.CanonicalMessage{
+				ChainID:   "remote-chain",
+				Height:    big.NewInt(height),
+				Round:     big.NewInt(0),
+				Timestamp: time.Unix(1700000000, 0).UTC(),
+				Type:      abstraction.MsgTypePrevote,
+				BlockHash: "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+				Validator: "validator-1",
+			}
+			raw, err := client.FromCanonical(msg)
+			if err != nil {
+				errs <- err
+				return
+			}
+			canonical, err := client.ToCanonical(*raw)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if canonical.Height.Int64() != height || canonical.BlockHash != msg.BlockHash {
+				errs <- fmt.Errorf("height %d: round trip returned height %v hash %s", height, canonical.Height, canonical.BlockHash)
+			}
+		}(int64(i + 1))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// slowMapper blocks conversions until release is closed and fails ToCanonical.
+type slowMapper struct {
+	release chan struct{}
+}
+
+func (m *slowMapper) ToCanonical(abstraction.RawConsensusMessage) (*abstraction.CanonicalMessage, error) {
+	return nil, fmt.Errorf("unsupported payload")
+}
+
+func (m *slowMapper) FromCanonical(msg *abstraction.CanonicalMessage) (*abstraction.RawConsensusMessage, error) {
+	<-m.release
+	return &abstraction.RawConsensusMessage{ChainType: abstraction.ChainTypeKaia, MessageType: string(msg.Type)}, nil
+}
+
+func (m *slowMapper) GetSupportedTypes() []abstraction.MsgType {
+	return []abstraction.MsgType{abstraction.MsgTypeVote}
+}
+
+func (m *slowMapper) GetChainType() abstraction.ChainType { return abstraction.ChainTypeKaia }
+
+func TestRemoteMapperTimeoutsAndErrors(t *testing.T) {
+	mapper := &slowMapper{release: make(chan struct{})}
+	client := serve(t, mapper, Options{PoolSize: 1, Timeout: 50 * time.Millisecond})
+
+	if _, err := client.ToCanonical(abstraction.RawConsensusMessage{}); err == nil || !strings.Contains(err.Error(), "unsupported payload") {
+		t.Fatalf("expected remote error to be propagated, got %v", err)
+	}
+
+	_, err := client.FromCanonical(&abstraction.CanonicalMessage{Type: abstraction.MsgTypeVote})
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	// The caller's context ends a call well before the client's own timeout.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err := abstraction.FromCanonicalContext(ctx, client, &abstraction.CanonicalMessage{Type: abstraction.MsgTypeVote}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled call to fail with context.Canceled, got %v", err)
+	}
+
+	// A timed-out call does not poison the stream for later calls.
+	close(mapper.release)
+	raw, err := client.FromCanonical(&abstraction.CanonicalMessage{Type: abstraction.MsgTypeVote})
+	if err != nil || raw.MessageType != string(abstraction.MsgTypeVote) {
+		t.Fatalf("expected call after timeout to succeed, got %v (%v)", raw, err)
+	}
+}
@@ -0,0 +1,323 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: remote.proto
+
+// Remote mapper protocol: a Mapper served by another process, such as an adapter written in another
+// language. message/abstraction/remote is the Go client and reference server; see docs/remote_mapper.md.
+
+package remotepb
+
+import (
+	canonicalpb "codec/message/abstraction/canonical/canonicalpb"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Mapper method a request invokes.
+type Op int32
+
+const (
+	Op_OP_UNSPECIFIED Op = 0
+	// Asks for the chain type and supported message types.
+	Op_OP_DESCRIBE Op = 1
+	// Converts MapRequest.raw.
+	Op_OP_TO_CANONICAL Op = 2
+	// Converts MapRequest.canonical.
+	Op_OP_FROM_CANONICAL Op = 3
+)
+
+// Enum value maps for Op.
+var (
+	Op_name = map[int32]string{
+		0: "OP_UNSPECIFIED",
+		1: "OP_DESCRIBE",
+		2: "OP_TO_CANONICAL",
+		3: "OP_FROM_CANONICAL",
+	}
+	Op_value = map[string]int32{
+		"OP_UNSPECIFIED":    0,
+		"OP_DESCRIBE":       1,
+		"OP_TO_CANONICAL":   2,
+		"OP_FROM_CANONICAL": 3,
+	}
+)
+
+func (x Op) Enum() *Op {
+	p := new(Op)
+	*p = x
+	return p
+}
+
+func (x Op) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Op) Descriptor() protoreflect.EnumDescriptor {
+	return file_remote_proto_enumTypes[0].Descriptor()
+}
+
+func (Op) Type() protoreflect.EnumType {
+	return &file_remote_proto_enumTypes[0]
+}
+
+func (x Op) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Op.Descriptor instead.
+func (Op) EnumDescriptor() ([]byte, []int) {
+	return file_remote_proto_rawDescGZIP(), []int{0}
+}
+
+type MapRequest struct {
+	state         protoimpl.MessageState           `protogen:"open.v1"`
+	Id            uint64                           `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Op            Op                               `protobuf:"varint,2,opt,name=op,proto3,enum=codec.remote.v1.Op" json:"op,omitempty"`
+	Raw           *canonicalpb.RawConsensusMessage `protobuf:"bytes,3,opt,name=raw,proto3" json:"raw,omitempty"`
+	Canonical     *canonicalpb.CanonicalMessage    `protobuf:"bytes,4,opt,name=canonical,proto3" json:"canonical,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapRequest) Reset() {
+	*x = MapRequest{}
+	mi := &file_remote_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapRequest) ProtoMessage() {}
+
+func (x *MapRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_remote_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapRequest.ProtoReflect.Descriptor instead.
+func (*MapRequest) Descriptor() ([]byte, []int) {
+	return file_remote_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MapRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *MapRequest) GetOp() Op {
+	if x != nil {
+		return x.Op
+	}
+	return Op_OP_UNSPECIFIED
+}
+
+func (x *MapRequest) GetRaw() *canonicalpb.RawConsensusMessage {
+	if x != nil {
+		return x.Raw
+	}
+	return nil
+}
+
+func (x *MapRequest) GetCanonical() *canonicalpb.CanonicalMessage {
+	if x != nil {
+		return x.Canonical
+	}
+	return nil
+}
+
+// Answers the request with the same id. error is set instead of a result when the call failed.
+type MapResponse struct {
+	state          protoimpl.MessageState           `protogen:"open.v1"`
+	Id             uint64                           `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Raw            *canonicalpb.RawConsensusMessage `protobuf:"bytes,2,opt,name=raw,proto3" json:"raw,omitempty"`
+	Canonical      *canonicalpb.CanonicalMessage    `protobuf:"bytes,3,opt,name=canonical,proto3" json:"canonical,omitempty"`
+	ChainType      string                           `protobuf:"bytes,4,opt,name=chain_type,json=chainType,proto3" json:"chain_type,omitempty"`
+	SupportedTypes []string                         `protobuf:"bytes,5,rep,name=supported_types,json=supportedTypes,proto3" json:"supported_types,omitempty"`
+	Error          string                           `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MapResponse) Reset() {
+	*x = MapResponse{}
+	mi := &file_remote_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapResponse) ProtoMessage() {}
+
+func (x *MapResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_remote_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapResponse.ProtoReflect.Descriptor instead.
+func (*MapResponse) Descriptor() ([]byte, []int) {
+	return file_remote_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *MapResponse) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *MapResponse) GetRaw() *canonicalpb.RawConsensusMessage {
+	if x != nil {
+		return x.Raw
+	}
+	return nil
+}
+
+func (x *MapResponse) GetCanonical() *canonicalpb.CanonicalMessage {
+	if x != nil {
+		return x.Canonical
+	}
+	return nil
+}
+
+func (x *MapResponse) GetChainType() string {
+	if x != nil {
+		return x.ChainType
+	}
+	return ""
+}
+
+func (x *MapResponse) GetSupportedTypes() []string {
+	if x != nil {
+		return x.SupportedTypes
+	}
+	return nil
+}
+
+func (x *MapResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_remote_proto protoreflect.FileDescriptor
+
+const file_remote_proto_rawDesc = "" +
+	"\n" +
+	"\fremote.proto\x12\x0fcodec.remote.v1\x1a\x0fcanonical.proto\"\xc8\x01\n" +
+	"\n" +
+	"MapRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12#\n" +
+	"\x02op\x18\x02 \x01(\x0e2\x13.codec.remote.v1.OpR\x02op\x12=\n" +
+	"\x03raw\x18\x03 \x01(\v2+.byzantine.canonical.v1.RawConsensusMessageR\x03raw\x12F\n" +
+	"\tcanonical\x18\x04 \x01(\v2(.byzantine.canonical.v1.CanonicalMessageR\tcanonical\"\x82\x02\n" +
+	"\vMapResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12=\n" +
+	"\x03raw\x18\x02 \x01(\v2+.byzantine.canonical.v1.RawConsensusMessageR\x03raw\x12F\n" +
+	"\tcanonical\x18\x03 \x01(\v2(.byzantine.canonical.v1.CanonicalMessageR\tcanonical\x12\x1d\n" +
+	"\n" +
+	"chain_type\x18\x04 \x01(\tR\tchainType\x12'\n" +
+	"\x0fsupported_types\x18\x05 \x03(\tR\x0esupportedTypes\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error*U\n" +
+	"\x02Op\x12\x12\n" +
+	"\x0eOP_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vOP_DESCRIBE\x10\x01\x12\x13\n" +
+	"\x0fOP_TO_CANONICAL\x10\x02\x12\x15\n" +
+	"\x11OP_FROM_CANONICAL\x10\x032U\n" +
+	"\rMapperService\x12D\n" +
+	"\x03Map\x12\x1b.codec.remote.v1.MapRequest\x1a\x1c.codec.remote.v1.MapResponse(\x010\x01B+Z)codec/message/abstraction/remote/remotepbb\x06proto3"
+
+var (
+	file_remote_proto_rawDescOnce sync.Once
+	file_remote_proto_rawDescData []byte
+)
+
+func file_remote_proto_rawDescGZIP() []byte {
+	file_remote_proto_rawDescOnce.Do(func() {
+		file_remote_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_remote_proto_rawDesc), len(file_remote_proto_rawDesc)))
+	})
+	return file_remote_proto_rawDescData
+}
+
+var file_remote_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_remote_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_remote_proto_goTypes = []any{
+	(Op)(0),                                 // 0: codec.remote.v1.Op
+	(*MapRequest)(nil),                      // 1: codec.remote.v1.MapRequest
+	(*MapResponse)(nil),                     // 2: codec.remote.v1.MapResponse
+	(*canonicalpb.RawConsensusMessage)(nil), // 3: byzantine.canonical.v1.RawConsensusMessage
+	(*canonicalpb.CanonicalMessage)(nil),    // 4: byzantine.canonical.v1.CanonicalMessage
+}
+var file_remote_proto_depIdxs = []int32{
+	0, // 0: codec.remote.v1.MapRequest.op:type_name -> codec.remote.v1.Op
+	3, // 1: codec.remote.v1.MapRequest.raw:type_name -> byzantine.canonical.v1.RawConsensusMessage
+	4, // 2: codec.remote.v1.MapRequest.canonical:type_name -> byzantine.canonical.v1.CanonicalMessage
+	3, // 3: codec.remote.v1.MapResponse.raw:type_name -> byzantine.canonical.v1.RawConsensusMessage
+	4, // 4: codec.remote.v1.MapResponse.canonical:type_name -> byzantine.canonical.v1.CanonicalMessage
+	1, // 5: codec.remote.v1.MapperService.Map:input_type -> codec.remote.v1.MapRequest
+	2, // 6: codec.remote.v1.MapperService.Map:output_type -> codec.remote.v1.MapResponse
+	6, // [6:7] is the sub-list for method output_type
+	5, // [5:6] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_remote_proto_init() }
+func file_remote_proto_init() {
+	if File_remote_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_remote_proto_rawDesc), len(file_remote_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_remote_proto_goTypes,
+		DependencyIndexes: file_remote_proto_depIdxs,
+		EnumInfos:         file_remote_proto_enumTypes,
+		MessageInfos:      file_remote_proto_msgTypes,
+	}.Build()
+	File_remote_proto = out.File
+	file_remote_proto_goTypes = nil
+	file_remote_proto_depIdxs = nil
+}
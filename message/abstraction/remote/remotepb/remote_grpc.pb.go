@@ -0,0 +1,122 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: remote.proto
+
+// Remote mapper protocol: a Mapper served by another process, such as an adapter written in another
+// language. message/abstraction/remote is the Go client and reference server; see docs/remote_mapper.md.
+
+package remotepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MapperService_Map_FullMethodName = "/codec.remote.v1.MapperService/Map"
+)
+
+// MapperServiceClient is the client API for MapperService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MapperServiceClient interface {
+	// Map carries every call of one client connection. Responses carry the id of their request and may be
+	// sent in any order.
+	Map(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MapRequest, MapResponse], error)
+}
+
+type mapperServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMapperServiceClient(cc grpc.ClientConnInterface) MapperServiceClient {
+	return &mapperServiceClient{cc}
+}
+
+func (c *mapperServiceClient) Map(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MapRequest, MapResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MapperService_ServiceDesc.Streams[0], MapperService_Map_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MapRequest, MapResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MapperService_MapClient = grpc.BidiStreamingClient[MapRequest, MapResponse]
+
+// MapperServiceServer is the server API for MapperService service.
+// All implementations must embed UnimplementedMapperServiceServer
+// for forward compatibility.
+type MapperServiceServer interface {
+	// Map carries every call of one client connection. Responses carry the id of their request and may be
+	// sent in any order.
+	Map(grpc.BidiStreamingServer[MapRequest, MapResponse]) error
+	mustEmbedUnimplementedMapperServiceServer()
+}
+
+// UnimplementedMapperServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMapperServiceServer struct{}
+
+func (UnimplementedMapperServiceServer) Map(grpc.BidiStreamingServer[MapRequest, MapResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Map not implemented")
+}
+func (UnimplementedMapperServiceServer) mustEmbedUnimplementedMapperServiceServer() {}
+func (UnimplementedMapperServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeMapperServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MapperServiceServer will
+// result in compilation errors.
+type UnsafeMapperServiceServer interface {
+	mustEmbedUnimplementedMapperServiceServer()
+}
+
+func RegisterMapperServiceServer(s grpc.ServiceRegistrar, srv MapperServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMapperServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MapperService_ServiceDesc, srv)
+}
+
+func _MapperService_Map_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MapperServiceServer).Map(&grpc.GenericServerStream[MapRequest, MapResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MapperService_MapServer = grpc.BidiStreamingServer[MapRequest, MapResponse]
+
+// MapperService_ServiceDesc is the grpc.ServiceDesc for MapperService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MapperService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "codec.remote.v1.MapperService",
+	HandlerType: (*MapperServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Map",
+			Handler:       _MapperService_Map_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "remote.proto",
+}
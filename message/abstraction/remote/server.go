@@ -0,0 +1,95 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/canonical"
+	"codec/message/abstraction/remote/remotepb"
+
+	"google.golang.org/grpc"
+)
+
+// mapperServer serves a Mapper as MapperService.
+type mapperServer struct {
+	remotepb.UnimplementedMapperServiceServer
+	mapper abstraction.Mapper
+}
+
+// Register serves mapper as MapperService on srv. It is the reference server and what Go adapters use to
+// run out of process; servers in other languages implement the same service from remote.proto.
+func Register(srv *grpc.Server, mapper abstraction.Mapper) {
+	remotepb.RegisterMapperServiceServer(srv, mapperServer{mapper: mapper})
+}
+
+// Map answers requests concurrently; responses are matched to requests by ID.
+func (s mapperServer) Map(stream remotepb.MapperService_MapServer) error {
+	var (
+		sendMu sync.Mutex
+		wg     sync.WaitGroup
+	)
+	defer wg.Wait()
+
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := handle(s.mapper, req)
+			sendMu.Lock()
+			defer sendMu.Unlock()
+			_ = stream.Send(resp)
+		}()
+	}
+}
+
+func handle(mapper abstraction.Mapper, req *remotepb.MapRequest) *remotepb.MapResponse {
+	resp := &remotepb.MapResponse{Id: req.GetId()}
+	switch req.GetOp() {
+	case remotepb.Op_OP_DESCRIBE:
+		resp.ChainType = string(mapper.GetChainType())
+		for _, msgType := range mapper.GetSupportedTypes() {
+			resp.SupportedTypes = append(resp.SupportedTypes, string(msgType))
+		}
+	case remotepb.Op_OP_TO_CANONICAL:
+		if req.GetRaw() == nil {
+			resp.Error = "to_canonical request has no raw message"
+			break
+		}
+		msg, err := mapper.ToCanonical(*canonical.RawFromProto(req.GetRaw()))
+		if err == nil {
+			resp.Canonical, err = canonical.ToProto(msg)
+		}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+	case remotepb.Op_OP_FROM_CANONICAL:
+		if req.GetCanonical() == nil {
+			resp.Error = "from_canonical request has no canonical message"
+			break
+		}
+		msg, err := canonical.FromProto(req.GetCanonical())
+		var raw *abstraction.RawConsensusMessage
+		if err == nil {
+			raw, err = mapper.FromCanonical(msg)
+		}
+		if err == nil {
+			resp.Raw, err = canonical.RawToProto(raw)
+		}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+	default:
+		resp.Error = fmt.Sprintf("unknown op %s", req.GetOp())
+	}
+	return resp
+}
@@ -0,0 +1,292 @@
+// Package roundtrip checks that a chain mapper preserves canonical messages through FromCanonical followed by
+// ToCanonical. Adapter authors call Assert from their own tests; the per-chain profiles record which fields
+// each built-in chain's wire format can carry.
+package roundtrip
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+// Profile selects the CanonicalMessage fields that must survive a round trip.
+type Profile struct {
+	ChainID, Type, Height, Round, View, Timestamp bool
+	BlockHash, PrevHash                           bool
+	Proposer, Validator, Signature                bool
+	CommitSeals, ViewChanges                      bool
+	// TimestampPrecision truncates both timestamps before comparing them; zero compares them exactly.
+	TimestampPrecision time.Duration
+	// Extensions lists the extension keys that must survive when the input sets them. Values are compared after
+	// a JSON round trip, so an int32 and the float64 it decodes to are equal.
+	Extensions []string
+	// AllExtensions compares every extension key either message sets, in place of Extensions.
+	AllExtensions bool
+}
+
+// Full compares every field except the extensions.
+var Full = Profile{
+	ChainID: true, Type: true, Height: true, Round: true, View: true, Timestamp: true,
+	BlockHash: true, PrevHash: true, Proposer: true, Validator: true, Signature: true,
+	CommitSeals: true, ViewChanges: true,
+}
+
+var profiles = struct {
+	sync.RWMutex
+	byChain map[abstraction.ChainType]Profile
+}{byChain: map[abstraction.ChainType]Profile{}}
+
+// RegisterProfile sets the profile used for a chain, replacing the built-in one.
+func RegisterProfile(chain abstraction.ChainType, p Profile) {
+	profiles.Lock()
+	defer profiles.Unlock()
+	profiles.byChain[chain] = p
+}
+
+// ProfileFor returns the profile for a chain: a registered one, else the built-in one, else Full.
+func ProfileFor(chain abstraction.ChainType) Profile {
+	profiles.RLock()
+	p, ok := profiles.byChain[chain]
+	profiles.RUnlock()
+	if ok {
+		return p
+	}
+	return DefaultProfile(chain)
+}
+
+// DefaultProfile returns the built-in profile for a chain. Chains without one get Full.
+func DefaultProfile(chain abstraction.ChainType) Profile {
+	p := Full
+	switch chain {
+	case abstraction.ChainTypeCometBFT:
+		p.View, p.PrevHash, p.CommitSeals, p.ViewChanges = false, false, false, false
+		p.Extensions = []string{"pol_round", "validator_index", "extension", "extension_signature", "part_index"}
+	case abstraction.ChainTypeHyperledger:
+		// The Besu encoder derives the proposer from the validator and writes placeholder signatures.
+		p.View, p.PrevHash, p.Proposer, p.Signature, p.CommitSeals, p.ViewChanges = false, false, false, false, false, false
+	case abstraction.ChainTypeKaia:
+		// Kaia messages are stamped on receipt and proposals name a placeholder proposer.
+		p.View, p.Timestamp, p.Proposer, p.CommitSeals, p.ViewChanges = false, false, false, false, false
+	case abstraction.ChainTypeFabric:
+		p.Round, p.CommitSeals, p.ViewChanges = false, false, false
+		p.Extensions = []string{"channel_id", "config_seq", "msp_id", "current_view"}
+	case abstraction.ChainTypeFabricRaft:
+		// Raft messages are unsigned and only appends and snapshots name a block.
+		p.Round, p.PrevHash, p.Signature, p.CommitSeals, p.ViewChanges = false, false, false, false, false
+		p.Extensions = []string{"channel_id"}
+	case abstraction.ChainTypeHotStuff:
+		p.Round, p.ViewChanges = false, false
+		p.Extensions = []string{"qc_signers", "justify_phase", "justify_view", "justify_block_hash"}
+	case abstraction.ChainTypeEthereum:
+		// Beacon messages carry no wall-clock time and block roots are recomputed from the header.
+		p.Round, p.View, p.Timestamp, p.BlockHash, p.CommitSeals, p.ViewChanges = false, false, false, false, false, false
+		p.Extensions = []string{"beacon_message_type", "source_epoch", "target_epoch"}
+	case abstraction.ChainTypeAptos:
+		// Block IDs are recomputed from the block data, parents travel in the quorum_cert extension and
+		// timestamps as timestamp_usecs.
+		p.Round, p.Timestamp, p.BlockHash, p.PrevHash, p.ViewChanges = false, false, false, false, false
+		p.Extensions = []string{"aptos_message_type", "timestamp_usecs"}
+	case abstraction.ChainTypeAvalanche:
+		p.Round, p.View, p.CommitSeals, p.ViewChanges = false, false, false, false
+		p.Extensions = []string{"snowman_message_type", "blockchain_id", "request_id"}
+	}
+	return p
+}
+
+// Without returns p with the named fields left out of the comparison. Names are the Profile field names,
+// matched case-insensitively; extension keys are given as Extensions[key].
+func (p Profile) Without(fields ...string) (Profile, error) {
+	v := reflect.ValueOf(&p).Elem()
+	for _, field := range fields {
+		if strings.HasPrefix(field, "Extensions[") && strings.HasSuffix(field, "]") {
+			key := field[len("Extensions[") : len(field)-1]
+			var kept []string
+			for _, k := range p.Extensions {
+				if k != key {
+					kept = append(kept, k)
+				}
+			}
+			p.Extensions = kept
+			continue
+		}
+		f := v.FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, field) })
+		if !f.IsValid() || f.Kind() != reflect.Bool {
+			return p, fmt.Errorf("unknown field %q", field)
+		}
+		f.SetBool(false)
+	}
+	return p, nil
+}
+
+// Check converts msg with mapper.FromCanonical and back with mapper.ToCanonical, and compares the result with
+// msg under p. The error lists every field that changed.
+func Check(mapper abstraction.Mapper, msg *abstraction.CanonicalMessage, p Profile) error {
+	raw, err := mapper.FromCanonical(msg)
+	if err != nil {
+		return fmt.Errorf("FromCanonical: %w", err)
+	}
+	got, err := mapper.ToCanonical(*raw)
+	if err != nil {
+		return fmt.Errorf("ToCanonical: %w", err)
+	}
+	if diffs := Compare(msg, got, p); len(diffs) > 0 {
+		return fmt.Errorf("%s round trip changed %d field(s):\n  %s",
+			mapper.GetChainType(), len(diffs), strings.Join(diffs, "\n  "))
+	}
+	return nil
+}
+
+// Assert fails t unless msg survives a round trip through mapper under the mapper's chain profile.
+func Assert(t testing.TB, mapper abstraction.Mapper, msg *abstraction.CanonicalMessage) {
+	t.Helper()
+	if err := Check(mapper, msg, ProfileFor(mapper.GetChainType())); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Diff is one field that differs between two messages, with both values as printed.
+type Diff struct {
+	Field string `json:"field"`
+	Want  string `json:"want"`
+	Got   string `json:"got"`
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("%s: %s != %s", d.Field, d.Want, d.Got)
+}
+
+// Compare returns one line per field that differs between want and got under p.
+func Compare(want, got *abstraction.CanonicalMessage, p Profile) []string {
+	var lines []string
+	for _, d := range Differences(want, got, p) {
+		lines = append(lines, d.String())
+	}
+	return lines
+}
+
+// Differences returns the fields that differ between want and got under p.
+func Differences(want, got *abstraction.CanonicalMessage, p Profile) []Diff {
+	var diffs []Diff
+	differ := func(field string, a, b interface{}) {
+		diffs = append(diffs, Diff{Field: field, Want: fmt.Sprint(a), Got: fmt.Sprint(b)})
+	}
+
+	if p.ChainID && want.ChainID != got.ChainID {
+		differ("ChainID", want.ChainID, got.ChainID)
+	}
+	if p.Type && want.Type != got.Type {
+		differ("Type", want.Type, got.Type)
+	}
+	if p.Height && !bigIntEqual(want.Height, got.Height) {
+		differ("Height", want.Height, got.Height)
+	}
+	if p.Round && !bigIntEqual(want.Round, got.Round) {
+		differ("Round", want.Round, got.Round)
+	}
+	if p.View && !bigIntEqual(want.View, got.View) {
+		differ("View", want.View, got.View)
+	}
+	if p.Timestamp && !timestampEqual(want.Timestamp, got.Timestamp, p.TimestampPrecision) {
+		differ("Timestamp", want.Timestamp, got.Timestamp)
+	}
+	if p.BlockHash && want.BlockHash != got.BlockHash {
+		differ("BlockHash", want.BlockHash, got.BlockHash)
+	}
+	if p.PrevHash && want.PrevHash != got.PrevHash {
+		differ("PrevHash", want.PrevHash, got.PrevHash)
+	}
+	if p.Proposer && want.Proposer != got.Proposer {
+		differ("Proposer", want.Proposer, got.Proposer)
+	}
+	if p.Validator && want.Validator != got.Validator {
+		differ("Validator", want.Validator, got.Validator)
+	}
+	if p.Signature && want.Signature != got.Signature {
+		differ("Signature", fmt.Sprintf("%q", want.Signature), fmt.Sprintf("%q", got.Signature))
+	}
+	if p.CommitSeals && !(len(want.CommitSeals) == 0 && len(got.CommitSeals) == 0) &&
+		!reflect.DeepEqual(want.CommitSeals, got.CommitSeals) {
+		differ("CommitSeals", want.CommitSeals, got.CommitSeals)
+	}
+	if p.ViewChanges {
+		if len(want.ViewChanges) != len(got.ViewChanges) {
+			differ("len(ViewChanges)", len(want.ViewChanges), len(got.ViewChanges))
+		} else {
+			for i, a := range want.ViewChanges {
+				b := got.ViewChanges[i]
+				if !bigIntEqual(a.View, b.View) || !bigIntEqual(a.Height, b.Height) ||
+					a.Validator != b.Validator || a.Signature != b.Signature {
+					differ(fmt.Sprintf("ViewChanges[%d]", i), fmt.Sprintf("%+v", a), fmt.Sprintf("%+v", b))
+				}
+			}
+		}
+	}
+	keys := p.Extensions
+	if p.AllExtensions {
+		keys = extensionKeys(want, got)
+	}
+	for _, key := range keys {
+		if !p.AllExtensions && !want.Extensions.Has(key) {
+			continue
+		}
+		a, b := normalize(want.Extensions[key]), normalize(got.Extensions[key])
+		if !reflect.DeepEqual(a, b) {
+			differ("Extensions["+key+"]", a, b)
+		}
+	}
+	return diffs
+}
+
+// extensionKeys returns the extension keys set by either message, sorted.
+func extensionKeys(a, b *abstraction.CanonicalMessage) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, msg := range []*abstraction.CanonicalMessage{a, b} {
+		for key := range msg.Extensions {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// normalize gives a value the types it would have after a JSON round trip.
+func normalize(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+func timestampEqual(a, b time.Time, precision time.Duration) bool {
+	if precision > 0 {
+		a, b = a.Truncate(precision), b.Truncate(precision)
+	}
+	return a.Equal(b)
+}
+
+func bigIntEqual(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Cmp(b) == 0
+}
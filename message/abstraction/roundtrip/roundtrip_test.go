@@ -0,0 +1,183 @@
+package roundtrip_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	aptosAdapter "codec/aptos/adapter"
+	avalancheAdapter "codec/avalanche/adapter"
+	cometbftAdapter "codec/cometbft/adapter"
+	ethereumAdapter "codec/ethereum/adapter"
+	hotstuffAdapter "codec/hotstuff/adapter"
+	besuAdapter "codec/hyperledger/besu/adapter"
+	fabricAdapter "codec/hyperledger/fabric/adapter"
+	kaiaAdapter "codec/kaia/adapter"
+	"codec/message/abstraction"
+	"codec/message/abstraction/roundtrip"
+)
+
+func hexOf(b string, n int) string {
+	return "0x" + strings.Repeat(b, n)
+}
+
+func message(typ abstraction.MsgType, edit func(*abstraction.CanonicalMessage)) *abstraction.CanonicalMessage {
+	msg := &abstraction.CanonicalMessage{
+		ChainID:   "roundtrip-test",
+		Height:    big.NewInt(1000),
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC),
+		Type:      typ,
+	}
+	edit(msg)
+	return msg
+}
+
+func TestBuiltinMappersRoundTrip(t *testing.T) {
+	const chainID = "roundtrip-test"
+	tests := []struct {
+		mapper abstraction.Mapper
+		msgs   []*abstraction.CanonicalMessage
+	}{
+		{cometbftAdapter.NewCometBFTMapper(chainID), []*abstraction.CanonicalMessage{
+			message(abstraction.MsgTypeProposal, func(m *abstraction.CanonicalMessage) {
+				m.Round, m.BlockHash, m.Proposer, m.Signature = big.NewInt(1), strings.Repeat("AB", 32), "ABCDEF0123456789ABCDEF0123456789ABCDEF01", "c2ln"
+				m.Extensions = abstraction.Extensions{"pol_round": int32(-1)}
+			}),
+			message(abstraction.MsgTypePrecommit, func(m *abstraction.CanonicalMessage) {
+				m.Round, m.BlockHash, m.Validator, m.Signature = big.NewInt(1), strings.Repeat("AB", 32), "ABCDEF0123456789ABCDEF0123456789ABCDEF01", "c2ln"
+				m.Extensions = abstraction.Extensions{"validator_index": int32(2), "extension": "ZXh0", "extension_signature": "c2ln"}
+			}),
+		}},
+		{besuAdapter.NewBesuMapper(chainID), []*abstraction.CanonicalMessage{
+			message(abstraction.MsgTypeCommit, func(m *abstraction.CanonicalMessage) {
+				m.Round, m.BlockHash, m.Validator = big.NewInt(0), hexOf("ab", 32), hexOf("11", 20)
+			}),
+		}},
+		{kaiaAdapter.NewKaiaMapper(chainID), []*abstraction.CanonicalMessage{
+			message(abstraction.MsgTypePrepare, func(m *abstraction.CanonicalMessage) {
+				m.Round, m.BlockHash, m.PrevHash, m.Validator, m.Signature = big.NewInt(0), hexOf("ab", 32), hexOf("cd", 32), hexOf("11", 20), hexOf("22", 65)
+			}),
+			message(abstraction.MsgTypeCommit, func(m *abstraction.CanonicalMessage) {
+				m.Round, m.BlockHash, m.PrevHash, m.Validator, m.Signature = big.NewInt(0), hexOf("ab", 32), hexOf("cd", 32), hexOf("11", 20), hexOf("22", 65)
+			}),
+			message(abstraction.MsgTypeRoundChange, func(m *abstraction.CanonicalMessage) {
+				m.Round, m.BlockHash, m.PrevHash, m.Validator, m.Signature = big.NewInt(1), hexOf("ab", 32), hexOf("cd", 32), hexOf("11", 20), hexOf("22", 65)
+			}),
+		}},
+		{fabricAdapter.NewFabricMapper(chainID), []*abstraction.CanonicalMessage{
+			message(abstraction.MsgTypeProposal, func(m *abstraction.CanonicalMessage) {
+				m.View, m.BlockHash, m.PrevHash, m.Proposer, m.Signature = big.NewInt(3), strings.Repeat("11", 32), strings.Repeat("22", 32), "OrdererMSP/1", "sig-1"
+				m.Extensions = abstraction.Extensions{"channel_id": "mychannel", "config_seq": uint64(7), "msp_id": "OrdererMSP"}
+			}),
+			message(abstraction.MsgTypeViewChange, func(m *abstraction.CanonicalMessage) {
+				m.View, m.Validator, m.Signature = big.NewInt(4), "OrdererMSP/2", "sig-2"
+			}),
+		}},
+		{fabricAdapter.NewFabricRaftMapper(chainID), []*abstraction.CanonicalMessage{
+			message(abstraction.MsgTypeProposal, func(m *abstraction.CanonicalMessage) {
+				m.View, m.BlockHash, m.Proposer = big.NewInt(4), "0xb11", "1"
+				m.Extensions = abstraction.Extensions{"channel_id": "mychannel"}
+			}),
+			message(abstraction.MsgTypeVote, func(m *abstraction.CanonicalMessage) {
+				m.View, m.Validator = big.NewInt(5), "3"
+			}),
+		}},
+		{hotstuffAdapter.NewHotStuffMapper(chainID), []*abstraction.CanonicalMessage{
+			message(abstraction.MsgTypePrecommit, func(m *abstraction.CanonicalMessage) {
+				m.View, m.BlockHash, m.Proposer, m.CommitSeals = big.NewInt(12), "b1", "node-1", []string{"s1", "s2", "s3"}
+				m.Extensions = abstraction.Extensions{"qc_signers": []string{"node-1", "node-2", "node-3"}}
+			}),
+			message(abstraction.MsgTypeVote, func(m *abstraction.CanonicalMessage) {
+				m.View, m.BlockHash, m.Validator, m.Signature = big.NewInt(12), "b1", "node-2", "sig"
+			}),
+		}},
+		{ethereumAdapter.NewEthereumMapper(chainID), []*abstraction.CanonicalMessage{
+			message(abstraction.MsgTypeVote, func(m *abstraction.CanonicalMessage) {
+				m.BlockHash, m.Validator, m.Signature = hexOf("aa", 32), "3:1,5", hexOf("22", 96)
+				m.Extensions = abstraction.Extensions{"source_epoch": uint64(30), "target_epoch": uint64(31)}
+			}),
+		}},
+		{aptosAdapter.NewAptosMapper(chainID), []*abstraction.CanonicalMessage{
+			message(abstraction.MsgTypeVote, func(m *abstraction.CanonicalMessage) {
+				m.View, m.BlockHash, m.Validator, m.Signature = big.NewInt(7), hexOf("ab", 32), hexOf("11", 32), hexOf("22", 64)
+				m.Extensions = abstraction.Extensions{"timestamp_usecs": uint64(1714564800123456)}
+			}),
+		}},
+		{avalancheAdapter.NewAvalancheMapper(chainID), []*abstraction.CanonicalMessage{
+			message(abstraction.MsgTypeVote, func(m *abstraction.CanonicalMessage) {
+				m.BlockHash, m.Validator = "blk1", "NodeID-abc"
+				m.Extensions = abstraction.Extensions{"request_id": uint32(9)}
+			}),
+		}},
+	}
+
+	for _, tt := range tests {
+		for _, msg := range tt.msgs {
+			t.Run(string(tt.mapper.GetChainType())+"/"+string(msg.Type), func(t *testing.T) {
+				roundtrip.Assert(t, tt.mapper, msg)
+			})
+		}
+	}
+}
+
+func TestCompareReportsChangedFields(t *testing.T) {
+	want := &abstraction.CanonicalMessage{
+		Height:     big.NewInt(5),
+		BlockHash:  "0xaa",
+		Extensions: abstraction.Extensions{"index": int32(3), "ignored": "x"},
+	}
+	got := &abstraction.CanonicalMessage{
+		Height:     big.NewInt(5),
+		BlockHash:  "0xbb",
+		Extensions: abstraction.Extensions{"index": float64(3)},
+	}
+	p := roundtrip.Full
+	p.Extensions = []string{"index", "missing"}
+
+	diffs := roundtrip.Compare(want, got, p)
+	if len(diffs) != 1 || !strings.HasPrefix(diffs[0], "BlockHash:") {
+		t.Fatalf("expected only the block hash to differ, got %q", diffs)
+	}
+
+	p.BlockHash = false
+	p.Extensions = append(p.Extensions, "ignored")
+	if diffs := roundtrip.Compare(want, got, p); len(diffs) != 1 || !strings.HasPrefix(diffs[0], "Extensions[ignored]") {
+		t.Fatalf("expected a dropped extension to be reported, got %q", diffs)
+	}
+}
+
+func TestRegisterProfileOverridesDefault(t *testing.T) {
+	chain := abstraction.ChainType("roundtrip-test-chain")
+	if !roundtrip.ProfileFor(chain).Signature {
+		t.Fatalf("expected an unknown chain to use the full profile")
+	}
+	p := roundtrip.Full
+	p.Signature = false
+	roundtrip.RegisterProfile(chain, p)
+	if roundtrip.ProfileFor(chain).Signature {
+		t.Fatalf("expected the registered profile to be used")
+	}
+}
+
+func TestAllExtensionsAndWithout(t *testing.T) {
+	want := &abstraction.CanonicalMessage{Extensions: abstraction.Extensions{"a": 1}}
+	got := &abstraction.CanonicalMessage{Extensions: abstraction.Extensions{"a": 1, "b": "added"}}
+	p := roundtrip.Full
+	p.AllExtensions = true
+	diffs := roundtrip.Differences(want, got, p)
+	if len(diffs) != 1 || diffs[0].Field != "Extensions[b]" || diffs[0].Got != "added" {
+		t.Fatalf("expected an added extension to be reported, got %+v", diffs)
+	}
+
+	p, err := roundtrip.Full.Without("signature", "BlockHash", "Extensions[a]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Signature || p.BlockHash || !p.Height {
+		t.Fatalf("expected only the named fields to be dropped, got %+v", p)
+	}
+	if _, err := roundtrip.Full.Without("TimestampPrecision"); err == nil {
+		t.Fatal("expected a non-boolean field to be rejected")
+	}
+}
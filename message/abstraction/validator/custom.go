@@ -0,0 +1,237 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"codec/message/abstraction"
+)
+
+// ErrRuleRegistered is returned when a rule name is already taken
+var ErrRuleRegistered = errors.New("rule already registered")
+
+// RuleFactory builds a rule's check from the parameters a rule file gives it. Factories are called once per
+// rule file entry, so a check may keep state across the messages it sees.
+type RuleFactory func(params map[string]interface{}) (func(*abstraction.CanonicalMessage) error, error)
+
+// RuleRef names a registered rule and its parameters in a rule file
+type RuleRef struct {
+	Name   string                 `json:"name"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
+type registeredRule struct {
+	description string
+	factory     RuleFactory
+}
+
+var ruleRegistry = struct {
+	sync.RWMutex
+	rules map[string]registeredRule
+}{
+	rules: map[string]registeredRule{
+		"monotonic_height": {
+			description: "Reject heights more than params.tolerance below the highest seen on the chain",
+			factory:     monotonicHeight,
+		},
+		"proposer_in_validator_set": {
+			description: "Reject proposers missing from params.validators",
+			factory:     proposerInValidatorSet,
+		},
+		"timestamp_drift": {
+			description: "Reject timestamps more than params.max_future_seconds ahead or params.max_past_seconds behind the clock",
+			factory:     timestampDrift,
+		},
+	},
+}
+
+// RegisterRule makes a rule implementation available to rule files under name. A name that is already
+// registered, built-in ones included, is rejected with ErrRuleRegistered.
+func RegisterRule(name, description string, factory RuleFactory) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("rule name cannot be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("rule %q has no factory", name)
+	}
+
+	ruleRegistry.Lock()
+	defer ruleRegistry.Unlock()
+	if _, ok := ruleRegistry.rules[name]; ok {
+		return fmt.Errorf("rule %q: %w", name, ErrRuleRegistered)
+	}
+	ruleRegistry.rules[name] = registeredRule{description: description, factory: factory}
+	return nil
+}
+
+// RuleNames returns the names of the registered rules in order
+func RuleNames() []string {
+	ruleRegistry.RLock()
+	defer ruleRegistry.RUnlock()
+	names := make([]string, 0, len(ruleRegistry.rules))
+	for name := range ruleRegistry.rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewCustomRule builds the registered rule name with params
+func NewCustomRule(name string, params map[string]interface{}) (CustomValidationRule, error) {
+	ruleRegistry.RLock()
+	rule, ok := ruleRegistry.rules[name]
+	ruleRegistry.RUnlock()
+	if !ok {
+		return CustomValidationRule{}, fmt.Errorf("unknown rule %q (%s)", name, strings.Join(RuleNames(), ", "))
+	}
+	check, err := rule.factory(params)
+	if err != nil {
+		return CustomValidationRule{}, fmt.Errorf("rule %s: %w", name, err)
+	}
+	return CustomValidationRule{
+		Name:        name,
+		Description: rule.description,
+		Function:    check,
+	}, nil
+}
+
+// monotonicHeight remembers the highest height per chain ID and rejects messages that fall too far behind it
+func monotonicHeight(params map[string]interface{}) (func(*abstraction.CanonicalMessage) error, error) {
+	if err := onlyParams(params, "tolerance"); err != nil {
+		return nil, err
+	}
+	tolerance, _, err := numberParam(params, "tolerance")
+	if err != nil {
+		return nil, err
+	}
+	slack := big.NewInt(int64(tolerance))
+
+	var mu sync.Mutex
+	highest := map[string]*big.Int{}
+	return func(msg *abstraction.CanonicalMessage) error {
+		if msg.Height == nil {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		top, ok := highest[msg.ChainID]
+		if !ok || msg.Height.Cmp(top) > 0 {
+			highest[msg.ChainID] = new(big.Int).Set(msg.Height)
+			return nil
+		}
+		if floor := new(big.Int).Sub(top, slack); msg.Height.Cmp(floor) < 0 {
+			return fmt.Errorf("height %s is more than %s below height %s already seen", msg.Height, slack, top)
+		}
+		return nil
+	}, nil
+}
+
+// proposerInValidatorSet rejects messages whose proposer is not one of the configured validators
+func proposerInValidatorSet(params map[string]interface{}) (func(*abstraction.CanonicalMessage) error, error) {
+	if err := onlyParams(params, "validators"); err != nil {
+		return nil, err
+	}
+	var list []interface{}
+	switch v := params["validators"].(type) {
+	case []interface{}:
+		list = v
+	case []string:
+		for _, id := range v {
+			list = append(list, id)
+		}
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("params.validators must be a non-empty list")
+	}
+	validators := make(map[string]bool, len(list))
+	for _, entry := range list {
+		id, ok := entry.(string)
+		if !ok || id == "" {
+			return nil, fmt.Errorf("params.validators must hold validator IDs, got %v", entry)
+		}
+		validators[normalizeID(id)] = true
+	}
+	return func(msg *abstraction.CanonicalMessage) error {
+		if msg.Proposer == "" || validators[normalizeID(msg.Proposer)] {
+			return nil
+		}
+		return fmt.Errorf("proposer %s is not in the validator set", msg.Proposer)
+	}, nil
+}
+
+// timestampDrift rejects messages whose timestamp is too far from the local clock
+func timestampDrift(params map[string]interface{}) (func(*abstraction.CanonicalMessage) error, error) {
+	if err := onlyParams(params, "max_future_seconds", "max_past_seconds"); err != nil {
+		return nil, err
+	}
+	future, hasFuture, err := numberParam(params, "max_future_seconds")
+	if err != nil {
+		return nil, err
+	}
+	past, hasPast, err := numberParam(params, "max_past_seconds")
+	if err != nil {
+		return nil, err
+	}
+	if !hasFuture && !hasPast {
+		return nil, fmt.Errorf("params need max_future_seconds or max_past_seconds")
+	}
+	return func(msg *abstraction.CanonicalMessage) error {
+		if msg.Timestamp.IsZero() {
+			return nil
+		}
+		drift := msg.Timestamp.Sub(abstraction.Now()).Seconds()
+		if hasFuture && drift > future {
+			return fmt.Errorf("timestamp is %.0f seconds in the future", drift)
+		}
+		if hasPast && -drift > past {
+			return fmt.Errorf("timestamp is %.0f seconds in the past", -drift)
+		}
+		return nil
+	}, nil
+}
+
+// onlyParams rejects parameters a rule does not take
+func onlyParams(params map[string]interface{}, allowed ...string) error {
+	for name := range params {
+		known := false
+		for _, a := range allowed {
+			known = known || name == a
+		}
+		if !known {
+			return fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+	return nil
+}
+
+// numberParam reads an optional non-negative number parameter
+func numberParam(params map[string]interface{}, name string) (float64, bool, error) {
+	value, ok := params[name]
+	if !ok {
+		return 0, false, nil
+	}
+	var n float64
+	switch v := value.(type) {
+	case float64:
+		n = v
+	case int:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	default:
+		return 0, false, fmt.Errorf("params.%s must be a number", name)
+	}
+	if n < 0 {
+		return 0, false, fmt.Errorf("params.%s must not be negative", name)
+	}
+	return n, true, nil
+}
+
+// normalizeID compares hex validator IDs without regard to case or a 0x prefix
+func normalizeID(id string) string {
+	return strings.TrimPrefix(strings.ToLower(id), "0x")
+}
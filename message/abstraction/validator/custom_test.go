@@ -0,0 +1,119 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+func TestBuiltinCustomRules(t *testing.T) {
+	monotonic, err := NewCustomRule("monotonic_height", map[string]interface{}{"tolerance": float64(1)})
+	if err != nil {
+		t.Fatalf("NewCustomRule: %v", err)
+	}
+	for _, height := range []int64{5, 9, 8} {
+		if err := monotonic.Function(&abstraction.CanonicalMessage{ChainID: "a", Height: big.NewInt(height)}); err != nil {
+			t.Fatalf("height %d: %v", height, err)
+		}
+	}
+	if err := monotonic.Function(&abstraction.CanonicalMessage{ChainID: "a", Height: big.NewInt(7)}); err == nil {
+		t.Fatalf("expected height 7 after 9 to exceed the tolerance")
+	}
+	if err := monotonic.Function(&abstraction.CanonicalMessage{ChainID: "b", Height: big.NewInt(1)}); err != nil {
+		t.Fatalf("heights are tracked per chain: %v", err)
+	}
+
+	proposer, err := NewCustomRule("proposer_in_validator_set", map[string]interface{}{"validators": []string{"0xAB12"}})
+	if err != nil {
+		t.Fatalf("NewCustomRule: %v", err)
+	}
+	if err := proposer.Function(&abstraction.CanonicalMessage{Proposer: "ab12"}); err != nil {
+		t.Fatalf("listed proposer rejected: %v", err)
+	}
+	if err := proposer.Function(&abstraction.CanonicalMessage{Proposer: "cd34"}); err == nil {
+		t.Fatalf("expected an unlisted proposer to be rejected")
+	}
+
+	drift, err := NewCustomRule("timestamp_drift", map[string]interface{}{"max_future_seconds": float64(10)})
+	if err != nil {
+		t.Fatalf("NewCustomRule: %v", err)
+	}
+	if err := drift.Function(&abstraction.CanonicalMessage{Timestamp: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("past timestamps are allowed without max_past_seconds: %v", err)
+	}
+	if err := drift.Function(&abstraction.CanonicalMessage{Timestamp: time.Now().Add(time.Minute)}); err == nil {
+		t.Fatalf("expected a timestamp a minute ahead to be rejected")
+	}
+
+	for name, params := range map[string]map[string]interface{}{
+		"monotonic_height":          {"tolerance": "one"},
+		"proposer_in_validator_set": nil,
+		"timestamp_drift":           {"max_skew": float64(1)},
+		"no_such_rule":              nil,
+	} {
+		if _, err := NewCustomRule(name, params); err == nil {
+			t.Errorf("%s %v: expected an error", name, params)
+		}
+	}
+}
+
+func TestRegisteredRulesInRuleFiles(t *testing.T) {
+	err := RegisterRule("even_round", "Reject odd rounds", func(params map[string]interface{}) (func(*abstraction.CanonicalMessage) error, error) {
+		return func(msg *abstraction.CanonicalMessage) error {
+			if msg.Round != nil && msg.Round.Bit(0) == 1 {
+				return fmt.Errorf("round %s is odd", msg.Round)
+			}
+			return nil
+		}, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterRule: %v", err)
+	}
+	if err := RegisterRule("monotonic_height", "", func(map[string]interface{}) (func(*abstraction.CanonicalMessage) error, error) {
+		return nil, nil
+	}); !errors.Is(err, ErrRuleRegistered) {
+		t.Fatalf("expected built-in names to be taken, got %v", err)
+	}
+
+	rules, err := ParseRules([]byte(`{"chains": {"cometbft": {"extends_defaults": true, "rules": [
+		{"name": "even_round"},
+		{"name": "proposer_in_validator_set", "params": {"validators": ["val1"]}}
+	]}}}`))
+	if err != nil {
+		t.Fatalf("ParseRules: %v", err)
+	}
+	v := NewValidatorWithRules(abstraction.ChainTypeCometBFT, rules[abstraction.ChainTypeCometBFT])
+	msg := &abstraction.CanonicalMessage{
+		ChainID:   "cosmos-hub-4",
+		Height:    big.NewInt(3),
+		Round:     big.NewInt(2),
+		Timestamp: time.Now(),
+		Type:      abstraction.MsgTypeProposal,
+		Proposer:  "val1",
+	}
+	if err := v.Validate(msg); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	msg.Round = big.NewInt(3)
+	if err := v.Validate(msg); err == nil || !strings.Contains(err.Error(), "odd") {
+		t.Fatalf("expected the registered rule to run, got %v", err)
+	}
+	msg.Round, msg.Proposer = big.NewInt(2), "val9"
+	if err := v.Validate(msg); err == nil {
+		t.Fatalf("expected the built-in rule to run")
+	}
+	msg.Type = abstraction.MsgTypeCommit
+	var validationErr *abstraction.MessageValidationError
+	if err := v.Validate(msg); !errors.As(err, &validationErr) || validationErr.Field != "cometbft_message_type" {
+		t.Fatalf("extended rules must keep the default type check first, got %v", err)
+	}
+
+	if _, err := ParseRules([]byte(`{"chains": {"cometbft": {"rules": [{"name": "missing"}]}}}`)); err == nil {
+		t.Fatalf("expected an unknown rule name to be rejected")
+	}
+}
@@ -0,0 +1,235 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"codec/message/abstraction"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleFile is the layout of a validation rule file, see docs/validation_rules.schema.json
+type RuleFile struct {
+	Chains map[abstraction.ChainType]RuleSpec `json:"chains"`
+}
+
+// RuleSpec defines the rules of one chain in a rule file
+type RuleSpec struct {
+	// ExtendsDefaults starts from the chain's built-in rules. Required fields and message types given in the
+	// file then replace the built-in ones, and field types and constraints override them field by field.
+	ExtendsDefaults bool                   `json:"extends_defaults,omitempty"`
+	RequiredFields  []string               `json:"required_fields,omitempty"`
+	FieldTypes      map[string]string      `json:"field_types,omitempty"`
+	Constraints     map[string]interface{} `json:"constraints,omitempty"`
+	// MaxAgeSeconds is shorthand for the timestamp max_age_seconds constraint
+	MaxAgeSeconds *float64 `json:"max_age_seconds,omitempty"`
+	// MessageTypes lists the canonical message types the chain accepts
+	MessageTypes []abstraction.MsgType `json:"message_types,omitempty"`
+	// Rules adds registered custom rules, see RegisterRule
+	Rules      []RuleRef       `json:"rules,omitempty"`
+	Structure  *StructureRules `json:"structure,omitempty"`
+	Signatures *SignatureRules `json:"signatures,omitempty"`
+}
+
+// checkedFields are the fields checkFieldPresent knows about
+var checkedFields = map[string]bool{
+	"chain_id": true, "height": true, "timestamp": true, "type": true, "round": true, "view": true,
+	"proposer": true, "validator": true, "signature": true, "block_hash": true,
+}
+
+// fieldTypes are the types checkFieldType accepts for each field
+var fieldTypes = map[string]string{
+	"height": "bigint", "round": "bigint", "view": "bigint", "timestamp": "time", "chain_id": "string", "type": "string",
+}
+
+// constraintKeys are the constraints checkConstraint applies to each field
+var constraintKeys = map[string]string{
+	"height":    "min",
+	"round":     "min",
+	"timestamp": "max_age_seconds",
+}
+
+// LoadRules reads validation rules per chain from a YAML or JSON rule file. The format is chosen by extension
+// and YAML is assumed for anything but .json. Unknown fields, chains and message types are errors.
+func LoadRules(path string) (map[abstraction.ChainType]ValidationRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	if strings.ToLower(filepath.Ext(path)) != ".json" {
+		// Going through JSON keeps one set of field names for both formats.
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("parse rules %s: %w", path, err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("parse rules %s: %w", path, err)
+		}
+	}
+	rules, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("rules %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// ParseRules builds validation rules per chain from a JSON rule file
+func ParseRules(data []byte) (map[abstraction.ChainType]ValidationRules, error) {
+	var file RuleFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("parse rules: %w", err)
+	}
+	if len(file.Chains) == 0 {
+		return nil, fmt.Errorf("no chains defined")
+	}
+
+	chains := make([]string, 0, len(file.Chains))
+	for chainType := range file.Chains {
+		chains = append(chains, string(chainType))
+	}
+	sort.Strings(chains)
+
+	rules := make(map[abstraction.ChainType]ValidationRules, len(file.Chains))
+	for _, name := range chains {
+		chainType := abstraction.ChainType(name)
+		built, err := file.Chains[chainType].build(chainType)
+		if err != nil {
+			return nil, fmt.Errorf("chains.%s: %w", name, err)
+		}
+		rules[chainType] = built
+	}
+	return rules, nil
+}
+
+// build checks the spec and turns it into validation rules for the chain
+func (s RuleSpec) build(chainType abstraction.ChainType) (ValidationRules, error) {
+	if !abstraction.IsKnownChainType(chainType) {
+		return ValidationRules{}, fmt.Errorf("unknown chain type %q", chainType)
+	}
+
+	var rules ValidationRules
+	if s.ExtendsDefaults {
+		rules = getDefaultRules(chainType)
+	}
+	if s.RequiredFields != nil {
+		rules.RequiredFields = nil
+	}
+	for _, field := range s.RequiredFields {
+		if !checkedFields[field] {
+			return ValidationRules{}, fmt.Errorf("required_fields: unknown field %q", field)
+		}
+		rules.RequiredFields = append(rules.RequiredFields, field)
+	}
+
+	if len(s.FieldTypes) > 0 {
+		merged := make(map[string]string, len(rules.FieldTypes)+len(s.FieldTypes))
+		for field, typ := range rules.FieldTypes {
+			merged[field] = typ
+		}
+		for field, typ := range s.FieldTypes {
+			if want, ok := fieldTypes[field]; !ok || typ != want {
+				return ValidationRules{}, fmt.Errorf("field_types.%s: %q is not supported", field, typ)
+			}
+			merged[field] = typ
+		}
+		rules.FieldTypes = merged
+	}
+
+	constraints := s.Constraints
+	if s.MaxAgeSeconds != nil {
+		constraints = make(map[string]interface{}, len(s.Constraints)+1)
+		for field, constraint := range s.Constraints {
+			constraints[field] = constraint
+		}
+		constraints["timestamp"] = map[string]interface{}{"max_age_seconds": *s.MaxAgeSeconds}
+	}
+	if len(constraints) > 0 {
+		merged := make(map[string]interface{}, len(rules.Constraints)+len(constraints))
+		for field, constraint := range rules.Constraints {
+			merged[field] = constraint
+		}
+		for field, constraint := range constraints {
+			if err := checkConstraintSpec(field, constraint); err != nil {
+				return ValidationRules{}, fmt.Errorf("constraints.%s: %w", field, err)
+			}
+			merged[field] = constraint
+		}
+		rules.Constraints = merged
+	}
+
+	if s.MessageTypes != nil {
+		validTypes := make(map[abstraction.MsgType]bool, len(s.MessageTypes))
+		for _, msgType := range s.MessageTypes {
+			if !abstraction.IsKnownMsgType(msgType) {
+				return ValidationRules{}, fmt.Errorf("message_types: unknown message type %q", msgType)
+			}
+			validTypes[msgType] = true
+		}
+		rules.CustomRules = []CustomValidationRule{
+			{
+				Name:        string(chainType) + "_message_type",
+				Description: fmt.Sprintf("Validate %s message types", chainType),
+				Function: func(msg *abstraction.CanonicalMessage) error {
+					if validTypes[msg.Type] {
+						return nil
+					}
+					return fmt.Errorf("unsupported %s message type: %s", chainType, msg.Type)
+				},
+			},
+		}
+	}
+
+	for i, ref := range s.Rules {
+		rule, err := NewCustomRule(ref.Name, ref.Params)
+		if err != nil {
+			return ValidationRules{}, fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		rules.CustomRules = append(rules.CustomRules, rule)
+	}
+
+	if s.Structure != nil {
+		if s.Structure.ValidatorCount < 0 {
+			return ValidationRules{}, fmt.Errorf("structure.validator_count must not be negative")
+		}
+		rules.Structure = s.Structure
+	}
+
+	if s.Signatures != nil {
+		switch s.Signatures.Scheme {
+		case SchemeEd25519, SchemeSecp256k1:
+		default:
+			return ValidationRules{}, fmt.Errorf("signatures.scheme: unknown scheme %q (%s, %s)", s.Signatures.Scheme, SchemeEd25519, SchemeSecp256k1)
+		}
+		rules.Signatures = s.Signatures
+	}
+	return rules, nil
+}
+
+// checkConstraintSpec rejects constraints checkConstraint would not understand
+func checkConstraintSpec(field string, constraint interface{}) error {
+	key, ok := constraintKeys[field]
+	if !ok {
+		return fmt.Errorf("no constraints are supported for this field")
+	}
+	spec, ok := constraint.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected an object with %s", key)
+	}
+	for name, value := range spec {
+		if name != key {
+			return fmt.Errorf("unknown constraint %q (%s)", name, key)
+		}
+		if n, ok := value.(float64); !ok || n < 0 {
+			return fmt.Errorf("%s must be a non-negative number", name)
+		}
+	}
+	return nil
+}
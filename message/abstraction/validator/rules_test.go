@@ -0,0 +1,91 @@
+package validator
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+func TestLoadRulesFromExampleFile(t *testing.T) {
+	rules, err := LoadRules("../../../configs/validation_rules.yaml")
+	if err != nil {
+		t.Fatalf("LoadRules: %v", err)
+	}
+	cometbft, ok := rules[abstraction.ChainTypeCometBFT]
+	if !ok {
+		t.Fatalf("expected cometbft rules, got %v", rules)
+	}
+	v := NewValidatorWithRules(abstraction.ChainTypeCometBFT, cometbft)
+
+	msg := &abstraction.CanonicalMessage{
+		ChainID:   "cosmos-hub-4",
+		Height:    big.NewInt(10),
+		Round:     big.NewInt(0),
+		Timestamp: time.Now(),
+		Type:      abstraction.MsgTypePrevote,
+	}
+	if err := v.Validate(msg); err == nil || !strings.Contains(err.Error(), "validator is required") {
+		t.Fatalf("expected the file's required validator field to be enforced, got %v", err)
+	}
+	msg.Validator = "val1"
+	if err := v.Validate(msg); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	msg.Timestamp = time.Now().Add(-20 * time.Minute)
+	if err := v.Validate(msg); err == nil {
+		t.Fatalf("expected the file's max age to replace the default hour")
+	}
+	msg.Timestamp = time.Now()
+	msg.Type = abstraction.MsgTypeCommit
+	if err := v.Validate(msg); err == nil {
+		t.Fatalf("extended rules must keep the default message type check")
+	}
+
+	kaia := NewValidatorWithRules(abstraction.ChainTypeKaia, rules[abstraction.ChainTypeKaia])
+	if err := kaia.Validate(&abstraction.CanonicalMessage{
+		ChainID: "kaia", Height: big.NewInt(1), Round: big.NewInt(0), Timestamp: time.Now(), Type: abstraction.MsgTypeBlock,
+	}); err != nil {
+		t.Fatalf("kaia block rejected: %v", err)
+	}
+}
+
+func TestLoadRulesRejectsInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"unknown_field.json":      `{"chains": {"cometbft": {"required": ["height"]}}}`,
+		"unknown_chain.yaml":      "chains:\n  solana:\n    required_fields: [height]\n",
+		"unknown_required.yaml":   "chains:\n  cometbft:\n    required_fields: [gas]\n",
+		"bad_constraint.yaml":     "chains:\n  cometbft:\n    constraints:\n      height:\n        max: 10\n",
+		"bad_field_type.yaml":     "chains:\n  cometbft:\n    field_types:\n      height: string\n",
+		"unknown_msg_type.yaml":   "chains:\n  kaia:\n    message_types: [gossip]\n",
+		"unknown_scheme.yaml":     "chains:\n  cometbft:\n    signatures:\n      scheme: bls\n",
+		"no_chains.yaml":          "chains: {}\n",
+		"negative_max_age.yaml":   "chains:\n  cometbft:\n    constraints:\n      timestamp:\n        max_age_seconds: -1\n",
+		"constraint_not_map.json": `{"chains": {"cometbft": {"constraints": {"round": 0}}}}`,
+	}
+	for name, content := range tests {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadRules(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRulesWithoutDefaultsStandAlone(t *testing.T) {
+	rules, err := ParseRules([]byte(`{"chains": {"hotstuff": {"required_fields": ["height"]}}}`))
+	if err != nil {
+		t.Fatalf("ParseRules: %v", err)
+	}
+	v := NewValidatorWithRules(abstraction.ChainTypeHotStuff, rules[abstraction.ChainTypeHotStuff])
+	if err := v.Validate(&abstraction.CanonicalMessage{Height: big.NewInt(1), Type: "anything"}); err != nil {
+		t.Fatalf("only the listed rules may apply: %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package validator
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"codec/message/abstraction"
+)
+
+// Invariant names a protocol-level rule a message stream must keep
+type Invariant string
+
+const (
+	// InvariantProposalFirst requires a proposal for a height and round before votes for a block there
+	InvariantProposalFirst Invariant = "proposal_first"
+	// InvariantHeightMonotonic forbids messages below the highest height already seen on the chain
+	InvariantHeightMonotonic Invariant = "height_monotonic"
+	// InvariantStepOrder forbids a signer from going back to an earlier round or step within a height
+	InvariantStepOrder Invariant = "step_order"
+)
+
+// Violation is one invariant broken by a message of the stream
+type Violation struct {
+	Invariant Invariant                     `json:"invariant"`
+	Index     int                           `json:"index"`
+	Message   string                        `json:"message"`
+	Msg       *abstraction.CanonicalMessage `json:"msg"`
+}
+
+// SequenceValidator checks an ordered stream of canonical messages against invariants that span messages.
+// Where Validator judges each message alone, it tells whether an experiment perturbed the protocol itself.
+// It is safe for concurrent use, though invariants are judged in the order messages are observed.
+type SequenceValidator struct {
+	mu         sync.Mutex
+	observed   int
+	highest    map[string]*big.Int
+	proposals  map[sequenceStep]bool
+	progress   map[sequenceSigner]stepPosition
+	violations []Violation
+}
+
+type sequenceStep struct {
+	chain, height, round string
+}
+
+type sequenceSigner struct {
+	chain, height, signer string
+}
+
+// stepPosition orders a signer's messages within a height by round, then phase
+type stepPosition struct {
+	round *big.Int
+	phase int
+	typ   abstraction.MsgType
+}
+
+// NewSequenceValidator creates a sequence validator with no history
+func NewSequenceValidator() *SequenceValidator {
+	return &SequenceValidator{
+		highest:   map[string]*big.Int{},
+		proposals: map[sequenceStep]bool{},
+		progress:  map[sequenceSigner]stepPosition{},
+	}
+}
+
+// Observe checks the next message of the stream and returns the violations it causes
+func (s *SequenceValidator) Observe(msg *abstraction.CanonicalMessage) []Violation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.observed
+	s.observed++
+	if msg == nil || msg.Height == nil {
+		return nil
+	}
+
+	var found []Violation
+	report := func(invariant Invariant, format string, args ...interface{}) {
+		found = append(found, Violation{Invariant: invariant, Index: index, Message: fmt.Sprintf(format, args...), Msg: msg})
+	}
+
+	if top, ok := s.highest[msg.ChainID]; !ok || msg.Height.Cmp(top) > 0 {
+		s.highest[msg.ChainID] = new(big.Int).Set(msg.Height)
+	} else if msg.Height.Cmp(top) < 0 {
+		report(InvariantHeightMonotonic, "height %s after height %s", msg.Height, top)
+	}
+
+	round := msg.Round
+	if round == nil {
+		round = msg.View
+	}
+	phase, phased := sequencePhase(msg.Type)
+	if round == nil || !phased {
+		s.violations = append(s.violations, found...)
+		return found
+	}
+
+	step := sequenceStep{chain: msg.ChainID, height: msg.Height.String(), round: round.String()}
+	if msg.Type == abstraction.MsgTypeProposal {
+		s.proposals[step] = true
+	} else if msg.BlockHash != "" && !s.proposals[step] {
+		report(InvariantProposalFirst, "%s for %s at height %s round %s before any proposal", msg.Type, msg.BlockHash, step.height, step.round)
+	}
+
+	signer := msg.Validator
+	if msg.Type == abstraction.MsgTypeProposal && msg.Proposer != "" {
+		signer = msg.Proposer
+	}
+	if signer != "" {
+		key := sequenceSigner{chain: msg.ChainID, height: step.height, signer: signer}
+		position := stepPosition{round: new(big.Int).Set(round), phase: phase, typ: msg.Type}
+		if last, ok := s.progress[key]; !ok || last.before(position) {
+			s.progress[key] = position
+		} else if position.before(last) {
+			report(InvariantStepOrder, "%s sent %s in round %s after %s in round %s", signer, msg.Type, round, last.typ, last.round)
+		}
+	}
+
+	s.violations = append(s.violations, found...)
+	return found
+}
+
+// Violations returns every violation found so far, in stream order
+func (s *SequenceValidator) Violations() []Violation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Violation(nil), s.violations...)
+}
+
+// Prune forgets proposals and signer progress below height, so a long-running validator stays bounded. The
+// highest height per chain and the violations found are kept.
+func (s *SequenceValidator) Prune(height *big.Int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	below := func(h string) bool {
+		n, ok := new(big.Int).SetString(h, 10)
+		return ok && n.Cmp(height) < 0
+	}
+	for step := range s.proposals {
+		if below(step.height) {
+			delete(s.proposals, step)
+		}
+	}
+	for key := range s.progress {
+		if below(key.height) {
+			delete(s.progress, key)
+		}
+	}
+}
+
+// before reports whether p comes earlier in a height than other
+func (p stepPosition) before(other stepPosition) bool {
+	if c := p.round.Cmp(other.round); c != 0 {
+		return c < 0
+	}
+	return p.phase < other.phase
+}
+
+// sequencePhase places a message type within a round: proposal, then the first vote, then the second
+func sequencePhase(msgType abstraction.MsgType) (int, bool) {
+	switch msgType {
+	case abstraction.MsgTypeProposal:
+		return 0, true
+	case abstraction.MsgTypePrevote, abstraction.MsgTypePrepare, abstraction.MsgTypeVote:
+		return 1, true
+	case abstraction.MsgTypePrecommit, abstraction.MsgTypeCommit:
+		return 2, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,80 @@
+package validator
+
+import (
+	"math/big"
+	"testing"
+
+	"codec/message/abstraction"
+)
+
+func sequenceMsg(typ abstraction.MsgType, height, round int64, signer, hash string) *abstraction.CanonicalMessage {
+	msg := &abstraction.CanonicalMessage{
+		ChainID:   "seq-chain",
+		Type:      typ,
+		Height:    big.NewInt(height),
+		Round:     big.NewInt(round),
+		BlockHash: hash,
+	}
+	if typ == abstraction.MsgTypeProposal {
+		msg.Proposer = signer
+	} else {
+		msg.Validator = signer
+	}
+	return msg
+}
+
+func TestSequenceValidatorAcceptsHonestRounds(t *testing.T) {
+	s := NewSequenceValidator()
+	stream := []*abstraction.CanonicalMessage{
+		sequenceMsg(abstraction.MsgTypeProposal, 1, 0, "v1", "A"),
+		sequenceMsg(abstraction.MsgTypePrevote, 1, 0, "v1", "A"),
+		sequenceMsg(abstraction.MsgTypePrevote, 1, 0, "v2", "A"),
+		sequenceMsg(abstraction.MsgTypePrecommit, 1, 0, "v2", ""),
+		// A nil prevote in a round whose proposal never arrived is how a timeout looks.
+		sequenceMsg(abstraction.MsgTypePrevote, 1, 1, "v2", ""),
+		sequenceMsg(abstraction.MsgTypeProposal, 2, 0, "v2", "B"),
+		sequenceMsg(abstraction.MsgTypePrecommit, 2, 0, "v1", "B"),
+	}
+	for _, msg := range stream {
+		if found := s.Observe(msg); len(found) != 0 {
+			t.Fatalf("honest stream reported %+v", found)
+		}
+	}
+}
+
+func TestSequenceValidatorReportsViolations(t *testing.T) {
+	s := NewSequenceValidator()
+	s.Observe(sequenceMsg(abstraction.MsgTypePrevote, 5, 0, "v1", "A"))
+	s.Observe(sequenceMsg(abstraction.MsgTypeProposal, 5, 0, "v2", "A"))
+	s.Observe(sequenceMsg(abstraction.MsgTypePrecommit, 5, 1, "v1", ""))
+	s.Observe(sequenceMsg(abstraction.MsgTypePrevote, 5, 0, "v1", ""))
+	s.Observe(sequenceMsg(abstraction.MsgTypeProposal, 4, 0, "v3", "Z"))
+
+	want := []struct {
+		invariant Invariant
+		index     int
+	}{
+		{InvariantProposalFirst, 0},
+		{InvariantStepOrder, 3},
+		{InvariantHeightMonotonic, 4},
+	}
+	got := s.Violations()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d violations, got %+v", len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Invariant != w.invariant || got[i].Index != w.index || got[i].Msg == nil || got[i].Message == "" {
+			t.Errorf("violation %d: expected %s at %d, got %+v", i, w.invariant, w.index, got[i])
+		}
+	}
+}
+
+func TestSequenceValidatorPrune(t *testing.T) {
+	s := NewSequenceValidator()
+	s.Observe(sequenceMsg(abstraction.MsgTypeProposal, 3, 0, "v1", "A"))
+	s.Observe(sequenceMsg(abstraction.MsgTypeProposal, 5, 0, "v2", "B"))
+	s.Prune(big.NewInt(4))
+	if found := s.Observe(sequenceMsg(abstraction.MsgTypePrevote, 3, 0, "v1", "A")); len(found) != 2 {
+		t.Fatalf("expected pruned proposals to be forgotten and the height to stay known, got %+v", found)
+	}
+}
@@ -0,0 +1,249 @@
+package validator
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"codec/cometbft/adapter"
+	"codec/message/abstraction"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SignatureScheme names the cryptography a chain signs consensus messages with
+type SignatureScheme string
+
+const (
+	// SchemeEd25519 checks CometBFT prevotes, precommits and proposals against their canonical sign bytes
+	SchemeEd25519 SignatureScheme = "ed25519"
+	// SchemeSecp256k1 checks the ECDSA commit seals of Besu and Kaia commits
+	SchemeSecp256k1 SignatureScheme = "secp256k1"
+)
+
+// kaiaMsgCommit is the Istanbul message code Kaia appends to the block hash before sealing it
+const kaiaMsgCommit = 2
+
+// SignatureRules defines how signatures are verified
+type SignatureRules struct {
+	Scheme SignatureScheme `json:"scheme"`
+	// Keys maps signer IDs, as they appear in canonical messages, to hex or base64 public keys. ed25519
+	// needs the key of every signer. secp256k1 recovers the signer from the seal, so only the IDs are used:
+	// when Keys is set, seals from addresses outside it are rejected.
+	Keys map[string]string `json:"keys,omitempty"`
+	// SealDigest returns the digest a commit seal signs. By default Kaia seals keccak256(block hash || 0x02)
+	// and other chains seal the block hash itself, which for Besu must be its committed-seal hash.
+	SealDigest func(*abstraction.CanonicalMessage) ([]byte, error) `json:"-"`
+}
+
+// verifySignatures checks the message's signatures when the rules ask for it
+func (v *Validator) verifySignatures(msg *abstraction.CanonicalMessage) error {
+	rules := v.rules.Signatures
+	if rules == nil {
+		return nil
+	}
+	switch rules.Scheme {
+	case SchemeEd25519:
+		return v.verifyEd25519(rules, msg)
+	case SchemeSecp256k1:
+		return v.verifySecp256k1(rules, msg)
+	default:
+		return &abstraction.MessageValidationError{
+			Field:   "signature",
+			Message: fmt.Sprintf("unsupported signature scheme: %s", rules.Scheme),
+			Code:    "INVALID_SIGNATURE",
+		}
+	}
+}
+
+// verifyEd25519 checks a CometBFT vote or proposal signature with the signer's key
+func (v *Validator) verifyEd25519(rules *SignatureRules, msg *abstraction.CanonicalMessage) error {
+	signer := msg.Validator
+	switch msg.Type {
+	case abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit:
+	case abstraction.MsgTypeProposal:
+		if msg.Proposer != "" {
+			signer = msg.Proposer
+		}
+	default:
+		return nil
+	}
+
+	if msg.Signature == "" {
+		return signatureError("MISSING_FIELD", "signature is required")
+	}
+	if signer == "" {
+		return signatureError("MISSING_FIELD", "signer is required to verify the signature")
+	}
+	encodedKey, ok := lookupKey(rules.Keys, signer)
+	if !ok {
+		return signatureError("INVALID_SIGNATURE", fmt.Sprintf("no public key for signer %s", signer))
+	}
+	pubKey, ok := decodeSized(encodedKey, ed25519.PublicKeySize)
+	if !ok {
+		return signatureError("INVALID_SIGNATURE", fmt.Sprintf("public key of signer %s is not an ed25519 key", signer))
+	}
+	sig, ok := decodeSized(msg.Signature, ed25519.SignatureSize)
+	if !ok {
+		return signatureError("INVALID_SIGNATURE", "signature is not an ed25519 signature")
+	}
+
+	signBytes, err := adapter.SignBytes(msg.ChainID, msg)
+	if err != nil {
+		return signatureError("INVALID_SIGNATURE", fmt.Sprintf("cannot rebuild sign bytes: %v", err))
+	}
+	if !ed25519.Verify(pubKey, signBytes, sig) {
+		return signatureError("INVALID_SIGNATURE", fmt.Sprintf("signature does not verify for signer %s", signer))
+	}
+	return nil
+}
+
+// verifySecp256k1 recovers the signers of a commit's seal and of any aggregated commit seals
+func (v *Validator) verifySecp256k1(rules *SignatureRules, msg *abstraction.CanonicalMessage) error {
+	commit := isCommit(msg)
+	if !commit && len(msg.CommitSeals) == 0 {
+		return nil
+	}
+
+	digest, err := v.sealDigest(rules, msg)
+	if err != nil {
+		return signatureError("INVALID_SIGNATURE", fmt.Sprintf("cannot compute the seal digest: %v", err))
+	}
+
+	if commit {
+		if msg.Signature == "" {
+			return signatureError("MISSING_FIELD", "signature is required")
+		}
+		if !common.IsHexAddress(msg.Validator) {
+			return signatureError("INVALID_SIGNATURE", fmt.Sprintf("validator %q is not an address", msg.Validator))
+		}
+		signer, err := recoverSigner(digest, msg.Signature)
+		if err != nil {
+			return signatureError("INVALID_SIGNATURE", err.Error())
+		}
+		if signer != common.HexToAddress(msg.Validator) {
+			return signatureError("INVALID_SIGNATURE", fmt.Sprintf("commit seal was signed by %s, not %s", signer.Hex(), msg.Validator))
+		}
+		if !allowedSigner(rules.Keys, signer) {
+			return signatureError("INVALID_SIGNATURE", fmt.Sprintf("commit seal signer %s is not a known validator", signer.Hex()))
+		}
+	}
+
+	seen := make(map[common.Address]bool, len(msg.CommitSeals))
+	for i, seal := range msg.CommitSeals {
+		signer, err := recoverSigner(digest, seal)
+		if err != nil {
+			return &abstraction.MessageValidationError{
+				Field:   "commit_seals",
+				Message: fmt.Sprintf("commit seal %d: %v", i, err),
+				Code:    "INVALID_SIGNATURE",
+			}
+		}
+		if seen[signer] || !allowedSigner(rules.Keys, signer) {
+			return &abstraction.MessageValidationError{
+				Field:   "commit_seals",
+				Message: fmt.Sprintf("commit seal %d from %s is a duplicate or from an unknown validator", i, signer.Hex()),
+				Code:    "INVALID_SIGNATURE",
+			}
+		}
+		seen[signer] = true
+	}
+	return nil
+}
+
+// sealDigest returns the digest commit seals sign for the message's block
+func (v *Validator) sealDigest(rules *SignatureRules, msg *abstraction.CanonicalMessage) ([]byte, error) {
+	if rules.SealDigest != nil {
+		return rules.SealDigest(msg)
+	}
+	hash, ok := decodeSized(msg.BlockHash, common.HashLength)
+	if !ok {
+		return nil, fmt.Errorf("block_hash %q is not a 32-byte hash", msg.BlockHash)
+	}
+	if v.chainType == abstraction.ChainTypeKaia {
+		return crypto.Keccak256(hash, []byte{kaiaMsgCommit}), nil
+	}
+	return hash, nil
+}
+
+// isCommit reports whether the message's signature is a commit seal
+func isCommit(msg *abstraction.CanonicalMessage) bool {
+	if msg.Type == abstraction.MsgTypeCommit {
+		return true
+	}
+	if kind, _ := msg.Extensions.GetString("kaia_message_type"); kind == "Commit" {
+		return true
+	}
+	kind, _ := msg.Extensions.GetString("ibft_type")
+	return kind == "Commit"
+}
+
+// recoverSigner returns the address that produced a 65-byte recoverable signature over digest
+func recoverSigner(digest []byte, signature string) (common.Address, error) {
+	sig, ok := decodeSized(signature, crypto.SignatureLength)
+	if !ok {
+		return common.Address{}, fmt.Errorf("seal is not a %d-byte secp256k1 signature", crypto.SignatureLength)
+	}
+	sig = append([]byte(nil), sig...)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pubKey, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("cannot recover seal signer: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// allowedSigner reports whether addr is one of the configured signers, or whether no signers are configured
+func allowedSigner(keys map[string]string, addr common.Address) bool {
+	if len(keys) == 0 {
+		return true
+	}
+	for id := range keys {
+		if common.IsHexAddress(id) && common.HexToAddress(id) == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupKey finds the signer's key, ignoring case and a 0x prefix on hex IDs
+func lookupKey(keys map[string]string, signer string) (string, bool) {
+	if key, ok := keys[signer]; ok {
+		return key, true
+	}
+	want := normalizeID(signer)
+	for id, key := range keys {
+		if normalizeID(id) == want {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// decodeSized decodes a 0x-hex, base64 or hex value, keeping the first decoding of the expected length
+func decodeSized(value string, size int) ([]byte, bool) {
+	if strings.HasPrefix(value, "0x") || strings.HasPrefix(value, "0X") {
+		data, err := hex.DecodeString(value[2:])
+		return data, err == nil && len(data) == size
+	}
+	if data, err := base64.StdEncoding.DecodeString(value); err == nil && len(data) == size {
+		return data, true
+	}
+	if data, err := hex.DecodeString(value); err == nil && len(data) == size {
+		return data, true
+	}
+	return nil, false
+}
+
+func signatureError(code, message string) error {
+	return &abstraction.MessageValidationError{
+		Field:   "signature",
+		Message: message,
+		Code:    code,
+	}
+}
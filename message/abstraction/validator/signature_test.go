@@ -0,0 +1,128 @@
+package validator
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"codec/cometbft/adapter"
+	"codec/message/abstraction"
+
+	cmted25519 "github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func signatureCode(err error) string {
+	var validationErr *abstraction.MessageValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Code
+	}
+	return ""
+}
+
+func TestEd25519VerificationDetectsForgedVotes(t *testing.T) {
+	privKey := cmted25519.GenPrivKey()
+	signer := adapter.NewPrivValSigner("sig-chain", privKey)
+	vote := &abstraction.CanonicalMessage{
+		ChainID:   "sig-chain",
+		Height:    big.NewInt(7),
+		Round:     big.NewInt(0),
+		Timestamp: time.Now().UTC(),
+		Type:      abstraction.MsgTypePrevote,
+		BlockHash: "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+		Extensions: map[string]interface{}{
+			"part_set_header": adapter.PartSetHeader{Total: 1, Hash: make([]byte, 32)},
+		},
+	}
+	if err := signer.Sign(vote); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	rules := DefaultRules(abstraction.ChainTypeCometBFT)
+	rules.Signatures = &SignatureRules{
+		Scheme: SchemeEd25519,
+		Keys:   map[string]string{signer.Address(): hex.EncodeToString(privKey.PubKey().Bytes())},
+	}
+	v := NewValidatorWithRules(abstraction.ChainTypeCometBFT, rules)
+	if err := v.Validate(vote); err != nil {
+		t.Fatalf("signed vote rejected: %v", err)
+	}
+
+	forged := *vote
+	forged.BlockHash = "BBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB"
+	if err := v.Validate(&forged); signatureCode(err) != "INVALID_SIGNATURE" {
+		t.Fatalf("expected a forged block hash to fail verification, got %v", err)
+	}
+
+	unknown := *vote
+	unknown.Validator = "0000000000000000000000000000000000000000"
+	if err := v.Validate(&unknown); signatureCode(err) != "INVALID_SIGNATURE" {
+		t.Fatalf("expected a signer without a key to be rejected, got %v", err)
+	}
+
+	if err := NewValidator(abstraction.ChainTypeCometBFT).Validate(&forged); err != nil {
+		t.Fatalf("signatures must not be checked unless enabled: %v", err)
+	}
+}
+
+func TestSecp256k1VerificationChecksCommitSeals(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	blockHash := crypto.Keccak256([]byte("block"))
+	seal := func(k *ecdsa.PrivateKey, digest []byte) string {
+		sig, err := crypto.Sign(digest, k)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		return "0x" + hex.EncodeToString(sig)
+	}
+
+	besuRules := DefaultRules(abstraction.ChainTypeHyperledger)
+	besuRules.Signatures = &SignatureRules{Scheme: SchemeSecp256k1}
+	besu := NewValidatorWithRules(abstraction.ChainTypeHyperledger, besuRules)
+	commit := &abstraction.CanonicalMessage{
+		ChainID:   "besu",
+		Height:    big.NewInt(3),
+		Round:     big.NewInt(0),
+		Timestamp: time.Now(),
+		Type:      abstraction.MsgTypeCommit,
+		BlockHash: "0x" + hex.EncodeToString(blockHash),
+		Validator: address.Hex(),
+		Signature: seal(key, blockHash),
+	}
+	if err := besu.Validate(commit); err != nil {
+		t.Fatalf("valid Besu commit seal rejected: %v", err)
+	}
+	commit.Signature = seal(other, blockHash)
+	if err := besu.Validate(commit); signatureCode(err) != "INVALID_SIGNATURE" {
+		t.Fatalf("expected a seal by another key to be rejected, got %v", err)
+	}
+
+	kaiaDigest := crypto.Keccak256(blockHash, []byte{kaiaMsgCommit})
+	kaiaRules := DefaultRules(abstraction.ChainTypeKaia)
+	kaiaRules.Signatures = &SignatureRules{
+		Scheme: SchemeSecp256k1,
+		Keys:   map[string]string{address.Hex(): ""},
+	}
+	kaia := NewValidatorWithRules(abstraction.ChainTypeKaia, kaiaRules)
+	block := &abstraction.CanonicalMessage{
+		ChainID:     "kaia",
+		Height:      big.NewInt(3),
+		Round:       big.NewInt(0),
+		Timestamp:   time.Now(),
+		Type:        abstraction.MsgTypeBlock,
+		BlockHash:   hex.EncodeToString(blockHash),
+		CommitSeals: []string{seal(key, kaiaDigest)},
+	}
+	if err := kaia.Validate(block); err != nil {
+		t.Fatalf("valid Kaia commit seals rejected: %v", err)
+	}
+	block.CommitSeals = append(block.CommitSeals, seal(other, kaiaDigest))
+	if err := kaia.Validate(block); signatureCode(err) != "INVALID_SIGNATURE" {
+		t.Fatalf("expected a seal from outside the validator set to be rejected, got %v", err)
+	}
+}
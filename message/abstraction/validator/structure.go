@@ -0,0 +1,111 @@
+package validator
+
+import (
+	"fmt"
+	"math/big"
+
+	"codec/message/abstraction"
+)
+
+// StructureRules checks the view change entries and commit seals IBFT-family chains attach to messages
+type StructureRules struct {
+	// ValidatorCount is the size of the validator set. When set, no message may carry more view changes or
+	// commit seals than validators, and seals must reach the IBFT quorum of ceil(2n/3).
+	ValidatorCount int `json:"validator_count,omitempty"`
+}
+
+// Quorum returns the number of commit seals that finalize a block with the configured validator count
+func (s StructureRules) Quorum() int {
+	return (2*s.ValidatorCount + 2) / 3
+}
+
+// validateStructure checks view change entries and commit seals when the rules ask for it
+func (v *Validator) validateStructure(msg *abstraction.CanonicalMessage) error {
+	rules := v.rules.Structure
+	if rules == nil {
+		return nil
+	}
+	if err := rules.checkViewChanges(msg); err != nil {
+		return err
+	}
+	return rules.checkCommitSeals(msg)
+}
+
+// checkViewChanges requires every entry to name a validator and a view, views to never go backwards or past
+// the message's own view, heights to match the message, and each validator to appear once per view
+func (s StructureRules) checkViewChanges(msg *abstraction.CanonicalMessage) error {
+	if len(msg.ViewChanges) == 0 {
+		return nil
+	}
+	if s.ValidatorCount > 0 && len(msg.ViewChanges) > s.ValidatorCount {
+		return structureError("view_changes", fmt.Sprintf("%d view changes from %d validators", len(msg.ViewChanges), s.ValidatorCount))
+	}
+
+	target := msg.View
+	if target == nil {
+		target = msg.Round
+	}
+	seen := make(map[string]bool, len(msg.ViewChanges))
+	var previous *big.Int
+	for i, entry := range msg.ViewChanges {
+		where := fmt.Sprintf("view_changes[%d]", i)
+		if entry.Validator == "" {
+			return structureError(where, "validator is required")
+		}
+		if entry.View == nil || entry.View.Sign() < 0 {
+			return structureError(where, "view must be a non-negative number")
+		}
+		if previous != nil && entry.View.Cmp(previous) < 0 {
+			return structureError(where, fmt.Sprintf("view %s follows view %s", entry.View, previous))
+		}
+		if target != nil && entry.View.Cmp(target) > 0 {
+			return structureError(where, fmt.Sprintf("view %s is after the message's view %s", entry.View, target))
+		}
+		if entry.Height != nil && msg.Height != nil && entry.Height.Cmp(msg.Height) != 0 {
+			return structureError(where, fmt.Sprintf("height %s does not match the message's height %s", entry.Height, msg.Height))
+		}
+		key := normalizeID(entry.Validator) + "@" + entry.View.String()
+		if seen[key] {
+			return structureError(where, fmt.Sprintf("validator %s already sent a view change for view %s", entry.Validator, entry.View))
+		}
+		seen[key] = true
+		previous = entry.View
+	}
+	return nil
+}
+
+// checkCommitSeals rejects empty and repeated seals and, with a validator count, seal counts outside
+// [quorum, validators]
+func (s StructureRules) checkCommitSeals(msg *abstraction.CanonicalMessage) error {
+	if len(msg.CommitSeals) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(msg.CommitSeals))
+	for i, seal := range msg.CommitSeals {
+		if seal == "" {
+			return structureError(fmt.Sprintf("commit_seals[%d]", i), "seal is empty")
+		}
+		if seen[seal] {
+			return structureError(fmt.Sprintf("commit_seals[%d]", i), "seal is repeated")
+		}
+		seen[seal] = true
+	}
+	if s.ValidatorCount == 0 {
+		return nil
+	}
+	if len(msg.CommitSeals) > s.ValidatorCount {
+		return structureError("commit_seals", fmt.Sprintf("%d seals from %d validators", len(msg.CommitSeals), s.ValidatorCount))
+	}
+	if len(msg.CommitSeals) < s.Quorum() {
+		return structureError("commit_seals", fmt.Sprintf("%d seals are below the quorum of %d", len(msg.CommitSeals), s.Quorum()))
+	}
+	return nil
+}
+
+func structureError(field, message string) error {
+	return &abstraction.MessageValidationError{
+		Field:   field,
+		Message: message,
+		Code:    "INVALID_STRUCTURE",
+	}
+}
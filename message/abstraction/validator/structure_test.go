@@ -0,0 +1,93 @@
+package validator
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+func structureField(err error) string {
+	var validationErr *abstraction.MessageValidationError
+	if errors.As(err, &validationErr) && validationErr.Code == "INVALID_STRUCTURE" {
+		return validationErr.Field
+	}
+	return ""
+}
+
+func TestStructureChecksViewChanges(t *testing.T) {
+	v := NewValidator(abstraction.ChainTypeHyperledger)
+	entry := func(view int64, validator string) abstraction.ViewChangeEntry {
+		return abstraction.ViewChangeEntry{View: big.NewInt(view), Height: big.NewInt(8), Validator: validator, Signature: "sig"}
+	}
+	msg := &abstraction.CanonicalMessage{
+		ChainID:     "besu",
+		Height:      big.NewInt(8),
+		Round:       big.NewInt(2),
+		Timestamp:   time.Now(),
+		Type:        abstraction.MsgTypeNewView,
+		ViewChanges: []abstraction.ViewChangeEntry{entry(1, "v1"), entry(2, "v2"), entry(2, "v3")},
+	}
+	if err := v.Validate(msg); err != nil {
+		t.Fatalf("valid view changes rejected: %v", err)
+	}
+
+	tests := map[string][]abstraction.ViewChangeEntry{
+		"view_changes[1]": {entry(2, "v1"), entry(1, "v2")},
+		"view_changes[0]": {entry(3, "v1")},
+		"view_changes[2]": {entry(1, "v1"), entry(2, "v2"), entry(2, "V2")},
+	}
+	for field, entries := range tests {
+		msg.ViewChanges = entries
+		if err := v.Validate(msg); structureField(err) != field {
+			t.Errorf("%v: expected an error at %s, got %v", entries, field, err)
+		}
+	}
+
+	msg.ViewChanges = []abstraction.ViewChangeEntry{entry(1, "")}
+	if err := v.Validate(msg); structureField(err) != "view_changes[0]" {
+		t.Errorf("expected a missing validator to be rejected, got %v", err)
+	}
+	wrongHeight := entry(1, "v1")
+	wrongHeight.Height = big.NewInt(7)
+	msg.ViewChanges = []abstraction.ViewChangeEntry{wrongHeight}
+	if err := v.Validate(msg); structureField(err) != "view_changes[0]" {
+		t.Errorf("expected an entry for another height to be rejected, got %v", err)
+	}
+}
+
+func TestStructureChecksCommitSealsAgainstValidatorCount(t *testing.T) {
+	rules := DefaultRules(abstraction.ChainTypeKaia)
+	rules.Structure = &StructureRules{ValidatorCount: 4}
+	v := NewValidatorWithRules(abstraction.ChainTypeKaia, rules)
+	msg := &abstraction.CanonicalMessage{
+		ChainID:     "kaia",
+		Height:      big.NewInt(8),
+		Round:       big.NewInt(0),
+		Timestamp:   time.Now(),
+		Type:        abstraction.MsgTypeBlock,
+		CommitSeals: []string{"s1", "s2", "s3"},
+	}
+	if err := v.Validate(msg); err != nil {
+		t.Fatalf("a quorum of seals rejected: %v", err)
+	}
+
+	for _, seals := range [][]string{{"s1", "s2"}, {"s1", "s2", "s3", "s4", "s5"}, {"s1", "s2", "s2"}, {"s1", "", "s3"}} {
+		msg.CommitSeals = seals
+		if err := v.Validate(msg); structureField(err) == "" {
+			t.Errorf("%v: expected a structure error, got %v", seals, err)
+		}
+	}
+
+	if q := (StructureRules{ValidatorCount: 7}).Quorum(); q != 5 {
+		t.Fatalf("expected a quorum of 5 for 7 validators, got %d", q)
+	}
+	if err := NewValidator(abstraction.ChainTypeCometBFT).Validate(&abstraction.CanonicalMessage{
+		ChainID: "c", Height: big.NewInt(1), Round: big.NewInt(0), Timestamp: time.Now(), Type: abstraction.MsgTypeBlock,
+		CommitSeals: []string{"s", "s"},
+	}); err != nil {
+		t.Fatalf("chains outside the IBFT family are not checked: %v", err)
+	}
+}
@@ -3,7 +3,6 @@ package validator
 import (
 	"fmt"
 	"math/big"
-	"time"
 
 	"codec/message/abstraction"
 )
@@ -20,6 +19,10 @@ type ValidationRules struct {
 	FieldTypes     map[string]string      `json:"field_types"`
 	Constraints    map[string]interface{} `json:"constraints"`
 	CustomRules    []CustomValidationRule `json:"custom_rules"`
+	// Structure checks view change entries and commit seals; nil leaves them unchecked.
+	Structure *StructureRules `json:"structure,omitempty"`
+	// Signatures turns on cryptographic signature checks; nil leaves them off.
+	Signatures *SignatureRules `json:"signatures,omitempty"`
 }
 
 // CustomValidationRule defines a custom validation function
@@ -37,6 +40,19 @@ func NewValidator(chainType abstraction.ChainType) *Validator {
 	}
 }
 
+// NewValidatorWithRules creates a validator that enforces rules instead of the chain's defaults
+func NewValidatorWithRules(chainType abstraction.ChainType, rules ValidationRules) *Validator {
+	return &Validator{
+		chainType: chainType,
+		rules:     rules,
+	}
+}
+
+// DefaultRules returns the rules NewValidator uses for the chain type
+func DefaultRules(chainType abstraction.ChainType) ValidationRules {
+	return getDefaultRules(chainType)
+}
+
 // Validate validates a canonical message against chain-specific rules
 func (v *Validator) Validate(msg *abstraction.CanonicalMessage) error {
 	if msg == nil {
@@ -62,11 +78,21 @@ func (v *Validator) Validate(msg *abstraction.CanonicalMessage) error {
 		return err
 	}
 
+	// Validate view changes and commit seals
+	if err := v.validateStructure(msg); err != nil {
+		return err
+	}
+
 	// Run custom validation rules
 	if err := v.runCustomRules(msg); err != nil {
 		return err
 	}
 
+	// Verify signatures
+	if err := v.verifySignatures(msg); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -157,6 +183,14 @@ func (v *Validator) checkFieldPresent(msg *abstraction.CanonicalMessage, field s
 				Code:    "MISSING_FIELD",
 			}
 		}
+	case "view":
+		if msg.View == nil {
+			return &abstraction.MessageValidationError{
+				Field:   field,
+				Message: "view is required",
+				Code:    "MISSING_FIELD",
+			}
+		}
 	case "proposer":
 		if msg.Proposer == "" {
 			return &abstraction.MessageValidationError{
@@ -264,7 +298,7 @@ func (v *Validator) checkConstraint(msg *abstraction.CanonicalMessage, field str
 		if !msg.Timestamp.IsZero() {
 			if maxAge, ok := constraint.(map[string]interface{})["max_age_seconds"]; ok {
 				if maxAgeVal, ok := maxAge.(float64); ok {
-					age := time.Since(msg.Timestamp).Seconds()
+					age := abstraction.Now().Sub(msg.Timestamp).Seconds()
 					if age > maxAgeVal {
 						return &abstraction.MessageValidationError{
 							Field:   field,
@@ -311,7 +345,7 @@ func getDefaultRules(chainType abstraction.ChainType) ValidationRules {
 				},
 			},
 		}
-	case abstraction.ChainTypeHyperledger:
+	case abstraction.ChainTypeHyperledger, abstraction.ChainTypeFabric:
 		return ValidationRules{
 			RequiredFields: []string{"chain_id", "height", "timestamp", "type"},
 			FieldTypes: map[string]string{
@@ -335,6 +369,7 @@ func getDefaultRules(chainType abstraction.ChainType) ValidationRules {
 					Function:    validateHyperledgerMessageType,
 				},
 			},
+			Structure: &StructureRules{},
 		}
 	case abstraction.ChainTypeKaia:
 		return ValidationRules{
@@ -364,8 +399,140 @@ func getDefaultRules(chainType abstraction.ChainType) ValidationRules {
 					Function:    validateKaiaMessageType,
 				},
 			},
+			Structure: &StructureRules{},
+		}
+	case abstraction.ChainTypeFabricRaft:
+		return ValidationRules{
+			RequiredFields: []string{"chain_id", "height", "view", "timestamp", "type"},
+			FieldTypes: map[string]string{
+				"chain_id":  "string",
+				"height":    "bigint",
+				"view":      "bigint",
+				"timestamp": "time",
+				"type":      "string",
+			},
+			Constraints: map[string]interface{}{
+				"height": map[string]interface{}{
+					"min": float64(0),
+				},
+				"timestamp": map[string]interface{}{
+					"max_age_seconds": float64(7200), // 2 hours
+				},
+			},
+			CustomRules: []CustomValidationRule{
+				{
+					Name:        "raft_message_type",
+					Description: "Validate Fabric Raft message types",
+					Function:    validateRaftMessageType,
+				},
+			},
+		}
+	case abstraction.ChainTypeHotStuff:
+		return ValidationRules{
+			RequiredFields: []string{"chain_id", "height", "view", "timestamp", "type"},
+			FieldTypes: map[string]string{
+				"chain_id":  "string",
+				"height":    "bigint",
+				"view":      "bigint",
+				"timestamp": "time",
+				"type":      "string",
+			},
+			Constraints: map[string]interface{}{
+				"height": map[string]interface{}{
+					"min": float64(0),
+				},
+				"timestamp": map[string]interface{}{
+					"max_age_seconds": float64(3600), // 1 hour
+				},
+			},
+			CustomRules: []CustomValidationRule{
+				{
+					Name:        "hotstuff_message_type",
+					Description: "Validate HotStuff-specific message types",
+					Function:    validateHotStuffMessageType,
+				},
+			},
+		}
+	case abstraction.ChainTypeEthereum:
+		return ValidationRules{
+			RequiredFields: []string{"chain_id", "height", "timestamp", "type"},
+			FieldTypes: map[string]string{
+				"chain_id":  "string",
+				"height":    "bigint",
+				"timestamp": "time",
+				"type":      "string",
+			},
+			Constraints: map[string]interface{}{
+				"height": map[string]interface{}{
+					"min": float64(0),
+				},
+				"timestamp": map[string]interface{}{
+					"max_age_seconds": float64(3600), // 1 hour
+				},
+			},
+			CustomRules: []CustomValidationRule{
+				{
+					Name:        "ethereum_message_type",
+					Description: "Validate beacon-chain message types",
+					Function:    validateEthereumMessageType,
+				},
+			},
+		}
+	case abstraction.ChainTypeAptos:
+		return ValidationRules{
+			RequiredFields: []string{"chain_id", "height", "view", "timestamp", "type"},
+			FieldTypes: map[string]string{
+				"chain_id":  "string",
+				"height":    "bigint",
+				"view":      "bigint",
+				"timestamp": "time",
+				"type":      "string",
+			},
+			Constraints: map[string]interface{}{
+				"height": map[string]interface{}{
+					"min": float64(0),
+				},
+				"timestamp": map[string]interface{}{
+					"max_age_seconds": float64(3600), // 1 hour
+				},
+			},
+			CustomRules: []CustomValidationRule{
+				{
+					Name:        "aptos_message_type",
+					Description: "Validate AptosBFT message types",
+					Function:    validateAptosMessageType,
+				},
+			},
+		}
+	case abstraction.ChainTypeAvalanche:
+		return ValidationRules{
+			RequiredFields: []string{"chain_id", "height", "timestamp", "type"},
+			FieldTypes: map[string]string{
+				"chain_id":  "string",
+				"height":    "bigint",
+				"timestamp": "time",
+				"type":      "string",
+			},
+			Constraints: map[string]interface{}{
+				"height": map[string]interface{}{
+					"min": float64(0),
+				},
+				"timestamp": map[string]interface{}{
+					"max_age_seconds": float64(3600), // 1 hour
+				},
+			},
+			CustomRules: []CustomValidationRule{
+				{
+					Name:        "avalanche_message_type",
+					Description: "Validate Snowman message types",
+					Function:    validateAvalancheMessageType,
+				},
+			},
 		}
 	default:
+		if info, ok := abstraction.LookupChainType(chainType); ok {
+			return registeredRules(info)
+		}
 		return ValidationRules{
 			RequiredFields: []string{"chain_id", "height", "timestamp", "type"},
 			FieldTypes: map[string]string{
@@ -378,6 +545,45 @@ func getDefaultRules(chainType abstraction.ChainType) ValidationRules {
 	}
 }
 
+// registeredRules builds the rules for a chain type added with abstraction.RegisterChainType.
+func registeredRules(info abstraction.ChainTypeInfo) ValidationRules {
+	rules := ValidationRules{
+		RequiredFields: []string{"chain_id", "height", "timestamp", "type"},
+		FieldTypes: map[string]string{
+			"chain_id":  "string",
+			"height":    "bigint",
+			"timestamp": "time",
+			"type":      "string",
+		},
+	}
+	if info.RequiresRound {
+		rules.RequiredFields = append(rules.RequiredFields, "round")
+		rules.FieldTypes["round"] = "bigint"
+	}
+	if info.RequiresView {
+		rules.RequiredFields = append(rules.RequiredFields, "view")
+		rules.FieldTypes["view"] = "bigint"
+	}
+
+	validTypes := make(map[abstraction.MsgType]bool, len(info.MsgTypes))
+	for _, msgType := range info.MsgTypes {
+		validTypes[msgType] = true
+	}
+	rules.CustomRules = []CustomValidationRule{
+		{
+			Name:        string(info.Type) + "_message_type",
+			Description: fmt.Sprintf("Validate %s message types", info.Type),
+			Function: func(msg *abstraction.CanonicalMessage) error {
+				if validTypes[msg.Type] || len(validTypes) == 0 && abstraction.IsKnownMsgType(msg.Type) {
+					return nil
+				}
+				return fmt.Errorf("unsupported %s message type: %s", info.Type, msg.Type)
+			},
+		},
+	}
+	return rules
+}
+
 // Chain-specific validation functions
 func validateCometBFTMessageType(msg *abstraction.CanonicalMessage) error {
 	validTypes := map[abstraction.MsgType]bool{
@@ -408,7 +614,81 @@ func validateHyperledgerMessageType(msg *abstraction.CanonicalMessage) error {
 	return nil
 }
 
+func validateRaftMessageType(msg *abstraction.CanonicalMessage) error {
+	validTypes := map[abstraction.MsgType]bool{
+		abstraction.MsgTypeProposal:   true,
+		abstraction.MsgTypeCommit:     true,
+		abstraction.MsgTypeViewChange: true,
+		abstraction.MsgTypeVote:       true,
+		abstraction.MsgTypeBlock:      true,
+	}
+
+	if !validTypes[msg.Type] {
+		return fmt.Errorf("unsupported Fabric Raft message type: %s", msg.Type)
+	}
+	return nil
+}
+
 func validateKaiaMessageType(msg *abstraction.CanonicalMessage) error {
+	// Vote and block are kept for messages decoded before Prepare, Commit, and RoundChange had their own types.
+	validTypes := map[abstraction.MsgType]bool{
+		abstraction.MsgTypeProposal:    true,
+		abstraction.MsgTypePrepare:     true,
+		abstraction.MsgTypeCommit:      true,
+		abstraction.MsgTypeRoundChange: true,
+		abstraction.MsgTypeVote:        true,
+		abstraction.MsgTypeBlock:       true,
+	}
+
+	if !validTypes[msg.Type] {
+		return fmt.Errorf("unsupported Kaia message type: %s", msg.Type)
+	}
+	return nil
+}
+
+func validateHotStuffMessageType(msg *abstraction.CanonicalMessage) error {
+	validTypes := map[abstraction.MsgType]bool{
+		abstraction.MsgTypeProposal:  true,
+		abstraction.MsgTypePrepare:   true,
+		abstraction.MsgTypePrecommit: true,
+		abstraction.MsgTypeCommit:    true,
+		abstraction.MsgTypeVote:      true,
+		abstraction.MsgTypeNewView:   true,
+	}
+
+	if !validTypes[msg.Type] {
+		return fmt.Errorf("unsupported HotStuff message type: %s", msg.Type)
+	}
+	return nil
+}
+
+func validateEthereumMessageType(msg *abstraction.CanonicalMessage) error {
+	validTypes := map[abstraction.MsgType]bool{
+		abstraction.MsgTypeProposal: true,
+		abstraction.MsgTypeVote:     true,
+	}
+
+	if !validTypes[msg.Type] {
+		return fmt.Errorf("unsupported Ethereum message type: %s", msg.Type)
+	}
+	return nil
+}
+
+func validateAptosMessageType(msg *abstraction.CanonicalMessage) error {
+	validTypes := map[abstraction.MsgType]bool{
+		abstraction.MsgTypeProposal:   true,
+		abstraction.MsgTypeVote:       true,
+		abstraction.MsgTypeViewChange: true,
+		abstraction.MsgTypeCommit:     true,
+	}
+
+	if !validTypes[msg.Type] {
+		return fmt.Errorf("unsupported Aptos message type: %s", msg.Type)
+	}
+	return nil
+}
+
+func validateAvalancheMessageType(msg *abstraction.CanonicalMessage) error {
 	validTypes := map[abstraction.MsgType]bool{
 		abstraction.MsgTypeProposal: true,
 		abstraction.MsgTypeVote:     true,
@@ -416,7 +696,7 @@ func validateKaiaMessageType(msg *abstraction.CanonicalMessage) error {
 	}
 
 	if !validTypes[msg.Type] {
-		return fmt.Errorf("unsupported Kaia message type: %s", msg.Type)
+		return fmt.Errorf("unsupported Avalanche message type: %s", msg.Type)
 	}
 	return nil
 }
@@ -0,0 +1,131 @@
+package abstraction
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentVersion is the CanonicalMessage schema version this package writes. Version 0 is the unversioned
+// layout written before the field existed; it is the same as version 1.
+const CurrentVersion = 1
+
+// Migration upgrades a message's JSON document from version From to From+1. Apply is nil for a step that
+// only bumps the version, such as one that adds an optional field.
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(doc map[string]interface{}) error
+}
+
+// migrations holds one step per version, in order: migrations[i].From == i.
+var migrations = []Migration{
+	{From: 0, Description: "version 1 gives the unversioned layout a number"},
+}
+
+// MarshalJSON writes the message with its schema version. A message built in memory without one is written
+// as CurrentVersion.
+func (m CanonicalMessage) MarshalJSON() ([]byte, error) {
+	type plain CanonicalMessage
+	if m.Version == 0 {
+		m.Version = CurrentVersion
+	}
+	return json.Marshal(plain(m))
+}
+
+// UnmarshalJSON reads a message written by any schema version up to CurrentVersion and migrates it to
+// CurrentVersion. Messages from a newer version are read as they are; fields this version does not know are
+// dropped.
+func (m *CanonicalMessage) UnmarshalJSON(data []byte) error {
+	type plain CanonicalMessage
+	if err := json.Unmarshal(data, (*plain)(m)); err != nil {
+		return err
+	}
+	if m.Version >= CurrentVersion {
+		return nil
+	}
+	if !documentChanges(migrations, m.Version, CurrentVersion) {
+		m.Version = CurrentVersion
+		return nil
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if err := migrateDocument(doc, migrations, m.Version, CurrentVersion); err != nil {
+		return err
+	}
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	*m = CanonicalMessage{}
+	return json.Unmarshal(migrated, (*plain)(m))
+}
+
+// Migrate returns a copy of msg upgraded to targetVersion. Messages are migrated when they are decoded, so
+// this is only needed for messages built from an older layout by other means. Downgrades are not supported.
+func Migrate(msg *CanonicalMessage, targetVersion int) (*CanonicalMessage, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("canonical message is nil")
+	}
+	if targetVersion < msg.Version || targetVersion > CurrentVersion {
+		return nil, fmt.Errorf("cannot migrate a version %d message to version %d (current version is %d)",
+			msg.Version, targetVersion, CurrentVersion)
+	}
+
+	if !documentChanges(migrations, msg.Version, targetVersion) {
+		migrated := *msg
+		migrated.Version = targetVersion
+		return &migrated, nil
+	}
+
+	type plain CanonicalMessage
+	data, err := json.Marshal((*plain)(msg))
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if err := migrateDocument(doc, migrations, msg.Version, targetVersion); err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(doc); err != nil {
+		return nil, err
+	}
+	migrated := &CanonicalMessage{}
+	if err := json.Unmarshal(data, (*plain)(migrated)); err != nil {
+		return nil, err
+	}
+	migrated.Version = targetVersion
+	migrated.RawPayload = msg.RawPayload
+	return migrated, nil
+}
+
+// migrateDocument applies steps[from:to] to doc and records the new version in it.
+func migrateDocument(doc map[string]interface{}, steps []Migration, from, to int) error {
+	for version := from; version < to; version++ {
+		if version >= len(steps) || steps[version].From != version {
+			return fmt.Errorf("no migration from version %d", version)
+		}
+		if apply := steps[version].Apply; apply != nil {
+			if err := apply(doc); err != nil {
+				return fmt.Errorf("migrating from version %d (%s): %w", version, steps[version].Description, err)
+			}
+		}
+	}
+	doc["version"] = to
+	return nil
+}
+
+// documentChanges reports whether any step between two versions rewrites the document.
+func documentChanges(steps []Migration, from, to int) bool {
+	for version := from; version < to && version < len(steps); version++ {
+		if steps[version].Apply != nil {
+			return true
+		}
+	}
+	return false
+}
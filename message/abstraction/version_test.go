@@ -0,0 +1,58 @@
+package abstraction
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestUnversionedMessagesMigrate(t *testing.T) {
+	var msg CanonicalMessage
+	if err := json.Unmarshal([]byte(`{"chain_id":"kaia","height":7,"type":"commit","timestamp":"2024-05-01T12:00:00Z"}`), &msg); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if msg.Version != CurrentVersion || msg.Height.Int64() != 7 {
+		t.Fatalf("unexpected migrated message %+v", msg)
+	}
+
+	msg.Version = 0
+	data, err := json.Marshal(&msg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"version":1`) {
+		t.Fatalf("expected the current version to be written, got %s", data)
+	}
+
+	if _, err := Migrate(&CanonicalMessage{Version: CurrentVersion}, 0); err == nil {
+		t.Fatalf("expected a downgrade to be rejected")
+	}
+	if _, err := Migrate(&msg, CurrentVersion+1); err == nil {
+		t.Fatalf("expected a migration past the current version to be rejected")
+	}
+}
+
+func TestMigrateDocumentAppliesSteps(t *testing.T) {
+	steps := []Migration{
+		{From: 0},
+		{From: 1, Description: "rename proposer_id", Apply: func(doc map[string]interface{}) error {
+			doc["proposer"] = doc["proposer_id"]
+			delete(doc, "proposer_id")
+			return nil
+		}},
+	}
+	if documentChanges(steps, 0, 1) || !documentChanges(steps, 0, 2) {
+		t.Fatalf("documentChanges misreports which steps rewrite the document")
+	}
+
+	doc := map[string]interface{}{"version": float64(1), "proposer_id": "node-3"}
+	if err := migrateDocument(doc, steps, 1, 2); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if doc["proposer"] != "node-3" || doc["version"] != 2 || doc["proposer_id"] != nil {
+		t.Fatalf("unexpected migrated document %v", doc)
+	}
+	if err := migrateDocument(doc, steps, 2, 3); err == nil {
+		t.Fatalf("expected a missing step to be reported")
+	}
+}
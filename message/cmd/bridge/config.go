@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/validator"
+
+	"gopkg.in/yaml.v3"
+)
+
+// builtinChains are the chains the bridge has a mapper for. Any other chain must name a remote_mapper.
+var builtinChains = []string{"cometbft", "besu", "kaia", "fabric", "fabric-raft", "hotstuff", "ethereum", "aptos", "avalanche"}
+
+// routableTypes are the canonical message types a routing rule can match.
+var routableTypes = []abstraction.MsgType{
+	abstraction.MsgTypeProposal, abstraction.MsgTypePrepare, abstraction.MsgTypeVote, abstraction.MsgTypeCommit,
+	abstraction.MsgTypeViewChange, abstraction.MsgTypeNewView, abstraction.MsgTypeBlock, abstraction.MsgTypePrevote,
+	abstraction.MsgTypePrecommit, abstraction.MsgTypeRoundChange,
+}
+
+// envReference matches ${NAME} and ${NAME:-default} in a config file.
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// loadConfig reads a bridge configuration from a YAML or JSON file, substituting environment variables, and
+// validates it. The format is chosen by extension and YAML is assumed for anything but .json.
+func loadConfig(filename string) (BridgeConfig, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return BridgeConfig{}, err
+	}
+	if data, err = expandEnv(data); err != nil {
+		return BridgeConfig{}, fmt.Errorf("config %s: %w", filename, err)
+	}
+	if strings.ToLower(filepath.Ext(filename)) != ".json" {
+		// Going through JSON keeps one set of field names for both formats.
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return BridgeConfig{}, fmt.Errorf("parse config %s: %w", filename, err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return BridgeConfig{}, fmt.Errorf("parse config %s: %w", filename, err)
+		}
+	}
+	var config BridgeConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return BridgeConfig{}, fmt.Errorf("parse config %s: %w", filename, err)
+	}
+	if err := config.Validate(); err != nil {
+		return BridgeConfig{}, fmt.Errorf("invalid config %s:\n%w", filename, err)
+	}
+	return config, nil
+}
+
+// expandEnv replaces ${NAME} with the variable's value and ${NAME:-default} with its value or, when it is
+// unset or empty, the default. A reference to an unset variable without a default is an error.
+func expandEnv(data []byte) ([]byte, error) {
+	var missing []string
+	out := envReference.ReplaceAllFunc(data, func(ref []byte) []byte {
+		m := envReference.FindSubmatch(ref)
+		if value := os.Getenv(string(m[1])); value != "" {
+			return []byte(value)
+		}
+		if m[2] != nil {
+			return m[3]
+		}
+		if _, ok := os.LookupEnv(string(m[1])); !ok {
+			missing = append(missing, string(m[1]))
+		}
+		return nil
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("undefined environment variables %s (set them or write ${NAME:-default})", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+// Validate reports every problem with the configuration, one per line: unknown chains and message types,
+// malformed egress targets and sinks, and routing rules that name chains the bridge does not run.
+func (c BridgeConfig) Validate() error {
+	var errs []error
+	fail := func(where, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", where, fmt.Sprintf(format, args...)))
+	}
+
+	if len(c.Chains) == 0 {
+		fail("chains", "no chains configured")
+	}
+	configured := make(map[string]bool)
+	for i, chain := range c.Chains {
+		where := fmt.Sprintf("chains[%d]", i)
+		if chain.Name == "" {
+			fail(where, "name is required")
+			continue
+		}
+		where += " (" + chain.Name + ")"
+		if configured[chain.Name] {
+			fail(where, "chain is configured more than once")
+		}
+		configured[chain.Name] = true
+		if remote, _ := chain.Config["remote_mapper"].(string); remote == "" && !contains(builtinChains, chain.Name) {
+			fail(where, "unknown chain %q (%s, or set config.remote_mapper)", chain.Name, strings.Join(builtinChains, ", "))
+		}
+		if path, _ := chain.Config["validation_rules"].(string); path != "" {
+			if _, err := validator.LoadRules(path); err != nil {
+				fail(where+".config.validation_rules", "%v", err)
+			}
+		}
+		if _, err := newCollector(chain, slog.Default()); err != nil {
+			fail(where+".ingress", "%v", err)
+		}
+		for j, target := range chain.Egress.Targets {
+			targetWhere := fmt.Sprintf("%s.egress.targets[%d]", where, j)
+			switch target.Type {
+			case "kafka", "jetstream":
+				if target.Topic == "" {
+					fail(targetWhere, "%s target needs a topic", target.Type)
+					continue
+				}
+			case "file":
+				if target.Path == "" {
+					fail(targetWhere, "file target needs a path")
+					continue
+				}
+			default:
+				fail(targetWhere, "unknown type %q (kafka, jetstream, file)", target.Type)
+				continue
+			}
+			sink, _ := target.sink()
+			if err := validateSink(sink); err != nil {
+				fail(targetWhere, "%v", err)
+			}
+		}
+	}
+
+	for i, rule := range c.Router.Rules {
+		where := fmt.Sprintf("router.rules[%d]", i)
+		if rule.Match.Chain != "" && !configured[rule.Match.Chain] {
+			fail(where+".match", "chain %q is not configured (%s)", rule.Match.Chain, strings.Join(sortedKeys(configured), ", "))
+		}
+		if rule.Match.MessageType != "" && !containsType(rule.Match.MessageType) {
+			fail(where+".match", "unknown message_type %q", rule.Match.MessageType)
+		}
+		if len(rule.Forward) == 0 {
+			fail(where, "rule forwards nowhere")
+		}
+		for j, target := range rule.Forward {
+			targetWhere := fmt.Sprintf("%s.forward[%d]", where, j)
+			switch {
+			case target.Chain != "" && target.Sink != "":
+				fail(targetWhere, "set either chain or sink, not both")
+			case target.Chain != "":
+				if !configured[target.Chain] {
+					fail(targetWhere, "chain %q is not configured (%s)", target.Chain, strings.Join(sortedKeys(configured), ", "))
+				}
+			case target.Sink != "":
+				if err := validateSink(target.Sink); err != nil {
+					fail(targetWhere, "%v", err)
+				}
+			default:
+				fail(targetWhere, "a chain or a sink is required")
+			}
+		}
+	}
+
+	pipeline := c.Router.Pipeline
+	for _, field := range []struct {
+		name  string
+		value int
+	}{
+		{"convert_workers", pipeline.ConvertWorkers}, {"validate_workers", pipeline.ValidateWorkers},
+		{"route_workers", pipeline.RouteWorkers}, {"queue_size", pipeline.QueueSize},
+	} {
+		if field.value < 0 {
+			fail("router.pipeline."+field.name, "must not be negative")
+		}
+	}
+	if policy := pipeline.Backpressure; policy != "" && policy != backpressureBlock && policy != backpressureDrop {
+		fail("router.pipeline.backpressure", "unknown policy %q (%s, %s)", policy, backpressureBlock, backpressureDrop)
+	}
+
+	if c.DeadLetter != "" {
+		if err := validateSink(c.DeadLetter); err != nil {
+			fail("dead_letter", "%v", err)
+		}
+	}
+	if endpoint := c.Tracing.Endpoint; endpoint != "" {
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("tracing.endpoint", "%q is not an http or https URL", endpoint)
+		}
+	}
+	if ratio := c.Tracing.SampleRatio; ratio < 0 || ratio > 1 {
+		fail("tracing.sample_ratio", "%v is not between 0 and 1", ratio)
+	}
+	if level := c.Global.LogLevel; level != "" && !contains([]string{"debug", "info", "warn", "error"}, level) {
+		fail("global.log_level", "unknown level %q (debug, info, warn, error)", level)
+	}
+	if format := c.Global.LogFormat; format != "" && format != logFormatText && format != logFormatJSON {
+		fail("global.log_format", "unknown format %q (%s, %s)", format, logFormatText, logFormatJSON)
+	}
+	if interval := c.Global.HealthCheckInterval; interval != "" {
+		if d, err := time.ParseDuration(interval); err != nil || d <= 0 {
+			fail("global.health_check_interval", "invalid duration %q", interval)
+		}
+	}
+	if size := c.Global.MaxMessageSize; size != "" {
+		if _, err := parseSize(size); err != nil {
+			fail("global.max_message_size", "%v", err)
+		}
+	}
+	if c.Global.BufferSize < 0 {
+		fail("global.buffer_size", "must not be negative")
+	}
+	return errors.Join(errs...)
+}
+
+// validateSink checks a sink target parses the way the sink that writes it will parse it.
+func validateSink(sink string) error {
+	var err error
+	switch {
+	case strings.HasPrefix(sink, fileSinkScheme):
+		_, _, err = parseFileTarget(sink)
+	case strings.HasPrefix(sink, kafkaSinkScheme):
+		_, err = parseKafkaTarget(sink)
+	case strings.HasPrefix(sink, jetStreamSinkScheme):
+		_, _, err = parseJetStreamTarget(sink)
+	default:
+		err = fmt.Errorf("unknown sink %q (%s, %s, or %s)", sink, fileSinkScheme, kafkaSinkScheme, jetStreamSinkScheme)
+	}
+	return err
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsType(t string) bool {
+	for _, v := range routableTypes {
+		if string(v) == t {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigShipped(t *testing.T) {
+	config, err := loadConfig(filepath.Join("..", "..", "..", defaultConfigFile))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(config.Chains) == 0 || len(config.Router.Rules) == 0 {
+		t.Fatalf("expected chains and rules, got %+v", config)
+	}
+	if err := defaultConfig().Validate(); err != nil {
+		t.Fatalf("built-in config is invalid: %v", err)
+	}
+}
+
+func TestLoadConfigSubstitutesEnvironment(t *testing.T) {
+	t.Setenv("BRIDGE_TEST_TOPIC", "votes.cometbft")
+	path := writeConfig(t, "bridge.yaml", `
+chains:
+  - name: cometbft
+    enabled: true
+    endpoint: ${BRIDGE_TEST_ENDPOINT:-grpc://localhost:9090}
+    egress:
+      targets:
+        - type: kafka
+          topic: ${BRIDGE_TEST_TOPIC}
+        - type: file
+          path: /tmp/cometbft.ndjson
+          config:
+            max_size: 1MB
+router:
+  rules:
+    - match: {chain: cometbft, message_type: prevote}
+      forward:
+        - sink: jetstream://consensus.{chain}
+`)
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	chain := config.Chains[0]
+	if chain.Endpoint != "grpc://localhost:9090" || chain.Egress.Targets[0].Topic != "votes.cometbft" {
+		t.Fatalf("environment not substituted: %+v", chain)
+	}
+	if sink, _ := chain.Egress.Targets[1].sink(); sink != "file:///tmp/cometbft.ndjson?max_size=1MB" {
+		t.Fatalf("unexpected file sink %q", sink)
+	}
+
+	jsonPath := writeConfig(t, "bridge.json", `{"chains": [{"name": "kaia", "enabled": true}], "router": {"rules": []}}`)
+	if config, err := loadConfig(jsonPath); err != nil || config.Chains[0].Name != "kaia" {
+		t.Fatalf("expected the JSON config to load, got %+v (%v)", config, err)
+	}
+
+	missing := writeConfig(t, "bridge.yaml", "chains:\n  - name: ${BRIDGE_TEST_UNSET}\n")
+	if _, err := loadConfig(missing); err == nil || !strings.Contains(err.Error(), "BRIDGE_TEST_UNSET") {
+		t.Fatalf("expected the undefined variable to be named, got %v", err)
+	}
+}
+
+func TestLoadConfigReportsEveryProblem(t *testing.T) {
+	path := writeConfig(t, "bridge.yaml", `
+chains:
+  - name: cometbft
+    egress:
+      targets:
+        - type: s3
+          topic: archive
+        - type: file
+  - name: solana
+    ingress:
+      type: websocket
+  - name: cometbft
+router:
+  rules:
+    - match: {chain: besu, message_type: vote_extension}
+      forward:
+        - chain: fabric
+        - sink: redis://votes
+        - sink: kafka://votes?key=round
+        - {}
+  pipeline:
+    convert_workers: -2
+    backpressure: shed
+`)
+	_, err := loadConfig(path)
+	if err == nil {
+		t.Fatalf("expected the config to be rejected")
+	}
+	for _, want := range []string{
+		`chains[0] (cometbft).egress.targets[0]: unknown type "s3"`,
+		`chains[0] (cometbft).egress.targets[1]: file target needs a path`,
+		`chains[1] (solana): unknown chain "solana"`,
+		`chains[1] (solana).ingress: websocket ingress needs a ws:// or wss:// url`,
+		`chains[2] (cometbft): chain is configured more than once`,
+		`router.rules[0].match: chain "besu" is not configured (cometbft, solana)`,
+		`router.rules[0].match: unknown message_type "vote_extension"`,
+		`router.rules[0].forward[0]: chain "fabric" is not configured`,
+		`router.rules[0].forward[1]: unknown sink "redis://votes"`,
+		`router.rules[0].forward[2]: kafka sink "kafka://votes?key=round": unknown key "round"`,
+		`router.rules[0].forward[3]: a chain or a sink is required`,
+		`router.pipeline.convert_workers: must not be negative`,
+		`router.pipeline.backpressure: unknown policy "shed" (block, drop)`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in:\n%v", want, err)
+		}
+	}
+
+	unknown := writeConfig(t, "bridge.yaml", "chains:\n  - name: kaia\n    endpiont: http://localhost:8551\n")
+	if _, err := loadConfig(unknown); err == nil || !strings.Contains(err.Error(), `unknown field "endpiont"`) {
+		t.Fatalf("expected the misspelled field to be reported, got %v", err)
+	}
+}
+
+func TestGlobalLoggerFollowsLevelAndFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := GlobalConfig{LogLevel: "warn", LogFormat: "json"}.logger(&buf)
+	logger.Info("dropped")
+	logger.Warn("kept", "chain", "cometbft")
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil || record["msg"] != "kept" || record["chain"] != "cometbft" {
+		t.Fatalf("expected only the warning, as JSON, got %q", buf.String())
+	}
+
+	config := defaultConfig()
+	config.Global = GlobalConfig{LogLevel: "verbose", LogFormat: "yaml"}
+	err := config.Validate()
+	if err == nil || !strings.Contains(err.Error(), "global.log_level") || !strings.Contains(err.Error(), `global.log_format: unknown format "yaml"`) {
+		t.Fatalf("expected log level and format errors, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/bridgeapi"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// deadLetter writes a message that failed at stage to the dead-letter sink, with the error and, for forward
+// and egress failures, the target that refused it. Without a sink the message is dropped as before. A dead
+// letter that cannot be written is only logged, so a broken sink never fails processing twice.
+func (mb *MessageBridge) deadLetter(stage string, cause error, chain, target string, raw *abstraction.RawConsensusMessage, canonical *abstraction.CanonicalMessage) {
+	if mb.deadLetterSink == "" {
+		return
+	}
+	letter := &bridgeapi.DeadLetter{
+		Time:      time.Now().UTC(),
+		Stage:     stage,
+		Error:     cause.Error(),
+		Chain:     chain,
+		Target:    target,
+		Raw:       *raw,
+		Canonical: canonical,
+	}
+	if err := mb.writeDeadLetter(letter); err != nil {
+		mb.logger.Error("failed to write dead letter", "sink", mb.deadLetterSink, "stage", stage, "chain", chain, "err", err)
+	}
+}
+
+// cancelled reports whether err only says that ctx ended, which stops a message without it having failed.
+func cancelled(ctx context.Context, err error) bool {
+	return ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded))
+}
+
+// writeDeadLetter sends a dead letter, always as JSON, to the dead-letter sink. {chain} in a Kafka topic or
+// JetStream subject is the raw message's chain ID and {type} is the failed stage. Kafka records are keyed by
+// the chain ID.
+func (mb *MessageBridge) writeDeadLetter(letter *bridgeapi.DeadLetter) error {
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+	sink := mb.deadLetterSink
+	vars := strings.NewReplacer("{chain}", letter.Raw.ChainID, "{type}", letter.Stage)
+	switch {
+	case strings.HasPrefix(sink, fileSinkScheme):
+		return mb.files.writeLine(sink, data)
+	case strings.HasPrefix(sink, kafkaSinkScheme):
+		target, err := parseKafkaTarget(sink)
+		if err != nil || mb.kafka.client == nil {
+			return err
+		}
+		mb.kafka.produce(context.Background(), &kgo.Record{
+			Topic: vars.Replace(target.topic),
+			Key:   []byte(letter.Raw.ChainID),
+			Value: data,
+		})
+		return nil
+	case strings.HasPrefix(sink, jetStreamSinkScheme):
+		subject, _, err := parseJetStreamTarget(sink)
+		if err != nil || mb.jetstream.js == nil {
+			return err
+		}
+		_, err = mb.jetstream.js.Publish(context.Background(), vars.Replace(subject), data)
+		return err
+	}
+	return fmt.Errorf("unknown dead-letter sink %q", sink)
+}
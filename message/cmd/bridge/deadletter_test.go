@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/bridgeapi"
+)
+
+func TestDeadLetters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letters.ndjson")
+	bridge := NewMessageBridge(BridgeConfig{
+		Chains: []ChainConfig{{Name: "cometbft", Enabled: true, Endpoint: "cometbft-test"}},
+		Router: RouterConfig{Rules: []RoutingRule{{
+			Match:   MatchCondition{Chain: "cometbft-test"},
+			Forward: []ForwardTarget{{Chain: "besu"}},
+		}}},
+		DeadLetter: fileSinkScheme + path,
+	})
+	defer bridge.files.Close()
+
+	vote := fmt.Sprintf(`{"type":1,"height":"12","round":"0","message_type":"Vote","vote_type":"prevote",`+
+		`"timestamp":%q,"validator_address":"validator-a","block_id":{"hash":"0xabc"}}`, time.Now().UTC().Format(time.RFC3339Nano))
+	for _, raw := range []abstraction.RawConsensusMessage{
+		{ChainID: "unknown", Payload: []byte{0x01}},
+		{ChainID: "cometbft", ChainType: abstraction.ChainTypeCometBFT, MessageType: "Vote", Encoding: "json", Payload: []byte("{not json")},
+		{ChainID: "cometbft", ChainType: abstraction.ChainTypeCometBFT, MessageType: "Vote", Encoding: "json", Payload: []byte(vote), Timestamp: time.Now()},
+	} {
+		bridge.ProcessMessage(raw)
+	}
+
+	// A message stopped by its caller's context is not a failure and is not dead-lettered.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cancelledVote := abstraction.RawConsensusMessage{ChainID: "cometbft", ChainType: abstraction.ChainTypeCometBFT, MessageType: "Vote",
+		Encoding: "json", Payload: []byte(vote), Timestamp: time.Now()}
+	if err := bridge.ProcessMessageContext(ctx, cancelledVote); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancelled message to fail with context.Canceled, got %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open dead letters: %v", err)
+	}
+	defer f.Close()
+	var letters []bridgeapi.DeadLetter
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		var letter bridgeapi.DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			t.Fatalf("invalid dead letter: %v", err)
+		}
+		letters = append(letters, letter)
+	}
+	if len(letters) != 3 {
+		t.Fatalf("expected three dead letters, got %+v", letters)
+	}
+	if letters[0].Stage != bridgeapi.StageResolve || letters[0].Raw.ChainID != "unknown" || letters[0].Chain != "" {
+		t.Fatalf("unexpected resolve letter %+v", letters[0])
+	}
+	if letters[1].Stage != bridgeapi.StageConvert || string(letters[1].Raw.Payload) != "{not json" || letters[1].Error == "" {
+		t.Fatalf("unexpected convert letter %+v", letters[1])
+	}
+	if letters[2].Stage != bridgeapi.StageForward || letters[2].Target != "chain:besu" || letters[2].Canonical == nil {
+		t.Fatalf("unexpected forward letter %+v", letters[2])
+	}
+}
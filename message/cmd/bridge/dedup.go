@@ -0,0 +1,91 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+
+	"codec/message/abstraction"
+)
+
+// defaultDedupWindow is the number of recent message keys the bridge remembers to suppress duplicates.
+const defaultDedupWindow = 10000
+
+// dedupKey identifies a consensus message however many times it is delivered. Two messages from one
+// validator that differ only in block hash are an equivocation, not a duplicate, so the hash is part of it.
+type dedupKey struct {
+	chain     string
+	height    string
+	round     string
+	msgType   abstraction.MsgType
+	validator string
+	blockHash string
+}
+
+func keyOf(msg *abstraction.CanonicalMessage) dedupKey {
+	key := dedupKey{chain: msg.ChainID, msgType: msg.Type, validator: msg.Validator, blockHash: msg.BlockHash}
+	if msg.Height != nil {
+		key.height = msg.Height.String()
+	}
+	// PBFT-style chains count views rather than rounds.
+	if msg.Round != nil {
+		key.round = msg.Round.String()
+	} else if msg.View != nil {
+		key.round = "v" + msg.View.String()
+	}
+	if key.validator == "" {
+		key.validator = msg.Proposer
+	}
+	return key
+}
+
+// deduplicator remembers the keys of the most recently seen messages. A key seen again moves back to the
+// front, so a message that keeps being replayed stays suppressed however old its first delivery is.
+type deduplicator struct {
+	mu     sync.Mutex
+	window int
+	order  *list.List
+	seen   map[dedupKey]*list.Element
+	// suppressed counts the duplicates found, for the bridge_duplicates_suppressed_total metric.
+	suppressed int64
+}
+
+// newDeduplicator remembers up to window keys. A window of zero or less disables deduplication and returns nil.
+func newDeduplicator(window int) *deduplicator {
+	if window <= 0 {
+		return nil
+	}
+	return &deduplicator{window: window, order: list.New(), seen: make(map[dedupKey]*list.Element, window)}
+}
+
+// duplicate records msg and reports whether a message with the same key is already in the window. A nil
+// deduplicator reports nothing as a duplicate.
+func (d *deduplicator) duplicate(msg *abstraction.CanonicalMessage) bool {
+	if d == nil {
+		return false
+	}
+	key := keyOf(msg)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if elem, ok := d.seen[key]; ok {
+		d.order.MoveToFront(elem)
+		d.suppressed++
+		return true
+	}
+	d.seen[key] = d.order.PushFront(key)
+	if d.order.Len() > d.window {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.seen, oldest.Value.(dedupKey))
+	}
+	return false
+}
+
+// count returns the number of duplicates this deduplicator suppressed.
+func (d *deduplicator) count() int64 {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.suppressed
+}
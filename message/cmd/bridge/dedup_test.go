@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+func TestDeduplicatorWindow(t *testing.T) {
+	d := newDeduplicator(2)
+	vote := func(height int64, hash string) *abstraction.CanonicalMessage {
+		return &abstraction.CanonicalMessage{ChainID: "cometbft", Height: big.NewInt(height), Round: big.NewInt(0),
+			Type: abstraction.MsgTypePrevote, Validator: "validator-a", BlockHash: hash}
+	}
+
+	for _, step := range []struct {
+		msg       *abstraction.CanonicalMessage
+		duplicate bool
+	}{
+		{vote(1, "0xaa"), false},
+		{vote(1, "0xaa"), true},
+		{vote(1, "0xbb"), false}, // an equivocation is not a duplicate
+		{vote(1, "0xaa"), true},  // refreshes 0xaa, so 0xbb is now the oldest
+		{vote(2, "0xaa"), false}, // evicts 0xbb
+		{vote(1, "0xbb"), false},
+		{vote(1, "0xaa"), false}, // evicted by the previous message
+	} {
+		if got := d.duplicate(step.msg); got != step.duplicate {
+			t.Fatalf("height %v hash %s: duplicate = %v, want %v", step.msg.Height, step.msg.BlockHash, got, step.duplicate)
+		}
+	}
+	if d.count() != 2 {
+		t.Fatalf("expected two suppressed duplicates, got %d", d.count())
+	}
+	if newDeduplicator(0).duplicate(vote(1, "0xaa")) {
+		t.Fatalf("a disabled deduplicator reported a duplicate")
+	}
+}
+
+func TestBridgeForwardsDuplicatesOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "votes.ndjson")
+	bridge := NewMessageBridge(BridgeConfig{
+		Chains: []ChainConfig{{Name: "cometbft", Enabled: true, Endpoint: "cometbft-test"}},
+		Router: RouterConfig{Rules: []RoutingRule{{
+			Forward: []ForwardTarget{{Sink: fileSinkScheme + path}},
+		}}},
+	})
+	defer bridge.files.Close()
+
+	vote := fmt.Sprintf(`{"type":1,"height":"12","round":"0","message_type":"Vote","vote_type":"prevote",`+
+		`"timestamp":%q,"validator_address":"validator-a","block_id":{"hash":"0xabc"}}`, time.Now().UTC().Format(time.RFC3339Nano))
+	raw := abstraction.RawConsensusMessage{ChainID: "cometbft", ChainType: abstraction.ChainTypeCometBFT,
+		MessageType: "Vote", Encoding: "json", Payload: []byte(vote), Timestamp: time.Now()}
+	first, err := bridge.process(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	second, err := bridge.process(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("process duplicate: %v", err)
+	}
+	if first.Duplicate || !second.Duplicate || second.Seq != first.Seq+1 {
+		t.Fatalf("expected the second delivery to be recorded as a duplicate, got %+v then %+v", first, second)
+	}
+	bridge.files.Close()
+	if data, _ := os.ReadFile(path); strings.Count(string(data), "\n") != 1 {
+		t.Fatalf("expected the vote forwarded once, got %q", data)
+	}
+
+	rec := httptest.NewRecorder()
+	bridge.metricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), "\nbridge_duplicates_suppressed_total 1\n") {
+		t.Fatalf("unexpected metrics:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"codec/message/abstraction"
+	"codec/message/ingress"
+)
+
+// Ingress types a chain can name in ingress.type.
+const (
+	// ingressCollector chains receive messages pushed to the Operator API by an external collector.
+	ingressCollector = "collector"
+	// ingressWebSocket chains are subscribed to over the node's WebSocket endpoint in ingress.url.
+	ingressWebSocket = "websocket"
+)
+
+// collector is an ingress that pulls traffic from a node until its context ends.
+type collector interface {
+	Run(ctx context.Context, deliver ingress.Deliver) error
+}
+
+// newCollector builds the collector a chain's ingress configuration asks for, logging to logger; it returns
+// nil for chains fed through the Operator API.
+func newCollector(chain ChainConfig, logger *slog.Logger) (collector, error) {
+	switch chain.Ingress.Type {
+	case "", ingressCollector:
+		return nil, nil
+	case ingressWebSocket:
+	default:
+		return nil, fmt.Errorf("unknown ingress type %q (%s or %s)", chain.Ingress.Type, ingressCollector, ingressWebSocket)
+	}
+	if !strings.HasPrefix(chain.Ingress.URL, "ws://") && !strings.HasPrefix(chain.Ingress.URL, "wss://") {
+		return nil, fmt.Errorf("websocket ingress needs a ws:// or wss:// url, got %q", chain.Ingress.URL)
+	}
+	switch chain.Name {
+	case "cometbft":
+		return ingress.NewCometBFTCollector(ingress.CometBFTConfig{
+			URL:     chain.Ingress.URL,
+			ChainID: chain.Name,
+			Events:  chain.Ingress.Events,
+			OnValidatorUpdates: func(height int64, updates []ingress.ValidatorUpdate) {
+				for _, u := range updates {
+					logger.Info("validator set update", "chain", chain.Name, "height", height, "validator", u.Address, "power", u.VotingPower)
+				}
+			},
+			Logger: logger,
+		})
+	case "besu":
+		protocol, _ := chain.Config["consensus_type"].(string)
+		return ingress.NewBesuCollector(ingress.BesuConfig{
+			URL:      chain.Ingress.URL,
+			ChainID:  chain.Name,
+			Protocol: protocol,
+			Logger:   logger,
+		})
+	}
+	return nil, fmt.Errorf("chain %s has no websocket ingress", chain.Name)
+}
+
+// collectors builds the collectors of the enabled chains.
+func (mb *MessageBridge) collectors() (map[string]collector, error) {
+	out := make(map[string]collector)
+	for _, chain := range mb.config.Chains {
+		if !chain.Enabled {
+			continue
+		}
+		c, err := newCollector(chain, mb.logger)
+		if err != nil {
+			return nil, fmt.Errorf("chain %s: %v", chain.Name, err)
+		}
+		if c != nil {
+			out[chain.Name] = c
+		}
+	}
+	return out, nil
+}
+
+// runCollectors feeds what every collector delivers through the pipeline until ctx ends. Failed messages are
+// logged and dead-lettered by process. With router.pipeline configured the collectors only queue messages,
+// and runCollectors returns once the queued ones are processed.
+func (mb *MessageBridge) runCollectors(ctx context.Context, collectors map[string]collector) {
+	if p := mb.pipeline; p != nil {
+		p.start()
+		defer p.close()
+	}
+	var wg sync.WaitGroup
+	for name, c := range collectors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mb.logger.Info("collecting traffic", "chain", name)
+			c.Run(ctx, func(raw abstraction.RawConsensusMessage) {
+				if mb.pipeline != nil {
+					mb.pipeline.submit(ctx, raw)
+					return
+				}
+				if _, err := mb.process(ctx, raw); err != nil {
+					mb.logger.Warn("failed to process message", append(abstraction.RawLogAttrs(raw), "err", err)...)
+				}
+			})
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+)
+
+// Log formats global.log_format accepts.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// logger returns the logger global.log_level and global.log_format ask for, writing to w. Unset settings log
+// text at info level.
+func (g GlobalConfig) logger(w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: slog.LevelInfo}
+	if g.LogLevel != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(g.LogLevel)); err == nil {
+			opts.Level = level
+		}
+	}
+	if g.LogFormat == logFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
@@ -1,23 +1,63 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"codec/message/abstraction"
+	"codec/message/abstraction/bridgeapi"
+	"codec/message/abstraction/detect"
+	"codec/message/abstraction/remote"
 	"codec/message/abstraction/validator"
 
-	cometbftAdapter "codec/cometbft/adapter"
-	besuAdapter "codec/hyperledger/besu/adapter"
-	kaiaAdapter "codec/kaia/adapter"
+	_ "codec/adapters"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
 )
 
+// defaultConfigFile is read when no config file is named on the command line.
+const defaultConfigFile = "configs/bridge.yaml"
+
 // BridgeConfig represents the configuration for the message bridge
 type BridgeConfig struct {
 	Chains []ChainConfig `json:"chains"`
 	Router RouterConfig  `json:"router"`
+	// DeadLetter is the sink that keeps messages the bridge failed to process; empty drops them.
+	DeadLetter string       `json:"dead_letter,omitempty"`
+	Global     GlobalConfig `json:"global,omitempty"`
+	// Tracing exports a trace of every processed message; without an endpoint nothing is traced.
+	Tracing TracingConfig `json:"tracing,omitempty"`
+	// Logger receives the bridge's log; nil uses slog.Default. The bridge command builds it from Global.
+	Logger *slog.Logger `json:"-"`
+}
+
+// GlobalConfig holds deployment-wide settings. LogLevel (debug, info, warn, error) and LogFormat (text,
+// json) configure the bridge's log, and BufferSize is the default size of the pipeline's queues; the others
+// are checked when the config is loaded but the bridge does not act on them yet.
+type GlobalConfig struct {
+	LogLevel            string `json:"log_level,omitempty"`
+	LogFormat           string `json:"log_format,omitempty"`
+	MetricsEnabled      bool   `json:"metrics_enabled,omitempty"`
+	HealthCheckInterval string `json:"health_check_interval,omitempty"`
+	MaxMessageSize      string `json:"max_message_size,omitempty"`
+	BufferSize          int    `json:"buffer_size,omitempty"`
 }
 
 // ChainConfig represents configuration for a specific chain
@@ -34,6 +74,10 @@ type ChainConfig struct {
 type IngressConfig struct {
 	Type    string `json:"type"`
 	Decoder string `json:"decoder"`
+	// URL is the node endpoint a websocket ingress subscribes to.
+	URL string `json:"url,omitempty"`
+	// Events limits a websocket ingress to some of the node's events; empty subscribes to all it supports.
+	Events []string `json:"events,omitempty"`
 }
 
 // EgressConfig represents egress configuration
@@ -45,13 +89,47 @@ type EgressConfig struct {
 type EgressTarget struct {
 	Type   string                 `json:"type"`
 	Topic  string                 `json:"topic,omitempty"`
+	Path   string                 `json:"path,omitempty"`
 	Chain  string                 `json:"chain,omitempty"`
 	Config map[string]interface{} `json:"config,omitempty"`
 }
 
+// sink returns the sink target an egress target publishes to. Kafka targets take their record key and
+// payload format from the "key" and "format" config entries; JetStream targets publish to Topic as a subject
+// and take "format"; file targets append to Path and rotate by "max_size", "max_age", and "compress".
+func (t EgressTarget) sink() (string, bool) {
+	var scheme, target string
+	var options []string
+	switch t.Type {
+	case "kafka":
+		scheme, target, options = kafkaSinkScheme, t.Topic, []string{"key", "format"}
+	case "jetstream":
+		scheme, target, options = jetStreamSinkScheme, t.Topic, []string{"format"}
+	case "file":
+		scheme, target, options = fileSinkScheme, t.Path, []string{"max_size", "max_age", "compress"}
+	default:
+		return "", false
+	}
+	if target == "" {
+		return "", false
+	}
+	query := url.Values{}
+	for _, name := range options {
+		if value, ok := t.Config[name]; ok && value != nil && value != "" {
+			query.Set(name, fmt.Sprint(value))
+		}
+	}
+	if len(query) == 0 {
+		return scheme + target, true
+	}
+	return scheme + target + "?" + query.Encode(), true
+}
+
 // RouterConfig represents router configuration
 type RouterConfig struct {
 	Rules []RoutingRule `json:"rules"`
+	// Pipeline processes collected messages on worker pools; unset processes each on its collector's goroutine.
+	Pipeline PipelineConfig `json:"pipeline,omitempty"`
 }
 
 // RoutingRule represents a routing rule
@@ -72,14 +150,40 @@ type ForwardTarget struct {
 	Sink  string `json:"sink,omitempty"`
 }
 
+// String names the target as it appears in dead letters: the chain name, or the sink target.
+func (t ForwardTarget) String() string {
+	if t.Chain != "" {
+		return "chain:" + t.Chain
+	}
+	return t.Sink
+}
+
 // MessageBridge orchestrates message collection, normalization, and routing
 type MessageBridge struct {
 	config     BridgeConfig
 	mappers    map[string]abstraction.Mapper
 	validators map[string]*validator.Validator
 	rules      []RoutingRule
+	events     *eventLog
+	files      *fileSinks
+	kafka      *kafkaSinks
+	jetstream  *jetStreamSinks
+	// deadLetterSink receives the messages that fail processing, see deadLetter.
+	deadLetterSink string
+	// dedup suppresses forwarding of messages already seen; nil forwards every message.
+	dedup *deduplicator
+	// tracer records a span for each pipeline stage of a message; the default no-op tracer records nothing.
+	tracer trace.Tracer
+	// pool recycles the messages that conversions leave behind without recording; nil pools nothing.
+	pool *abstraction.Pool
+	// pipeline processes what the collectors deliver on worker pools; nil processes it synchronously.
+	pipeline *pipeline
+	logger   *slog.Logger
 }
 
+// defaultHistory is the number of processed messages kept for the Viewer API's Query and SubscribeCanonical replay.
+const defaultHistory = 10000
+
 // NewMessageBridge creates a new message bridge
 func NewMessageBridge(config BridgeConfig) *MessageBridge {
 	bridge := &MessageBridge{
@@ -87,7 +191,22 @@ func NewMessageBridge(config BridgeConfig) *MessageBridge {
 		mappers:    make(map[string]abstraction.Mapper),
 		validators: make(map[string]*validator.Validator),
 		rules:      config.Router.Rules,
+		events:     newEventLog(defaultHistory),
+		files:      newFileSinks(),
+		kafka:      &kafkaSinks{},
+		jetstream:  &jetStreamSinks{},
+
+		deadLetterSink: config.DeadLetter,
+		dedup:          newDeduplicator(defaultDedupWindow),
+		tracer:         noop.NewTracerProvider().Tracer(tracerName),
+		pool:           abstraction.NewPool(),
+		logger:         config.Logger,
+	}
+	if bridge.logger == nil {
+		bridge.logger = slog.Default()
 	}
+	bridge.kafka.logger = bridge.logger
+	bridge.pipeline = newPipeline(bridge, config.Router.Pipeline, config.Global.BufferSize)
 
 	// Initialize mappers for each enabled chain
 	for _, chainConfig := range config.Chains {
@@ -101,73 +220,244 @@ func NewMessageBridge(config BridgeConfig) *MessageBridge {
 
 // initializeMapper initializes a mapper for a specific chain
 func (mb *MessageBridge) initializeMapper(config ChainConfig) {
-	var mapper abstraction.Mapper
-	var chainType abstraction.ChainType
-
-	switch config.Name {
-	case "cometbft":
-		chainType = abstraction.ChainTypeCometBFT
-		mapper = cometbftAdapter.NewCometBFTMapper(config.Endpoint)
-	case "besu":
-		chainType = abstraction.ChainTypeHyperledger
-		mapper = besuAdapter.NewBesuMapper(config.Endpoint)
-	case "kaia":
-		chainType = abstraction.ChainTypeKaia
-		mapper = kaiaAdapter.NewKaiaMapper(config.Endpoint)
-	default:
-		log.Printf("Unknown chain type: %s", config.Name)
+	// Chains served by an out-of-process mapper name its gRPC address instead of a built-in adapter.
+	if target, ok := config.Config["remote_mapper"].(string); ok && target != "" {
+		remoteMapper, err := remote.Dial(target, remote.Options{})
+		if err != nil {
+			mb.logger.Error("failed to connect remote mapper", "chain", config.Name, "target", target, "err", err)
+			return
+		}
+		mb.mappers[config.Name] = remoteMapper
+		mb.validators[config.Name] = mb.newChainValidator(config, remoteMapper.GetChainType())
+		mb.logger.Info("initialized remote mapper", "chain", config.Name, "chain_type", remoteMapper.GetChainType(), "target", target)
+		return
+	}
+
+	chainType := abstraction.ParseChainType(config.Name)
+	mapper, err := abstraction.NewMapper(chainType, config.Endpoint)
+	if err != nil {
+		mb.logger.Error("unknown chain type", "chain", config.Name, "err", err)
 		return
 	}
 
 	mb.mappers[config.Name] = mapper
-	mb.validators[config.Name] = validator.NewValidator(chainType)
-	log.Printf("Initialized mapper for chain: %s", config.Name)
+	mb.validators[config.Name] = mb.newChainValidator(config, chainType)
+	mb.logger.Info("initialized mapper", "chain", config.Name, "chain_type", chainType)
+}
+
+// newChainValidator returns the chain's validator, with the rules from the file named by
+// config.validation_rules when there is one and the chain's defaults otherwise.
+func (mb *MessageBridge) newChainValidator(config ChainConfig, chainType abstraction.ChainType) *validator.Validator {
+	path, _ := config.Config["validation_rules"].(string)
+	if path == "" {
+		return validator.NewValidator(chainType)
+	}
+	rules, err := validator.LoadRules(path)
+	if err != nil {
+		mb.logger.Warn("failed to load validation rules, using defaults", "chain", config.Name, "path", path, "err", err)
+		return validator.NewValidator(chainType)
+	}
+	chainRules, ok := rules[chainType]
+	if !ok {
+		mb.logger.Warn("validation rules define nothing for chain type, using defaults", "chain", config.Name, "chain_type", chainType, "path", path)
+		return validator.NewValidator(chainType)
+	}
+	return validator.NewValidatorWithRules(chainType, chainRules)
 }
 
 // ProcessMessage processes a raw consensus message
 func (mb *MessageBridge) ProcessMessage(raw abstraction.RawConsensusMessage) error {
-	// Find the appropriate mapper
-	mapper, exists := mb.mappers[raw.ChainID]
-	if !exists {
-		return fmt.Errorf("no mapper found for chain: %s", raw.ChainID)
+	return mb.ProcessMessageContext(context.Background(), raw)
+}
+
+// ProcessMessageContext processes a raw consensus message, tracing it as part of the trace ctx carries. When
+// ctx ends, the conversions, validation, and sink writes still to come are abandoned and ctx's error is
+// returned; a message stopped that way is the caller's decision, so it is not dead-lettered.
+func (mb *MessageBridge) ProcessMessageContext(ctx context.Context, raw abstraction.RawConsensusMessage) error {
+	_, err := mb.process(ctx, raw)
+	return err
+}
+
+// process normalizes, validates, and routes a raw message, and records it for the Viewer API. Each stage is
+// traced as a child of one bridge.process span, which records the stage a failed message stopped at.
+func (mb *MessageBridge) process(ctx context.Context, raw abstraction.RawConsensusMessage) (*bridgeapi.Event, error) {
+	j := &job{ctx: ctx, raw: raw}
+	mb.trace(j)
+	defer j.span().End()
+	if err := mb.convert(j); err != nil {
+		return nil, err
+	}
+	if err := mb.validate(j); err != nil {
+		return nil, err
 	}
+	return mb.forward(j)
+}
 
-	// Convert to canonical format
-	canonical, err := mapper.ToCanonical(raw)
-	if err != nil {
-		return fmt.Errorf("failed to convert to canonical: %v", err)
+// job carries a message through the stages of process, which the pipeline runs on separate workers. Its ctx
+// carries the message's bridge.process span.
+type job struct {
+	ctx       context.Context
+	raw       abstraction.RawConsensusMessage
+	name      string
+	canonical *abstraction.CanonicalMessage
+}
+
+// trace opens the bridge.process span of a message about to enter the stages. The caller ends it.
+func (mb *MessageBridge) trace(j *job) {
+	j.ctx, _ = mb.tracer.Start(j.ctx, "bridge.process", trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("chain.id", j.raw.ChainID),
+			attribute.String("chain.type", string(j.raw.ChainType)),
+			attribute.String("message.encoding", j.raw.Encoding),
+			attribute.Int("message.payload_size", len(j.raw.Payload))))
+}
+
+// span returns j's bridge.process span.
+func (j *job) span() trace.Span {
+	return trace.SpanFromContext(j.ctx)
+}
+
+// stopped records on j's span the stage its message stopped at.
+func (j *job) stopped(stage string, err error) {
+	span := j.span()
+	span.SetAttributes(attribute.String("bridge.stage", stage))
+	recordError(span, err)
+}
+
+// deadLetterJob keeps j's message, which failed at stage, unless it only stopped because j's context ended.
+func (mb *MessageBridge) deadLetterJob(j *job, stage string, err error, target string) {
+	if !cancelled(j.ctx, err) {
+		mb.deadLetter(stage, err, j.name, target, &j.raw, j.canonical)
 	}
+}
 
-	// Validate the canonical message
-	validator, exists := mb.validators[raw.ChainID]
-	if exists {
-		if err := validator.Validate(canonical); err != nil {
-			return fmt.Errorf("validation failed: %v", err)
-		}
+// convert finds the mapper of j's message and decodes it.
+func (mb *MessageBridge) convert(j *job) error {
+	name, mapper, err := mb.resolveMapper(&j.raw)
+	if err != nil {
+		j.stopped(bridgeapi.StageResolve, err)
+		mb.deadLetterJob(j, bridgeapi.StageResolve, err, "")
+		return err
 	}
+	j.name = name
+	j.span().SetAttributes(attribute.String("bridge.chain", name), attribute.String("chain.type", string(j.raw.ChainType)))
 
-	// Apply routing rules
-	if err := mb.routeMessage(canonical); err != nil {
-		return fmt.Errorf("routing failed: %v", err)
+	_, stage := mb.tracer.Start(j.ctx, "bridge.to_canonical", trace.WithAttributes(attribute.String("bridge.chain", name)))
+	canonical, err := mb.pool.ToCanonicalContext(j.ctx, mapper, j.raw)
+	recordError(stage, err)
+	stage.End()
+	if err != nil {
+		j.stopped(bridgeapi.StageConvert, err)
+		mb.deadLetterJob(j, bridgeapi.StageConvert, err, "")
+		return fmt.Errorf("failed to convert to canonical: %w", err)
 	}
+	j.canonical = canonical
+	j.span().SetAttributes(messageAttributes(canonical)...)
+	return nil
+}
 
-	log.Printf("Successfully processed message: chain=%s, type=%s, height=%v",
-		canonical.ChainID, canonical.Type, canonical.Height)
+// validate checks j's canonical message against its chain's validator, if it has one.
+func (mb *MessageBridge) validate(j *job) error {
+	validator, exists := mb.validators[j.name]
+	if !exists {
+		return nil
+	}
+	_, stage := mb.tracer.Start(j.ctx, "bridge.validate", trace.WithAttributes(attribute.String("bridge.chain", j.name)))
+	err := j.ctx.Err()
+	if err == nil {
+		err = validator.Validate(j.canonical)
+	}
+	recordError(stage, err)
+	stage.End()
+	if err != nil {
+		j.stopped(bridgeapi.StageValidate, err)
+		mb.deadLetterJob(j, bridgeapi.StageValidate, err, "")
+		// A rejected message is neither recorded nor forwarded, and the dead letter has been written.
+		mb.pool.PutCanonical(j.canonical)
+		j.canonical = nil
+		return fmt.Errorf("validation failed: %w", err)
+	}
 	return nil
 }
 
+// forward routes j's message and records it. A replayed or duplicated delivery is recorded for the Viewer
+// API but not forwarded again, and a failed target does not fail the message, but it is dead-lettered.
+func (mb *MessageBridge) forward(j *job) (*bridgeapi.Event, error) {
+	if mb.dedup.duplicate(j.canonical) {
+		j.span().SetAttributes(attribute.Bool("bridge.duplicate", true))
+		mb.logger.Debug("suppressed duplicate message", abstraction.LogAttrs(j.canonical)...)
+		return mb.events.record(&bridgeapi.Event{Chain: j.name, Canonical: j.canonical, Duplicate: true}), nil
+	}
+
+	mb.route(j.ctx, j.canonical, func(target string, err error) {
+		mb.deadLetterJob(j, bridgeapi.StageForward, err, target)
+	})
+	mb.publishEgress(j.ctx, j.name, j.canonical, func(target string, err error) {
+		mb.deadLetterJob(j, bridgeapi.StageEgress, err, target)
+	})
+	if err := j.ctx.Err(); err != nil {
+		j.stopped(bridgeapi.StageForward, err)
+		return nil, err
+	}
+
+	mb.logger.Debug("processed message", abstraction.LogAttrs(j.canonical)...)
+	return mb.events.append(j.name, j.canonical, false), nil
+}
+
+// resolveMapper finds the mapper for a raw message by its chain name, then by its chain type. Mixed traffic
+// without reliable metadata is sniffed with detect, and the guess is recorded on the message.
+func (mb *MessageBridge) resolveMapper(raw *abstraction.RawConsensusMessage) (string, abstraction.Mapper, error) {
+	if mapper, ok := mb.mappers[raw.ChainID]; ok {
+		return raw.ChainID, mapper, nil
+	}
+	if raw.ChainType == "" {
+		chainType, encoding, confidence := detect.Detect(raw.Payload)
+		if chainType == "" || confidence < detect.MinConfidence {
+			return "", nil, fmt.Errorf("no mapper found for chain %q and payload origin could not be detected", raw.ChainID)
+		}
+		raw.ChainType = chainType
+		if raw.Encoding == "" {
+			raw.Encoding = encoding
+		}
+		mb.logger.Debug("detected payload origin", "chain", raw.ChainID, "chain_type", chainType, "encoding", encoding, "confidence", confidence)
+	}
+	for _, config := range mb.config.Chains {
+		if mapper, ok := mb.mappers[config.Name]; ok && mapper.GetChainType() == raw.ChainType {
+			return config.Name, mapper, nil
+		}
+	}
+	return "", nil, fmt.Errorf("no mapper found for chain %q (%s)", raw.ChainID, raw.ChainType)
+}
+
 // routeMessage applies routing rules to a canonical message
-func (mb *MessageBridge) routeMessage(msg *abstraction.CanonicalMessage) error {
+func (mb *MessageBridge) routeMessage(ctx context.Context, msg *abstraction.CanonicalMessage) error {
+	mb.route(ctx, msg, nil)
+	return nil
+}
+
+// route forwards msg to the targets of every matching rule. A failed target is logged and, when failed is
+// set, reported to it with the chain or sink that failed. Once ctx ends the remaining targets are skipped.
+func (mb *MessageBridge) route(ctx context.Context, msg *abstraction.CanonicalMessage, failed func(target string, err error)) {
+	ctx, span := mb.tracer.Start(ctx, "bridge.route")
+	defer span.End()
+	matched := 0
 	for _, rule := range mb.rules {
 		if mb.matchesRule(msg, rule.Match) {
+			matched++
 			for _, target := range rule.Forward {
-				if err := mb.forwardMessage(msg, target); err != nil {
-					log.Printf("Failed to forward message: %v", err)
+				if ctx.Err() != nil {
+					break
+				}
+				if err := mb.forwardMessage(ctx, msg, target); err != nil {
+					mb.logger.Warn("failed to forward message", append(abstraction.LogAttrs(msg), "target", target.String(), "err", err)...)
+					if failed != nil {
+						failed(target.String(), err)
+					}
 				}
 			}
 		}
 	}
-	return nil
+	span.SetAttributes(attribute.Int("bridge.rules_matched", matched))
 }
 
 // matchesRule checks if a message matches a routing rule
@@ -181,45 +471,114 @@ func (mb *MessageBridge) matchesRule(msg *abstraction.CanonicalMessage, match Ma
 	return true
 }
 
-// forwardMessage forwards a message to a target
-func (mb *MessageBridge) forwardMessage(msg *abstraction.CanonicalMessage, target ForwardTarget) error {
+// forwardMessage forwards a message to a target, traced as a bridge.forward span.
+func (mb *MessageBridge) forwardMessage(ctx context.Context, msg *abstraction.CanonicalMessage, target ForwardTarget) (err error) {
+	_, span := mb.tracer.Start(ctx, "bridge.forward", trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("bridge.target", target.String())))
+	defer func() {
+		recordError(span, err)
+		span.End()
+	}()
 	if target.Chain != "" {
 		// Forward to another chain
-		return mb.forwardToChain(msg, target.Chain)
+		return mb.forwardToChain(ctx, msg, target.Chain)
 	}
 	if target.Sink != "" {
 		// Forward to a sink (e.g., Kafka, file)
-		return mb.forwardToSink(msg, target.Sink)
+		return mb.forwardToSink(ctx, msg, target.Sink)
 	}
 	return fmt.Errorf("no valid target specified")
 }
 
 // forwardToChain forwards a message to another chain
-func (mb *MessageBridge) forwardToChain(msg *abstraction.CanonicalMessage, targetChain string) error {
+func (mb *MessageBridge) forwardToChain(ctx context.Context, msg *abstraction.CanonicalMessage, targetChain string) error {
 	mapper, exists := mb.mappers[targetChain]
 	if !exists {
 		return fmt.Errorf("no mapper found for target chain: %s", targetChain)
 	}
 
 	// Convert canonical message to target chain format
-	raw, err := mapper.FromCanonical(msg)
+	raw, err := mb.pool.FromCanonicalContext(ctx, mapper, msg)
 	if err != nil {
-		return fmt.Errorf("failed to convert to target chain format: %v", err)
+		return fmt.Errorf("failed to convert to target chain format: %w", err)
 	}
+	defer mb.pool.PutRaw(raw)
 
-	log.Printf("Forwarded message to chain %s: type=%s, height=%v",
-		targetChain, raw.MessageType, msg.Height)
+	mb.logger.Debug("forwarded message to chain", append(abstraction.LogAttrs(msg), "target", targetChain, "message_type", raw.MessageType)...)
 	return nil
 }
 
-// forwardToSink forwards a message to a sink
-func (mb *MessageBridge) forwardToSink(msg *abstraction.CanonicalMessage, sink string) error {
-	// In a real implementation, this would send to Kafka, write to file, etc.
-	log.Printf("Forwarded message to sink %s: chain=%s, type=%s, height=%v",
-		sink, msg.ChainID, msg.Type, msg.Height)
+// forwardToSink forwards a message to a sink. File writes are short and not interrupted, but none starts
+// after ctx ends.
+func (mb *MessageBridge) forwardToSink(ctx context.Context, msg *abstraction.CanonicalMessage, sink string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	switch {
+	case strings.HasPrefix(sink, fileSinkScheme):
+		if err := mb.files.write(sink, msg); err != nil {
+			return err
+		}
+	case strings.HasPrefix(sink, kafkaSinkScheme):
+		if err := mb.kafka.write(ctx, sink, msg); err != nil {
+			return err
+		}
+	case strings.HasPrefix(sink, jetStreamSinkScheme):
+		if err := mb.jetstream.write(ctx, sink, msg); err != nil {
+			return err
+		}
+	}
+	mb.logger.Debug("forwarded message to sink", append(abstraction.LogAttrs(msg), "sink", sink)...)
 	return nil
 }
 
+// replay feeds a canonical message from a source through the routing rules and into the event log. It is not
+// validated, since replayed traffic is historical and the validator rejects stale messages, and it is not
+// published to egress targets, which may be the very subjects it was consumed from. Like ingested messages,
+// duplicates are recorded but not routed.
+func (mb *MessageBridge) replay(ctx context.Context, msg *abstraction.CanonicalMessage) *bridgeapi.Event {
+	ctx, span := mb.tracer.Start(ctx, "bridge.replay", trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(messageAttributes(msg)...))
+	defer span.End()
+	if mb.dedup.duplicate(msg) {
+		span.SetAttributes(attribute.Bool("bridge.duplicate", true))
+		return mb.events.record(&bridgeapi.Event{Chain: msg.ChainID, Canonical: msg, Duplicate: true})
+	}
+	if err := mb.routeMessage(ctx, msg); err != nil {
+		mb.logger.Warn("failed to route replayed message", append(abstraction.LogAttrs(msg), "err", err)...)
+	}
+	return mb.events.append(msg.ChainID, msg, false)
+}
+
+// publishEgress sends a message to the egress targets of the chain it came from. Only Kafka, JetStream, and
+// file targets are published; like routing, a failed target is logged, reported to failed, and does not fail
+// the message, and the remaining targets are skipped once ctx ends.
+func (mb *MessageBridge) publishEgress(ctx context.Context, chain string, msg *abstraction.CanonicalMessage, failed func(target string, err error)) {
+	for _, chainConfig := range mb.config.Chains {
+		if chainConfig.Name != chain {
+			continue
+		}
+		for _, target := range chainConfig.Egress.Targets {
+			if ctx.Err() != nil {
+				return
+			}
+			sink, ok := target.sink()
+			if !ok {
+				continue
+			}
+			_, span := mb.tracer.Start(ctx, "bridge.egress", trace.WithSpanKind(trace.SpanKindProducer),
+				trace.WithAttributes(attribute.String("bridge.target", sink)))
+			err := mb.forwardToSink(ctx, msg, sink)
+			recordError(span, err)
+			span.End()
+			if err != nil {
+				mb.logger.Warn("failed to publish message", append(abstraction.LogAttrs(msg), "sink", sink, "err", err)...)
+				failed(sink, err)
+			}
+		}
+	}
+}
+
 // GetSupportedChains returns the list of supported chains
 func (mb *MessageBridge) GetSupportedChains() []string {
 	var chains []string
@@ -245,41 +604,232 @@ func (mb *MessageBridge) GetChainInfo(chainName string) (map[string]interface{},
 }
 
 func main() {
+	viewerAddr := flag.String("viewer-listen", "", "Address for the read-only Viewer API (stream, query, explain); safe to expose to dashboards and students")
+	operatorAddr := flag.String("operator-listen", "", "Address for the Operator API (submit, ingest, convert, attack) plus the Viewer API; keep it private")
+	demo := flag.Bool("demo", false, "Process the built-in sample messages before serving; always on when no API listener is set")
+	kafkaBrokers := flag.String("kafka-brokers", "", "Comma separated Kafka bootstrap brokers for kafka:// sinks; without them Kafka sinks are only logged")
+	kafkaBatchBytes := flag.Int("kafka-batch-bytes", 1000000, "Largest Kafka record batch in bytes")
+	kafkaLinger := flag.Duration("kafka-linger", 100*time.Millisecond, "How long a Kafka record waits for others to fill its batch")
+	kafkaRetries := flag.Int("kafka-retries", 3, "Retries for Kafka records that fail with a retriable error")
+	natsURL := flag.String("nats-url", "", "NATS server (nats://[user:pass@]host:4222) for jetstream:// sinks and -jetstream-source; without it JetStream sinks are only logged")
+	jetStreamSourceSpec := flag.String("jetstream-source", "", "Replay canonical messages from a durable JetStream consumer: <stream>/<durable>[?filter=<subject>&deliver=all|new|last|seq:<n>|time:<RFC3339>&format=json|protobuf]")
+	blockStoreSourceSpec := flag.String("blockstore-source", "", "Replay the committed votes of a stopped CometBFT node: <data dir>[?from=<height>&to=<height>]")
+	jetStreamBatch := flag.Int("jetstream-batch", 100, "Number of messages pulled from the JetStream source at once")
+	dedupWindow := flag.Int("dedup-window", defaultDedupWindow, "Number of recent messages remembered to suppress forwarding duplicates; 0 forwards every message")
+	metricsAddr := flag.String("metrics-listen", "", "Optional HTTP address serving the Prometheus /metrics endpoint")
+	history := flag.Int("history", defaultHistory, "Number of processed messages retained for Query and SubscribeCanonical replay")
+	deadLetter := flag.String("dead-letter", "", "Sink (file://, kafka://, or jetstream://) for messages that fail conversion, validation, or forwarding; overrides dead_letter in the config")
+	otlpEndpoint := flag.String("otlp-endpoint", "", "OTLP/HTTP collector (http://host:4318) to export a trace of every processed message to; overrides tracing.endpoint in the config")
+	validateOnly := flag.Bool("validate-config", false, "Load and validate the config file, report any problems, and exit")
+	flag.Parse()
+
 	// Load configuration
-	configFile := "configs/bridge.yaml"
-	if len(os.Args) > 1 {
-		configFile = os.Args[1]
+	configFile := defaultConfigFile
+	if flag.NArg() > 0 {
+		configFile = flag.Arg(0)
 	}
 
-	config, err := loadConfig(configFile)
-	if err != nil {
+	var config BridgeConfig
+	var err error
+	_, statErr := os.Stat(configFile)
+	builtin := flag.NArg() == 0 && os.IsNotExist(statErr)
+	if builtin {
+		config = defaultConfig()
+	} else if config, err = loadConfig(configFile); err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	// The log package writes through the same handler from here on, so its fatal errors are structured too.
+	logger := config.Global.logger(os.Stderr)
+	slog.SetDefault(logger)
+	config.Logger = logger
+	if builtin {
+		logger.Info("no config file, using the built-in configuration", "path", configFile)
+	}
+	if *deadLetter != "" {
+		if err := validateSink(*deadLetter); err != nil {
+			log.Fatalf("-dead-letter: %v", err)
+		}
+		config.DeadLetter = *deadLetter
+	}
+	if *otlpEndpoint != "" {
+		config.Tracing.Endpoint = *otlpEndpoint
+	}
+	if *validateOnly {
+		var chains []string
+		for _, chain := range config.Chains {
+			chains = append(chains, chain.Name)
+		}
+		fmt.Printf("%s is valid: %d chains (%s), %d routing rules\n",
+			configFile, len(config.Chains), strings.Join(chains, ", "), len(config.Router.Rules))
+		return
+	}
 
 	// Create message bridge
 	bridge := NewMessageBridge(config)
+	bridge.events = newEventLog(*history)
+	bridge.dedup = newDeduplicator(*dedupWindow)
+	defer bridge.files.Close()
+	provider, err := config.Tracing.provider()
+	if err != nil {
+		log.Fatalf("Failed to start tracing: %v", err)
+	}
+	if provider != nil {
+		bridge.tracer = provider.Tracer(tracerName)
+		defer provider.Shutdown(context.Background())
+		logger.Info("exporting traces", "endpoint", config.Tracing.Endpoint)
+	}
+	var brokers []string
+	for _, broker := range strings.Split(*kafkaBrokers, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+	if len(brokers) > 0 {
+		client, err := kgo.NewClient(
+			kgo.SeedBrokers(brokers...),
+			kgo.ClientID("codec-bridge"),
+			kgo.ProducerBatchMaxBytes(int32(*kafkaBatchBytes)),
+			kgo.ProducerLinger(*kafkaLinger),
+			kgo.RecordRetries(*kafkaRetries),
+		)
+		if err != nil {
+			log.Fatalf("Failed to start Kafka producer: %v", err)
+		}
+		bridge.kafka.client = client
+		defer bridge.kafka.Close()
+		logger.Info("publishing Kafka sinks", "brokers", strings.Join(brokers, ","))
+	}
+
+	var source *jetStreamSource
+	if *jetStreamSourceSpec != "" {
+		if *natsURL == "" {
+			log.Fatalf("-jetstream-source needs -nats-url")
+		}
+		if source, err = parseJetStreamSource(*jetStreamSourceSpec, *jetStreamBatch); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	if *natsURL != "" {
+		conn, err := nats.Connect(*natsURL, nats.Name("codec-bridge"))
+		if err != nil {
+			log.Fatalf("Failed to connect to NATS: %v", err)
+		}
+		defer conn.Close()
+		js, err := jetstream.New(conn)
+		if err != nil {
+			log.Fatalf("Failed to open JetStream: %v", err)
+		}
+		bridge.jetstream.js = js
+		logger.Info("publishing JetStream sinks", "url", *natsURL)
+	}
+	if source != nil {
+		if err := source.start(bridge.jetstream.js); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	var archive *blockStoreSource
+	if *blockStoreSourceSpec != "" {
+		if archive, err = parseBlockStoreSource(*blockStoreSourceSpec); err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := archive.start(); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	switch sink := bridge.deadLetterSink; {
+	case sink == "":
+	case strings.HasPrefix(sink, kafkaSinkScheme) && bridge.kafka.client == nil,
+		strings.HasPrefix(sink, jetStreamSinkScheme) && bridge.jetstream.js == nil:
+		logger.Warn("dead-letter sink has no broker configured; failed messages are dropped", "sink", sink)
+	default:
+		logger.Info("writing failed messages to dead-letter sink", "sink", sink)
+	}
 
 	// Print supported chains
 	fmt.Println("Supported chains:")
 	for _, chain := range bridge.GetSupportedChains() {
 		info, err := bridge.GetChainInfo(chain)
 		if err != nil {
-			log.Printf("Failed to get chain info for %s: %v", chain, err)
+			logger.Error("failed to get chain info", "chain", chain, "err", err)
 			continue
 		}
 		fmt.Printf("  %s: %v\n", chain, info)
 	}
 
-	// Run demo with sample messages
-	runDemo(bridge)
-}
+	collectors, err := bridge.collectors()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
 
-// loadConfig loads configuration from a file
-func loadConfig(filename string) (BridgeConfig, error) {
-	var config BridgeConfig
+	// Without a listener, a source, or a collector the bridge has nothing to serve, so it runs the demo with
+	// sample messages and exits.
+	if *viewerAddr == "" && *operatorAddr == "" && source == nil && archive == nil && len(collectors) == 0 {
+		runDemo(bridge)
+		return
+	}
+	if *demo {
+		runDemo(bridge)
+	}
+	var servers []*grpc.Server
+	for _, listener := range []struct {
+		addr string
+		role bridgeapi.Role
+	}{{*viewerAddr, bridgeapi.RoleViewer}, {*operatorAddr, bridgeapi.RoleOperator}} {
+		if listener.addr == "" {
+			continue
+		}
+		srv, err := serveAPI(listener.addr, bridge, listener.role)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		servers = append(servers, srv)
+	}
+	if *metricsAddr != "" {
+		lis, err := net.Listen("tcp", *metricsAddr)
+		if err != nil {
+			log.Fatalf("Failed to listen for metrics: %v", err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", bridge.metricsHandler())
+		go http.Serve(lis, mux)
+		logger.Info("serving metrics", "url", fmt.Sprintf("http://%s/metrics", lis.Addr()))
+	}
 
-	// For demo purposes, create a default config
-	config = BridgeConfig{
+	ctx, cancel := context.WithCancel(context.Background())
+	replayed := make(chan struct{})
+	go func() {
+		defer close(replayed)
+		if archive != nil {
+			archive.run(ctx, bridge)
+		}
+		if source != nil {
+			source.run(ctx, bridge)
+		}
+	}()
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		bridge.runCollectors(ctx, collectors)
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	cancel()
+	<-replayed
+	<-collected
+	for _, srv := range servers {
+		srv.GracefulStop()
+	}
+	if n := bridge.dedup.count(); n > 0 {
+		logger.Info("suppressed duplicate messages", "count", n)
+	}
+}
+
+// defaultConfig is the configuration the bridge runs with when no config file is given and the default one
+// is missing.
+func defaultConfig() BridgeConfig {
+	return BridgeConfig{
 		Chains: []ChainConfig{
 			{
 				Name:     "cometbft",
@@ -339,8 +889,6 @@ func loadConfig(filename string) (BridgeConfig, error) {
 			},
 		},
 	}
-
-	return config, nil
 }
 
 // runDemo runs a demonstration of the message bridge
@@ -358,10 +906,10 @@ func runDemo(bridge *MessageBridge) {
 			Timestamp:   time.Now(),
 		},
 		{
-			ChainType:   abstraction.ChainTypeHyperledger,
+			ChainType:   abstraction.ChainTypeFabric,
 			ChainID:     "fabric",
-			MessageType: "PROPOSAL",
-			Payload:     []byte(`{"block_number":1000,"type":"PROPOSAL","block_hash":"0xdef456","proposer":"peer1","channel_id":"mychannel"}`),
+			MessageType: "PrePrepare",
+			Payload:     []byte(`{"message_type":"PrePrepare","channel_id":"mychannel","view":0,"seq":1000,"digest":"0xdef456","config_seq":1,"signer":{"msp_id":"OrdererMSP","id":1}}`),
 			Encoding:    "json",
 			Timestamp:   time.Now(),
 		},
@@ -381,7 +929,7 @@ func runDemo(bridge *MessageBridge) {
 		fmt.Printf("Chain: %s, Type: %s\n", rawMsg.ChainID, rawMsg.MessageType)
 
 		if err := bridge.ProcessMessage(rawMsg); err != nil {
-			log.Printf("Failed to process message: %v", err)
+			bridge.logger.Warn("failed to process message", append(abstraction.RawLogAttrs(rawMsg), "err", err)...)
 		}
 	}
 
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsHandler serves the bridge's counters for a Prometheus scrape. The collectors read the deduplicator
+// and pipeline at scrape time, so the bridge keeps a single copy of each count.
+func (mb *MessageBridge) metricsHandler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "bridge_duplicates_suppressed_total",
+			Help: "Messages recorded but not forwarded because they were already seen.",
+		}, func() float64 { return float64(mb.dedup.count()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "bridge_pipeline_dropped_total",
+			Help: "Collected messages dropped because the pipeline's ingest queue was full.",
+		}, func() float64 { return float64(mb.pipeline.droppedCount()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "bridge_pipeline_queued",
+			Help: "Collected messages waiting in the pipeline's queues.",
+		}, func() float64 { return float64(mb.pipeline.queued()) }),
+	)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
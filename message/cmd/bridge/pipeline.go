@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"codec/message/abstraction"
+)
+
+// Back-pressure policies router.pipeline.backpressure accepts.
+const (
+	backpressureBlock = "block"
+	backpressureDrop  = "drop"
+)
+
+// errPipelineFull is recorded on the span of a message the drop policy discards.
+var errPipelineFull = errors.New("pipeline is full")
+
+// defaultQueueSize bounds each pipeline queue when neither router.pipeline.queue_size nor global.buffer_size
+// is set.
+const defaultQueueSize = 1000
+
+// stageQueue is the bridge.stage span attribute of a message that was not admitted to the ingest queue.
+const stageQueue = "queue"
+
+// PipelineConfig runs the messages collectors deliver through worker pools for the convert, validate, and
+// route stages, joined by bounded queues, so a slow sink or remote mapper no longer holds up collection.
+type PipelineConfig struct {
+	// ConvertWorkers, ValidateWorkers, and RouteWorkers are how many messages each stage works on at once.
+	// Setting any of them enables the pipeline, and the others then default to 1. With one worker per stage
+	// messages are forwarded in the order they were collected; more workers may reorder them.
+	ConvertWorkers  int `json:"convert_workers,omitempty"`
+	ValidateWorkers int `json:"validate_workers,omitempty"`
+	RouteWorkers    int `json:"route_workers,omitempty"`
+	// QueueSize bounds the queue in front of each stage (default global.buffer_size, or 1000).
+	QueueSize int `json:"queue_size,omitempty"`
+	// Backpressure is what happens to a message collected while the ingest queue is full: block (default)
+	// holds up its collector until there is room, and drop discards it. The queues between stages always
+	// block, so a message is only dropped before any work is spent on it.
+	Backpressure string `json:"backpressure,omitempty"`
+}
+
+// enabled reports whether any stage has workers configured.
+func (c PipelineConfig) enabled() bool {
+	return c.ConvertWorkers > 0 || c.ValidateWorkers > 0 || c.RouteWorkers > 0
+}
+
+// pipeline runs process's stages on worker pools. A message's span opens when it is submitted, so it covers
+// its time in the queues too, including any wait for room in the ingest queue.
+type pipeline struct {
+	mb      *MessageBridge
+	cfg     PipelineConfig
+	ingest  chan *job
+	queues  []chan *job
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+// newPipeline returns the pipeline cfg describes, or nil when it configures no workers. bufferSize is
+// global.buffer_size, the queue size when cfg does not set one.
+func newPipeline(mb *MessageBridge, cfg PipelineConfig, bufferSize int) *pipeline {
+	if !cfg.enabled() {
+		return nil
+	}
+	for _, workers := range []*int{&cfg.ConvertWorkers, &cfg.ValidateWorkers, &cfg.RouteWorkers} {
+		if *workers <= 0 {
+			*workers = 1
+		}
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = bufferSize
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	p := &pipeline{mb: mb, cfg: cfg, done: make(chan struct{})}
+	p.ingest = make(chan *job, cfg.QueueSize)
+	p.queues = []chan *job{p.ingest, make(chan *job, cfg.QueueSize), make(chan *job, cfg.QueueSize)}
+	return p
+}
+
+// start starts the stages' workers. close stops them.
+func (p *pipeline) start() {
+	mb := p.mb
+	convert := p.stage(p.queues[0], p.queues[1], p.cfg.ConvertWorkers, mb.convert)
+	validate := p.stage(p.queues[1], p.queues[2], p.cfg.ValidateWorkers, mb.validate)
+	route := p.stage(p.queues[2], nil, p.cfg.RouteWorkers, func(j *job) error {
+		_, err := mb.forward(j)
+		return err
+	})
+	go func() {
+		convert.Wait()
+		close(p.queues[1])
+		validate.Wait()
+		close(p.queues[2])
+		route.Wait()
+		close(p.done)
+	}()
+}
+
+// stage starts workers that run work on the jobs from in and pass those it succeeds for to out. The last
+// stage has no out, and ends the span of each job it finishes.
+func (p *pipeline) stage(in <-chan *job, out chan<- *job, workers int, work func(*job) error) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range in {
+				err := work(j)
+				// Messages still queued when the collectors stop end with their context; that is not a failure.
+				if err != nil && !cancelled(j.ctx, err) {
+					p.mb.logger.Warn("failed to process message", append(abstraction.RawLogAttrs(j.raw), "err", err)...)
+				}
+				if err != nil || out == nil {
+					j.span().End()
+					continue
+				}
+				out <- j
+			}
+		}()
+	}
+	return &wg
+}
+
+// submit queues raw, collected under ctx, for the pipeline. Under the drop policy a full queue discards raw
+// and submit returns false; otherwise submit waits for room, returning false if ctx ends first.
+func (p *pipeline) submit(ctx context.Context, raw abstraction.RawConsensusMessage) bool {
+	j := &job{ctx: ctx, raw: raw}
+	p.mb.trace(j)
+	if p.cfg.Backpressure == backpressureDrop {
+		select {
+		case p.ingest <- j:
+			return true
+		default:
+			p.dropped.Add(1)
+			p.mb.logger.Debug("pipeline is full, dropped message", abstraction.RawLogAttrs(raw)...)
+			j.stopped(stageQueue, errPipelineFull)
+			j.span().End()
+			return false
+		}
+	}
+	select {
+	case p.ingest <- j:
+		return true
+	case <-ctx.Done():
+		j.stopped(stageQueue, nil)
+		j.span().End()
+		return false
+	}
+}
+
+// close waits for the messages already queued to go through every stage and stops the workers. Nothing may
+// be submitted afterwards.
+func (p *pipeline) close() {
+	close(p.ingest)
+	<-p.done
+}
+
+// queued returns the number of messages waiting in the pipeline's queues.
+func (p *pipeline) queued() int {
+	if p == nil {
+		return 0
+	}
+	n := 0
+	for _, q := range p.queues {
+		n += len(q)
+	}
+	return n
+}
+
+// droppedCount returns the number of messages the drop policy discarded.
+func (p *pipeline) droppedCount() int64 {
+	if p == nil {
+		return 0
+	}
+	return p.dropped.Load()
+}
@@ -0,0 +1,186 @@
+//go:build e2e
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cometbftConsensus "codec/cometbft"
+	cometbftAdapter "codec/cometbft/adapter"
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+	"codec/message/abstraction/detect"
+	"codec/scenario"
+)
+
+// TestPipelineDoubleVote runs the whole stack once, in the order the pieces are meant to compose:
+//
+//  1. the in-process CometBFT simulator drives one height with four validators of equal power;
+//  2. the faulty validator's prevote and precommit go through the byzantine mutation pipeline (double_vote);
+//  3. every wire message is submitted to the bridge, which normalizes it and routes it to a file sink;
+//  4. the sink is read back, replayed into the simulator, and analyzed: the equivocation must be flagged, and the
+//     honest block must still reach a commit quorum in round 0.
+//
+// Run it with: go test -tags e2e ./message/cmd/bridge -run TestPipelineDoubleVote
+func TestPipelineDoubleVote(t *testing.T) {
+	const (
+		chainID   = "e2e-chain"
+		height    = int64(100)
+		honest    = "8F3E1A2B4C5D6E7F8091A2B3C4D5E6F708192A3B4C5D6E7F8091A2B3C4D5E6F7"
+		alternate = "1C2D3E4F5A6B7C8D9E0F1A2B3C4D5E6F7A8B9C0D1E2F3A4B5C6D7E8F9A0B1C2D"
+	)
+	validators := []cometbftConsensus.Validator{
+		{Address: "4F5E6D7C8B9A0F1E2D3C4B5A69788796A5B4C3D2", VotingPower: 100},
+		{Address: "A1B2C3D4E5F60718293A4B5C6D7E8F9011223344", VotingPower: 100},
+		{Address: "B1B2C3D4E5F60718293A4B5C6D7E8F9011223344", VotingPower: 100},
+		{Address: "C1B2C3D4E5F60718293A4B5C6D7E8F9011223344", VotingPower: 100},
+	}
+	faulty := validators[0].Address
+
+	// 1. Simulator: one height, round 0.
+	sim := cometbftConsensus.NewConsensusEngine(validators)
+	sim.AdvanceHeight(height)
+	// The bridge validator rejects stale messages, so the height starts now.
+	start := time.Now().UTC()
+	message := func(msgType abstraction.MsgType, index int, offset time.Duration) *abstraction.CanonicalMessage {
+		return &abstraction.CanonicalMessage{
+			ChainID:    chainID,
+			Height:     big.NewInt(height),
+			Round:      big.NewInt(0),
+			Timestamp:  start.Add(offset),
+			Type:       msgType,
+			BlockHash:  honest,
+			Validator:  validators[index].Address,
+			Signature:  "c2lnbmF0dXJl",
+			Extensions: map[string]interface{}{"validator_index": index},
+		}
+	}
+	proposal := message(abstraction.MsgTypeProposal, 0, 0)
+	proposal.Validator, proposal.Proposer = "", sim.GetState().Validators.Proposer.Address
+
+	// 2. Mutation pipeline: honest messages are encoded as-is, the faulty validator's votes are forked.
+	mapper := cometbftAdapter.NewCometBFTMapper(chainID)
+	engine := cometbftAdapter.ByzantineEngine
+	action := cometbftAdapter.ByzantineActionDoubleVote
+	opts := byzantine.Options{AlternateBlockHash: alternate}
+
+	var wire []*abstraction.RawConsensusMessage
+	encode := func(msg *abstraction.CanonicalMessage) {
+		t.Helper()
+		if msg.Validator == faulty {
+			raws, err := engine.ApplyAndEncode(mapper, msg, action, opts)
+			if err != nil {
+				t.Fatalf("mutate %s: %v", msg.Type, err)
+			}
+			wire = append(wire, raws...)
+			return
+		}
+		raw, err := mapper.FromCanonical(msg)
+		if err != nil {
+			t.Fatalf("encode %s: %v", msg.Type, err)
+		}
+		wire = append(wire, raw)
+	}
+	encode(proposal)
+	for i := range validators {
+		encode(message(abstraction.MsgTypePrevote, i, time.Second))
+	}
+	for i := range validators {
+		encode(message(abstraction.MsgTypePrecommit, i, 2*time.Second))
+	}
+	if want := 1 + 2*len(validators) + 2; len(wire) != want {
+		t.Fatalf("expected %d wire messages (one extra prevote and precommit from the faulty validator), got %d", want, len(wire))
+	}
+
+	// 3. Bridge: normalize every wire message and route it to a file sink.
+	sinkPath := filepath.Join(t.TempDir(), "canonical.ndjson")
+	bridge := NewMessageBridge(BridgeConfig{
+		Chains: []ChainConfig{{Name: "cometbft", Enabled: true, Endpoint: chainID}},
+		Router: RouterConfig{Rules: []RoutingRule{{Forward: []ForwardTarget{{Sink: fileSinkScheme + sinkPath}}}}},
+	})
+	for _, raw := range wire {
+		if chain, _, confidence := detect.Detect(raw.Payload); chain != abstraction.ChainTypeCometBFT || confidence < detect.MinConfidence {
+			t.Fatalf("expected %s payload to be detected as cometbft, got %q (%.2f)", raw.MessageType, chain, confidence)
+		}
+		if err := bridge.ProcessMessage(*raw); err != nil {
+			t.Fatalf("bridge rejected %s: %v", raw.MessageType, err)
+		}
+	}
+	if err := bridge.files.Close(); err != nil {
+		t.Fatalf("close sink: %v", err)
+	}
+
+	// 4. Detector and analysis over what the sink recorded.
+	recorded := readSink(t, sinkPath)
+	if len(recorded) != len(wire) {
+		t.Fatalf("sink recorded %d messages, want %d", len(recorded), len(wire))
+	}
+	outputs := make([]scenario.Output, len(recorded))
+	for i, msg := range recorded {
+		if err := sim.ProcessMessage(msg); err != nil {
+			t.Fatalf("simulator rejected recorded %s from %s: %v", msg.Type, msg.Validator, err)
+		}
+		outputs[i] = scenario.Output{Step: i + 1, Canonical: msg}
+	}
+
+	alert := scenario.Assertion{Kind: "conflicting_votes"}.Evaluate(outputs)
+	if !alert.Passed {
+		t.Fatalf("expected the double vote to raise an alert: %s", alert.Detail)
+	}
+	t.Logf("alert: %s", alert.Detail)
+	for _, ev := range sim.GetEvidence() {
+		if ev.Validator != faulty || ev.Type != cometbftConsensus.EvidenceDuplicateVote {
+			t.Fatalf("evidence against the wrong validator: %+v", ev)
+		}
+	}
+	if n := len(sim.GetEvidence()); n != 2 {
+		t.Fatalf("expected evidence of the faulty prevote and precommit, got %d", n)
+	}
+
+	// Liveness: one equivocating validator holds a quarter of the power, so the honest block still commits and
+	// the conflicting block never reaches a quorum.
+	power := make(map[string]int64)
+	for _, msg := range recorded {
+		if msg.Type == abstraction.MsgTypePrecommit {
+			power[msg.BlockHash] += sim.GetValidatorPower(msg.Validator)
+		}
+	}
+	quorum := sim.GetTotalPower()*2/3 + 1
+	if power[honest] < quorum {
+		t.Fatalf("expected the honest block to commit with %d of %d power, got %d", quorum, sim.GetTotalPower(), power[honest])
+	}
+	if power[alternate] >= quorum {
+		t.Fatalf("conflicting block reached a quorum with %d power; the chain forked", power[alternate])
+	}
+	if !sim.IsConsensusReached() || sim.GetCurrentRound() != 0 {
+		t.Fatalf("expected the simulator to reach precommit in round 0, got %+v", sim.GetState())
+	}
+}
+
+func readSink(t *testing.T, path string) []*abstraction.CanonicalMessage {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open sink: %v", err)
+	}
+	defer f.Close()
+	var msgs []*abstraction.CanonicalMessage
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var msg abstraction.CanonicalMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("decode sink line %d: %v", len(msgs)+1, err)
+		}
+		msgs = append(msgs, &msg)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("read sink: %v", err)
+	}
+	return msgs
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/bridgeapi"
+	"codec/message/ingress"
+)
+
+// sliceCollector delivers its messages and returns.
+type sliceCollector []abstraction.RawConsensusMessage
+
+func (c sliceCollector) Run(ctx context.Context, deliver ingress.Deliver) error {
+	for _, raw := range c {
+		deliver(raw)
+	}
+	return nil
+}
+
+func pipelineVote(height int) abstraction.RawConsensusMessage {
+	vote := fmt.Sprintf(`{"type":1,"height":"%d","round":"0","message_type":"Vote","vote_type":"prevote",`+
+		`"timestamp":%q,"validator_address":"validator-a","block_id":{"hash":"0xabc"}}`, height, time.Now().UTC().Format(time.RFC3339Nano))
+	return abstraction.RawConsensusMessage{ChainID: "cometbft", ChainType: abstraction.ChainTypeCometBFT,
+		MessageType: "Vote", Encoding: "json", Payload: []byte(vote), Timestamp: time.Now()}
+}
+
+func TestPipelineProcessesCollectedMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "votes.ndjson")
+	bridge := NewMessageBridge(BridgeConfig{
+		Chains: []ChainConfig{{Name: "cometbft", Enabled: true, Endpoint: "cometbft-test"}},
+		Router: RouterConfig{
+			Rules:    []RoutingRule{{Forward: []ForwardTarget{{Sink: fileSinkScheme + path}}}},
+			Pipeline: PipelineConfig{ConvertWorkers: 4, QueueSize: 8},
+		},
+	})
+	defer bridge.files.Close()
+	if p := bridge.pipeline; p == nil || p.cfg.ValidateWorkers != 1 || p.cfg.RouteWorkers != 1 {
+		t.Fatalf("expected unset stages to get one worker, got %+v", p)
+	}
+
+	var votes sliceCollector
+	for height := 1; height <= 100; height++ {
+		votes = append(votes, pipelineVote(height))
+	}
+	votes = append(votes, abstraction.RawConsensusMessage{ChainID: "cometbft", ChainType: abstraction.ChainTypeCometBFT,
+		MessageType: "Vote", Encoding: "json", Payload: []byte("not a vote")})
+	bridge.runCollectors(context.Background(), map[string]collector{"cometbft": votes})
+
+	// runCollectors returns once the queued messages went through every stage.
+	if got := len(bridge.events.query(bridgeapi.Filter{}, 0)); got != 100 {
+		t.Fatalf("expected 100 recorded votes, got %d", got)
+	}
+	bridge.files.Close()
+	if data, _ := os.ReadFile(path); strings.Count(string(data), "\n") != 100 {
+		t.Fatalf("expected 100 forwarded votes, got %d lines", strings.Count(string(data), "\n"))
+	}
+}
+
+func TestPipelineDropPolicy(t *testing.T) {
+	bridge := NewMessageBridge(BridgeConfig{
+		Chains: []ChainConfig{{Name: "cometbft", Enabled: true, Endpoint: "cometbft-test"}},
+		Router: RouterConfig{Pipeline: PipelineConfig{RouteWorkers: 1, QueueSize: 1, Backpressure: backpressureDrop}},
+	})
+
+	// Nothing drains the queue until the workers start, so the second message finds it full.
+	p := bridge.pipeline
+	if !p.submit(context.Background(), pipelineVote(1)) || p.submit(context.Background(), pipelineVote(2)) {
+		t.Fatal("expected the second message to be dropped")
+	}
+	rec := httptest.NewRecorder()
+	bridge.metricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "\nbridge_pipeline_dropped_total 1\n") || !strings.Contains(body, "\nbridge_pipeline_queued 1\n") {
+		t.Fatalf("unexpected metrics:\n%s", body)
+	}
+	p.start()
+	p.close()
+	if got := len(bridge.events.query(bridgeapi.Filter{}, 0)); got != 1 {
+		t.Fatalf("expected the queued vote to be processed on close, got %d events", got)
+	}
+
+	// A blocked submit gives up when its context ends.
+	blocked := newPipeline(bridge, PipelineConfig{RouteWorkers: 1, QueueSize: 1}, 0)
+	blocked.submit(context.Background(), pipelineVote(3))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if blocked.submit(ctx, pipelineVote(4)) {
+		t.Fatal("expected a blocked submit to give up with its context")
+	}
+}
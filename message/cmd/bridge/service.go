@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/bridgeapi"
+	"codec/message/abstraction/byzantine"
+	"codec/message/abstraction/detect"
+	"codec/message/abstraction/lint"
+	"codec/scenario"
+
+	"google.golang.org/grpc"
+)
+
+// subscriberBuffer is how many events a slow SubscribeCanonical subscriber may fall behind before events are
+// dropped for it; Event.Seq gaps tell the subscriber what it missed.
+const subscriberBuffer = 256
+
+// eventLog retains recent events for Query and fans new ones out to SubscribeCanonical subscribers.
+type eventLog struct {
+	mu          sync.Mutex
+	limit       int
+	seq         uint64
+	events      []*bridgeapi.Event
+	subscribers map[chan *bridgeapi.Event]bridgeapi.Filter
+}
+
+func newEventLog(limit int) *eventLog {
+	return &eventLog{limit: limit, subscribers: make(map[chan *bridgeapi.Event]bridgeapi.Filter)}
+}
+
+func (l *eventLog) append(chain string, msg *abstraction.CanonicalMessage, forged bool) *bridgeapi.Event {
+	return l.record(&bridgeapi.Event{Chain: chain, Canonical: msg, Forged: forged})
+}
+
+// record numbers and timestamps ev, keeps it in the history, and sends it to matching subscribers.
+func (l *eventLog) record(ev *bridgeapi.Event) *bridgeapi.Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.seq++
+	ev.Seq, ev.Received = l.seq, time.Now().UTC()
+	if l.limit > 0 {
+		if len(l.events) == l.limit {
+			l.events = append(l.events[:0], l.events[1:]...)
+		}
+		l.events = append(l.events, ev)
+	}
+	for ch, filter := range l.subscribers {
+		if !filter.Matches(ev) {
+			continue
+		}
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+	return ev
+}
+
+func (l *eventLog) query(filter bridgeapi.Filter, limit int) []*bridgeapi.Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []*bridgeapi.Event
+	for _, ev := range l.events {
+		if filter.Matches(ev) {
+			out = append(out, ev)
+		}
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out
+}
+
+// subscribe registers a subscriber and, when replay is set, returns the retained matches it has not been sent.
+func (l *eventLog) subscribe(filter bridgeapi.Filter, replay bool) (chan *bridgeapi.Event, []*bridgeapi.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ch := make(chan *bridgeapi.Event, subscriberBuffer)
+	l.subscribers[ch] = filter
+	if !replay {
+		return ch, nil
+	}
+	var backlog []*bridgeapi.Event
+	for _, ev := range l.events {
+		if filter.Matches(ev) {
+			backlog = append(backlog, ev)
+		}
+	}
+	return ch, backlog
+}
+
+func (l *eventLog) unsubscribe(ch chan *bridgeapi.Event) {
+	l.mu.Lock()
+	delete(l.subscribers, ch)
+	l.mu.Unlock()
+}
+
+// bridgeService implements both halves of the bridge API on a MessageBridge.
+type bridgeService struct {
+	bridge *MessageBridge
+}
+
+var (
+	_ bridgeapi.Viewer   = bridgeService{}
+	_ bridgeapi.Operator = bridgeService{}
+)
+
+func (s bridgeService) SubscribeCanonical(ctx context.Context, req *bridgeapi.SubscribeRequest, send func(*bridgeapi.Event) error) error {
+	ch, backlog := s.bridge.events.subscribe(req.Filter, req.Replay)
+	defer s.bridge.events.unsubscribe(ch)
+	for _, ev := range backlog {
+		if err := send(ev); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-ch:
+			if err := send(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s bridgeService) Query(_ context.Context, req *bridgeapi.QueryRequest) (*bridgeapi.QueryResponse, error) {
+	return &bridgeapi.QueryResponse{Events: s.bridge.events.query(req.Filter, req.Limit)}, nil
+}
+
+// Explain runs detection, decoding, validation, and lint on a copy of the message without routing or recording it.
+func (s bridgeService) Explain(ctx context.Context, req *bridgeapi.ExplainRequest) (*bridgeapi.ExplainResponse, error) {
+	raw := req.Raw
+	resp := &bridgeapi.ExplainResponse{}
+	if _, ok := s.bridge.mappers[raw.ChainID]; !ok && raw.ChainType == "" {
+		result := detect.Sniff(raw.Payload)
+		resp.Detection = &result
+	}
+	name, mapper, err := s.bridge.resolveMapper(&raw)
+	if err != nil {
+		resp.Error = err.Error()
+		return resp, nil
+	}
+	resp.Chain = name
+	canonical, err := abstraction.ToCanonicalContext(ctx, mapper, raw)
+	if err != nil {
+		if cancelled(ctx, err) {
+			return nil, err
+		}
+		resp.Error = fmt.Sprintf("failed to convert to canonical: %v", err)
+		return resp, nil
+	}
+	resp.Canonical = canonical
+	resp.Issues = lint.Lint(canonical, lint.DefaultProfile(mapper.GetChainType()), byzantine.ActionNone)
+	if validator, ok := s.bridge.validators[name]; ok {
+		if err := validator.Validate(canonical); err != nil {
+			resp.Error = fmt.Sprintf("validation failed: %v", err)
+		}
+	}
+	return resp, nil
+}
+
+func (s bridgeService) Submit(ctx context.Context, req *bridgeapi.SubmitRequest) (*bridgeapi.SubmitResponse, error) {
+	ev, err := s.bridge.process(traceContext(ctx), req.Raw)
+	if err != nil {
+		return nil, err
+	}
+	return &bridgeapi.SubmitResponse{Event: ev}, nil
+}
+
+// IngestRaw processes a collector's stream. A message the bridge rejects is reported in the summary rather than
+// ending the stream, so one malformed capture does not cost the collector its connection.
+func (s bridgeService) IngestRaw(ctx context.Context, recv func() (*abstraction.RawConsensusMessage, error)) (*bridgeapi.IngestResponse, error) {
+	ctx = traceContext(ctx)
+	resp := &bridgeapi.IngestResponse{}
+	for index := 0; ; index++ {
+		raw, err := recv()
+		if errors.Is(err, io.EOF) {
+			return resp, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ev, err := s.bridge.process(ctx, *raw)
+		if cancelled(ctx, err) {
+			return nil, err
+		}
+		if err != nil {
+			resp.Rejected = append(resp.Rejected, bridgeapi.IngestError{Index: index, Error: err.Error()})
+			continue
+		}
+		resp.Accepted++
+		resp.LastSeq = ev.Seq
+	}
+}
+
+func (s bridgeService) Convert(ctx context.Context, req *bridgeapi.ConvertRequest) (*bridgeapi.ConvertResponse, error) {
+	if req.Canonical == nil {
+		return nil, fmt.Errorf("convert request has no canonical message")
+	}
+	mapper, ok := s.bridge.mappers[req.TargetChain]
+	if !ok {
+		return nil, fmt.Errorf("no mapper found for target chain: %s", req.TargetChain)
+	}
+	raw, err := abstraction.FromCanonicalContext(ctx, mapper, req.Canonical)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert to target chain format: %w", err)
+	}
+	return &bridgeapi.ConvertResponse{Raw: raw}, nil
+}
+
+func (s bridgeService) Attack(ctx context.Context, req *bridgeapi.AttackRequest) (*bridgeapi.AttackResponse, error) {
+	if req.Canonical == nil {
+		return nil, fmt.Errorf("attack request has no canonical message")
+	}
+	mapper, ok := s.bridge.mappers[req.TargetChain]
+	if !ok {
+		return nil, fmt.Errorf("no mapper found for target chain: %s", req.TargetChain)
+	}
+	target, err := scenario.DefaultTarget(string(mapper.GetChainType()), req.Canonical.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	action, err := target.Engine.Parse(req.Action)
+	if err != nil {
+		return nil, err
+	}
+	opts := req.Options.Options()
+	opts.Params = req.Params
+
+	canonicals, err := target.Engine.Apply(req.Canonical, action, opts)
+	if err != nil {
+		return nil, err
+	}
+	raws, err := byzantine.Encode(mapper, canonicals)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range raws {
+		if raw.Payload, err = target.Engine.MutatePayload(action, raw.Payload, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Route {
+		for _, canonical := range canonicals {
+			if err := s.bridge.routeMessage(traceContext(ctx), canonical); err != nil {
+				return nil, fmt.Errorf("routing failed: %v", err)
+			}
+			s.bridge.events.append(req.TargetChain, canonical, true)
+		}
+	}
+	return &bridgeapi.AttackResponse{Canonicals: canonicals, Raws: raws}, nil
+}
+
+// serveAPI starts a gRPC listener. Operator listeners serve both services; viewer listeners only the Viewer,
+// so nothing reachable through them can change bridge state.
+func serveAPI(addr string, bridge *MessageBridge, role bridgeapi.Role) (*grpc.Server, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for %s API on %s: %w", role, addr, err)
+	}
+	srv := grpc.NewServer()
+	svc := bridgeService{bridge: bridge}
+	bridgeapi.RegisterViewer(srv, svc)
+	if role == bridgeapi.RoleOperator {
+		bridgeapi.RegisterOperator(srv, svc)
+	}
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			bridge.logger.Error("API listener stopped", "role", role, "address", addr, "err", err)
+		}
+	}()
+	bridge.logger.Info("serving API", "role", role, "address", lis.Addr().String())
+	return srv, nil
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/canonical"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// fileSinkScheme prefixes sink targets that append canonical messages to a local file as newline-delimited
+// JSON, written as file://<path>[?max_size=<n>[KB|MB|GB]&max_age=<duration>&compress=gzip]. A file that
+// would grow past max_size, or that has been open for max_age when a message arrives, is renamed to
+// <path>.<UTC time> and a new file is started. With compress=gzip rotated files are compressed to
+// <path>.<UTC time>.gz in the background.
+const fileSinkScheme = "file://"
+
+// rotatedSuffix is the time layout appended to rotated files; it sorts in rotation order.
+const rotatedSuffix = "20060102T150405.000Z"
+
+// fileRotation is when and how a file sink rotates. Zero values never rotate.
+type fileRotation struct {
+	maxSize int64
+	maxAge  time.Duration
+	gzip    bool
+}
+
+func parseFileTarget(sink string) (string, fileRotation, error) {
+	path, query, _ := strings.Cut(strings.TrimPrefix(sink, fileSinkScheme), "?")
+	if path == "" {
+		return "", fileRotation{}, fmt.Errorf("file sink %q has no path", sink)
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", fileRotation{}, fmt.Errorf("invalid file sink %q: %v", sink, err)
+	}
+	var rotation fileRotation
+	if v := values.Get("max_size"); v != "" {
+		if rotation.maxSize, err = parseSize(v); err != nil {
+			return "", fileRotation{}, fmt.Errorf("file sink %q: invalid max_size: %v", sink, err)
+		}
+	}
+	if v := values.Get("max_age"); v != "" {
+		if rotation.maxAge, err = time.ParseDuration(v); err != nil || rotation.maxAge <= 0 {
+			return "", fileRotation{}, fmt.Errorf("file sink %q: invalid max_age %q", sink, v)
+		}
+	}
+	switch v := values.Get("compress"); v {
+	case "":
+	case "gzip":
+		rotation.gzip = true
+	default:
+		return "", fileRotation{}, fmt.Errorf("file sink %q: unknown compression %q (gzip)", sink, v)
+	}
+	return path, rotation, nil
+}
+
+// parseSize reads a byte count with an optional KB, MB, or GB suffix (powers of 1024).
+func parseSize(v string) (int64, error) {
+	multiplier := int64(1)
+	upper := strings.ToUpper(strings.TrimSpace(v))
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper, multiplier = strings.TrimSpace(strings.TrimSuffix(upper, unit.suffix)), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%q is not a positive size", v)
+	}
+	return n * multiplier, nil
+}
+
+// sinkFile is an open file sink. Its rotation is fixed by the first target that names the path.
+type sinkFile struct {
+	rotation fileRotation
+	f        *os.File
+	size     int64
+	opened   time.Time
+}
+
+// fileSinks appends canonical messages to files, one open handle per path.
+type fileSinks struct {
+	mu    sync.Mutex
+	files map[string]*sinkFile
+	// compressing tracks background compression of rotated files, which Close waits for.
+	compressing sync.WaitGroup
+	now         func() time.Time
+}
+
+func newFileSinks() *fileSinks {
+	return &fileSinks{files: make(map[string]*sinkFile), now: time.Now}
+}
+
+// write appends msg to the file named by a file:// sink target.
+func (s *fileSinks) write(sink string, msg *abstraction.CanonicalMessage) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message for %s: %v", sink, err)
+	}
+	return s.writeLine(sink, line)
+}
+
+// writeLine appends one JSON record to the file named by a file:// sink target, rotating the file first
+// when it is due.
+func (s *fileSinks) writeLine(sink string, record []byte) error {
+	path, rotation, err := parseFileTarget(sink)
+	if err != nil {
+		return err
+	}
+	line := append(append(make([]byte, 0, len(record)+1), record...), '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	file, ok := s.files[path]
+	if !ok {
+		file = &sinkFile{rotation: rotation}
+		if err := file.open(path, now); err != nil {
+			return err
+		}
+		s.files[path] = file
+	}
+	if file.due(int64(len(line)), now) {
+		if err := s.rotate(path, file, now); err != nil {
+			return err
+		}
+	}
+	n, err := file.f.Write(line)
+	file.size += int64(n)
+	return err
+}
+
+func (f *sinkFile) open(path string, now time.Time) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open file sink: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open file sink: %v", err)
+	}
+	f.f, f.size, f.opened = file, info.Size(), now
+	return nil
+}
+
+// due reports whether the file must be rotated before n more bytes are written. An empty file is never
+// rotated, so a single message larger than max_size still gets written.
+func (f *sinkFile) due(n int64, now time.Time) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.rotation.maxSize > 0 && f.size+n > f.rotation.maxSize {
+		return true
+	}
+	return f.rotation.maxAge > 0 && now.Sub(f.opened) >= f.rotation.maxAge
+}
+
+// rotate moves the current file aside and starts a new one at path.
+func (s *fileSinks) rotate(path string, file *sinkFile, now time.Time) error {
+	if err := file.f.Close(); err != nil {
+		return fmt.Errorf("failed to close file sink for rotation: %v", err)
+	}
+	rotated := path + "." + now.UTC().Format(rotatedSuffix)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(rotated); os.IsNotExist(err) {
+			break
+		}
+		rotated = fmt.Sprintf("%s.%s-%d", path, now.UTC().Format(rotatedSuffix), i)
+	}
+	if err := os.Rename(path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate file sink: %v", err)
+	}
+	if file.rotation.gzip {
+		s.compressing.Add(1)
+		go func() {
+			defer s.compressing.Done()
+			if err := compressFile(rotated); err != nil {
+				slog.Error("failed to compress rotated file sink", "path", rotated, "err", err)
+			}
+		}()
+	}
+	return file.open(path, now)
+}
+
+// compressFile replaces path with path.gz. The original is only removed once the archive is complete.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := path + ".gz.tmp"
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	zw.Name = filepath.Base(path)
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Close closes every open sink file and waits for rotated files to be compressed.
+func (s *fileSinks) Close() error {
+	s.mu.Lock()
+	var first error
+	for path, file := range s.files {
+		if err := file.f.Close(); err != nil && first == nil {
+			first = err
+		}
+		delete(s.files, path)
+	}
+	s.mu.Unlock()
+	s.compressing.Wait()
+	return first
+}
+
+// Payload formats shared by the Kafka and JetStream sinks and the JetStream source.
+const (
+	formatJSON = "json"
+	// formatProtobuf is a byzantine.canonical.v1.CanonicalMessage (message/proto/canonical.proto).
+	formatProtobuf = "protobuf"
+)
+
+func parseFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return formatJSON, nil
+	case formatJSON, formatProtobuf:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown format %q (json or protobuf)", format)
+}
+
+// encodeCanonical serializes a canonical message in a payload format.
+func encodeCanonical(msg *abstraction.CanonicalMessage, format string) ([]byte, error) {
+	if format == formatProtobuf {
+		return canonical.MarshalProto(msg)
+	}
+	return json.Marshal(msg)
+}
+
+// decodeCanonical reads a payload written by encodeCanonical.
+func decodeCanonical(data []byte, format string) (*abstraction.CanonicalMessage, error) {
+	if format == formatProtobuf {
+		return canonical.UnmarshalProto(data)
+	}
+	msg := &abstraction.CanonicalMessage{}
+	if err := json.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// expandSubject replaces {chain} and {type} in a topic or subject template.
+func expandSubject(template string, msg *abstraction.CanonicalMessage) string {
+	return strings.NewReplacer("{chain}", msg.ChainID, "{type}", string(msg.Type)).Replace(template)
+}
+
+// kafkaSinkScheme prefixes sink targets that publish canonical messages to Kafka, written as
+// kafka://<topic>[?key=height|validator][&format=json|protobuf]. The topic may contain {chain} and {type},
+// which are replaced by the message's chain ID and canonical type to get a topic per chain or message type.
+const kafkaSinkScheme = "kafka://"
+
+// kafkaTarget is a parsed kafka:// sink target.
+type kafkaTarget struct {
+	topic string
+	// key is the message field used as the record key, which keeps one height's or one validator's
+	// messages in order on a single partition; empty spreads records over the partitions.
+	key string
+	// format is the payload format, json or protobuf.
+	format string
+}
+
+func parseKafkaTarget(sink string) (kafkaTarget, error) {
+	topic, query, _ := strings.Cut(strings.TrimPrefix(sink, kafkaSinkScheme), "?")
+	if topic == "" {
+		return kafkaTarget{}, fmt.Errorf("kafka sink %q has no topic", sink)
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return kafkaTarget{}, fmt.Errorf("invalid kafka sink %q: %v", sink, err)
+	}
+	target := kafkaTarget{topic: topic, key: values.Get("key"), format: values.Get("format")}
+	switch target.key {
+	case "", "height", "validator":
+	default:
+		return kafkaTarget{}, fmt.Errorf("kafka sink %q: unknown key %q (height or validator)", sink, target.key)
+	}
+	if target.format, err = parseFormat(target.format); err != nil {
+		return kafkaTarget{}, fmt.Errorf("kafka sink %q: %v", sink, err)
+	}
+	return target, nil
+}
+
+// record builds the Kafka record a canonical message becomes.
+func (t kafkaTarget) record(msg *abstraction.CanonicalMessage) (*kgo.Record, error) {
+	value, err := encodeCanonical(msg, t.format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message for kafka: %v", err)
+	}
+	record := &kgo.Record{Topic: expandSubject(t.topic, msg), Value: value}
+	switch t.key {
+	case "height":
+		if msg.Height != nil {
+			record.Key = []byte(msg.Height.String())
+		}
+	case "validator":
+		if msg.Validator != "" {
+			record.Key = []byte(msg.Validator)
+		}
+	}
+	return record, nil
+}
+
+// kafkaSinks publishes to kafka:// sink targets through one franz-go client, which batches, partitions by
+// key, and retries. Without brokers the targets are only logged, as the demo configuration names Kafka
+// topics that need not exist.
+type kafkaSinks struct {
+	client *kgo.Client
+	logger *slog.Logger
+}
+
+// write queues msg for the topic named by a kafka:// sink target, waiting for room in the client's buffer
+// until ctx ends. Delivery happens in the background, so delivery failures are logged rather than returned
+// here.
+func (s *kafkaSinks) write(ctx context.Context, sink string, msg *abstraction.CanonicalMessage) error {
+	target, err := parseKafkaTarget(sink)
+	if err != nil {
+		return err
+	}
+	if s.client == nil {
+		return nil
+	}
+	record, err := target.record(msg)
+	if err != nil {
+		return err
+	}
+	s.produce(ctx, record)
+	return nil
+}
+
+// produce queues record and logs it if it cannot be delivered.
+func (s *kafkaSinks) produce(ctx context.Context, record *kgo.Record) {
+	s.client.Produce(ctx, record, func(record *kgo.Record, err error) {
+		if err != nil {
+			s.logger.Error("failed to deliver kafka record", "topic", record.Topic, "err", err)
+		}
+	})
+}
+
+// Close delivers queued records and disconnects from the brokers.
+func (s *kafkaSinks) Close() error {
+	if s.client == nil {
+		return nil
+	}
+	err := s.client.Flush(context.Background())
+	s.client.Close()
+	return err
+}
+
+// jetStreamSinkScheme prefixes sink targets that publish canonical messages to NATS JetStream, written as
+// jetstream://<subject>[?format=json|protobuf]. {chain} and {type} in the subject are expanded as in Kafka
+// topics, and a stream on the server must already store the subject.
+const jetStreamSinkScheme = "jetstream://"
+
+// jetStreamSinks publishes to jetstream:// sink targets. Without a NATS connection the targets are only
+// logged.
+type jetStreamSinks struct {
+	js jetstream.JetStream
+}
+
+// parseJetStreamTarget splits a jetstream:// sink target into its subject template and payload format.
+func parseJetStreamTarget(sink string) (string, string, error) {
+	subject, query, _ := strings.Cut(strings.TrimPrefix(sink, jetStreamSinkScheme), "?")
+	if subject == "" {
+		return "", "", fmt.Errorf("jetstream sink %q has no subject", sink)
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid jetstream sink %q: %v", sink, err)
+	}
+	format, err := parseFormat(values.Get("format"))
+	if err != nil {
+		return "", "", fmt.Errorf("jetstream sink %q: %v", sink, err)
+	}
+	return subject, format, nil
+}
+
+// write publishes msg and waits for the stream to store it, or for ctx to end.
+func (s *jetStreamSinks) write(ctx context.Context, sink string, msg *abstraction.CanonicalMessage) error {
+	subject, format, err := parseJetStreamTarget(sink)
+	if err != nil {
+		return err
+	}
+	if s.js == nil {
+		return nil
+	}
+	data, err := encodeCanonical(msg, format)
+	if err != nil {
+		return fmt.Errorf("failed to encode message for jetstream: %v", err)
+	}
+	_, err = s.js.Publish(ctx, expandSubject(subject, msg), data)
+	return err
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/canonical"
+)
+
+func TestKafkaTargetRecord(t *testing.T) {
+	msg := &abstraction.CanonicalMessage{
+		ChainID:   "cometbft",
+		Height:    big.NewInt(42),
+		Type:      abstraction.MsgTypePrevote,
+		Validator: "validator-a",
+	}
+
+	target, err := parseKafkaTarget("kafka://consensus.{chain}.{type}?key=height&format=protobuf")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	record, err := target.record(msg)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if record.Topic != "consensus.cometbft."+string(abstraction.MsgTypePrevote) || string(record.Key) != "42" {
+		t.Fatalf("unexpected topic %q or key %q", record.Topic, record.Key)
+	}
+	decoded, err := canonical.UnmarshalProto(record.Value)
+	if err != nil {
+		t.Fatalf("payload is not a protobuf CanonicalMessage: %v", err)
+	}
+	if decoded.Validator != "validator-a" || decoded.Height.Cmp(msg.Height) != 0 {
+		t.Fatalf("unexpected payload %+v", decoded)
+	}
+
+	target, _ = parseKafkaTarget("kafka://consensus.vote?key=validator")
+	if record, _ = target.record(msg); string(record.Key) != "validator-a" || record.Value[0] != '{' {
+		t.Fatalf("expected a JSON record keyed by validator, got %q/%q", record.Key, record.Value)
+	}
+	for _, sink := range []string{"kafka://", "kafka://votes?key=round", "kafka://votes?format=avro"} {
+		if _, err := parseKafkaTarget(sink); err == nil {
+			t.Fatalf("expected %q to be rejected", sink)
+		}
+	}
+
+	egress := EgressTarget{Type: "kafka", Topic: "kaia.consensus", Config: map[string]interface{}{"key": "validator"}}
+	if sink, ok := egress.sink(); !ok || sink != "kafka://kaia.consensus?key=validator" {
+		t.Fatalf("unexpected egress sink %q", sink)
+	}
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "consensus.ndjson")
+	files := newFileSinks()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	files.now = func() time.Time { return now }
+
+	sink := fileSinkScheme + path + "?max_size=1KB&max_age=1h&compress=gzip"
+	write := func(height int64) {
+		t.Helper()
+		msg := &abstraction.CanonicalMessage{ChainID: "cometbft", Height: big.NewInt(height), Type: abstraction.MsgTypePrevote}
+		if err := files.write(sink, msg); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	// Enough messages to pass max_size several times, then one after max_age has passed.
+	for height := int64(1); height <= 20; height++ {
+		write(height)
+		now = now.Add(time.Second)
+	}
+	now = now.Add(time.Hour)
+	write(21)
+	if err := files.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	entries, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	sort.Strings(entries)
+	if len(entries) < 2 {
+		t.Fatalf("expected the file to rotate more than once, got %v", entries)
+	}
+	var heights []string
+	for _, name := range append(entries, path) {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		var scanner *bufio.Scanner
+		if strings.HasSuffix(name, ".gz") {
+			zr, err := gzip.NewReader(f)
+			if err != nil {
+				t.Fatalf("%s is not gzip: %v", name, err)
+			}
+			scanner = bufio.NewScanner(zr)
+		} else if name != path {
+			t.Fatalf("rotated file %s was not compressed", name)
+		} else {
+			scanner = bufio.NewScanner(f)
+		}
+		var size int
+		for scanner.Scan() {
+			size += len(scanner.Bytes()) + 1
+			var msg abstraction.CanonicalMessage
+			if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+				t.Fatalf("invalid line in %s: %v", name, err)
+			}
+			heights = append(heights, msg.Height.String())
+		}
+		f.Close()
+		if size > 1024 {
+			t.Fatalf("%s holds %d bytes, past max_size", name, size)
+		}
+	}
+	if want := "1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20,21"; strings.Join(heights, ",") != want {
+		t.Fatalf("expected every message once in order, got %v", heights)
+	}
+	if data, _ := os.ReadFile(path); strings.Count(string(data), "\n") != 1 {
+		t.Fatalf("expected max_age to start a new file for the last message, got %q", data)
+	}
+
+	for _, bad := range []string{"file://", "file:///tmp/x?max_size=0", "file:///tmp/x?max_size=1TB", "file:///tmp/x?max_age=soon", "file:///tmp/x?compress=zstd"} {
+		if _, _, err := parseFileTarget(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+	egress := EgressTarget{Type: "file", Path: "/tmp/kaia.ndjson", Config: map[string]interface{}{"max_size": "100MB", "compress": "gzip"}}
+	if sink, ok := egress.sink(); !ok || sink != "file:///tmp/kaia.ndjson?compress=gzip&max_size=100MB" {
+		t.Fatalf("unexpected egress sink %q", sink)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"codec/cometbft/blockstore"
+	"codec/message/abstraction"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// jetStreamSource replays canonical messages from a durable JetStream consumer into the bridge. The
+// consumer keeps its position on the server, so a restarted bridge resumes where it stopped.
+type jetStreamSource struct {
+	stream   string
+	consumer jetstream.ConsumerConfig
+	format   string
+	batch    int
+	// pull is the consumer start found or created.
+	pull jetstream.Consumer
+}
+
+// parseJetStreamSource reads a source written as
+// <stream>/<durable>[?filter=<subject>&deliver=all|new|last|seq:<n>|time:<RFC3339>&format=json|protobuf].
+// deliver only applies when the durable consumer is first created.
+func parseJetStreamSource(spec string, batch int) (*jetStreamSource, error) {
+	path, query, _ := strings.Cut(spec, "?")
+	stream, durable, ok := strings.Cut(path, "/")
+	if !ok || stream == "" || durable == "" {
+		return nil, fmt.Errorf("jetstream source %q must be <stream>/<durable>", spec)
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jetstream source %q: %v", spec, err)
+	}
+	source := &jetStreamSource{
+		stream: stream,
+		consumer: jetstream.ConsumerConfig{
+			Durable:       durable,
+			FilterSubject: values.Get("filter"),
+			AckPolicy:     jetstream.AckExplicitPolicy,
+		},
+		batch: batch,
+	}
+	if source.format, err = parseFormat(values.Get("format")); err != nil {
+		return nil, fmt.Errorf("jetstream source %q: %v", spec, err)
+	}
+	switch deliver := values.Get("deliver"); {
+	case deliver == "" || deliver == "all":
+		source.consumer.DeliverPolicy = jetstream.DeliverAllPolicy
+	case deliver == "new":
+		source.consumer.DeliverPolicy = jetstream.DeliverNewPolicy
+	case deliver == "last":
+		source.consumer.DeliverPolicy = jetstream.DeliverLastPolicy
+	case strings.HasPrefix(deliver, "seq:"):
+		seq, err := strconv.ParseUint(strings.TrimPrefix(deliver, "seq:"), 10, 64)
+		if err != nil || seq == 0 {
+			return nil, fmt.Errorf("jetstream source %q: invalid start sequence %q", spec, deliver)
+		}
+		source.consumer.DeliverPolicy, source.consumer.OptStartSeq = jetstream.DeliverByStartSequencePolicy, seq
+	case strings.HasPrefix(deliver, "time:"):
+		start, err := time.Parse(time.RFC3339, strings.TrimPrefix(deliver, "time:"))
+		if err != nil {
+			return nil, fmt.Errorf("jetstream source %q: invalid start time: %v", spec, err)
+		}
+		source.consumer.DeliverPolicy, source.consumer.OptStartTime = jetstream.DeliverByStartTimePolicy, &start
+	default:
+		return nil, fmt.Errorf("jetstream source %q: unknown deliver policy %q", spec, deliver)
+	}
+	if source.batch <= 0 {
+		source.batch = 100
+	}
+	return source, nil
+}
+
+// start finds the durable consumer a previous run created, or creates it. An existing consumer is left as it
+// is, so its position survives a change of deliver.
+func (s *jetStreamSource) start(js jetstream.JetStream) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	pull, err := js.Consumer(ctx, s.stream, s.consumer.Durable)
+	if errors.Is(err, jetstream.ErrConsumerNotFound) {
+		pull, err = js.CreateConsumer(ctx, s.stream, s.consumer)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create consumer %s on stream %s: %w", s.consumer.Durable, s.stream, err)
+	}
+	s.pull = pull
+	slog.Info("replaying JetStream consumer", "stream", s.stream, "consumer", s.consumer.Durable)
+	return nil
+}
+
+// run replays the consumer's messages into the bridge until ctx ends. A message that cannot be decoded is
+// terminated so the server does not redeliver it forever.
+func (s *jetStreamSource) run(ctx context.Context, bridge *MessageBridge) {
+	for ctx.Err() == nil {
+		batch, err := s.pull.Fetch(s.batch, jetstream.FetchMaxWait(5*time.Second))
+		if err == nil {
+			for msg := range batch.Messages() {
+				s.replay(ctx, bridge, msg)
+			}
+			err = batch.Error()
+		}
+		if err != nil {
+			bridge.logger.Warn("failed to fetch from JetStream", "stream", s.stream, "consumer", s.consumer.Durable, "err", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+// replay hands one fetched message to the bridge and acknowledges it.
+func (s *jetStreamSource) replay(ctx context.Context, bridge *MessageBridge, msg jetstream.Msg) {
+	canonical, err := decodeCanonical(msg.Data(), s.format)
+	if err != nil {
+		bridge.logger.Warn("dropping undecodable message", "subject", msg.Subject(), "err", err)
+		msg.Term()
+		return
+	}
+	bridge.replay(ctx, canonical)
+	if err := msg.Ack(); err != nil {
+		bridge.logger.Warn("failed to acknowledge message", "subject", msg.Subject(), "err", err)
+	}
+}
+
+// blockStoreSource replays the committed heights of a stopped CometBFT node's block store into the bridge, once.
+type blockStoreSource struct {
+	dir      string
+	from, to int64
+	reader   *blockstore.Reader
+}
+
+// parseBlockStoreSource reads a source written as <data dir>[?from=<height>&to=<height>]. Missing bounds default
+// to the lowest and highest stored heights.
+func parseBlockStoreSource(spec string) (*blockStoreSource, error) {
+	dir, query, _ := strings.Cut(spec, "?")
+	if dir == "" {
+		return nil, fmt.Errorf("blockstore source %q must name a data directory", spec)
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid blockstore source %q: %v", spec, err)
+	}
+	source := &blockStoreSource{dir: dir}
+	for _, bound := range []struct {
+		name string
+		dst  *int64
+	}{{"from", &source.from}, {"to", &source.to}} {
+		v := values.Get(bound.name)
+		if v == "" {
+			continue
+		}
+		if *bound.dst, err = strconv.ParseInt(v, 10, 64); err != nil || *bound.dst <= 0 {
+			return nil, fmt.Errorf("blockstore source %q: invalid %s height %q", spec, bound.name, v)
+		}
+	}
+	if source.to != 0 && source.to < source.from {
+		return nil, fmt.Errorf("blockstore source %q: to is below from", spec)
+	}
+	return source, nil
+}
+
+// start opens the block store, so a locked or missing database stops the bridge before it serves.
+func (s *blockStoreSource) start() error {
+	r, err := blockstore.Open(s.dir)
+	if err != nil {
+		return err
+	}
+	s.reader = r
+	base, top := r.Heights()
+	slog.Info("replaying block store", "dir", s.dir, "base", base, "top", top)
+	return nil
+}
+
+// run replays the proposals and commit precommits of the selected heights into the bridge and closes the block
+// store. It returns early when ctx ends.
+func (s *blockStoreSource) run(ctx context.Context, bridge *MessageBridge) {
+	defer s.reader.Close()
+	count := 0
+	err := s.reader.Range(s.from, s.to, func(msg *abstraction.CanonicalMessage) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		bridge.replay(ctx, msg)
+		count++
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		bridge.logger.Error("stopped replaying block store", "dir", s.dir, "err", err)
+	}
+	bridge.logger.Info("replayed block store", "dir", s.dir, "messages", count)
+}
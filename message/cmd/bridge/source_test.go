@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"codec/message/abstraction"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func TestParseJetStreamSource(t *testing.T) {
+	source, err := parseJetStreamSource("CONSENSUS/bridge?filter=consensus.cometbft.>&deliver=seq:42&format=protobuf", 0)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	cfg := source.consumer
+	if source.stream != "CONSENSUS" || cfg.Durable != "bridge" || cfg.FilterSubject != "consensus.cometbft.>" ||
+		cfg.DeliverPolicy != jetstream.DeliverByStartSequencePolicy || cfg.OptStartSeq != 42 || source.format != formatProtobuf || source.batch != 100 {
+		t.Fatalf("unexpected source %+v", source)
+	}
+	for _, spec := range []string{"CONSENSUS", "CONSENSUS/", "CONSENSUS/bridge?deliver=seq:0", "CONSENSUS/bridge?deliver=yesterday", "CONSENSUS/bridge?format=xml"} {
+		if _, err := parseJetStreamSource(spec, 10); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestCanonicalPayloadRoundTrip(t *testing.T) {
+	msg := &abstraction.CanonicalMessage{ChainID: "kaia", Height: big.NewInt(1000000), Type: abstraction.MsgTypeCommit, Validator: "v1"}
+	for _, format := range []string{formatJSON, formatProtobuf} {
+		data, err := encodeCanonical(msg, format)
+		if err != nil {
+			t.Fatalf("encode %s: %v", format, err)
+		}
+		got, err := decodeCanonical(data, format)
+		if err != nil {
+			t.Fatalf("decode %s: %v", format, err)
+		}
+		if got.ChainID != msg.ChainID || got.Height.Cmp(msg.Height) != 0 || got.Type != msg.Type || got.Validator != msg.Validator {
+			t.Fatalf("%s round trip changed the message: %+v", format, got)
+		}
+	}
+}
+
+func TestParseBlockStoreSource(t *testing.T) {
+	source, err := parseBlockStoreSource("/var/cometbft/data?from=100&to=200")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if source.dir != "/var/cometbft/data" || source.from != 100 || source.to != 200 {
+		t.Fatalf("unexpected source %+v", source)
+	}
+	for _, spec := range []string{"", "?from=1", "data?from=x", "data?to=-1", "data?from=9&to=3"} {
+		if _, err := parseBlockStoreSource(spec); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+}
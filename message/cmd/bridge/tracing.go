@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"codec/message/abstraction"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// tracerName is the instrumentation scope of the bridge's spans.
+const tracerName = "codec/message/cmd/bridge"
+
+// TracingConfig configures the OTLP/HTTP exporter the bridge sends its spans to.
+type TracingConfig struct {
+	// Endpoint is the collector's OTLP/HTTP address, such as http://localhost:4318. A URL without a path gets
+	// the standard /v1/traces.
+	Endpoint    string `json:"endpoint,omitempty"`
+	ServiceName string `json:"service_name,omitempty"`
+	// SampleRatio is the fraction of messages traced, from 0 to 1; unset traces every message. Messages
+	// submitted with a traceparent follow the caller's decision.
+	SampleRatio float64           `json:"sample_ratio,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// provider starts the exporter the config describes, or returns nil when tracing is off. The caller shuts the
+// provider down, which flushes the spans still batched.
+func (c TracingConfig) provider() (*sdktrace.TracerProvider, error) {
+	if c.Endpoint == "" {
+		return nil, nil
+	}
+	endpoint, err := url.Parse(c.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("tracing: endpoint %q is not an http or https URL", c.Endpoint)
+	}
+	if endpoint.Path == "" || endpoint.Path == "/" {
+		endpoint.Path = "/v1/traces"
+	}
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(endpoint.String())}
+	if len(c.Headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(c.Headers))
+	}
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("tracing: %w", err)
+	}
+
+	serviceName := c.ServiceName
+	if serviceName == "" {
+		serviceName = "codec-bridge"
+	}
+	sampler := sdktrace.AlwaysSample()
+	if c.SampleRatio > 0 && c.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(c.SampleRatio)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	), nil
+}
+
+// metadataCarrier reads and writes W3C trace context in gRPC metadata.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// traceContext continues the caller's trace when the call carries a W3C traceparent in its metadata, so a
+// collector's own spans and the bridge's appear in one trace.
+func traceContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, metadataCarrier(md))
+}
+
+// recordError marks span as failed with err; a nil err leaves it alone.
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// messageAttributes describes a canonical message on a span.
+func messageAttributes(msg *abstraction.CanonicalMessage) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.String("message.type", string(msg.Type))}
+	if height, ok := msg.HeightInt64(); ok {
+		attrs = append(attrs, attribute.Int64("message.height", height))
+	}
+	if round, ok := msg.RoundInt64(); ok {
+		attrs = append(attrs, attribute.Int64("message.round", round))
+	}
+	if view, ok := msg.ViewInt64(); ok {
+		attrs = append(attrs, attribute.Int64("message.view", view))
+	}
+	return attrs
+}
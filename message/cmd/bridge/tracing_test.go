@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestBridgeTracesPipeline(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+
+	config := BridgeConfig{
+		Chains: []ChainConfig{{Name: "cometbft", Enabled: true, Endpoint: "cometbft-test"}},
+		Router: RouterConfig{Rules: []RoutingRule{{
+			Forward: []ForwardTarget{{Sink: fileSinkScheme + filepath.Join(t.TempDir(), "votes.ndjson")}},
+		}}},
+	}
+	bridge := NewMessageBridge(config)
+	defer bridge.files.Close()
+	bridge.tracer = provider.Tracer(tracerName)
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := traceContext(metadata.NewIncomingContext(context.Background(), metadata.Pairs("traceparent", parent)))
+	vote := fmt.Sprintf(`{"type":1,"height":"12","round":"0","message_type":"Vote","vote_type":"prevote",`+
+		`"timestamp":%q,"validator_address":"validator-a","block_id":{"hash":"0xabc"}}`, time.Now().UTC().Format(time.RFC3339Nano))
+	raw := abstraction.RawConsensusMessage{ChainID: "cometbft", ChainType: abstraction.ChainTypeCometBFT,
+		MessageType: "Vote", Encoding: "json", Payload: []byte(vote), Timestamp: time.Now()}
+	if err := bridge.ProcessMessageContext(ctx, raw); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	raw.Payload = []byte("not a vote")
+	if err := bridge.ProcessMessage(raw); err == nil {
+		t.Fatal("expected the malformed vote to fail")
+	}
+
+	spans := exporter.GetSpans()
+	byName := make(map[string][]tracetest.SpanStub)
+	for _, s := range spans {
+		byName[s.Name] = append(byName[s.Name], s)
+	}
+	processed := byName["bridge.process"]
+	if len(processed) != 2 {
+		t.Fatalf("expected two bridge.process spans, got %+v", spans)
+	}
+	ok, failed := processed[0], processed[1]
+	if ok.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || ok.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Fatalf("expected the first message to continue the caller's trace, got %+v", ok)
+	}
+	for _, name := range []string{"bridge.validate", "bridge.route", "bridge.forward"} {
+		if len(byName[name]) != 1 || byName[name][0].SpanContext.TraceID() != ok.SpanContext.TraceID() {
+			t.Fatalf("expected one %s span in the caller's trace, got %+v", name, byName[name])
+		}
+	}
+	if forward := byName["bridge.forward"][0]; forward.Parent.SpanID() != byName["bridge.route"][0].SpanContext.SpanID() ||
+		!strings.HasPrefix(spanAttribute(forward, "bridge.target"), fileSinkScheme) {
+		t.Fatalf("expected bridge.forward under bridge.route with its sink, got %+v", forward)
+	}
+	if failed.SpanContext.TraceID() == ok.SpanContext.TraceID() || failed.Status.Code != codes.Error ||
+		spanAttribute(failed, "bridge.stage") != "convert" {
+		t.Fatalf("expected the malformed vote to fail at convert in its own trace, got %+v", failed)
+	}
+}
+
+func spanAttribute(span tracetest.SpanStub, key string) string {
+	for _, attr := range span.Attributes {
+		if string(attr.Key) == key {
+			return attr.Value.AsString()
+		}
+	}
+	return ""
+}
+
+func TestValidateTracingConfig(t *testing.T) {
+	config := defaultConfig()
+	config.Tracing = TracingConfig{Endpoint: "localhost:4318", SampleRatio: 2}
+	err := config.Validate()
+	if err == nil || !strings.Contains(err.Error(), "tracing.endpoint") || !strings.Contains(err.Error(), "tracing.sample_ratio") {
+		t.Fatalf("expected endpoint and sample ratio errors, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"codec/capture"
+	"codec/cometbft/blockstore"
+	"codec/message/abstraction"
+)
+
+func runBlockstore(args []string) int {
+	fs := flag.NewFlagSet("blockstore", flag.ExitOnError)
+	home := fs.String("home", "", "CometBFT home directory; its data directory holds blockstore.db and state.db")
+	dataDir := fs.String("data", "", "Data directory to read instead of <home>/data")
+	from := fs.Int64("from", 0, "First height to export (defaults to the lowest stored height)")
+	to := fs.Int64("to", 0, "Last height to export (defaults to the highest stored height)")
+	output := fs.String("o", "", "Capture to write; required")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridgectl blockstore (-home DIR | -data DIR) [-from N] [-to M] -o capture.jsonl")
+		fmt.Fprintln(os.Stderr, "Exports the proposals and commit precommits of stored heights as a capture for evidence and check.")
+		fmt.Fprintln(os.Stderr, "Stop the node first, or point at a copy of its data directory; goleveldb allows one process at a time.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 0 || *output == "" || (*home == "") == (*dataDir == "") {
+		fs.Usage()
+		return 2
+	}
+	if *to != 0 && *to < *from {
+		log.Printf("-to must not be below -from")
+		return 2
+	}
+	dir := *dataDir
+	if dir == "" {
+		dir = filepath.Join(*home, "data")
+	}
+
+	r, err := blockstore.Open(dir)
+	if err != nil {
+		log.Printf("%v", err)
+		return 2
+	}
+	defer r.Close()
+	base, top := r.Heights()
+	if *from < base {
+		*from = base
+	}
+	if *to == 0 || *to > top {
+		*to = top
+	}
+
+	w, err := capture.Create(*output)
+	if err != nil {
+		log.Printf("%v", err)
+		return 2
+	}
+	count := 0
+	err = r.Range(*from, *to, func(msg *abstraction.CanonicalMessage) error {
+		count++
+		return w.Write(&capture.Record{Time: msg.Timestamp, Source: "blockstore", Canonical: msg})
+	})
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		log.Printf("%v", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "wrote %d messages from heights %d to %d of %s\n", count, *from, *to, dir)
+	// Print the validator set in -validators form so the capture can go straight to evidence and check.
+	if powers, err := r.VotingPowers(*from); err == nil {
+		pairs := make([]string, 0, len(powers))
+		for id, power := range powers {
+			pairs = append(pairs, fmt.Sprintf("%s=%d", id, power))
+		}
+		sort.Strings(pairs)
+		fmt.Fprintf(os.Stderr, "validators: %s\n", strings.Join(pairs, ","))
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"codec/capture"
+)
+
+func runSlice(args []string) int {
+	fs := flag.NewFlagSet("slice", flag.ExitOnError)
+	from := fs.Int64("from", 0, "First height to print")
+	to := fs.Int64("to", -1, "Last height to print (defaults to -from)")
+	slack := fs.Int("slack", 1000, "Stop after this many consecutive records above -to; stray future-height copies do not end the slice early")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridgectl slice -from N [-to M] capture.jsonl")
+		fmt.Fprintln(os.Stderr, "Prints the records between two heights as JSON Lines, using the capture's height index.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	if *to < 0 {
+		*to = *from
+	}
+	if *to < *from {
+		log.Printf("-to must not be below -from")
+		return 2
+	}
+
+	q, err := capture.Query(fs.Arg(0), capture.Filter{FromHeight: from, ToHeight: to, Slack: *slack})
+	if err != nil {
+		log.Printf("failed to open capture: %v", err)
+		return 2
+	}
+	defer q.Close()
+
+	encoder := json.NewEncoder(os.Stdout)
+	for {
+		rec, err := q.Next()
+		if err == io.EOF {
+			return 0
+		}
+		if err != nil {
+			log.Printf("%v", err)
+			return 1
+		}
+		if err := encoder.Encode(rec); err != nil {
+			log.Printf("failed to write record: %v", err)
+			return 1
+		}
+	}
+}
+
+func runReindex(args []string) int {
+	fs := flag.NewFlagSet("reindex", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridgectl reindex capture.jsonl...")
+		fmt.Fprintln(os.Stderr, "Rebuilds the height index of captures whose writer did not shut down cleanly.")
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	for _, path := range fs.Args() {
+		if err := capture.BuildIndex(path); err != nil {
+			log.Printf("%s: %v", path, err)
+			return 1
+		}
+		fmt.Printf("%s: wrote %s\n", path, capture.IndexPath(path))
+	}
+	return 0
+}
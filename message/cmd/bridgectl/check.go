@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"codec/capture"
+	"codec/message/abstraction/properties"
+)
+
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	validators := fs.String("validators", "", "Validator set as id=power,... ; every voter counts equally without it")
+	byzantine := fs.String("byzantine", "", "Comma-separated faulty validators; their commits do not count as honest")
+	from := fs.Int64("from", 0, "First height that must commit (default: lowest height in the captures)")
+	to := fs.Int64("to", 0, "Last height that must commit (default: highest height in the captures)")
+	maxRounds := fs.Int64("max-rounds", 0, "Progress bound: every height must commit in a round below this (0 = unbounded)")
+	maxDuration := fs.Duration("max-duration", 0, "Progress bound: time from a height's first message to its commit (0 = unbounded)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridgectl check [flags] capture.jsonl...")
+		fmt.Fprintln(os.Stderr, "Checks agreement, validity, and progress over the messages in captures and prints the verdicts as JSON.")
+		fmt.Fprintln(os.Stderr, "Exits 1 when a property is violated.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	set, err := parseValidatorSet(*validators)
+	if err != nil {
+		log.Printf("invalid -validators: %v", err)
+		return 2
+	}
+	cfg := properties.Config{
+		Validators:  set,
+		From:        *from,
+		To:          *to,
+		MaxRounds:   *maxRounds,
+		MaxDuration: *maxDuration,
+	}
+	for _, id := range strings.Split(*byzantine, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			cfg.Byzantine = append(cfg.Byzantine, id)
+		}
+	}
+
+	checker := properties.NewChecker(cfg)
+	for _, path := range fs.Args() {
+		r, err := capture.Open(path)
+		if err != nil {
+			log.Printf("failed to open capture: %v", err)
+			return 2
+		}
+		var records []*capture.Record
+		for {
+			rec, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				r.Close()
+				log.Printf("%s: %v", path, err)
+				return 1
+			}
+			records = append(records, rec)
+		}
+		r.Close()
+		// A byzproxy capture also holds what the proxy received before it changed or dropped it
+		for _, msg := range capture.OnWire(records) {
+			checker.Observe(msg)
+		}
+	}
+
+	report := checker.Report()
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Printf("failed to write verdicts: %v", err)
+		return 1
+	}
+	if !report.Holds {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"codec/message/abstraction/bridgeapi"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func runRequeue(args []string) int {
+	fs := flag.NewFlagSet("requeue", flag.ExitOnError)
+	operator := fs.String("operator", "127.0.0.1:7401", "Address of the bridge's Operator API")
+	stage := fs.String("stage", "", "Only requeue dead letters that failed at this stage (resolve|convert|validate|forward|egress)")
+	chain := fs.String("chain", "", "Only requeue dead letters whose raw message names this chain ID")
+	dryRun := fs.Bool("dry-run", false, "Count the dead letters that would be requeued without sending them")
+	timeout := fs.Duration("timeout", time.Minute, "Deadline for the whole requeue")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridgectl requeue [flags] dead-letters.ndjson[.gz]...")
+		fmt.Fprintln(os.Stderr, "Feeds the raw messages of a file dead-letter sink back into a bridge through its IngestRaw call.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var letters []*bridgeapi.DeadLetter
+	for _, path := range fs.Args() {
+		read, err := readDeadLetters(path)
+		if err != nil {
+			log.Printf("failed to read %s: %v", path, err)
+			return 2
+		}
+		for _, letter := range read {
+			if (*stage == "" || letter.Stage == *stage) && (*chain == "" || letter.Raw.ChainID == *chain) {
+				letters = append(letters, letter)
+			}
+		}
+	}
+	if *dryRun || len(letters) == 0 {
+		fmt.Printf("%d dead letters to requeue\n", len(letters))
+		return 0
+	}
+
+	conn, err := grpc.NewClient(*operator, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Printf("failed to connect to %s: %v", *operator, err)
+		return 2
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	stream, err := bridgeapi.NewOperatorClient(conn).IngestRaw(ctx)
+	if err != nil {
+		log.Printf("failed to open ingest stream: %v", err)
+		return 1
+	}
+	for _, letter := range letters {
+		if err := stream.Send(&letter.Raw); err != nil {
+			break // CloseAndRecv reports why the stream ended.
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		log.Printf("requeue failed: %v", err)
+		return 1
+	}
+	fmt.Printf("requeued %d dead letters: %d accepted, %d rejected\n", len(letters), resp.Accepted, len(resp.Rejected))
+	for _, rejected := range resp.Rejected {
+		letter := letters[rejected.Index]
+		fmt.Printf("  %s %s (failed at %s %s): %s\n",
+			letter.Raw.ChainID, letter.Raw.MessageType, letter.Stage, letter.Time.Format(time.RFC3339), rejected.Error)
+	}
+	if len(resp.Rejected) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// readDeadLetters reads the JSON lines of a file dead-letter sink, gzip-compressed when the name ends in .gz
+// as rotated files do.
+func readDeadLetters(path string) ([]*bridgeapi.DeadLetter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	var letters []*bridgeapi.DeadLetter
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		letter := new(bridgeapi.DeadLetter)
+		if err := json.Unmarshal(scanner.Bytes(), letter); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		letters = append(letters, letter)
+	}
+	return letters, scanner.Err()
+}
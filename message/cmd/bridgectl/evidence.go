@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"codec/capture"
+	"codec/cometbft/validatorset"
+	"codec/message/abstraction/evidence"
+	"codec/message/abstraction/quorum"
+)
+
+func runEvidence(args []string) int {
+	fs := flag.NewFlagSet("evidence", flag.ExitOnError)
+	validators := fs.String("validators", "", "Validator set as id=power,... ; excuses prevotes that follow a polka when checking locks")
+	validatorsURL := fs.String("validators-url", "", "CometBFT RPC address to read each height's validator set from, instead of -validators")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridgectl evidence [-validators id=power,... | -validators-url URL] capture.jsonl...")
+		fmt.Fprintln(os.Stderr, "Prints the double votes, double proposals, and lock violations found in captures as JSON Lines.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	set, err := parseValidatorSet(*validators)
+	if err != nil {
+		log.Printf("invalid -validators: %v", err)
+		return 2
+	}
+
+	detector := evidence.NewDetector(set)
+	if *validatorsURL != "" {
+		if set != nil {
+			log.Print("-validators and -validators-url are exclusive")
+			return 2
+		}
+		poller := validatorset.NewPoller(*validatorsURL, validatorset.NewTracker())
+		detector = evidence.NewDetectorFrom(poller.Source(context.Background()))
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	for _, path := range fs.Args() {
+		r, err := capture.Open(path)
+		if err != nil {
+			log.Printf("failed to open capture: %v", err)
+			return 2
+		}
+		for {
+			rec, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				r.Close()
+				log.Printf("%s: %v", path, err)
+				return 1
+			}
+			if rec.Canonical == nil {
+				continue
+			}
+			if len(rec.Canonical.RawPayload) == 0 && rec.Raw != nil {
+				rec.Canonical.RawPayload = rec.Raw.Payload
+			}
+			for _, ev := range detector.Observe(rec.Canonical) {
+				if err := encoder.Encode(ev); err != nil {
+					r.Close()
+					log.Printf("failed to write evidence: %v", err)
+					return 1
+				}
+			}
+		}
+		r.Close()
+	}
+	return 0
+}
+
+func parseValidatorSet(spec string) (quorum.ValidatorSet, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	set := quorum.ValidatorSet{}
+	for _, entry := range strings.Split(spec, ",") {
+		id, power, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("%q is not id=power", entry)
+		}
+		n, err := strconv.ParseInt(power, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%q has no positive voting power", entry)
+		}
+		set[id] = n
+	}
+	return set, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"codec/message/abstraction/detect"
+)
+
+func runIdentify(args []string) int {
+	fs := flag.NewFlagSet("identify", flag.ExitOnError)
+	format := fs.String("input", "binary", "How payload files are written (binary|hex|base64)")
+	jsonOut := fs.Bool("json", false, "Print results as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridgectl identify [flags] payload...")
+		fmt.Fprintln(os.Stderr, "Guesses the chain and encoding of unlabeled payloads; use - to read stdin.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	uncertain := false
+	results := make(map[string]detect.Result, fs.NArg())
+	for _, path := range fs.Args() {
+		payload, err := readPayload(path, *format)
+		if err != nil {
+			log.Printf("failed to read %s: %v", path, err)
+			return 2
+		}
+		result := detect.Sniff(payload)
+		if result.ChainType == "" || result.Confidence < detect.MinConfidence {
+			uncertain = true
+		}
+		results[path] = result
+		if !*jsonOut {
+			chain := string(result.ChainType)
+			if chain == "" {
+				chain = "unknown"
+			}
+			encoding := result.Encoding
+			if encoding == "" {
+				encoding = "unknown"
+			}
+			fmt.Printf("%s: %s %s confidence=%.2f (%s)\n", path, chain, encoding, result.Confidence, result.Reason)
+		}
+	}
+
+	if *jsonOut {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			log.Printf("failed to encode results: %v", err)
+			return 2
+		}
+	}
+	if uncertain {
+		return 1
+	}
+	return 0
+}
+
+func readPayload(path, format string) ([]byte, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "binary":
+		return data, nil
+	case "hex":
+		return hex.DecodeString(string(bytes.TrimPrefix(bytes.TrimSpace(data), []byte("0x"))))
+	case "base64":
+		return base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	default:
+		return nil, fmt.Errorf("unknown input format %q", format)
+	}
+}
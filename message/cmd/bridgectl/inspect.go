@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"codec/message/codec"
+)
+
+func runInspect(args []string) int {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	format := fs.String("format", string(codec.FormatAuto), "Payload format (auto or a registered codec format)")
+	input := fs.String("input", "binary", "How payload files are written (binary|hex|base64)")
+	schema := fs.String("ssz-schema", "", "SSZ schema name for -format ssz")
+	protoName := fs.String("proto-message", "", "Protobuf message full name for -format protobuf")
+	synonyms := fs.String("synonyms", "", "Write the suggested synonyms to this YAML file for codec.LoadSynonymsFile")
+	jsonOut := fs.Bool("json", false, "Print reports as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridgectl inspect [flags] payload...")
+		fmt.Fprintln(os.Stderr, "Reports which canonical fields the codec recognizes in payloads, which keys it leaves unmapped,")
+		fmt.Fprintln(os.Stderr, "and synonyms that would map them; use - to read stdin. Exits 1 when something is left unmapped.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	incomplete := false
+	suggested := codec.SynonymFile{Phases: map[string]string{}, Fields: map[string]string{}}
+	reports := make(map[string]*codec.InspectReport, fs.NArg())
+	for _, path := range fs.Args() {
+		payload, err := readPayload(path, *input)
+		if err != nil {
+			log.Printf("failed to read %s: %v", path, err)
+			return 2
+		}
+		report, err := codec.InspectWithOptions(payload, codec.ParseOptions{
+			Format:               codec.Format(*format),
+			SSZSchema:            *schema,
+			ProtoMessageFullName: *protoName,
+		})
+		if err != nil {
+			log.Printf("%s: %v", path, err)
+			return 2
+		}
+		if !report.TypeKnown || len(report.Unmapped) > 0 {
+			incomplete = true
+		}
+		reports[path] = report
+		file := report.SynonymFile()
+		for name, target := range file.Phases {
+			suggested.Phases[name] = target
+		}
+		for name, target := range file.Fields {
+			suggested.Fields[name] = target
+		}
+		if !*jsonOut {
+			fmt.Printf("%s: %s", path, report)
+		}
+	}
+
+	if *jsonOut {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			log.Printf("failed to encode reports: %v", err)
+			return 2
+		}
+	}
+	if *synonyms != "" {
+		data, err := yaml.Marshal(suggested)
+		if err != nil {
+			log.Printf("failed to encode synonyms: %v", err)
+			return 2
+		}
+		if err := os.WriteFile(*synonyms, data, 0o644); err != nil {
+			log.Printf("failed to write %s: %v", *synonyms, err)
+			return 2
+		}
+	}
+	if incomplete {
+		return 1
+	}
+	return 0
+}
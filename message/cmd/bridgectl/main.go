@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+	"codec/message/abstraction/lint"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "lint":
+		os.Exit(runLint(os.Args[2:]))
+	case "identify":
+		os.Exit(runIdentify(os.Args[2:]))
+	case "slice":
+		os.Exit(runSlice(os.Args[2:]))
+	case "reindex":
+		os.Exit(runReindex(os.Args[2:]))
+	case "requeue":
+		os.Exit(runRequeue(os.Args[2:]))
+	case "evidence":
+		os.Exit(runEvidence(os.Args[2:]))
+	case "check":
+		os.Exit(runCheck(os.Args[2:]))
+	case "inspect":
+		os.Exit(runInspect(os.Args[2:]))
+	case "blockstore":
+		os.Exit(runBlockstore(os.Args[2:]))
+	case "help", "-h", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: bridgectl <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  lint       Check hand-written canonical messages before a byzantine experiment")
+	fmt.Fprintln(os.Stderr, "  identify   Guess the chain and encoding of unlabeled payloads")
+	fmt.Fprintln(os.Stderr, "  slice      Print the records of a capture between two heights")
+	fmt.Fprintln(os.Stderr, "  reindex    Rebuild the height index of a capture")
+	fmt.Fprintln(os.Stderr, "  requeue    Feed a bridge's dead letters back into its Operator API")
+	fmt.Fprintln(os.Stderr, "  evidence   Report double votes, double proposals, and lock violations in captures")
+	fmt.Fprintln(os.Stderr, "  check      Check agreement, validity, and progress over captures")
+	fmt.Fprintln(os.Stderr, "  inspect    Report recognized and unmapped fields of a payload and suggest synonyms")
+	fmt.Fprintln(os.Stderr, "  blockstore Export the committed votes of a stopped CometBFT node as a capture")
+}
+
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	chain := fs.String("chain", string(abstraction.ChainTypeCometBFT), "Target chain (cometbft|fabric|besu|kaia)")
+	action := fs.String("action", string(byzantine.ActionNone), "Byzantine action the message will be used with")
+	fix := fs.Bool("fix", false, "Apply automatic fixes and write the corrected message")
+	output := fs.String("o", "", "Path for the fixed message (defaults to overwriting the input)")
+	jsonOut := fs.Bool("json", false, "Print issues as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: bridgectl lint [flags] message.json...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	if *output != "" && fs.NArg() > 1 {
+		log.Printf("-o can only be used with a single input")
+		return 2
+	}
+
+	profile := lint.DefaultProfile(abstraction.ParseChainType(*chain))
+	failed := false
+	reports := make(map[string]*lint.Result, fs.NArg())
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("failed to read %s: %v", path, err)
+			return 2
+		}
+		result := lint.LintJSON(data, profile, byzantine.Action(strings.ToLower(*action)))
+
+		if *fix {
+			if applied := result.Fix(); applied > 0 {
+				target := path
+				if *output != "" {
+					target = *output
+				}
+				if err := writeMessage(target, result.Message); err != nil {
+					log.Printf("failed to write %s: %v", target, err)
+					return 2
+				}
+				// Re-lint so the report reflects what was written.
+				result = &lint.Result{Message: result.Message, Issues: lint.Lint(result.Message, profile, byzantine.Action(strings.ToLower(*action)))}
+				if !*jsonOut {
+					fmt.Printf("%s: applied %d fix(es), wrote %s\n", path, applied, target)
+				}
+			}
+		}
+
+		if result.HasErrors() {
+			failed = true
+		}
+		reports[path] = result
+		if !*jsonOut {
+			printIssues(path, result)
+		}
+	}
+
+	if *jsonOut {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			log.Printf("failed to encode report: %v", err)
+			return 2
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func printIssues(path string, result *lint.Result) {
+	if len(result.Issues) == 0 {
+		fmt.Printf("%s: ok\n", path)
+		return
+	}
+	for _, issue := range result.Issues {
+		fmt.Printf("%s: %s: %s [%s] %s\n", path, issue.Severity, issue.Field, issue.Code, issue.Message)
+		if issue.Suggestion != "" {
+			marker := "suggestion"
+			if issue.Fixable {
+				marker = "fix (-fix)"
+			}
+			fmt.Printf("    %s: %s\n", marker, issue.Suggestion)
+		}
+	}
+}
+
+func writeMessage(path string, msg *abstraction.CanonicalMessage) error {
+	data, err := json.MarshalIndent(msg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
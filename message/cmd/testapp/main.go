@@ -11,6 +11,7 @@ import (
 
 	"codec/message/abstraction"
 	"codec/message/abstraction/validator"
+	"codec/message/codec"
 
 	cometbftAdapter "codec/cometbft/adapter"
 	besuAdapter "codec/hyperledger/besu/adapter"
@@ -328,7 +329,7 @@ func canonicalizeForCompare(m *abstraction.AbstractMessage, formatName string) *
 	}
 	m.RawPayload = nil
 	switch formatName {
-	case "json", "rlp", "msgpack", "bcs", "generic":
+	case "json", "rlp", "msgpack", "bcs", "cbor", "generic":
 		for k, v := range m.Extras {
 			if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
 				m.Extras[k] = bytes.Trim(v, "\"")
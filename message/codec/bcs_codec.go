@@ -1,35 +1,164 @@
 package codec
 
 import (
+	"bytes"
 	"fmt"
+	"math/big"
+	"sort"
+	"time"
 
-	"codec/abstraction"
+	"codec/message/abstraction"
 
 	bcs "github.com/fardream/go-bcs/bcs"
 )
 
 type bcsCodec struct{} //bcs 포맷 parsing/serializing
 
+// bcsMessage는 AbstractMessage의 BCS layout. BCS에는 schema가 없으므로 필드 정의 순서가 곧 직렬화 순서이며,
+// 문자열/vector 길이는 ULEB128, 정수는 little-endian으로 기록된다.
+type bcsMessage struct {
+	Type           string
+	Height         *bcs.Uint128 `bcs:"optional"` //Move의 Option<u128>
+	Round          *bcs.Uint128 `bcs:"optional"`
+	View           *bcs.Uint128 `bcs:"optional"`
+	TimestampUsecs *uint64      `bcs:"optional"` //Aptos BlockInfo와 같이 Unix epoch 기준 마이크로초
+	BlockHash      string
+	PrevHash       string
+	Proposer       string
+	Validator      string
+	Signature      string
+	CommitSeals    []string
+	ViewChanges    []bcsViewChange
+	Extras         []bcsExtra //BCS map: key의 BCS 바이트 기준 오름차순, 중복 없음
+}
+
+type bcsViewChange struct {
+	View      *bcs.Uint128 `bcs:"optional"`
+	Height    *bcs.Uint128 `bcs:"optional"`
+	Validator string
+	Signature string
+}
+
+type bcsExtra struct {
+	Key   string
+	Value []byte
+}
+
 func (bcsCodec) Parse(data []byte, opts ParseOptions) (*abstraction.AbstractMessage, error) {
-	var raw []byte
-	if _, err := bcs.Unmarshal(data, &raw); err == nil {
-		return (jsonCodec{}).Parse(raw, ParseOptions{Format: FormatJSON, OverrideMsgType: opts.OverrideMsgType})
-	}
-	var decoded map[string]interface{}
-	if _, err := bcs.Unmarshal(data, &decoded); err != nil {
+	var m bcsMessage
+	n, err := bcs.Unmarshal(data, &m)
+	if err != nil {
 		return nil, fmt.Errorf("bcs decode: %w", err)
 	}
-	js, err := jsonFromInterface(decoded)
-	if err != nil {
-		return nil, err
+	if n != len(data) { //BCS 값 하나 뒤에 남는 바이트는 허용하지 않음
+		return nil, fmt.Errorf("bcs decode: %d trailing bytes", len(data)-n)
 	}
-	return (jsonCodec{}).Parse(js, ParseOptions{Format: FormatJSON, OverrideMsgType: opts.OverrideMsgType})
-} //bcs 바이트를 AbstractMessage로 변환
+	am := &abstraction.AbstractMessage{
+		Type:           abstraction.MsgType(m.Type),
+		Height:         bcsBigInt(m.Height),
+		Round:          bcsBigInt(m.Round),
+		View:           bcsBigInt(m.View),
+		BlockHash:      m.BlockHash,
+		PrevHash:       m.PrevHash,
+		Proposer:       m.Proposer,
+		Validator:      m.Validator,
+		Signature:      m.Signature,
+		CommitSeals:    m.CommitSeals,
+		Extras:         make(map[string][]byte, len(m.Extras)),
+		RawPayload:     append([]byte(nil), data...), //원본 BCS 바이트
+		OriginalFormat: string(FormatBCS),
+	} //AbstractMessage 초기화
+	if opts.OverrideMsgType != "" { //타입 지정 시
+		am.Type = abstraction.MsgType(opts.OverrideMsgType)
+	} else if mapped, ok := PhaseSynonyms[m.Type]; ok { //유의어 정규화
+		am.Type = abstraction.MsgType(mapped)
+	}
+	if m.TimestampUsecs != nil {
+		am.Timestamp = time.UnixMicro(int64(*m.TimestampUsecs)).UTC()
+	}
+	for _, vc := range m.ViewChanges {
+		am.ViewChanges = append(am.ViewChanges, abstraction.ViewChangeEntry{
+			View:      bcsBigInt(vc.View),
+			Height:    bcsBigInt(vc.Height),
+			Validator: vc.Validator,
+			Signature: vc.Signature,
+		})
+	}
+	for i, e := range m.Extras {
+		if i > 0 && bytes.Compare(bcsKey(m.Extras[i-1].Key), bcsKey(e.Key)) >= 0 { //정렬되지 않았거나 중복된 key는 canonical이 아님
+			return nil, fmt.Errorf("bcs decode: extras are not in canonical order at key %q", e.Key)
+		}
+		am.Extras[e.Key] = e.Value
+	}
+	return am, nil
+} //BCS 바이트를 AbstractMessage로 변환
 
 func (bcsCodec) Serialize(am *abstraction.AbstractMessage, _ SerializeOptions) ([]byte, error) {
-	js, err := (jsonCodec{}).Serialize(am, SerializeOptions{Format: FormatJSON}) //JSON 바이트로 변환
-	if err != nil {
+	m := bcsMessage{
+		Type:        string(am.Type),
+		BlockHash:   am.BlockHash,
+		PrevHash:    am.PrevHash,
+		Proposer:    am.Proposer,
+		Validator:   am.Validator,
+		Signature:   am.Signature,
+		CommitSeals: am.CommitSeals,
+	}
+	var err error
+	if m.Height, err = bcsUint128("height", am.Height); err != nil {
 		return nil, err
 	}
-	return bcs.Marshal(js) //bcs payload를 []byte(JSON) 형태로 serializing
+	if m.Round, err = bcsUint128("round", am.Round); err != nil {
+		return nil, err
+	}
+	if m.View, err = bcsUint128("view", am.View); err != nil {
+		return nil, err
+	}
+	if !am.Timestamp.IsZero() {
+		usecs := am.Timestamp.UnixMicro()
+		if usecs < 0 {
+			return nil, fmt.Errorf("bcs encode: timestamp %s is before the Unix epoch", am.Timestamp)
+		}
+		u := uint64(usecs)
+		m.TimestampUsecs = &u
+	}
+	for i, e := range am.ViewChanges {
+		vc := bcsViewChange{Validator: e.Validator, Signature: e.Signature}
+		if vc.View, err = bcsUint128(fmt.Sprintf("view_changes[%d].view", i), e.View); err != nil {
+			return nil, err
+		}
+		if vc.Height, err = bcsUint128(fmt.Sprintf("view_changes[%d].height", i), e.Height); err != nil {
+			return nil, err
+		}
+		m.ViewChanges = append(m.ViewChanges, vc)
+	}
+	for k, v := range am.Extras {
+		m.Extras = append(m.Extras, bcsExtra{Key: k, Value: v})
+	}
+	sort.Slice(m.Extras, func(i, j int) bool { //map 순서와 무관하게 같은 바이트가 나오도록 정렬
+		return bytes.Compare(bcsKey(m.Extras[i].Key), bcsKey(m.Extras[j].Key)) < 0
+	})
+	return bcs.Marshal(m)
 } //AbstractMessage를 BCS 바이트로 변환
+
+func bcsUint128(field string, x *big.Int) (*bcs.Uint128, error) {
+	if x == nil {
+		return nil, nil
+	}
+	u, err := bcs.NewUint128FromBigInt(x)
+	if err != nil {
+		return nil, fmt.Errorf("bcs encode: %s does not fit in u128: %w", field, err)
+	}
+	return u, nil
+} //*big.Int를 Option<u128> 값으로 변환, 음수나 2^128 이상은 에러
+
+func bcsBigInt(u *bcs.Uint128) *big.Int {
+	if u == nil {
+		return nil
+	}
+	return u.Big()
+} //Option<u128> 값을 *big.Int로 변환
+
+func bcsKey(key string) []byte {
+	b, _ := bcs.Marshal(key)
+	return b
+} //map key 정렬 기준인 key의 BCS 바이트(ULEB128 길이 + UTF-8)
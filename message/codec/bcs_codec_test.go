@@ -0,0 +1,87 @@
+package codec
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+func TestBCSRoundTrip(t *testing.T) {
+	want := sampleAbstractMessage()
+	want.Timestamp = want.Timestamp.Truncate(time.Microsecond) // BCS keeps microseconds
+	want.Signature = strings.Repeat("s", 200)                  // 128 bytes and more take a two-byte ULEB128 length
+
+	data, err := Serialize(want, SerializeOptions{Format: FormatBCS})
+	if err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	got, err := Parse(data, ParseOptions{Format: FormatBCS})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !bytes.Equal(got.RawPayload, data) || got.OriginalFormat != string(FormatBCS) {
+		t.Fatalf("parse should keep the payload and format, got %q %q", got.RawPayload, got.OriginalFormat)
+	}
+	got.RawPayload, got.OriginalFormat = nil, ""
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip changed the message\n got: %+v\nwant: %+v", got, want)
+	}
+	if !bytes.Contains(data, append([]byte{0xc8, 0x01}, want.Signature...)) {
+		t.Fatal("a 200-byte string should carry the ULEB128 length c8 01")
+	}
+
+	for i := 0; i < 10; i++ { // map iteration order must not leak into the bytes
+		again, err := Serialize(got, SerializeOptions{Format: FormatBCS})
+		if err != nil {
+			t.Fatalf("serialize again: %v", err)
+		}
+		if !bytes.Equal(again, data) {
+			t.Fatal("serializing the same message twice produced different bytes")
+		}
+	}
+}
+
+func TestBCSLayout(t *testing.T) {
+	data, err := Serialize(&abstraction.AbstractMessage{
+		Type:   "Vote",
+		Height: big.NewInt(5),
+		Extras: map[string][]byte{"bb": {1}, "c": {2}},
+	}, SerializeOptions{Format: FormatBCS})
+	if err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	want := []byte{4, 'V', 'o', 't', 'e'}                                  //type
+	want = append(want, 1, 5, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0) //height: Some(u128 5)
+	want = append(want, 0, 0, 0)                                           //round, view, timestamp: None
+	want = append(want, 0, 0, 0, 0, 0)                                     //block_hash..signature: ""
+	want = append(want, 0, 0)                                              //commit_seals, view_changes: []
+	want = append(want, 2, 1, 'c', 1, 2, 2, 'b', 'b', 1, 1)                //extras: the shorter key sorts first by its BCS bytes
+	if !bytes.Equal(data, want) {
+		t.Fatalf("unexpected layout\n got: %x\nwant: %x", data, want)
+	}
+
+	if _, err := Parse(append(data, 0), ParseOptions{Format: FormatBCS}); err == nil {
+		t.Error("expected an error for trailing bytes")
+	}
+	unsorted := append(append([]byte(nil), want[:len(want)-10]...), 2, 2, 'b', 'b', 1, 1, 1, 'c', 1, 2)
+	if _, err := Parse(unsorted, ParseOptions{Format: FormatBCS}); err == nil {
+		t.Error("expected an error for extras out of canonical order")
+	}
+}
+
+func TestBCSRejectsValuesOutsideItsTypes(t *testing.T) {
+	for name, am := range map[string]*abstraction.AbstractMessage{
+		"negative height": {Type: "Vote", Height: big.NewInt(-1)},
+		"huge round":      {Type: "Vote", Round: new(big.Int).Lsh(big.NewInt(1), 128)},
+		"ancient time":    {Type: "Vote", Timestamp: time.Date(1960, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		if _, err := Serialize(am, SerializeOptions{Format: FormatBCS}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"codec/message/abstraction"
+)
+
+type cborCodec struct{} //CBOR(RFC 8949) 포맷 parsing/serializing
+
+// CBOR major type. 각 data item은 major type(상위 3비트)과 argument(하위 5비트 + 후속 바이트)로 시작한다.
+const (
+	cborUint   = 0 << 5
+	cborNegInt = 1 << 5
+	cborBytes  = 2 << 5
+	cborText   = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+	cborTag    = 6 << 5
+	cborSimple = 7 << 5
+
+	cborTagDateTime  = 0 //RFC3339 문자열 시간
+	cborTagEpoch     = 1 //epoch seconds 시간
+	cborTagPosBignum = 2 //양수 bignum
+	cborTagNegBignum = 3 //음수 bignum, 값은 -1-n
+
+	cborMaxDepth = 64 //중첩 한도
+)
+
+func (cborCodec) Parse(data []byte, opts ParseOptions) (*abstraction.AbstractMessage, error) {
+	d := &cborDecoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, fmt.Errorf("cbor decode: %w", err)
+	}
+	if d.pos != len(data) { //data item 하나 뒤에 남는 바이트는 허용하지 않음
+		return nil, fmt.Errorf("cbor decode: %d trailing bytes", len(data)-d.pos)
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cbor decode: top-level item is %T, not a map", v)
+	}
+	am := &abstraction.AbstractMessage{
+		Extras:         map[string][]byte{},          //표준화되지 않은 필드
+		RawPayload:     append([]byte(nil), data...), //원본 CBOR 바이트
+		OriginalFormat: string(FormatCBOR),
+	} //AbstractMessage 초기화
+	if err := parseFieldMap(m, am, opts); err != nil {
+		return nil, fmt.Errorf("cbor %w", err)
+	}
+	return am, nil
+} //CBOR 바이트를 AbstractMessage로 변환
+
+func (cborCodec) Serialize(am *abstraction.AbstractMessage, _ SerializeOptions) ([]byte, error) {
+	out := fieldMap(am, func(x *big.Int) interface{} { return x }) //정수 표현은 encoder가 결정
+	var buf bytes.Buffer
+	if err := cborEncode(&buf, out); err != nil {
+		return nil, fmt.Errorf("cbor encode: %w", err)
+	}
+	return buf.Bytes(), nil
+} //AbstractMessage를 canonical CBOR 바이트로 변환
+
+// cborEncode는 RFC 8949 §4.2.1 core deterministic encoding을 따른다.
+// argument는 가장 짧은 형태, 길이는 항상 definite, map key는 encoding된 바이트의 사전순으로 기록한다.
+func cborEncode(buf *bytes.Buffer, v interface{}) error {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteByte(cborSimple | 22) //null
+	case bool:
+		if t {
+			buf.WriteByte(cborSimple | 21)
+		} else {
+			buf.WriteByte(cborSimple | 20)
+		}
+	case string:
+		cborHead(buf, cborText, uint64(len(t)))
+		buf.WriteString(t)
+	case []byte:
+		cborHead(buf, cborBytes, uint64(len(t)))
+		buf.Write(t)
+	case *big.Int:
+		cborBigInt(buf, t)
+	case time.Time: //나노초까지 보존하도록 tag 0 문자열로 기록
+		cborHead(buf, cborTag, cborTagDateTime)
+		return cborEncode(buf, t.UTC().Format(time.RFC3339Nano))
+	case []string:
+		cborHead(buf, cborArray, uint64(len(t)))
+		for _, s := range t {
+			cborEncode(buf, s)
+		}
+	case []map[string]interface{}:
+		cborHead(buf, cborArray, uint64(len(t)))
+		for _, e := range t {
+			if err := cborEncode(buf, e); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		type entry struct{ key, value []byte }
+		entries := make([]entry, 0, len(t))
+		for k, e := range t {
+			var kb, vb bytes.Buffer
+			cborEncode(&kb, k)
+			if err := cborEncode(&vb, e); err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+			entries = append(entries, entry{kb.Bytes(), vb.Bytes()})
+		}
+		sort.Slice(entries, func(i, j int) bool { //encoding된 key의 바이트 사전순
+			return bytes.Compare(entries[i].key, entries[j].key) < 0
+		})
+		cborHead(buf, cborMap, uint64(len(entries)))
+		for _, e := range entries {
+			buf.Write(e.key)
+			buf.Write(e.value)
+		}
+	default:
+		return fmt.Errorf("unsupported value %T", v)
+	}
+	return nil
+} //Go 값을 deterministic CBOR로 기록
+
+func cborHead(buf *bytes.Buffer, major byte, n uint64) {
+	var b [9]byte
+	switch {
+	case n < 24:
+		buf.WriteByte(major | byte(n))
+		return
+	case n <= math.MaxUint8:
+		b[0], b[1] = major|24, byte(n)
+		buf.Write(b[:2])
+	case n <= math.MaxUint16:
+		b[0] = major | 25
+		binary.BigEndian.PutUint16(b[1:], uint16(n))
+		buf.Write(b[:3])
+	case n <= math.MaxUint32:
+		b[0] = major | 26
+		binary.BigEndian.PutUint32(b[1:], uint32(n))
+		buf.Write(b[:5])
+	default:
+		b[0] = major | 27
+		binary.BigEndian.PutUint64(b[1:], n)
+		buf.Write(b[:9])
+	}
+} //major type과 argument를 가장 짧은 형태로 기록
+
+func cborBigInt(buf *bytes.Buffer, x *big.Int) {
+	if x.Sign() >= 0 {
+		if x.IsUint64() { //64비트 이내는 정수로
+			cborHead(buf, cborUint, x.Uint64())
+			return
+		}
+		cborHead(buf, cborTag, cborTagPosBignum)
+		cborEncode(buf, x.Bytes()) //big-endian, 앞쪽 0 바이트 없음
+		return
+	}
+	n := new(big.Int).Neg(x) //음수는 -1-n으로 기록
+	n.Sub(n, big.NewInt(1))
+	if n.IsUint64() {
+		cborHead(buf, cborNegInt, n.Uint64())
+		return
+	}
+	cborHead(buf, cborTag, cborTagNegBignum)
+	cborEncode(buf, n.Bytes())
+} //*big.Int를 정수 또는 bignum(tag 2/3)으로 기록
+
+// cborDecoder는 indefinite 길이를 포함한 일반 CBOR를 읽는다. 다른 도구가 만든 입력도 받을 수 있도록 deterministic 여부는 검사하지 않는다.
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) head() (major byte, info byte, n uint64, err error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, 0, fmt.Errorf("unexpected end of data")
+	}
+	b := d.data[d.pos]
+	d.pos++
+	major, info = b&0xe0, b&0x1f
+	size := 0
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		size = 1 << (info - 24)
+	case info == 31: //indefinite 길이 또는 break
+		return major, info, 0, nil
+	default:
+		return 0, 0, 0, fmt.Errorf("reserved additional info %d", info)
+	}
+	if len(d.data)-d.pos < size {
+		return 0, 0, 0, fmt.Errorf("unexpected end of data")
+	}
+	for _, c := range d.data[d.pos : d.pos+size] {
+		n = n<<8 | uint64(c)
+	}
+	d.pos += size
+	return major, info, n, nil
+} //data item의 major type과 argument를 읽음
+
+func (d *cborDecoder) value(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, fmt.Errorf("nesting deeper than %d", cborMaxDepth)
+	}
+	start := d.pos
+	major, info, n, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	if info == 31 && (major == cborUint || major == cborNegInt || major == cborTag) {
+		return nil, fmt.Errorf("indefinite length not allowed for major type %d", major>>5)
+	}
+	switch major {
+	case cborUint:
+		return n, nil
+	case cborNegInt:
+		if n <= math.MaxInt64 {
+			return -1 - int64(n), nil
+		}
+		x := new(big.Int).SetUint64(n)
+		return x.Neg(x.Add(x, big.NewInt(1))), nil
+	case cborBytes, cborText:
+		b, err := d.chunks(major, info, n)
+		if err != nil {
+			return nil, err
+		}
+		if major == cborBytes {
+			return b, nil
+		}
+		if !utf8.Valid(b) {
+			return nil, fmt.Errorf("text string at offset %d is not UTF-8", start)
+		}
+		return string(b), nil
+	case cborArray:
+		var arr []interface{}
+		for i := uint64(0); info == 31 || i < n; i++ {
+			if info == 31 && d.isBreak() {
+				break
+			}
+			if info != 31 && uint64(len(d.data)-d.pos) < n-i { //item은 최소 1바이트
+				return nil, fmt.Errorf("array length %d exceeds data", n)
+			}
+			e, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, e)
+		}
+		if arr == nil {
+			arr = []interface{}{}
+		}
+		return arr, nil
+	case cborMap:
+		m := map[string]interface{}{}
+		for i := uint64(0); info == 31 || i < n; i++ {
+			if info == 31 && d.isBreak() {
+				break
+			}
+			if info != 31 && uint64(len(d.data)-d.pos) < 2*(n-i) { //key, value 각 최소 1바이트
+				return nil, fmt.Errorf("map length %d exceeds data", n)
+			}
+			kv, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			k, ok := kv.(string)
+			if !ok {
+				return nil, fmt.Errorf("map key %v is not a text string", kv)
+			}
+			if _, dup := m[k]; dup {
+				return nil, fmt.Errorf("duplicate map key %q", k)
+			}
+			if m[k], err = d.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case cborTag:
+		content, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return cborTagged(n, content)
+	default: //simple/float
+		switch {
+		case info == 20:
+			return false, nil
+		case info == 21:
+			return true, nil
+		case info == 22 || info == 23: //null, undefined
+			return nil, nil
+		case info == 25:
+			return cborHalf(uint16(n)), nil
+		case info == 26:
+			return float64(math.Float32frombits(uint32(n))), nil
+		case info == 27:
+			return math.Float64frombits(n), nil
+		case info == 31:
+			return nil, fmt.Errorf("unexpected break at offset %d", start)
+		}
+		return nil, fmt.Errorf("unsupported simple value %d", n)
+	}
+} //data item 하나를 Go 값으로 읽음
+
+func (d *cborDecoder) chunks(major, info byte, n uint64) ([]byte, error) {
+	if info != 31 {
+		if uint64(len(d.data)-d.pos) < n {
+			return nil, fmt.Errorf("string length %d exceeds data", n)
+		}
+		b := append([]byte(nil), d.data[d.pos:d.pos+int(n)]...)
+		d.pos += int(n)
+		return b, nil
+	}
+	b := []byte{}
+	for !d.isBreak() { //indefinite 문자열은 같은 major type의 definite chunk 연속
+		cm, ci, cn, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if cm != major || ci == 31 {
+			return nil, fmt.Errorf("invalid chunk in indefinite-length string")
+		}
+		c, err := d.chunks(cm, ci, cn)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, c...)
+	}
+	return b, nil
+} //byte/text string 본문을 읽음
+
+func (d *cborDecoder) isBreak() bool {
+	if d.pos < len(d.data) && d.data[d.pos] == cborSimple|31 {
+		d.pos++
+		return true
+	}
+	return false
+} //break(0xff)이면 소비
+
+func cborTagged(tag uint64, content interface{}) (interface{}, error) {
+	switch tag {
+	case cborTagDateTime:
+		s, ok := content.(string)
+		if !ok {
+			return nil, fmt.Errorf("tag 0 content is %T, not a string", content)
+		}
+		tm, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("tag 0: %w", err)
+		}
+		return tm.UTC(), nil
+	case cborTagEpoch:
+		switch t := content.(type) {
+		case uint64:
+			if t > math.MaxInt64 {
+				return nil, fmt.Errorf("tag 1 epoch %d out of range", t)
+			}
+			return time.Unix(int64(t), 0).UTC(), nil
+		case int64:
+			return time.Unix(t, 0).UTC(), nil
+		case float64:
+			if math.IsNaN(t) || math.IsInf(t, 0) {
+				return nil, fmt.Errorf("tag 1 epoch %v out of range", t)
+			}
+			sec, frac := math.Modf(t)
+			return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+		}
+		return nil, fmt.Errorf("tag 1 content is %T, not a number", content)
+	case cborTagPosBignum, cborTagNegBignum:
+		b, ok := content.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("tag %d content is %T, not a byte string", tag, content)
+		}
+		x := new(big.Int).SetBytes(b)
+		if tag == cborTagNegBignum { //-1-n
+			x.Neg(x.Add(x, big.NewInt(1)))
+		}
+		return x, nil
+	}
+	return content, nil //알 수 없는 tag는 내용만 사용
+} //의미를 아는 tag(시간, bignum)를 Go 값으로 변환
+
+func cborHalf(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var v float64
+	switch exp {
+	case 0: //subnormal
+		v = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			v = math.Inf(1)
+		} else {
+			v = math.NaN()
+		}
+	default:
+		v = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -v
+	}
+	return v
+} //half precision(binary16) 실수를 float64로 변환
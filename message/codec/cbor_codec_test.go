@@ -0,0 +1,112 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+func TestCBORRoundTrip(t *testing.T) {
+	want := sampleAbstractMessage()
+	data, err := Serialize(want, SerializeOptions{Format: FormatCBOR})
+	if err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	got, err := Parse(data, ParseOptions{Format: FormatCBOR})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !bytes.Equal(got.RawPayload, data) || got.OriginalFormat != string(FormatCBOR) {
+		t.Fatalf("parse should keep the payload and format, got %x %q", got.RawPayload, got.OriginalFormat)
+	}
+	got.RawPayload, got.OriginalFormat, got.OriginalFieldNames = nil, "", nil
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip changed the message\n got: %+v\nwant: %+v", got, want)
+	}
+
+	again, err := Serialize(got, SerializeOptions{Format: FormatCBOR})
+	if err != nil {
+		t.Fatalf("serialize again: %v", err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Fatal("serializing the same message twice produced different bytes")
+	}
+}
+
+// TestCBORCanonicalLayout pins the deterministic encoding: shortest arguments, bignums only past 64 bits and
+// map keys ordered by their encoded bytes, so shorter keys come first.
+func TestCBORCanonicalLayout(t *testing.T) {
+	am := &abstraction.AbstractMessage{
+		Type:      "Prevote",
+		Height:    big.NewInt(500),
+		Round:     big.NewInt(-1),
+		View:      new(big.Int).Lsh(big.NewInt(1), 64),
+		Timestamp: time.Unix(0, 0),
+		Extras:    map[string][]byte{"x": {0x01}},
+	}
+	data, err := Serialize(am, SerializeOptions{Format: FormatCBOR})
+	if err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	want := "a6" +
+		"61" + "78" + "41" + "01" + // "x": h'01'
+		"64" + "74797065" + "67" + "507265766f7465" + // "type": "Prevote"
+		"64" + "76696577" + "c2" + "49" + "010000000000000000" + // "view": 2(h'010000000000000000')
+		"65" + "726f756e64" + "20" + // "round": -1
+		"66" + "686569676874" + "19" + "01f4" + // "height": 500
+		"69" + "74696d657374616d70" + "c0" + "74" + hex.EncodeToString([]byte("1970-01-01T00:00:00Z"))
+	if got := hex.EncodeToString(data); got != want {
+		t.Fatalf("unexpected encoding\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestCBORParsesForeignEncodings(t *testing.T) {
+	// An indefinite-length map with synonym keys, a tag 1 epoch, a negative bignum and a chunked text string
+	data, _ := hex.DecodeString("bf" +
+		"64" + "74797065" + "6a" + "50726550726570617265" + // "type": "PrePrepare"
+		"67" + "7365715f6e756d" + "07" + // "seq_num": 7
+		"6b" + "766965775f6e756d626572" + "c3" + "49" + "010000000000000000" + // "view_number": -1-2^64
+		"6a" + "637265617465645f6174" + "c1" + "1a" + "65e1c9d5" + // "created_at": 1(1709296085)
+		"66" + "646967657374" + "7f" + "62" + "3078" + "63" + "616263" + "ff" + // "digest": (_ "0x", "abc")
+		"6c" + "636f6d6d69745f7365616c73" + "9f" + "61" + "41" + "ff" + // "commit_seals": [_ "A"]
+		"64" + "6e6f7465" + "f9" + "3e00" + // "note": 1.5
+		"ff")
+	got, err := Parse(data, ParseOptions{Format: FormatCBOR})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	view, _ := new(big.Int).SetString("-18446744073709551617", 10)
+	if got.Type != "Proposal" || got.Height.Int64() != 7 || got.View.Cmp(view) != 0 || got.BlockHash != "0xabc" ||
+		!got.Timestamp.Equal(time.Unix(1709296085, 0)) || !reflect.DeepEqual(got.CommitSeals, []string{"A"}) {
+		t.Fatalf("synonyms and value encodings not normalized: %+v", got)
+	}
+	if string(got.Extras["note"]) != "1.5" {
+		t.Fatalf("non-binary extras should be kept as JSON like the JSON codec does, got %q", got.Extras["note"])
+	}
+}
+
+func TestCBORRejectsMalformedInput(t *testing.T) {
+	for name, tc := range map[string]struct{ hex, want string }{
+		"truncated":      {"a1646e6f7465", "end of data"},
+		"trailing bytes": {"a000", "trailing"},
+		"not a map":      {"83010203", "not a map"},
+		"integer key":    {"a10102", "not a text string"},
+		"duplicate key":  {"a2617801617802", "duplicate"},
+		"reserved info":  {"1c", "reserved"},
+		"stray break":    {"a16178ff", "break"},
+		"bad height":     {"a166686569676874f5", "field height"},
+		"bad date":       {"a16474797065c06474696d65", "tag 0"},
+		"huge length":    {"9b00000000ffffffff", "exceeds data"},
+	} {
+		data, _ := hex.DecodeString(tc.hex)
+		if _, err := Parse(data, ParseOptions{Format: FormatCBOR}); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: want error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
@@ -3,10 +3,9 @@ package codec
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"unicode/utf8"
 
-	"codec/abstraction"
+	"codec/message/abstraction"
 )
 
 type Format string
@@ -19,6 +18,8 @@ const (
 	FormatRLP      Format = "rlp"      //Ethereum RLP
 	FormatMsgPack  Format = "msgpack"  //MessagePack
 	FormatBCS      Format = "bcs"      //BCS(Binary Canonical Serialization)
+	FormatCBOR     Format = "cbor"     //CBOR(RFC 8949, canonical encoding)
+	FormatSSZ      Format = "ssz"      //SSZ(Ethereum consensus), schema 필요
 )
 
 type ParseOptions struct {
@@ -27,6 +28,8 @@ type ParseOptions struct {
 	ProtoMessageFullName string                  //protobuf 메시지 full name
 	DescriptorProvider   ProtoDescriptorProvider //protobuf 동적 parsing에 필요한 descriptor
 	ProtoDiscardUnknown  bool                    //protobuf → JSON 변환 시 지원되지 않는 필드 무시
+	SSZSchema            string                  //ssz schema 이름
+	SSZRegistry          *SSZRegistry            //ssz schema registry, nil일 시 DefaultSSZRegistry
 }
 
 type SerializeOptions struct {
@@ -34,6 +37,8 @@ type SerializeOptions struct {
 	ProtoMessageFullName string                  //protobuf로 직렬화할 때 대상 메시지 full name
 	DescriptorProvider   ProtoDescriptorProvider //protobuf 메시지 동적 생성에 필요한 descriptor
 	ProtoDiscardUnknown  bool                    //JSON→protobuf 역매핑 시 지원되지 않는 필드 무시
+	SSZSchema            string                  //ssz schema 이름, 비어있을 시 OriginalMsgName
+	SSZRegistry          *SSZRegistry            //ssz schema registry, nil일 시 DefaultSSZRegistry
 }
 
 type Codec interface {
@@ -66,43 +71,21 @@ func Parse(data []byte, opts ParseOptions) (*abstraction.AbstractMessage, error)
 	if format == "" || format == FormatAuto { // 빈 값 또는 auto일 시
 		format = DetectFormat(data) //입력으로 포맷 추정
 	}
-	switch format {
-	case FormatGeneric:
-		return (genericCodec{}).Parse(data, opts)
-	case FormatJSON:
-		return (jsonCodec{}).Parse(data, opts)
-	case FormatProtobuf:
-		return (protoCodec{}).Parse(data, opts)
-	case FormatRLP:
-		return (rlpCodec{}).Parse(data, opts)
-	case FormatMsgPack:
-		return (msgpackCodec{}).Parse(data, opts)
-	case FormatBCS:
-		return (bcsCodec{}).Parse(data, opts)
-	default:
-		return nil, fmt.Errorf("unsupported format: %s", format) //지원되지 않는 포맷
+	m, err := lookupFormat(format)
+	if err != nil {
+		return nil, err
 	}
-} //포맷에 맞는 codec으로 parsing
+	return m.Parse(data, opts)
+} //포맷에 등록된 codec으로 parsing
 
 func Serialize(am *abstraction.AbstractMessage, opts SerializeOptions) ([]byte, error) {
 	format := opts.Format                     //출력 포맷 확인
 	if format == "" || format == FormatAuto { //지정 안 되어있을 시
 		format = FormatGeneric //human-readable generic 사용
 	}
-	switch format {
-	case FormatGeneric:
-		return (genericCodec{}).Serialize(am, opts)
-	case FormatJSON:
-		return (jsonCodec{}).Serialize(am, opts)
-	case FormatProtobuf:
-		return (protoCodec{}).Serialize(am, opts)
-	case FormatRLP:
-		return (rlpCodec{}).Serialize(am, opts)
-	case FormatMsgPack:
-		return (msgpackCodec{}).Serialize(am, opts)
-	case FormatBCS:
-		return (bcsCodec{}).Serialize(am, opts)
-	default:
-		return nil, fmt.Errorf("unsupported format: %s", format) //지원되지 않는 포맷
+	m, err := lookupFormat(format)
+	if err != nil {
+		return nil, err
 	}
-} //포맷에 맞는 codec으로 직렬화
+	return m.Serialize(am, opts)
+} //포맷에 등록된 codec으로 직렬화
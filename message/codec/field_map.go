@@ -0,0 +1,238 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+// msgpack, CBOR처럼 key-value map을 그대로 표현하는 binary 포맷의 공용 변환.
+// decoding된 map의 key는 JSON 경로와 같이 유의어로 정규화하고, 값은 포맷이 가진 정수/바이트/시간 타입을 그대로 받는다.
+
+func parseFieldMap(m map[string]interface{}, am *abstraction.AbstractMessage, opts ParseOptions) error {
+	if am.Extras == nil {
+		am.Extras = map[string][]byte{}
+	}
+	if am.OriginalFieldNames == nil {
+		am.OriginalFieldNames = map[string]string{} //표준 필드명 -> 원본 필드명
+	}
+	if opts.OverrideMsgType != "" { //타입 지정 시
+		am.Type = abstraction.MsgType(opts.OverrideMsgType)
+	} else if s, ok := m["type"].(string); ok { //type 키가 문자열일 때만 처리
+		if mapped, ok := PhaseSynonyms[s]; ok { //유의어 정규화
+			am.Type = abstraction.MsgType(mapped)
+		} else {
+			am.Type = abstraction.MsgType(s)
+		}
+	}
+	for kRaw, v := range m { //kRaw는 원본 키
+		key := kRaw
+		if mapped, ok := FieldSynonyms[key]; ok { //유의어 정규화
+			key = mapped
+			am.OriginalFieldNames[key] = kRaw //원본 필드명 기록
+		}
+		var err error
+		switch key {
+		case "Height":
+			am.Height, err = fieldBigInt(v)
+		case "Round":
+			am.Round, err = fieldBigInt(v)
+		case "View":
+			am.View, err = fieldBigInt(v)
+		case "BlockHash":
+			am.BlockHash = fieldString(v)
+		case "PrevHash":
+			am.PrevHash = fieldString(v)
+		case "Timestamp":
+			am.Timestamp, err = fieldTime(v)
+		case "Proposer":
+			am.Proposer = fieldString(v)
+		case "Validator":
+			am.Validator = fieldString(v)
+		case "Signature":
+			am.Signature = fieldString(v)
+		case "CommitSeals":
+			am.CommitSeals, err = fieldStrings(v)
+		case "ViewChanges":
+			am.ViewChanges, err = fieldViewChanges(v)
+		case "type":
+		default:
+			if b, ok := v.([]byte); ok { //binary 값은 바이트 그대로 보존
+				am.Extras[kRaw] = b
+				break
+			}
+			am.Extras[kRaw], err = json.Marshal(v) //그 외에는 JSON codec과 같이 JSON 바이트로 보존
+		}
+		if err != nil {
+			return fmt.Errorf("field %s: %w", kRaw, err)
+		}
+	}
+	return nil
+} //decoding된 map을 AbstractMessage 필드로 정규화
+
+func fieldMap(am *abstraction.AbstractMessage, bigValue func(*big.Int) interface{}) map[string]interface{} {
+	out := map[string]interface{}{
+		"type": string(am.Type),
+	}
+	if am.Height != nil { //필드가 존재할 시
+		out["height"] = bigValue(am.Height)
+	}
+	if am.Round != nil {
+		out["round"] = bigValue(am.Round)
+	}
+	if am.View != nil {
+		out["view"] = bigValue(am.View)
+	}
+	if am.BlockHash != "" {
+		out["block_hash"] = am.BlockHash
+	}
+	if am.PrevHash != "" {
+		out["prev_hash"] = am.PrevHash
+	}
+	if !am.Timestamp.IsZero() {
+		out["timestamp"] = am.Timestamp.UTC() //포맷의 시간 타입으로 나노초까지 보존
+	}
+	if am.Proposer != "" {
+		out["proposer"] = am.Proposer
+	}
+	if am.Validator != "" {
+		out["validator"] = am.Validator
+	}
+	if am.Signature != "" {
+		out["signature"] = am.Signature
+	}
+	if len(am.CommitSeals) > 0 {
+		out["commit_seals"] = am.CommitSeals
+	}
+	if len(am.ViewChanges) > 0 {
+		vc := make([]map[string]interface{}, 0, len(am.ViewChanges))
+		for _, e := range am.ViewChanges {
+			item := map[string]interface{}{
+				"validator": e.Validator,
+				"signature": e.Signature,
+			}
+			if e.View != nil { //nil일 시 키 생략
+				item["view"] = bigValue(e.View)
+			}
+			if e.Height != nil {
+				item["height"] = bigValue(e.Height)
+			}
+			vc = append(vc, item)
+		}
+		out["view_changes"] = vc
+	}
+	for k, v := range am.Extras { //Extras는 binary 값으로 병합
+		if _, exists := out[k]; exists {
+			continue
+		}
+		out[k] = v
+	} //동일 key 가진 필드 중 표준 필드 우선
+	return out
+} //AbstractMessage를 포맷 encoder에 넘길 map으로 변환, 정수 표현은 bigValue가 결정
+
+func fieldBigInt(v interface{}) (*big.Int, error) {
+	if v == nil {
+		return nil, nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() { //정수는 포맷과 크기에 따라 int8~uint64로 decoding됨
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64: //정수 값인 실수만 허용
+		if f := rv.Float(); f == math.Trunc(f) && !math.IsInf(f, 0) {
+			bi, _ := big.NewFloat(f).Int(nil)
+			return bi, nil
+		}
+		return nil, fmt.Errorf("not an integer: %v", v)
+	}
+	switch t := v.(type) {
+	case *big.Int: //CBOR bignum
+		return new(big.Int).Set(t), nil
+	case string:
+		if bi, ok := new(big.Int).SetString(t, 10); ok {
+			return bi, nil
+		}
+	case []byte:
+		if bi, ok := new(big.Int).SetString(string(t), 10); ok {
+			return bi, nil
+		}
+	}
+	return nil, fmt.Errorf("not an integer: %v", v)
+} //정수/bignum/10진수 문자열을 *big.Int로 변환
+
+func fieldTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time: //포맷의 시간 타입
+		return t.UTC(), nil
+	case string:
+		if tm, err := time.Parse(time.RFC3339Nano, t); err == nil { //RFC3339
+			return tm.UTC(), nil
+		}
+	default: //epoch seconds
+		if secs, err := fieldBigInt(v); err == nil && secs.IsInt64() {
+			return time.Unix(secs.Int64(), 0).UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("not a timestamp: %v", v)
+} //시간 타입/RFC3339 문자열/epoch seconds를 time.Time으로 변환
+
+func fieldString(v interface{}) string {
+	if b, ok := v.([]byte); ok { //binary는 문자열로
+		return string(b)
+	}
+	return toString(v)
+} //문자열/binary 값을 문자열로 변환
+
+func fieldStrings(v interface{}) ([]string, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, e := range t {
+			out = append(out, fieldString(e))
+		}
+		return out, nil
+	case string, []byte:
+		return []string{fieldString(t)}, nil
+	}
+	return nil, fmt.Errorf("not a string array: %v", v)
+} //배열을 []string으로 변환
+
+func fieldViewChanges(v interface{}) ([]abstraction.ViewChangeEntry, error) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("not an array: %v", v)
+	}
+	entries := make([]abstraction.ViewChangeEntry, 0, len(arr))
+	for i, iv := range arr {
+		obj, ok := iv.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("entry %d is not a map", i)
+		}
+		view, err := fieldBigInt(obj["view"])
+		if err != nil {
+			return nil, fmt.Errorf("entry %d view: %w", i, err)
+		}
+		height, err := fieldBigInt(obj["height"])
+		if err != nil {
+			return nil, fmt.Errorf("entry %d height: %w", i, err)
+		}
+		entries = append(entries, abstraction.ViewChangeEntry{
+			View:      view,
+			Height:    height,
+			Validator: fieldString(obj["validator"]),
+			Signature: fieldString(obj["signature"]),
+		})
+	}
+	return entries, nil
+} //view/height/validator/signature 맵의 배열을 []ViewChangeEntry로 변환
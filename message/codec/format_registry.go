@@ -0,0 +1,64 @@
+package codec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Marshaller는 RegisterFormat으로 등록하는 포맷 구현. 내장 codec과 같은 Parse/Serialize를 가진다.
+type Marshaller = Codec
+
+var formats = struct {
+	sync.RWMutex
+	byName map[Format]Marshaller
+}{
+	byName: map[Format]Marshaller{
+		FormatGeneric:  genericCodec{},
+		FormatJSON:     jsonCodec{},
+		FormatProtobuf: protoCodec{},
+		FormatRLP:      rlpCodec{},
+		FormatMsgPack:  msgpackCodec{},
+		FormatBCS:      bcsCodec{},
+		FormatCBOR:     cborCodec{},
+		FormatSSZ:      sszCodec{},
+	},
+} //포맷 이름 -> 구현, 내장 포맷으로 초기화
+
+func RegisterFormat(name Format, m Marshaller) error {
+	if strings.TrimSpace(string(name)) == "" || name == FormatAuto { //auto는 감지용 예약어
+		return fmt.Errorf("invalid format name %q", name)
+	}
+	if m == nil {
+		return fmt.Errorf("format %s has no marshaller", name)
+	}
+	formats.Lock()
+	defer formats.Unlock()
+	if _, exists := formats.byName[name]; exists { //내장 포맷 포함 덮어쓰기 불가
+		return fmt.Errorf("format %s already registered", name)
+	}
+	formats.byName[name] = m
+	return nil
+} //Parse/Serialize가 name 포맷을 m으로 처리하도록 등록
+
+func Formats() []Format {
+	formats.RLock()
+	defer formats.RUnlock()
+	out := make([]Format, 0, len(formats.byName))
+	for name := range formats.byName {
+		out = append(out, name)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+} //등록된 포맷 이름 목록(이름순)
+
+func lookupFormat(name Format) (Marshaller, error) {
+	formats.RLock()
+	defer formats.RUnlock()
+	m, ok := formats.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported format: %s", name) //지원되지 않는 포맷
+	}
+	return m, nil
+} //이름으로 포맷 구현 조회
@@ -0,0 +1,63 @@
+package codec
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"codec/message/abstraction"
+)
+
+// lineFormat is a toy third-party format: "<type> <height>".
+type lineFormat struct{}
+
+func (lineFormat) Parse(data []byte, _ ParseOptions) (*abstraction.AbstractMessage, error) {
+	typ, height, _ := strings.Cut(string(data), " ")
+	h, _ := new(big.Int).SetString(height, 10)
+	return &abstraction.AbstractMessage{Type: abstraction.MsgType(typ), Height: h, RawPayload: data, OriginalFormat: "line"}, nil
+}
+
+func (lineFormat) Serialize(am *abstraction.AbstractMessage, _ SerializeOptions) ([]byte, error) {
+	return []byte(string(am.Type) + " " + am.Height.String()), nil
+}
+
+func TestRegisterFormat(t *testing.T) {
+	if err := RegisterFormat("line", lineFormat{}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	am, err := Parse([]byte("Prevote 12"), ParseOptions{Format: "line"})
+	if err != nil || am.Type != "Prevote" || am.Height.Int64() != 12 {
+		t.Fatalf("parse through the registered format: %+v, %v", am, err)
+	}
+	out, err := Serialize(am, SerializeOptions{Format: "line"})
+	if err != nil || !bytes.Equal(out, []byte("Prevote 12")) {
+		t.Fatalf("serialize through the registered format: %q, %v", out, err)
+	}
+
+	found := false
+	for _, f := range Formats() {
+		found = found || f == "line"
+	}
+	if !found {
+		t.Fatalf("registered format missing from %v", Formats())
+	}
+
+	for name, tc := range map[string]struct {
+		format Format
+		m      Marshaller
+	}{
+		"duplicate":     {"line", lineFormat{}},
+		"built-in":      {FormatJSON, lineFormat{}},
+		"auto":          {FormatAuto, lineFormat{}},
+		"empty":         {" ", lineFormat{}},
+		"no marshaller": {"nothing", nil},
+	} {
+		if err := RegisterFormat(tc.format, tc.m); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := Parse(nil, ParseOptions{Format: "nothing"}); err == nil || !strings.Contains(err.Error(), "unsupported format") {
+		t.Fatalf("want unsupported format, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package codec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 새 체인의 메시지 포맷을 붙일 때, payload 하나를 parsing해 어떤 필드가 표준 필드로 인식되었고
+// 어떤 key가 Extras로 남았는지 보고한다. 남은 key와 모르는 메시지 타입에는 이름을 근거로 유의어를 제안하며,
+// 제안은 SynonymFile로 바꿔 LoadSynonymsFile 형식의 파일로 저장할 수 있다.
+
+type FieldReport struct {
+	Field string `json:"field"`         //표준 필드명
+	Key   string `json:"key,omitempty"` //원본 필드명, 포맷이 기록하지 않으면 빈 값
+} //인식된 필드
+
+type SynonymSuggestion struct {
+	Kind   string `json:"kind"`   //"phase" 또는 "field"
+	Name   string `json:"name"`   //원본 이름
+	Target string `json:"target"` //제안하는 표준 이름
+	Reason string `json:"reason"` //제안 근거
+} //제안하는 유의어
+
+type InspectReport struct {
+	Format      Format              `json:"format"`      //parsing에 사용한 포맷
+	Type        string              `json:"type"`        //정규화된 메시지 타입
+	TypeKnown   bool                `json:"type_known"`  //표준 타입으로 정규화되었는지
+	Recognized  []FieldReport       `json:"recognized"`  //표준 필드로 인식된 필드
+	Unmapped    []string            `json:"unmapped"`    //Extras로 남은 원본 key
+	Suggestions []SynonymSuggestion `json:"suggestions"` //모르는 타입/key에 대한 유의어 제안
+} //Inspect 결과
+
+func Inspect(payload []byte, format Format) (*InspectReport, error) {
+	return InspectWithOptions(payload, ParseOptions{Format: format})
+} //payload를 parsing해 필드 인식 결과 보고
+
+func InspectWithOptions(payload []byte, opts ParseOptions) (*InspectReport, error) {
+	format := opts.Format
+	if format == "" || format == FormatAuto { //Parse와 같은 감지 결과를 보고
+		format = DetectFormat(payload)
+		opts.Format = format
+	}
+	am, err := Parse(payload, opts)
+	if err != nil {
+		return nil, err
+	}
+	r := &InspectReport{
+		Format:      format,
+		Type:        string(am.Type),
+		TypeKnown:   synonymTargets(PhaseSynonyms)[string(am.Type)],
+		Recognized:  []FieldReport{},
+		Unmapped:    []string{},
+		Suggestions: []SynonymSuggestion{},
+	}
+	set := map[string]bool{} //값이 있는 표준 필드
+	for _, f := range []struct {
+		name string
+		ok   bool
+	}{
+		{"Height", am.Height != nil},
+		{"Round", am.Round != nil},
+		{"View", am.View != nil},
+		{"BlockHash", am.BlockHash != ""},
+		{"PrevHash", am.PrevHash != ""},
+		{"Timestamp", !am.Timestamp.IsZero()},
+		{"Proposer", am.Proposer != ""},
+		{"Validator", am.Validator != ""},
+		{"Signature", am.Signature != ""},
+		{"CommitSeals", len(am.CommitSeals) > 0},
+		{"ViewChanges", len(am.ViewChanges) > 0},
+	} {
+		if f.ok {
+			set[f.name] = true
+			r.Recognized = append(r.Recognized, FieldReport{Field: f.name, Key: am.OriginalFieldNames[f.name]})
+		}
+	}
+	for key := range am.Extras {
+		r.Unmapped = append(r.Unmapped, key)
+	}
+	sort.Strings(r.Unmapped)
+
+	if !r.TypeKnown && r.Type != "" {
+		if target, reason, ok := suggestSynonym(r.Type, PhaseSynonyms, phaseHints); ok {
+			r.Suggestions = append(r.Suggestions, SynonymSuggestion{Kind: "phase", Name: r.Type, Target: target, Reason: reason})
+		}
+	}
+	for _, key := range r.Unmapped {
+		target, reason, ok := suggestSynonym(key, FieldSynonyms, fieldHints)
+		if ok && !set[target] { //이미 값이 있는 필드는 제안하지 않음
+			r.Suggestions = append(r.Suggestions, SynonymSuggestion{Kind: "field", Name: key, Target: target, Reason: reason})
+		}
+	}
+	return r, nil
+} //ParseOptions로 parsing해 필드 인식 결과 보고
+
+func (r *InspectReport) SynonymFile() SynonymFile {
+	f := SynonymFile{Phases: map[string]string{}, Fields: map[string]string{}}
+	for _, s := range r.Suggestions {
+		if s.Kind == "phase" {
+			f.Phases[s.Name] = s.Target
+		} else {
+			f.Fields[s.Name] = s.Target
+		}
+	}
+	return f
+} //제안을 MergeSynonyms에 넘길 수 있는 형태로 변환
+
+func (r *InspectReport) String() string {
+	var sb strings.Builder
+	known := "unknown"
+	if r.TypeKnown {
+		known = "known"
+	}
+	fmt.Fprintf(&sb, "format=%s type=%s (%s)\n", r.Format, r.Type, known)
+	for _, f := range r.Recognized {
+		if f.Key != "" && f.Key != strings.ToLower(f.Field) {
+			fmt.Fprintf(&sb, "  recognized %s <- %s\n", f.Field, f.Key)
+		} else {
+			fmt.Fprintf(&sb, "  recognized %s\n", f.Field)
+		}
+	}
+	for _, key := range r.Unmapped {
+		fmt.Fprintf(&sb, "  unmapped   %s\n", key)
+	}
+	for _, s := range r.Suggestions {
+		fmt.Fprintf(&sb, "  suggest    %s %s: %s (%s)\n", s.Kind, s.Name, s.Target, s.Reason)
+	}
+	return sb.String()
+} //사람이 읽는 보고서
+
+// 이름에 들어 있으면 해당 표준 이름을 제안하는 토큰. 앞의 토큰이 우선한다(parent_hash는 BlockHash가 아닌 PrevHash).
+var fieldHints = []struct{ token, target string }{
+	{"parent", "PrevHash"}, {"prev", "PrevHash"},
+	{"height", "Height"}, {"slot", "Height"}, {"seq", "Height"}, {"number", "Height"},
+	{"round", "Round"}, {"view", "View"},
+	{"hash", "BlockHash"}, {"digest", "BlockHash"}, {"root", "BlockHash"},
+	{"time", "Timestamp"},
+	{"proposer", "Proposer"}, {"leader", "Proposer"},
+	{"validator", "Validator"}, {"signer", "Validator"}, {"voter", "Validator"}, {"replica", "Validator"},
+	{"seals", "CommitSeals"}, {"sig", "Signature"},
+}
+
+var phaseHints = []struct{ token, target string }{
+	{"prevote", "Prepare"}, {"precommit", "Commit"},
+	{"propos", "Proposal"}, {"prepare", "Prepare"},
+	{"viewchange", "ViewChange"}, {"roundchange", "ViewChange"}, {"newview", "NewView"}, {"newround", "NewView"},
+	{"commit", "Commit"}, {"vote", "Vote"},
+}
+
+func suggestSynonym(name string, synonyms map[string]string, hints []struct{ token, target string }) (string, string, bool) {
+	norm := normalizeName(name)
+	known := make([]string, 0, len(synonyms))
+	for k := range synonyms {
+		known = append(known, k)
+	}
+	sort.Strings(known) //같은 입력에 같은 제안
+	for _, k := range known {
+		if normalizeName(k) == norm {
+			return synonyms[k], fmt.Sprintf("same as synonym %q", k), true
+		}
+	}
+	for _, k := range known {
+		if limit := len(norm) / 5; limit > 0 && editDistance(norm, normalizeName(k)) <= limit { //5자당 오타 1개 허용
+			return synonyms[k], fmt.Sprintf("close to synonym %q", k), true
+		}
+	}
+	for _, h := range hints {
+		if strings.Contains(norm, h.token) {
+			return h.target, fmt.Sprintf("name contains %q", h.token), true
+		}
+	}
+	return "", "", false
+} //이름 표기, 오타, 토큰 순으로 표준 이름 추정
+
+func normalizeName(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			sb.WriteRune(unicode.ToLower(c))
+		}
+	}
+	return sb.String()
+} //소문자로 바꾸고 구분자 제거(blockHeight, block-height -> blockheight)
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+} //Levenshtein 거리
@@ -0,0 +1,79 @@
+package codec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInspectReportsFieldsAndSuggestions(t *testing.T) {
+	payload := []byte(`{
+		"type": "BlockVoteMsg",
+		"seq_num": 12,
+		"digest": "0xab",
+		"blockTime": "2024-03-01T12:30:45Z",
+		"parentBlockHash": "0xcd",
+		"voterAddr": "n1",
+		"validatr_id": "n2",
+		"payload_size": 3
+	}`)
+	r, err := Inspect(payload, FormatAuto)
+	if err != nil {
+		t.Fatalf("inspect: %v", err)
+	}
+	if r.Format != FormatJSON || r.Type != "BlockVoteMsg" || r.TypeKnown {
+		t.Fatalf("unexpected header %+v", r)
+	}
+	wantRecognized := []FieldReport{{Field: "Height", Key: "seq_num"}, {Field: "BlockHash", Key: "digest"}}
+	if !reflect.DeepEqual(r.Recognized, wantRecognized) {
+		t.Fatalf("recognized: got %+v, want %+v", r.Recognized, wantRecognized)
+	}
+	if !reflect.DeepEqual(r.Unmapped, []string{"blockTime", "parentBlockHash", "payload_size", "validatr_id", "voterAddr"}) {
+		t.Fatalf("unexpected unmapped keys %v", r.Unmapped)
+	}
+
+	got := map[string]string{}
+	for _, s := range r.Suggestions {
+		got[s.Name] = s.Target + " / " + s.Reason
+	}
+	want := map[string]string{
+		"BlockVoteMsg":    `Vote / name contains "vote"`,
+		"blockTime":       `Timestamp / name contains "time"`,
+		"parentBlockHash": `PrevHash / name contains "parent"`,
+		"validatr_id":     `Validator / close to synonym "validator_id"`,
+		"voterAddr":       `Validator / name contains "voter"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("suggestions:\n got %v\nwant %v", got, want)
+	}
+	if !strings.Contains(r.String(), "recognized Height <- seq_num") {
+		t.Fatalf("unexpected text report:\n%s", r)
+	}
+
+	// The suggestions load as a synonym file and make the payload fully recognized
+	restoreSynonyms(t)
+	conflicts, err := MergeSynonyms(r.SynonymFile(), "inspect")
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf("merge suggestions: %v %v", conflicts, err)
+	}
+	again, err := Inspect(payload, FormatJSON)
+	if err != nil {
+		t.Fatalf("inspect again: %v", err)
+	}
+	if !again.TypeKnown || !reflect.DeepEqual(again.Unmapped, []string{"payload_size"}) {
+		t.Fatalf("after merging: %+v", again)
+	}
+}
+
+func TestSuggestSynonym(t *testing.T) {
+	target, reason, ok := suggestSynonym("Block-Height", FieldSynonyms, fieldHints)
+	if !ok || target != "Height" || reason != `same as synonym "block_height"` {
+		t.Fatalf("spelling variants should match a synonym, got %s %s %v", target, reason, ok)
+	}
+	if _, _, ok := suggestSynonym("payload_size", FieldSynonyms, fieldHints); ok {
+		t.Fatal("expected no suggestion for an unrelated name")
+	}
+	if _, err := InspectWithOptions([]byte{0x82, 0xa4}, ParseOptions{Format: FormatCBOR}); err == nil {
+		t.Fatal("expected the parse error to be returned")
+	}
+}
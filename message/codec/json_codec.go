@@ -6,7 +6,7 @@ import (
 	"math/big"
 	"time"
 
-	"codec/abstraction"
+	"codec/message/abstraction"
 )
 
 type jsonCodec struct{} //JSON parsing/serializing
@@ -17,8 +17,9 @@ func (jsonCodec) Parse(data []byte, opts ParseOptions) (*abstraction.AbstractMes
 		return nil, fmt.Errorf("json unmarshal: %w", err)
 	}
 	am := &abstraction.AbstractMessage{
-		Extras:     map[string][]byte{},          //표준화되지 않은 필드
-		RawPayload: append([]byte(nil), data...), //원본 JSON
+		Extras:             map[string][]byte{},          //표준화되지 않은 필드
+		RawPayload:         append([]byte(nil), data...), //원본 JSON
+		OriginalFieldNames: map[string]string{},          //표준 필드명 -> 원본 필드명
 	} //AbstractMessage 초기화
 	if opts.OverrideMsgType != "" { //타입 지정 시
 		am.Type = abstraction.MsgType(opts.OverrideMsgType)
@@ -35,6 +36,7 @@ func (jsonCodec) Parse(data []byte, opts ParseOptions) (*abstraction.AbstractMes
 		key := kRaw
 		if mapped, ok := FieldSynonyms[key]; ok { //유의어 정규화
 			key = mapped
+			am.OriginalFieldNames[key] = kRaw //원본 필드명 기록
 		}
 		switch key {
 		case "Height":
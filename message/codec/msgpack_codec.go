@@ -1,9 +1,11 @@
 package codec
 
 import (
+	"bytes"
 	"fmt"
+	"math/big"
 
-	"codec/abstraction"
+	"codec/message/abstraction"
 
 	"github.com/vmihailenco/msgpack/v5"
 )
@@ -11,25 +13,39 @@ import (
 type msgpackCodec struct{} //MessagePack 포맷 parsing/serializing
 
 func (msgpackCodec) Parse(data []byte, opts ParseOptions) (*abstraction.AbstractMessage, error) {
-	var decoded map[string]interface{}
-	if err := msgpack.Unmarshal(data, &decoded); err != nil {
+	var m map[string]interface{}
+	if err := msgpack.Unmarshal(data, &m); err != nil {
 		return nil, fmt.Errorf("msgpack decode: %w", err)
 	}
-	js, err := jsonFromInterface(decoded)
-	if err != nil {
-		return nil, err
+	am := &abstraction.AbstractMessage{
+		Extras:         map[string][]byte{},          //표준화되지 않은 필드
+		RawPayload:     append([]byte(nil), data...), //원본 MessagePack 바이트
+		OriginalFormat: string(FormatMsgPack),
+	} //AbstractMessage 초기화
+	if err := parseFieldMap(m, am, opts); err != nil {
+		return nil, fmt.Errorf("msgpack %w", err)
 	}
-	return (jsonCodec{}).Parse(js, ParseOptions{Format: FormatJSON, OverrideMsgType: opts.OverrideMsgType})
+	return am, nil
 } //MessagePack 바이트를 AbstractMessage로 변환
 
 func (msgpackCodec) Serialize(am *abstraction.AbstractMessage, _ SerializeOptions) ([]byte, error) {
-	js, err := (jsonCodec{}).Serialize(am, SerializeOptions{Format: FormatJSON}) //JSON 바이트로 변환
-	if err != nil {
-		return nil, err
-	}
-	var obj map[string]interface{}
-	if err := unmarshalJSON(js, &obj); err != nil {
-		return nil, err
+	out := fieldMap(am, msgpackBigIntValue) //시간은 timestamp extension(-1), Extras는 bin
+
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetSortMapKeys(true) //같은 메시지는 항상 같은 바이트로 직렬화
+	if err := enc.Encode(out); err != nil {
+		return nil, fmt.Errorf("msgpack encode: %w", err)
 	}
-	return msgpack.Marshal(obj)
+	return buf.Bytes(), nil
 } //AbstractMessage를 MessagePack 바이트로 변환
+
+func msgpackBigIntValue(x *big.Int) interface{} {
+	if x.IsInt64() {
+		return x.Int64()
+	}
+	if x.IsUint64() {
+		return x.Uint64()
+	}
+	return x.String() //64비트를 넘는 값은 10진수 문자열
+} //*big.Int를 MessagePack 정수 또는 10진수 문자열로 변환
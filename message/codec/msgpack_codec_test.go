@@ -0,0 +1,113 @@
+package codec
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"codec/message/abstraction"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func sampleAbstractMessage() *abstraction.AbstractMessage {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	return &abstraction.AbstractMessage{
+		Type:        "Proposal",
+		Height:      huge,
+		Round:       big.NewInt(2),
+		View:        big.NewInt(0),
+		Timestamp:   time.Date(2024, time.March, 1, 12, 30, 45, 123456789, time.UTC),
+		BlockHash:   "0xdeadbeef",
+		PrevHash:    "0xfeedbead",
+		Proposer:    "node 1",
+		Validator:   "node 2",
+		Signature:   "SIG",
+		CommitSeals: []string{"seal A", "seal B"},
+		ViewChanges: []abstraction.ViewChangeEntry{
+			{View: big.NewInt(1), Height: new(big.Int).SetUint64(1 << 63), Validator: "node 3", Signature: "vc_sig"},
+			{Validator: "node 4"},
+		},
+		Extras: map[string][]byte{
+			"payload": []byte("hello"),
+			"binary":  {0x00, 0xff, 0x10},
+			"json":    []byte(`{"a":1}`),
+		},
+	}
+}
+
+func TestMsgPackRoundTrip(t *testing.T) {
+	want := sampleAbstractMessage()
+	data, err := Serialize(want, SerializeOptions{Format: FormatMsgPack})
+	if err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	got, err := Parse(data, ParseOptions{Format: FormatMsgPack})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !bytes.Equal(got.RawPayload, data) || got.OriginalFormat != string(FormatMsgPack) {
+		t.Fatalf("parse should keep the payload and format, got %q %q", got.RawPayload, got.OriginalFormat)
+	}
+	got.RawPayload, got.OriginalFormat, got.OriginalFieldNames = nil, "", nil
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip changed the message\n got: %+v\nwant: %+v", got, want)
+	}
+
+	again, err := Serialize(got, SerializeOptions{Format: FormatMsgPack})
+	if err != nil {
+		t.Fatalf("serialize again: %v", err)
+	}
+	if !bytes.Equal(again, data) {
+		t.Fatal("serializing the same message twice produced different bytes")
+	}
+}
+
+func TestMsgPackParsesForeignEncodings(t *testing.T) {
+	data, err := msgpack.Marshal(map[string]interface{}{
+		"type":         "PrePrepare",
+		"seq_num":      uint8(7),
+		"view_number":  "99999999999999999999",
+		"created_at":   "2024-03-01T12:30:45Z",
+		"digest":       []byte("0xabc"),
+		"commit_seals": "only-seal",
+		"note":         "free text",
+	})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	got, err := Parse(data, ParseOptions{Format: FormatMsgPack})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	view, _ := new(big.Int).SetString("99999999999999999999", 10)
+	if got.Type != "Proposal" || got.Height.Int64() != 7 || got.View.Cmp(view) != 0 || got.BlockHash != "0xabc" ||
+		!got.Timestamp.Equal(time.Date(2024, time.March, 1, 12, 30, 45, 0, time.UTC)) ||
+		!reflect.DeepEqual(got.CommitSeals, []string{"only-seal"}) {
+		t.Fatalf("synonyms and value encodings not normalized: %+v", got)
+	}
+	if string(got.Extras["note"]) != `"free text"` {
+		t.Fatalf("non-binary extras should be kept as JSON like the JSON codec does, got %q", got.Extras["note"])
+	}
+}
+
+func TestMsgPackRejectsMalformedFields(t *testing.T) {
+	for name, fields := range map[string]map[string]interface{}{
+		"height":       {"height": "tall"},
+		"timestamp":    {"timestamp": true},
+		"view_changes": {"view_changes": "none"},
+	} {
+		data, err := msgpack.Marshal(fields)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		if _, err := Parse(data, ParseOptions{Format: FormatMsgPack}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := Parse([]byte{0xc1}, ParseOptions{Format: FormatMsgPack}); err == nil {
+		t.Error("expected an error for bytes that are not MessagePack")
+	}
+}
@@ -1,7 +1,7 @@
 package codec
 
 import (
-	"codec/abstraction"
+	"codec/message/abstraction"
 	"fmt"
 	"math/big"
 	"strings"
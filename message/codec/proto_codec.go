@@ -16,7 +16,7 @@ import (
 	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/dynamicpb"
 
-	"codec/abstraction"
+	"codec/message/abstraction"
 )
 
 type ProtoDescriptorProvider interface {
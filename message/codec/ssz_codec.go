@@ -0,0 +1,570 @@
+package codec
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+
+	"codec/message/abstraction"
+)
+
+type sszCodec struct{} //SSZ(Ethereum consensus) 포맷 parsing/serializing
+
+// SSZ에는 필드명이나 타입 정보가 없으므로 바이트를 읽으려면 schema가 필요하다.
+// schema는 SSZRegistry에 이름으로 등록하고 ParseOptions/SerializeOptions의 SSZSchema로 지정한다.
+
+type SSZKind int
+
+const (
+	SSZUint64    SSZKind = iota //uint64(little-endian 8바이트)
+	SSZBytes                    //ByteVector[Size], root와 서명 등
+	SSZByteList                 //ByteList[Limit]
+	SSZBitlist                  //Bitlist[Limit], 마지막 비트 뒤에 delimiter 비트
+	SSZContainer                //Fields를 순서대로 나열한 container
+)
+
+type SSZField struct {
+	Name   string     //필드명, decoding된 값의 key
+	Kind   SSZKind    //SSZ 타입
+	Size   int        //SSZBytes의 바이트 수
+	Limit  int        //SSZByteList 최대 바이트 수, SSZBitlist 최대 비트 수
+	Fields []SSZField //SSZContainer의 하위 필드
+	Inline bool       //SSZContainer 하위 필드를 "<Name>_" 접두어 없이 펼침
+} //schema의 필드 하나
+
+type SSZSchema struct {
+	Name      string     //schema 이름, 예: Attestation
+	Type      string     //AbstractMessage 타입, PhaseSynonyms로 정규화
+	Fields    []SSZField //최상위 container의 필드
+	BlockRoot string     //hash tree root가 BlockHash가 되는 최상위 container 필드명(선택)
+} //SSZ container 하나의 layout. 하위 container는 펼쳐져 FieldSynonyms 정규화를 거친 뒤 AbstractMessage 필드나 Extras가 됨
+
+type SSZRegistry struct {
+	mu      sync.RWMutex
+	schemas map[string]*SSZSchema
+} //이름 -> SSZSchema
+
+var DefaultSSZRegistry = newPhase0SSZRegistry() //beacon chain phase0 schema가 등록된 registry
+
+func NewSSZRegistry() *SSZRegistry {
+	return &SSZRegistry{schemas: map[string]*SSZSchema{}}
+} //빈 schema registry 생성
+
+func (r *SSZRegistry) Register(s SSZSchema) error {
+	if s.Name == "" {
+		return fmt.Errorf("ssz schema name cannot be empty")
+	}
+	if err := validateSSZFields(s.Fields, "", map[string]bool{}); err != nil {
+		return fmt.Errorf("ssz schema %s: %w", s.Name, err)
+	}
+	if s.BlockRoot != "" { //block root 필드는 최상위 container여야 함
+		found := false
+		for _, f := range s.Fields {
+			found = found || (f.Name == s.BlockRoot && f.Kind == SSZContainer)
+		}
+		if !found {
+			return fmt.Errorf("ssz schema %s: block root %q is not a top-level container", s.Name, s.BlockRoot)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.schemas[s.Name]; exists {
+		return fmt.Errorf("ssz schema %s already registered", s.Name)
+	}
+	r.schemas[s.Name] = &s
+	return nil
+} //schema 검증 후 등록, 같은 이름은 거부
+
+func (r *SSZRegistry) Lookup(name string) (*SSZSchema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.schemas[name]
+	return s, ok
+} //이름으로 schema 조회
+
+func validateSSZFields(fields []SSZField, prefix string, keys map[string]bool) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("container %q has no fields", strings.TrimSuffix(prefix, "_"))
+	}
+	for _, f := range fields {
+		if f.Name == "" {
+			return fmt.Errorf("field in %q has no name", strings.TrimSuffix(prefix, "_"))
+		}
+		switch f.Kind {
+		case SSZUint64:
+		case SSZBytes:
+			if f.Size <= 0 {
+				return fmt.Errorf("field %s: byte vector needs a size", prefix+f.Name)
+			}
+		case SSZByteList, SSZBitlist:
+			if f.Limit <= 0 {
+				return fmt.Errorf("field %s: list needs a limit", prefix+f.Name)
+			}
+		case SSZContainer:
+			if err := validateSSZFields(f.Fields, sszChildPrefix(prefix, f), keys); err != nil {
+				return err
+			}
+			continue
+		default:
+			return fmt.Errorf("field %s: unknown kind %d", prefix+f.Name, f.Kind)
+		}
+		if keys[prefix+f.Name] { //펼친 key는 유일해야 함
+			return fmt.Errorf("field %s appears twice", prefix+f.Name)
+		}
+		keys[prefix+f.Name] = true
+	}
+	return nil
+} //필드 정의와 펼친 key의 유일성 검사
+
+func sszChildPrefix(prefix string, f SSZField) string {
+	if f.Inline {
+		return prefix
+	}
+	return prefix + f.Name + "_"
+} //하위 container 필드의 key 접두어
+
+func newPhase0SSZRegistry() *SSZRegistry {
+	r := NewSSZRegistry()
+	checkpoint := []SSZField{
+		{Name: "epoch", Kind: SSZUint64},
+		{Name: "root", Kind: SSZBytes, Size: 32},
+	}
+	schemas := []SSZSchema{
+		{
+			Name: "Attestation",
+			Type: "Vote",
+			Fields: []SSZField{
+				{Name: "aggregation_bits", Kind: SSZBitlist, Limit: 2048}, //MAX_VALIDATORS_PER_COMMITTEE
+				{Name: "data", Kind: SSZContainer, Inline: true, Fields: []SSZField{
+					{Name: "slot", Kind: SSZUint64},
+					{Name: "index", Kind: SSZUint64},
+					{Name: "beacon_block_root", Kind: SSZBytes, Size: 32},
+					{Name: "source", Kind: SSZContainer, Fields: checkpoint},
+					{Name: "target", Kind: SSZContainer, Fields: checkpoint},
+				}},
+				{Name: "signature", Kind: SSZBytes, Size: 96},
+			},
+		},
+		{
+			Name: "SignedBeaconBlockHeader",
+			Type: "Proposal",
+			Fields: []SSZField{
+				{Name: "message", Kind: SSZContainer, Inline: true, Fields: []SSZField{
+					{Name: "slot", Kind: SSZUint64},
+					{Name: "proposer_index", Kind: SSZUint64},
+					{Name: "parent_root", Kind: SSZBytes, Size: 32},
+					{Name: "state_root", Kind: SSZBytes, Size: 32},
+					{Name: "body_root", Kind: SSZBytes, Size: 32},
+				}},
+				{Name: "signature", Kind: SSZBytes, Size: 96},
+			},
+			BlockRoot: "message", //block root는 header의 hash tree root
+		},
+		{
+			Name: "SyncCommitteeMessage",
+			Type: "Vote",
+			Fields: []SSZField{
+				{Name: "slot", Kind: SSZUint64},
+				{Name: "beacon_block_root", Kind: SSZBytes, Size: 32},
+				{Name: "validator_index", Kind: SSZUint64},
+				{Name: "signature", Kind: SSZBytes, Size: 96},
+			},
+		},
+	}
+	for _, s := range schemas {
+		if err := r.Register(s); err != nil {
+			panic(err)
+		}
+	}
+	return r
+} //phase0 Attestation, SignedBeaconBlockHeader, SyncCommitteeMessage 등록
+
+func sszSchemaFrom(registry *SSZRegistry, name string) (*SSZSchema, error) {
+	if registry == nil {
+		registry = DefaultSSZRegistry
+	}
+	if name == "" {
+		return nil, fmt.Errorf("ssz needs a schema name")
+	}
+	s, ok := registry.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown ssz schema %q", name)
+	}
+	return s, nil
+} //옵션의 registry(없을 시 DefaultSSZRegistry)에서 schema 조회
+
+func (sszCodec) Parse(data []byte, opts ParseOptions) (*abstraction.AbstractMessage, error) {
+	schema, err := sszSchemaFrom(opts.SSZRegistry, opts.SSZSchema)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := sszSplit(schema.Fields, data)
+	if err != nil {
+		return nil, fmt.Errorf("ssz decode %s: %w", schema.Name, err)
+	}
+	m := map[string]interface{}{"type": schema.Type}
+	for i, f := range schema.Fields {
+		if err := sszDecodeField(f, parts[i], "", m); err != nil {
+			return nil, fmt.Errorf("ssz decode %s: %w", schema.Name, err)
+		}
+	}
+	am := &abstraction.AbstractMessage{
+		Extras:          map[string][]byte{},          //표준화되지 않은 필드
+		RawPayload:      append([]byte(nil), data...), //원본 SSZ 바이트
+		OriginalFormat:  string(FormatSSZ),
+		OriginalMsgName: schema.Name, //Serialize 시 schema 이름으로 사용
+	} //AbstractMessage 초기화
+	if err := parseFieldMap(m, am, opts); err != nil {
+		return nil, fmt.Errorf("ssz %w", err)
+	}
+	for i, f := range schema.Fields {
+		if f.Name == schema.BlockRoot { //block hash는 해당 container의 hash tree root
+			root, err := sszHashTreeRoot(f, parts[i])
+			if err != nil {
+				return nil, fmt.Errorf("ssz %s root: %w", f.Name, err)
+			}
+			am.BlockHash = "0x" + hex.EncodeToString(root[:])
+		}
+	}
+	return am, nil
+} //SSZ 바이트를 schema에 따라 AbstractMessage로 변환
+
+func (sszCodec) Serialize(am *abstraction.AbstractMessage, opts SerializeOptions) ([]byte, error) {
+	name := opts.SSZSchema
+	if name == "" { //SSZ에서 parsing된 메시지는 원래 schema 사용
+		name = am.OriginalMsgName
+	}
+	schema, err := sszSchemaFrom(opts.SSZRegistry, name)
+	if err != nil {
+		return nil, err
+	}
+	out, err := sszEncodeFields(schema.Fields, "", am)
+	if err != nil {
+		return nil, fmt.Errorf("ssz encode %s: %w", schema.Name, err)
+	}
+	return out, nil
+} //AbstractMessage를 schema에 따라 SSZ 바이트로 변환. BlockRoot로 계산되는 BlockHash는 기록하지 않음
+
+func sszFixedSize(f SSZField) (int, bool) {
+	switch f.Kind {
+	case SSZUint64:
+		return 8, true
+	case SSZBytes:
+		return f.Size, true
+	case SSZContainer:
+		total := 0
+		for _, c := range f.Fields {
+			n, fixed := sszFixedSize(c)
+			if !fixed {
+				return 0, false
+			}
+			total += n
+		}
+		return total, true
+	}
+	return 0, false
+} //고정 크기 타입이면 바이트 수, 가변 타입이면 false
+
+func sszSplit(fields []SSZField, data []byte) ([][]byte, error) {
+	fixedLen := 0
+	for _, f := range fields { //가변 필드는 고정 영역에 4바이트 offset
+		if n, fixed := sszFixedSize(f); fixed {
+			fixedLen += n
+		} else {
+			fixedLen += 4
+		}
+	}
+	if len(data) < fixedLen {
+		return nil, fmt.Errorf("%d bytes, expected at least %d", len(data), fixedLen)
+	}
+	parts := make([][]byte, len(fields))
+	var variable []int //가변 필드 index
+	var offsets []int
+	pos := 0
+	for i, f := range fields {
+		if n, fixed := sszFixedSize(f); fixed {
+			parts[i] = data[pos : pos+n]
+			pos += n
+			continue
+		}
+		variable = append(variable, i)
+		offsets = append(offsets, int(binary.LittleEndian.Uint32(data[pos:])))
+		pos += 4
+	}
+	if len(variable) == 0 {
+		if len(data) != fixedLen {
+			return nil, fmt.Errorf("%d bytes, expected %d", len(data), fixedLen)
+		}
+		return parts, nil
+	}
+	if offsets[0] != fixedLen { //첫 offset은 가변 영역의 시작
+		return nil, fmt.Errorf("first offset is %d, expected %d", offsets[0], fixedLen)
+	}
+	offsets = append(offsets, len(data))
+	for j, i := range variable {
+		if offsets[j+1] < offsets[j] || offsets[j+1] > len(data) {
+			return nil, fmt.Errorf("field %s: offset %d out of order", fields[i].Name, offsets[j+1])
+		}
+		parts[i] = data[offsets[j]:offsets[j+1]]
+	}
+	return parts, nil
+} //container 바이트를 필드별 바이트로 분할
+
+func sszDecodeField(f SSZField, b []byte, prefix string, out map[string]interface{}) error {
+	key := prefix + f.Name
+	switch f.Kind {
+	case SSZUint64:
+		out[key] = binary.LittleEndian.Uint64(b)
+	case SSZBytes:
+		out[key] = "0x" + hex.EncodeToString(b)
+	case SSZByteList:
+		if len(b) > f.Limit {
+			return fmt.Errorf("field %s: %d bytes, limit is %d", key, len(b), f.Limit)
+		}
+		out[key] = "0x" + hex.EncodeToString(b)
+	case SSZBitlist:
+		if _, err := sszBitlistLen(b, f.Limit); err != nil {
+			return fmt.Errorf("field %s: %w", key, err)
+		}
+		out[key] = "0x" + hex.EncodeToString(b) //delimiter 비트를 포함한 SSZ 바이트
+	case SSZContainer:
+		parts, err := sszSplit(f.Fields, b)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", key, err)
+		}
+		for i, c := range f.Fields {
+			if err := sszDecodeField(c, parts[i], sszChildPrefix(prefix, f), out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+} //필드 값을 펼친 key로 out에 기록. 바이트 값은 0x hex 문자열
+
+func sszBitlistLen(b []byte, limit int) (int, error) {
+	if len(b) == 0 || b[len(b)-1] == 0 {
+		return 0, fmt.Errorf("bitlist has no delimiter bit")
+	}
+	n := (len(b)-1)*8 + bits.Len8(b[len(b)-1]) - 1
+	if n > limit {
+		return 0, fmt.Errorf("bitlist has %d bits, limit is %d", n, limit)
+	}
+	return n, nil
+} //delimiter 비트로 bitlist 길이 계산
+
+func sszEncodeFields(fields []SSZField, prefix string, am *abstraction.AbstractMessage) ([]byte, error) {
+	var fixedPart, variablePart []byte
+	var offsetAt []int //offset을 채울 고정 영역 위치
+	var values [][]byte
+	for _, f := range fields {
+		b, err := sszEncodeField(f, prefix, am)
+		if err != nil {
+			return nil, err
+		}
+		if _, fixed := sszFixedSize(f); fixed {
+			fixedPart = append(fixedPart, b...)
+			continue
+		}
+		offsetAt = append(offsetAt, len(fixedPart))
+		values = append(values, b)
+		fixedPart = append(fixedPart, 0, 0, 0, 0)
+	}
+	for i, b := range values {
+		binary.LittleEndian.PutUint32(fixedPart[offsetAt[i]:], uint32(len(fixedPart)+len(variablePart)))
+		variablePart = append(variablePart, b...)
+	}
+	return append(fixedPart, variablePart...), nil
+} //container를 고정 영역(가변 필드는 offset) + 가변 영역으로 직렬화
+
+func sszEncodeField(f SSZField, prefix string, am *abstraction.AbstractMessage) ([]byte, error) {
+	key := prefix + f.Name
+	if f.Kind == SSZContainer {
+		return sszEncodeFields(f.Fields, sszChildPrefix(prefix, f), am)
+	}
+	v := sszFieldValue(am, key)
+	if f.Kind == SSZUint64 {
+		n, err := sszUint(v)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", key, err)
+		}
+		return binary.LittleEndian.AppendUint64(nil, n), nil
+	}
+	b, err := sszByteValue(v)
+	if err != nil {
+		return nil, fmt.Errorf("field %s: %w", key, err)
+	}
+	switch f.Kind {
+	case SSZBytes:
+		if b == nil { //없는 값은 0으로 채움
+			b = make([]byte, f.Size)
+		}
+		if len(b) != f.Size {
+			return nil, fmt.Errorf("field %s: %d bytes, expected %d", key, len(b), f.Size)
+		}
+	case SSZByteList:
+		if len(b) > f.Limit {
+			return nil, fmt.Errorf("field %s: %d bytes, limit is %d", key, len(b), f.Limit)
+		}
+	case SSZBitlist:
+		if b == nil { //없는 값은 빈 bitlist
+			b = []byte{0x01}
+		}
+		if _, err := sszBitlistLen(b, f.Limit); err != nil {
+			return nil, fmt.Errorf("field %s: %w", key, err)
+		}
+	}
+	return b, nil
+} //필드 하나를 SSZ 바이트로 직렬화
+
+func sszFieldValue(am *abstraction.AbstractMessage, key string) interface{} {
+	std, ok := FieldSynonyms[key]
+	if !ok { //표준 필드가 아닌 값은 Extras에서
+		raw, ok := am.Extras[key]
+		if !ok {
+			return nil
+		}
+		var v interface{}
+		if err := unmarshalJSON(raw, &v); err != nil {
+			return raw //JSON이 아니면 원본 바이트
+		}
+		return v
+	}
+	var bi *big.Int
+	switch std {
+	case "Height":
+		bi = am.Height
+	case "Round":
+		bi = am.Round
+	case "View":
+		bi = am.View
+	case "BlockHash":
+		return am.BlockHash
+	case "PrevHash":
+		return am.PrevHash
+	case "Proposer":
+		return am.Proposer
+	case "Validator":
+		return am.Validator
+	case "Signature":
+		return am.Signature
+	default:
+		return nil
+	}
+	if bi == nil {
+		return nil
+	}
+	return bi
+} //펼친 key에 해당하는 AbstractMessage 값 조회
+
+func sszUint(v interface{}) (uint64, error) {
+	switch t := v.(type) {
+	case nil:
+		return 0, nil
+	case *big.Int:
+		if t.IsUint64() {
+			return t.Uint64(), nil
+		}
+	case json.Number:
+		return strconv.ParseUint(t.String(), 10, 64)
+	case string:
+		if t == "" {
+			return 0, nil
+		}
+		return strconv.ParseUint(t, 10, 64)
+	}
+	return 0, fmt.Errorf("not a uint64: %v", v)
+} //값을 uint64로 변환
+
+func sszByteValue(v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return t, nil
+	case string:
+		if t == "" {
+			return nil, nil
+		}
+		b, err := hex.DecodeString(strings.TrimPrefix(t, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("not hex: %q", t)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("not bytes: %v", v)
+} //0x hex 문자열 또는 원본 바이트를 바이트로 변환
+
+func sszHashTreeRoot(f SSZField, b []byte) ([32]byte, error) {
+	switch f.Kind {
+	case SSZUint64, SSZBytes: //basic 값은 32바이트 chunk로 packing
+		return sszMerkleize(sszChunks(b), (f.Size+31)/32), nil
+	case SSZByteList:
+		return sszMixInLength(sszMerkleize(sszChunks(b), (f.Limit+31)/32), len(b)), nil
+	case SSZBitlist:
+		n, err := sszBitlistLen(b, f.Limit)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		packed := append([]byte(nil), b[:(n+7)/8]...) //delimiter 비트 제거
+		if n%8 != 0 {
+			packed[len(packed)-1] &^= 1 << (n % 8)
+		}
+		return sszMixInLength(sszMerkleize(sszChunks(packed), (f.Limit+255)/256), n), nil
+	}
+	parts, err := sszSplit(f.Fields, b)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	roots := make([][32]byte, len(f.Fields))
+	for i, c := range f.Fields {
+		if roots[i], err = sszHashTreeRoot(c, parts[i]); err != nil {
+			return [32]byte{}, err
+		}
+	}
+	return sszMerkleize(roots, len(roots)), nil
+} //SSZ 바이트의 hash tree root 계산
+
+func sszChunks(b []byte) [][32]byte {
+	chunks := make([][32]byte, (len(b)+31)/32)
+	for i := range chunks {
+		copy(chunks[i][:], b[i*32:])
+	}
+	return chunks
+} //바이트를 32바이트 chunk로 분할, 마지막 chunk는 0으로 채움
+
+func sszMerkleize(chunks [][32]byte, limit int) [32]byte {
+	depth := bits.Len(uint(limit - 1)) //limit 이상인 가장 작은 2의 거듭제곱의 지수
+	if limit <= 1 {
+		depth = 0
+	}
+	zero := [32]byte{} //현재 깊이의 빈 subtree root
+	layer := chunks
+	for d := 0; d < depth; d++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, zero)
+		}
+		next := make([][32]byte, len(layer)/2)
+		for i := range next {
+			next[i] = sha256.Sum256(append(layer[2*i][:], layer[2*i+1][:]...))
+		}
+		layer = next
+		zero = sha256.Sum256(append(zero[:], zero[:]...))
+	}
+	if len(layer) == 0 {
+		return zero
+	}
+	return layer[0]
+} //chunk를 limit 개 leaf의 Merkle tree로 묶은 root
+
+func sszMixInLength(root [32]byte, n int) [32]byte {
+	var length [32]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(n))
+	return sha256.Sum256(append(root[:], length[:]...))
+} //list root에 길이를 섞음
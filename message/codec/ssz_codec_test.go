@@ -0,0 +1,148 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	eth "codec/ethereum/adapter"
+)
+
+func sszRoot(b byte) (r eth.Root) {
+	for i := range r {
+		r[i] = b
+	}
+	return r
+}
+
+func TestSSZAttestationRoundTrip(t *testing.T) {
+	att := &eth.Attestation{
+		AggregationBits: []bool{false, true, false, false, false, true, false, false, false},
+		Data: eth.AttestationData{Slot: 6432, Index: 3, BeaconBlockRoot: sszRoot(0xaa),
+			Source: eth.Checkpoint{Epoch: 199, Root: sszRoot(0xbb)}, Target: eth.Checkpoint{Epoch: 201, Root: sszRoot(0xcc)}},
+		Signature: eth.Signature{1, 2, 3},
+	}
+	data := att.MarshalSSZ()
+	am, err := Parse(data, ParseOptions{Format: FormatSSZ, SSZSchema: "Attestation"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if am.Type != "Vote" || am.Height.Uint64() != 6432 || am.BlockHash != "0x"+strings.Repeat("aa", 32) ||
+		!strings.HasPrefix(am.Signature, "0x010203") || am.OriginalMsgName != "Attestation" {
+		t.Fatalf("attestation not mapped: %+v", am)
+	}
+	if got := string(am.Extras["target_epoch"]); got != "201" {
+		t.Fatalf("target epoch should be kept in the extras, got %q", got)
+	}
+	if got := string(am.Extras["aggregation_bits"]); got != `"0x2202"` {
+		t.Fatalf("aggregation bits should keep their SSZ bytes, got %q", got)
+	}
+
+	out, err := Serialize(am, SerializeOptions{Format: FormatSSZ})
+	if err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Fatalf("round trip changed the bytes\n got: %x\nwant: %x", out, data)
+	}
+}
+
+func TestSSZBlockHeaderUsesHashTreeRoot(t *testing.T) {
+	header := &eth.SignedBeaconBlockHeader{
+		Message: eth.BeaconBlockHeader{Slot: 6433, ProposerIndex: 812, ParentRoot: sszRoot(0xaa),
+			StateRoot: sszRoot(0x01), BodyRoot: sszRoot(0x02)},
+		Signature: eth.Signature{9},
+	}
+	data := header.MarshalSSZ()
+	am, err := Parse(data, ParseOptions{Format: FormatSSZ, SSZSchema: "SignedBeaconBlockHeader"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	root := header.Message.HashTreeRoot()
+	if am.Type != "Proposal" || am.Proposer != "812" || am.PrevHash != "0x"+strings.Repeat("aa", 32) ||
+		am.BlockHash != "0x"+hex.EncodeToString(root[:]) {
+		t.Fatalf("header not mapped: %+v", am)
+	}
+
+	// An edited message re-encodes with the new values; the block hash follows from the header
+	am.Height.SetUint64(6500)
+	am.BlockHash = ""
+	out, err := Serialize(am, SerializeOptions{Format: FormatSSZ, SSZSchema: "SignedBeaconBlockHeader"})
+	if err != nil {
+		t.Fatalf("serialize: %v", err)
+	}
+	decoded, err := eth.DecodeSignedBeaconBlockHeader(out)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if decoded.Message.Slot != 6500 || decoded.Message.ProposerIndex != 812 || decoded.Message.BodyRoot != sszRoot(0x02) {
+		t.Fatalf("unexpected header %+v", decoded.Message)
+	}
+}
+
+func TestSSZRegistry(t *testing.T) {
+	r := NewSSZRegistry()
+	schema := SSZSchema{
+		Name: "Note",
+		Type: "Prevote",
+		Fields: []SSZField{
+			{Name: "height", Kind: SSZUint64},
+			{Name: "memo", Kind: SSZByteList, Limit: 8},
+			{Name: "voters", Kind: SSZBitlist, Limit: 16},
+		},
+	}
+	if err := r.Register(schema); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := r.Register(schema); err == nil {
+		t.Fatal("expected an error registering the same name twice")
+	}
+	data, _ := hex.DecodeString("0500000000000000" + "10000000" + "12000000" + "6869" + "0b")
+	am, err := Parse(data, ParseOptions{Format: FormatSSZ, SSZSchema: "Note", SSZRegistry: r})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if am.Type != "Prepare" || am.Height.Int64() != 5 || string(am.Extras["memo"]) != `"0x6869"` {
+		t.Fatalf("variable fields not decoded: %+v", am)
+	}
+	out, err := Serialize(am, SerializeOptions{Format: FormatSSZ, SSZRegistry: r})
+	if err != nil || !bytes.Equal(out, data) {
+		t.Fatalf("round trip: %x, %v", out, err)
+	}
+
+	for name, bad := range map[string]SSZSchema{
+		"empty":         {Name: "A"},
+		"no size":       {Name: "B", Fields: []SSZField{{Name: "root", Kind: SSZBytes}}},
+		"duplicate key": {Name: "C", Fields: []SSZField{{Name: "x_y", Kind: SSZUint64}, {Name: "x", Kind: SSZContainer, Fields: []SSZField{{Name: "y", Kind: SSZUint64}}}}},
+		"bad root":      {Name: "D", Fields: []SSZField{{Name: "x", Kind: SSZUint64}}, BlockRoot: "x"},
+	} {
+		if err := r.Register(bad); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSSZRejectsMalformedInput(t *testing.T) {
+	att := (&eth.Attestation{AggregationBits: []bool{true}}).MarshalSSZ()
+	badOffset := append([]byte(nil), att...)
+	badOffset[0]++
+	noDelimiter := append([]byte(nil), att...)
+	noDelimiter[len(noDelimiter)-1] = 0
+	for name, tc := range map[string]struct {
+		schema string
+		data   []byte
+		want   string
+	}{
+		"no schema":      {"", att, "needs a schema"},
+		"unknown schema": {"Deposit", att, "unknown ssz schema"},
+		"short":          {"Attestation", att[:100], "expected at least"},
+		"bad offset":     {"Attestation", badOffset, "first offset"},
+		"no delimiter":   {"Attestation", noDelimiter, "delimiter"},
+		"trailing":       {"SyncCommitteeMessage", make([]byte, 145), "expected 144"},
+	} {
+		if _, err := Parse(tc.data, ParseOptions{Format: FormatSSZ, SSZSchema: tc.schema}); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: want error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"codec/message/abstraction"
+)
+
+// 캡처 파일 전체를 메모리에 올리지 않고 레코드 단위로 parsing하는 decoder.
+// 포맷별 framing:
+//   - json, generic: 한 줄에 메시지 하나(NDJSON), 빈 줄은 건너뜀
+//   - protobuf: uvarint 길이 prefix + 메시지 바이트(protodelim과 같은 형식)
+//   - rlp: RLP item을 이어 붙인 스트림, item header로 경계 판단
+
+const DefaultMaxStreamRecord = 64 << 20 //레코드 하나의 기본 최대 크기(64 MiB)
+
+type StreamDecoder struct {
+	r         *bufio.Reader
+	opts      ParseOptions
+	next      func() ([]byte, error) //포맷별 framing
+	offset    int64                  //다음 레코드 시작 위치
+	start     int64                  //마지막으로 읽은 레코드 시작 위치
+	count     int                    //읽은 레코드 수
+	MaxRecord int                    //레코드 최대 크기, 넘으면 에러
+}
+
+func NewStreamDecoder(r io.Reader, format Format) (*StreamDecoder, error) {
+	return NewStreamDecoderWithOptions(r, ParseOptions{Format: format})
+} //format의 framing으로 r을 읽는 decoder 생성
+
+func NewStreamDecoderWithOptions(r io.Reader, opts ParseOptions) (*StreamDecoder, error) {
+	d := &StreamDecoder{r: bufio.NewReaderSize(r, 64<<10), opts: opts, MaxRecord: DefaultMaxStreamRecord}
+	if opts.Format == "" || opts.Format == FormatAuto { //첫 바이트가 '{'면 NDJSON, 그 외에는 판별 불가
+		b, err := d.r.Peek(1)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(b) == 0 || b[0] != '{' {
+			return nil, fmt.Errorf("stream format must be given unless the stream is NDJSON")
+		}
+		d.opts.Format = FormatJSON
+	}
+	switch d.opts.Format {
+	case FormatJSON, FormatGeneric:
+		d.next = d.nextLine
+	case FormatProtobuf:
+		d.next = d.nextDelimited
+	case FormatRLP:
+		d.next = d.nextRLP
+	default:
+		return nil, fmt.Errorf("no stream framing for format %s", d.opts.Format)
+	}
+	return d, nil
+} //ParseOptions로 각 레코드를 parsing하는 decoder 생성
+
+func (d *StreamDecoder) Next() (*abstraction.AbstractMessage, error) {
+	d.start = d.offset
+	record, err := d.next()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("record %d at offset %d: %w", d.count, d.start, err)
+	}
+	d.count++
+	am, err := Parse(record, d.opts)
+	if err != nil { //레코드 경계는 유지되므로 다음 Next는 이어서 읽음
+		return nil, fmt.Errorf("record %d at offset %d: %w", d.count-1, d.start, err)
+	}
+	return am, nil
+} //다음 메시지 반환, 스트림 끝에서 io.EOF. parsing 에러 뒤에도 계속 읽을 수 있으나 framing 에러 뒤에는 불가
+
+func (d *StreamDecoder) Offset() int64 {
+	return d.start
+} //마지막으로 읽은 레코드의 시작 바이트 위치
+
+func (d *StreamDecoder) nextLine() ([]byte, error) {
+	for {
+		line, err := d.r.ReadBytes('\n')
+		d.offset += int64(len(line))
+		if len(line) > d.MaxRecord {
+			return nil, fmt.Errorf("line of %d bytes exceeds %d", len(line), d.MaxRecord)
+		}
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 { //개행 없는 마지막 줄도 레코드로 처리
+			return trimmed, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		d.start = d.offset //빈 줄 건너뜀
+	}
+} //NDJSON 한 줄
+
+func (d *StreamDecoder) nextDelimited() ([]byte, error) {
+	n, err := binary.ReadUvarint(byteCounter{d})
+	if err != nil {
+		if err == io.EOF && d.offset == d.start { //레코드 경계에서 끝남
+			return nil, io.EOF
+		}
+		return nil, unexpectedEOF(err)
+	}
+	return d.read(n)
+} //uvarint 길이 prefix 레코드
+
+func (d *StreamDecoder) nextRLP() ([]byte, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err //경계에서 io.EOF
+	}
+	d.offset++
+	head := []byte{b}
+	var size uint64
+	switch {
+	case b < 0x80: //단일 바이트
+		return head, nil
+	case b <= 0xb7:
+		size = uint64(b - 0x80)
+	case b < 0xc0:
+		ext, err := d.read(uint64(b - 0xb7))
+		if err != nil {
+			return nil, err
+		}
+		head, size = append(head, ext...), rlpLength(ext)
+	case b <= 0xf7:
+		size = uint64(b - 0xc0)
+	default:
+		ext, err := d.read(uint64(b - 0xf7))
+		if err != nil {
+			return nil, err
+		}
+		head, size = append(head, ext...), rlpLength(ext)
+	}
+	body, err := d.read(size)
+	if err != nil {
+		return nil, err
+	}
+	return append(head, body...), nil
+} //RLP item 하나(header 포함)
+
+func (d *StreamDecoder) read(n uint64) ([]byte, error) {
+	if n > uint64(d.MaxRecord) {
+		return nil, fmt.Errorf("record of %d bytes exceeds %d", n, d.MaxRecord)
+	}
+	buf := make([]byte, n)
+	read, err := io.ReadFull(d.r, buf)
+	d.offset += int64(read)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return buf, nil
+} //n바이트를 정확히 읽음
+
+func rlpLength(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+} //big-endian 길이
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+} //레코드 중간의 EOF는 잘린 레코드
+
+type byteCounter struct{ d *StreamDecoder } //ReadUvarint가 읽은 바이트를 offset에 반영
+
+func (c byteCounter) ReadByte() (byte, error) {
+	b, err := c.d.r.ReadByte()
+	if err == nil {
+		c.d.offset++
+	}
+	return b, err
+} //한 바이트 읽고 offset 증가
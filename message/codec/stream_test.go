@@ -0,0 +1,131 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"codec/message/abstraction"
+)
+
+// drain reads every message of a stream and returns the heights it saw.
+func drain(t *testing.T, d *StreamDecoder) []int64 {
+	t.Helper()
+	var heights []int64
+	for {
+		am, err := d.Next()
+		if err == io.EOF {
+			return heights
+		}
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		heights = append(heights, am.Height.Int64())
+	}
+}
+
+func TestStreamDecoderNDJSON(t *testing.T) {
+	stream := "{\"type\":\"Prevote\",\"height\":1}\n\n" +
+		"{\"type\":\"Precommit\",\"height\":2}\n" +
+		"not json\n" +
+		"{\"type\":\"Precommit\",\"height\":3}"
+	d, err := NewStreamDecoder(strings.NewReader(stream), FormatAuto)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	for _, want := range []int64{1, 2} {
+		am, err := d.Next()
+		if err != nil || am.Height.Int64() != want {
+			t.Fatalf("want height %d, got %+v %v", want, am, err)
+		}
+	}
+	if d.Offset() != 31 {
+		t.Fatalf("second record starts after the blank line at 31, got %d", d.Offset())
+	}
+	if _, err := d.Next(); err == nil || !strings.Contains(err.Error(), "record 2 at offset 63") {
+		t.Fatalf("want an error naming the bad line, got %v", err)
+	}
+	// A bad line does not end the stream, and the last line needs no newline
+	if got := drain(t, d); len(got) != 1 || got[0] != 3 {
+		t.Fatalf("want the record after the bad line, got %v", got)
+	}
+}
+
+func TestStreamDecoderRLP(t *testing.T) {
+	var stream bytes.Buffer
+	for h := int64(1); h <= 3; h++ {
+		data, err := Serialize(&abstraction.AbstractMessage{Type: "Commit", Height: big.NewInt(h), BlockHash: strings.Repeat("ab", 32)},
+			SerializeOptions{Format: FormatRLP})
+		if err != nil {
+			t.Fatalf("serialize: %v", err)
+		}
+		stream.Write(data)
+	}
+	d, err := NewStreamDecoder(bytes.NewReader(stream.Bytes()), FormatRLP)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if got := drain(t, d); len(got) != 3 || got[2] != 3 {
+		t.Fatalf("want three messages, got %v", got)
+	}
+
+	truncated, _ := NewStreamDecoder(bytes.NewReader(stream.Bytes()[:stream.Len()-5]), FormatRLP)
+	drainUntilError(t, truncated, io.ErrUnexpectedEOF)
+}
+
+func TestStreamDecoderDelimitedProtobuf(t *testing.T) {
+	var stream bytes.Buffer
+	for h := 1.0; h <= 2; h++ {
+		msg, _ := structpb.NewStruct(map[string]interface{}{"type": "Prevote", "height": h})
+		data, err := proto.Marshal(msg)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		stream.Write(binary.AppendUvarint(nil, uint64(len(data))))
+		stream.Write(data)
+	}
+	d, err := NewStreamDecoderWithOptions(bytes.NewReader(stream.Bytes()), ParseOptions{Format: FormatProtobuf, ProtoMessageFullName: "google.protobuf.Struct"})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if got := drain(t, d); len(got) != 2 || got[1] != 2 {
+		t.Fatalf("want two messages, got %v", got)
+	}
+
+	huge, _ := NewStreamDecoderWithOptions(bytes.NewReader(binary.AppendUvarint(nil, 1<<40)), ParseOptions{Format: FormatProtobuf})
+	if _, err := huge.Next(); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Fatalf("want a size error, got %v", err)
+	}
+}
+
+func TestStreamDecoderFormats(t *testing.T) {
+	if _, err := NewStreamDecoder(strings.NewReader("\x01"), FormatAuto); err == nil {
+		t.Fatal("expected an error guessing a binary stream")
+	}
+	if _, err := NewStreamDecoder(strings.NewReader(""), FormatBCS); err == nil {
+		t.Fatal("expected an error for a format without stream framing")
+	}
+}
+
+func drainUntilError(t *testing.T, d *StreamDecoder, want error) {
+	t.Helper()
+	for {
+		_, err := d.Next()
+		if err == io.EOF {
+			t.Fatalf("want %v before the end of the stream", want)
+		}
+		if err != nil {
+			if !errors.Is(err, want) {
+				t.Fatalf("want %v, got %v", want, err)
+			}
+			return
+		}
+	}
+}
@@ -75,6 +75,7 @@ var FieldSynonyms = map[string]string{
 	"block_height":    "Height",
 	"block_sequence":  "Height",
 	"pp_seq_no":       "Height",
+	"slot":            "Height",
 
 	"round":        "Round",
 	"round_id":     "Round",
@@ -94,11 +95,13 @@ var FieldSynonyms = map[string]string{
 	"message_digest":    "BlockHash",
 	"proposal_hash":     "BlockHash",
 	"proposal_id":       "BlockHash",
+	"beacon_block_root": "BlockHash",
 
 	"prev_hash":       "PrevHash",
 	"previous_hash":   "PrevHash",
 	"parent_hash":     "PrevHash",
 	"last_block_hash": "PrevHash",
+	"parent_root":     "PrevHash",
 
 	"time":          "Timestamp",
 	"timestamp":     "Timestamp",
@@ -107,11 +110,12 @@ var FieldSynonyms = map[string]string{
 	"created_at":    "Timestamp",
 	"creation_time": "Timestamp",
 
-	"proposer":    "Proposer",
-	"proposer_id": "Proposer",
-	"leader":      "Proposer",
-	"leader_id":   "Proposer",
-	"primary":     "Proposer",
+	"proposer":       "Proposer",
+	"proposer_id":    "Proposer",
+	"leader":         "Proposer",
+	"leader_id":      "Proposer",
+	"primary":        "Proposer",
+	"proposer_index": "Proposer",
 
 	"validator":            "Validator",
 	"validator_id":         "Validator",
@@ -122,6 +126,7 @@ var FieldSynonyms = map[string]string{
 	"replica_id":           "Validator",
 	"signer_id":            "Validator",
 	"signer":               "Validator",
+	"validator_index":      "Validator",
 
 	"signature":           "Signature",
 	"sig":                 "Signature",
@@ -0,0 +1,131 @@
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 내장 유의어에 없는 구현체의 메시지 타입명/필드명은 YAML(또는 JSON) 파일로 추가한다.
+//
+//	phases:
+//	  BlockVote: Commit
+//	fields:
+//	  blk_no: Height
+//
+// 병합은 PhaseSynonyms/FieldSynonyms를 직접 수정하므로 parsing 전에(start-up 시) 호출해야 한다.
+
+type SynonymFile struct {
+	Phases map[string]string `yaml:"phases"` //원본 메시지 타입명 -> 표준 타입명
+	Fields map[string]string `yaml:"fields"` //원본 필드명 -> 표준 필드명
+} //유의어 파일 내용
+
+type SynonymConflict struct {
+	Kind      string //"phase" 또는 "field"
+	Name      string //원본 이름
+	Existing  string //이미 등록된 표준 이름, 유지됨
+	Requested string //파일이 요청한 표준 이름, 무시됨
+	Source    string //파일 경로
+} //이미 다른 표준 이름으로 등록된 유의어
+
+func (c SynonymConflict) String() string {
+	return fmt.Sprintf("%s: %s synonym %q maps to %s, keeping it over %s", c.Source, c.Kind, c.Name, c.Existing, c.Requested)
+} //충돌 내용을 한 줄로 표현
+
+func LoadSynonymsFile(path string) ([]SynonymConflict, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read synonyms: %w", err)
+	}
+	var f SynonymFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true) //phases/fields 외 key는 오타로 간주
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("parse synonyms %s: %w", path, err)
+	}
+	return MergeSynonyms(f, path)
+} //파일의 유의어를 읽어 병합하고 충돌 목록 반환
+
+func MergeSynonyms(f SynonymFile, source string) ([]SynonymConflict, error) {
+	phaseTargets, fieldTargets := synonymTargets(PhaseSynonyms), synonymTargets(FieldSynonyms)
+	if err := checkSynonyms("phase", f.Phases, phaseTargets); err != nil { //하나라도 잘못되면 아무것도 병합하지 않음
+		return nil, fmt.Errorf("synonyms %s: %w", source, err)
+	}
+	if err := checkSynonyms("field", f.Fields, fieldTargets); err != nil {
+		return nil, fmt.Errorf("synonyms %s: %w", source, err)
+	}
+	conflicts := mergeSynonymMap("phase", PhaseSynonyms, f.Phases, source)
+	conflicts = append(conflicts, mergeSynonymMap("field", FieldSynonyms, f.Fields, source)...)
+	return conflicts, nil
+} //유의어를 PhaseSynonyms/FieldSynonyms에 병합. 기존 매핑과 다른 항목은 병합하지 않고 충돌로 반환
+
+func synonymTargets(m map[string]string) map[string]bool {
+	out := map[string]bool{}
+	for _, std := range m {
+		out[std] = true
+	}
+	return out
+} //유의어 맵의 표준 이름 집합
+
+func checkSynonyms(kind string, m map[string]string, targets map[string]bool) error {
+	for name, std := range m {
+		if strings.TrimSpace(name) == "" || (kind == "field" && name == "type") { //type은 메시지 타입 key로 예약
+			return fmt.Errorf("invalid %s synonym name %q", kind, name)
+		}
+		if !targets[std] {
+			known := make([]string, 0, len(targets))
+			for t := range targets {
+				known = append(known, t)
+			}
+			sort.Strings(known)
+			return fmt.Errorf("%s synonym %q maps to unknown %s %q (known: %s)", kind, name, kind, std, strings.Join(known, ", "))
+		}
+	}
+	return nil
+} //원본 이름과 표준 이름 검사
+
+func mergeSynonymMap(kind string, dst, src map[string]string, source string) []SynonymConflict {
+	names := make([]string, 0, len(src))
+	for name := range src {
+		names = append(names, name)
+	}
+	sort.Strings(names) //충돌 목록 순서 고정
+	var conflicts []SynonymConflict
+	for _, name := range names {
+		existing, ok := dst[name]
+		switch {
+		case !ok:
+			dst[name] = src[name]
+		case existing != src[name]:
+			conflicts = append(conflicts, SynonymConflict{Kind: kind, Name: name, Existing: existing, Requested: src[name], Source: source})
+		}
+	}
+	return conflicts
+} //src를 dst에 추가, 다른 값으로 이미 있는 항목은 충돌로 기록
+
+func init() {
+	paths := os.Getenv("SYNONYM_FILES") //경로 목록
+	if paths == "" {
+		return //환경변수 미설정 시
+	}
+	for _, p := range strings.Split(paths, string(os.PathListSeparator)) { //경로 목록 순회
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		conflicts, err := LoadSynonymsFile(p)
+		if err != nil {
+			log.Printf("[synonyms] load failed: %v\n", err) //실패
+			continue
+		}
+		for _, c := range conflicts {
+			log.Printf("[synonyms] conflict: %s\n", c) //충돌
+		}
+		log.Printf("[synonyms] loaded: %s\n", p) //성공
+	}
+} //환경변수 SYNONYM_FILES 읽어 자동으로 유의어 병합
@@ -0,0 +1,92 @@
+package codec
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// restoreSynonyms puts the built-in synonym maps back once the test is done.
+func restoreSynonyms(t *testing.T) {
+	t.Helper()
+	phases, fields := map[string]string{}, map[string]string{}
+	for k, v := range PhaseSynonyms {
+		phases[k] = v
+	}
+	for k, v := range FieldSynonyms {
+		fields[k] = v
+	}
+	t.Cleanup(func() { PhaseSynonyms, FieldSynonyms = phases, fields })
+}
+
+func writeSynonyms(t *testing.T, doc string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "synonyms.yaml")
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatalf("write synonyms: %v", err)
+	}
+	return path
+}
+
+func TestLoadSynonymsFile(t *testing.T) {
+	restoreSynonyms(t)
+	path := writeSynonyms(t, `
+phases:
+  BlockVote: Commit
+  Prevote: Commit
+fields:
+  blk_no: Height
+  voter: Validator
+  digest: PrevHash
+  height: Height
+`)
+	conflicts, err := LoadSynonymsFile(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(conflicts) != 2 || conflicts[0].Name != "Prevote" || conflicts[1].Name != "digest" ||
+		conflicts[1].Existing != "BlockHash" || conflicts[1].Requested != "PrevHash" {
+		t.Fatalf("unexpected conflicts %+v", conflicts)
+	}
+	if !strings.Contains(conflicts[1].String(), path) {
+		t.Fatalf("conflict should name its file: %s", conflicts[1])
+	}
+
+	am, err := Parse([]byte(`{"type":"BlockVote","blk_no":9,"voter":"n1","digest":"0xab"}`), ParseOptions{Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if am.Type != "Commit" || am.Height.Int64() != 9 || am.Validator != "n1" || am.BlockHash != "0xab" {
+		t.Fatalf("merged synonyms not applied, conflicting ones not kept out: %+v", am)
+	}
+}
+
+func TestLoadSynonymsFileRejectsBadEntries(t *testing.T) {
+	restoreSynonyms(t)
+	for name, tc := range map[string]struct{ doc, want string }{
+		"unknown field":  {"fields: {blk_no: Heigth}", `unknown field "Heigth"`},
+		"unknown phase":  {"phases: {BlockVote: Finalize}", `unknown phase "Finalize"`},
+		"reserved name":  {"fields: {type: Height}", "invalid field synonym"},
+		"misspelled key": {"field: {blk_no: Height}", "not found"},
+	} {
+		if _, err := LoadSynonymsFile(writeSynonyms(t, tc.doc)); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: want error containing %q, got %v", name, tc.want, err)
+		}
+	}
+	// A file with one bad entry merges nothing
+	if _, err := LoadSynonymsFile(writeSynonyms(t, "phases: {BlockVote: Commit}\nfields: {x: Nope}")); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, ok := PhaseSynonyms["BlockVote"]; ok {
+		t.Fatal("a rejected file must not merge its valid entries")
+	}
+}
+
+func TestExampleSynonymsFile(t *testing.T) {
+	restoreSynonyms(t)
+	conflicts, err := LoadSynonymsFile(filepath.Join("..", "..", "configs", "synonyms.yaml"))
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf("example file should load without conflicts: %v %v", conflicts, err)
+	}
+}
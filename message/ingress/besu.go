@@ -0,0 +1,307 @@
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	besuAdapter "codec/hyperledger/besu/adapter"
+	"codec/message/abstraction"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Besu BFT protocols a collector can follow. They differ in the JSON-RPC namespace, the message codes, and
+// the consensus_type label of the raw messages.
+const (
+	ProtocolQBFT  = "qbft"
+	ProtocolIBFT2 = "ibft2"
+)
+
+// BesuConfig configures a Besu collector.
+type BesuConfig struct {
+	// URL is the node's WebSocket endpoint, e.g. ws://127.0.0.1:8546. It needs the ETH API and the QBFT or
+	// IBFT API of Protocol enabled.
+	URL string
+	// ChainID labels the raw messages; it should be the bridge chain name so they reach its mapper.
+	ChainID string
+	// Protocol is ProtocolQBFT or ProtocolIBFT2; empty uses QBFT.
+	Protocol string
+	// IdleTimeout reconnects when the node sends nothing for this long; zero uses two minutes. Besu chains
+	// seal a block every few seconds, so a silent node has usually stopped producing.
+	IdleTimeout time.Duration
+	// Logger receives the collector's reconnects; nil uses slog.Default.
+	Logger *slog.Logger
+}
+
+// BesuCollector follows a Besu node's new heads and reconstructs the QBFT round that sealed each block. The
+// node does not expose the round's messages, so each block yields the Proposal of its proposer and one
+// Commit per commit seal in its extraData, in the JSON layout the Besu mapper reads.
+type BesuCollector struct {
+	cfg BesuConfig
+	// namespace prefixes the validator query: qbft or ibft.
+	namespace string
+	// codes are the protocol's Proposal and Commit message codes.
+	proposalCode, commitCode uint8
+	consensusType            string
+}
+
+// NewBesuCollector returns a collector for cfg; it connects when Run is called.
+func NewBesuCollector(cfg BesuConfig) (*BesuCollector, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("besu collector: a WebSocket URL is required")
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = 2 * time.Minute
+	}
+	c := &BesuCollector{cfg: cfg}
+	switch strings.ToLower(cfg.Protocol) {
+	case "", ProtocolQBFT:
+		c.namespace, c.proposalCode, c.commitCode, c.consensusType = "qbft", 0x12, 0x14, "QBFT"
+	case ProtocolIBFT2, "ibft":
+		c.namespace, c.proposalCode, c.commitCode, c.consensusType = "ibft", 0x00, 0x02, "IBFT2.0"
+	default:
+		return nil, fmt.Errorf("besu collector: unknown protocol %q (%s or %s)", cfg.Protocol, ProtocolQBFT, ProtocolIBFT2)
+	}
+	return c, nil
+}
+
+// Run follows the node's new heads and delivers the messages of each block until ctx ends, reconnecting
+// whenever the connection drops. Blocks sealed while disconnected are not fetched.
+func (c *BesuCollector) Run(ctx context.Context, deliver Deliver) error {
+	return runConnected(ctx, collectorLogger(c.cfg.Logger, "besu", c.cfg.URL), func(ctx context.Context, progressed func()) error {
+		return c.session(ctx, deliver, progressed)
+	})
+}
+
+func (c *BesuCollector) session(ctx context.Context, deliver Deliver, progressed func()) error {
+	conn, err := dialRPC(ctx, c.cfg.URL, c.cfg.IdleTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	subscribe, err := conn.call("eth_subscribe", []string{"newHeads"})
+	if err != nil {
+		return err
+	}
+	// Heads wait here for the validator set of their block, which the node answers between notifications.
+	pending := make(map[int]besuHead)
+	for {
+		frame, err := conn.read()
+		if err != nil {
+			return err
+		}
+		if frame.Method == "eth_subscription" {
+			var note struct {
+				Result json.RawMessage `json:"result"`
+			}
+			if err := json.Unmarshal(frame.Params, &note); err != nil {
+				return fmt.Errorf("newHeads notification: %v", err)
+			}
+			head, err := decodeHead(note.Result)
+			if err != nil {
+				return fmt.Errorf("newHeads notification: %v", err)
+			}
+			progressed()
+			id, err := conn.call(c.namespace+"_getValidatorsByBlockNumber", []string{hexutil.EncodeBig(head.header.Number)})
+			if err != nil {
+				return err
+			}
+			pending[id] = head
+			continue
+		}
+		id := idOf(frame.ID)
+		if id == subscribe {
+			if frame.Error != nil {
+				return fmt.Errorf("eth_subscribe: %v", frame.Error)
+			}
+			continue
+		}
+		head, ok := pending[id]
+		if !ok {
+			continue
+		}
+		delete(pending, id)
+		var validators []common.Address
+		if frame.Error != nil {
+			return fmt.Errorf("%s_getValidatorsByBlockNumber: %v", c.namespace, frame.Error)
+		}
+		if err := json.Unmarshal(frame.Result, &validators); err != nil {
+			return fmt.Errorf("%s_getValidatorsByBlockNumber: %v", c.namespace, err)
+		}
+		raws, err := c.convert(head, validators)
+		if err != nil {
+			return fmt.Errorf("block %v: %v", head.header.Number, err)
+		}
+		for _, raw := range raws {
+			deliver(raw)
+		}
+	}
+}
+
+// besuHead is a newHeads header with the hash the node reports for it. Besu hashes BFT blocks without their
+// commit seals, so the hash cannot be recomputed from the header as geth would.
+type besuHead struct {
+	header *types.Header
+	hash   common.Hash
+}
+
+func decodeHead(data json.RawMessage) (besuHead, error) {
+	var head besuHead
+	var hash struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err := json.Unmarshal(data, &hash); err != nil {
+		return head, err
+	}
+	head.header, head.hash = new(types.Header), hash.Hash
+	if err := json.Unmarshal(data, head.header); err != nil {
+		return head, err
+	}
+	return head, nil
+}
+
+// bftExtraData is a BFT block's extraData: the RLP list [vanity, validators, vote, round, seals]. The items
+// stay raw so the list can be re-encoded without its seals exactly as the node encoded it.
+type bftExtraData struct {
+	items []rlp.RawValue
+	round uint64
+	seals [][]byte
+}
+
+func decodeExtraData(extra []byte) (*bftExtraData, error) {
+	var items []rlp.RawValue
+	if err := rlp.DecodeBytes(extra, &items); err != nil {
+		return nil, fmt.Errorf("extraData: %v", err)
+	}
+	if len(items) != 5 {
+		return nil, fmt.Errorf("extraData has %d items, expected 5", len(items))
+	}
+	// IBFT 2.0 writes the round as four bytes and QBFT as an integer; both read as a big-endian number.
+	var round []byte
+	if err := rlp.DecodeBytes(items[3], &round); err != nil {
+		return nil, fmt.Errorf("extraData round: %v", err)
+	}
+	data := &bftExtraData{items: items, round: new(big.Int).SetBytes(round).Uint64()}
+	if err := rlp.DecodeBytes(items[4], &data.seals); err != nil {
+		return nil, fmt.Errorf("extraData seals: %v", err)
+	}
+	return data, nil
+}
+
+// sealHash is the hash validators sign in their commit seals: the header hash with the seals removed from its
+// extraData but the round kept.
+func (d *bftExtraData) sealHash(header *types.Header) (common.Hash, error) {
+	items := append(append([]rlp.RawValue(nil), d.items[:4]...), rlp.EmptyList)
+	extra, err := rlp.EncodeToBytes(items)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	unsealed := types.CopyHeader(header)
+	unsealed.Extra = extra
+	return unsealed.Hash(), nil
+}
+
+// convert reconstructs the Proposal and Commits that sealed head. A seal whose signer cannot be recovered,
+// or is not in validators, is delivered with a "seal-<index>" validator so that it is still counted.
+func (c *BesuCollector) convert(head besuHead, validators []common.Address) ([]abstraction.RawConsensusMessage, error) {
+	header := head.header
+	extra, err := decodeExtraData(header.Extra)
+	if err != nil {
+		return nil, err
+	}
+	sealHash, err := extra.sealHash(header)
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[common.Address]bool, len(validators))
+	for _, v := range validators {
+		members[v] = true
+	}
+
+	now := time.Now().UTC()
+	blockHash := head.hash
+	message := func(messageType string, payload interface{}, validator string, extraMeta map[string]interface{}) (abstraction.RawConsensusMessage, error) {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return abstraction.RawConsensusMessage{}, err
+		}
+		metadata := map[string]interface{}{
+			"source":          "besu_websocket",
+			"validator":       validator,
+			"gas_limit":       header.GasLimit,
+			"gas_used":        header.GasUsed,
+			"validator_count": len(validators),
+			"consensus_type":  c.consensusType,
+			"block_time":      header.Time,
+		}
+		for k, v := range extraMeta {
+			metadata[k] = v
+		}
+		return abstraction.RawConsensusMessage{
+			ChainType:   abstraction.ChainTypeHyperledger,
+			ChainID:     c.cfg.ChainID,
+			MessageType: messageType,
+			Payload:     data,
+			Encoding:    "json",
+			Timestamp:   now,
+			Metadata:    metadata,
+		}, nil
+	}
+
+	body := func(code uint8, signature []byte) besuAdapter.BesuIBFTMessage {
+		return besuAdapter.BesuIBFTMessage{
+			Code:      code,
+			Height:    new(big.Int).Set(header.Number),
+			Round:     extra.round,
+			BlockHash: blockHash,
+			Signature: signature,
+		}
+	}
+	// Besu puts the proposer's address in the block's coinbase.
+	proposal, err := message("Proposal", body(c.proposalCode, nil), strings.ToLower(header.Coinbase.Hex()), nil)
+	if err != nil {
+		return nil, err
+	}
+	raws := []abstraction.RawConsensusMessage{proposal}
+
+	for i, seal := range extra.seals {
+		validator := fmt.Sprintf("seal-%d", i)
+		recovered := false
+		if signer, err := sealSigner(sealHash, seal); err == nil && members[signer] {
+			validator, recovered = strings.ToLower(signer.Hex()), true
+		}
+		commit, err := message("Commit", besuAdapter.BesuCommitPayload{Body: body(c.commitCode, nil), CommitSeal: seal},
+			validator, map[string]interface{}{"seal_index": i, "seal_recovered": recovered})
+		if err != nil {
+			return nil, err
+		}
+		raws = append(raws, commit)
+	}
+	return raws, nil
+}
+
+// sealSigner recovers the address that produced a 65-byte [R || S || V] seal over hash. V is 0 or 1 as Besu
+// writes it; 27 and 28 are accepted too.
+func sealSigner(hash common.Hash, seal []byte) (common.Address, error) {
+	if len(seal) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("seal is %d bytes, expected %d", len(seal), crypto.SignatureLength)
+	}
+	sig := append([]byte(nil), seal...)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	pub, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
@@ -0,0 +1,195 @@
+package ingress
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	besuAdapter "codec/hyperledger/besu/adapter"
+	"codec/message/abstraction"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"golang.org/x/net/websocket"
+)
+
+// fakeBesu answers eth_subscribe, sends one head per connection, and answers the validator query for it. The
+// first connection is closed afterwards, so the collector has to reconnect for the second head.
+type fakeBesu struct {
+	validators []common.Address
+	heads      []string
+
+	mu          sync.Mutex
+	connections int
+	methods     []string
+}
+
+func (f *fakeBesu) serve(ws *websocket.Conn) {
+	f.mu.Lock()
+	n := f.connections
+	f.connections++
+	f.mu.Unlock()
+
+	validators, _ := json.Marshal(f.validators)
+	for {
+		var req struct {
+			ID     int      `json:"id"`
+			Method string   `json:"method"`
+			Params []string `json:"params"`
+		}
+		if err := websocket.JSON.Receive(ws, &req); err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.methods = append(f.methods, req.Method+" "+strings.Join(req.Params, ","))
+		f.mu.Unlock()
+		switch req.Method {
+		case "eth_subscribe":
+			websocket.Message.Send(ws, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":"0xcafe"}`, req.ID))
+			if n < len(f.heads) {
+				websocket.Message.Send(ws, `{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xcafe","result":`+f.heads[n]+`}}`)
+			}
+		default:
+			websocket.Message.Send(ws, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, validators))
+			if n == 0 {
+				ws.Close()
+				return
+			}
+		}
+	}
+}
+
+// sealedHead builds a newHeads notification for a QBFT block sealed by signers, followed by extra raw seals.
+func sealedHead(t *testing.T, number int64, round uint64, proposer common.Address, validators []common.Address,
+	signers []*ecdsa.PrivateKey, extraSeals ...[]byte) string {
+	t.Helper()
+	encode := func(seals [][]byte) []byte {
+		extra, err := rlp.EncodeToBytes([]interface{}{make([]byte, 32), validators, []interface{}{}, round, seals})
+		if err != nil {
+			t.Fatalf("encode extraData: %v", err)
+		}
+		return extra
+	}
+	header := &types.Header{
+		ParentHash: common.HexToHash("0x01"),
+		UncleHash:  types.EmptyUncleHash,
+		Coinbase:   proposer,
+		Difficulty: big.NewInt(1),
+		Number:     big.NewInt(number),
+		GasLimit:   30000000,
+		GasUsed:    21000,
+		Time:       uint64(time.Now().Unix()),
+		Extra:      encode(nil),
+	}
+	sealHash := header.Hash()
+	var seals [][]byte
+	for _, key := range signers {
+		seal, err := crypto.Sign(sealHash.Bytes(), key)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		seals = append(seals, seal)
+	}
+	header.Extra = encode(append(seals, extraSeals...))
+	data, err := header.MarshalJSON()
+	if err != nil {
+		t.Fatalf("marshal header: %v", err)
+	}
+	// Stand in for the node's seal-less block hash, which differs from geth's hash of the full header.
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	fields["hash"] = common.BigToHash(big.NewInt(number)).Hex()
+	data, _ = json.Marshal(fields)
+	return string(data)
+}
+
+func TestBesuCollector(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	var validators []common.Address
+	for i := 0; i < 3; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("generate key: %v", err)
+		}
+		keys = append(keys, key)
+		validators = append(validators, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	fake := &fakeBesu{validators: validators, heads: []string{
+		sealedHead(t, 5, 2, validators[0], validators, keys[:2], make([]byte, 65)),
+		sealedHead(t, 6, 0, validators[1], validators, keys[2:]),
+	}}
+	server := httptest.NewServer(websocket.Handler(fake.serve))
+	defer server.Close()
+
+	collector, err := NewBesuCollector(BesuConfig{URL: "ws" + strings.TrimPrefix(server.URL, "http"), ChainID: "besu"})
+	if err != nil {
+		t.Fatalf("new collector: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var raws []abstraction.RawConsensusMessage
+	done := make(chan error, 1)
+	go func() {
+		done <- collector.Run(ctx, func(raw abstraction.RawConsensusMessage) {
+			raws = append(raws, raw)
+			if len(raws) == 6 {
+				cancel()
+			}
+		})
+	}()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected Run to end with the context, got %v", err)
+	}
+	if len(raws) != 6 {
+		t.Fatalf("expected six raw messages across the reconnect, got %d", len(raws))
+	}
+	if fake.connections != 2 || len(fake.methods) != 4 || fake.methods[1] != "qbft_getValidatorsByBlockNumber 0x5" {
+		t.Fatalf("expected a resubscription after the reconnect, got %d connections and %v", fake.connections, fake.methods)
+	}
+
+	lower := func(a common.Address) string { return strings.ToLower(a.Hex()) }
+	mapper := besuAdapter.NewBesuMapper("besu")
+	want := []struct {
+		msgType   abstraction.MsgType
+		height    int64
+		round     int64
+		validator string
+	}{
+		{abstraction.MsgTypeProposal, 5, 2, lower(validators[0])},
+		{abstraction.MsgTypeCommit, 5, 2, lower(validators[0])},
+		{abstraction.MsgTypeCommit, 5, 2, lower(validators[1])},
+		{abstraction.MsgTypeCommit, 5, 2, "seal-2"},
+		{abstraction.MsgTypeProposal, 6, 0, lower(validators[1])},
+		{abstraction.MsgTypeCommit, 6, 0, lower(validators[2])},
+	}
+	for i, raw := range raws {
+		if raw.ChainID != "besu" || raw.ChainType != abstraction.ChainTypeHyperledger || raw.Metadata["consensus_type"] != "QBFT" {
+			t.Fatalf("raw %d has unexpected labels: %+v", i, raw)
+		}
+		canonical, err := mapper.ToCanonical(raw)
+		if err != nil {
+			t.Fatalf("raw %d does not convert: %v", i, err)
+		}
+		if canonical.Type != want[i].msgType || canonical.Height.Int64() != want[i].height || canonical.Round.Int64() != want[i].round ||
+			canonical.Validator != want[i].validator || canonical.BlockHash != common.BigToHash(big.NewInt(want[i].height)).Hex() {
+			payload, _ := json.Marshal(canonical)
+			t.Fatalf("raw %d converted to %s", i, payload)
+		}
+	}
+	if raws[3].Metadata["seal_recovered"] != false || raws[0].Metadata["validator_count"] != 3 {
+		t.Fatalf("unexpected metadata %v / %v", raws[3].Metadata, raws[0].Metadata)
+	}
+
+	if _, err := NewBesuCollector(BesuConfig{URL: "ws://node", Protocol: "clique"}); err == nil {
+		t.Fatalf("expected an unknown protocol to be rejected")
+	}
+}
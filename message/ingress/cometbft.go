@@ -0,0 +1,306 @@
+package ingress
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	cometbftAdapter "codec/cometbft/adapter"
+	"codec/message/abstraction"
+)
+
+// CometBFT event names a collector can subscribe to.
+const (
+	EventNewRound            = "NewRound"
+	EventCompleteProposal    = "CompleteProposal"
+	EventVote                = "Vote"
+	EventValidatorSetUpdates = "ValidatorSetUpdates"
+)
+
+// DefaultCometBFTEvents are the events a collector subscribes to when none are configured.
+var DefaultCometBFTEvents = []string{EventNewRound, EventCompleteProposal, EventVote, EventValidatorSetUpdates}
+
+// roundSteps numbers CometBFT's RoundStepType names as the consensus reactor does.
+var roundSteps = map[string]uint32{
+	"RoundStepNewHeight":     1,
+	"RoundStepNewRound":      2,
+	"RoundStepPropose":       3,
+	"RoundStepPrevote":       4,
+	"RoundStepPrevoteWait":   5,
+	"RoundStepPrecommit":     6,
+	"RoundStepPrecommitWait": 7,
+	"RoundStepCommit":        8,
+}
+
+// CometBFTConfig configures a CometBFT collector.
+type CometBFTConfig struct {
+	// URL is the node's WebSocket endpoint, e.g. ws://127.0.0.1:26657/websocket.
+	URL string
+	// ChainID labels the raw messages; it should be the bridge chain name so they reach its mapper.
+	ChainID string
+	// Events to subscribe to; empty subscribes to DefaultCometBFTEvents.
+	Events []string
+	// IdleTimeout reconnects when the node sends nothing for this long; zero uses two minutes.
+	IdleTimeout time.Duration
+	// OnValidatorUpdates receives ValidatorSetUpdates events. The bridge has no canonical type for them,
+	// so they are not delivered as raw messages.
+	OnValidatorUpdates func(height int64, updates []ValidatorUpdate)
+	// Logger receives the collector's reconnects; nil uses slog.Default.
+	Logger *slog.Logger
+}
+
+// ValidatorUpdate is one entry of a ValidatorSetUpdates event. A voting power of zero removes the validator.
+type ValidatorUpdate struct {
+	Address          string `json:"address"`
+	PubKeyType       string `json:"pub_key_type"`
+	PubKey           string `json:"pub_key"`
+	VotingPower      int64  `json:"voting_power"`
+	ProposerPriority int64  `json:"proposer_priority"`
+}
+
+// CometBFTCollector subscribes to a CometBFT node's consensus events and delivers them as raw messages in the
+// JSON layout the CometBFT mapper reads.
+type CometBFTCollector struct {
+	cfg CometBFTConfig
+
+	mu sync.Mutex
+	// height is the height of the latest NewRound, which ValidatorSetUpdates events do not carry.
+	height int64
+}
+
+// NewCometBFTCollector returns a collector for cfg; it connects when Run is called.
+func NewCometBFTCollector(cfg CometBFTConfig) (*CometBFTCollector, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("cometbft collector: a WebSocket URL is required")
+	}
+	if len(cfg.Events) == 0 {
+		cfg.Events = DefaultCometBFTEvents
+	}
+	for _, event := range cfg.Events {
+		switch event {
+		case EventNewRound, EventCompleteProposal, EventVote, EventValidatorSetUpdates:
+		default:
+			return nil, fmt.Errorf("cometbft collector: unknown event %q", event)
+		}
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = 2 * time.Minute
+	}
+	return &CometBFTCollector{cfg: cfg}, nil
+}
+
+// Run subscribes to the node and delivers its events until ctx ends, reconnecting whenever the connection
+// drops. Events that happen while disconnected are lost; the node does not replay them.
+func (c *CometBFTCollector) Run(ctx context.Context, deliver Deliver) error {
+	return runConnected(ctx, collectorLogger(c.cfg.Logger, "cometbft", c.cfg.URL), func(ctx context.Context, progressed func()) error {
+		return c.session(ctx, deliver, progressed)
+	})
+}
+
+func (c *CometBFTCollector) session(ctx context.Context, deliver Deliver, progressed func()) error {
+	conn, err := dialRPC(ctx, c.cfg.URL, c.cfg.IdleTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	for _, event := range c.cfg.Events {
+		if _, err := conn.call("subscribe", map[string]string{"query": fmt.Sprintf("tm.event='%s'", event)}); err != nil {
+			return err
+		}
+	}
+	for {
+		frame, err := conn.read()
+		if err != nil {
+			return err
+		}
+		if frame.Error != nil {
+			return frame.Error
+		}
+		var result struct {
+			Data *struct {
+				Type  string          `json:"type"`
+				Value json.RawMessage `json:"value"`
+			} `json:"data"`
+		}
+		if len(frame.Result) == 0 || json.Unmarshal(frame.Result, &result) != nil || result.Data == nil {
+			continue // a subscription acknowledgement
+		}
+		progressed()
+		raw, ok, err := c.convert(result.Data.Type, result.Data.Value)
+		if err != nil {
+			return fmt.Errorf("%s event: %v", result.Data.Type, err)
+		}
+		if ok {
+			deliver(raw)
+		}
+	}
+}
+
+// cometBlockID is a block ID as CometBFT events encode it.
+type cometBlockID struct {
+	Hash  string `json:"hash"`
+	Parts struct {
+		Total uint32 `json:"total"`
+		Hash  string `json:"hash"`
+	} `json:"parts"`
+}
+
+func (b cometBlockID) adapter() cometbftAdapter.BlockID {
+	hash, _ := hex.DecodeString(b.Parts.Hash)
+	return cometbftAdapter.BlockID{
+		Hash:          b.Hash,
+		PartSetHeader: cometbftAdapter.PartSetHeader{Total: b.Parts.Total, Hash: hash},
+	}
+}
+
+// convert turns one event into a raw message. ok is false for events that are consumed rather than delivered.
+func (c *CometBFTCollector) convert(eventType string, value json.RawMessage) (raw abstraction.RawConsensusMessage, ok bool, err error) {
+	now := time.Now().UTC()
+	metadata := map[string]interface{}{"source": "cometbft_websocket", "event": eventType}
+	var msg cometbftAdapter.CometBFTConsensusMessage
+
+	switch eventType {
+	case "tendermint/event/NewRound":
+		var ev struct {
+			Height   string `json:"height"`
+			Round    int32  `json:"round"`
+			Step     string `json:"step"`
+			Proposer struct {
+				Address string `json:"address"`
+				Index   int32  `json:"index"`
+			} `json:"proposer"`
+		}
+		if err := json.Unmarshal(value, &ev); err != nil {
+			return raw, false, err
+		}
+		if height, err := strconv.ParseInt(ev.Height, 10, 64); err == nil {
+			c.mu.Lock()
+			c.height = height
+			c.mu.Unlock()
+		}
+		msg = cometbftAdapter.CometBFTConsensusMessage{
+			MessageType: "NewRoundStep",
+			Height:      ev.Height,
+			Round:       strconv.Itoa(int(ev.Round)),
+			Step:        roundSteps[ev.Step],
+			Timestamp:   now,
+		}
+		metadata["proposer_address"] = ev.Proposer.Address
+		metadata["proposer_index"] = ev.Proposer.Index
+
+	case "tendermint/event/CompleteProposal":
+		var ev struct {
+			Height  string       `json:"height"`
+			Round   int32        `json:"round"`
+			Step    string       `json:"step"`
+			BlockID cometBlockID `json:"block_id"`
+		}
+		if err := json.Unmarshal(value, &ev); err != nil {
+			return raw, false, err
+		}
+		msg = cometbftAdapter.CometBFTConsensusMessage{
+			MessageType: "Proposal",
+			Height:      ev.Height,
+			Round:       strconv.Itoa(int(ev.Round)),
+			Step:        roundSteps[ev.Step],
+			BlockID:     ev.BlockID.adapter(),
+			Timestamp:   now,
+		}
+
+	case "tendermint/event/Vote":
+		var ev struct {
+			Vote struct {
+				Type               int32        `json:"type"`
+				Height             string       `json:"height"`
+				Round              int32        `json:"round"`
+				BlockID            cometBlockID `json:"block_id"`
+				Timestamp          time.Time    `json:"timestamp"`
+				ValidatorAddress   string       `json:"validator_address"`
+				ValidatorIndex     int32        `json:"validator_index"`
+				Signature          string       `json:"signature"`
+				Extension          string       `json:"extension"`
+				ExtensionSignature string       `json:"extension_signature"`
+			} `json:"Vote"`
+		}
+		if err := json.Unmarshal(value, &ev); err != nil {
+			return raw, false, err
+		}
+		vote := ev.Vote
+		msg = cometbftAdapter.CometBFTConsensusMessage{
+			MessageType:        "Vote",
+			Type:               vote.Type,
+			Height:             vote.Height,
+			Round:              strconv.Itoa(int(vote.Round)),
+			BlockID:            vote.BlockID.adapter(),
+			Timestamp:          vote.Timestamp,
+			ValidatorAddress:   vote.ValidatorAddress,
+			ValidatorIndex:     vote.ValidatorIndex,
+			Signature:          vote.Signature,
+			Extension:          vote.Extension,
+			ExtensionSignature: vote.ExtensionSignature,
+		}
+		switch vote.Type {
+		case 1:
+			msg.VoteType = "prevote"
+		case 2:
+			msg.VoteType = "precommit"
+		}
+
+	case "tendermint/event/ValidatorSetUpdates":
+		var ev struct {
+			Updates []struct {
+				Address string `json:"address"`
+				PubKey  struct {
+					Type  string `json:"type"`
+					Value string `json:"value"`
+				} `json:"pub_key"`
+				VotingPower      string `json:"voting_power"`
+				ProposerPriority string `json:"proposer_priority"`
+			} `json:"validator_updates"`
+		}
+		if err := json.Unmarshal(value, &ev); err != nil {
+			return raw, false, err
+		}
+		if c.cfg.OnValidatorUpdates != nil {
+			updates := make([]ValidatorUpdate, 0, len(ev.Updates))
+			for _, u := range ev.Updates {
+				power, _ := strconv.ParseInt(u.VotingPower, 10, 64)
+				priority, _ := strconv.ParseInt(u.ProposerPriority, 10, 64)
+				updates = append(updates, ValidatorUpdate{
+					Address:          u.Address,
+					PubKeyType:       u.PubKey.Type,
+					PubKey:           u.PubKey.Value,
+					VotingPower:      power,
+					ProposerPriority: priority,
+				})
+			}
+			c.mu.Lock()
+			height := c.height
+			c.mu.Unlock()
+			c.cfg.OnValidatorUpdates(height, updates)
+		}
+		return raw, false, nil
+
+	default:
+		return raw, false, nil
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return raw, false, err
+	}
+	return abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeCometBFT,
+		ChainID:     c.cfg.ChainID,
+		MessageType: msg.MessageType,
+		Payload:     payload,
+		Encoding:    "json",
+		Timestamp:   now,
+		Metadata:    metadata,
+	}, true, nil
+}
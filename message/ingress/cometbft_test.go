@@ -0,0 +1,156 @@
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cometbftAdapter "codec/cometbft/adapter"
+	"codec/message/abstraction"
+
+	"golang.org/x/net/websocket"
+)
+
+// fakeCometBFT answers subscribe requests and then sends the events queued for the connection. The first
+// connection is closed after its events, so the collector has to reconnect for the rest.
+type fakeCometBFT struct {
+	mu          sync.Mutex
+	connections int
+	queries     []string
+	events      [][]string
+}
+
+func (f *fakeCometBFT) serve(ws *websocket.Conn) {
+	f.mu.Lock()
+	n := f.connections
+	f.connections++
+	var events []string
+	if n < len(f.events) {
+		events = f.events[n]
+	}
+	f.mu.Unlock()
+
+	for i := 0; i < len(DefaultCometBFTEvents); i++ {
+		var req struct {
+			ID     int               `json:"id"`
+			Method string            `json:"method"`
+			Params map[string]string `json:"params"`
+		}
+		if err := websocket.JSON.Receive(ws, &req); err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.queries = append(f.queries, req.Method+" "+req.Params["query"])
+		f.mu.Unlock()
+		websocket.Message.Send(ws, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":{}}`, req.ID))
+	}
+	for _, event := range events {
+		websocket.Message.Send(ws, `{"jsonrpc":"2.0","id":3,"result":{"query":"q","data":`+event+`}}`)
+	}
+	if n == 0 {
+		ws.Close()
+		return
+	}
+	// Later connections stay open until the collector goes away.
+	var discard interface{}
+	websocket.JSON.Receive(ws, &discard)
+}
+
+func TestCometBFTCollector(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	vote := func(height int, typ int) string {
+		return fmt.Sprintf(`{"type":"tendermint/event/Vote","value":{"Vote":{"type":%d,"height":"%d","round":0,`+
+			`"block_id":{"hash":"ABCD","parts":{"total":1,"hash":"0102"}},"timestamp":%q,`+
+			`"validator_address":"VAL1","validator_index":2,"signature":"c2ln"}}}`, typ, height, now)
+	}
+	fake := &fakeCometBFT{events: [][]string{
+		{
+			`{"type":"tendermint/event/NewRound","value":{"height":"7","round":1,"step":"RoundStepNewRound","proposer":{"address":"VAL0","index":0}}}`,
+			`{"type":"tendermint/event/CompleteProposal","value":{"height":"7","round":1,"step":"RoundStepPropose","block_id":{"hash":"ABCD","parts":{"total":1,"hash":"0102"}}}}`,
+			`{"type":"tendermint/event/ValidatorSetUpdates","value":{"validator_updates":[{"address":"VAL3","pub_key":{"type":"tendermint/PubKeyEd25519","value":"a2V5"},"voting_power":"10","proposer_priority":"-5"}]}}`,
+			vote(7, 1),
+		},
+		{vote(7, 2)},
+	}}
+	server := httptest.NewServer(websocket.Handler(fake.serve))
+	defer server.Close()
+
+	var updates []ValidatorUpdate
+	var updateHeight int64
+	collector, err := NewCometBFTCollector(CometBFTConfig{
+		URL:     "ws" + strings.TrimPrefix(server.URL, "http"),
+		ChainID: "cometbft",
+		OnValidatorUpdates: func(height int64, u []ValidatorUpdate) {
+			updateHeight, updates = height, u
+		},
+	})
+	if err != nil {
+		t.Fatalf("new collector: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var raws []abstraction.RawConsensusMessage
+	done := make(chan error, 1)
+	go func() {
+		done <- collector.Run(ctx, func(raw abstraction.RawConsensusMessage) {
+			raws = append(raws, raw)
+			if len(raws) == 4 {
+				cancel()
+			}
+		})
+	}()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected Run to end with the context, got %v", err)
+	}
+	if len(raws) != 4 {
+		t.Fatalf("expected four raw messages across the reconnect, got %d", len(raws))
+	}
+	if fake.connections != 2 || len(fake.queries) != 2*len(DefaultCometBFTEvents) || fake.queries[0] != "subscribe tm.event='NewRound'" {
+		t.Fatalf("expected a resubscription after the reconnect, got %d connections and %v", fake.connections, fake.queries)
+	}
+	if updateHeight != 7 || len(updates) != 1 || updates[0].VotingPower != 10 || updates[0].ProposerPriority != -5 {
+		t.Fatalf("unexpected validator updates at %d: %+v", updateHeight, updates)
+	}
+
+	mapper := cometbftAdapter.NewCometBFTMapper("cometbft")
+	want := []struct {
+		msgType   abstraction.MsgType
+		blockHash string
+		validator string
+	}{
+		{abstraction.MsgTypeProposal, "", ""},
+		{abstraction.MsgTypeProposal, "ABCD", ""},
+		{abstraction.MsgTypePrevote, "ABCD", "VAL1"},
+		{abstraction.MsgTypePrecommit, "ABCD", "VAL1"},
+	}
+	for i, raw := range raws {
+		if raw.ChainID != "cometbft" || raw.ChainType != abstraction.ChainTypeCometBFT || raw.Encoding != "json" {
+			t.Fatalf("raw %d has unexpected labels: %+v", i, raw)
+		}
+		canonical, err := mapper.ToCanonical(raw)
+		if err != nil {
+			t.Fatalf("raw %d does not convert: %v", i, err)
+		}
+		if canonical.Type != want[i].msgType || canonical.BlockHash != want[i].blockHash || canonical.Validator != want[i].validator ||
+			canonical.Height.Int64() != 7 {
+			payload, _ := json.Marshal(canonical)
+			t.Fatalf("raw %d converted to %s", i, payload)
+		}
+	}
+	if raws[0].Metadata["proposer_address"] != "VAL0" || raws[0].Metadata["event"] != "tendermint/event/NewRound" {
+		t.Fatalf("unexpected NewRound metadata %v", raws[0].Metadata)
+	}
+	if raws[1].Payload == nil || !strings.Contains(string(raws[1].Payload), `"step":3`) {
+		t.Fatalf("expected the proposal step in %s", raws[1].Payload)
+	}
+
+	if _, err := NewCometBFTCollector(CometBFTConfig{URL: "ws://node", Events: []string{"Tx"}}); err == nil {
+		t.Fatalf("expected an unknown event to be rejected")
+	}
+}
@@ -0,0 +1,138 @@
+// Package ingress collects consensus traffic from running nodes and turns it into raw consensus messages for
+// the bridge. Collectors hold a subscription open over the node's WebSocket JSON-RPC endpoint, so events arrive
+// as the node produces them, and they reconnect and resubscribe when the connection drops.
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"codec/message/abstraction"
+
+	"golang.org/x/net/websocket"
+)
+
+// Deliver receives each raw message a collector produces. It is called from the collector's goroutine, one
+// message at a time, so a slow Deliver slows down reading from the node.
+type Deliver func(abstraction.RawConsensusMessage)
+
+// Reconnect backoff bounds shared by the collectors.
+const (
+	minBackoff = time.Second
+	maxBackoff = 30 * time.Second
+)
+
+// runConnected calls session until ctx ends, waiting between attempts with an exponential backoff that resets
+// once a session has delivered something, and logging each failed session to logger. It only returns ctx's error.
+func runConnected(ctx context.Context, logger *slog.Logger, session func(ctx context.Context, progressed func()) error) error {
+	backoff := minBackoff
+	for {
+		err := session(ctx, func() { backoff = minBackoff })
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logger.Warn("collector disconnected", "err", err, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// collectorLogger returns logger, or slog.Default when it is nil, labelled with the collector's chain and URL.
+func collectorLogger(logger *slog.Logger, chain, url string) *slog.Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return logger.With("collector", chain, "url", url)
+}
+
+// rpcFrame is any JSON-RPC 2.0 frame a node sends: a response to a request, or a notification.
+type rpcFrame struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+func (e *rpcError) Error() string {
+	if e.Data != "" {
+		return fmt.Sprintf("rpc error %d: %s (%s)", e.Code, e.Message, e.Data)
+	}
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// rpcConn is a JSON-RPC 2.0 session over a WebSocket.
+type rpcConn struct {
+	ws     *websocket.Conn
+	stop   func() bool
+	nextID int
+	// idle bounds the wait for each frame; a node that goes quiet for longer is treated as gone.
+	idle time.Duration
+}
+
+func dialRPC(ctx context.Context, url string, idle time.Duration) (*rpcConn, error) {
+	config, err := websocket.NewConfig(url, "http://localhost/")
+	if err != nil {
+		return nil, err
+	}
+	ws, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Closing the socket unblocks a pending read when ctx ends.
+	stop := context.AfterFunc(ctx, func() { ws.Close() })
+	return &rpcConn{ws: ws, stop: stop, idle: idle}, nil
+}
+
+// call sends a request and returns its ID, which the node echoes in the response.
+func (c *rpcConn) call(method string, params interface{}) (int, error) {
+	c.nextID++
+	req := struct {
+		JSONRPC string      `json:"jsonrpc"`
+		ID      int         `json:"id"`
+		Method  string      `json:"method"`
+		Params  interface{} `json:"params"`
+	}{"2.0", c.nextID, method, params}
+	return c.nextID, websocket.JSON.Send(c.ws, req)
+}
+
+// read returns the next frame from the node.
+func (c *rpcConn) read() (*rpcFrame, error) {
+	if c.idle > 0 {
+		c.ws.SetReadDeadline(time.Now().Add(c.idle))
+	}
+	frame := new(rpcFrame)
+	if err := websocket.JSON.Receive(c.ws, frame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+func (c *rpcConn) Close() error {
+	c.stop()
+	return c.ws.Close()
+}
+
+// idOf reads a numeric frame ID, returning -1 when there is none.
+func idOf(raw json.RawMessage) int {
+	var id int
+	if len(raw) == 0 || json.Unmarshal(raw, &id) != nil {
+		return -1
+	}
+	return id
+}
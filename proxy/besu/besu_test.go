@@ -0,0 +1,174 @@
+package besu
+
+import (
+	"crypto/ecdsa"
+	"strings"
+	"testing"
+	"time"
+
+	besuAdapter "codec/hyperledger/besu/adapter"
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// signedVote builds a prepare or commit the way Besu sends it.
+func signedVote(t *testing.T, key *ecdsa.PrivateKey, code uint64, height, round uint64, digest common.Hash) []byte {
+	t.Helper()
+	fields := []interface{}{height, round, digest.Bytes()}
+	if code == codeCommit {
+		seal, err := crypto.Sign(digest.Bytes(), key)
+		if err != nil {
+			t.Fatalf("seal: %v", err)
+		}
+		fields = append(fields, seal)
+	}
+	payload, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		t.Fatalf("encode payload: %v", err)
+	}
+	hashed, err := rlp.EncodeToBytes([]interface{}{code, rlp.RawValue(payload)})
+	if err != nil {
+		t.Fatalf("encode signing data: %v", err)
+	}
+	sig, err := crypto.Sign(crypto.Keccak256(hashed), key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	data, err := rlp.EncodeToBytes([]interface{}{rlp.RawValue(payload), sig})
+	if err != nil {
+		t.Fatalf("encode message: %v", err)
+	}
+	return data
+}
+
+func canonicalOf(t *testing.T, msg *wireMessage) *abstraction.CanonicalMessage {
+	t.Helper()
+	raw, err := msg.toRaw("besu-test", time.Now())
+	if err != nil {
+		t.Fatalf("toRaw: %v", err)
+	}
+	canonical, err := besuAdapter.NewBesuMapper("besu-test").ToCanonical(*raw)
+	if err != nil {
+		t.Fatalf("ToCanonical: %v", err)
+	}
+	return canonical
+}
+
+func TestWireRoundTrip(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	address := strings.ToLower(crypto.PubkeyToAddress(key.PublicKey).Hex()[2:])
+	digest := common.HexToHash("0xabc1")
+	data := signedVote(t, key, codeCommit, 12, 1, digest)
+
+	msg, err := decodeWire(codeCommit, data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if msg.height.Int64() != 12 || msg.round != 1 || msg.digest != digest {
+		t.Fatalf("decoded height=%v round=%d digest=%s", msg.height, msg.round, msg.digest)
+	}
+	if got := msg.author(); got != address {
+		t.Fatalf("author = %s, want %s", got, address)
+	}
+	encoded, err := msg.encode()
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if string(encoded) != string(data) {
+		t.Fatalf("re-encoded message differs from the original")
+	}
+
+	canonical := canonicalOf(t, msg)
+	if canonical.Type != abstraction.MsgTypeCommit || canonical.Validator != address || canonical.Height.Int64() != 12 || canonical.BlockHash != digest.Hex() {
+		t.Fatalf("canonical = %+v", canonical)
+	}
+	// The canonical signature of a commit is its seal, which must survive an unmutated pass unchanged.
+	out, err := msg.apply(canonical, key)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if encoded, _ := out.encode(); string(encoded) != string(data) {
+		t.Fatalf("unmutated commit changed on apply")
+	}
+}
+
+func TestDoubleVoteResigned(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	address := strings.ToLower(crypto.PubkeyToAddress(key.PublicKey).Hex()[2:])
+	digest := common.HexToHash("0xabc1")
+	msg, err := decodeWire(codePrepare, signedVote(t, key, codePrepare, 7, 0, digest))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	mutated, err := ByzantineEngine.Apply(canonicalOf(t, msg), byzantine.ActionDoubleVote, byzantine.Options{})
+	if err != nil {
+		t.Fatalf("apply action: %v", err)
+	}
+	if len(mutated) != 2 {
+		t.Fatalf("double_vote produced %d messages", len(mutated))
+	}
+	digests := map[common.Hash]bool{}
+	for _, m := range mutated {
+		out, err := msg.apply(m, key)
+		if err != nil {
+			t.Fatalf("apply to wire: %v", err)
+		}
+		frame, err := out.encode()
+		if err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		decoded, err := decodeWire(codePrepare, frame)
+		if err != nil {
+			t.Fatalf("decode mutated: %v", err)
+		}
+		if decoded.height.Int64() != 7 {
+			t.Fatalf("height changed to %v", decoded.height)
+		}
+		if got := decoded.author(); got != address {
+			t.Fatalf("mutated prepare signed by %q, want %s", got, address)
+		}
+		digests[decoded.digest] = true
+	}
+	if len(digests) != 2 {
+		t.Fatalf("double_vote did not produce conflicting digests: %v", digests)
+	}
+
+	// Without the validator key the conflicting vote keeps the original signature and no longer verifies.
+	out, err := msg.apply(mutated[1], nil)
+	if err != nil {
+		t.Fatalf("apply without key: %v", err)
+	}
+	if out.digest == digest && out.author() == address {
+		t.Fatalf("unsigned conflicting vote still verifies")
+	}
+}
+
+func TestProposalBlockCannotChange(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	header, _ := rlp.EncodeToBytes([]interface{}{uint64(1), uint64(2)})
+	block, _ := rlp.EncodeToBytes([]interface{}{rlp.RawValue(header), []interface{}{}})
+	payload, _ := rlp.EncodeToBytes([]interface{}{uint64(3), uint64(0), rlp.RawValue(block)})
+	signed, _ := rlp.EncodeToBytes([]interface{}{rlp.RawValue(payload), make([]byte, crypto.SignatureLength)})
+	data, _ := rlp.EncodeToBytes([]interface{}{rlp.RawValue(signed), []interface{}{}})
+
+	msg, err := decodeWire(codeProposal, data)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if msg.digest != crypto.Keccak256Hash(header) {
+		t.Fatalf("proposal digest is not the header hash")
+	}
+	canonical := canonicalOf(t, msg)
+	canonical.BlockHash = common.HexToHash("0xdead").Hex()
+	if _, err := msg.apply(canonical, key); err == nil {
+		t.Fatalf("expected changing a proposal's block to fail")
+	}
+	if _, err := ParseByzantineAction("double_proposal"); err == nil {
+		t.Fatalf("double_proposal should not be offered")
+	}
+}
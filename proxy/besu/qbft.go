@@ -0,0 +1,332 @@
+package besu
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	besuAdapter "codec/hyperledger/besu/adapter"
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+	"codec/proxy/devp2p"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Protocol is the devp2p capability Besu runs QBFT and IBFT 2.0 on.
+const Protocol = "istanbul"
+
+// Message codes of the consensus messages, relative to the istanbul protocol. QBFT and IBFT 2.0 share them.
+const (
+	codeProposal    = 0x12
+	codePrepare     = 0x13
+	codeCommit      = 0x14
+	codeRoundChange = 0x15
+
+	// messageSpace is the number of codes istanbul/100 reserves.
+	messageSpace = 0x16
+)
+
+var messageTypes = map[uint64]string{
+	codeProposal:    "Proposal",
+	codePrepare:     "Prepare",
+	codeCommit:      "Commit",
+	codeRoundChange: "RoundChange",
+}
+
+// wireMessage is a consensus message as Besu sends it. A message is a signed payload, or for proposals and
+// round changes a list that starts with one, followed by the piggybacked proposals and prepares. Only the
+// payload's height, round, and digest are interpreted; every other field is kept as raw RLP, so a message
+// re-encodes byte for byte unless those change.
+type wireMessage struct {
+	code uint64
+	// outer holds the list items of a proposal or round change; it is nil for prepares and commits.
+	outer     []rlp.RawValue
+	fields    []rlp.RawValue
+	signature []byte
+
+	height *big.Int
+	round  uint64
+	// digest is the block hash a prepare or commit is for, or the hash of a proposed block's header.
+	digest common.Hash
+}
+
+func decodeWire(code uint64, data []byte) (*wireMessage, error) {
+	if _, ok := messageTypes[code]; !ok {
+		return nil, fmt.Errorf("unsupported message code 0x%x", code)
+	}
+	msg := &wireMessage{code: code}
+	signed := data
+	if code == codeProposal || code == codeRoundChange {
+		if err := rlp.DecodeBytes(data, &msg.outer); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", messageTypes[code], err)
+		}
+		if len(msg.outer) == 0 {
+			return nil, fmt.Errorf("decode %s: empty message", messageTypes[code])
+		}
+		signed = msg.outer[0]
+	}
+	var signedData []rlp.RawValue
+	if err := rlp.DecodeBytes(signed, &signedData); err != nil || len(signedData) != 2 {
+		return nil, fmt.Errorf("decode %s signed payload: %v", messageTypes[code], err)
+	}
+	if err := rlp.DecodeBytes(signedData[0], &msg.fields); err != nil || len(msg.fields) < 2 {
+		return nil, fmt.Errorf("decode %s payload: %v", messageTypes[code], err)
+	}
+	if err := rlp.DecodeBytes(signedData[1], &msg.signature); err != nil {
+		return nil, fmt.Errorf("decode %s signature: %w", messageTypes[code], err)
+	}
+	msg.height = new(big.Int)
+	if err := rlp.DecodeBytes(msg.fields[0], msg.height); err != nil {
+		return nil, fmt.Errorf("decode %s height: %w", messageTypes[code], err)
+	}
+	if err := rlp.DecodeBytes(msg.fields[1], &msg.round); err != nil {
+		return nil, fmt.Errorf("decode %s round: %w", messageTypes[code], err)
+	}
+	switch code {
+	case codePrepare, codeCommit:
+		var digest []byte
+		if len(msg.fields) < 3 || rlp.DecodeBytes(msg.fields[2], &digest) != nil {
+			return nil, fmt.Errorf("decode %s digest", messageTypes[code])
+		}
+		msg.digest = common.BytesToHash(digest)
+	case codeProposal:
+		// The proposed block is [header, body...]; its hash is the header's.
+		var block []rlp.RawValue
+		if len(msg.fields) > 2 && rlp.DecodeBytes(msg.fields[2], &block) == nil && len(block) > 0 {
+			msg.digest = crypto.Keccak256Hash(block[0])
+		}
+	}
+	return msg, nil
+}
+
+// signingHash is what Besu signs: the hash of the message code and the payload as one RLP list.
+func (m *wireMessage) signingHash() (common.Hash, error) {
+	payload, err := rlp.EncodeToBytes(m.fields)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	data, err := rlp.EncodeToBytes([]interface{}{m.code, rlp.RawValue(payload)})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(data), nil
+}
+
+// author recovers the address that signed the message; it is empty when the signature does not recover.
+func (m *wireMessage) author() string {
+	hash, err := m.signingHash()
+	if err != nil || len(m.signature) != crypto.SignatureLength {
+		return ""
+	}
+	pub, err := crypto.SigToPub(hash.Bytes(), m.signature)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(crypto.PubkeyToAddress(*pub).Hex()[2:])
+}
+
+func (m *wireMessage) encode() ([]byte, error) {
+	payload, err := rlp.EncodeToBytes(m.fields)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := rlp.EncodeToBytes([]interface{}{rlp.RawValue(payload), m.signature})
+	if err != nil {
+		return nil, err
+	}
+	if m.outer == nil {
+		return signed, nil
+	}
+	outer := append([]rlp.RawValue{signed}, m.outer[1:]...)
+	return rlp.EncodeToBytes(outer)
+}
+
+// toRaw describes the message in the JSON form BesuMapper reads.
+func (m *wireMessage) toRaw(chainID string, received time.Time) (*abstraction.RawConsensusMessage, error) {
+	body := besuAdapter.BesuIBFTMessage{Code: uint8(m.code - codeProposal), Height: m.height, Round: m.round, BlockHash: m.digest, Signature: m.signature}
+	var payload []byte
+	var err error
+	if m.code == codeCommit {
+		payload, err = json.Marshal(besuAdapter.BesuCommitPayload{Body: body, CommitSeal: m.seal()})
+	} else {
+		payload, err = json.Marshal(body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeHyperledger,
+		ChainID:     chainID,
+		MessageType: messageTypes[m.code],
+		Payload:     payload,
+		Encoding:    "json",
+		Timestamp:   received,
+		Metadata: map[string]interface{}{
+			"validator":      m.author(),
+			"consensus_type": "QBFT",
+		},
+	}, nil
+}
+
+// apply rewrites a copy of the message to carry the height, round, digest, and signature of mutated. The
+// digest of a proposal is its block, which the proxy cannot forge, so a proposal must keep its block hash. The
+// message is re-signed with key when its payload changed and the action did not drop the signature.
+func (m *wireMessage) apply(mutated *abstraction.CanonicalMessage, key *ecdsa.PrivateKey) (*wireMessage, error) {
+	if messageTypes[m.code] != besuMessageType(mutated.Type) {
+		return nil, fmt.Errorf("cannot send a %s as a %s", mutated.Type, messageTypes[m.code])
+	}
+	out := *m
+	out.fields = append([]rlp.RawValue(nil), m.fields...)
+
+	var err error
+	if mutated.Height != nil && mutated.Height.Cmp(m.height) != 0 {
+		if out.fields[0], err = rlp.EncodeToBytes(mutated.Height); err != nil {
+			return nil, err
+		}
+	}
+	if mutated.Round != nil && mutated.Round.Uint64() != m.round {
+		if out.fields[1], err = rlp.EncodeToBytes(mutated.Round.Uint64()); err != nil {
+			return nil, err
+		}
+	}
+	digest := common.Hash{}
+	if mutated.BlockHash != "" {
+		digest = common.HexToHash(mutated.BlockHash)
+	}
+	if digest != m.digest {
+		if m.code == codeProposal || m.code == codeRoundChange {
+			return nil, fmt.Errorf("cannot change the block of a %s", messageTypes[m.code])
+		}
+		if out.fields[2], err = rlp.EncodeToBytes(digest.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	// The canonical signature of a commit is its commit seal, which is part of the payload.
+	if m.code == codeCommit && len(m.fields) > 3 {
+		if seal := signatureBytes(mutated.Signature, m.seal()); string(seal) != string(m.seal()) {
+			if out.fields[3], err = rlp.EncodeToBytes(seal); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		out.signature = signatureBytes(mutated.Signature, m.signature)
+	}
+	changed := !equalFields(out.fields, m.fields)
+	if key != nil && changed && len(out.signature) > 0 {
+		hash, err := out.signingHash()
+		if err != nil {
+			return nil, err
+		}
+		if out.signature, err = crypto.Sign(hash.Bytes(), key); err != nil {
+			return nil, fmt.Errorf("failed to sign %s: %w", messageTypes[m.code], err)
+		}
+	}
+	return &out, nil
+}
+
+// seal returns the commit seal of a commit.
+func (m *wireMessage) seal() []byte {
+	var seal []byte
+	if m.code == codeCommit && len(m.fields) > 3 {
+		_ = rlp.DecodeBytes(m.fields[3], &seal)
+	}
+	return seal
+}
+
+// signatureBytes returns the bytes of a canonical signature, which BesuMapper formats as 0x-prefixed hex.
+func signatureBytes(signature string, original []byte) []byte {
+	if signature == fmt.Sprintf("0x%x", original) {
+		return original
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil {
+		// Actions may substitute a readable placeholder; it does not verify either way.
+		return []byte(signature)
+	}
+	return b
+}
+
+func besuMessageType(t abstraction.MsgType) string {
+	switch t {
+	case abstraction.MsgTypeProposal:
+		return "Proposal"
+	case abstraction.MsgTypePrepare:
+		return "Prepare"
+	case abstraction.MsgTypeCommit:
+		return "Commit"
+	case abstraction.MsgTypeRoundChange:
+		return "RoundChange"
+	}
+	return string(t)
+}
+
+func equalFields(a, b []rlp.RawValue) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if string(a[i]) != string(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// codec decodes istanbul messages for devp2p.Interceptor.
+type codec struct {
+	chainID string
+	mapper  *besuAdapter.BesuMapper
+}
+
+func (c *codec) Decode(code uint64, payload []byte, received time.Time) (devp2p.Message, error) {
+	if _, ok := messageTypes[code]; !ok {
+		return nil, nil
+	}
+	wire, err := decodeWire(code, payload)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := wire.toRaw(c.chainID, received)
+	if err != nil {
+		return nil, err
+	}
+	canonical, err := c.mapper.ToCanonical(*raw)
+	if err != nil {
+		return nil, err
+	}
+	return &message{wire: wire, canonical: canonical}, nil
+}
+
+func (c *codec) Actions() *byzantine.Engine {
+	return ByzantineEngine
+}
+
+// message is a decoded istanbul message and its canonical form.
+type message struct {
+	wire      *wireMessage
+	canonical *abstraction.CanonicalMessage
+}
+
+func (m *message) Canonical() *abstraction.CanonicalMessage {
+	return m.canonical
+}
+
+func (m *message) Kind() string {
+	return string(m.canonical.Type)
+}
+
+func (m *message) Encode(mutated *abstraction.CanonicalMessage, key *ecdsa.PrivateKey) ([]byte, error) {
+	out, err := m.wire.apply(mutated, key)
+	if err != nil {
+		return nil, err
+	}
+	return out.encode()
+}
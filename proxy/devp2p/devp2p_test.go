@@ -0,0 +1,189 @@
+package devp2p
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/rlpx"
+)
+
+func TestProtocolOffset(t *testing.T) {
+	peer := []Cap{{"eth", 67}, {"eth", 68}, {"istanbul", 100}, {"snap", 1}}
+	validator := []Cap{{"istanbul", 100}, {"eth", 68}, {"eth", 66}}
+	matched, offset, ok, err := protocolOffset(peer, validator, "istanbul", nil)
+	if err != nil || !ok {
+		t.Fatalf("protocolOffset: ok=%v err=%v", ok, err)
+	}
+	if matched != (Cap{"istanbul", 100}) || offset != baseProtocolLength+17 {
+		t.Fatalf("got %s at %d", matched, offset)
+	}
+
+	if _, _, ok, _ := protocolOffset(peer, []Cap{{"eth", 68}}, "istanbul", nil); ok {
+		t.Fatalf("expected no shared istanbul capability")
+	}
+	if _, _, _, err := protocolOffset([]Cap{{"abc", 1}, {"istanbul", 100}}, []Cap{{"abc", 1}, {"istanbul", 100}}, "istanbul", nil); err == nil {
+		t.Fatalf("expected an error for an unknown capability ahead of istanbul")
+	}
+}
+
+// handlerFunc adapts a function to Handler.
+type handlerFunc func(link *Link, flow Flow, code uint64, payload []byte) error
+
+func (f handlerFunc) Handle(link *Link, flow Flow, code uint64, payload []byte) error {
+	return f(link, flow, code, payload)
+}
+
+func TestProxyRelaysAndIntercepts(t *testing.T) {
+	t.Run("ethereum", func(t *testing.T) { testRelay(t, false) })
+	// Kaia nodes exchange connection types before their hellos.
+	t.Run("conn type", func(t *testing.T) { testRelay(t, true) })
+}
+
+func testRelay(t *testing.T, connType bool) {
+	validatorKey, _ := crypto.GenerateKey()
+	proxyKey, _ := crypto.GenerateKey()
+	peerKey, _ := crypto.GenerateKey()
+	caps := []Cap{{"eth", 68}, {"istanbul", 100}}
+	offset := uint64(baseProtocolLength + 17)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	validatorSent := make(chan error, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			validatorSent <- err
+			return
+		}
+		conn := rlpx.NewConn(c, nil)
+		pub, err := conn.Handshake(validatorKey)
+		if err != nil {
+			validatorSent <- err
+			return
+		}
+		if !pub.Equal(&proxyKey.PublicKey) {
+			t.Errorf("validator sees %x, want the proxy key", crypto.FromECDSAPub(pub))
+		}
+		if connType {
+			if _, data, _, err := conn.Read(); err != nil || string(data) != "\x01" {
+				t.Errorf("validator got connection type %x, %v", data, err)
+			}
+			if _, err := conn.Write(helloMsg, []byte{0x80}); err != nil {
+				validatorSent <- err
+				return
+			}
+		}
+		if _, err := readHello(conn); err != nil {
+			validatorSent <- err
+			return
+		}
+		hello := &Hello{Version: snappyVersion, Name: "validator", Caps: caps, ID: crypto.FromECDSAPub(&validatorKey.PublicKey)[1:]}
+		if err := writeHello(conn, hello); err != nil {
+			validatorSent <- err
+			return
+		}
+		conn.SetSnappy(true)
+		// An eth message is relayed untouched; an istanbul message goes to the handler.
+		if _, err := conn.Write(baseProtocolLength+1, []byte{0xc1, 0x01}); err != nil {
+			validatorSent <- err
+			return
+		}
+		_, err = conn.Write(offset+0x13, []byte{0xc1, 0x02})
+		validatorSent <- err
+		_, _, _, _ = conn.Read()
+	}()
+
+	proxyAddr := freeAddress(t)
+	proxy, err := NewProxy(Config{
+		ListenAddress: proxyAddr,
+		Upstream:      &Node{PublicKey: &validatorKey.PublicKey, Address: ln.Addr().String()},
+		NodeKey:       proxyKey,
+		Protocol:      "istanbul",
+		MessageSpaces: map[Cap]uint64{{"istanbul", 100}: 0x16},
+		ConnType:      connType,
+		Handler: handlerFunc(func(link *Link, flow Flow, code uint64, payload []byte) error {
+			if flow != FlowUpstream || code != 0x13 {
+				t.Errorf("handler got code 0x%x on %s", code, flow)
+			}
+			return link.Send(flow, code, []byte{0xc1, 0x03})
+		}),
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewProxy: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = proxy.Run(ctx) }()
+
+	var c net.Conn
+	for i := 0; i < 50; i++ {
+		if c, err = net.Dial("tcp", proxyAddr); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("dial proxy: %v", err)
+	}
+	defer c.Close()
+	_ = c.SetDeadline(time.Now().Add(5 * time.Second))
+	peer := rlpx.NewConn(c, &proxyKey.PublicKey)
+	if _, err := peer.Handshake(peerKey); err != nil {
+		t.Fatalf("peer handshake: %v", err)
+	}
+	if connType {
+		if _, err := peer.Write(helloMsg, []byte{0x01}); err != nil {
+			t.Fatalf("peer connection type: %v", err)
+		}
+		if _, data, _, err := peer.Read(); err != nil || string(data) != "\x80" {
+			t.Fatalf("peer got connection type %x, %v", data, err)
+		}
+	}
+	if err := writeHello(peer, &Hello{Version: snappyVersion, Name: "peer", Caps: caps, ID: crypto.FromECDSAPub(&peerKey.PublicKey)[1:]}); err != nil {
+		t.Fatalf("peer hello: %v", err)
+	}
+	hello, err := readHello(peer)
+	if err != nil {
+		t.Fatalf("read hello: %v", err)
+	}
+	if hello.Name != "validator" || string(hello.ID) != string(crypto.FromECDSAPub(&proxyKey.PublicKey)[1:]) {
+		t.Fatalf("peer got hello %q with the wrong ID", hello.Name)
+	}
+	peer.SetSnappy(true)
+	if err := <-validatorSent; err != nil {
+		t.Fatalf("validator: %v", err)
+	}
+
+	want := []struct {
+		code    uint64
+		payload byte
+	}{{baseProtocolLength + 1, 0x01}, {offset + 0x13, 0x03}}
+	for _, w := range want {
+		code, data, _, err := peer.Read()
+		if err != nil {
+			t.Fatalf("peer read: %v", err)
+		}
+		if code != w.code || len(data) != 2 || data[1] != w.payload {
+			t.Fatalf("peer got code 0x%x payload %x, want 0x%x", code, data, w.code)
+		}
+	}
+}
+
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
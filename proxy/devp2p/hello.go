@@ -0,0 +1,170 @@
+package devp2p
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/p2p/rlpx"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Base protocol message codes.
+const (
+	helloMsg = 0x00
+	discMsg  = 0x01
+)
+
+const (
+	// baseProtocolLength is the message space reserved for the base protocol; subprotocols are numbered after it.
+	baseProtocolLength = 16
+	// snappyVersion is the first base protocol version that compresses messages after the hello exchange.
+	snappyVersion = 5
+)
+
+// Cap is a subprotocol a node announces in its hello.
+type Cap struct {
+	Name    string
+	Version uint
+}
+
+func (c Cap) String() string {
+	return fmt.Sprintf("%s/%d", c.Name, c.Version)
+}
+
+// KnownMessageSpaces are the message spaces of the common Ethereum subprotocols. Chain packages add the
+// spaces of their own protocols.
+var KnownMessageSpaces = map[Cap]uint64{
+	{Name: "eth", Version: 63}: 17,
+	{Name: "eth", Version: 64}: 17,
+	{Name: "eth", Version: 65}: 17,
+	{Name: "eth", Version: 66}: 17,
+	{Name: "eth", Version: 67}: 17,
+	{Name: "eth", Version: 68}: 17,
+	{Name: "eth", Version: 69}: 18,
+	{Name: "snap", Version: 1}: 8,
+}
+
+// Hello is the base protocol handshake each side sends first.
+type Hello struct {
+	Version uint64
+	Name    string
+	Caps    []Cap
+	// ListenPort is kept raw: Kaia nodes announce a list of ports, one per channel, where Ethereum clients
+	// announce one.
+	ListenPort rlp.RawValue
+	// ID is the sender's uncompressed secp256k1 public key without the 0x04 prefix.
+	ID []byte
+
+	// Rest keeps fields added by later versions.
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+func readHello(conn *rlpx.Conn) (*Hello, error) {
+	code, data, _, err := conn.Read()
+	if err != nil {
+		return nil, err
+	}
+	switch code {
+	case helloMsg:
+	case discMsg:
+		return nil, fmt.Errorf("peer disconnected during the handshake: %s", disconnectReason(data))
+	default:
+		return nil, fmt.Errorf("expected hello, got message 0x%x", code)
+	}
+	var hello Hello
+	if err := rlp.DecodeBytes(data, &hello); err != nil {
+		return nil, fmt.Errorf("invalid hello: %w", err)
+	}
+	return &hello, nil
+}
+
+// relayConnType reads the connection type a Kaia node sends ahead of its hello from one side and writes it to
+// the other, so each side learns the kind of node it talks to.
+func relayConnType(from, to *rlpx.Conn) error {
+	code, data, _, err := from.Read()
+	if err != nil {
+		return err
+	}
+	if code != helloMsg {
+		return fmt.Errorf("expected connection type, got message 0x%x", code)
+	}
+	_, err = to.Write(helloMsg, data)
+	return err
+}
+
+func writeHello(conn *rlpx.Conn, hello *Hello) error {
+	if len(hello.ListenPort) == 0 {
+		// A hello built without a port announces none.
+		out := *hello
+		out.ListenPort = rlp.RawValue{0x80}
+		hello = &out
+	}
+	data, err := rlp.EncodeToBytes(hello)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(helloMsg, data)
+	return err
+}
+
+// disconnectReason formats the reason of a disconnect message, which is a one element list or, from older
+// nodes, a bare integer.
+func disconnectReason(data []byte) string {
+	var reasons []uint
+	if err := rlp.DecodeBytes(data, &reasons); err == nil && len(reasons) > 0 {
+		return fmt.Sprintf("reason %d", reasons[0])
+	}
+	var reason uint
+	if err := rlp.DecodeBytes(data, &reason); err == nil {
+		return fmt.Sprintf("reason %d", reason)
+	}
+	return "no reason"
+}
+
+// protocolOffset finds protocol among the capabilities both hellos announce and returns the version the two
+// sides run and the code its messages start at. Shared capabilities are numbered in name order after the base
+// protocol, with the highest shared version of each, so every capability ahead of protocol needs a known
+// message space. ok is false when the sides do not share protocol.
+func protocolOffset(a, b []Cap, protocol string, spaces map[Cap]uint64) (matched Cap, offset uint64, ok bool, err error) {
+	theirs := make(map[Cap]bool, len(b))
+	for _, c := range b {
+		theirs[c] = true
+	}
+	shared := make(map[string]Cap)
+	for _, c := range a {
+		if !theirs[c] {
+			continue
+		}
+		if prev, seen := shared[c.Name]; !seen || c.Version > prev.Version {
+			shared[c.Name] = c
+		}
+	}
+	names := make([]string, 0, len(shared))
+	for name := range shared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	offset = baseProtocolLength
+	for _, name := range names {
+		c := shared[name]
+		if name == protocol {
+			return c, offset, true, nil
+		}
+		space, known := messageSpace(c, spaces)
+		if !known {
+			return Cap{}, 0, false, fmt.Errorf("unknown message space of %s, which is numbered ahead of %s", c, protocol)
+		}
+		offset += space
+	}
+	return Cap{}, 0, false, nil
+}
+
+// messageSpace looks c up in spaces and then in KnownMessageSpaces.
+func messageSpace(c Cap, spaces map[Cap]uint64) (uint64, bool) {
+	if space, ok := spaces[c]; ok {
+		return space, true
+	}
+	space, ok := KnownMessageSpaces[c]
+	return space, ok
+}
@@ -0,0 +1,233 @@
+package devp2p
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+	"codec/proxy/engine"
+)
+
+// Codec decodes the consensus messages of a chain's subprotocol and encodes mutated copies of them.
+type Codec interface {
+	// Decode returns the consensus message behind a Config.Protocol message, or nil for other messages.
+	Decode(code uint64, payload []byte, received time.Time) (Message, error)
+	// Actions holds the byzantine actions the chain's wire format can carry.
+	Actions() *byzantine.Engine
+}
+
+// Message is a decoded consensus message.
+type Message interface {
+	// Canonical is the message as its chain's mapper reads it.
+	Canonical() *abstraction.CanonicalMessage
+	// Kind names the message for step triggers, metrics, and logs.
+	Kind() string
+	// Encode rewrites the message to carry mutated and returns its payload, re-signed with key when key is
+	// set and the message changed.
+	Encode(mutated *abstraction.CanonicalMessage, key *ecdsa.PrivateKey) ([]byte, error)
+}
+
+// Hooks define behavioural mutations around forwarding.
+type Hooks struct {
+	Delay     time.Duration
+	Drop      bool
+	Duplicate bool
+}
+
+// InterceptorConfig holds the attack an Interceptor carries out.
+type InterceptorConfig struct {
+	Codec Codec
+	// SigningKey, when set, re-signs mutated messages, making them valid messages of that validator.
+	SigningKey *ecdsa.PrivateKey
+
+	Action  byzantine.Action
+	Options byzantine.Options
+
+	// Trigger selects the messages to attack. Its step is matched against Message.Kind and, failing that,
+	// the canonical type.
+	Trigger   engine.Trigger
+	Hooks     Hooks
+	Direction engine.Direction
+
+	// TriggerSeed seeds the generator behind Trigger.Probability; zero picks one from the clock.
+	TriggerSeed int64
+	Logger      *slog.Logger
+}
+
+// Interceptor is a Handler that applies the triggers, hooks, and byzantine actions of the CometBFT proxy to
+// the consensus messages its Codec decodes, and relays everything else unchanged.
+type Interceptor struct {
+	cfg     InterceptorConfig
+	metrics *engine.Metrics
+
+	// sampleMu guards the state behind Trigger.Every and Trigger.Probability.
+	sampleMu sync.Mutex
+	matched  int64
+	rng      *rand.Rand
+}
+
+// NewInterceptor validates the trigger and normalises its step and validators, which are matched as
+// lower-case hex without 0x.
+func NewInterceptor(cfg InterceptorConfig) (*Interceptor, error) {
+	if cfg.Codec == nil {
+		return nil, fmt.Errorf("codec is required")
+	}
+	if cfg.Trigger.Every < 0 {
+		return nil, fmt.Errorf("trigger every must not be negative")
+	}
+	if cfg.Trigger.Probability < 0 || cfg.Trigger.Probability > 1 {
+		return nil, fmt.Errorf("trigger probability must be between 0 and 1")
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+	}
+	if cfg.TriggerSeed == 0 {
+		cfg.TriggerSeed = time.Now().UnixNano()
+	}
+	trigger := cfg.Trigger
+	trigger.Step = strings.ToLower(strings.TrimSpace(trigger.Step))
+	trigger.Validators = nil
+	for _, validator := range cfg.Trigger.Validators {
+		validator = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(validator), "0x"), "0X"))
+		if validator != "" {
+			trigger.Validators = append(trigger.Validators, validator)
+		}
+	}
+	cfg.Trigger = trigger
+	return &Interceptor{cfg: cfg, metrics: engine.NewMetrics(), rng: rand.New(rand.NewSource(cfg.TriggerSeed))}, nil
+}
+
+// TriggerSeed returns the seed in use, which NewInterceptor picks when none was configured.
+func (i *Interceptor) TriggerSeed() int64 {
+	return i.cfg.TriggerSeed
+}
+
+// Metrics returns the interceptor's counters, shared by every peer.
+func (i *Interceptor) Metrics() *engine.Metrics {
+	return i.metrics
+}
+
+// Handle decodes a message and applies the hooks and byzantine action when the direction is mutated and the
+// trigger matches; every other message is relayed as received.
+func (i *Interceptor) Handle(link *Link, flow Flow, code uint64, payload []byte) error {
+	received := time.Now()
+	labels := engine.MessageLabels{Channel: byte(code), Direction: string(flow)}
+	mutate := i.cfg.Direction.ShouldMutateUpstream()
+	if flow == FlowDownstream {
+		mutate = i.cfg.Direction.ShouldMutateDownstream()
+	}
+	if !mutate {
+		return i.forward(link, flow, labels, code, payload)
+	}
+
+	msg, err := i.cfg.Codec.Decode(code, payload, received)
+	if err != nil {
+		return err
+	}
+	if msg == nil {
+		return i.forward(link, flow, labels, code, payload)
+	}
+	canonical := msg.Canonical()
+	kind := msg.Kind()
+	labels.Type = kind
+
+	if !i.matches(msg) || !i.sample() {
+		defer func() { i.metrics.ObserveLatency(labels, time.Since(received)) }()
+		return i.forward(link, flow, labels, code, payload)
+	}
+
+	labels.Action = string(i.cfg.Action)
+	if i.cfg.Hooks.Delay > 0 {
+		i.metrics.Record(engine.EventDelayed, labels, 1)
+		time.Sleep(i.cfg.Hooks.Delay)
+	}
+	if i.cfg.Hooks.Drop {
+		i.metrics.Record(engine.EventDropped, labels, 1)
+		i.cfg.Logger.Info("dropped consensus message", "remote", link.Remote, "flow", flow, "height", canonical.Height, "round", canonical.Round, "type", kind, "validator", canonical.Validator)
+		return nil
+	}
+
+	actions := i.cfg.Codec.Actions()
+	mutated, err := actions.Apply(canonical, i.cfg.Action, i.cfg.Options)
+	if err != nil {
+		return err
+	}
+	frames := make([][]byte, 0, len(mutated))
+	for _, m := range mutated {
+		frame, err := msg.Encode(m, i.cfg.SigningKey)
+		if err != nil {
+			return err
+		}
+		// Payload actions damage the wire message itself, which is what the receiving decoder sees.
+		if frame, err = actions.MutatePayload(i.cfg.Action, frame, i.cfg.Options); err != nil {
+			return err
+		}
+		frames = append(frames, frame)
+	}
+
+	sent, duplicates := 0, 0
+	for _, frame := range frames {
+		if err := link.Send(flow, code, frame); err != nil {
+			return err
+		}
+		sent++
+		if i.cfg.Hooks.Duplicate {
+			if err := link.Send(flow, code, frame); err != nil {
+				return err
+			}
+			sent++
+			duplicates++
+		}
+	}
+	i.metrics.Record(engine.EventMutated, labels, sent)
+	i.metrics.Record(engine.EventDuplicated, labels, duplicates)
+	i.metrics.ObserveLatency(labels, time.Since(received))
+	i.cfg.Logger.Info("mutated consensus message", "remote", link.Remote, "flow", flow, "height", canonical.Height, "round", canonical.Round, "type", kind, "validator", canonical.Validator, "count", sent, "duplicates", duplicates)
+	return nil
+}
+
+// matches applies the trigger. Chains name their messages differently from the canonical types they map to,
+// so the step is matched here against either.
+func (i *Interceptor) matches(msg Message) bool {
+	trigger := i.cfg.Trigger
+	if step := trigger.Step; step != "" {
+		if step != msg.Kind() && step != strings.ToLower(string(msg.Canonical().Type)) {
+			return false
+		}
+		trigger.Step = ""
+	}
+	return trigger.Matches(msg.Canonical())
+}
+
+// forward relays a message unchanged and counts it.
+func (i *Interceptor) forward(link *Link, flow Flow, labels engine.MessageLabels, code uint64, payload []byte) error {
+	if err := link.Send(flow, code, payload); err != nil {
+		return err
+	}
+	i.metrics.Record(engine.EventForwarded, labels, 1)
+	return nil
+}
+
+// sample reports whether a message that met the trigger's conditions fires it.
+func (i *Interceptor) sample() bool {
+	trigger := i.cfg.Trigger
+	if trigger.Every <= 1 && trigger.Probability <= 0 {
+		return true
+	}
+	i.sampleMu.Lock()
+	defer i.sampleMu.Unlock()
+	i.matched++
+	if trigger.Every > 1 && i.matched%int64(trigger.Every) != 0 {
+		return false
+	}
+	return trigger.Probability <= 0 || i.rng.Float64() < trigger.Probability
+}
+
+var _ Handler = (*Interceptor)(nil)
@@ -0,0 +1,348 @@
+// Package devp2p relays RLPx connections between Ethereum-style peers and an upstream validator, handing the
+// messages of one subprotocol to a handler so it can inspect, mutate, or drop them. Every other message,
+// including the base protocol's pings and disconnects, is relayed unchanged. Interceptor is the handler the
+// chain packages use: it runs a chain's Codec through the triggers, hooks, and byzantine actions.
+package devp2p
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/rlpx"
+)
+
+// Flow is the direction a message travels through the proxy.
+type Flow string
+
+const (
+	// FlowUpstream carries messages from the validator to the peers.
+	FlowUpstream Flow = "upstream"
+	// FlowDownstream carries messages from the peers to the validator.
+	FlowDownstream Flow = "downstream"
+)
+
+// Handler receives the messages of Config.Protocol, with codes relative to the protocol, and sends them on, or
+// whatever replaces them, with Link.Send. When it returns an error the original message is relayed.
+type Handler interface {
+	Handle(link *Link, flow Flow, code uint64, payload []byte) error
+}
+
+// Node is an RLPx endpoint: its public key, which the handshake authenticates, and its TCP address.
+type Node struct {
+	PublicKey *ecdsa.PublicKey
+	Address   string
+}
+
+// ParseNode parses an enode URL, enode://<hex public key>@host:port, or its Kaia spelling kni://. Query
+// parameters such as discport are ignored.
+func ParseNode(raw string) (*Node, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "enode" && u.Scheme != "kni") || u.User == nil || u.Host == "" {
+		return nil, fmt.Errorf("expected enode://<public key>@host:port, got %q", raw)
+	}
+	key, err := hex.DecodeString(u.User.Username())
+	if err != nil {
+		return nil, fmt.Errorf("invalid node public key: %w", err)
+	}
+	pub, err := crypto.UnmarshalPubkey(append([]byte{0x04}, key...))
+	if err != nil {
+		return nil, fmt.Errorf("invalid node public key: %w", err)
+	}
+	return &Node{PublicKey: pub, Address: u.Host}, nil
+}
+
+func (n *Node) String() string {
+	return NodeURL(n.PublicKey, n.Address)
+}
+
+// NodeURL formats the enode URL peers use to reach the holder of key at addr.
+func NodeURL(key *ecdsa.PublicKey, addr string) string {
+	return fmt.Sprintf("enode://%x@%s", crypto.FromECDSAPub(key)[1:], addr)
+}
+
+// LoadNodeKey reads a hex encoded secp256k1 private key, as Besu and Kaia store it, with or without 0x.
+func LoadNodeKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimPrefix(strings.TrimSpace(string(data)), "0x")
+	return crypto.HexToECDSA(text)
+}
+
+// Config holds what the relay needs to accept peers and dial the validator.
+type Config struct {
+	ListenAddress string
+	Upstream      *Node
+	// NodeKey is the identity the proxy presents to the validator and, without ListenKey, to the peers.
+	// Validators usually only exchange consensus messages with peers they know as validators, so this is
+	// typically the key of the validator the peers stand for.
+	NodeKey *ecdsa.PrivateKey
+	// ListenKey, when set, is the identity presented to downstream peers, typically the upstream validator's
+	// own key so the peers keep seeing the node they expect.
+	ListenKey   *ecdsa.PrivateKey
+	DialTimeout time.Duration
+
+	// Protocol names the capability whose messages go to Handler, and MessageSpaces gives the message space of
+	// chain-specific capabilities numbered ahead of it.
+	Protocol      string
+	MessageSpaces map[Cap]uint64
+	Handler       Handler
+	// ConnType relays the connection type Kaia nodes exchange between the RLPx handshake and the hello.
+	ConnType bool
+
+	Logger *slog.Logger
+}
+
+// Proxy accepts peers and relays each to its own upstream connection.
+type Proxy struct {
+	cfg Config
+}
+
+// NewProxy validates the configuration.
+func NewProxy(cfg Config) (*Proxy, error) {
+	if cfg.Upstream == nil {
+		return nil, fmt.Errorf("upstream node is required")
+	}
+	if cfg.NodeKey == nil {
+		return nil, fmt.Errorf("node key is required")
+	}
+	if cfg.ListenKey == nil {
+		cfg.ListenKey = cfg.NodeKey
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 5 * time.Second
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+	}
+	return &Proxy{cfg: cfg}, nil
+}
+
+// Run accepts peers until the context is cancelled.
+func (p *Proxy) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", p.cfg.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", p.cfg.ListenAddress, err)
+	}
+	p.cfg.Logger.Info("proxy listening", "enode", NodeURL(&p.cfg.ListenKey.PublicKey, ln.Addr().String()), "upstream", p.cfg.Upstream)
+
+	var wg sync.WaitGroup
+	defer func() {
+		_ = ln.Close()
+		wg.Wait()
+	}()
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				p.cfg.Logger.Warn("temporary accept error", "err", err)
+				continue
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func(c net.Conn) {
+			defer wg.Done()
+			if err := p.serve(ctx, c); err != nil && ctx.Err() == nil {
+				p.cfg.Logger.Error("connection handler exited", "err", err, "remote", c.RemoteAddr().String())
+			}
+		}(conn)
+	}
+}
+
+// serve handshakes with a peer and the validator, relays each side's hello, and with ConnType first its
+// connection type, to the other so both negotiate the same capabilities, and then relays messages until either
+// side goes away.
+func (p *Proxy) serve(ctx context.Context, downstreamConn net.Conn) error {
+	defer downstreamConn.Close()
+	remote := downstreamConn.RemoteAddr().String()
+	logger := p.cfg.Logger.With("remote", remote)
+	logger.Info("accepted peer")
+
+	deadline := time.Now().Add(p.cfg.DialTimeout)
+	_ = downstreamConn.SetDeadline(deadline)
+	down := rlpx.NewConn(downstreamConn, nil)
+	if _, err := down.Handshake(p.cfg.ListenKey); err != nil {
+		return fmt.Errorf("handshake with downstream peer failed: %w", err)
+	}
+
+	upstreamConn, err := net.DialTimeout("tcp", p.cfg.Upstream.Address, p.cfg.DialTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to dial upstream %s: %w", p.cfg.Upstream.Address, err)
+	}
+	defer upstreamConn.Close()
+	_ = upstreamConn.SetDeadline(deadline)
+	up := rlpx.NewConn(upstreamConn, p.cfg.Upstream.PublicKey)
+	if _, err := up.Handshake(p.cfg.NodeKey); err != nil {
+		return fmt.Errorf("handshake with upstream failed: %w", err)
+	}
+	if p.cfg.ConnType {
+		if err := relayConnType(down, up); err != nil {
+			return fmt.Errorf("downstream connection type: %w", err)
+		}
+		if err := relayConnType(up, down); err != nil {
+			return fmt.Errorf("upstream connection type: %w", err)
+		}
+	}
+	peerHello, err := readHello(down)
+	if err != nil {
+		return fmt.Errorf("downstream hello: %w", err)
+	}
+	if err := writeHello(up, withID(peerHello, p.cfg.NodeKey)); err != nil {
+		return fmt.Errorf("upstream hello: %w", err)
+	}
+	validatorHello, err := readHello(up)
+	if err != nil {
+		return fmt.Errorf("upstream hello: %w", err)
+	}
+	if err := writeHello(down, withID(validatorHello, p.cfg.ListenKey)); err != nil {
+		return fmt.Errorf("downstream hello: %w", err)
+	}
+	_ = downstreamConn.SetDeadline(time.Time{})
+	_ = upstreamConn.SetDeadline(time.Time{})
+
+	snappy := peerHello.Version >= snappyVersion && validatorHello.Version >= snappyVersion
+	down.SetSnappy(snappy)
+	up.SetSnappy(snappy)
+
+	link := &Link{Remote: remote, down: down, up: up, logger: logger}
+	if p.cfg.Protocol != "" {
+		var ok bool
+		var offset uint64
+		link.Cap, offset, ok, err = protocolOffset(peerHello.Caps, validatorHello.Caps, p.cfg.Protocol, p.cfg.MessageSpaces)
+		space, known := messageSpace(link.Cap, p.cfg.MessageSpaces)
+		switch {
+		case err != nil:
+			logger.Warn("relaying without interception", "err", err)
+		case !ok:
+			logger.Warn("peer and validator share no "+p.cfg.Protocol+" capability; relaying without interception", "peer_caps", peerHello.Caps, "validator_caps", validatorHello.Caps)
+		case !known:
+			logger.Warn("unknown message space; relaying without interception", "protocol", link.Cap)
+		default:
+			link.offset, link.space = offset, space
+			logger.Info("peer session established", "protocol", link.Cap, "offset", offset, "peer", peerHello.Name, "validator", validatorHello.Name)
+		}
+	}
+
+	sessionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-sessionCtx.Done()
+		_ = downstreamConn.Close()
+		_ = upstreamConn.Close()
+	}()
+
+	errCh := make(chan error, 2)
+	go func() { errCh <- p.pipe(link, down, FlowDownstream) }()
+	go func() { errCh <- p.pipe(link, up, FlowUpstream) }()
+	err = <-errCh
+	cancel()
+	<-errCh
+	if errors.Is(err, errDisconnected) || errors.Is(err, net.ErrClosed) || ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+var errDisconnected = errors.New("disconnected")
+
+// pipe relays the messages read from conn along flow.
+func (p *Proxy) pipe(link *Link, conn *rlpx.Conn, flow Flow) error {
+	for {
+		code, data, _, err := conn.Read()
+		if err != nil {
+			return err
+		}
+		// Read reuses its buffer, and handlers may hold a message back.
+		data = append([]byte(nil), data...)
+		if code == discMsg {
+			link.logger.Info("peer disconnected", "flow", flow, "reason", disconnectReason(data))
+			_ = link.write(flow, code, data)
+			return errDisconnected
+		}
+		if p.cfg.Handler != nil && link.intercepts(code) {
+			if err := p.cfg.Handler.Handle(link, flow, code-link.offset, data); err != nil {
+				link.logger.Warn("failed to process message", "flow", flow, "code", fmt.Sprintf("0x%x", code-link.offset), "err", err)
+				if err := link.write(flow, code, data); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := link.write(flow, code, data); err != nil {
+			return err
+		}
+	}
+}
+
+// Link is a relayed peer: the RLPx connection to the peer and the one to the validator.
+type Link struct {
+	Remote string
+	// Cap is the version of Config.Protocol both sides run; it is zero when they do not share it.
+	Cap Cap
+
+	// offset and space locate Config.Protocol's messages; offset is 0 when they are not intercepted.
+	offset uint64
+	space  uint64
+	down   *rlpx.Conn
+	up     *rlpx.Conn
+	downMu sync.Mutex
+	upMu   sync.Mutex
+	logger *slog.Logger
+}
+
+// Send writes a Config.Protocol message along flow: toward the peer for FlowUpstream, toward the validator for
+// FlowDownstream.
+func (l *Link) Send(flow Flow, code uint64, payload []byte) error {
+	if l.offset == 0 {
+		return fmt.Errorf("no %s session with %s", l.Cap.Name, l.Remote)
+	}
+	return l.write(flow, l.offset+code, payload)
+}
+
+func (l *Link) intercepts(code uint64) bool {
+	return l.offset != 0 && code >= l.offset && code < l.offset+l.space
+}
+
+func (l *Link) write(flow Flow, code uint64, payload []byte) error {
+	conn, mu := l.up, &l.upMu
+	if flow == FlowUpstream {
+		conn, mu = l.down, &l.downMu
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	_, err := conn.Write(code, payload)
+	return err
+}
+
+// withID returns a copy of hello that names key as the sender, which the receiving side checks against the
+// key the RLPx handshake authenticated.
+func withID(hello *Hello, key *ecdsa.PrivateKey) *Hello {
+	out := *hello
+	out.ID = crypto.FromECDSAPub(&key.PublicKey)[1:]
+	return &out
+}
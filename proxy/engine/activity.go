@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// activityLogSize is how many consensus messages Activity keeps.
+const activityLogSize = 256
+
+// ActivityEntry is one consensus message the proxy handled. Event is EventForwarded, EventMutated, or
+// EventDropped; Action is the byzantine action for messages the trigger selected.
+type ActivityEntry struct {
+	Time      time.Time `json:"time"`
+	Channel   byte      `json:"channel"`
+	Direction string    `json:"direction"`
+	Type      string    `json:"type"`
+	Height    int64     `json:"height"`
+	Round     int64     `json:"round"`
+	Validator string    `json:"validator,omitempty"`
+	Event     string    `json:"event"`
+	Action    string    `json:"action,omitempty"`
+}
+
+// Activity is the recent consensus traffic through the proxy: the highest height and round seen in either
+// direction, and the last messages handled, oldest first.
+type Activity struct {
+	Height int64           `json:"height"`
+	Round  int64           `json:"round"`
+	Recent []ActivityEntry `json:"recent"`
+}
+
+// activityLog keeps the last messages in a ring shared by every session.
+type activityLog struct {
+	mu            sync.Mutex
+	entries       []ActivityEntry
+	next          int
+	full          bool
+	height, round int64
+}
+
+func newActivityLog(size int) *activityLog {
+	return &activityLog{entries: make([]ActivityEntry, size)}
+}
+
+// observe appends entry, dropping the oldest once the ring is full.
+func (l *activityLog) observe(entry ActivityEntry) {
+	if l == nil || len(l.entries) == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+	if entry.Height > l.height || (entry.Height == l.height && entry.Round > l.round) {
+		l.height, l.round = entry.Height, entry.Round
+	}
+}
+
+func (l *activityLog) snapshot() Activity {
+	if l == nil {
+		return Activity{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	activity := Activity{Height: l.height, Round: l.round}
+	if l.full {
+		activity.Recent = append(activity.Recent, l.entries[l.next:]...)
+	}
+	activity.Recent = append(activity.Recent, l.entries[:l.next]...)
+	return activity
+}
+
+// Activity returns the recent consensus traffic through the proxy.
+func (e *Engine) Activity() Activity {
+	return e.cfg.activity.snapshot()
+}
+
+// ChannelCounts returns the message counts per channel and event, summed over directions, types, and actions.
+func (m *Metrics) ChannelCounts() map[byte]map[string]int64 {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make(map[byte]map[string]int64, len(m.channels))
+	for ch, events := range m.channels {
+		counts[ch] = make(map[string]int64, len(events))
+		for event, n := range events {
+			counts[ch][event] = n
+		}
+	}
+	return counts
+}
+
+// ChannelName names a p2p channel the proxy relays for display, or returns its hex ID.
+func ChannelName(chID byte) string {
+	switch chID {
+	case stateChannelID:
+		return "consensus/state"
+	case dataChannelID:
+		return "consensus/data"
+	case voteChannelID:
+		return "consensus/vote"
+	case voteSetBitsChannelID:
+		return "consensus/vote_set_bits"
+	case mempoolChannelID:
+		return "mempool"
+	case evidenceChannelID:
+		return "evidence"
+	case blocksyncChannelID:
+		return "blocksync"
+	case snapshotChannelID:
+		return "snapshot"
+	case chunkChannelID:
+		return "snapshot/chunk"
+	default:
+		return fmt.Sprintf("0x%02X", chID)
+	}
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// channelNames maps the names accepted by ParseChannelBlocks to the p2p channels they cover.
+var channelNames = map[string][]byte{
+	"consensus": {stateChannelID, dataChannelID, voteChannelID, voteSetBitsChannelID},
+	"mempool":   {mempoolChannelID},
+	"evidence":  {evidenceChannelID},
+	"blocksync": {blocksyncChannelID},
+	"snapshot":  {snapshotChannelID, chunkChannelID},
+}
+
+// ChannelBlock blackholes a p2p channel: frames on it are dropped silently instead of relayed, in the flows
+// Direction selects (upstream: from the validator to the peers, downstream: from the peers to the validator).
+// Every channel without a block is relayed unmodified apart from consensus attacks.
+type ChannelBlock struct {
+	Channel   byte
+	Direction Direction
+}
+
+// ParseChannelBlocks parses a comma separated spec such as "evidence,mempool=downstream,0x40". Channels are
+// given by name (consensus, mempool, evidence, blocksync, snapshot) or as a hex ID, optionally followed by
+// =upstream, =downstream, or =both (the default).
+func ParseChannelBlocks(spec string) ([]ChannelBlock, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	known := make(map[byte]bool)
+	for _, desc := range defaultDescriptors() {
+		known[desc.ID] = true
+	}
+	var blocks []ChannelBlock
+	for _, entry := range strings.Split(spec, ",") {
+		name, value, hasDirection := strings.Cut(strings.TrimSpace(entry), "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		direction := DirectionBoth
+		if hasDirection {
+			var err error
+			if direction, err = ParseDirection(value); err != nil {
+				return nil, fmt.Errorf("invalid channel block %q: %w", entry, err)
+			}
+		}
+		channels, ok := channelNames[name]
+		if !ok {
+			id, err := strconv.ParseUint(strings.TrimPrefix(name, "0x"), 16, 8)
+			if err != nil || !known[byte(id)] {
+				return nil, fmt.Errorf("unknown channel %q (expected %s or a hex channel ID the proxy relays)", name, strings.Join(channelNameList(), ", "))
+			}
+			channels = []byte{byte(id)}
+		}
+		for _, id := range channels {
+			blocks = append(blocks, ChannelBlock{Channel: id, Direction: direction})
+		}
+	}
+	return blocks, nil
+}
+
+func channelNameList() []string {
+	names := make([]string, 0, len(channelNames))
+	for name := range channelNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// blocksChannel reports whether frames on chID travelling in flow are blackholed.
+func blocksChannel(blocks []ChannelBlock, chID byte, flow flowDirection) bool {
+	for _, block := range blocks {
+		if block.Channel != chID {
+			continue
+		}
+		if flow == directionUpstream && block.Direction.ShouldMutateUpstream() {
+			return true
+		}
+		if flow == directionDownstream && block.Direction.ShouldMutateDownstream() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+// VictimValidator names the proxied validator as the victim of a clock skew.
+const VictimValidator = "validator"
+
+// VictimClockSkew emulates a node whose clock is off by shifting the timestamp of every proposal and vote
+// delivered to it. Unlike timestamp_skew, which forges the attacker's own messages, the skew applies to all
+// consensus traffic the victim receives, whoever signed it, and regardless of the trigger.
+type VictimClockSkew struct {
+	// Victim is VictimValidator for the proxied validator, or a peer's node ID, address, or host as accepted by
+	// TargetPeers.
+	Victim string
+	// Offset is added to timestamps; a positive offset makes the victim's peers appear to run ahead of it,
+	// which is what the victim sees when its own clock lags.
+	Offset time.Duration
+}
+
+// ParseVictimClockSkews parses a comma separated spec such as "validator=-2s,<node-id>=+500ms".
+func ParseVictimClockSkews(spec string) ([]VictimClockSkew, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	var skews []VictimClockSkew
+	for _, entry := range strings.Split(spec, ",") {
+		victim, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		victim = strings.TrimSpace(victim)
+		if !ok || victim == "" {
+			return nil, fmt.Errorf("invalid victim clock skew %q (expected victim=offset)", entry)
+		}
+		offset, err := time.ParseDuration(strings.TrimPrefix(strings.TrimSpace(value), "+"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid clock offset for %q: %w", victim, err)
+		}
+		skews = append(skews, VictimClockSkew{Victim: victim, Offset: offset})
+	}
+	return skews, nil
+}
+
+// victimOffsets returns the offsets for traffic delivered to the proxied validator and to the peer with the
+// given identities. Offsets for the same victim add up.
+func victimOffsets(skews []VictimClockSkew, peer []string) (toValidator, toPeer time.Duration) {
+	for _, skew := range skews {
+		victim := strings.TrimSpace(skew.Victim)
+		if strings.EqualFold(victim, VictimValidator) {
+			toValidator += skew.Offset
+			continue
+		}
+		for _, id := range peer {
+			if id != "" && strings.EqualFold(victim, id) {
+				toPeer += skew.Offset
+				break
+			}
+		}
+	}
+	return toValidator, toPeer
+}
+
+// skewTimestamp shifts a proposal or vote timestamp by offset and reports whether the message had one. Votes
+// signed by the configured privval key are re-signed; everything else keeps its original signature, which no
+// longer covers the shifted timestamp.
+func (s *session) skewTimestamp(msg *abstraction.CanonicalMessage, offset time.Duration) bool {
+	if msg.Timestamp.IsZero() {
+		return false
+	}
+	msg.Timestamp = msg.Timestamp.Add(offset)
+
+	signer := s.cfg.Options.Signer
+	if signer == nil {
+		return true
+	}
+	if own, ok := signer.(interface{ Address() string }); ok && msg.Validator != "" && strings.EqualFold(own.Address(), msg.Validator) {
+		if err := signer.Sign(msg); err != nil {
+			s.logger.Warn("failed to re-sign skewed message", "type", msg.Type, "err", err)
+		}
+	}
+	return true
+}
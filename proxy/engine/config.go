@@ -5,10 +5,12 @@ import (
 	"log/slog"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	cometbftAdapter "codec/cometbft/adapter"
+	"codec/experiment"
 	"codec/message/abstraction"
 	"github.com/cometbft/cometbft/p2p"
 )
@@ -39,6 +41,18 @@ func ParseDirection(value string) (Direction, error) {
 	}
 }
 
+// String returns the name ParseDirection accepts.
+func (d Direction) String() string {
+	switch d {
+	case DirectionDownstream:
+		return "downstream"
+	case DirectionBoth:
+		return "both"
+	default:
+		return "upstream"
+	}
+}
+
 // ShouldMutateUpstream reports if messages from the upstream validator should be mutated.
 func (d Direction) ShouldMutateUpstream() bool {
 	return d == DirectionUpstream || d == DirectionBoth
@@ -51,37 +65,143 @@ func (d Direction) ShouldMutateDownstream() bool {
 
 // Trigger describes when the engine should mutate traffic.
 type Trigger struct {
-	Height *int64
-	Round  *int64
-	Step   string
+	Height *int64 `json:"height"`
+	// MinHeight and MaxHeight bound the height inclusively, for triggers that span several heights.
+	MinHeight *int64 `json:"min_height,omitempty"`
+	MaxHeight *int64 `json:"max_height,omitempty"`
+	Round     *int64 `json:"round"`
+	Step      string `json:"step"`
+	// Validators restricts the trigger to messages signed by the listed validators, each given as a hex address
+	// (matched against the vote signer or the proposer) or as a decimal validator index.
+	Validators []string `json:"validators,omitempty"`
+	// Every fires the trigger on every Nth message that meets the conditions above; 0 and 1 fire on all.
+	Every int `json:"every,omitempty"`
+	// Probability fires the trigger on a message that meets the conditions, and is picked by Every, with the
+	// given chance; 0 means always. Draws come from the generator seeded with Config.TriggerSeed.
+	Probability float64 `json:"probability,omitempty"`
 }
 
-// Matches reports whether the canonical message satisfies the trigger.
+// validate checks the sampling settings.
+func (t Trigger) validate() error {
+	if t.Every < 0 {
+		return fmt.Errorf("trigger every must not be negative")
+	}
+	if t.Probability < 0 || t.Probability > 1 {
+		return fmt.Errorf("trigger probability must be between 0 and 1")
+	}
+	return nil
+}
+
+// Matches reports whether the canonical message satisfies the trigger's conditions. Every and Probability
+// are applied afterwards by the engine, which keeps the count and the random generator they need.
 func (t Trigger) Matches(msg *abstraction.CanonicalMessage) bool {
 	if msg == nil {
 		return false
 	}
-	if t.Height != nil {
-		if msg.Height == nil || msg.Height.Int64() != *t.Height {
+	if t.Height != nil || t.MinHeight != nil || t.MaxHeight != nil {
+		height, ok := msg.HeightInt64()
+		if !ok {
+			return false
+		}
+		if (t.Height != nil && height != *t.Height) || (t.MinHeight != nil && height < *t.MinHeight) || (t.MaxHeight != nil && height > *t.MaxHeight) {
 			return false
 		}
 	}
 	if t.Round != nil {
-		if msg.Round == nil || msg.Round.Int64() != *t.Round {
+		if round, ok := msg.RoundInt64(); !ok || round != *t.Round {
 			return false
 		}
 	}
-	if t.Step != "" && strings.ToLower(string(msg.Type)) != t.Step {
+	if t.Step != "" && messageKind(msg) != t.Step {
+		return false
+	}
+	if len(t.Validators) > 0 && !matchesValidator(t.Validators, msg) {
 		return false
 	}
 	return true
 }
 
+// messageKind names the message for step triggers, metrics, and logs: the canonical type, or for the state and
+// data channel messages that only borrow one, the CometBFT type in snake case (block_part, has_vote, ...).
+func messageKind(msg *abstraction.CanonicalMessage) string {
+	if messageType, ok := cometbftAdapter.MessageTypeFromExtensions(msg); ok {
+		if kind, ok := stateMessageKinds[messageType]; ok {
+			return kind
+		}
+	}
+	return strings.ToLower(string(msg.Type))
+}
+
+var stateMessageKinds = map[string]string{
+	"NewRoundStep": "new_round_step",
+	"BlockPart":    "block_part",
+	"HasVote":      "has_vote",
+	"VoteSetMaj23": "vote_set_maj23",
+	"VoteSetBits":  "vote_set_bits",
+}
+
+// matchesValidator reports whether msg was signed by one of validators. Proposals carry no validator index
+// and CometBFT proposals on the wire carry no proposer address, so those only match when the address is known.
+func matchesValidator(validators []string, msg *abstraction.CanonicalMessage) bool {
+	index, hasIndex := cometbftAdapter.ValidatorIndexFromExtensions(msg)
+	for _, validator := range validators {
+		if n, err := strconv.ParseInt(validator, 10, 32); err == nil && len(validator) < 40 {
+			if hasIndex && index == int32(n) {
+				return true
+			}
+			continue
+		}
+		if (msg.Validator != "" && strings.EqualFold(validator, msg.Validator)) || (msg.Proposer != "" && strings.EqualFold(validator, msg.Proposer)) {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the trigger for logs, with "*" for conditions that match anything.
+func (t Trigger) String() string {
+	height, round, step := "*", "*", t.Step
+	if t.Height != nil {
+		height = strconv.FormatInt(*t.Height, 10)
+	} else if t.MinHeight != nil || t.MaxHeight != nil {
+		height = optionalInt(t.MinHeight) + ".." + optionalInt(t.MaxHeight)
+	}
+	if t.Round != nil {
+		round = strconv.FormatInt(*t.Round, 10)
+	}
+	if step == "" {
+		step = "*"
+	}
+	desc := fmt.Sprintf("height=%s round=%s step=%s", height, round, step)
+	if len(t.Validators) > 0 {
+		desc += " validators=" + strings.Join(t.Validators, ",")
+	}
+	if t.Every > 1 {
+		desc += fmt.Sprintf(" every=%d", t.Every)
+	}
+	if t.Probability > 0 {
+		desc += " probability=" + strconv.FormatFloat(t.Probability, 'g', -1, 64)
+	}
+	return desc
+}
+
 // Hooks define behavioural mutations around forwarding.
 type Hooks struct {
 	Delay     time.Duration
 	Drop      bool
 	Duplicate bool
+	// ValidatorDelays holds triggered votes back per signer without blocking other traffic.
+	ValidatorDelays ValidatorDelayPolicy
+	// SplitPeers sends each message produced by the byzantine action to a different peer session instead of
+	// sending all of them to every peer; session i receives message i modulo the number produced.
+	SplitPeers bool
+	// VictimClockSkews shift the timestamps of consensus messages delivered to victim nodes, emulating victims
+	// whose clocks are off.
+	VictimClockSkews []VictimClockSkew
+	// Partition, when set, cuts the link for a time window or height range and then heals it.
+	Partition *Partition
+	// Shaping adds latency, jitter, and bandwidth limits to every forwarded frame, on top of Delay.
+	Shaping LinkShaping
 }
 
 // Config holds the runtime configuration for the proxy engine.
@@ -90,6 +210,10 @@ type Config struct {
 	ListenAddress   string
 	UpstreamNetwork string
 	UpstreamAddress string
+	// Failover lists further validators to dial, in order, when the upstream does not answer.
+	Failover []UpstreamTarget
+	// Reconnect, when set, redials a lost upstream instead of disconnecting the downstream peers.
+	Reconnect *Reconnect
 
 	ChainID string
 
@@ -104,22 +228,69 @@ type Config struct {
 
 	DialTimeout time.Duration
 
+	// Multiplex shares one upstream connection among all downstream peers instead of dialing the validator
+	// once per peer, which a validator rejects as duplicate connections from the proxy's node ID.
+	Multiplex bool
+
 	Logger *slog.Logger
+
+	// Resources, when set, receives per-direction usage for the experiment manifest.
+	Resources *experiment.ResourceAccountant
+
+	// TriggerSeed seeds the generator behind Trigger.Probability, so a run over the same traffic fires on the
+	// same messages. NewConfig picks one from the clock when none is given.
+	TriggerSeed int64
+
+	// Scenario, when set, replaces Action, Trigger, and the delay, drop, and duplicate hooks with a sequence of
+	// phases the engine steps through while it runs.
+	Scenario *Scenario
+
+	// Recorder, when set, receives every consensus message the proxy intercepts and every message it sends in
+	// place of one.
+	Recorder *Recorder
+
+	// BlockedChannels are blackholed instead of relayed, for example the evidence channel to censor evidence.
+	BlockedChannels []ChannelBlock
+
+	// Replay, when set, re-injects a recorded capture once the first peer connects.
+	Replay *ReplayOptions
+
+	// partition tracks Hooks.Partition; it is nil without one.
+	partition *partitionState
+	// upstreams dials UpstreamAddress and the failover targets.
+	upstreams *upstreamPool
+	// live holds the Attack sessions apply, seeded from Action, Trigger, and Hooks and replaced by SetAttack.
+	live *attackState
+	// runner advances Scenario; it is nil without one.
+	runner *scenarioRunner
+	// activity keeps the recent consensus messages for Engine.Activity.
+	activity *activityLog
+	// pool recycles the canonical and raw messages of consensus traffic that is forwarded without an attack.
+	pool *abstraction.Pool
 }
 
 // ConfigOptions contains inputs to build a Config.
 type ConfigOptions struct {
-	ListenAddress  string
-	UpstreamTarget string
-	ChainID        string
-	NodeKey        *p2p.NodeKey
-	Action         cometbftAdapter.ByzantineAction
-	Options        cometbftAdapter.ByzantineOptions
-	Trigger        Trigger
-	Hooks          Hooks
-	Direction      Direction
-	DialTimeout    time.Duration
-	Logger         *slog.Logger
+	ListenAddress   string
+	UpstreamTarget  string
+	FailoverTargets []string
+	Reconnect       *Reconnect
+	ChainID         string
+	NodeKey         *p2p.NodeKey
+	Action          cometbftAdapter.ByzantineAction
+	Options         cometbftAdapter.ByzantineOptions
+	Trigger         Trigger
+	Hooks           Hooks
+	Direction       Direction
+	DialTimeout     time.Duration
+	Multiplex       bool
+	Logger          *slog.Logger
+	Resources       *experiment.ResourceAccountant
+	TriggerSeed     int64
+	Scenario        *Scenario
+	Recorder        *Recorder
+	Replay          *ReplayOptions
+	BlockedChannels []ChannelBlock
 }
 
 // NewConfig validates and normalises proxy options.
@@ -132,6 +303,19 @@ func NewConfig(opts ConfigOptions) (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid upstream address: %w", err)
 	}
+	var failover []UpstreamTarget
+	for _, raw := range opts.FailoverTargets {
+		network, addr, err := parseNetworkAddress(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid failover address %q: %w", raw, err)
+		}
+		failover = append(failover, UpstreamTarget{Network: network, Address: addr})
+	}
+	if opts.Reconnect != nil {
+		if err := opts.Reconnect.validate(); err != nil {
+			return nil, err
+		}
+	}
 	if opts.NodeKey == nil {
 		return nil, fmt.Errorf("node key is required")
 	}
@@ -143,14 +327,26 @@ func NewConfig(opts ConfigOptions) (*Config, error) {
 		logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
 	}
 
-	trigger := Trigger{Step: strings.ToLower(strings.TrimSpace(opts.Trigger.Step))}
-	if opts.Trigger.Height != nil {
-		h := *opts.Trigger.Height
-		trigger.Height = &h
+	trigger := normalizeTrigger(opts.Trigger)
+	if err := trigger.validate(); err != nil {
+		return nil, err
 	}
-	if opts.Trigger.Round != nil {
-		r := *opts.Trigger.Round
-		trigger.Round = &r
+	if opts.Replay != nil {
+		if err := opts.Replay.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Hooks.Partition != nil {
+		if err := opts.Hooks.Partition.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := opts.Hooks.Shaping.validate(); err != nil {
+		return nil, err
+	}
+	seed := opts.TriggerSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
 	}
 
 	cfg := &Config{
@@ -158,6 +354,8 @@ func NewConfig(opts ConfigOptions) (*Config, error) {
 		ListenAddress:   listenAddr,
 		UpstreamNetwork: upstreamNetwork,
 		UpstreamAddress: upstreamAddr,
+		Failover:        failover,
+		Reconnect:       opts.Reconnect,
 		ChainID:         strings.TrimSpace(opts.ChainID),
 		NodeKey:         opts.NodeKey,
 		Action:          opts.Action,
@@ -166,16 +364,60 @@ func NewConfig(opts ConfigOptions) (*Config, error) {
 		Hooks:           opts.Hooks,
 		Direction:       opts.Direction,
 		DialTimeout:     opts.DialTimeout,
+		Multiplex:       opts.Multiplex,
 		Logger:          logger,
+		Resources:       opts.Resources,
+		TriggerSeed:     seed,
+		Scenario:        opts.Scenario,
+		Recorder:        opts.Recorder,
+		Replay:          opts.Replay,
+		BlockedChannels: opts.BlockedChannels,
 	}
 
 	if cfg.DialTimeout <= 0 {
 		cfg.DialTimeout = 5 * time.Second
 	}
+	cfg.live = newAttackState(cfg.attack(), seed)
 
 	return cfg, nil
 }
 
+func optionalInt(v *int64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(*v, 10)
+}
+
+// normalizeTrigger copies the heights, round, and validators so the trigger shares no state with the
+// caller's, lower-cases the step to match canonical message types, and drops "0x" from validator addresses.
+func normalizeTrigger(t Trigger) Trigger {
+	trigger := Trigger{Step: strings.ToLower(strings.TrimSpace(t.Step)), Every: t.Every, Probability: t.Probability}
+	for _, validator := range t.Validators {
+		validator = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(validator), "0x"), "0X")
+		if validator != "" {
+			trigger.Validators = append(trigger.Validators, validator)
+		}
+	}
+	if t.Height != nil {
+		h := *t.Height
+		trigger.Height = &h
+	}
+	if t.MinHeight != nil {
+		h := *t.MinHeight
+		trigger.MinHeight = &h
+	}
+	if t.MaxHeight != nil {
+		h := *t.MaxHeight
+		trigger.MaxHeight = &h
+	}
+	if t.Round != nil {
+		r := *t.Round
+		trigger.Round = &r
+	}
+	return trigger
+}
+
 func parseNetworkAddress(raw string) (string, string, error) {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
@@ -13,8 +13,9 @@ import (
 	cometbftAdapter "codec/cometbft/adapter"
 	"codec/message/abstraction"
 	consensuspb "github.com/cometbft/cometbft/proto/tendermint/consensus"
+	cmtcrypto "github.com/cometbft/cometbft/proto/tendermint/crypto"
+	cmtbits "github.com/cometbft/cometbft/proto/tendermint/libs/bits"
 	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
-	cmttypes "github.com/cometbft/cometbft/types"
 	gogoproto "github.com/cosmos/gogoproto/proto"
 )
 
@@ -54,7 +55,8 @@ func decodeConsensusMessage(payload []byte) (*consensuspb.Message, error) {
 	return &msg, nil
 }
 
-func canonicalFromConsensus(mapper *cometbftAdapter.CometBFTMapper, chainID string, msg *consensuspb.Message) (*abstraction.CanonicalMessage, error) {
+// canonicalFromConsensus decodes msg into a canonical message taken from pool, which may be nil.
+func canonicalFromConsensus(pool *abstraction.Pool, mapper *cometbftAdapter.CometBFTMapper, chainID string, msg *consensuspb.Message) (*abstraction.CanonicalMessage, error) {
 	adapterMsg, messageType, err := adapterMessageFromConsensus(msg)
 	if err != nil {
 		return nil, err
@@ -71,7 +73,12 @@ func canonicalFromConsensus(mapper *cometbftAdapter.CometBFTMapper, chainID stri
 		Encoding:    "json",
 		Timestamp:   adapterMsg.Timestamp,
 	}
-	return mapper.ToCanonical(raw)
+	canonical := pool.GetCanonical()
+	if err := mapper.ToCanonicalInto(raw, canonical); err != nil {
+		pool.PutCanonical(canonical)
+		return nil, err
+	}
+	return canonical, nil
 }
 
 func adapterMessageFromConsensus(msg *consensuspb.Message) (*cometbftAdapter.CometBFTConsensusMessage, string, error) {
@@ -80,22 +87,32 @@ func adapterMessageFromConsensus(msg *consensuspb.Message) (*cometbftAdapter.Com
 		return proposalToAdapter(payload.Proposal)
 	case *consensuspb.Message_Vote:
 		return voteToAdapter(payload.Vote)
+	case *consensuspb.Message_NewRoundStep:
+		return newRoundStepToAdapter(payload.NewRoundStep)
+	case *consensuspb.Message_BlockPart:
+		return blockPartToAdapter(payload.BlockPart)
+	case *consensuspb.Message_HasVote:
+		return hasVoteToAdapter(payload.HasVote)
+	case *consensuspb.Message_VoteSetMaj23:
+		return voteSetMaj23ToAdapter(payload.VoteSetMaj23)
+	case *consensuspb.Message_VoteSetBits:
+		return voteSetBitsToAdapter(payload.VoteSetBits)
 	default:
 		return nil, "", errUnsupportedMessage
 	}
 }
 
 func proposalToAdapter(wrapper *consensuspb.Proposal) (*cometbftAdapter.CometBFTConsensusMessage, string, error) {
-	if wrapper == nil || wrapper.Proposal == nil {
+	if wrapper == nil {
 		return nil, "", fmt.Errorf("empty proposal payload")
 	}
-	p := wrapper.Proposal
+	p := &wrapper.Proposal
 	msg := &cometbftAdapter.CometBFTConsensusMessage{
 		MessageType: "Proposal",
 		Height:      strconv.FormatInt(p.Height, 10),
 		Round:       strconv.FormatInt(int64(p.Round), 10),
 		Timestamp:   p.Timestamp,
-		POLRound:    p.POLRound,
+		POLRound:    p.PolRound,
 		Signature:   encodeBase64(p.Signature),
 		BlockID: cometbftAdapter.BlockID{
 			Hash:          hex.EncodeToString(p.BlockID.Hash),
@@ -126,6 +143,84 @@ func voteToAdapter(wrapper *consensuspb.Vote) (*cometbftAdapter.CometBFTConsensu
 	return msg, msg.MessageType, nil
 }
 
+func newRoundStepToAdapter(m *consensuspb.NewRoundStep) (*cometbftAdapter.CometBFTConsensusMessage, string, error) {
+	if m == nil {
+		return nil, "", fmt.Errorf("empty new round step payload")
+	}
+	msg := &cometbftAdapter.CometBFTConsensusMessage{
+		MessageType:           "NewRoundStep",
+		Height:                strconv.FormatInt(m.Height, 10),
+		Round:                 strconv.FormatInt(int64(m.Round), 10),
+		Step:                  m.Step,
+		SecondsSinceStartTime: m.SecondsSinceStartTime,
+		LastCommitRound:       m.LastCommitRound,
+	}
+	return msg, msg.MessageType, nil
+}
+
+func blockPartToAdapter(m *consensuspb.BlockPart) (*cometbftAdapter.CometBFTConsensusMessage, string, error) {
+	if m == nil {
+		return nil, "", fmt.Errorf("empty block part payload")
+	}
+	// The adapter carries the merkle proof as opaque bytes, so keep its protobuf encoding.
+	proof, err := m.Part.Proof.Marshal()
+	if err != nil {
+		return nil, "", fmt.Errorf("encode block part proof: %w", err)
+	}
+	msg := &cometbftAdapter.CometBFTConsensusMessage{
+		MessageType: "BlockPart",
+		Height:      strconv.FormatInt(m.Height, 10),
+		Round:       strconv.FormatInt(int64(m.Round), 10),
+		PartIndex:   m.Part.Index,
+		PartBytes:   append([]byte(nil), m.Part.Bytes...),
+		PartProof:   proof,
+	}
+	return msg, msg.MessageType, nil
+}
+
+func hasVoteToAdapter(m *consensuspb.HasVote) (*cometbftAdapter.CometBFTConsensusMessage, string, error) {
+	if m == nil {
+		return nil, "", fmt.Errorf("empty has vote payload")
+	}
+	msg := &cometbftAdapter.CometBFTConsensusMessage{
+		MessageType:    "HasVote",
+		Height:         strconv.FormatInt(m.Height, 10),
+		Round:          strconv.FormatInt(int64(m.Round), 10),
+		VoteType:       voteTypeName(m.Type),
+		ValidatorIndex: m.Index,
+	}
+	return msg, msg.MessageType, nil
+}
+
+func voteSetMaj23ToAdapter(m *consensuspb.VoteSetMaj23) (*cometbftAdapter.CometBFTConsensusMessage, string, error) {
+	if m == nil {
+		return nil, "", fmt.Errorf("empty vote set maj23 payload")
+	}
+	msg := &cometbftAdapter.CometBFTConsensusMessage{
+		MessageType: "VoteSetMaj23",
+		Height:      strconv.FormatInt(m.Height, 10),
+		Round:       strconv.FormatInt(int64(m.Round), 10),
+		VoteType:    voteTypeName(m.Type),
+		BlockID:     adapterBlockIDFromProto(m.BlockID),
+	}
+	return msg, msg.MessageType, nil
+}
+
+func voteSetBitsToAdapter(m *consensuspb.VoteSetBits) (*cometbftAdapter.CometBFTConsensusMessage, string, error) {
+	if m == nil {
+		return nil, "", fmt.Errorf("empty vote set bits payload")
+	}
+	msg := &cometbftAdapter.CometBFTConsensusMessage{
+		MessageType:   "VoteSetBits",
+		Height:        strconv.FormatInt(m.Height, 10),
+		Round:         strconv.FormatInt(int64(m.Round), 10),
+		VoteType:      voteTypeName(m.Type),
+		BlockID:       adapterBlockIDFromProto(m.BlockID),
+		VotesBitArray: bitArrayToStrings(m.Votes),
+	}
+	return msg, msg.MessageType, nil
+}
+
 func rawToConsensusMessage(raw *abstraction.RawConsensusMessage) (*consensuspb.Message, error) {
 	var adapterMsg cometbftAdapter.CometBFTConsensusMessage
 	if err := json.Unmarshal(raw.Payload, &adapterMsg); err != nil {
@@ -145,12 +240,12 @@ func protoFromAdapterMessage(msg *cometbftAdapter.CometBFTConsensusMessage) (*co
 		if err != nil {
 			return nil, fmt.Errorf("invalid proposal round: %w", err)
 		}
-		proposal := &cmttypes.Proposal{
+		proposal := cmtproto.Proposal{
 			Type:      cmtproto.ProposalType,
 			Height:    height,
 			Round:     int32(round),
-			POLRound:  msg.POLRound,
-			BlockID:   typesBlockIDFromAdapter(msg.BlockID),
+			PolRound:  msg.POLRound,
+			BlockID:   protoBlockIDFromAdapter(msg.BlockID),
 			Timestamp: ensureTime(msg.Timestamp),
 			Signature: decodeString(msg.Signature),
 		}
@@ -164,12 +259,12 @@ func protoFromAdapterMessage(msg *cometbftAdapter.CometBFTConsensusMessage) (*co
 		if err != nil {
 			return nil, fmt.Errorf("invalid vote round: %w", err)
 		}
-		vote := &cmttypes.Vote{
-			Type:               cmttypes.SignedMsgType(msg.Type),
+		vote := &cmtproto.Vote{
+			Type:               cmtproto.SignedMsgType(msg.Type),
 			Height:             height,
 			Round:              int32(round),
 			Timestamp:          ensureTime(msg.Timestamp),
-			BlockID:            typesBlockIDFromAdapter(msg.BlockID),
+			BlockID:            protoBlockIDFromAdapter(msg.BlockID),
 			ValidatorAddress:   decodeHexString(msg.ValidatorAddress),
 			ValidatorIndex:     msg.ValidatorIndex,
 			Signature:          decodeString(msg.Signature),
@@ -177,19 +272,151 @@ func protoFromAdapterMessage(msg *cometbftAdapter.CometBFTConsensusMessage) (*co
 			ExtensionSignature: decodeString(msg.ExtensionSignature),
 		}
 		return &consensuspb.Message{Sum: &consensuspb.Message_Vote{Vote: &consensuspb.Vote{Vote: vote}}}, nil
+	case "newroundstep":
+		height, round, err := parseHeightRound(msg, "new round step")
+		if err != nil {
+			return nil, err
+		}
+		return &consensuspb.Message{Sum: &consensuspb.Message_NewRoundStep{NewRoundStep: &consensuspb.NewRoundStep{
+			Height:                height,
+			Round:                 round,
+			Step:                  msg.Step,
+			SecondsSinceStartTime: msg.SecondsSinceStartTime,
+			LastCommitRound:       msg.LastCommitRound,
+		}}}, nil
+	case "blockpart":
+		height, round, err := parseHeightRound(msg, "block part")
+		if err != nil {
+			return nil, err
+		}
+		var proof cmtcrypto.Proof
+		if err := proof.Unmarshal(msg.PartProof); err != nil {
+			return nil, fmt.Errorf("invalid block part proof: %w", err)
+		}
+		part := cmtproto.Part{Index: msg.PartIndex, Bytes: msg.PartBytes, Proof: proof}
+		return &consensuspb.Message{Sum: &consensuspb.Message_BlockPart{BlockPart: &consensuspb.BlockPart{Height: height, Round: round, Part: part}}}, nil
+	case "hasvote":
+		height, round, err := parseHeightRound(msg, "has vote")
+		if err != nil {
+			return nil, err
+		}
+		voteType, err := parseVoteType(msg.VoteType)
+		if err != nil {
+			return nil, err
+		}
+		return &consensuspb.Message{Sum: &consensuspb.Message_HasVote{HasVote: &consensuspb.HasVote{Height: height, Round: round, Type: voteType, Index: msg.ValidatorIndex}}}, nil
+	case "votesetmaj23":
+		height, round, err := parseHeightRound(msg, "vote set maj23")
+		if err != nil {
+			return nil, err
+		}
+		voteType, err := parseVoteType(msg.VoteType)
+		if err != nil {
+			return nil, err
+		}
+		return &consensuspb.Message{Sum: &consensuspb.Message_VoteSetMaj23{VoteSetMaj23: &consensuspb.VoteSetMaj23{
+			Height:  height,
+			Round:   round,
+			Type:    voteType,
+			BlockID: protoBlockIDFromAdapter(msg.BlockID),
+		}}}, nil
+	case "votesetbits":
+		height, round, err := parseHeightRound(msg, "vote set bits")
+		if err != nil {
+			return nil, err
+		}
+		voteType, err := parseVoteType(msg.VoteType)
+		if err != nil {
+			return nil, err
+		}
+		return &consensuspb.Message{Sum: &consensuspb.Message_VoteSetBits{VoteSetBits: &consensuspb.VoteSetBits{
+			Height:  height,
+			Round:   round,
+			Type:    voteType,
+			BlockID: protoBlockIDFromAdapter(msg.BlockID),
+			Votes:   bitArrayFromStrings(msg.VotesBitArray),
+		}}}, nil
 	default:
 		return nil, errUnsupportedMessage
 	}
 }
 
-func typesBlockIDFromAdapter(block cometbftAdapter.BlockID) cmttypes.BlockID {
-	return cmttypes.BlockID{
-		Hash: hexDecodeOrCopy(block.Hash),
-		PartSetHeader: cmttypes.PartSetHeader{
-			Total: int(block.PartSetHeader.Total),
-			Hash:  append([]byte(nil), block.PartSetHeader.Hash...),
-		},
+func parseHeightRound(msg *cometbftAdapter.CometBFTConsensusMessage, kind string) (int64, int32, error) {
+	height, err := strconv.ParseInt(msg.Height, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid %s height: %w", kind, err)
+	}
+	round, err := strconv.ParseInt(msg.Round, 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid %s round: %w", kind, err)
+	}
+	return height, int32(round), nil
+}
+
+// voteTypeName spells a signed message type the way the adapter's vote_type field does.
+func voteTypeName(t cmtproto.SignedMsgType) string {
+	switch t {
+	case cmtproto.PrevoteType:
+		return "PrevoteType"
+	case cmtproto.PrecommitType:
+		return "PrecommitType"
+	case cmtproto.ProposalType:
+		return "ProposalType"
+	default:
+		return strconv.Itoa(int(t))
+	}
+}
+
+func parseVoteType(name string) (cmtproto.SignedMsgType, error) {
+	switch strings.ToLower(name) {
+	case "prevotetype", "prevote":
+		return cmtproto.PrevoteType, nil
+	case "precommittype", "precommit":
+		return cmtproto.PrecommitType, nil
+	case "proposaltype", "proposal":
+		return cmtproto.ProposalType, nil
+	}
+	value, err := strconv.ParseInt(name, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid vote type %q", name)
+	}
+	return cmtproto.SignedMsgType(value), nil
+}
+
+func adapterBlockIDFromProto(block cmtproto.BlockID) cometbftAdapter.BlockID {
+	return cometbftAdapter.BlockID{
+		Hash:          hex.EncodeToString(block.Hash),
+		PartSetHeader: cometbftAdapter.PartSetHeader{Total: block.PartSetHeader.Total, Hash: append([]byte(nil), block.PartSetHeader.Hash...)},
+	}
+}
+
+func protoBlockIDFromAdapter(block cometbftAdapter.BlockID) cmtproto.BlockID {
+	return cmtproto.BlockID{
+		Hash:          hexDecodeOrCopy(block.Hash),
+		PartSetHeader: cmtproto.PartSetHeader{Total: block.PartSetHeader.Total, Hash: append([]byte(nil), block.PartSetHeader.Hash...)},
+	}
+}
+
+// bitArrayToStrings writes one "x" (set) or "_" (unset) entry per validator, as CometBFT prints bit arrays.
+func bitArrayToStrings(bits cmtbits.BitArray) []string {
+	out := make([]string, bits.Bits)
+	for i := range out {
+		out[i] = "_"
+		if word := i / 64; word < len(bits.Elems) && bits.Elems[word]&(1<<uint(i%64)) != 0 {
+			out[i] = "x"
+		}
+	}
+	return out
+}
+
+func bitArrayFromStrings(entries []string) cmtbits.BitArray {
+	bits := cmtbits.BitArray{Bits: int64(len(entries)), Elems: make([]uint64, (len(entries)+63)/64)}
+	for i, entry := range entries {
+		if entry == "x" {
+			bits.Elems[i/64] |= 1 << uint(i%64)
+		}
 	}
+	return bits
 }
 
 func encodeBase64(b []byte) string {
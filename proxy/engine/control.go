@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	cometbftAdapter "codec/cometbft/adapter"
+)
+
+// Attack is the part of the configuration an operator can change while the proxy runs: the byzantine action,
+// the trigger selecting the messages it applies to, and the delay, drop, and duplicate hooks.
+type Attack struct {
+	Action    cometbftAdapter.ByzantineAction `json:"action"`
+	Trigger   Trigger                         `json:"trigger"`
+	Delay     time.Duration                   `json:"delay"`
+	Drop      bool                            `json:"drop"`
+	Duplicate bool                            `json:"duplicate"`
+	// Options, when set, overrides the configured byzantine options for this attack.
+	Options *ActionOptions `json:"options,omitempty"`
+}
+
+// ActionOptions overrides the byzantine options given on the command line. Zero fields keep the configured
+// values; the signer, peer targets, and flood range always come from the configuration.
+type ActionOptions struct {
+	AlternateBlockHash string            `json:"alternate_block_hash,omitempty"`
+	AlternatePrevHash  string            `json:"alternate_prev_hash,omitempty"`
+	AlternateSignature string            `json:"alternate_signature,omitempty"`
+	AlternateValidator string            `json:"alternate_validator,omitempty"`
+	RoundOffset        int64             `json:"round_offset,omitempty"`
+	HeightOffset       int64             `json:"height_offset,omitempty"`
+	EmitBoth           bool              `json:"emit_both,omitempty"`
+	FuzzSeed           int64             `json:"fuzz_seed,omitempty"`
+	Params             map[string]string `json:"params,omitempty"`
+}
+
+// apply returns base with the non-zero overrides applied.
+func (o *ActionOptions) apply(base cometbftAdapter.ByzantineOptions) cometbftAdapter.ByzantineOptions {
+	if o == nil {
+		return base
+	}
+	for _, field := range []struct {
+		override string
+		target   *string
+	}{
+		{o.AlternateBlockHash, &base.AlternateBlockHash},
+		{o.AlternatePrevHash, &base.AlternatePrevHash},
+		{o.AlternateSignature, &base.AlternateSignature},
+		{o.AlternateValidator, &base.AlternateValidator},
+	} {
+		if field.override != "" {
+			*field.target = field.override
+		}
+	}
+	if o.RoundOffset != 0 {
+		base.RoundOffset = o.RoundOffset
+	}
+	if o.HeightOffset != 0 {
+		base.HeightOffset = o.HeightOffset
+	}
+	if o.EmitBoth {
+		base.EmitBoth = true
+	}
+	if o.FuzzSeed != 0 {
+		base.FuzzSeed = o.FuzzSeed
+	}
+	if len(o.Params) > 0 {
+		params := make(map[string]string, len(base.Params)+len(o.Params))
+		for key, value := range base.Params {
+			params[key] = value
+		}
+		for key, value := range o.Params {
+			params[key] = value
+		}
+		base.Params = params
+	}
+	return base
+}
+
+// clone copies the pointers and maps in a so it shares no state with the live settings.
+func (a Attack) clone() Attack {
+	a.Trigger = normalizeTrigger(a.Trigger)
+	if a.Options != nil {
+		options := *a.Options
+		if a.Options.Params != nil {
+			options.Params = make(map[string]string, len(a.Options.Params))
+			for key, value := range a.Options.Params {
+				options.Params[key] = value
+			}
+		}
+		a.Options = &options
+	}
+	return a
+}
+
+type attackState struct {
+	mu     sync.RWMutex
+	attack Attack
+
+	// sampleMu guards the state behind Trigger.Every and Trigger.Probability; matched restarts with each
+	// new attack while the generator carries on, so a seeded run stays reproducible across changes.
+	sampleMu sync.Mutex
+	matched  int64
+	rng      *rand.Rand
+}
+
+func newAttackState(attack Attack, seed int64) *attackState {
+	return &attackState{attack: attack.clone(), rng: rand.New(rand.NewSource(seed))}
+}
+
+// sample reports whether a message that met the trigger's conditions fires it.
+func (c *Config) sample(trigger Trigger) bool {
+	if (trigger.Every <= 1 && trigger.Probability <= 0) || c.live == nil {
+		return true
+	}
+	c.live.sampleMu.Lock()
+	defer c.live.sampleMu.Unlock()
+	c.live.matched++
+	if trigger.Every > 1 && c.live.matched%int64(trigger.Every) != 0 {
+		return false
+	}
+	return trigger.Probability <= 0 || c.live.rng.Float64() < trigger.Probability
+}
+
+// attack returns the settings sessions apply to the next message. Configs built without NewConfig have no
+// live state and use their static fields.
+func (c *Config) attack() Attack {
+	if c.live == nil {
+		return Attack{Action: c.Action, Trigger: c.Trigger, Delay: c.Hooks.Delay, Drop: c.Hooks.Drop, Duplicate: c.Hooks.Duplicate}
+	}
+	c.live.mu.RLock()
+	defer c.live.mu.RUnlock()
+	return c.live.attack
+}
+
+// Attack returns the byzantine action, trigger, and hooks currently applied to triggered traffic. The result
+// is a copy, so callers may modify it before passing it to SetAttack.
+func (e *Engine) Attack() Attack {
+	return e.cfg.attack().clone()
+}
+
+// SetAttack replaces the live attack settings and returns them as applied. Connected sessions pick them up
+// from the next message they handle, so an experiment can move on without restarting the proxy.
+func (e *Engine) SetAttack(attack Attack) (Attack, error) {
+	applied, err := e.applyAttack(attack)
+	if err != nil {
+		return Attack{}, err
+	}
+	e.cfg.Logger.Info("attack updated", "attack", applied.Action, "trigger", applied.Trigger.String(), "delay", applied.Delay, "drop", applied.Drop, "duplicate", applied.Duplicate)
+	return applied, nil
+}
+
+// applyAttack validates and installs attack without logging it.
+func (e *Engine) applyAttack(attack Attack) (Attack, error) {
+	action, err := cometbftAdapter.ParseByzantineAction(string(attack.Action))
+	if err != nil {
+		return Attack{}, err
+	}
+	if attack.Delay < 0 {
+		return Attack{}, fmt.Errorf("delay must not be negative")
+	}
+	if err := attack.Trigger.validate(); err != nil {
+		return Attack{}, err
+	}
+	attack.Action = action
+	attack = attack.clone()
+
+	e.cfg.live.mu.Lock()
+	e.cfg.live.attack = attack
+	e.cfg.live.mu.Unlock()
+	e.cfg.live.sampleMu.Lock()
+	e.cfg.live.matched = 0
+	e.cfg.live.sampleMu.Unlock()
+	return attack, nil
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cometbftAdapter "codec/cometbft/adapter"
+	"codec/message/abstraction"
+)
+
+// ValidatorDelayPolicy assigns per-validator forwarding delays to votes based on the signer's validator index.
+// Explicit index entries take precedence over the even/odd parity delays.
+type ValidatorDelayPolicy struct {
+	Even    time.Duration
+	Odd     time.Duration
+	ByIndex map[int32]time.Duration
+}
+
+// ParseValidatorDelayPolicy parses a comma separated spec such as "even=500ms,odd=0,3=1s".
+func ParseValidatorDelayPolicy(spec string) (ValidatorDelayPolicy, error) {
+	var policy ValidatorDelayPolicy
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return policy, nil
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return ValidatorDelayPolicy{}, fmt.Errorf("invalid validator delay entry %q (expected key=duration)", entry)
+		}
+		delay, err := parseDelay(value)
+		if err != nil {
+			return ValidatorDelayPolicy{}, fmt.Errorf("invalid delay for %q: %w", key, err)
+		}
+		switch key = strings.ToLower(strings.TrimSpace(key)); key {
+		case "even":
+			policy.Even = delay
+		case "odd":
+			policy.Odd = delay
+		default:
+			index, err := strconv.ParseInt(key, 10, 32)
+			if err != nil || index < 0 {
+				return ValidatorDelayPolicy{}, fmt.Errorf("invalid validator index %q", key)
+			}
+			if policy.ByIndex == nil {
+				policy.ByIndex = make(map[int32]time.Duration)
+			}
+			policy.ByIndex[int32(index)] = delay
+		}
+	}
+	return policy, nil
+}
+
+func parseDelay(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "0" {
+		return 0, nil
+	}
+	delay, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if delay < 0 {
+		return 0, fmt.Errorf("delay must not be negative")
+	}
+	return delay, nil
+}
+
+// Enabled reports whether the policy delays any validator.
+func (p ValidatorDelayPolicy) Enabled() bool {
+	if p.Even > 0 || p.Odd > 0 {
+		return true
+	}
+	for _, delay := range p.ByIndex {
+		if delay > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// DelayFor returns the delay for a canonical vote. Messages without a validator index are not delayed.
+func (p ValidatorDelayPolicy) DelayFor(msg *abstraction.CanonicalMessage) time.Duration {
+	index, ok := cometbftAdapter.ValidatorIndexFromExtensions(msg)
+	if !ok {
+		return 0
+	}
+	if delay, ok := p.ByIndex[index]; ok {
+		return delay
+	}
+	if index%2 == 0 {
+		return p.Even
+	}
+	return p.Odd
+}
+
+// String renders the policy in the format accepted by ParseValidatorDelayPolicy.
+func (p ValidatorDelayPolicy) String() string {
+	parts := []string{fmt.Sprintf("even=%s", p.Even), fmt.Sprintf("odd=%s", p.Odd)}
+	indexes := make([]int, 0, len(p.ByIndex))
+	for index := range p.ByIndex {
+		indexes = append(indexes, int(index))
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		parts = append(parts, fmt.Sprintf("%d=%s", index, p.ByIndex[int32(index)]))
+	}
+	return strings.Join(parts, ",")
+}
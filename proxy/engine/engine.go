@@ -6,8 +6,12 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	cometbftAdapter "codec/cometbft/adapter"
+	"codec/message/abstraction"
+	"github.com/cometbft/cometbft/p2p"
 	p2pconn "github.com/cometbft/cometbft/p2p/conn"
 )
 
@@ -16,6 +20,16 @@ type Engine struct {
 	cfg     *Config
 	mapper  *cometbftAdapter.CometBFTMapper
 	metrics *Metrics
+
+	peers atomic.Int64
+	// hub is the shared upstream when Config.Multiplex is set.
+	hub *upstreamHub
+
+	sessionsMu sync.Mutex
+	sessions   map[*session]struct{}
+	// firstPeer is closed when the first peer session starts, which is when a replay can begin.
+	firstPeer     chan struct{}
+	firstPeerOnce sync.Once
 }
 
 // New constructs a proxy engine from the configuration.
@@ -23,12 +37,41 @@ func New(cfg *Config) *Engine {
 	if cfg == nil {
 		panic("engine config cannot be nil")
 	}
+	if cfg.live == nil {
+		cfg.live = newAttackState(cfg.attack(), cfg.TriggerSeed)
+	}
+	if cfg.Hooks.Partition != nil && cfg.partition == nil {
+		cfg.partition = newPartitionState(*cfg.Hooks.Partition, cfg.Logger)
+	}
+	if cfg.upstreams == nil {
+		cfg.upstreams = newUpstreamPool(cfg)
+	}
+	if cfg.activity == nil {
+		cfg.activity = newActivityLog(activityLogSize)
+	}
+	if cfg.pool == nil {
+		cfg.pool = abstraction.NewPool()
+	}
 	mapper := cometbftAdapter.NewCometBFTMapper(cfg.ChainID)
-	return &Engine{
-		cfg:     cfg,
-		mapper:  mapper,
-		metrics: NewMetrics(),
+	e := &Engine{
+		cfg:       cfg,
+		mapper:    mapper,
+		metrics:   NewMetrics(),
+		sessions:  make(map[*session]struct{}),
+		firstPeer: make(chan struct{}),
+	}
+	if cfg.Multiplex {
+		e.hub = newUpstreamHub(e)
+	}
+	if cfg.Scenario != nil {
+		cfg.runner = newScenarioRunner(e, cfg.Scenario)
 	}
+	return e
+}
+
+// Metrics returns the engine's counters, shared by every peer session.
+func (e *Engine) Metrics() *Metrics {
+	return e.metrics
 }
 
 // Run starts accepting peers until the context is cancelled.
@@ -38,11 +81,25 @@ func (e *Engine) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to listen on %s://%s: %w", e.cfg.ListenNetwork, e.cfg.ListenAddress, err)
 	}
 	e.cfg.Logger.Info("proxy listening", "network", e.cfg.ListenNetwork, "address", e.cfg.ListenAddress)
+	if e.cfg.runner != nil {
+		e.cfg.runner.start(ctx)
+	}
+	e.cfg.partition.start(time.Now())
+	if e.cfg.Replay != nil {
+		go func() {
+			if err := e.replay(ctx, *e.cfg.Replay); err != nil && ctx.Err() == nil {
+				e.cfg.Logger.Error("replay failed", "path", e.cfg.Replay.Path, "err", err)
+			}
+		}()
+	}
 
 	var wg sync.WaitGroup
 	defer func() {
 		_ = ln.Close()
 		wg.Wait()
+		if e.hub != nil {
+			e.hub.close()
+		}
 	}()
 
 	errCh := make(chan error, 1)
@@ -94,21 +151,41 @@ func (e *Engine) handleConnection(ctx context.Context, downstreamConn net.Conn)
 		return fmt.Errorf("handshake with downstream peer failed: %w", err)
 	}
 
-	upstreamConn, err := net.DialTimeout(e.cfg.UpstreamNetwork, e.cfg.UpstreamAddress, e.cfg.DialTimeout)
-	if err != nil {
-		return fmt.Errorf("failed to dial upstream %s://%s: %w", e.cfg.UpstreamNetwork, e.cfg.UpstreamAddress, err)
-	}
+	sessionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	upstreamSecret, err := p2pconn.MakeSecretConnection(upstreamConn, e.cfg.NodeKey.PrivKey)
-	if err != nil {
-		upstreamConn.Close()
-		return fmt.Errorf("handshake with upstream failed: %w", err)
+	var sess *session
+	if e.hub != nil {
+		sess = newPeerSession(sessionCtx, cancel, e.cfg, e.mapper, e.metrics, downstreamSecret)
+		if err := e.hub.join(sess); err != nil {
+			return err
+		}
+		defer e.hub.leave(sess)
+	} else {
+		upstreamSecret, err := e.dialUpstream()
+		if err != nil {
+			return err
+		}
+		sess = newSession(sessionCtx, cancel, e.cfg, e.mapper, e.metrics, downstreamSecret, upstreamSecret)
 	}
 
-	sessionCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
+	sess.identities = peerIdentities(downstreamSecret, remote)
+	sess.nodeID = string(p2p.PubKeyToID(downstreamSecret.RemotePubKey()))
+	policy := PeerPolicy{
+		Targeted: e.cfg.Options.TargetsPeer(sess.identities...),
+		Variant:  int(e.peers.Add(1) - 1),
+	}
+	policy.SkewToValidator, policy.SkewToPeer = victimOffsets(e.cfg.Hooks.VictimClockSkews, sess.identities)
+	sess.setPolicy(policy)
+	if len(e.cfg.Options.TargetPeers) > 0 {
+		e.cfg.Logger.Info("peer targeting", "remote", remote, "node_id", sess.nodeID, "targeted", policy.Targeted)
+	}
+	if policy.SkewToValidator != 0 || policy.SkewToPeer != 0 {
+		e.cfg.Logger.Info("victim clock skew", "remote", remote, "to_validator", policy.SkewToValidator, "to_peer", policy.SkewToPeer)
+	}
 
-	sess := newSession(sessionCtx, cancel, e.cfg, e.mapper, e.metrics, downstreamSecret, upstreamSecret)
+	e.addSession(sess)
+	defer e.removeSession(sess)
 	if err := sess.run(); err != nil {
 		if !strings.Contains(err.Error(), "closed network connection") {
 			return err
@@ -116,3 +193,20 @@ func (e *Engine) handleConnection(ctx context.Context, downstreamConn net.Conn)
 	}
 	return nil
 }
+
+// dialUpstream connects and handshakes with the upstream validator, failing over to the next configured
+// target when one does not answer.
+func (e *Engine) dialUpstream() (*p2pconn.SecretConnection, error) {
+	secret, _, err := e.cfg.upstreams.dial()
+	return secret, err
+}
+
+// peerIdentities lists the names TargetPeers may use for a downstream peer: its node ID, its address, and
+// its host without the port.
+func peerIdentities(conn *p2pconn.SecretConnection, remote string) []string {
+	ids := []string{string(p2p.PubKeyToID(conn.RemotePubKey())), remote}
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		ids = append(ids, host)
+	}
+	return ids
+}
@@ -3,16 +3,31 @@ package engine
 import (
 	"context"
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"math/rand"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"codec/capture"
 	cometbftAdapter "codec/cometbft/adapter"
+	"codec/message/abstraction"
 	"github.com/cometbft/cometbft/crypto/ed25519"
 	"github.com/cometbft/cometbft/p2p"
 	p2pconn "github.com/cometbft/cometbft/p2p/conn"
 	consensuspb "github.com/cometbft/cometbft/proto/tendermint/consensus"
-	cmttypes "github.com/cometbft/cometbft/types"
+	cmtcrypto "github.com/cometbft/cometbft/proto/tendermint/crypto"
+	cmtbits "github.com/cometbft/cometbft/proto/tendermint/libs/bits"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
 	gogoproto "github.com/cosmos/gogoproto/proto"
 )
 
@@ -43,12 +58,12 @@ func TestSessionDoublePrevoteMutation(t *testing.T) {
 	for i := range voteBytes {
 		voteBytes[i] = 0xAA
 	}
-	vote := &cmttypes.Vote{
-		Type:             cmttypes.PrevoteType,
+	vote := &cmtproto.Vote{
+		Type:             cmtproto.PrevoteType,
 		Height:           height,
 		Round:            2,
 		Timestamp:        time.Now().UTC(),
-		BlockID:          cmttypes.BlockID{Hash: voteBytes, PartSetHeader: cmttypes.PartSetHeader{Total: 1, Hash: []byte{0x01}}},
+		BlockID:          cmtproto.BlockID{Hash: voteBytes, PartSetHeader: cmtproto.PartSetHeader{Total: 1, Hash: []byte{0x01}}},
 		ValidatorAddress: []byte("validator-1"),
 		ValidatorIndex:   7,
 		Signature:        []byte("sig"),
@@ -111,12 +126,12 @@ func TestSessionDropTriggeredMessage(t *testing.T) {
 	harness := newProxyHarness(t, cfg)
 	defer harness.Close()
 
-	vote := &cmttypes.Vote{
-		Type:             cmttypes.PrevoteType,
+	vote := &cmtproto.Vote{
+		Type:             cmtproto.PrevoteType,
 		Height:           height,
 		Round:            1,
 		Timestamp:        time.Now().UTC(),
-		BlockID:          cmttypes.BlockID{Hash: []byte{0x01}, PartSetHeader: cmttypes.PartSetHeader{Total: 1, Hash: []byte{0x02}}},
+		BlockID:          cmtproto.BlockID{Hash: []byte{0x01}, PartSetHeader: cmtproto.PartSetHeader{Total: 1, Hash: []byte{0x02}}},
 		ValidatorAddress: []byte("validator"),
 		ValidatorIndex:   3,
 		Signature:        []byte("sig"),
@@ -162,12 +177,12 @@ func TestSessionDelayProposal(t *testing.T) {
 	harness := newProxyHarness(t, cfg)
 	defer harness.Close()
 
-	proposal := &cmttypes.Proposal{
-		Type:      cmttypes.ProposalType,
+	proposal := cmtproto.Proposal{
+		Type:      cmtproto.ProposalType,
 		Height:    height,
 		Round:     1,
-		POLRound:  0,
-		BlockID:   cmttypes.BlockID{Hash: []byte{0xAA}, PartSetHeader: cmttypes.PartSetHeader{Total: 1, Hash: []byte{0xBB}}},
+		PolRound:  0,
+		BlockID:   cmtproto.BlockID{Hash: []byte{0xAA}, PartSetHeader: cmtproto.PartSetHeader{Total: 1, Hash: []byte{0xBB}}},
 		Timestamp: time.Now().UTC(),
 		Signature: []byte("sig"),
 	}
@@ -187,6 +202,784 @@ func TestSessionDelayProposal(t *testing.T) {
 	}
 }
 
+func TestValidatorDelayPolicy(t *testing.T) {
+	policy, err := ParseValidatorDelayPolicy("even=500ms, odd=0, 3=1s")
+	if err != nil {
+		t.Fatalf("parse policy: %v", err)
+	}
+	if !policy.Enabled() {
+		t.Fatalf("expected policy to be enabled")
+	}
+
+	vote := func(index int32) *abstraction.CanonicalMessage {
+		return &abstraction.CanonicalMessage{Type: abstraction.MsgTypePrevote, Extensions: map[string]interface{}{"validator_index": index}}
+	}
+	cases := map[int32]time.Duration{0: 500 * time.Millisecond, 1: 0, 2: 500 * time.Millisecond, 3: time.Second}
+	for index, want := range cases {
+		if got := policy.DelayFor(vote(index)); got != want {
+			t.Fatalf("validator %d: expected %v, got %v", index, want, got)
+		}
+	}
+	if got := policy.DelayFor(&abstraction.CanonicalMessage{Type: abstraction.MsgTypeProposal}); got != 0 {
+		t.Fatalf("expected messages without validator index to pass through, got %v", got)
+	}
+
+	for _, bad := range []string{"even", "x=1s", "odd=-1s", "-2=1s"} {
+		if _, err := ParseValidatorDelayPolicy(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestVictimClockSkews(t *testing.T) {
+	skews, err := ParseVictimClockSkews("validator=-2s, peer-1=+500ms, 10.0.0.5=1s")
+	if err != nil {
+		t.Fatalf("parse skews: %v", err)
+	}
+	if len(skews) != 3 || skews[0].Offset != -2*time.Second || skews[1].Offset != 500*time.Millisecond {
+		t.Fatalf("unexpected skews %+v", skews)
+	}
+
+	toValidator, toPeer := victimOffsets(skews, []string{"PEER-1", "10.0.0.7:26656", "10.0.0.7"})
+	if toValidator != -2*time.Second || toPeer != 500*time.Millisecond {
+		t.Fatalf("expected -2s to the validator and 500ms to peer-1, got %v and %v", toValidator, toPeer)
+	}
+	if _, toPeer := victimOffsets(skews, []string{"peer-2", "10.0.0.5:26656", "10.0.0.5"}); toPeer != time.Second {
+		t.Fatalf("expected host match to skew by 1s, got %v", toPeer)
+	}
+	if _, toPeer := victimOffsets(skews, []string{"peer-3"}); toPeer != 0 {
+		t.Fatalf("expected unlisted peer to be left alone, got %v", toPeer)
+	}
+
+	for _, bad := range []string{"validator", "=1s", "validator=soon"} {
+		if _, err := ParseVictimClockSkews(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestStatusHandlerPeerPolicies(t *testing.T) {
+	cfg, err := NewConfig(ConfigOptions{
+		ListenAddress:  "tcp://0.0.0.0:0",
+		UpstreamTarget: "tcp://0.0.0.0:0",
+		ChainID:        "test-chain",
+		NodeKey:        &p2p.NodeKey{PrivKey: ed25519.GenPrivKey()},
+		Multiplex:      true,
+	})
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	eng := New(cfg)
+	first := &session{nodeID: "peer-1", identities: []string{"peer-1", "10.0.0.7"}, connected: time.Unix(1, 0), policy: PeerPolicy{Targeted: true}}
+	second := &session{nodeID: "peer-2", identities: []string{"peer-2", "10.0.0.8"}, connected: time.Unix(2, 0), policy: PeerPolicy{Targeted: true, Variant: 1}}
+	eng.addSession(second)
+	eng.addSession(first)
+
+	srv := httptest.NewServer(eng.StatusHandler())
+	defer srv.Close()
+
+	put := func(id, body string) int {
+		req, _ := http.NewRequest(http.MethodPut, srv.URL+"/peers/"+id, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("put %s: %v", id, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := put("10.0.0.8", `{"targeted":false,"variant":0}`); code != http.StatusOK {
+		t.Fatalf("expected policy update to succeed, got %d", code)
+	}
+	if code := put("peer-3", `{"targeted":false}`); code != http.StatusNotFound {
+		t.Fatalf("expected unknown peer to be rejected with 404, got %d", code)
+	}
+	if code := put("peer-1", `{"variant":-1}`); code != http.StatusBadRequest {
+		t.Fatalf("expected negative variant to be rejected with 400, got %d", code)
+	}
+
+	resp, err := http.Get(srv.URL + "/peers")
+	if err != nil {
+		t.Fatalf("get peers: %v", err)
+	}
+	defer resp.Body.Close()
+	var peers []PeerStatus
+	if err := json.NewDecoder(resp.Body).Decode(&peers); err != nil {
+		t.Fatalf("decode peers: %v", err)
+	}
+	if len(peers) != 2 || peers[0].NodeID != "peer-1" || peers[1].NodeID != "peer-2" {
+		t.Fatalf("expected peers in connection order, got %+v", peers)
+	}
+	if !peers[0].Policy.Targeted || peers[1].Policy.Targeted {
+		t.Fatalf("expected only peer-2 to be untargeted, got %+v", peers)
+	}
+}
+
+func TestStatusHandlerAttackUpdates(t *testing.T) {
+	height := int64(4)
+	cfg, err := NewConfig(ConfigOptions{
+		ListenAddress:  "tcp://0.0.0.0:0",
+		UpstreamTarget: "tcp://0.0.0.0:0",
+		ChainID:        "test-chain",
+		NodeKey:        &p2p.NodeKey{PrivKey: ed25519.GenPrivKey()},
+		Trigger:        Trigger{Height: &height, Step: "Prevote"},
+		Hooks:          Hooks{Drop: true},
+	})
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	eng := New(cfg)
+	srv := httptest.NewServer(eng.StatusHandler())
+	defer srv.Close()
+
+	put := func(body string) (int, Attack) {
+		req, _ := http.NewRequest(http.MethodPut, srv.URL+"/attack", strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("put attack: %v", err)
+		}
+		defer resp.Body.Close()
+		var attack Attack
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&attack); err != nil {
+				t.Fatalf("decode attack: %v", err)
+			}
+		}
+		return resp.StatusCode, attack
+	}
+
+	code, attack := put(`{"action":"DOUBLE_VOTE","trigger":{"height":9},"drop":false,"delay":1000000}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected attack update to succeed, got %d", code)
+	}
+	if attack.Action != cometbftAdapter.ByzantineActionDoubleVote || attack.Drop || attack.Delay != time.Millisecond {
+		t.Fatalf("unexpected attack %+v", attack)
+	}
+	if attack.Trigger.Height == nil || *attack.Trigger.Height != 9 || attack.Trigger.Step != "prevote" {
+		t.Fatalf("expected height to change and the step to be kept, got %s", attack.Trigger)
+	}
+	if height != 4 {
+		t.Fatalf("expected the caller's trigger to be left alone, got height %d", height)
+	}
+
+	if code, _ := put(`{"action":"no_such_attack"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected unknown action to be rejected with 400, got %d", code)
+	}
+	if code, _ := put(`{"delay":-1}`); code != http.StatusBadRequest {
+		t.Fatalf("expected negative delay to be rejected with 400, got %d", code)
+	}
+
+	if _, attack = put(`{"trigger":{"height":null}}`); attack.Trigger.Height != nil {
+		t.Fatalf("expected null to clear the trigger height, got %s", attack.Trigger)
+	}
+	if live := cfg.attack(); live.Action != cometbftAdapter.ByzantineActionDoubleVote || live.Trigger.Height != nil {
+		t.Fatalf("expected sessions to see the update, got %+v", live)
+	}
+}
+
+func TestScenarioPhases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "split.yaml")
+	doc := `name: split-then-silence
+phases:
+  - name: equivocate
+    heights: 10..12
+    step: prevote
+    action: double_vote
+    messages: 2
+    options:
+      alternate_block_hash: "BEEF"
+  - name: past-range
+    heights: "13"
+    action: none
+    drop: true
+  - name: silence
+    step: precommit
+    drop: true
+    delay: 250ms
+`
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatalf("write scenario: %v", err)
+	}
+	sc, err := LoadScenario(path)
+	if err != nil {
+		t.Fatalf("load scenario: %v", err)
+	}
+	cfg, err := NewConfig(ConfigOptions{
+		ListenAddress:  "tcp://0.0.0.0:0",
+		UpstreamTarget: "tcp://0.0.0.0:0",
+		ChainID:        "test-chain",
+		NodeKey:        &p2p.NodeKey{PrivKey: ed25519.GenPrivKey()},
+		Scenario:       sc,
+	})
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	eng := New(cfg)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfg.runner.start(ctx)
+
+	attack := eng.Attack()
+	if attack.Action != cometbftAdapter.ByzantineActionDoubleVote || attack.Options == nil || attack.Options.AlternateBlockHash != "BEEF" {
+		t.Fatalf("expected the first phase to be active, got %+v", attack)
+	}
+	at := func(height int64) *abstraction.CanonicalMessage {
+		return &abstraction.CanonicalMessage{Height: big.NewInt(height), Type: abstraction.MsgTypePrevote}
+	}
+	if !attack.Trigger.Matches(at(11)) || attack.Trigger.Matches(at(13)) {
+		t.Fatalf("expected trigger %s to cover heights 10..12 only", attack.Trigger)
+	}
+
+	cfg.runner.trigger()
+	if eng.Attack().Action != cometbftAdapter.ByzantineActionDoubleVote {
+		t.Fatalf("expected the first phase to last for two messages")
+	}
+	cfg.runner.trigger()
+	if attack := eng.Attack(); attack.Action != cometbftAdapter.ByzantineActionNone || !attack.Drop {
+		t.Fatalf("expected the message count to move to the second phase, got %+v", attack)
+	}
+
+	cfg.runner.observe(at(14))
+	attack = eng.Attack()
+	if !attack.Drop || attack.Delay != 250*time.Millisecond || attack.Trigger.Step != "precommit" {
+		t.Fatalf("expected traffic past the height range to move to the last phase, got %+v", attack)
+	}
+
+	if _, err := LoadScenario(writeFile(t, "bad.json", `{"phases":[{"action":"no_such_attack"}]}`)); err == nil {
+		t.Fatalf("expected an unknown action to be rejected")
+	}
+	if _, err := LoadScenario(writeFile(t, "empty.json", `{"name":"empty"}`)); err == nil {
+		t.Fatalf("expected a scenario without phases to be rejected")
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestTriggerSampling(t *testing.T) {
+	newEngine := func(trigger Trigger, seed int64) *Engine {
+		cfg, err := NewConfig(ConfigOptions{
+			ListenAddress:  "tcp://0.0.0.0:0",
+			UpstreamTarget: "tcp://0.0.0.0:0",
+			ChainID:        "test-chain",
+			NodeKey:        &p2p.NodeKey{PrivKey: ed25519.GenPrivKey()},
+			Trigger:        trigger,
+			TriggerSeed:    seed,
+		})
+		if err != nil {
+			t.Fatalf("config: %v", err)
+		}
+		return New(cfg)
+	}
+	fires := func(e *Engine, n int) []bool {
+		out := make([]bool, n)
+		for i := range out {
+			out[i] = e.cfg.sample(e.Attack().Trigger)
+		}
+		return out
+	}
+
+	every := fires(newEngine(Trigger{Every: 3}, 1), 6)
+	if want := []bool{false, false, true, false, false, true}; fmt.Sprint(every) != fmt.Sprint(want) {
+		t.Fatalf("expected every third message to fire, got %v", every)
+	}
+
+	first := fires(newEngine(Trigger{Probability: 0.3}, 42), 200)
+	again := fires(newEngine(Trigger{Probability: 0.3}, 42), 200)
+	if fmt.Sprint(first) != fmt.Sprint(again) {
+		t.Fatalf("expected the same seed to fire on the same messages")
+	}
+	hits := 0
+	for _, fired := range first {
+		if fired {
+			hits++
+		}
+	}
+	if hits < 30 || hits > 90 {
+		t.Fatalf("expected roughly 30%% of 200 messages to fire, got %d", hits)
+	}
+
+	if _, err := NewConfig(ConfigOptions{
+		ListenAddress:  "tcp://0.0.0.0:0",
+		UpstreamTarget: "tcp://0.0.0.0:0",
+		ChainID:        "test-chain",
+		NodeKey:        &p2p.NodeKey{PrivKey: ed25519.GenPrivKey()},
+		Trigger:        Trigger{Probability: 1.5},
+	}); err == nil {
+		t.Fatalf("expected a probability above 1 to be rejected")
+	}
+	if _, err := newEngine(Trigger{}, 1).SetAttack(Attack{Trigger: Trigger{Every: -2}}); err == nil {
+		t.Fatalf("expected a negative every to be rejected")
+	}
+}
+
+func TestTriggerValidators(t *testing.T) {
+	trigger := normalizeTrigger(Trigger{Step: "precommit", Validators: []string{"0xAABBCCDDEEFF00112233445566778899AABBCCDD", "3"}})
+	vote := func(address string, index int32) *abstraction.CanonicalMessage {
+		return &abstraction.CanonicalMessage{
+			Height:     big.NewInt(1),
+			Type:       abstraction.MsgTypePrecommit,
+			Validator:  address,
+			Extensions: map[string]interface{}{"validator_index": index},
+		}
+	}
+	if !trigger.Matches(vote("aabbccddeeff00112233445566778899aabbccdd", 0)) {
+		t.Fatalf("expected the listed address to match regardless of case and 0x prefix")
+	}
+	if !trigger.Matches(vote("1111111111111111111111111111111111111111", 3)) {
+		t.Fatalf("expected the listed index to match")
+	}
+	if trigger.Matches(vote("1111111111111111111111111111111111111111", 4)) {
+		t.Fatalf("expected other validators to be left alone")
+	}
+	if trigger.Matches(&abstraction.CanonicalMessage{Height: big.NewInt(1), Type: abstraction.MsgTypeProposal}) {
+		t.Fatalf("expected a message without a signer not to match")
+	}
+}
+
+func TestStateMessagesRoundTrip(t *testing.T) {
+	mapper := cometbftAdapter.NewCometBFTMapper("test-chain")
+	blockID := cmtproto.BlockID{Hash: []byte{0xAB, 0xCD}, PartSetHeader: cmtproto.PartSetHeader{Total: 2, Hash: []byte{0x01}}}
+	messages := map[string]*consensuspb.Message{
+		"new_round_step": {Sum: &consensuspb.Message_NewRoundStep{NewRoundStep: &consensuspb.NewRoundStep{Height: 7, Round: 1, Step: 3, SecondsSinceStartTime: 4, LastCommitRound: 0}}},
+		"block_part": {Sum: &consensuspb.Message_BlockPart{BlockPart: &consensuspb.BlockPart{Height: 7, Round: 1, Part: cmtproto.Part{
+			Index: 1,
+			Bytes: []byte("block data"),
+			Proof: cmtcrypto.Proof{Total: 2, Index: 1, LeafHash: []byte{0x02}, Aunts: [][]byte{{0x03}}},
+		}}}},
+		"has_vote":       {Sum: &consensuspb.Message_HasVote{HasVote: &consensuspb.HasVote{Height: 7, Round: 1, Type: cmtproto.PrecommitType, Index: 3}}},
+		"vote_set_maj23": {Sum: &consensuspb.Message_VoteSetMaj23{VoteSetMaj23: &consensuspb.VoteSetMaj23{Height: 7, Round: 1, Type: cmtproto.PrevoteType, BlockID: blockID}}},
+		"vote_set_bits": {Sum: &consensuspb.Message_VoteSetBits{VoteSetBits: &consensuspb.VoteSetBits{
+			Height:  7,
+			Round:   1,
+			Type:    cmtproto.PrevoteType,
+			BlockID: blockID,
+			Votes:   cmtbits.BitArray{Bits: 70, Elems: []uint64{0b101, 1 << 5}},
+		}}},
+	}
+	for kind, msg := range messages {
+		canonical, err := canonicalFromConsensus(nil, mapper, "test-chain", msg)
+		if err != nil {
+			t.Fatalf("%s: to canonical: %v", kind, err)
+		}
+		if got := messageKind(canonical); got != kind {
+			t.Fatalf("%s: unexpected kind %q", kind, got)
+		}
+		if !normalizeTrigger(Trigger{Step: kind}).Matches(canonical) || normalizeTrigger(Trigger{Step: "proposal"}).Matches(canonical) {
+			t.Fatalf("%s: step trigger should match the message's own kind only", kind)
+		}
+		raw, err := mapper.FromCanonical(canonical)
+		if err != nil {
+			t.Fatalf("%s: from canonical: %v", kind, err)
+		}
+		back, err := rawToConsensusMessage(raw)
+		if err != nil {
+			t.Fatalf("%s: to consensus: %v", kind, err)
+		}
+		if !gogoproto.Equal(msg, back) {
+			t.Fatalf("%s: round trip changed the message:\n got %v\nwant %v", kind, back, msg)
+		}
+	}
+
+	// A forged HasVote keeps its shape with a different validator index.
+	canonical, err := canonicalFromConsensus(nil, mapper, "test-chain", messages["has_vote"])
+	if err != nil {
+		t.Fatalf("to canonical: %v", err)
+	}
+	canonical.Extensions["validator_index"] = int32(5)
+	raw, err := mapper.FromCanonical(canonical)
+	if err != nil {
+		t.Fatalf("from canonical: %v", err)
+	}
+	back, err := rawToConsensusMessage(raw)
+	if err != nil {
+		t.Fatalf("to consensus: %v", err)
+	}
+	if hasVote := back.GetHasVote(); hasVote == nil || hasVote.Index != 5 || hasVote.Type != cmtproto.PrecommitType {
+		t.Fatalf("unexpected forged has vote %v", back)
+	}
+}
+
+func TestRecorderCapturesFrames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traffic.capture")
+	recorder, err := OpenRecorder(path)
+	if err != nil {
+		t.Fatalf("open recorder: %v", err)
+	}
+	s := &session{
+		cfg:    &Config{ChainID: "test-chain", Recorder: recorder},
+		mapper: cometbftAdapter.NewCometBFTMapper("test-chain"),
+		remote: "127.0.0.1:26656",
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	payload, err := marshalConsensusMessage(&consensuspb.Message{Sum: &consensuspb.Message_HasVote{HasVote: &consensuspb.HasVote{Height: 9, Round: 0, Type: cmtproto.PrevoteType, Index: 1}}})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	received := time.Now()
+	labels := MessageLabels{Channel: stateChannelID, Direction: string(directionUpstream), Type: "has_vote", Action: "fuzz_payload"}
+	s.record(RecordReceived, MessageLabels{Channel: stateChannelID, Direction: string(directionUpstream)}, payload, received)
+	s.record(RecordMutated, labels, []byte{0xFF, 0x00}, received)
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("close recorder: %v", err)
+	}
+
+	r, err := capture.Open(path)
+	if err != nil {
+		t.Fatalf("open capture: %v", err)
+	}
+	defer r.Close()
+	rec, err := r.Next()
+	if err != nil {
+		t.Fatalf("read received record: %v", err)
+	}
+	if rec.Event != RecordReceived || rec.Direction != "upstream" || rec.Channel != stateChannelID || rec.Source != "127.0.0.1:26656" {
+		t.Fatalf("unexpected received record %+v", rec)
+	}
+	if rec.Height() != 9 || rec.Raw.MessageType != "has_vote" || string(rec.Raw.Payload) != string(payload) || !rec.Raw.Timestamp.Equal(received.UTC()) {
+		t.Fatalf("received record lost the message: %+v", rec.Raw)
+	}
+	// A fuzzed frame no longer decodes but its bytes are kept.
+	rec, err = r.Next()
+	if err != nil {
+		t.Fatalf("read mutated record: %v", err)
+	}
+	if rec.Event != RecordMutated || rec.Action != "fuzz_payload" || rec.Canonical != nil || string(rec.Raw.Payload) != "\xff\x00" {
+		t.Fatalf("unexpected mutated record %+v", rec)
+	}
+}
+
+func TestReplaySelectsRecordedFlows(t *testing.T) {
+	received := func(direction string) *capture.Record {
+		return &capture.Record{Event: RecordReceived, Direction: direction}
+	}
+	cases := []struct {
+		rec       *capture.Record
+		direction Direction
+		flow      flowDirection
+		ok        bool
+	}{
+		{received("upstream"), DirectionUpstream, directionUpstream, true},
+		{received("upstream"), DirectionDownstream, directionUpstream, false},
+		{received("downstream"), DirectionDownstream, directionDownstream, true},
+		{received("downstream"), DirectionBoth, directionDownstream, true},
+		{&capture.Record{}, DirectionDownstream, directionDownstream, true},
+		{&capture.Record{Event: RecordMutated, Direction: "upstream"}, DirectionBoth, "", false},
+		{&capture.Record{Event: RecordDropped, Direction: "downstream"}, DirectionBoth, "", false},
+	}
+	for i, tc := range cases {
+		flow, ok := replayFlow(tc.rec, tc.direction)
+		if ok != tc.ok || (ok && flow != tc.flow) {
+			t.Fatalf("case %d: got %s/%v, want %s/%v", i, flow, ok, tc.flow, tc.ok)
+		}
+	}
+
+	nodeKey := &p2p.NodeKey{PrivKey: ed25519.GenPrivKey()}
+	_, err := NewConfig(ConfigOptions{
+		ListenAddress:  "tcp://127.0.0.1:0",
+		UpstreamTarget: "tcp://127.0.0.1:26656",
+		ChainID:        "test-chain",
+		NodeKey:        nodeKey,
+		Replay:         &ReplayOptions{Path: "traffic.capture", Speed: -1},
+	})
+	if err == nil {
+		t.Fatalf("expected a negative replay speed to be rejected")
+	}
+}
+
+func TestChannelBlocks(t *testing.T) {
+	blocks, err := ParseChannelBlocks("evidence, mempool=downstream, 0x40, snapshot=upstream")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	cases := []struct {
+		chID    byte
+		flow    flowDirection
+		blocked bool
+	}{
+		{evidenceChannelID, directionUpstream, true},
+		{evidenceChannelID, directionDownstream, true},
+		{mempoolChannelID, directionDownstream, true},
+		{mempoolChannelID, directionUpstream, false},
+		{blocksyncChannelID, directionUpstream, true},
+		{snapshotChannelID, directionUpstream, true},
+		{chunkChannelID, directionDownstream, false},
+		{voteChannelID, directionUpstream, false},
+	}
+	for _, tc := range cases {
+		if got := blocksChannel(blocks, tc.chID, tc.flow); got != tc.blocked {
+			t.Fatalf("channel 0x%X %s: blocked=%v, want %v", tc.chID, tc.flow, got, tc.blocked)
+		}
+	}
+
+	for _, spec := range []string{"gossip", "0x99", "evidence=sideways"} {
+		if _, err := ParseChannelBlocks(spec); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestPartitionWindow(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if err := (Partition{Direction: DirectionBoth}).validate(); err == nil {
+		t.Fatalf("expected a partition without a window to be rejected")
+	}
+
+	ps := newPartitionState(Partition{Direction: DirectionUpstream, After: time.Second, For: 2 * time.Second}, logger)
+	start := time.Now()
+	if ps.blocks(directionUpstream, start) {
+		t.Fatalf("expected no partition before the proxy starts")
+	}
+	ps.start(start)
+	for _, tc := range []struct {
+		at      time.Duration
+		flow    flowDirection
+		blocked bool
+	}{
+		{0, directionUpstream, false},
+		{1500 * time.Millisecond, directionUpstream, true},
+		{1500 * time.Millisecond, directionDownstream, false},
+		{3 * time.Second, directionUpstream, false},
+	} {
+		if got := ps.blocks(tc.flow, start.Add(tc.at)); got != tc.blocked {
+			t.Fatalf("at %s %s: blocked=%v, want %v", tc.at, tc.flow, got, tc.blocked)
+		}
+	}
+
+	from, to := int64(10), int64(11)
+	ps = newPartitionState(Partition{Direction: DirectionBoth, Trigger: Trigger{MinHeight: &from, MaxHeight: &to, Step: "prevote"}}, logger)
+	ps.start(start)
+	observe := func(height int64) {
+		payload, err := marshalConsensusMessage(&consensuspb.Message{Sum: &consensuspb.Message_NewRoundStep{NewRoundStep: &consensuspb.NewRoundStep{Height: height}}})
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		ps.observe(stateChannelID, payload)
+	}
+	observe(9)
+	if ps.blocks(directionDownstream, start) {
+		t.Fatalf("expected no partition below the height range")
+	}
+	observe(10)
+	if !ps.blocks(directionDownstream, start) || !ps.blocks(directionUpstream, start) {
+		t.Fatalf("expected the partition inside the height range")
+	}
+	// Stale traffic from a lagging peer does not move the partition back.
+	observe(12)
+	observe(10)
+	if ps.blocks(directionUpstream, start) {
+		t.Fatalf("expected the partition to heal past the height range")
+	}
+}
+
+func TestLinkShaping(t *testing.T) {
+	rates, err := ParseBandwidth("mempool=64k, 0x22=1000")
+	if err != nil {
+		t.Fatalf("parse bandwidth: %v", err)
+	}
+	if rates[mempoolChannelID] != 64<<10 || rates[voteChannelID] != 1000 || len(rates) != 2 {
+		t.Fatalf("unexpected rates: %v", rates)
+	}
+	if all, err := ParseBandwidth("all=1m"); err != nil || len(all) != len(defaultDescriptors()) {
+		t.Fatalf("expected all to cover every channel, got %v (%v)", all, err)
+	}
+	for _, spec := range []string{"mempool", "mempool=0", "mempool=fast", "0x99=1k"} {
+		if _, err := ParseBandwidth(spec); err == nil {
+			t.Fatalf("expected %q to be rejected", spec)
+		}
+	}
+	if err := (LinkShaping{Latency: LatencyModel{Distribution: "bursty"}}).validate(); err == nil {
+		t.Fatalf("expected an unknown distribution to be rejected")
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for _, dist := range []string{LatencyConstant, LatencyUniform, LatencyNormal, LatencyPareto} {
+		model := LatencyModel{Distribution: dist, Mean: 50 * time.Millisecond, Jitter: 20 * time.Millisecond}
+		for i := 0; i < 1000; i++ {
+			d := model.sample(rng)
+			if d < 0 {
+				t.Fatalf("%s: negative latency %s", dist, d)
+			}
+			if dist == LatencyConstant && d != model.Mean {
+				t.Fatalf("constant: got %s", d)
+			}
+			if dist == LatencyUniform && (d < 30*time.Millisecond || d > 70*time.Millisecond) {
+				t.Fatalf("uniform: %s outside mean ± jitter", d)
+			}
+			if dist == LatencyPareto && d < model.Mean {
+				t.Fatalf("pareto: %s below the mean", d)
+			}
+		}
+	}
+
+	// A 1000 byte/s channel delivers 500 byte frames 500ms apart, and jitter never reorders them.
+	shaper := newLinkShaper(nil, directionUpstream, LinkShaping{
+		Latency:   LatencyModel{Distribution: LatencyUniform, Mean: 100 * time.Millisecond, Jitter: 100 * time.Millisecond},
+		Bandwidth: map[byte]int64{mempoolChannelID: 1000},
+	}, 1)
+	now := time.Now()
+	var last time.Time
+	for i := 0; i < 5; i++ {
+		at := shaper.schedule(mempoolChannelID, 500, now)
+		if i > 0 && at.Sub(last) < 500*time.Millisecond {
+			t.Fatalf("frame %d scheduled %s after the previous one", i, at.Sub(last))
+		}
+		last = at
+	}
+	last = shaper.schedule(voteChannelID, 500, now)
+	if last.Sub(now) > 200*time.Millisecond {
+		t.Fatalf("expected the vote channel not to wait for the throttled mempool, got %s", last.Sub(now))
+	}
+	for i := 0; i < 50; i++ {
+		next := shaper.schedule(voteChannelID, 100, now)
+		if next.Before(last) {
+			t.Fatalf("vote frame %d reordered", i)
+		}
+		last = next
+	}
+}
+
+func TestUpstreamFailover(t *testing.T) {
+	if err := (Reconnect{}).validate(); err == nil {
+		t.Fatalf("expected a reconnect without backoff to be rejected")
+	}
+	policy := Reconnect{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 5: time.Second, 30: time.Second} {
+		if got := policy.wait(attempt); got != want {
+			t.Fatalf("attempt %d: wait %s, want %s", attempt, got, want)
+		}
+	}
+
+	// The primary refuses connections; the failover target answers with a secret handshake.
+	down, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	primary := down.Addr().String()
+	down.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				if _, err := p2pconn.MakeSecretConnection(conn, ed25519.GenPrivKey()); err != nil {
+					conn.Close()
+				}
+			}()
+		}
+	}()
+
+	cfg, err := NewConfig(ConfigOptions{
+		ListenAddress:   "tcp://0.0.0.0:0",
+		UpstreamTarget:  "tcp://" + primary,
+		FailoverTargets: []string{"tcp://" + ln.Addr().String()},
+		Reconnect:       &policy,
+		ChainID:         "test-chain",
+		NodeKey:         &p2p.NodeKey{PrivKey: ed25519.GenPrivKey()},
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("config: %v", err)
+	}
+	New(cfg)
+	secret, target, err := cfg.upstreams.dial()
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	secret.Close()
+	if target.Address != ln.Addr().String() || cfg.upstreams.current != 1 {
+		t.Fatalf("expected failover to %s, got %s (current %d)", ln.Addr(), target, cfg.upstreams.current)
+	}
+
+	ln.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, _, err := cfg.upstreams.redial(ctx, Reconnect{Backoff: time.Millisecond, Attempts: 3}); err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("expected redial to give up after 3 attempts, got %v", err)
+	}
+}
+
+func TestMetricsPrometheusExposition(t *testing.T) {
+	metrics := NewMetrics()
+	mutated := MessageLabels{Channel: voteChannelID, Direction: string(directionUpstream), Type: "prevote", Action: "double_vote"}
+	metrics.Record(EventForwarded, MessageLabels{Channel: dataChannelID, Direction: string(directionDownstream)}, 1)
+	metrics.Record(EventMutated, mutated, 2)
+	metrics.Record(EventDropped, mutated, 0)
+	metrics.ObserveLatency(mutated, 20*time.Millisecond)
+
+	if snapshot := metrics.Snapshot(); snapshot["mutated"] != 2 || snapshot["dropped"] != 0 {
+		t.Fatalf("unexpected snapshot %v", snapshot)
+	}
+
+	srv := httptest.NewServer(metrics.Handler())
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("scrape: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read scrape: %v", err)
+	}
+	text := string(body)
+	for _, want := range []string{
+		`byzproxy_messages_forwarded_total{action="",channel="0x21",direction="downstream",type=""} 1`,
+		`byzproxy_messages_mutated_total{action="double_vote",channel="0x22",direction="upstream",type="prevote"} 2`,
+		`byzproxy_added_latency_seconds_bucket{action="double_vote",channel="0x22",direction="upstream",type="prevote",le="0.01"} 0`,
+		`byzproxy_added_latency_seconds_bucket{action="double_vote",channel="0x22",direction="upstream",type="prevote",le="0.05"} 1`,
+		`byzproxy_added_latency_seconds_count{action="double_vote",channel="0x22",direction="upstream",type="prevote"} 1`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected scrape to contain %q, got:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "byzproxy_messages_dropped_total") {
+		t.Fatalf("expected no dropped series for a zero count, got:\n%s", text)
+	}
+	if counts := metrics.ChannelCounts(); counts[voteChannelID][EventMutated] != 2 || counts[dataChannelID][EventForwarded] != 1 {
+		t.Fatalf("unexpected channel counts %v", counts)
+	}
+}
+
+func TestActivityLogKeepsLatestEntries(t *testing.T) {
+	log := newActivityLog(3)
+	for h := int64(1); h <= 4; h++ {
+		log.observe(ActivityEntry{Channel: voteChannelID, Type: "prevote", Height: h, Round: 5 - h, Event: EventForwarded})
+	}
+	activity := log.snapshot()
+	if activity.Height != 4 || activity.Round != 1 {
+		t.Fatalf("expected height 4 round 1, got %d/%d", activity.Height, activity.Round)
+	}
+	if len(activity.Recent) != 3 || activity.Recent[0].Height != 2 || activity.Recent[2].Height != 4 {
+		t.Fatalf("expected heights 2..4 oldest first, got %+v", activity.Recent)
+	}
+
+	metrics := NewMetrics()
+	metrics.Record(EventForwarded, MessageLabels{Channel: voteChannelID, Direction: string(directionUpstream)}, 2)
+	metrics.Record(EventForwarded, MessageLabels{Channel: voteChannelID, Direction: string(directionDownstream)}, 3)
+	metrics.Record(EventDropped, MessageLabels{Channel: dataChannelID, Direction: string(directionUpstream)}, 1)
+	counts := metrics.ChannelCounts()
+	if counts[voteChannelID][EventForwarded] != 5 || counts[dataChannelID][EventDropped] != 1 {
+		t.Fatalf("unexpected channel counts %v", counts)
+	}
+	if ChannelName(voteChannelID) != "consensus/vote" || ChannelName(0x99) != "0x99" {
+		t.Fatalf("unexpected channel names %q %q", ChannelName(voteChannelID), ChannelName(0x99))
+	}
+}
+
 // proxyHarness manages a session and associated peer connections for tests.
 type proxyHarness struct {
 	t       *testing.T
@@ -234,8 +1027,8 @@ func newProxyHarness(t *testing.T, cfg *Config) *proxyHarness {
 	}
 	upRecv := func(chID byte, msg []byte) {}
 
-	downstreamPeerConn := p2pconn.NewMConnection(downstreamPeer, defaultDescriptors(), downRecv, func(any) {})
-	upstreamPeerConn := p2pconn.NewMConnection(upstreamPeer, defaultDescriptors(), upRecv, func(any) {})
+	downstreamPeerConn := newMConnection(downstreamPeer, downRecv, func(any) {})
+	upstreamPeerConn := newMConnection(upstreamPeer, upRecv, func(any) {})
 
 	if err := downstreamPeerConn.Start(); err != nil {
 		t.Fatalf("downstream peer start: %v", err)
@@ -299,7 +1092,7 @@ func (h *proxyHarness) Close() {
 	h.downstreamSecret.Close()
 }
 
-func decodeVote(t *testing.T, payload []byte) *cmttypes.Vote {
+func decodeVote(t *testing.T, payload []byte) *cmtproto.Vote {
 	t.Helper()
 	var msg consensuspb.Message
 	if err := msg.Unmarshal(payload); err != nil {
@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	p2pconn "github.com/cometbft/cometbft/p2p/conn"
+)
+
+// sessionInboxSize bounds how far one peer session may fall behind the shared upstream before frames for it
+// are dropped, so a peer stalled by delay hooks does not hold back the others.
+const sessionInboxSize = 1024
+
+// upstreamFrame is a message from the shared upstream validator queued for one peer session.
+type upstreamFrame struct {
+	chID    byte
+	payload []byte
+}
+
+// upstreamHub multiplexes every downstream peer onto one upstream connection, so the validator sees a single
+// peer however many connect to the proxy. Frames from any peer go out on the shared connection (fan-in);
+// frames from the validator are queued for every peer session, which applies its own policy before delivering
+// them (fan-out).
+type upstreamHub struct {
+	e *Engine
+	// ctx ends a reconnect in progress when the engine shuts down.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	conn     *p2pconn.MConnection
+	sessions map[*session]struct{}
+	// reconnecting is set while a lost shared upstream is redialed; sessions joining meanwhile wait for it.
+	reconnecting bool
+}
+
+func newUpstreamHub(e *Engine) *upstreamHub {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &upstreamHub{e: e, ctx: ctx, cancel: cancel, sessions: make(map[*session]struct{})}
+}
+
+// join attaches a session to the shared upstream, dialing it if no connection is up.
+func (h *upstreamHub) join(s *session) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conn == nil && !h.reconnecting {
+		secret, target, err := h.e.cfg.upstreams.dial()
+		if err != nil {
+			return err
+		}
+		if err := h.connect(secret, target); err != nil {
+			return err
+		}
+	}
+	s.setUpstream(h.conn)
+	s.inbox = make(chan upstreamFrame, sessionInboxSize)
+	h.sessions[s] = struct{}{}
+	return nil
+}
+
+func (h *upstreamHub) leave(s *session) {
+	h.mu.Lock()
+	delete(h.sessions, s)
+	h.mu.Unlock()
+}
+
+// receive fans a validator frame out to every session.
+func (h *upstreamHub) receive(chID byte, payload []byte) {
+	frame := upstreamFrame{chID: chID, payload: append([]byte(nil), payload...)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.sessions {
+		select {
+		case s.inbox <- frame:
+		default:
+			s.logger.Warn("peer session is falling behind; dropped upstream frame", "channel", fmt.Sprintf("0x%X", chID))
+		}
+	}
+}
+
+// connect starts the shared connection over secret. Callers hold mu.
+func (h *upstreamHub) connect(secret *p2pconn.SecretConnection, target UpstreamTarget) error {
+	var conn *p2pconn.MConnection
+	conn = newMConnection(secret, h.receive, func(err any) {
+		h.fail(conn, fmt.Errorf("%s mconnection error: %v", directionUpstream, err))
+	})
+	if err := conn.Start(); err != nil {
+		return fmt.Errorf("failed to start shared upstream connection: %w", err)
+	}
+	h.conn = conn
+	h.e.cfg.Logger.Info("shared upstream connected", "upstream", target)
+	return nil
+}
+
+// fail handles a broken shared upstream. With Reconnect the sessions stay up while it is redialed; otherwise
+// every session ends and the next peer to connect dials again.
+func (h *upstreamHub) fail(conn *p2pconn.MConnection, err error) {
+	h.mu.Lock()
+	if h.conn != conn {
+		h.mu.Unlock()
+		return
+	}
+	h.conn = nil
+	sessions := make([]*session, 0, len(h.sessions))
+	for s := range h.sessions {
+		sessions = append(sessions, s)
+	}
+	if h.e.cfg.Reconnect != nil {
+		h.reconnecting = true
+		for _, s := range sessions {
+			s.setUpstream(nil)
+		}
+		h.mu.Unlock()
+		h.e.cfg.Logger.Warn("shared upstream lost; reconnecting", "err", err)
+		go h.reconnect()
+		return
+	}
+	h.mu.Unlock()
+
+	h.e.cfg.Logger.Warn("shared upstream failed", "err", err)
+	for _, s := range sessions {
+		s.recordError(err)
+	}
+}
+
+// reconnect redials the shared upstream and reattaches every session, or ends them when it gives up.
+func (h *upstreamHub) reconnect() {
+	secret, target, err := h.e.cfg.upstreams.redial(h.ctx, *h.e.cfg.Reconnect)
+	h.mu.Lock()
+	h.reconnecting = false
+	if err == nil {
+		err = h.connect(secret, target)
+	}
+	sessions := make([]*session, 0, len(h.sessions))
+	for s := range h.sessions {
+		sessions = append(sessions, s)
+		s.setUpstream(h.conn)
+	}
+	h.mu.Unlock()
+
+	if err != nil {
+		err = fmt.Errorf("shared upstream reconnect failed: %w", err)
+		h.e.cfg.Logger.Warn("shared upstream failed", "err", err)
+		for _, s := range sessions {
+			s.recordError(err)
+		}
+		return
+	}
+	h.e.cfg.Logger.Info("shared upstream reconnected", "peers", len(sessions))
+}
+
+// close stops the shared connection when the engine shuts down.
+func (h *upstreamHub) close() {
+	h.cancel()
+	h.mu.Lock()
+	conn := h.conn
+	h.conn = nil
+	h.mu.Unlock()
+	if conn != nil {
+		conn.FlushStop()
+	}
+}
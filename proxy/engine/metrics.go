@@ -1,42 +1,105 @@
 package engine
 
-import "sync/atomic"
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
 
-// Metrics tracks runtime counters for the proxy.
-type Metrics struct {
-	mutated    atomic.Int64
-	dropped    atomic.Int64
-	duplicated atomic.Int64
-	delayed    atomic.Int64
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Message events counted per series by Record.
+const (
+	EventForwarded  = "forwarded"
+	EventMutated    = "mutated"
+	EventDropped    = "dropped"
+	EventDelayed    = "delayed"
+	EventDuplicated = "duplicated"
+	EventSkewed     = "skewed"
+)
+
+var metricEvents = []string{EventForwarded, EventMutated, EventDropped, EventDelayed, EventDuplicated, EventSkewed}
+
+// latencyBuckets are the upper bounds, in seconds, of the added latency histogram.
+var latencyBuckets = []float64{0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metricLabels are the Prometheus labels of every series, in the order values returns them.
+var metricLabels = []string{"channel", "direction", "type", "action"}
+
+// MessageLabels identifies a metrics series. Type is empty for frames the proxy did not decode, and Action is
+// empty for traffic forwarded without the byzantine action.
+type MessageLabels struct {
+	Channel   byte
+	Direction string
+	Type      string
+	Action    string
 }
 
-// NewMetrics creates an empty metrics handle.
-func NewMetrics() *Metrics {
-	return &Metrics{}
+func (l MessageLabels) values() []string {
+	return []string{fmt.Sprintf("0x%02X", l.Channel), l.Direction, l.Type, l.Action}
 }
 
-func (m *Metrics) IncMutated(delta int64) {
-	if m != nil && delta != 0 {
-		m.mutated.Add(delta)
-	}
+// Metrics tracks runtime counters for the proxy. Each Metrics has its own registry, so several proxies in
+// one process do not share series.
+type Metrics struct {
+	registry *prometheus.Registry
+	messages map[string]*prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+
+	mu sync.Mutex
+	// channels sums every event per channel for ChannelCounts and Snapshot.
+	channels map[byte]map[string]int64
 }
 
-func (m *Metrics) IncDropped() {
-	if m != nil {
-		m.dropped.Add(1)
+// NewMetrics creates an empty metrics handle.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		messages: make(map[string]*prometheus.CounterVec, len(metricEvents)),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "byzproxy_added_latency_seconds",
+			Help:    "Time the proxy held a consensus message before delivering it.",
+			Buckets: latencyBuckets,
+		}, metricLabels),
+		channels: make(map[byte]map[string]int64),
 	}
+	for _, event := range metricEvents {
+		m.messages[event] = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "byzproxy_messages_" + event + "_total",
+			Help: "Consensus proxy messages " + event + ", by channel, direction, message type, and byzantine action.",
+		}, metricLabels)
+		m.registry.MustRegister(m.messages[event])
+	}
+	m.registry.MustRegister(m.latency)
+	return m
 }
 
-func (m *Metrics) IncDuplicated(count int) {
-	if m != nil && count > 0 {
-		m.duplicated.Add(int64(count))
+// Record counts n messages for an event in the series named by labels.
+func (m *Metrics) Record(event string, labels MessageLabels, n int) {
+	if m == nil || n <= 0 {
+		return
+	}
+	if counter, ok := m.messages[event]; ok {
+		counter.WithLabelValues(labels.values()...).Add(float64(n))
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := m.channels[labels.Channel]
+	if counts == nil {
+		counts = make(map[string]int64)
+		m.channels[labels.Channel] = counts
+	}
+	counts[event] += int64(n)
 }
 
-func (m *Metrics) IncDelayed() {
-	if m != nil {
-		m.delayed.Add(1)
+// ObserveLatency records the time the proxy held a message before delivering it.
+func (m *Metrics) ObserveLatency(labels MessageLabels, d time.Duration) {
+	if m == nil {
+		return
 	}
+	m.latency.WithLabelValues(labels.values()...).Observe(d.Seconds())
 }
 
 // Snapshot returns the current counter values.
@@ -44,10 +107,18 @@ func (m *Metrics) Snapshot() map[string]int64 {
 	if m == nil {
 		return nil
 	}
-	return map[string]int64{
-		"mutated":    m.mutated.Load(),
-		"dropped":    m.dropped.Load(),
-		"duplicated": m.duplicated.Load(),
-		"delayed":    m.delayed.Load(),
+	snapshot := map[string]int64{EventMutated: 0, EventDropped: 0, EventDuplicated: 0, EventDelayed: 0, EventSkewed: 0}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, counts := range m.channels {
+		for event := range snapshot {
+			snapshot[event] += counts[event]
+		}
 	}
+	return snapshot
+}
+
+// Handler serves the metrics for a Prometheus scrape.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
 }
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"codec/message/abstraction"
+)
+
+// Partition drops all traffic between the validator and its peers in Direction while it is in effect, on
+// every channel, and then heals. It is in effect from After until After+For once the proxy runs (For 0 means
+// until the proxy stops), and, when Trigger has height or round conditions, only while the consensus round
+// the proxy last saw meets them. Other trigger conditions are ignored.
+type Partition struct {
+	Direction Direction
+	After     time.Duration
+	For       time.Duration
+	Trigger   Trigger
+}
+
+func (p Partition) validate() error {
+	if p.After < 0 || p.For < 0 {
+		return fmt.Errorf("partition window must not be negative")
+	}
+	if p.After == 0 && p.For == 0 && !p.hasRoundCondition() {
+		return fmt.Errorf("partition needs a time window or a height range")
+	}
+	return nil
+}
+
+func (p Partition) hasRoundCondition() bool {
+	t := p.Trigger
+	return t.Height != nil || t.MinHeight != nil || t.MaxHeight != nil || t.Round != nil
+}
+
+// partitionState tracks whether the partition is in effect and logs when it starts and heals.
+type partitionState struct {
+	p      Partition
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	started time.Time
+	// height and round are the highest consensus round seen in either direction.
+	height, round int64
+	seen          bool
+	active        bool
+}
+
+func newPartitionState(p Partition, logger *slog.Logger) *partitionState {
+	p.Trigger = normalizeTrigger(Trigger{Height: p.Trigger.Height, MinHeight: p.Trigger.MinHeight, MaxHeight: p.Trigger.MaxHeight, Round: p.Trigger.Round})
+	return &partitionState{p: p, logger: logger}
+}
+
+// start begins the partition's time window.
+func (ps *partitionState) start(now time.Time) {
+	if ps == nil {
+		return
+	}
+	ps.mu.Lock()
+	ps.started = now
+	ps.mu.Unlock()
+}
+
+// observe advances the consensus round from a frame on chID when the partition depends on it. Frames are
+// observed before they are dropped, so the partition also heals when it cuts both directions.
+func (ps *partitionState) observe(chID byte, payload []byte) {
+	if ps == nil || !ps.p.hasRoundCondition() || !isConsensusChannel(chID) {
+		return
+	}
+	msg, err := decodeConsensusMessage(payload)
+	if err != nil {
+		return
+	}
+	adapterMsg, _, err := adapterMessageFromConsensus(msg)
+	if err != nil {
+		return
+	}
+	height, err := strconv.ParseInt(adapterMsg.Height, 10, 64)
+	if err != nil {
+		return
+	}
+	round, _ := strconv.ParseInt(adapterMsg.Round, 10, 64)
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if !ps.seen || height > ps.height || (height == ps.height && round > ps.round) {
+		ps.height, ps.round, ps.seen = height, round, true
+	}
+}
+
+// blocks reports whether a frame travelling in flow is dropped by the partition at now.
+func (ps *partitionState) blocks(flow flowDirection, now time.Time) bool {
+	if ps == nil {
+		return false
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	active := !ps.started.IsZero() && !now.Before(ps.started.Add(ps.p.After))
+	if active && ps.p.For > 0 && !now.Before(ps.started.Add(ps.p.After+ps.p.For)) {
+		active = false
+	}
+	if active && ps.p.hasRoundCondition() {
+		active = ps.seen && ps.p.Trigger.Matches(&abstraction.CanonicalMessage{Height: big.NewInt(ps.height), Round: big.NewInt(ps.round)})
+	}
+	if active != ps.active {
+		ps.active = active
+		if active {
+			ps.logger.Info("network partition started", "direction", ps.p.Direction, "height", ps.height, "round", ps.round)
+		} else {
+			ps.logger.Info("network partition healed", "direction", ps.p.Direction, "height", ps.height, "round", ps.round)
+		}
+	}
+	if !active {
+		return false
+	}
+	if flow == directionUpstream {
+		return ps.p.Direction.ShouldMutateUpstream()
+	}
+	return ps.p.Direction.ShouldMutateDownstream()
+}
@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PeerPolicy is how the proxy treats one downstream peer. It is derived from the configuration when the peer
+// connects and can be changed while the peer stays connected.
+type PeerPolicy struct {
+	// Targeted peers have the byzantine action and hooks applied; other peers receive traffic unmodified.
+	Targeted bool `json:"targeted"`
+	// Variant picks the message this peer receives when SplitPeers is set and an action emits several.
+	Variant int `json:"variant"`
+	// SkewToValidator and SkewToPeer are the victim clock offsets for traffic delivered to each side.
+	SkewToValidator time.Duration `json:"skew_to_validator"`
+	SkewToPeer      time.Duration `json:"skew_to_peer"`
+}
+
+// PeerStatus describes a connected downstream peer.
+type PeerStatus struct {
+	NodeID    string     `json:"node_id"`
+	Remote    string     `json:"remote"`
+	Connected time.Time  `json:"connected"`
+	Policy    PeerPolicy `json:"policy"`
+}
+
+func (s *session) currentPolicy() PeerPolicy {
+	s.policyMu.RLock()
+	defer s.policyMu.RUnlock()
+	return s.policy
+}
+
+func (s *session) setPolicy(policy PeerPolicy) {
+	s.policyMu.Lock()
+	s.policy = policy
+	s.policyMu.Unlock()
+}
+
+// matches reports whether id names this session's peer by node ID, address, or host.
+func (s *session) matches(id string) bool {
+	for _, identity := range s.identities {
+		if identity != "" && strings.EqualFold(strings.TrimSpace(id), identity) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Engine) addSession(s *session) {
+	e.sessionsMu.Lock()
+	e.sessions[s] = struct{}{}
+	e.sessionsMu.Unlock()
+	e.firstPeerOnce.Do(func() { close(e.firstPeer) })
+}
+
+func (e *Engine) removeSession(s *session) {
+	e.sessionsMu.Lock()
+	delete(e.sessions, s)
+	e.sessionsMu.Unlock()
+}
+
+// Peers lists the connected downstream peers and their policies in connection order.
+func (e *Engine) Peers() []PeerStatus {
+	e.sessionsMu.Lock()
+	sessions := make([]*session, 0, len(e.sessions))
+	for s := range e.sessions {
+		sessions = append(sessions, s)
+	}
+	e.sessionsMu.Unlock()
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].connected.Before(sessions[j].connected) })
+	peers := make([]PeerStatus, len(sessions))
+	for i, s := range sessions {
+		peers[i] = PeerStatus{NodeID: s.nodeID, Remote: s.remote, Connected: s.connected, Policy: s.currentPolicy()}
+	}
+	return peers
+}
+
+// SetPeerPolicy replaces the policy of every connected peer that id names (node ID, address, or host) and
+// returns how many peers were updated.
+func (e *Engine) SetPeerPolicy(id string, policy PeerPolicy) (int, error) {
+	if policy.Variant < 0 {
+		return 0, fmt.Errorf("variant must not be negative")
+	}
+	e.sessionsMu.Lock()
+	defer e.sessionsMu.Unlock()
+	updated := 0
+	for s := range e.sessions {
+		if s.matches(id) {
+			s.setPolicy(policy)
+			updated++
+		}
+	}
+	if updated == 0 {
+		return 0, fmt.Errorf("no connected peer matches %q", id)
+	}
+	e.cfg.Logger.Info("peer policy updated", "peer", id, "targeted", policy.Targeted, "variant", policy.Variant, "skew_to_validator", policy.SkewToValidator, "skew_to_peer", policy.SkewToPeer)
+	return updated, nil
+}
+
+// StatusHandler serves the proxy's runtime state: GET /peers lists connected peers with their policies,
+// PUT /peers/{id} replaces the policy of the peers id names, GET /attack and PUT /attack read and change the
+// live attack settings, and GET /metrics is the Prometheus scrape.
+func (e *Engine) StatusHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", e.metrics.Handler())
+	mux.HandleFunc("GET /attack", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, e.Attack())
+	})
+	mux.HandleFunc("PUT /attack", func(w http.ResponseWriter, r *http.Request) {
+		// The body is decoded over the current settings, so omitted fields keep their values.
+		attack := e.Attack()
+		if err := json.NewDecoder(r.Body).Decode(&attack); err != nil {
+			http.Error(w, fmt.Sprintf("invalid attack: %v", err), http.StatusBadRequest)
+			return
+		}
+		applied, err := e.SetAttack(attack)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, applied)
+	})
+	mux.HandleFunc("GET /activity", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, e.Activity())
+	})
+	mux.HandleFunc("GET /peers", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, e.Peers())
+	})
+	mux.HandleFunc("PUT /peers/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var policy PeerPolicy
+		if err := json.NewDecoder(r.Body).Decode(&policy); err != nil || policy.Variant < 0 {
+			http.Error(w, fmt.Sprintf("invalid policy: %v", err), http.StatusBadRequest)
+			return
+		}
+		if _, err := e.SetPeerPolicy(r.PathValue("id"), policy); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, e.Peers())
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
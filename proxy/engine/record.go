@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"codec/capture"
+	"codec/message/abstraction"
+)
+
+// Capture events written by the Recorder.
+const (
+	// RecordReceived is a consensus message as the proxy received it.
+	RecordReceived = "received"
+	// RecordMutated is a message the proxy sent in place of a received one: the output of the byzantine
+	// action, a duplicate, or a copy with a skewed timestamp.
+	RecordMutated = "mutated"
+	// RecordDropped is a received message the drop hook discarded.
+	RecordDropped = "dropped"
+)
+
+// Recorder appends the consensus traffic the proxy intercepts to a capture file, one JSON line per message,
+// so an experiment can be analyzed offline or replayed. It is safe for use by every peer session.
+type Recorder struct {
+	mu sync.Mutex
+	w  *capture.Writer
+}
+
+// OpenRecorder appends to the capture at path, creating it when missing.
+func OpenRecorder(path string) (*Recorder, error) {
+	w, err := capture.Append(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{w: w}, nil
+}
+
+// Write appends rec and flushes it, so the capture stays complete if the proxy is killed.
+func (r *Recorder) Write(rec *capture.Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.w.Write(rec); err != nil {
+		return err
+	}
+	return r.w.Flush()
+}
+
+// Close flushes the capture and writes its height index.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.w.Close()
+}
+
+// record writes a frame sent or received on labels.Channel to the configured recorder. The wire bytes are
+// kept as the raw message; the canonical form is added when the frame still decodes.
+func (s *session) record(event string, labels MessageLabels, payload []byte, received time.Time) {
+	if s.cfg.Recorder == nil {
+		return
+	}
+	rec := &capture.Record{
+		Time:   time.Now().UTC(),
+		Source: s.remote,
+		Raw: &abstraction.RawConsensusMessage{
+			ChainType: abstraction.ChainTypeCometBFT,
+			ChainID:   s.cfg.ChainID,
+			Payload:   append([]byte(nil), payload...),
+			Encoding:  "proto",
+			Timestamp: received.UTC(),
+		},
+		Direction: labels.Direction,
+		Channel:   labels.Channel,
+		Event:     event,
+		Action:    labels.Action,
+	}
+	if msg, err := decodeConsensusMessage(payload); err == nil {
+		if canonical, err := canonicalFromConsensus(nil, s.mapper, s.cfg.ChainID, msg); err == nil {
+			rec.Canonical = canonical
+			rec.Raw.MessageType = messageKind(canonical)
+		}
+	}
+	if err := s.cfg.Recorder.Write(rec); err != nil {
+		s.logger.Warn("failed to record message", "event", event, "channel", fmt.Sprintf("0x%X", labels.Channel), "err", err)
+	}
+}
@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"codec/capture"
+	p2pconn "github.com/cometbft/cometbft/p2p/conn"
+)
+
+// ReplayOptions re-injects traffic recorded with --record into the running proxy.
+type ReplayOptions struct {
+	Path string
+	// Direction selects the recorded flows to replay, as --mutate-direction does for live traffic: upstream
+	// re-sends the validator's messages to the connected peers, downstream re-sends the peers' messages to the
+	// validator, and both sends every message the way it originally went.
+	Direction Direction
+	// Speed scales the recorded gaps between messages: 1 keeps the original timing and 2 replays twice as
+	// fast. 0 sends the messages back to back.
+	Speed float64
+	// Mutate runs replayed consensus messages through the live attack, its trigger, and the hooks, as if the
+	// proxy had just received them; otherwise they are sent as recorded.
+	Mutate bool
+}
+
+func (o ReplayOptions) validate() error {
+	if o.Path == "" {
+		return fmt.Errorf("replay needs a capture file")
+	}
+	if o.Speed < 0 {
+		return fmt.Errorf("replay speed must not be negative")
+	}
+	return nil
+}
+
+// replay waits for the first peer and then sends the received messages of the capture toward their original
+// destination on every connected session. Messages the proxy itself produced, recorded as mutated or dropped,
+// are not replayed.
+func (e *Engine) replay(ctx context.Context, opts ReplayOptions) error {
+	r, err := capture.Open(opts.Path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-e.firstPeer:
+	}
+	e.cfg.Logger.Info("replay started", "path", opts.Path, "speed", opts.Speed, "mutate", opts.Mutate)
+
+	var last time.Time
+	sent, skipped := 0, 0
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		flow, ok := replayFlow(rec, opts.Direction)
+		if !ok {
+			continue
+		}
+		// Only the proxy records wire bytes and the channel; other captures carry nothing to send.
+		if rec.Raw == nil || rec.Raw.Encoding != "proto" || len(rec.Raw.Payload) == 0 || rec.Channel == 0 {
+			skipped++
+			continue
+		}
+
+		at := rec.Raw.Timestamp
+		if at.IsZero() {
+			at = rec.Time
+		}
+		if !last.IsZero() && opts.Speed > 0 && at.After(last) {
+			gap := time.Duration(float64(at.Sub(last)) / opts.Speed)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(gap):
+			}
+		}
+		last = at
+
+		e.inject(flow, rec.Channel, rec.Raw.Payload, opts.Mutate)
+		sent++
+	}
+	e.cfg.Logger.Info("replay finished", "path", opts.Path, "sent", sent, "skipped", skipped)
+	return nil
+}
+
+// replayFlow returns the flow a record travelled and whether direction selects it. Records without a
+// direction count as traffic toward the validator.
+func replayFlow(rec *capture.Record, direction Direction) (flowDirection, bool) {
+	if rec.Event != "" && rec.Event != RecordReceived {
+		return "", false
+	}
+	if flowDirection(rec.Direction) == directionUpstream {
+		return directionUpstream, direction.ShouldMutateUpstream()
+	}
+	return directionDownstream, direction.ShouldMutateDownstream()
+}
+
+// inject sends a recorded frame along flow on every connected session. Sessions sharing the multiplexed
+// upstream send frames toward the validator once.
+func (e *Engine) inject(flow flowDirection, chID byte, payload []byte, mutate bool) {
+	e.sessionsMu.Lock()
+	sessions := make([]*session, 0, len(e.sessions))
+	for s := range e.sessions {
+		sessions = append(sessions, s)
+	}
+	e.sessionsMu.Unlock()
+
+	seen := make(map[*p2pconn.MConnection]bool)
+	for _, s := range sessions {
+		target := s.downstream
+		if flow == directionDownstream {
+			target = s.upstreamConn()
+		}
+		if target == nil || seen[target] {
+			continue
+		}
+		seen[target] = true
+		if mutate && isConsensusChannel(chID) {
+			if err := s.processConsensus(flow, chID, payload, target, s.currentPolicy(), true, 0); err != nil {
+				s.logger.Warn("failed to process replayed consensus message", "err", err)
+				s.forwardRaw(target, MessageLabels{Channel: chID}, payload)
+			}
+			continue
+		}
+		s.forwardRaw(target, MessageLabels{Channel: chID}, payload)
+	}
+}
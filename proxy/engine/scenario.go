@@ -0,0 +1,228 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	cometbftAdapter "codec/cometbft/adapter"
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+	"codec/scenario"
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is an ordered list of attack phases loaded with --scenario. The proxy starts in the first phase
+// and moves to the next when the current one ends; after the last phase it forwards traffic unmodified.
+type Scenario struct {
+	Name   string  `json:"name"`
+	Phases []Phase `json:"phases"`
+}
+
+// Phase is one attack in a scenario. A phase ends when its duration elapses, when it has attacked Messages
+// messages, or when traffic moves past the end of its height range, whichever comes first; a phase with none
+// of these lasts until the proxy stops.
+type Phase struct {
+	Name string `json:"name"`
+	// Heights restricts the phase to a height ("12") or an inclusive height range ("10..20").
+	Heights string `json:"heights,omitempty"`
+	Round   *int64 `json:"round,omitempty"`
+	Step    string `json:"step,omitempty"`
+	// Validators limits the phase to messages signed by these validators (hex addresses or indexes).
+	Validators []string `json:"validators,omitempty"`
+	// Every and Probability thin out the matching messages as the trigger fields of the same name do.
+	Every       int     `json:"every,omitempty"`
+	Probability float64 `json:"probability,omitempty"`
+	// Messages is the number of triggered messages, counted across every peer session, that end the phase.
+	Messages int               `json:"messages,omitempty"`
+	Duration scenario.Duration `json:"duration,omitempty"`
+
+	Action    string            `json:"action,omitempty"`
+	Options   *ActionOptions    `json:"options,omitempty"`
+	Delay     scenario.Duration `json:"delay,omitempty"`
+	Drop      bool              `json:"drop,omitempty"`
+	Duplicate bool              `json:"duplicate,omitempty"`
+}
+
+// LoadScenario reads a scenario from a YAML or JSON file; the format is chosen by extension and JSON is
+// assumed for anything but .yaml and .yml.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// Going through JSON keeps one set of field names and the duration strings' decoding.
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("parse scenario %s: %w", path, err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("parse scenario %s: %w", path, err)
+		}
+	}
+	var sc Scenario
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("parse scenario %s: %w", path, err)
+	}
+	if err := sc.Validate(); err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", path, err)
+	}
+	return &sc, nil
+}
+
+// Validate checks every phase can be turned into an attack.
+func (sc *Scenario) Validate() error {
+	if len(sc.Phases) == 0 {
+		return fmt.Errorf("scenario has no phases")
+	}
+	for i, phase := range sc.Phases {
+		if _, err := phase.attack(); err != nil {
+			return fmt.Errorf("phase %d (%s): %w", i+1, phase.Name, err)
+		}
+	}
+	return nil
+}
+
+// attack converts the phase into the attack settings it installs.
+func (p Phase) attack() (Attack, error) {
+	action, err := cometbftAdapter.ParseByzantineAction(p.Action)
+	if err != nil {
+		return Attack{}, err
+	}
+	if p.Messages < 0 || p.Duration < 0 || p.Delay < 0 {
+		return Attack{}, fmt.Errorf("messages, duration, and delay must not be negative")
+	}
+	trigger := Trigger{Round: p.Round, Step: p.Step, Validators: p.Validators, Every: p.Every, Probability: p.Probability}
+	if strings.TrimSpace(p.Heights) != "" {
+		from, to, err := byzantine.ParseRange(p.Heights)
+		if err != nil {
+			return Attack{}, fmt.Errorf("heights: %w", err)
+		}
+		trigger.MinHeight, trigger.MaxHeight = &from, &to
+	}
+	if err := trigger.validate(); err != nil {
+		return Attack{}, err
+	}
+	return Attack{
+		Action:    action,
+		Trigger:   normalizeTrigger(trigger),
+		Delay:     time.Duration(p.Delay),
+		Drop:      p.Drop,
+		Duplicate: p.Duplicate,
+		Options:   p.Options,
+	}, nil
+}
+
+// scenarioRunner moves the engine through a scenario's phases.
+type scenarioRunner struct {
+	e  *Engine
+	sc *Scenario
+
+	mu        sync.Mutex
+	phase     int
+	triggered int
+	// maxHeight is the end of the current phase's height range, if it has one.
+	maxHeight *int64
+	timer     *time.Timer
+}
+
+func newScenarioRunner(e *Engine, sc *Scenario) *scenarioRunner {
+	return &scenarioRunner{e: e, sc: sc, phase: -1}
+}
+
+// start enters the first phase and stops the phase timer when ctx is cancelled.
+func (r *scenarioRunner) start(ctx context.Context) {
+	r.mu.Lock()
+	r.enter(0)
+	r.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.timer != nil {
+			r.timer.Stop()
+		}
+	}()
+}
+
+// observe ends the current phase once traffic has moved past the end of its height range.
+func (r *scenarioRunner) observe(msg *abstraction.CanonicalMessage) {
+	if r == nil || msg == nil || msg.Height == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.phase < 0 || r.phase >= len(r.sc.Phases) {
+		return
+	}
+	if r.maxHeight != nil && msg.Height.Int64() > *r.maxHeight {
+		r.end("height range passed")
+	}
+}
+
+// trigger counts a message the current phase attacked and ends the phase when it reaches its message count.
+func (r *scenarioRunner) trigger() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.phase < 0 || r.phase >= len(r.sc.Phases) {
+		return
+	}
+	r.triggered++
+	if limit := r.sc.Phases[r.phase].Messages; limit > 0 && r.triggered >= limit {
+		r.end("message count reached")
+	}
+}
+
+// end leaves the current phase for the next one. The caller holds r.mu.
+func (r *scenarioRunner) end(reason string) {
+	phase := r.sc.Phases[r.phase]
+	r.e.cfg.Logger.Info("scenario phase ended", "scenario", r.sc.Name, "phase", r.phase+1, "name", phase.Name, "reason", reason, "triggered", r.triggered)
+	r.enter(r.phase + 1)
+}
+
+// enter installs phase i, or stops attacking when the scenario is over. The caller holds r.mu.
+func (r *scenarioRunner) enter(i int) {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	r.phase, r.triggered, r.maxHeight = i, 0, nil
+	if i >= len(r.sc.Phases) {
+		_, _ = r.e.applyAttack(Attack{Action: cometbftAdapter.ByzantineActionNone})
+		r.e.cfg.Logger.Info("scenario finished", "scenario", r.sc.Name, "phases", len(r.sc.Phases))
+		return
+	}
+
+	phase := r.sc.Phases[i]
+	attack, err := phase.attack()
+	if err == nil {
+		attack, err = r.e.applyAttack(attack)
+	}
+	if err != nil {
+		// Validate rejects such phases up front; skip rather than stall if one slipped through.
+		r.e.cfg.Logger.Error("scenario phase skipped", "scenario", r.sc.Name, "phase", i+1, "name", phase.Name, "err", err)
+		r.enter(i + 1)
+		return
+	}
+	r.maxHeight = attack.Trigger.MaxHeight
+	r.e.cfg.Logger.Info("scenario phase started", "scenario", r.sc.Name, "phase", i+1, "name", phase.Name, "attack", attack.Action, "trigger", attack.Trigger.String(), "messages", phase.Messages, "duration", time.Duration(phase.Duration))
+	if phase.Duration > 0 {
+		r.timer = time.AfterFunc(time.Duration(phase.Duration), func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.phase == i {
+				r.end("duration elapsed")
+			}
+		})
+	}
+}
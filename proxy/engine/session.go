@@ -10,16 +10,24 @@ import (
 	"time"
 
 	cometbftAdapter "codec/cometbft/adapter"
+	"codec/experiment"
 	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+	cmtlog "github.com/cometbft/cometbft/libs/log"
 	p2pconn "github.com/cometbft/cometbft/p2p/conn"
+	bcproto "github.com/cometbft/cometbft/proto/tendermint/blocksync"
 	consensuspb "github.com/cometbft/cometbft/proto/tendermint/consensus"
-	evidpb "github.com/cometbft/cometbft/proto/tendermint/evidence"
 	mempoolpb "github.com/cometbft/cometbft/proto/tendermint/mempool"
+	ssproto "github.com/cometbft/cometbft/proto/tendermint/statesync"
+	cmtproto "github.com/cometbft/cometbft/proto/tendermint/types"
 )
 
 const (
-	mempoolChannelID  byte = 0x30
-	evidenceChannelID byte = 0x38
+	mempoolChannelID   byte = 0x30
+	evidenceChannelID  byte = 0x38
+	blocksyncChannelID byte = 0x40
+	snapshotChannelID  byte = 0x60
+	chunkChannelID     byte = 0x61
 )
 
 type session struct {
@@ -31,54 +39,87 @@ type session struct {
 	metrics *Metrics
 
 	downstream *p2pconn.MConnection
+	// upstream is replaced when Reconnect redials and is nil while it does; read it with upstreamConn.
+	upstreamMu sync.RWMutex
 	upstream   *p2pconn.MConnection
 
+	// inbox receives frames from a shared upstream; it is nil when the session owns its upstream connection.
+	inbox chan upstreamFrame
+
+	// identities are the names a policy update may address this peer by; see peerIdentities.
+	identities []string
+	nodeID     string
+	remote     string
+	connected  time.Time
+
+	policyMu sync.RWMutex
+	policy   PeerPolicy
+
+	// shapers delay frames per flow when Hooks.Shaping is enabled.
+	shapersMu sync.Mutex
+	shapers   map[flowDirection]*linkShaper
+
 	logger  *slog.Logger
 	errOnce sync.Once
 	err     error
 }
 
+// newSession creates a session that owns its upstream connection.
 func newSession(ctx context.Context, cancel context.CancelFunc, cfg *Config, mapper *cometbftAdapter.CometBFTMapper, metrics *Metrics, downstream, upstream net.Conn) *session {
+	s := newPeerSession(ctx, cancel, cfg, mapper, metrics, downstream)
+	s.upstream = s.connectUpstream(upstream)
+	return s
+}
+
+// newPeerSession creates the downstream half of a session; the caller attaches the upstream. The policy
+// starts out targeting the peer with no skew.
+func newPeerSession(ctx context.Context, cancel context.CancelFunc, cfg *Config, mapper *cometbftAdapter.CometBFTMapper, metrics *Metrics, downstream net.Conn) *session {
 	s := &session{
-		ctx:     ctx,
-		cancel:  cancel,
-		cfg:     cfg,
-		mapper:  mapper,
-		metrics: metrics,
-		logger:  cfg.Logger.With("remote", downstream.RemoteAddr().String()),
+		ctx:       ctx,
+		cancel:    cancel,
+		cfg:       cfg,
+		mapper:    mapper,
+		metrics:   metrics,
+		remote:    downstream.RemoteAddr().String(),
+		connected: time.Now().UTC(),
+		policy:    PeerPolicy{Targeted: true},
+		logger:    cfg.Logger.With("remote", downstream.RemoteAddr().String()),
 	}
 
 	downRecv := func(chID byte, payload []byte) {
 		s.handleDownstream(chID, payload)
 	}
-	upRecv := func(chID byte, payload []byte) {
-		s.handleUpstream(chID, payload)
-	}
-	onError := func(direction flowDirection) func(any) {
-		return func(err any) {
-			s.recordError(fmt.Errorf("%s mconnection error: %v", direction, err))
-		}
-	}
-
-	s.downstream = p2pconn.NewMConnection(downstream, defaultDescriptors(), downRecv, onError(directionDownstream))
-	s.upstream = p2pconn.NewMConnection(upstream, defaultDescriptors(), upRecv, onError(directionUpstream))
-
+	s.downstream = newMConnection(downstream, downRecv, s.onError(directionDownstream))
 	return s
 }
 
+func (s *session) onError(direction flowDirection) func(any) {
+	return func(err any) {
+		s.recordError(fmt.Errorf("%s mconnection error: %v", direction, err))
+	}
+}
+
 func (s *session) run() error {
 	if err := s.downstream.Start(); err != nil {
 		s.recordError(err)
 		return err
 	}
-	if err := s.upstream.Start(); err != nil {
-		s.downstream.Stop()
-		s.recordError(err)
-		return err
-	}
-
 	defer s.downstream.FlushStop()
-	defer s.upstream.FlushStop()
+
+	if s.inbox != nil {
+		// The shared upstream belongs to the hub; this session only drains its share of the traffic.
+		go s.drainInbox()
+	} else {
+		if err := s.upstream.Start(); err != nil {
+			s.recordError(err)
+			return err
+		}
+		defer func() {
+			if upstream := s.upstreamConn(); upstream != nil {
+				upstream.FlushStop()
+			}
+		}()
+	}
 
 	<-s.ctx.Done()
 	if s.err != nil {
@@ -87,66 +128,140 @@ func (s *session) run() error {
 	return s.ctx.Err()
 }
 
+func (s *session) drainInbox() {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case frame := <-s.inbox:
+			s.handleUpstream(frame.chID, frame.payload)
+		}
+	}
+}
+
 func (s *session) handleDownstream(chID byte, payload []byte) {
-	if s.cfg.Direction.ShouldMutateDownstream() && isConsensusChannel(chID) {
-		if err := s.processConsensus(directionDownstream, chID, payload, s.upstream); err != nil {
+	meter := s.meter(directionDownstream)
+	defer meter.Track()()
+	meter.Observe(len(payload), 0)
+	s.cfg.partition.observe(chID, payload)
+	if s.cfg.partition.blocks(directionDownstream, time.Now()) {
+		s.metrics.Record(EventDropped, MessageLabels{Channel: chID, Direction: string(directionDownstream), Action: "partition"}, 1)
+		return
+	}
+	if blocksChannel(s.cfg.BlockedChannels, chID, directionDownstream) {
+		s.metrics.Record(EventDropped, MessageLabels{Channel: chID, Direction: string(directionDownstream)}, 1)
+		return
+	}
+	upstream := s.upstreamConn()
+	if upstream == nil {
+		s.metrics.Record(EventDropped, MessageLabels{Channel: chID, Direction: string(directionDownstream), Action: "reconnect"}, 1)
+		return
+	}
+	if isConsensusChannel(chID) {
+		s.record(RecordReceived, MessageLabels{Channel: chID, Direction: string(directionDownstream)}, payload, time.Now())
+	}
+
+	policy := s.currentPolicy()
+	mutate := s.cfg.Direction.ShouldMutateDownstream()
+	if (mutate || policy.SkewToValidator != 0) && isConsensusChannel(chID) {
+		if err := s.processConsensus(directionDownstream, chID, payload, upstream, policy, mutate, policy.SkewToValidator); err != nil {
 			s.logger.Warn("failed to process downstream consensus message", "err", err)
-			s.forwardRaw(s.upstream, chID, payload)
+			s.forwardRaw(upstream, MessageLabels{Channel: chID}, payload)
 		}
 		return
 	}
-	s.forwardRaw(s.upstream, chID, payload)
+	s.forwardRaw(upstream, MessageLabels{Channel: chID}, payload)
 }
 
 func (s *session) handleUpstream(chID byte, payload []byte) {
-	if s.cfg.Direction.ShouldMutateUpstream() && isConsensusChannel(chID) {
-		if err := s.processConsensus(directionUpstream, chID, payload, s.downstream); err != nil {
+	meter := s.meter(directionUpstream)
+	defer meter.Track()()
+	meter.Observe(len(payload), 0)
+	s.cfg.partition.observe(chID, payload)
+	if s.cfg.partition.blocks(directionUpstream, time.Now()) {
+		s.metrics.Record(EventDropped, MessageLabels{Channel: chID, Direction: string(directionUpstream), Action: "partition"}, 1)
+		return
+	}
+	if blocksChannel(s.cfg.BlockedChannels, chID, directionUpstream) {
+		s.metrics.Record(EventDropped, MessageLabels{Channel: chID, Direction: string(directionUpstream)}, 1)
+		return
+	}
+	if isConsensusChannel(chID) {
+		s.record(RecordReceived, MessageLabels{Channel: chID, Direction: string(directionUpstream)}, payload, time.Now())
+	}
+
+	policy := s.currentPolicy()
+	mutate := s.cfg.Direction.ShouldMutateUpstream()
+	if (mutate || policy.SkewToPeer != 0) && isConsensusChannel(chID) {
+		if err := s.processConsensus(directionUpstream, chID, payload, s.downstream, policy, mutate, policy.SkewToPeer); err != nil {
 			s.logger.Warn("failed to process upstream consensus message", "err", err)
-			s.forwardRaw(s.downstream, chID, payload)
+			s.forwardRaw(s.downstream, MessageLabels{Channel: chID}, payload)
 		}
 		return
 	}
-	s.forwardRaw(s.downstream, chID, payload)
+	s.forwardRaw(s.downstream, MessageLabels{Channel: chID}, payload)
 }
 
-func (s *session) processConsensus(direction flowDirection, chID byte, payload []byte, target *p2pconn.MConnection) error {
+// processConsensus decodes a consensus message, shifts its timestamp by skew when the destination is a clock
+// skew victim, and applies the hooks and byzantine action when mutate is set, the policy targets the peer, and
+// the trigger matches.
+func (s *session) processConsensus(direction flowDirection, chID byte, payload []byte, target *p2pconn.MConnection, policy PeerPolicy, mutate bool, skew time.Duration) error {
+	received := time.Now()
 	msg, err := decodeConsensusMessage(payload)
 	if err != nil {
 		return err
 	}
 
-	canonical, err := canonicalFromConsensus(s.mapper, s.cfg.ChainID, msg)
+	canonical, err := canonicalFromConsensus(s.cfg.pool, s.mapper, s.cfg.ChainID, msg)
 	if err != nil {
 		if errors.Is(err, errUnsupportedMessage) {
-			s.forwardRaw(target, chID, payload)
+			s.forwardRaw(target, MessageLabels{Channel: chID}, payload)
 			return nil
 		}
 		return err
 	}
 
-	if !s.cfg.Trigger.Matches(canonical) {
-		s.forwardRaw(target, chID, payload)
-		return nil
+	s.cfg.runner.observe(canonical)
+	attack := s.cfg.attack()
+	labels := MessageLabels{Channel: chID, Direction: string(direction), Type: messageKind(canonical)}
+	if skew != 0 && s.skewTimestamp(canonical, skew) {
+		s.metrics.Record(EventSkewed, labels, 1)
+	}
+
+	if !mutate || !policy.Targeted || !attack.Trigger.Matches(canonical) || !s.cfg.sample(attack.Trigger) {
+		defer func() { s.metrics.ObserveLatency(labels, time.Since(received)) }()
+		// Nothing keeps the canonical of a message that is only forwarded, so it goes back to the pool.
+		defer s.cfg.pool.PutCanonical(canonical)
+		s.observe(EventForwarded, labels, canonical, received)
+		if skew == 0 {
+			s.forwardRaw(target, labels, payload)
+			return nil
+		}
+		return s.forwardCanonical(target, labels, canonical, received)
 	}
 
-	if s.cfg.Hooks.Delay > 0 {
-		s.metrics.IncDelayed()
-		time.Sleep(s.cfg.Hooks.Delay)
+	s.cfg.runner.trigger()
+	labels.Action = string(attack.Action)
+	if attack.Delay > 0 {
+		s.metrics.Record(EventDelayed, labels, 1)
+		time.Sleep(attack.Delay)
 	}
 
-	if s.cfg.Hooks.Drop {
-		s.metrics.IncDropped()
-		s.logger.Info("dropped consensus message", "direction", direction, "channel", fmt.Sprintf("0x%X", chID), "height", canonicalHeight(canonical), "round", canonicalRound(canonical), "type", canonical.Type)
+	if attack.Drop {
+		s.metrics.Record(EventDropped, labels, 1)
+		s.observe(EventDropped, labels, canonical, received)
+		s.record(RecordDropped, labels, payload, received)
+		s.logger.Info("dropped consensus message", "direction", direction, "channel", fmt.Sprintf("0x%X", chID), "height", canonicalHeight(canonical), "round", canonicalRound(canonical), "type", messageKind(canonical))
 		return nil
 	}
 
-	raws, err := s.applyByzantineAction(canonical)
+	options := attack.Options.apply(s.cfg.Options)
+	raws, err := s.applyByzantineAction(attack.Action, options, canonical)
 	if err != nil {
 		return err
 	}
 
-	sent := 0
-	duplicateCount := 0
+	frames := make([][]byte, 0, len(raws))
 	for _, raw := range raws {
 		protoMsg, err := rawToConsensusMessage(raw)
 		if err != nil {
@@ -156,40 +271,158 @@ func (s *session) processConsensus(direction flowDirection, chID byte, payload [
 		if err != nil {
 			return err
 		}
-		s.forwardRaw(target, chID, bytes)
-		sent++
-		if s.cfg.Hooks.Duplicate {
-			s.forwardRaw(target, chID, bytes)
-			sent++
-			duplicateCount++
+		// Payload actions damage the wire frame itself, which is what peer decoders see.
+		if bytes, err = cometbftAdapter.ByzantineEngine.MutatePayload(attack.Action, bytes, options); err != nil {
+			return err
 		}
+		frames = append(frames, bytes)
+	}
+	if s.cfg.Hooks.SplitPeers && len(frames) > 1 {
+		variant := policy.Variant % len(frames)
+		frames = frames[variant : variant+1]
 	}
 
-	s.metrics.IncMutated(int64(sent))
-	if duplicateCount > 0 {
-		s.metrics.IncDuplicated(duplicateCount)
+	sent := len(frames)
+	duplicateCount := 0
+	if attack.Duplicate {
+		duplicateCount = len(frames)
+		sent += duplicateCount
+	}
+	deliver := func() {
+		for _, frame := range frames {
+			s.forwardRaw(target, labels, frame)
+			s.record(RecordMutated, labels, frame, received)
+			if attack.Duplicate {
+				s.forwardRaw(target, labels, frame)
+				s.record(RecordMutated, labels, frame, received)
+			}
+		}
+		s.metrics.ObserveLatency(labels, time.Since(received))
+	}
+
+	s.metrics.Record(EventMutated, labels, sent)
+	s.metrics.Record(EventDuplicated, labels, duplicateCount)
+	s.observe(EventMutated, labels, canonical, received)
+
+	if delay := s.cfg.Hooks.ValidatorDelays.DelayFor(canonical); delay > 0 {
+		// Deferred delivery keeps the receive routine free so other validators' votes are not held behind this one.
+		s.metrics.Record(EventDelayed, labels, 1)
+		time.AfterFunc(delay, func() {
+			if s.ctx.Err() == nil {
+				deliver()
+			}
+		})
+		s.logger.Info("delayed consensus message", "direction", direction, "channel", fmt.Sprintf("0x%X", chID), "height", canonicalHeight(canonical), "round", canonicalRound(canonical), "type", messageKind(canonical), "validator", canonical.Validator, "delay", delay)
+	} else {
+		deliver()
 	}
 
-	s.logger.Info("mutated consensus message", "direction", direction, "channel", fmt.Sprintf("0x%X", chID), "height", canonicalHeight(canonical), "round", canonicalRound(canonical), "type", canonical.Type, "count", sent, "duplicates", duplicateCount)
+	s.logger.Info("mutated consensus message", "direction", direction, "channel", fmt.Sprintf("0x%X", chID), "height", canonicalHeight(canonical), "round", canonicalRound(canonical), "type", messageKind(canonical), "count", sent, "duplicates", duplicateCount)
 
 	return nil
 }
 
-func (s *session) applyByzantineAction(canonical *abstraction.CanonicalMessage) ([]*abstraction.RawConsensusMessage, error) {
-	if s.cfg.Action == cometbftAdapter.ByzantineActionNone {
+// forwardCanonical re-encodes a canonical message received at received and forwards it.
+func (s *session) forwardCanonical(target *p2pconn.MConnection, labels MessageLabels, canonical *abstraction.CanonicalMessage, received time.Time) error {
+	raw := s.cfg.pool.GetRaw()
+	defer s.cfg.pool.PutRaw(raw)
+	if err := s.mapper.FromCanonicalInto(canonical, raw); err != nil {
+		return err
+	}
+	protoMsg, err := rawToConsensusMessage(raw)
+	if err != nil {
+		return err
+	}
+	bytes, err := marshalConsensusMessage(protoMsg)
+	if err != nil {
+		return err
+	}
+	s.forwardRaw(target, labels, bytes)
+	s.record(RecordMutated, labels, bytes, received)
+	return nil
+}
+
+func (s *session) applyByzantineAction(action cometbftAdapter.ByzantineAction, options cometbftAdapter.ByzantineOptions, canonical *abstraction.CanonicalMessage) ([]*abstraction.RawConsensusMessage, error) {
+	if action == cometbftAdapter.ByzantineActionNone || cometbftAdapter.ByzantineEngine.IsPayloadAction(action) {
 		raw, err := s.mapper.FromCanonical(canonical)
 		if err != nil {
 			return nil, err
 		}
 		return []*abstraction.RawConsensusMessage{raw}, nil
 	}
-	return s.mapper.FromCanonicalByzantine(canonical, s.cfg.Action, s.cfg.Options)
+	canonicals, err := cometbftAdapter.ByzantineEngine.Apply(canonical, action, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.cfg.Options.TargetPeers) > 0 {
+		// The original keeps flowing to untargeted peers, so targeted ones only get the conflicting messages.
+		canonicals = byzantine.Conflicting(canonical, canonicals)
+	}
+	return byzantine.Encode(s.mapper, canonicals)
+}
+
+// forwardRaw sends a frame on labels.Channel and counts it as forwarded; the direction is taken from target.
+// With link shaping the frame is sent once its latency has passed.
+func (s *session) forwardRaw(target *p2pconn.MConnection, labels MessageLabels, payload []byte) {
+	if shaping := s.cfg.Hooks.Shaping; shaping.enabled() {
+		s.shaper(s.flowTo(target), shaping).send(labels, payload)
+		return
+	}
+	s.sendFrame(target, labels, payload)
+}
+
+// flowTo returns the flow a frame sent to target travels: toward the peer or toward the validator.
+func (s *session) flowTo(target *p2pconn.MConnection) flowDirection {
+	if target == s.downstream {
+		return directionUpstream
+	}
+	return directionDownstream
+}
+
+// connFor returns the connection frames travelling in flow are sent on.
+func (s *session) connFor(flow flowDirection) *p2pconn.MConnection {
+	if flow == directionUpstream {
+		return s.downstream
+	}
+	return s.upstreamConn()
 }
 
-func (s *session) forwardRaw(target *p2pconn.MConnection, chID byte, payload []byte) {
-	if ok := target.Send(chID, append([]byte(nil), payload...)); !ok {
-		s.logger.Warn("failed to forward message", "channel", fmt.Sprintf("0x%X", chID))
+// shaper returns the link shaper for flow, creating it on first use.
+func (s *session) shaper(flow flowDirection, shaping LinkShaping) *linkShaper {
+	s.shapersMu.Lock()
+	defer s.shapersMu.Unlock()
+	if s.shapers == nil {
+		s.shapers = make(map[flowDirection]*linkShaper)
 	}
+	l, ok := s.shapers[flow]
+	if !ok {
+		seed := s.cfg.TriggerSeed + int64(len(s.shapers))
+		l = newLinkShaper(s, flow, shaping, seed)
+		s.shapers[flow] = l
+	}
+	return l
+}
+
+// sendFrame writes a frame to target immediately. A nil target is an upstream that is reconnecting.
+func (s *session) sendFrame(target *p2pconn.MConnection, labels MessageLabels, payload []byte) {
+	direction := s.flowTo(target)
+	if target == nil {
+		labels.Direction, labels.Action = string(direction), "reconnect"
+		s.metrics.Record(EventDropped, labels, 1)
+		return
+	}
+	if ok := target.Send(labels.Channel, append([]byte(nil), payload...)); !ok {
+		s.logger.Warn("failed to forward message", "channel", fmt.Sprintf("0x%X", labels.Channel))
+		return
+	}
+	labels.Direction = string(direction)
+	s.metrics.Record(EventForwarded, labels, 1)
+	s.meter(direction).AddOutput(len(payload))
+}
+
+// meter returns the resource meter for a flow direction, or nil when accounting is disabled.
+func (s *session) meter(direction flowDirection) *experiment.ComponentMeter {
+	return s.cfg.Resources.Component("proxy." + string(direction))
 }
 
 func (s *session) recordError(err error) {
@@ -202,18 +435,43 @@ func (s *session) recordError(err error) {
 	})
 }
 
+// observe adds a consensus message to the activity log.
+func (s *session) observe(event string, labels MessageLabels, canonical *abstraction.CanonicalMessage, received time.Time) {
+	s.cfg.activity.observe(ActivityEntry{
+		Time:      received.UTC(),
+		Channel:   labels.Channel,
+		Direction: labels.Direction,
+		Type:      labels.Type,
+		Height:    canonicalHeight(canonical),
+		Round:     canonicalRound(canonical),
+		Validator: canonical.Validator,
+		Event:     event,
+		Action:    labels.Action,
+	})
+}
+
 func canonicalHeight(msg *abstraction.CanonicalMessage) int64 {
-	if msg == nil || msg.Height == nil {
+	if msg == nil {
 		return 0
 	}
-	return msg.Height.Int64()
+	height, _ := msg.HeightInt64()
+	return height
 }
 
 func canonicalRound(msg *abstraction.CanonicalMessage) int64 {
-	if msg == nil || msg.Round == nil {
+	if msg == nil {
 		return 0
 	}
-	return msg.Round.Int64()
+	round, _ := msg.RoundInt64()
+	return round
+}
+
+// newMConnection multiplexes the proxy's channels over conn. The 0.38 channels log through the connection's
+// logger and have none until SetLogger, so every connection gets a no-op logger before it starts.
+func newMConnection(conn net.Conn, onReceive func(chID byte, payload []byte), onError func(any)) *p2pconn.MConnection {
+	mconn := p2pconn.NewMConnection(conn, defaultDescriptors(), onReceive, onError)
+	mconn.SetLogger(cmtlog.NewNopLogger())
+	return mconn
 }
 
 func defaultDescriptors() []*p2pconn.ChannelDescriptor {
@@ -258,7 +516,31 @@ func defaultDescriptors() []*p2pconn.ChannelDescriptor {
 			Priority:            4,
 			SendQueueCapacity:   32,
 			RecvMessageCapacity: 1 << 20,
-			MessageType:         &evidpb.Message{},
+			// The 0.38 evidence reactor sends a bare EvidenceList, not a wrapped Message.
+			MessageType: &cmtproto.EvidenceList{},
+		},
+		{
+			ID:                 blocksyncChannelID,
+			Priority:           5,
+			SendQueueCapacity:  1000,
+			RecvBufferCapacity: 50 * 4096,
+			// Block responses carry whole blocks, up to types.MaxBlockSizeBytes.
+			RecvMessageCapacity: 1 << 27,
+			MessageType:         &bcproto.Message{},
+		},
+		{
+			ID:                  snapshotChannelID,
+			Priority:            5,
+			SendQueueCapacity:   10,
+			RecvMessageCapacity: 1 << 22,
+			MessageType:         &ssproto.Message{},
+		},
+		{
+			ID:                  chunkChannelID,
+			Priority:            3,
+			SendQueueCapacity:   10,
+			RecvMessageCapacity: 1 << 24,
+			MessageType:         &ssproto.Message{},
 		},
 	}
 }
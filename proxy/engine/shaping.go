@@ -0,0 +1,226 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Latency distributions understood by LatencyModel.
+const (
+	LatencyConstant = "constant"
+	LatencyUniform  = "uniform"
+	LatencyNormal   = "normal"
+	LatencyPareto   = "pareto"
+)
+
+// paretoShape is the tail index of the pareto distribution: most samples stay close to the mean while a few
+// take several times the jitter.
+const paretoShape = 1.5
+
+// shapingQueueSize bounds the frames held back per channel before the receive routine blocks, as a full
+// socket buffer would.
+const shapingQueueSize = 1024
+
+// LatencyModel is the one-way delay added to every forwarded frame.
+type LatencyModel struct {
+	// Distribution is constant (the default), uniform (Mean ± Jitter), normal (Jitter is the standard
+	// deviation), or pareto (Mean plus a heavy tail scaled by Jitter).
+	Distribution string
+	Mean         time.Duration
+	Jitter       time.Duration
+}
+
+// LinkShaping degrades the link like a slow or congested network for all traffic, including mutated messages.
+// Frames on a channel keep their order: a frame is never delivered before the one sent ahead of it.
+type LinkShaping struct {
+	Latency LatencyModel
+	// Bandwidth caps channels by ID at the given bytes per second; channels without an entry are not throttled.
+	Bandwidth map[byte]int64
+}
+
+func (l LinkShaping) enabled() bool {
+	return l.Latency.Mean > 0 || l.Latency.Jitter > 0 || len(l.Bandwidth) > 0
+}
+
+func (l LinkShaping) validate() error {
+	switch strings.ToLower(l.Latency.Distribution) {
+	case "", LatencyConstant, LatencyUniform, LatencyNormal, LatencyPareto:
+	default:
+		return fmt.Errorf("unknown latency distribution %q (expected constant, uniform, normal, or pareto)", l.Latency.Distribution)
+	}
+	if l.Latency.Mean < 0 || l.Latency.Jitter < 0 {
+		return fmt.Errorf("latency must not be negative")
+	}
+	for chID, rate := range l.Bandwidth {
+		if rate <= 0 {
+			return fmt.Errorf("bandwidth for channel 0x%X must be positive", chID)
+		}
+	}
+	return nil
+}
+
+// sample draws a delay from the model.
+func (m LatencyModel) sample(rng *rand.Rand) time.Duration {
+	mean, jitter := float64(m.Mean), float64(m.Jitter)
+	var d float64
+	switch strings.ToLower(m.Distribution) {
+	case LatencyUniform:
+		d = mean + (2*rng.Float64()-1)*jitter
+	case LatencyNormal:
+		d = mean + rng.NormFloat64()*jitter
+	case LatencyPareto:
+		d = mean + jitter*(math.Pow(1-rng.Float64(), -1/paretoShape)-1)
+	default:
+		d = mean
+	}
+	if d < 0 {
+		return 0
+	}
+	return time.Duration(d)
+}
+
+// ParseBandwidth parses a comma separated spec such as "mempool=64k,0x22=1m" into bytes per second per
+// channel. Channels are named as for ParseChannelBlocks, or "all"; rates take an optional k or m suffix
+// (multiples of 1024).
+func ParseBandwidth(spec string) (map[byte]int64, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	rates := make(map[byte]int64)
+	for _, entry := range strings.Split(spec, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid bandwidth entry %q (expected channel=bytes-per-second)", entry)
+		}
+		rate, err := parseByteRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bandwidth for %q: %w", name, err)
+		}
+		var channels []byte
+		if strings.EqualFold(strings.TrimSpace(name), "all") {
+			for _, desc := range defaultDescriptors() {
+				channels = append(channels, desc.ID)
+			}
+		} else {
+			blocks, err := ParseChannelBlocks(name)
+			if err != nil {
+				return nil, err
+			}
+			for _, block := range blocks {
+				channels = append(channels, block.Channel)
+			}
+		}
+		for _, chID := range channels {
+			rates[chID] = rate
+		}
+	}
+	return rates, nil
+}
+
+func parseByteRate(value string) (int64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(value, "k"):
+		multiplier, value = 1<<10, strings.TrimSuffix(value, "k")
+	case strings.HasSuffix(value, "m"):
+		multiplier, value = 1<<20, strings.TrimSuffix(value, "m")
+	}
+	rate, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("expected a positive number of bytes, got %q", value)
+	}
+	return rate * multiplier, nil
+}
+
+// shapedFrame is a frame waiting for its delivery time.
+type shapedFrame struct {
+	at      time.Time
+	labels  MessageLabels
+	payload []byte
+}
+
+// linkShaper holds frames for one flow back by the sampled latency and the channel's bandwidth. Each
+// channel has its own queue, so a throttled channel does not hold back the others.
+type linkShaper struct {
+	s    *session
+	flow flowDirection
+	cfg  LinkShaping
+
+	mu  sync.Mutex
+	rng *rand.Rand
+	// last is the delivery time of the previous frame per channel; frames are never scheduled before it.
+	last   map[byte]time.Time
+	queues map[byte]chan shapedFrame
+}
+
+func newLinkShaper(s *session, flow flowDirection, cfg LinkShaping, seed int64) *linkShaper {
+	return &linkShaper{
+		s:      s,
+		flow:   flow,
+		cfg:    cfg,
+		rng:    rand.New(rand.NewSource(seed)),
+		last:   make(map[byte]time.Time),
+		queues: make(map[byte]chan shapedFrame),
+	}
+}
+
+// schedule returns the delivery time of a frame of size bytes on chID sent at now. Callers hold mu.
+func (l *linkShaper) schedule(chID byte, size int, now time.Time) time.Time {
+	at := now.Add(l.cfg.Latency.sample(l.rng))
+	if last := l.last[chID]; at.Before(last) {
+		at = last
+	}
+	if rate := l.cfg.Bandwidth[chID]; rate > 0 {
+		// The frame occupies the channel for its transmission time after the previous one.
+		at = at.Add(time.Duration(float64(size) / float64(rate) * float64(time.Second)))
+	}
+	l.last[chID] = at
+	return at
+}
+
+// send schedules payload for delivery, blocking only while the channel's queue is full.
+func (l *linkShaper) send(labels MessageLabels, payload []byte) {
+	l.mu.Lock()
+	at := l.schedule(labels.Channel, len(payload), time.Now())
+	queue, ok := l.queues[labels.Channel]
+	if !ok {
+		queue = make(chan shapedFrame, shapingQueueSize)
+		l.queues[labels.Channel] = queue
+		go l.deliver(queue)
+	}
+	l.mu.Unlock()
+
+	select {
+	case queue <- shapedFrame{at: at, labels: labels, payload: append([]byte(nil), payload...)}:
+	case <-l.s.ctx.Done():
+	}
+}
+
+func (l *linkShaper) deliver(queue chan shapedFrame) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-l.s.ctx.Done():
+			return
+		case frame := <-queue:
+			if wait := time.Until(frame.at); wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-l.s.ctx.Done():
+					return
+				case <-timer.C:
+				}
+			}
+			// The connection is looked up on delivery, so held frames follow a reconnected upstream.
+			l.s.sendFrame(l.s.connFor(l.flow), frame.labels, frame.payload)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/cometbft/cometbft/p2p"
+	p2pconn "github.com/cometbft/cometbft/p2p/conn"
+)
+
+// UpstreamTarget is a validator address the proxy dials.
+type UpstreamTarget struct {
+	Network string
+	Address string
+}
+
+func (t UpstreamTarget) String() string {
+	return t.Network + "://" + t.Address
+}
+
+// Reconnect keeps downstream peers connected when the upstream validator goes away, for example while it
+// restarts: the proxy redials, waiting Backoff after the first failed attempt and doubling the wait after each
+// further one up to MaxBackoff. Frames toward the validator are dropped until it is back.
+type Reconnect struct {
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Attempts bounds the dials per outage; 0 retries until the proxy stops. Once they are used up the
+	// sessions end as they would without Reconnect.
+	Attempts int
+}
+
+func (r Reconnect) validate() error {
+	if r.Backoff <= 0 {
+		return fmt.Errorf("reconnect backoff must be positive")
+	}
+	if r.MaxBackoff < 0 || r.Attempts < 0 {
+		return fmt.Errorf("reconnect limits must not be negative")
+	}
+	return nil
+}
+
+// wait returns the pause after the given failed attempt, counting from 1.
+func (r Reconnect) wait(attempt int) time.Duration {
+	wait := r.Backoff
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if r.MaxBackoff > 0 && wait >= r.MaxBackoff {
+			return r.MaxBackoff
+		}
+	}
+	return wait
+}
+
+// upstreamPool dials the upstream validator, failing over to the next target when one does not answer. The
+// target that last answered is tried first, so sessions stay on it until it fails.
+type upstreamPool struct {
+	targets []UpstreamTarget
+	nodeKey *p2p.NodeKey
+	timeout time.Duration
+	logger  *slog.Logger
+
+	mu      sync.Mutex
+	current int
+}
+
+func newUpstreamPool(cfg *Config) *upstreamPool {
+	targets := append([]UpstreamTarget{{Network: cfg.UpstreamNetwork, Address: cfg.UpstreamAddress}}, cfg.Failover...)
+	return &upstreamPool{targets: targets, nodeKey: cfg.NodeKey, timeout: cfg.DialTimeout, logger: cfg.Logger}
+}
+
+// dial connects and handshakes with the first target that answers, trying each once.
+func (p *upstreamPool) dial() (*p2pconn.SecretConnection, UpstreamTarget, error) {
+	p.mu.Lock()
+	start := p.current
+	p.mu.Unlock()
+
+	var errs []error
+	for i := range p.targets {
+		idx := (start + i) % len(p.targets)
+		target := p.targets[idx]
+		secret, err := dialTarget(target, p.nodeKey, p.timeout)
+		if err != nil {
+			errs = append(errs, err)
+			if len(p.targets) > 1 {
+				p.logger.Warn("upstream unreachable", "upstream", target, "err", err)
+			}
+			continue
+		}
+		p.mu.Lock()
+		p.current = idx
+		p.mu.Unlock()
+		if idx != start {
+			p.logger.Info("failed over to upstream", "upstream", target)
+		}
+		return secret, target, nil
+	}
+	return nil, UpstreamTarget{}, errors.Join(errs...)
+}
+
+// redial dials until a target answers, r's attempts are used up, or ctx ends.
+func (p *upstreamPool) redial(ctx context.Context, r Reconnect) (*p2pconn.SecretConnection, UpstreamTarget, error) {
+	for attempt := 1; ; attempt++ {
+		secret, target, err := p.dial()
+		if err == nil {
+			return secret, target, nil
+		}
+		if r.Attempts > 0 && attempt >= r.Attempts {
+			return nil, UpstreamTarget{}, fmt.Errorf("upstream did not come back after %d attempts: %w", attempt, err)
+		}
+		wait := r.wait(attempt)
+		p.logger.Debug("upstream reconnect failed", "attempt", attempt, "retry_in", wait, "err", err)
+		select {
+		case <-ctx.Done():
+			return nil, UpstreamTarget{}, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func dialTarget(target UpstreamTarget, nodeKey *p2p.NodeKey, timeout time.Duration) (*p2pconn.SecretConnection, error) {
+	upstreamConn, err := net.DialTimeout(target.Network, target.Address, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial upstream %s: %w", target, err)
+	}
+
+	upstreamSecret, err := p2pconn.MakeSecretConnection(upstreamConn, nodeKey.PrivKey)
+	if err != nil {
+		upstreamConn.Close()
+		return nil, fmt.Errorf("handshake with upstream %s failed: %w", target, err)
+	}
+	return upstreamSecret, nil
+}
+
+// upstreamConn returns the session's current upstream connection, or nil while it reconnects.
+func (s *session) upstreamConn() *p2pconn.MConnection {
+	s.upstreamMu.RLock()
+	defer s.upstreamMu.RUnlock()
+	return s.upstream
+}
+
+func (s *session) setUpstream(conn *p2pconn.MConnection) {
+	s.upstreamMu.Lock()
+	s.upstream = conn
+	s.upstreamMu.Unlock()
+}
+
+// connectUpstream wraps an upstream connection the session owns. With Reconnect a broken connection is
+// replaced instead of ending the session.
+func (s *session) connectUpstream(conn net.Conn) *p2pconn.MConnection {
+	upRecv := func(chID byte, payload []byte) {
+		s.handleUpstream(chID, payload)
+	}
+	var mconn *p2pconn.MConnection
+	onError := s.onError(directionUpstream)
+	if s.cfg.Reconnect != nil {
+		onError = func(err any) { s.upstreamFailed(mconn, err) }
+	}
+	mconn = newMConnection(conn, upRecv, onError)
+	return mconn
+}
+
+// upstreamFailed detaches a broken upstream connection and redials in the background; the downstream peer
+// stays connected meanwhile.
+func (s *session) upstreamFailed(conn *p2pconn.MConnection, err any) {
+	s.upstreamMu.Lock()
+	if s.upstream != conn {
+		s.upstreamMu.Unlock()
+		return
+	}
+	s.upstream = nil
+	s.upstreamMu.Unlock()
+	s.logger.Warn("upstream connection lost; reconnecting", "err", err)
+
+	go func() {
+		secret, target, err := s.cfg.upstreams.redial(s.ctx, *s.cfg.Reconnect)
+		if err != nil {
+			s.recordError(fmt.Errorf("upstream reconnect failed: %w", err))
+			return
+		}
+		next := s.connectUpstream(secret)
+		if err := next.Start(); err != nil {
+			s.recordError(fmt.Errorf("failed to start upstream connection: %w", err))
+			return
+		}
+		s.setUpstream(next)
+		if s.ctx.Err() != nil {
+			// The peer left while the proxy redialed; run has already stopped the previous connection.
+			_ = next.Stop()
+			return
+		}
+		s.logger.Info("upstream reconnected", "upstream", target)
+	}()
+}
@@ -0,0 +1,182 @@
+// Package kaia runs the byzantine proxy in front of a Kaia (formerly Klaytn) consensus node. It relays the
+// node's RLPx connections with proxy/devp2p, decodes the IBFT messages of the istanbul subprotocol through
+// KaiaMapper, and applies the same triggers, hooks, and byzantine actions as the CometBFT proxy.
+package kaia
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	kaiaAdapter "codec/kaia/adapter"
+	"codec/message/abstraction/byzantine"
+	"codec/proxy/devp2p"
+	"codec/proxy/engine"
+)
+
+// ByzantineEngine holds the actions the proxy can carry out on Kaia messages. IBFT messages carry no
+// timestamp, so timestamp_skew has nothing to change, and a preprepare carries its whole block, which the proxy
+// cannot forge, so double_proposal is left out. alter_validator rewrites the address a message names, which
+// must be given in hex.
+var ByzantineEngine = newByzantineEngine()
+
+func newByzantineEngine() *byzantine.Engine {
+	e := byzantine.NewEngine()
+	e.Unregister(byzantine.ActionTimestampSkew)
+	e.Unregister(byzantine.ActionDoubleProposal)
+	return e
+}
+
+// ParseByzantineAction converts a CLI string to an action the proxy supports.
+func ParseByzantineAction(value string) (byzantine.Action, error) {
+	return ByzantineEngine.Parse(value)
+}
+
+// Config holds the runtime configuration for the Kaia proxy.
+type Config struct {
+	ListenAddress string
+	Upstream      *devp2p.Node
+	ChainID       string
+
+	// NodeKey and ListenKey are the RLPx identities of the proxy; see devp2p.Config.
+	NodeKey   *ecdsa.PrivateKey
+	ListenKey *ecdsa.PrivateKey
+	// ValidatorKey, when set, re-signs mutated messages, making them valid messages of that validator.
+	ValidatorKey *ecdsa.PrivateKey
+
+	Action  byzantine.Action
+	Options byzantine.Options
+
+	Trigger   engine.Trigger
+	Hooks     devp2p.Hooks
+	Direction engine.Direction
+
+	DialTimeout time.Duration
+	Logger      *slog.Logger
+
+	// TriggerSeed seeds the generator behind Trigger.Probability. NewConfig picks one from the clock when none
+	// is given.
+	TriggerSeed int64
+}
+
+// ConfigOptions contains inputs to build a Config.
+type ConfigOptions struct {
+	ListenAddress string
+	// Upstream is the consensus node's kni or enode URL.
+	Upstream     string
+	ChainID      string
+	NodeKey      *ecdsa.PrivateKey
+	ListenKey    *ecdsa.PrivateKey
+	ValidatorKey *ecdsa.PrivateKey
+	Action       byzantine.Action
+	Options      byzantine.Options
+	Trigger      engine.Trigger
+	Hooks        devp2p.Hooks
+	Direction    engine.Direction
+	DialTimeout  time.Duration
+	Logger       *slog.Logger
+	TriggerSeed  int64
+}
+
+// NewConfig validates and normalises proxy options.
+func NewConfig(opts ConfigOptions) (*Config, error) {
+	if strings.TrimSpace(opts.ListenAddress) == "" {
+		return nil, fmt.Errorf("invalid listen address: address cannot be empty")
+	}
+	upstream, err := devp2p.ParseNode(opts.Upstream)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upstream: %w", err)
+	}
+	if opts.NodeKey == nil {
+		return nil, fmt.Errorf("node key is required")
+	}
+	if strings.TrimSpace(opts.ChainID) == "" {
+		return nil, fmt.Errorf("chain id is required")
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+	}
+	seed := opts.TriggerSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	cfg := &Config{
+		ListenAddress: strings.TrimPrefix(strings.TrimSpace(opts.ListenAddress), "tcp://"),
+		Upstream:      upstream,
+		ChainID:       strings.TrimSpace(opts.ChainID),
+		NodeKey:       opts.NodeKey,
+		ListenKey:     opts.ListenKey,
+		ValidatorKey:  opts.ValidatorKey,
+		Action:        opts.Action,
+		Options:       opts.Options,
+		Trigger:       opts.Trigger,
+		Hooks:         opts.Hooks,
+		Direction:     opts.Direction,
+		DialTimeout:   opts.DialTimeout,
+		Logger:        logger,
+		TriggerSeed:   seed,
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 5 * time.Second
+	}
+	return cfg, nil
+}
+
+// Engine runs the Kaia proxy.
+type Engine struct {
+	proxy       *devp2p.Proxy
+	interceptor *devp2p.Interceptor
+}
+
+// New constructs a Kaia proxy engine from the configuration.
+func New(cfg *Config) (*Engine, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("engine config cannot be nil")
+	}
+	interceptor, err := devp2p.NewInterceptor(devp2p.InterceptorConfig{
+		Codec:       &codec{chainID: cfg.ChainID, mapper: kaiaAdapter.NewKaiaMapper(cfg.ChainID)},
+		SigningKey:  cfg.ValidatorKey,
+		Action:      cfg.Action,
+		Options:     cfg.Options,
+		Trigger:     cfg.Trigger,
+		Hooks:       cfg.Hooks,
+		Direction:   cfg.Direction,
+		TriggerSeed: cfg.TriggerSeed,
+		Logger:      cfg.Logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := devp2p.NewProxy(devp2p.Config{
+		ListenAddress: cfg.ListenAddress,
+		Upstream:      cfg.Upstream,
+		NodeKey:       cfg.NodeKey,
+		ListenKey:     cfg.ListenKey,
+		DialTimeout:   cfg.DialTimeout,
+		Protocol:      Protocol,
+		MessageSpaces: messageSpaces,
+		Handler:       interceptor,
+		ConnType:      true,
+		Logger:        cfg.Logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{proxy: proxy, interceptor: interceptor}, nil
+}
+
+// Metrics returns the engine's counters, shared by every peer.
+func (e *Engine) Metrics() *engine.Metrics {
+	return e.interceptor.Metrics()
+}
+
+// Run accepts peers until the context is cancelled.
+func (e *Engine) Run(ctx context.Context) error {
+	return e.proxy.Run(ctx)
+}
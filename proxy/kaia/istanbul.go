@@ -0,0 +1,389 @@
+package kaia
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	kaiaAdapter "codec/kaia/adapter"
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+	"codec/proxy/devp2p"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Protocol is the devp2p capability Kaia consensus nodes announce.
+const Protocol = "istanbul"
+
+// istanbulMsg is the code, relative to the protocol, of the message that carries every consensus message.
+const istanbulMsg = 0x11
+
+// messageSpaces are the lengths of the istanbul protocol versions, which extend the klay protocol's messages.
+var messageSpaces = map[devp2p.Cap]uint64{
+	{Name: Protocol, Version: 63}: 19,
+	{Name: Protocol, Version: 64}: 21,
+	{Name: Protocol, Version: 65}: 23,
+}
+
+// IBFT message codes, carried inside the consensus message.
+const (
+	msgPreprepare  = 0
+	msgPrepare     = 1
+	msgCommit      = 2
+	msgRoundChange = 3
+)
+
+var messageTypes = map[uint64]string{
+	msgPreprepare:  "Preprepare",
+	msgPrepare:     "Prepare",
+	msgCommit:      "Commit",
+	msgRoundChange: "RoundChange",
+}
+
+// consensusMsg is what Kaia sends as istanbulMsg: the parent hash the message is for and the encoded message.
+type consensusMsg struct {
+	PrevHash common.Hash
+	Payload  []byte
+}
+
+// view is the round and sequence (height) a message is for.
+type view struct {
+	Round    *big.Int
+	Sequence *big.Int
+}
+
+// subject is the body of prepares, commits, and round changes.
+type subject struct {
+	View     *view
+	Digest   common.Hash
+	PrevHash common.Hash
+}
+
+// wireMessage is a decoded IBFT message, [code, msg, address, signature, committedSeal], optionally preceded
+// by a hash as newer nodes send it. The body is re-encoded only when the view, digest, or parent hash change,
+// so a proposed block is forwarded byte for byte.
+type wireMessage struct {
+	outer consensusMsg
+	// items are the list items of the message; base is the index of its code.
+	items []rlp.RawValue
+	base  int
+
+	code          uint64
+	body          []byte
+	address       common.Address
+	signature     []byte
+	committedSeal []byte
+
+	view *view
+	// subject is nil for a preprepare, whose body is the view and the proposed block.
+	subject    *subject
+	blockItems []rlp.RawValue
+}
+
+func decodeWire(payload []byte) (*wireMessage, error) {
+	m := &wireMessage{}
+	if err := rlp.DecodeBytes(payload, &m.outer); err != nil {
+		return nil, fmt.Errorf("decode consensus message: %w", err)
+	}
+	if err := rlp.DecodeBytes(m.outer.Payload, &m.items); err != nil {
+		return nil, fmt.Errorf("decode istanbul message: %w", err)
+	}
+	switch len(m.items) {
+	case 5:
+	case 6:
+		m.base = 1
+	default:
+		return nil, fmt.Errorf("decode istanbul message: unexpected %d fields", len(m.items))
+	}
+	fields := []interface{}{&m.code, &m.body, &m.address, &m.signature, &m.committedSeal}
+	for i, field := range fields {
+		if err := rlp.DecodeBytes(m.items[m.base+i], field); err != nil {
+			return nil, fmt.Errorf("decode istanbul message field %d: %w", i, err)
+		}
+	}
+	if _, ok := messageTypes[m.code]; !ok {
+		return nil, fmt.Errorf("unsupported istanbul message code %d", m.code)
+	}
+
+	if m.code == msgPreprepare {
+		var body []rlp.RawValue
+		if err := rlp.DecodeBytes(m.body, &body); err != nil || len(body) != 2 {
+			return nil, fmt.Errorf("decode preprepare: %v", err)
+		}
+		m.view = new(view)
+		if err := rlp.DecodeBytes(body[0], m.view); err != nil {
+			return nil, fmt.Errorf("decode preprepare view: %w", err)
+		}
+		// The block is [header, ...]; only the header's leading parent hash is read.
+		_ = rlp.DecodeBytes(body[1], &m.blockItems)
+	} else {
+		m.subject = new(subject)
+		if err := rlp.DecodeBytes(m.body, m.subject); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", messageTypes[m.code], err)
+		}
+		m.view = m.subject.View
+	}
+	if m.view == nil || m.view.Round == nil || m.view.Sequence == nil {
+		return nil, fmt.Errorf("decode %s: missing view", messageTypes[m.code])
+	}
+	return m, nil
+}
+
+// parentHash returns the parent hash of a preprepare's block, when its header decodes.
+func (m *wireMessage) parentHash() (common.Hash, bool) {
+	if len(m.blockItems) == 0 {
+		return common.Hash{}, false
+	}
+	var header []rlp.RawValue
+	var parent []byte
+	if rlp.DecodeBytes(m.blockItems[0], &header) != nil || len(header) == 0 || rlp.DecodeBytes(header[0], &parent) != nil || len(parent) != common.HashLength {
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(parent), true
+}
+
+// payloadNoSig is what Kaia signs: the message with an empty signature.
+func (m *wireMessage) payloadNoSig() ([]byte, error) {
+	return m.encodeMessage(nil)
+}
+
+func (m *wireMessage) encodeMessage(signature []byte) ([]byte, error) {
+	items := append([]rlp.RawValue(nil), m.items[:m.base]...)
+	fields := []interface{}{m.code, m.body, m.address, signature, m.committedSeal}
+	for _, field := range fields {
+		item, err := rlp.EncodeToBytes(field)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return rlp.EncodeToBytes(items)
+}
+
+// author recovers the address that signed the message; it is empty when the signature does not recover.
+func (m *wireMessage) author() string {
+	data, err := m.payloadNoSig()
+	if err != nil || len(m.signature) != crypto.SignatureLength {
+		return ""
+	}
+	pub, err := crypto.SigToPub(crypto.Keccak256(data), m.signature)
+	if err != nil {
+		return ""
+	}
+	return addressString(crypto.PubkeyToAddress(*pub))
+}
+
+func (m *wireMessage) encode() ([]byte, error) {
+	payload, err := m.encodeMessage(m.signature)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(consensusMsg{PrevHash: m.outer.PrevHash, Payload: payload})
+}
+
+// toRaw describes the message in the JSON form KaiaMapper reads. The validator is the address the message
+// names; the signature tells whether it really sent it.
+func (m *wireMessage) toRaw(chainID string, received time.Time) (*abstraction.RawConsensusMessage, error) {
+	messageType := messageTypes[m.code]
+	v := &kaiaAdapter.KaiaView{Round: int32(m.view.Round.Int64()), Sequence: m.view.Sequence.Int64()}
+	msg := kaiaAdapter.KaiaMessage{
+		MessageType:  messageType,
+		View:         v,
+		Validator:    addressString(m.address),
+		Timestamp:    received.Format(time.RFC3339),
+		ConsensusMsg: &kaiaAdapter.KaiaConsensusMsg{PrevHash: m.outer.PrevHash.Hex(), Payload: "0x" + hex.EncodeToString(m.outer.Payload)},
+	}
+	if m.code == msgCommit {
+		msg.CommittedSeal = fmt.Sprintf("0x%x", m.committedSeal)
+	}
+	if m.subject != nil {
+		msg.Subject = &kaiaAdapter.KaiaSubject{View: v, Digest: m.subject.Digest.Hex(), PrevHash: m.subject.PrevHash.Hex()}
+	} else if parent, ok := m.parentHash(); ok {
+		// Kaia hashes headers without their seals, which the proxy does not reproduce, so the block hash is
+		// left out.
+		msg.Proposal = &kaiaAdapter.KaiaProposal{Number: v.Sequence, ParentHash: parent.Hex()}
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &abstraction.RawConsensusMessage{
+		ChainType:   abstraction.ChainTypeKaia,
+		ChainID:     chainID,
+		MessageType: messageType,
+		Payload:     payload,
+		Encoding:    "json",
+		Timestamp:   received,
+	}, nil
+}
+
+// apply rewrites a copy of the message to carry the height, round, digest, parent hash, validator, and
+// signature of mutated, where original is the canonical form of the message. A preprepare carries its block,
+// which the proxy cannot forge, so its digest and parent hash must stay. With key set, a changed message is
+// re-signed, along with the committed seal of a commit whose digest changed.
+func (m *wireMessage) apply(original, mutated *abstraction.CanonicalMessage, key *ecdsa.PrivateKey) (*wireMessage, error) {
+	if mutated.Type != original.Type {
+		return nil, fmt.Errorf("cannot send a %s as a %s", mutated.Type, messageTypes[m.code])
+	}
+	out := *m
+	v := &view{Round: new(big.Int).Set(m.view.Round), Sequence: new(big.Int).Set(m.view.Sequence)}
+	if mutated.Height != nil {
+		v.Sequence.Set(mutated.Height)
+	}
+	if mutated.Round != nil {
+		v.Round.Set(mutated.Round)
+	}
+	out.view = v
+	viewChanged := v.Round.Cmp(m.view.Round) != 0 || v.Sequence.Cmp(m.view.Sequence) != 0
+
+	var err error
+	if m.subject != nil {
+		s := *m.subject
+		s.View = v
+		if mutated.BlockHash != original.BlockHash {
+			s.Digest = common.HexToHash(mutated.BlockHash)
+		}
+		if mutated.PrevHash != original.PrevHash {
+			s.PrevHash = common.HexToHash(mutated.PrevHash)
+			out.outer.PrevHash = s.PrevHash
+		}
+		out.subject = &s
+		if viewChanged || s.Digest != m.subject.Digest || s.PrevHash != m.subject.PrevHash {
+			if out.body, err = rlp.EncodeToBytes(&s); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		if mutated.BlockHash != original.BlockHash || mutated.PrevHash != original.PrevHash {
+			return nil, fmt.Errorf("cannot change the block of a %s", messageTypes[m.code])
+		}
+		if viewChanged {
+			var body []rlp.RawValue
+			if err := rlp.DecodeBytes(m.body, &body); err != nil {
+				return nil, err
+			}
+			if body[0], err = rlp.EncodeToBytes(v); err != nil {
+				return nil, err
+			}
+			if out.body, err = rlp.EncodeToBytes(body); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	validator, previous := mutated.Validator, original.Validator
+	if mutated.Type == abstraction.MsgTypeProposal {
+		validator, previous = mutated.Proposer, original.Proposer
+	}
+	if validator != previous {
+		hexAddress := strings.TrimPrefix(strings.TrimPrefix(validator, "0x"), "0X")
+		if !common.IsHexAddress(hexAddress) {
+			return nil, fmt.Errorf("validator %q is not a hex address", validator)
+		}
+		out.address = common.HexToAddress(hexAddress)
+	}
+
+	// The canonical signature of a commit is its committed seal; other messages take it as their signature.
+	if mutated.Signature != original.Signature {
+		if m.code == msgCommit {
+			out.committedSeal = signatureBytes(mutated.Signature)
+		} else {
+			out.signature = signatureBytes(mutated.Signature)
+		}
+	}
+
+	changed := !bytes.Equal(out.body, m.body) || out.address != m.address || !bytes.Equal(out.committedSeal, m.committedSeal)
+	if key != nil && changed && len(out.signature) > 0 {
+		if m.code == msgCommit && len(out.committedSeal) > 0 && out.subject.Digest != m.subject.Digest {
+			// The committed seal signs the digest followed by the commit code.
+			seal := append(out.subject.Digest.Bytes(), byte(msgCommit))
+			if out.committedSeal, err = crypto.Sign(crypto.Keccak256(seal), key); err != nil {
+				return nil, fmt.Errorf("failed to seal commit: %w", err)
+			}
+		}
+		data, err := out.payloadNoSig()
+		if err != nil {
+			return nil, err
+		}
+		if out.signature, err = crypto.Sign(crypto.Keccak256(data), key); err != nil {
+			return nil, fmt.Errorf("failed to sign %s: %w", messageTypes[m.code], err)
+		}
+	}
+	return &out, nil
+}
+
+// signatureBytes decodes a canonical signature, which is 0x-prefixed hex.
+func signatureBytes(signature string) []byte {
+	b, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil {
+		// Actions may substitute a readable placeholder; it does not verify either way.
+		return []byte(signature)
+	}
+	return b
+}
+
+func addressString(address common.Address) string {
+	return strings.ToLower(address.Hex()[2:])
+}
+
+// codec decodes istanbul messages for devp2p.Interceptor.
+type codec struct {
+	chainID string
+	mapper  *kaiaAdapter.KaiaMapper
+}
+
+func (c *codec) Decode(code uint64, payload []byte, received time.Time) (devp2p.Message, error) {
+	if code != istanbulMsg {
+		return nil, nil
+	}
+	wire, err := decodeWire(payload)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := wire.toRaw(c.chainID, received)
+	if err != nil {
+		return nil, err
+	}
+	canonical, err := c.mapper.ToCanonical(*raw)
+	if err != nil {
+		return nil, err
+	}
+	canonical.Timestamp = received
+	return &message{wire: wire, canonical: canonical}, nil
+}
+
+func (c *codec) Actions() *byzantine.Engine {
+	return ByzantineEngine
+}
+
+// message is a decoded IBFT message and its canonical form.
+type message struct {
+	wire      *wireMessage
+	canonical *abstraction.CanonicalMessage
+}
+
+func (m *message) Canonical() *abstraction.CanonicalMessage {
+	return m.canonical
+}
+
+// Kind is the IBFT message name, so step triggers and metrics can say preprepare and roundchange as Kaia does
+// as well as the canonical proposal and round_change.
+func (m *message) Kind() string {
+	return strings.ToLower(messageTypes[m.wire.code])
+}
+
+func (m *message) Encode(mutated *abstraction.CanonicalMessage, key *ecdsa.PrivateKey) ([]byte, error) {
+	out, err := m.wire.apply(m.canonical, mutated, key)
+	if err != nil {
+		return nil, err
+	}
+	return out.encode()
+}
@@ -0,0 +1,156 @@
+package kaia
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	kaiaAdapter "codec/kaia/adapter"
+	"codec/message/abstraction/byzantine"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// signedMessage builds an istanbul message the way a Kaia node sends it; withHash prepends the hash field
+// newer nodes send.
+func signedMessage(t *testing.T, key *ecdsa.PrivateKey, code uint64, body []byte, seal []byte, withHash bool) []byte {
+	t.Helper()
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	fields := []interface{}{code, body, address, []byte{}, seal}
+	if withHash {
+		fields = append([]interface{}{common.HexToHash("0x01")}, fields...)
+	}
+	unsigned, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		t.Fatalf("encode message: %v", err)
+	}
+	sig, err := crypto.Sign(crypto.Keccak256(unsigned), key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	fields[len(fields)-2] = sig
+	payload, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		t.Fatalf("encode message: %v", err)
+	}
+	data, err := rlp.EncodeToBytes(consensusMsg{PrevHash: common.HexToHash("0xaa"), Payload: payload})
+	if err != nil {
+		t.Fatalf("encode consensus message: %v", err)
+	}
+	return data
+}
+
+func subjectBody(t *testing.T, height, round int64, digest common.Hash) []byte {
+	t.Helper()
+	body, err := rlp.EncodeToBytes(&subject{View: &view{Round: big.NewInt(round), Sequence: big.NewInt(height)}, Digest: digest, PrevHash: common.HexToHash("0xaa")})
+	if err != nil {
+		t.Fatalf("encode subject: %v", err)
+	}
+	return body
+}
+
+func decode(t *testing.T, data []byte) *message {
+	t.Helper()
+	c := &codec{chainID: "kaia-test", mapper: kaiaAdapter.NewKaiaMapper("kaia-test")}
+	msg, err := c.Decode(istanbulMsg, data, time.Now())
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return msg.(*message)
+}
+
+func TestCommitRoundTrip(t *testing.T) {
+	for _, withHash := range []bool{false, true} {
+		key, _ := crypto.GenerateKey()
+		digest := common.HexToHash("0xbeef")
+		seal, _ := crypto.Sign(crypto.Keccak256(append(digest.Bytes(), msgCommit)), key)
+		data := signedMessage(t, key, msgCommit, subjectBody(t, 40, 2, digest), seal, withHash)
+
+		msg := decode(t, data)
+		if msg.Kind() != "commit" || msg.canonical.Height.Int64() != 40 || msg.canonical.Round.Int64() != 2 || msg.canonical.BlockHash != digest.Hex() {
+			t.Fatalf("canonical = %+v", msg.canonical)
+		}
+		if author := msg.wire.author(); author != addressString(crypto.PubkeyToAddress(key.PublicKey)) || msg.canonical.Validator != author {
+			t.Fatalf("author %s, validator %s", author, msg.canonical.Validator)
+		}
+		encoded, err := msg.Encode(msg.canonical, key)
+		if err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		if string(encoded) != string(data) {
+			t.Fatalf("unmutated commit changed on encode (hash field %v)", withHash)
+		}
+	}
+}
+
+func TestDoubleVoteResealed(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	digest := common.HexToHash("0xbeef")
+	seal, _ := crypto.Sign(crypto.Keccak256(append(digest.Bytes(), msgCommit)), key)
+	msg := decode(t, signedMessage(t, key, msgCommit, subjectBody(t, 40, 0, digest), seal, false))
+
+	mutated, err := ByzantineEngine.Apply(msg.canonical, byzantine.ActionDoubleVote, byzantine.Options{})
+	if err != nil {
+		t.Fatalf("apply action: %v", err)
+	}
+	encoded, err := msg.Encode(mutated[1], key)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	conflicting := decode(t, encoded)
+	if conflicting.wire.subject.Digest == digest {
+		t.Fatalf("double_vote kept the digest")
+	}
+	if conflicting.wire.author() != msg.wire.author() {
+		t.Fatalf("conflicting commit is not signed by the validator")
+	}
+	seal = append(conflicting.wire.subject.Digest.Bytes(), msgCommit)
+	pub, err := crypto.SigToPub(crypto.Keccak256(seal), conflicting.wire.committedSeal)
+	if err != nil || crypto.PubkeyToAddress(*pub) != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("committed seal does not cover the new digest: %v", err)
+	}
+}
+
+func TestAlterValidatorAndPreprepare(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	msg := decode(t, signedMessage(t, key, msgPrepare, subjectBody(t, 9, 0, common.HexToHash("0x01")), nil, false))
+	other := "0x00000000000000000000000000000000000000aa"
+	mutated, err := ByzantineEngine.Apply(msg.canonical, byzantine.ActionAlterValidator, byzantine.Options{AlternateValidator: other})
+	if err != nil {
+		t.Fatalf("apply action: %v", err)
+	}
+	encoded, err := msg.Encode(mutated[0], nil)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if altered := decode(t, encoded); altered.canonical.Validator != other[2:] {
+		t.Fatalf("validator = %s", altered.canonical.Validator)
+	}
+
+	header, _ := rlp.EncodeToBytes([]interface{}{common.HexToHash("0xaa"), uint64(1)})
+	block, _ := rlp.EncodeToBytes([]interface{}{rlp.RawValue(header), []interface{}{}})
+	body, _ := rlp.EncodeToBytes([]interface{}{&view{Round: big.NewInt(0), Sequence: big.NewInt(9)}, rlp.RawValue(block)})
+	preprepare := decode(t, signedMessage(t, key, msgPreprepare, body, nil, false))
+	if preprepare.Kind() != "preprepare" || preprepare.canonical.Height.Int64() != 9 || preprepare.canonical.PrevHash != common.HexToHash("0xaa").Hex() {
+		t.Fatalf("preprepare canonical = %+v", preprepare.canonical)
+	}
+	forged := *preprepare.canonical
+	forged.BlockHash = "0xdead"
+	if _, err := preprepare.Encode(&forged, key); err == nil {
+		t.Fatalf("expected changing a preprepare's block to fail")
+	}
+	flooded, err := ByzantineEngine.Apply(preprepare.canonical, byzantine.ActionHeightFlood, byzantine.Options{FloodFrom: 1, FloodTo: 1})
+	if err != nil {
+		t.Fatalf("height_flood: %v", err)
+	}
+	encoded, err = preprepare.Encode(flooded[0], key)
+	if err != nil {
+		t.Fatalf("encode flooded preprepare: %v", err)
+	}
+	if decode(t, encoded).canonical.Height.Int64() != 10 {
+		t.Fatalf("height_flood did not move the preprepare")
+	}
+}
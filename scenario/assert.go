@@ -0,0 +1,136 @@
+package scenario
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+// Assertion is a check over the messages a scenario emitted. Kind selects the check; the other fields
+// are its parameters.
+type Assertion struct {
+	Kind string `json:"kind"`
+	// Count is the exact number of messages for "emitted".
+	Count *int `json:"count,omitempty"`
+	// Min is the lower bound for "nil_votes" and "round_advance".
+	Min int `json:"min,omitempty"`
+	// Duration is the minimum shift for "timestamp_skew".
+	Duration Duration `json:"duration,omitempty"`
+}
+
+// AssertionOutcome records whether an assertion held and why.
+type AssertionOutcome struct {
+	Kind   string `json:"kind"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+type check func(a Assertion, outputs []Output) (bool, string)
+
+var checks = map[string]check{
+	"emitted":           checkEmitted,
+	"conflicting_votes": checkConflictingVotes,
+	"nil_votes":         checkNilVotes,
+	"round_advance":     checkRoundAdvance,
+	"timestamp_skew":    checkTimestampSkew,
+}
+
+// Evaluate runs the assertion against the emitted messages.
+func (a Assertion) Evaluate(outputs []Output) AssertionOutcome {
+	fn, ok := checks[a.Kind]
+	if !ok {
+		return AssertionOutcome{Kind: a.Kind, Detail: "unknown assertion kind"}
+	}
+	passed, detail := fn(a, outputs)
+	return AssertionOutcome{Kind: a.Kind, Passed: passed, Detail: detail}
+}
+
+// checkEmitted counts every emitted message.
+func checkEmitted(a Assertion, outputs []Output) (bool, string) {
+	if a.Count == nil {
+		return false, "emitted requires count"
+	}
+	return len(outputs) == *a.Count, fmt.Sprintf("emitted %d messages, want %d", len(outputs), *a.Count)
+}
+
+// checkConflictingVotes looks for two votes from the same validator for the same slot that name different blocks.
+func checkConflictingVotes(_ Assertion, outputs []Output) (bool, string) {
+	seen := make(map[string]string)
+	for _, out := range outputs {
+		msg := out.Canonical
+		if !byzantine.IsVote(msg.Type) {
+			continue
+		}
+		key := fmt.Sprintf("%s/%s/%s/%s", msg.Type, bigString(msg.Height), bigString(slot(msg)), msg.Validator)
+		if previous, ok := seen[key]; ok && previous != msg.BlockHash {
+			return true, fmt.Sprintf("validator %q voted for %q and %q at %s", msg.Validator, previous, msg.BlockHash, key)
+		}
+		seen[key] = msg.BlockHash
+	}
+	return false, "no validator voted for two different blocks in the same slot"
+}
+
+// checkNilVotes counts votes without a block.
+func checkNilVotes(a Assertion, outputs []Output) (bool, string) {
+	count := 0
+	for _, out := range outputs {
+		if byzantine.IsVote(out.Canonical.Type) && out.Canonical.BlockHash == "" {
+			count++
+		}
+	}
+	return count >= a.Min, fmt.Sprintf("%d nil votes, want at least %d", count, a.Min)
+}
+
+// checkRoundAdvance counts the distinct rounds (or views) above the lowest input round that messages were emitted for.
+func checkRoundAdvance(a Assertion, outputs []Output) (bool, string) {
+	var base *big.Int
+	for _, out := range outputs {
+		if s := slot(out.Input); s != nil && (base == nil || s.Cmp(base) < 0) {
+			base = s
+		}
+	}
+	if base == nil {
+		return false, "inputs carry no round or view"
+	}
+	ahead := make(map[string]bool)
+	for _, out := range outputs {
+		if s := slot(out.Canonical); s != nil && s.Cmp(base) > 0 {
+			ahead[s.String()] = true
+		}
+	}
+	return len(ahead) >= a.Min, fmt.Sprintf("messages for %d rounds above %s, want at least %d", len(ahead), base, a.Min)
+}
+
+// checkTimestampSkew looks for an emitted message whose timestamp moved at least Duration from its input.
+func checkTimestampSkew(a Assertion, outputs []Output) (bool, string) {
+	want := time.Duration(a.Duration)
+	var largest time.Duration
+	for _, out := range outputs {
+		shift := out.Canonical.Timestamp.Sub(out.Input.Timestamp)
+		if shift < 0 {
+			shift = -shift
+		}
+		if shift > largest {
+			largest = shift
+		}
+	}
+	return want > 0 && largest >= want, fmt.Sprintf("largest timestamp shift %s, want at least %s", largest, want)
+}
+
+// slot is the round, or the view for protocols that only have views.
+func slot(msg *abstraction.CanonicalMessage) *big.Int {
+	if msg.Round != nil {
+		return msg.Round
+	}
+	return msg.View
+}
+
+func bigString(v *big.Int) string {
+	if v == nil {
+		return "-"
+	}
+	return v.String()
+}
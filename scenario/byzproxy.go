@@ -0,0 +1,78 @@
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"codec/capture"
+	"codec/message/abstraction/properties"
+	"codec/message/abstraction/quorum"
+)
+
+// EvaluateCapture judges an experiment run on a live network from the capture byzproxy wrote with --record.
+// Only what went on the wire counts: a received message the proxy replaced or dropped is left out, and the
+// messages it sent in its place are counted instead. Safety is violated where the messages break agreement
+// and liveness stalled where they break progress, as the properties package checks them; evidence was
+// produced where a validator signed two different votes for one step.
+func (e *Experiment) EvaluateCapture(path string) (*ExperimentReport, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	reader, err := capture.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open capture: %w", err)
+	}
+	defer reader.Close()
+
+	var records []*capture.Record
+	for {
+		rec, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read capture: %w", err)
+		}
+		records = append(records, rec)
+	}
+
+	validators := quorum.ValidatorSet{}
+	for _, v := range e.Validators {
+		validators[v.Name] = v.power()
+	}
+	tracker := quorum.NewTracker(validators)
+	wire := capture.OnWire(records)
+	for _, msg := range wire {
+		tracker.Add(msg)
+	}
+
+	// Safety and liveness are agreement and progress over what went on the wire
+	props := properties.Check(e.propertiesConfig(0), wire)
+	outcome := Outcome{Committed: map[int64]map[string][]string{}}
+	for _, commit := range props.Commits {
+		if outcome.Committed[commit.Height] == nil {
+			outcome.Committed[commit.Height] = map[string][]string{}
+		}
+		outcome.Committed[commit.Height][commit.Block] = commit.Validators
+	}
+	agreement, _ := props.Verdict(properties.Agreement)
+	for _, violation := range agreement.Violations {
+		outcome.SafetyViolated = true
+		outcome.Events = append(outcome.Events, fmt.Sprintf("height %d: %s: %s", violation.Height, violation.Detail, strings.Join(violation.Blocks, ", ")))
+	}
+	progress, _ := props.Verdict(properties.Progress)
+	if len(progress.Violations) > 0 {
+		outcome.LivenessStalled = true
+		violation := progress.Violations[0]
+		outcome.Events = append(outcome.Events, fmt.Sprintf("height %d: %s", violation.Height, violation.Detail))
+	}
+	for _, eq := range tracker.Equivocations() {
+		outcome.EvidenceProduced = true
+		outcome.Events = append(outcome.Events, eq.String())
+	}
+	report := e.report(TargetByzproxy, outcome)
+	report.Properties = props
+	return report, nil
+}
@@ -0,0 +1,450 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cometbftAdapter "codec/cometbft/adapter"
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+	"codec/message/abstraction/properties"
+	"codec/message/abstraction/quorum"
+	"gopkg.in/yaml.v3"
+)
+
+// Validator roles in an experiment.
+const (
+	RoleHonest    = "honest"
+	RoleByzantine = "byzantine"
+)
+
+// Experiment targets.
+const (
+	// TargetSimnet runs the experiment in process, with one CometBFT consensus engine per honest validator.
+	TargetSimnet = "simnet"
+	// TargetByzproxy runs it on a live network: the attacks become a byzproxy phase file and the outcome is
+	// judged from the capture byzproxy records.
+	TargetByzproxy = "byzproxy"
+)
+
+// Experiment describes a multi-height byzantine experiment: the validator set and which validators are
+// byzantine, the attacks they mount at each height, and the outcome expected. Where a Scenario mutates a few
+// hand-written messages, an Experiment drives whole heights of consensus.
+type Experiment struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	ChainID     string `json:"chain_id,omitempty"`
+	// Seed makes simnet runs reproducible, see abstraction.Seed.
+	Seed int64 `json:"seed,omitempty"`
+	// StartHeight is the first height run, 1 by default, and Heights how many are run.
+	StartHeight int64 `json:"start_height,omitempty"`
+	Heights     int   `json:"heights"`
+	// MaxRounds bounds the rounds simnet spends on a height before it counts as stalled; 3 by default.
+	MaxRounds  int                   `json:"max_rounds,omitempty"`
+	Validators []ExperimentValidator `json:"validators"`
+	Attacks    []HeightAttack        `json:"attacks,omitempty"`
+	Expect     Expectation           `json:"expect"`
+}
+
+// ExperimentValidator is one member of the validator set. On byzproxy the name must be the validator's
+// address as it appears in canonical messages.
+type ExperimentValidator struct {
+	Name string `json:"name"`
+	// Power is the voting power, 10 by default.
+	Power int64 `json:"power,omitempty"`
+	// Role is honest, the default, or byzantine.
+	Role string `json:"role,omitempty"`
+}
+
+// HeightAttack is a byzantine action some byzantine validators apply to their own messages over a range of
+// heights. When several attacks match a message the first one wins.
+type HeightAttack struct {
+	// Heights is a height ("5") or an inclusive height range ("5..8").
+	Heights string `json:"heights"`
+	// Validators names the attackers; every byzantine validator by default.
+	Validators []string `json:"validators,omitempty"`
+	// Step limits the attack to proposals, prevotes or precommits.
+	Step    string      `json:"step,omitempty"`
+	Action  string      `json:"action,omitempty"`
+	Options StepOptions `json:"options,omitempty"`
+	// Drop withholds the messages instead of sending them.
+	Drop bool `json:"drop,omitempty"`
+	// Split sends each variant the action emits to a different share of the honest validators, as byzproxy's
+	// --split-peers does, instead of every variant to all of them.
+	Split bool `json:"split,omitempty"`
+}
+
+// Expectation lists the outcomes an experiment must produce. Outcomes left unset are reported but not
+// checked.
+type Expectation struct {
+	// SafetyViolated expects honest validators to commit different blocks at some height.
+	SafetyViolated *bool `json:"safety_violated,omitempty"`
+	// LivenessStalled expects some height to end without any honest validator committing.
+	LivenessStalled *bool `json:"liveness_stalled,omitempty"`
+	// EvidenceProduced expects an honest validator to see a validator sign conflicting messages.
+	EvidenceProduced *bool `json:"evidence_produced,omitempty"`
+}
+
+// Outcome is what an experiment run observed.
+type Outcome struct {
+	SafetyViolated   bool `json:"safety_violated"`
+	LivenessStalled  bool `json:"liveness_stalled"`
+	EvidenceProduced bool `json:"evidence_produced"`
+	// Committed maps each height to the blocks committed there; simnet lists the honest validators that
+	// committed each block, byzproxy the validators that precommitted it.
+	Committed map[int64]map[string][]string `json:"committed,omitempty"`
+	// Events explains the outcome: conflicting commits, stalled heights and evidence, in order.
+	Events []string `json:"events,omitempty"`
+}
+
+// ExperimentReport is the pass/fail report of an experiment run.
+type ExperimentReport struct {
+	Experiment string             `json:"experiment"`
+	Target     string             `json:"target"`
+	Outcome    Outcome            `json:"outcome"`
+	Checks     []AssertionOutcome `json:"checks"`
+	// Properties holds the agreement, validity and progress verdicts over the run's message log.
+	Properties *properties.Report `json:"properties,omitempty"`
+}
+
+// Passed reports whether the run produced every expected outcome.
+func (r *ExperimentReport) Passed() bool {
+	for _, check := range r.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the report for a terminal.
+func (r *ExperimentReport) String() string {
+	var b strings.Builder
+	status := "PASS"
+	if !r.Passed() {
+		status = "FAIL"
+	}
+	fmt.Fprintf(&b, "%s %s on %s\n", status, r.Experiment, r.Target)
+	for _, check := range r.Checks {
+		mark := "✅"
+		if !check.Passed {
+			mark = "❌"
+		}
+		fmt.Fprintf(&b, "  %s %s: %s\n", mark, check.Kind, check.Detail)
+	}
+	if r.Properties != nil {
+		for _, verdict := range r.Properties.Verdicts {
+			state := "holds"
+			if !verdict.Holds {
+				state = "violated"
+			}
+			fmt.Fprintf(&b, "  %s %s: %s\n", verdict.Property, state, verdict.Detail)
+		}
+	}
+	for _, event := range r.Outcome.Events {
+		fmt.Fprintf(&b, "  - %s\n", event)
+	}
+	return b.String()
+}
+
+// ParseExperiment decodes an experiment from JSON and checks that it is runnable.
+func ParseExperiment(data []byte) (*Experiment, error) {
+	var e Experiment
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("failed to decode experiment: %w", err)
+	}
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// LoadExperiment reads an experiment from a YAML or JSON file; the format is chosen by extension and JSON
+// is assumed for anything but .yaml and .yml.
+func LoadExperiment(path string) (*Experiment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read experiment: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// Going through JSON keeps one set of field names and the duration strings' decoding.
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("parse experiment %s: %w", path, err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("parse experiment %s: %w", path, err)
+		}
+	}
+	e, err := ParseExperiment(data)
+	if err != nil {
+		return nil, fmt.Errorf("experiment %s: %w", path, err)
+	}
+	return e, nil
+}
+
+// Validate checks the validator set, that only byzantine validators attack and that every attack parses.
+func (e *Experiment) Validate() error {
+	if strings.TrimSpace(e.Name) == "" {
+		return fmt.Errorf("experiment has no name")
+	}
+	if e.Heights <= 0 {
+		return fmt.Errorf("experiment %s: heights must be positive", e.Name)
+	}
+	if e.StartHeight < 0 || e.MaxRounds < 0 {
+		return fmt.Errorf("experiment %s: start_height and max_rounds must not be negative", e.Name)
+	}
+	roles := make(map[string]string, len(e.Validators))
+	honest := 0
+	for i, v := range e.Validators {
+		if strings.TrimSpace(v.Name) == "" {
+			return fmt.Errorf("experiment %s: validator %d has no name", e.Name, i+1)
+		}
+		if _, dup := roles[v.Name]; dup {
+			return fmt.Errorf("experiment %s: validator %s is listed twice", e.Name, v.Name)
+		}
+		if v.Power < 0 {
+			return fmt.Errorf("experiment %s: validator %s has negative power", e.Name, v.Name)
+		}
+		switch v.role() {
+		case RoleHonest:
+			honest++
+		case RoleByzantine:
+		default:
+			return fmt.Errorf("experiment %s: validator %s has unknown role %q (honest|byzantine)", e.Name, v.Name, v.Role)
+		}
+		roles[v.Name] = v.role()
+	}
+	if honest == 0 {
+		return fmt.Errorf("experiment %s: needs at least one honest validator", e.Name)
+	}
+	for i, attack := range e.Attacks {
+		if _, err := attack.compile(roles); err != nil {
+			return fmt.Errorf("experiment %s: attack %d: %w", e.Name, i+1, err)
+		}
+	}
+	if e.Expect.SafetyViolated == nil && e.Expect.LivenessStalled == nil && e.Expect.EvidenceProduced == nil {
+		return fmt.Errorf("experiment %s: expect names no outcome", e.Name)
+	}
+	return nil
+}
+
+// SplitsPeers reports whether an attack splits its variants among the honest validators, which byzproxy
+// does for every attack when started with --split-peers.
+func (e *Experiment) SplitsPeers() bool {
+	for _, attack := range e.Attacks {
+		if attack.Split {
+			return true
+		}
+	}
+	return false
+}
+
+func (v ExperimentValidator) role() string {
+	if v.Role == "" {
+		return RoleHonest
+	}
+	return strings.ToLower(v.Role)
+}
+
+func (v ExperimentValidator) power() int64 {
+	if v.Power == 0 {
+		return 10
+	}
+	return v.Power
+}
+
+func (e *Experiment) startHeight() int64 {
+	if e.StartHeight == 0 {
+		return 1
+	}
+	return e.StartHeight
+}
+
+func (e *Experiment) maxRounds() int {
+	if e.MaxRounds == 0 {
+		return 3
+	}
+	return e.MaxRounds
+}
+
+func (e *Experiment) chainID() string {
+	if e.ChainID == "" {
+		return "experiment-" + e.Name
+	}
+	return e.ChainID
+}
+
+// compiledAttack is a HeightAttack resolved against the validator set.
+type compiledAttack struct {
+	from, to  int64
+	attackers map[string]bool
+	step      abstraction.MsgType
+	action    byzantine.Action
+	opts      byzantine.Options
+	drop      bool
+	split     bool
+}
+
+// compile resolves the attack against the validators' roles.
+func (a HeightAttack) compile(roles map[string]string) (compiledAttack, error) {
+	from, to, err := byzantine.ParseRange(a.Heights)
+	if err != nil {
+		return compiledAttack{}, fmt.Errorf("heights: %w", err)
+	}
+	c := compiledAttack{from: from, to: to, attackers: map[string]bool{}, opts: a.Options.Options(), drop: a.Drop, split: a.Split}
+	for _, name := range a.Validators {
+		switch roles[name] {
+		case RoleByzantine:
+			c.attackers[name] = true
+		case RoleHonest:
+			return compiledAttack{}, fmt.Errorf("validator %s is honest and cannot attack", name)
+		default:
+			return compiledAttack{}, fmt.Errorf("unknown validator %s", name)
+		}
+	}
+	if len(a.Validators) == 0 {
+		for name, role := range roles {
+			if role == RoleByzantine {
+				c.attackers[name] = true
+			}
+		}
+		if len(c.attackers) == 0 {
+			return compiledAttack{}, fmt.Errorf("no byzantine validator to attack")
+		}
+	}
+	switch step := abstraction.MsgType(strings.ToLower(a.Step)); step {
+	case "", abstraction.MsgTypeProposal, abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit:
+		c.step = step
+	default:
+		return compiledAttack{}, fmt.Errorf("unknown step %q (proposal|prevote|precommit)", a.Step)
+	}
+	if a.Drop && a.Action != "" {
+		return compiledAttack{}, fmt.Errorf("drop withholds messages and takes no action")
+	}
+	c.action = byzantine.Action(cometbftAdapter.ByzantineActionNone)
+	if a.Action != "" {
+		if c.action, err = cometbftAdapter.ByzantineEngine.Parse(a.Action); err != nil {
+			return compiledAttack{}, err
+		}
+	}
+	return c, nil
+}
+
+func (c compiledAttack) matches(height int64, validator string, step abstraction.MsgType) bool {
+	return height >= c.from && height <= c.to && c.attackers[validator] && (c.step == "" || c.step == step)
+}
+
+// ProxyScenario is the phase file byzproxy reads with --scenario.
+type ProxyScenario struct {
+	Name   string       `json:"name"`
+	Phases []ProxyPhase `json:"phases"`
+}
+
+// ProxyPhase mirrors the fields of a byzproxy scenario phase that experiments use.
+type ProxyPhase struct {
+	Name       string       `json:"name"`
+	Heights    string       `json:"heights"`
+	Step       string       `json:"step,omitempty"`
+	Validators []string     `json:"validators,omitempty"`
+	Action     string       `json:"action,omitempty"`
+	Options    *StepOptions `json:"options,omitempty"`
+	Drop       bool         `json:"drop,omitempty"`
+}
+
+// ProxyScenario converts the attacks into byzproxy phases, ordered by height. byzproxy runs one phase at a
+// time and shifts timestamps only through --timestamp-skew, so overlapping attacks and timestamp shifts are
+// rejected.
+func (e *Experiment) ProxyScenario() (*ProxyScenario, error) {
+	type ranged struct {
+		from, to int64
+		attack   HeightAttack
+	}
+	attacks := make([]ranged, 0, len(e.Attacks))
+	for i, attack := range e.Attacks {
+		from, to, err := byzantine.ParseRange(attack.Heights)
+		if err != nil {
+			return nil, fmt.Errorf("attack %d: heights: %w", i+1, err)
+		}
+		if attack.Options.TimestampShift != 0 {
+			return nil, fmt.Errorf("attack %d: byzproxy phases cannot shift timestamps; use --timestamp-skew", i+1)
+		}
+		attacks = append(attacks, ranged{from: from, to: to, attack: attack})
+	}
+	sort.SliceStable(attacks, func(i, j int) bool { return attacks[i].from < attacks[j].from })
+
+	sc := &ProxyScenario{Name: e.Name}
+	for i, r := range attacks {
+		if i > 0 && r.from <= attacks[i-1].to {
+			return nil, fmt.Errorf("attacks on heights %s and %s overlap; byzproxy runs one phase at a time", attacks[i-1].attack.Heights, r.attack.Heights)
+		}
+		phase := ProxyPhase{
+			Name:       fmt.Sprintf("heights %s", r.attack.Heights),
+			Heights:    r.attack.Heights,
+			Step:       strings.ToLower(r.attack.Step),
+			Validators: r.attack.Validators,
+			Action:     r.attack.Action,
+			Drop:       r.attack.Drop,
+		}
+		if phase.Validators == nil {
+			for _, v := range e.Validators {
+				if v.role() == RoleByzantine {
+					phase.Validators = append(phase.Validators, v.Name)
+				}
+			}
+		}
+		if r.attack.Options != (StepOptions{}) {
+			options := r.attack.Options
+			phase.Options = &options
+		}
+		sc.Phases = append(sc.Phases, phase)
+	}
+	return sc, nil
+}
+
+// propertiesConfig describes the experiment to the property checker: every height run must commit, within
+// maxRounds rounds unless it is zero.
+func (e *Experiment) propertiesConfig(maxRounds int64) properties.Config {
+	cfg := properties.Config{
+		Validators: quorum.ValidatorSet{},
+		From:       e.startHeight(),
+		To:         e.startHeight() + int64(e.Heights) - 1,
+		MaxRounds:  maxRounds,
+	}
+	for _, v := range e.Validators {
+		cfg.Validators[v.Name] = v.power()
+		if v.role() == RoleByzantine {
+			cfg.Byzantine = append(cfg.Byzantine, v.Name)
+		}
+	}
+	return cfg
+}
+
+// report compares the observed outcome with the expectation.
+func (e *Experiment) report(target string, outcome Outcome) *ExperimentReport {
+	report := &ExperimentReport{Experiment: e.Name, Target: target, Outcome: outcome}
+	for _, c := range []struct {
+		kind     string
+		expected *bool
+		observed bool
+	}{
+		{"safety_violated", e.Expect.SafetyViolated, outcome.SafetyViolated},
+		{"liveness_stalled", e.Expect.LivenessStalled, outcome.LivenessStalled},
+		{"evidence_produced", e.Expect.EvidenceProduced, outcome.EvidenceProduced},
+	} {
+		if c.expected == nil {
+			continue
+		}
+		report.Checks = append(report.Checks, AssertionOutcome{
+			Kind:   c.kind,
+			Passed: *c.expected == c.observed,
+			Detail: fmt.Sprintf("observed %t, want %t", c.observed, *c.expected),
+		})
+	}
+	return report
+}
@@ -0,0 +1,200 @@
+package scenario
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"codec/capture"
+	"codec/message/abstraction"
+	"codec/message/abstraction/properties"
+)
+
+// TestExampleExperiments runs every example experiment on the simulated network and expects it to pass.
+func TestExampleExperiments(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "configs", "experiments", "*.yaml"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no example experiments found: %v", err)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			e, err := LoadExperiment(path)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			report, err := e.RunSimnet(nil)
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if !report.Passed() {
+				t.Fatalf("experiment failed:\n%s", report)
+			}
+			agreement, _ := report.Properties.Verdict(properties.Agreement)
+			progress, _ := report.Properties.Verdict(properties.Progress)
+			if agreement.Holds == report.Outcome.SafetyViolated || progress.Holds == report.Outcome.LivenessStalled {
+				t.Fatalf("the message log disagrees with the engines:\n%s", report)
+			}
+		})
+	}
+}
+
+func TestExperimentSimnetIsReproducible(t *testing.T) {
+	run := func() string {
+		e, err := LoadExperiment(filepath.Join("..", "configs", "experiments", "split-brain.yaml"))
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		report, err := e.RunSimnet(nil)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		return report.String()
+	}
+	if first, second := run(), run(); first != second {
+		t.Fatalf("seeded runs differ:\n%s\n---\n%s", first, second)
+	}
+}
+
+func TestExperimentValidate(t *testing.T) {
+	cases := map[string]struct {
+		doc  string
+		want string
+	}{
+		"honest attacker": {
+			doc: `name: x
+heights: 1
+validators: [{name: a}, {name: b, role: byzantine}]
+attacks: [{heights: "1", validators: [a], action: double_vote}]
+expect: {safety_violated: false}`,
+			want: "is honest and cannot attack",
+		},
+		"unknown step": {
+			doc: `name: x
+heights: 1
+validators: [{name: a}, {name: b, role: byzantine}]
+attacks: [{heights: "1", step: commit, action: double_vote}]
+expect: {safety_violated: false}`,
+			want: "step",
+		},
+		"no honest validator": {
+			doc: `name: x
+heights: 1
+validators: [{name: b, role: byzantine}]
+expect: {safety_violated: false}`,
+			want: "honest",
+		},
+		"no expectation": {
+			doc: `name: x
+heights: 1
+validators: [{name: a}]`,
+			want: "expect",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadExperimentDoc(t, tc.doc)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("want error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestExperimentProxyScenario(t *testing.T) {
+	e, err := loadExperimentDoc(t, `name: x
+heights: 4
+validators: [{name: a}, {name: b}, {name: c}, {name: z, role: byzantine}]
+attacks:
+  - {heights: "3..4", drop: true}
+  - {heights: "1", step: prevote, action: double_vote}
+expect: {liveness_stalled: false}`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	sc, err := e.ProxyScenario()
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if len(sc.Phases) != 2 || sc.Phases[0].Heights != "1" || sc.Phases[1].Heights != "3..4" {
+		t.Fatalf("phases not ordered by height: %+v", sc.Phases)
+	}
+	if got := sc.Phases[0].Validators; len(got) != 1 || got[0] != "z" {
+		t.Fatalf("phase should default to the byzantine validators, got %v", got)
+	}
+
+	e.Attacks = append(e.Attacks, HeightAttack{Heights: "4..5", Action: "double_vote"})
+	if _, err := e.ProxyScenario(); err == nil || !strings.Contains(err.Error(), "overlap") {
+		t.Fatalf("want overlap error, got %v", err)
+	}
+}
+
+// TestEvaluateCapture judges a capture in which the proxy turned a byzantine precommit into one for each
+// half of the honest validators. The byzantine validator holds enough power that both blocks reach +2/3.
+func TestEvaluateCapture(t *testing.T) {
+	e, err := loadExperimentDoc(t, `name: x
+heights: 1
+validators: [{name: a}, {name: b}, {name: c}, {name: d}, {name: z, power: 30, role: byzantine}]
+attacks: [{heights: "1", step: precommit, action: double_vote, split: true}]
+expect: {safety_violated: true, evidence_produced: true}`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	vote := func(validator, block string) *abstraction.CanonicalMessage {
+		return &abstraction.CanonicalMessage{
+			Height:    big.NewInt(1),
+			Round:     big.NewInt(0),
+			Type:      abstraction.MsgTypePrecommit,
+			Validator: validator,
+			BlockHash: block,
+		}
+	}
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	w, err := capture.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for _, rec := range []*capture.Record{
+		{Source: "a", Event: "received", Canonical: vote("a", "AA")},
+		{Source: "b", Event: "received", Canonical: vote("b", "AA")},
+		{Source: "c", Event: "received", Canonical: vote("c", "BB")},
+		{Source: "d", Event: "received", Canonical: vote("d", "BB")},
+		{Source: "z", Event: "received", Canonical: vote("z", "AA")},
+		{Source: "z", Event: "mutated", Canonical: vote("z", "AA")},
+		{Source: "z", Event: "mutated", Canonical: vote("z", "BB")},
+	} {
+		rec.Time = time.Unix(0, 0)
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	report, err := e.EvaluateCapture(path)
+	if err != nil {
+		t.Fatalf("evaluate: %v", err)
+	}
+	if !report.Passed() {
+		t.Fatalf("experiment failed:\n%s", report)
+	}
+	for block, voters := range report.Outcome.Committed[1] {
+		if len(voters) != 3 || voters[2] != "z" {
+			t.Fatalf("want %s precommitted by two honest validators and z, got %v", block, voters)
+		}
+	}
+}
+
+// loadExperimentDoc loads an experiment written in YAML the way LoadExperiment reads it from a file.
+func loadExperimentDoc(t *testing.T, doc string) (*Experiment, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "experiment.yaml")
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatalf("write experiment: %v", err)
+	}
+	return LoadExperiment(path)
+}
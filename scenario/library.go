@@ -0,0 +1,44 @@
+package scenario
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// libraryFS holds the curated attack scenarios, one JSON file per scenario named after it.
+//
+//go:embed library/*.json
+var libraryFS embed.FS
+
+// LibraryNames lists the scenarios in the embedded library.
+func LibraryNames() []string {
+	entries, err := libraryFS.ReadDir("library")
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Library loads a scenario from the embedded library by name.
+func Library(name string) (*Scenario, error) {
+	data, err := libraryFS.ReadFile(path.Join("library", name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown library scenario %q (available: %s)", name, strings.Join(LibraryNames(), ", "))
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if s.Name != name {
+		return nil, fmt.Errorf("library file %s.json declares scenario %q", name, s.Name)
+	}
+	return s, nil
+}
@@ -0,0 +1,257 @@
+// Package scenario runs named byzantine attack scenarios against chain adapters and checks their outcome
+// with assertions. A curated library of published attack patterns ships embedded in the binary.
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	cometbftAdapter "codec/cometbft/adapter"
+	ethereumAdapter "codec/ethereum/adapter"
+	fabricAdapter "codec/hyperledger/fabric/adapter"
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+)
+
+// LibraryPrefix selects a scenario from the embedded library, e.g. "library:equivocation-fork".
+const LibraryPrefix = "library:"
+
+// Citation points at the publication or specification an attack pattern comes from.
+type Citation struct {
+	Title   string `json:"title"`
+	Authors string `json:"authors,omitempty"`
+	Venue   string `json:"venue,omitempty"`
+	Year    int    `json:"year,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// String renders the citation in a single line.
+func (c Citation) String() string {
+	parts := make([]string, 0, 4)
+	if c.Authors != "" {
+		parts = append(parts, c.Authors)
+	}
+	parts = append(parts, fmt.Sprintf("%q", c.Title))
+	if c.Venue != "" {
+		parts = append(parts, c.Venue)
+	}
+	if c.Year != 0 {
+		parts = append(parts, fmt.Sprintf("%d", c.Year))
+	}
+	line := strings.Join(parts, ", ")
+	if c.URL != "" {
+		line += " <" + c.URL + ">"
+	}
+	return line
+}
+
+// Scenario is an attack pattern parameterized per chain.
+type Scenario struct {
+	Name        string              `json:"name"`
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Citations   []Citation          `json:"citations"`
+	Chains      map[string]ChainRun `json:"chains"`
+}
+
+// ChainRun is the chain-specific part of a scenario: the messages to mutate and the expected outcome.
+type ChainRun struct {
+	Steps      []Step      `json:"steps"`
+	Assertions []Assertion `json:"assert"`
+}
+
+// Step applies one byzantine action to one canonical message.
+type Step struct {
+	Message abstraction.CanonicalMessage `json:"message"`
+	Action  string                       `json:"action"`
+	Options StepOptions                  `json:"options,omitempty"`
+}
+
+// StepOptions mirrors byzantine.Options in a form that can be written by hand.
+type StepOptions struct {
+	AlternateBlockHash string   `json:"alternate_block_hash,omitempty"`
+	AlternatePrevHash  string   `json:"alternate_prev_hash,omitempty"`
+	AlternateSignature string   `json:"alternate_signature,omitempty"`
+	AlternateValidator string   `json:"alternate_validator,omitempty"`
+	RoundOffset        int64    `json:"round_offset,omitempty"`
+	HeightOffset       int64    `json:"height_offset,omitempty"`
+	TimestampShift     Duration `json:"timestamp_shift,omitempty"`
+	EmitBoth           bool     `json:"emit_both,omitempty"`
+}
+
+// Options converts the step options for the byzantine engine.
+func (o StepOptions) Options() byzantine.Options {
+	return byzantine.Options{
+		AlternateBlockHash: o.AlternateBlockHash,
+		AlternatePrevHash:  o.AlternatePrevHash,
+		AlternateSignature: o.AlternateSignature,
+		AlternateValidator: o.AlternateValidator,
+		RoundOffset:        o.RoundOffset,
+		HeightOffset:       o.HeightOffset,
+		TimestampShift:     time.Duration(o.TimestampShift),
+		EmitBoth:           o.EmitBoth,
+	}
+}
+
+// Duration is a time.Duration written as a Go duration string ("250ms") in scenario files.
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("duration must be a string such as \"250ms\": %w", err)
+	}
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Parse decodes a scenario and checks that it is runnable.
+func Parse(data []byte) (*Scenario, error) {
+	var s Scenario
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to decode scenario: %w", err)
+	}
+	if strings.TrimSpace(s.Name) == "" {
+		return nil, fmt.Errorf("scenario has no name")
+	}
+	if len(s.Chains) == 0 {
+		return nil, fmt.Errorf("scenario %s has no chains", s.Name)
+	}
+	for chain, run := range s.Chains {
+		if len(run.Steps) == 0 {
+			return nil, fmt.Errorf("scenario %s: chain %s has no steps", s.Name, chain)
+		}
+		if len(run.Assertions) == 0 {
+			return nil, fmt.Errorf("scenario %s: chain %s has no assertions", s.Name, chain)
+		}
+		for i, assertion := range run.Assertions {
+			if _, ok := checks[assertion.Kind]; !ok {
+				return nil, fmt.Errorf("scenario %s: chain %s assertion %d: unknown kind %q", s.Name, chain, i+1, assertion.Kind)
+			}
+		}
+	}
+	return &s, nil
+}
+
+// Load reads a scenario from a library reference ("library:<name>") or a file path.
+func Load(ref string) (*Scenario, error) {
+	if name, ok := strings.CutPrefix(ref, LibraryPrefix); ok {
+		return Library(name)
+	}
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+	return Parse(data)
+}
+
+// ChainNames lists the chains the scenario is parameterized for.
+func (s *Scenario) ChainNames() []string {
+	names := make([]string, 0, len(s.Chains))
+	for name := range s.Chains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Target is a chain the scenario can run against.
+type Target struct {
+	Engine  *byzantine.Engine
+	Encoder byzantine.Encoder
+}
+
+// DefaultTarget returns the byzantine engine and mapper for a chain name.
+func DefaultTarget(chain, chainID string) (Target, error) {
+	switch abstraction.ChainType(strings.ToLower(chain)) {
+	case abstraction.ChainTypeCometBFT:
+		return Target{Engine: cometbftAdapter.ByzantineEngine.Engine, Encoder: cometbftAdapter.NewCometBFTMapper(chainID)}, nil
+	case abstraction.ChainTypeFabric:
+		return Target{Engine: fabricAdapter.ByzantineEngine, Encoder: fabricAdapter.NewFabricMapper(chainID)}, nil
+	case abstraction.ChainTypeFabricRaft:
+		return Target{Engine: fabricAdapter.RaftByzantineEngine, Encoder: fabricAdapter.NewFabricRaftMapper(chainID)}, nil
+	case abstraction.ChainTypeEthereum:
+		return Target{Engine: ethereumAdapter.ByzantineEngine, Encoder: ethereumAdapter.NewEthereumMapper(chainID)}, nil
+	default:
+		return Target{}, fmt.Errorf("no byzantine engine for chain %q", chain)
+	}
+}
+
+// Output is one message emitted by a step.
+type Output struct {
+	Step      int                              `json:"step"`
+	Input     *abstraction.CanonicalMessage    `json:"input"`
+	Canonical *abstraction.CanonicalMessage    `json:"canonical"`
+	Raw       *abstraction.RawConsensusMessage `json:"raw"`
+}
+
+// Result is the outcome of running a scenario on one chain.
+type Result struct {
+	Scenario string             `json:"scenario"`
+	Chain    string             `json:"chain"`
+	Outputs  []Output           `json:"outputs"`
+	Checks   []AssertionOutcome `json:"checks"`
+}
+
+// Passed reports whether every assertion held.
+func (r *Result) Passed() bool {
+	for _, check := range r.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Run executes the scenario's steps for chain against target and evaluates its assertions.
+// Errors are reserved for scenarios that cannot run; failed assertions are reported in the result.
+func (s *Scenario) Run(chain string, target Target) (*Result, error) {
+	run, ok := s.Chains[chain]
+	if !ok {
+		return nil, fmt.Errorf("scenario %s is not parameterized for chain %s (available: %s)", s.Name, chain, strings.Join(s.ChainNames(), ", "))
+	}
+
+	result := &Result{Scenario: s.Name, Chain: chain}
+	for i, step := range run.Steps {
+		input := byzantine.Clone(&step.Message)
+		action, err := target.Engine.Parse(step.Action)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		canonicals, err := target.Engine.Apply(input, action, step.Options.Options())
+		if err != nil {
+			return nil, fmt.Errorf("step %d (%s): %w", i+1, action, err)
+		}
+		raws, err := byzantine.Encode(target.Encoder, canonicals)
+		if err != nil {
+			return nil, fmt.Errorf("step %d (%s): failed to encode: %w", i+1, action, err)
+		}
+		for _, raw := range raws {
+			if raw.Payload, err = target.Engine.MutatePayload(action, raw.Payload, step.Options.Options()); err != nil {
+				return nil, fmt.Errorf("step %d (%s): %w", i+1, action, err)
+			}
+		}
+		for j, canonical := range canonicals {
+			result.Outputs = append(result.Outputs, Output{Step: i + 1, Input: input, Canonical: canonical, Raw: raws[j]})
+		}
+	}
+
+	for _, assertion := range run.Assertions {
+		result.Checks = append(result.Checks, assertion.Evaluate(result.Outputs))
+	}
+	return result, nil
+}
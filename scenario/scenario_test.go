@@ -0,0 +1,106 @@
+package scenario
+
+import (
+	"strings"
+	"testing"
+
+	"codec/message/abstraction"
+	"codec/message/abstraction/byzantine"
+	"codec/message/abstraction/lint"
+)
+
+// TestLibraryRegression runs every library scenario on every chain it is parameterized for.
+func TestLibraryRegression(t *testing.T) {
+	names := LibraryNames()
+	for _, want := range []string{"equivocation-fork", "silence-liveness", "round-change-storm", "timestamp-manipulation"} {
+		if !contains(names, want) {
+			t.Fatalf("library is missing %s (have %v)", want, names)
+		}
+	}
+
+	for _, name := range names {
+		s, err := Load(LibraryPrefix + name)
+		if err != nil {
+			t.Fatalf("load %s: %v", name, err)
+		}
+		if len(s.Citations) == 0 {
+			t.Errorf("%s: library scenarios must cite their source", name)
+		}
+		for _, chain := range s.ChainNames() {
+			t.Run(name+"/"+chain, func(t *testing.T) {
+				for i, step := range s.Chains[chain].Steps {
+					action := byzantine.Action(step.Action)
+					for _, issue := range lint.Lint(&step.Message, lint.DefaultProfile(abstraction.ChainType(chain)), action) {
+						if issue.Severity == lint.SeverityError {
+							t.Errorf("step %d: lint %s: %s", i+1, issue.Code, issue.Message)
+						}
+					}
+				}
+
+				target, err := DefaultTarget(chain, "scenario-regression")
+				if err != nil {
+					t.Fatalf("target: %v", err)
+				}
+				result, err := s.Run(chain, target)
+				if err != nil {
+					t.Fatalf("run: %v", err)
+				}
+				for _, check := range result.Checks {
+					if !check.Passed {
+						t.Errorf("assertion %s failed: %s", check.Kind, check.Detail)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestAssertionsReportFailures(t *testing.T) {
+	s, err := Parse([]byte(`{
+		"name": "honest",
+		"chains": {"cometbft": {
+			"steps": [{"message": {"chain_id": "c", "height": 1, "round": 0, "type": "prevote", "block_hash": "AA", "validator": "v"}, "action": "none"}],
+			"assert": [{"kind": "conflicting_votes"}, {"kind": "emitted", "count": 1}]
+		}}
+	}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	target, err := DefaultTarget("cometbft", "c")
+	if err != nil {
+		t.Fatalf("target: %v", err)
+	}
+	result, err := s.Run("cometbft", target)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.Passed() {
+		t.Fatalf("expected honest vote to fail the conflicting_votes assertion")
+	}
+	if !result.Checks[1].Passed {
+		t.Fatalf("expected emitted assertion to pass: %s", result.Checks[1].Detail)
+	}
+
+	if _, err := s.Run("fabric", target); err == nil || !strings.Contains(err.Error(), "not parameterized") {
+		t.Fatalf("expected unknown chain to be rejected, got %v", err)
+	}
+}
+
+func TestParseRejectsUnknownAssertion(t *testing.T) {
+	_, err := Parse([]byte(`{"name": "x", "chains": {"cometbft": {"steps": [{"action": "none"}], "assert": [{"kind": "finality"}]}}}`))
+	if err == nil || !strings.Contains(err.Error(), "finality") {
+		t.Fatalf("expected unknown assertion kind to be rejected, got %v", err)
+	}
+	if _, err := Load(LibraryPrefix + "missing"); err == nil {
+		t.Fatalf("expected unknown library scenario to be rejected")
+	}
+}
+
+func contains(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,283 @@
+package scenario
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"sort"
+	"strings"
+
+	"codec/cometbft"
+	cometbftAdapter "codec/cometbft/adapter"
+	"codec/message/abstraction"
+	"codec/message/abstraction/properties"
+)
+
+// simnet runs an experiment in process. Every honest validator is a CometBFT consensus engine that decides
+// its own prevotes and precommits under the locking rules; byzantine validators follow the protocol except
+// where an attack rewrites, multiplies or withholds their messages.
+type simnet struct {
+	e       *Experiment
+	chainID string
+	nodes   []*simNode
+	roles   map[string]string
+	order   []string
+	attacks []compiledAttack
+	outcome Outcome
+	// log holds every message sent, once however many validators it reached
+	log []*abstraction.CanonicalMessage
+}
+
+type simNode struct {
+	name   string
+	engine *cometbft.ConsensusEngine
+}
+
+// RunSimnet runs the experiment on the in-process simulated network and reports its outcome. Each round the
+// proposer proposes, then every validator prevotes and precommits, and honest validators that did not
+// commit move to the next round as if their timeouts fired. A height on which no honest validator commits
+// within MaxRounds stalls the run. The engines' progress log goes to log as text, each record naming its node, and log may be nil. The messages sent
+// are checked for agreement, validity and progress as well.
+func (e *Experiment) RunSimnet(log io.Writer) (*ExperimentReport, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	if e.Seed != 0 {
+		abstraction.Seed(e.Seed)
+	}
+	logger := slog.New(slog.DiscardHandler)
+	if log != nil {
+		logger = slog.New(slog.NewTextHandler(log, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	s := &simnet{e: e, chainID: e.chainID(), roles: map[string]string{}}
+	validators := make([]cometbft.Validator, len(e.Validators))
+	for i, v := range e.Validators {
+		validators[i] = cometbft.Validator{Address: v.Name, VotingPower: v.power()}
+		s.roles[v.Name] = v.role()
+		s.order = append(s.order, v.Name)
+	}
+	for _, v := range e.Validators {
+		if v.role() != RoleHonest {
+			continue
+		}
+		engine := cometbft.NewConsensusEngine(validators)
+		engine.SetLogger(logger.With("node", v.Name))
+		engine.AdvanceHeight(e.startHeight())
+		s.nodes = append(s.nodes, &simNode{name: v.Name, engine: engine})
+	}
+	for _, attack := range e.Attacks {
+		compiled, err := attack.compile(s.roles)
+		if err != nil {
+			return nil, err
+		}
+		s.attacks = append(s.attacks, compiled)
+	}
+
+	s.outcome.Committed = map[int64]map[string][]string{}
+	for height := e.startHeight(); height < e.startHeight()+int64(e.Heights); height++ {
+		for _, n := range s.nodes {
+			// Validators left behind at the previous height catch up by block sync
+			if n.engine.GetCurrentHeight() < height {
+				n.engine.AdvanceHeight(height)
+			}
+		}
+		for round := int32(0); round < int32(e.maxRounds()); round++ {
+			participants := s.participants(height)
+			if len(participants) == 0 {
+				break
+			}
+			s.round(height, round, participants)
+		}
+		if !s.judge(height) {
+			break
+		}
+	}
+
+	for _, n := range s.nodes {
+		for _, ev := range n.engine.GetEvidence() {
+			s.outcome.EvidenceProduced = true
+			s.outcome.Events = append(s.outcome.Events, fmt.Sprintf("%s found %s evidence against %s: %s at height %d round %d",
+				n.name, ev.Type, ev.Validator, ev.Second.Type, ev.Height, ev.Round))
+		}
+	}
+	report := e.report(TargetSimnet, s.outcome)
+	report.Properties = properties.Check(e.propertiesConfig(int64(e.maxRounds())), s.log)
+	return report, nil
+}
+
+// participants returns the honest validators still deciding height
+func (s *simnet) participants(height int64) []*simNode {
+	var nodes []*simNode
+	for _, n := range s.nodes {
+		if n.engine.GetCurrentHeight() == height {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// round plays one round of height among the participants, who all start it in the same round
+func (s *simnet) round(height int64, round int32, participants []*simNode) {
+	proposer := s.order[int(round)%len(s.order)]
+	block := blockHash(s.chainID, height, round)
+
+	// Propose: an honest proposer re-proposes its valid block, with the round of its polka
+	polRound := int64(-1)
+	if node := s.node(proposer); node != nil {
+		state := node.engine.GetState()
+		if node.engine.GetCurrentHeight() != height {
+			block = ""
+		} else if state.ValidBlock != "" {
+			block, polRound = state.ValidBlock, int64(state.ValidRound)
+		}
+	}
+	if block != "" {
+		proposal := s.message(abstraction.MsgTypeProposal, height, round, proposer, block)
+		proposal.Extensions = map[string]interface{}{"pol_round": polRound}
+		s.send(proposal, participants)
+	}
+
+	// Prevote and precommit: honest validators decide from their own state, byzantine ones vote the proposal
+	deciding := make(map[string]bool, len(participants))
+	for _, n := range participants {
+		deciding[n.name] = true
+	}
+	for _, msgType := range []abstraction.MsgType{abstraction.MsgTypePrevote, abstraction.MsgTypePrecommit} {
+		votes := make([]*abstraction.CanonicalMessage, 0, len(s.order))
+		for _, name := range s.order {
+			if node := s.node(name); node != nil {
+				if deciding[name] {
+					votes = append(votes, s.message(msgType, height, round, name, node.decide(msgType, round)))
+				}
+				continue
+			}
+			votes = append(votes, s.message(msgType, height, round, name, block))
+		}
+		for _, vote := range votes {
+			s.send(vote, participants)
+		}
+	}
+
+	// Timeouts: whoever neither committed nor saw +2/3 nil precommits moves on
+	for _, n := range participants {
+		if n.engine.GetCurrentHeight() == height && n.engine.GetCurrentRound() == round {
+			n.engine.AdvanceRound()
+		}
+	}
+}
+
+// decide returns the block hash an honest validator prevotes or precommits in round
+func (n *simNode) decide(msgType abstraction.MsgType, round int32) string {
+	if msgType == abstraction.MsgTypePrevote {
+		return n.engine.PrevoteFor()
+	}
+	polka, ok := n.engine.HasTwoThirdsMajority(round, abstraction.MsgTypePrevote)
+	if !ok || polka != n.engine.GetState().ProposalBlock {
+		return ""
+	}
+	return polka
+}
+
+// send delivers msg to the participants, through the first attack that matches it when its sender is
+// byzantine
+func (s *simnet) send(msg *abstraction.CanonicalMessage, participants []*simNode) {
+	variants := []*abstraction.CanonicalMessage{msg}
+	split := false
+	sender := msg.Validator
+	if msg.Type == abstraction.MsgTypeProposal {
+		sender = msg.Proposer
+	}
+	for _, attack := range s.attacks {
+		if !attack.matches(msg.Height.Int64(), sender, msg.Type) {
+			continue
+		}
+		if attack.drop {
+			return
+		}
+		mutated, err := cometbftAdapter.ByzantineEngine.Apply(msg, attack.action, attack.opts)
+		if err != nil {
+			s.outcome.Events = append(s.outcome.Events, fmt.Sprintf("%s could not apply %s to its %s at height %s: %v",
+				sender, attack.action, msg.Type, msg.Height, err))
+			break
+		}
+		variants, split = mutated, attack.split
+		break
+	}
+	s.log = append(s.log, variants...)
+
+	for i, n := range participants {
+		if split && len(variants) > 1 {
+			n.engine.ProcessMessage(variants[i%len(variants)])
+			continue
+		}
+		for _, variant := range variants {
+			// Messages for a step the engine has left are rejected, as a node ignores them
+			n.engine.ProcessMessage(variant)
+		}
+	}
+}
+
+// judge records what height ended with and reports whether the run goes on
+func (s *simnet) judge(height int64) bool {
+	committed := map[string][]string{}
+	for _, n := range s.nodes {
+		if hash, ok := n.engine.CommittedBlock(height); ok {
+			committed[hash] = append(committed[hash], n.name)
+		}
+	}
+	if len(committed) > 0 {
+		s.outcome.Committed[height] = committed
+	}
+	switch {
+	case len(committed) == 0:
+		s.outcome.LivenessStalled = true
+		s.outcome.Events = append(s.outcome.Events, fmt.Sprintf("height %d: no honest validator committed within %d rounds", height, s.e.maxRounds()))
+		return false
+	case len(committed) > 1:
+		s.outcome.SafetyViolated = true
+		blocks := make([]string, 0, len(committed))
+		for hash, nodes := range committed {
+			blocks = append(blocks, fmt.Sprintf("%s by %s", hash, strings.Join(nodes, ",")))
+		}
+		sort.Strings(blocks)
+		s.outcome.Events = append(s.outcome.Events, fmt.Sprintf("height %d: honest validators committed different blocks: %s", height, strings.Join(blocks, "; ")))
+	}
+	return true
+}
+
+func (s *simnet) node(name string) *simNode {
+	for _, n := range s.nodes {
+		if n.name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+func (s *simnet) message(msgType abstraction.MsgType, height int64, round int32, sender, block string) *abstraction.CanonicalMessage {
+	msg := &abstraction.CanonicalMessage{
+		ChainID:   s.chainID,
+		Height:    big.NewInt(height),
+		Round:     big.NewInt(int64(round)),
+		Timestamp: abstraction.Now(),
+		Type:      msgType,
+		BlockHash: block,
+		Signature: "simnet:" + sender,
+	}
+	if msgType == abstraction.MsgTypeProposal {
+		msg.Proposer = sender
+	} else {
+		msg.Validator = sender
+	}
+	return msg
+}
+
+// blockHash derives the block an honest proposer proposes at a height and round
+func blockHash(chainID string, height int64, round int32) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%d/%d", chainID, height, round)))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}